@@ -0,0 +1,171 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package audit keeps an append-only record of every request sent, as proof
+// of what a scan actually did.  Each entry carries the hash of the one
+// before it, so editing, removing or reordering entries breaks the chain.
+package audit
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// Entry records one request.
+type Entry struct {
+	Seq    int64     `json:"seq"`
+	Time   time.Time `json:"time"`
+	Method string    `json:"method"`
+	URL    string    `json:"url"`
+	// Local address the request was sent from
+	Source string `json:"source,omitempty"`
+	Code   int    `json:"code,omitempty"`
+	Error  string `json:"error,omitempty"`
+	// Hash of the previous entry, empty for the first
+	Prev string `json:"prev"`
+	// SHA-256 of this entry without its hash
+	Hash string `json:"hash,omitempty"`
+}
+
+// Log is stored as one JSON entry per line, appended as requests are sent.
+type Log struct {
+	fp   *os.File
+	seq  int64
+	prev string
+	sync.Mutex
+}
+
+// Open the log at path to append to, continuing its chain.  A log that fails
+// verification isn't appended to.
+func Open(path string) (*Log, error) {
+	l := &Log{}
+	if fp, err := os.Open(path); err == nil {
+		last, err := verify(fp)
+		fp.Close()
+		if err != nil {
+			return nil, fmt.Errorf("Audit log %s is damaged: %s", path, err.Error())
+		}
+		l.seq, l.prev = last.Seq, last.Hash
+	} else if !os.IsNotExist(err) {
+		return nil, err
+	}
+	fp, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, err
+	}
+	l.fp = fp
+	return l, nil
+}
+
+// Append e to the log, numbering and chaining it.
+func (l *Log) Record(e Entry) error {
+	if l == nil {
+		return nil
+	}
+	l.Lock()
+	defer l.Unlock()
+	e.Seq = l.seq + 1
+	e.Prev = l.prev
+	e.Hash = ""
+	e.Hash = entryHash(e)
+	line, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	if _, err := l.fp.Write(append(line, '\n')); err != nil {
+		return err
+	}
+	l.seq, l.prev = e.Seq, e.Hash
+	return nil
+}
+
+// Hash of the last entry, which vouches for the whole log, e.g. when noted
+// in a report.
+func (l *Log) Head() string {
+	if l == nil {
+		return ""
+	}
+	l.Lock()
+	defer l.Unlock()
+	return l.prev
+}
+
+func (l *Log) Close() error {
+	if l == nil {
+		return nil
+	}
+	return l.fp.Close()
+}
+
+func entryHash(e Entry) string {
+	e.Hash = ""
+	data, _ := json.Marshal(e)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// Check the chain of a log, returning the number of entries and the hash of
+// the last.  Truncation after the last entry can only be found by comparing
+// the hash with one noted elsewhere.
+func Verify(rdr io.Reader) (int64, string, error) {
+	last, err := verify(rdr)
+	return last.Seq, last.Hash, err
+}
+
+func verify(rdr io.Reader) (Entry, error) {
+	var last Entry
+	scanner := bufio.NewScanner(rdr)
+	scanner.Buffer(nil, 1024*1024)
+	for scanner.Scan() {
+		var e Entry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			return last, fmt.Errorf("Entry %d: %s", last.Seq+1, err.Error())
+		}
+		if e.Seq != last.Seq+1 || e.Prev != last.Hash {
+			return last, fmt.Errorf("Entry %d: Doesn't follow entry %d.", last.Seq+1, last.Seq)
+		}
+		if e.Hash != entryHash(e) {
+			return last, fmt.Errorf("Entry %d: Hash doesn't match.", e.Seq)
+		}
+		last = e
+	}
+	return last, scanner.Err()
+}
+
+// Run the "audit" subcommand: "verify FILE..." checks the chain of each log.
+func RunCommand(args []string, out io.Writer) error {
+	if len(args) < 2 || args[0] != "verify" {
+		return fmt.Errorf("Usage: audit verify FILE...")
+	}
+	for _, path := range args[1:] {
+		fp, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		count, head, err := Verify(fp)
+		fp.Close()
+		if err != nil {
+			return fmt.Errorf("%s: %s", path, err.Error())
+		}
+		fmt.Fprintf(out, "%s\t%d entries\t%s\n", path, count, head)
+	}
+	return nil
+}
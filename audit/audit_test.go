@@ -0,0 +1,112 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package audit
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func tempLog(t *testing.T) (string, func()) {
+	dir, err := ioutil.TempDir("", "gobuster-audit")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return filepath.Join(dir, "audit.log"), func() { os.RemoveAll(dir) }
+}
+
+func TestLog_Chain(t *testing.T) {
+	path, cleanup := tempLog(t)
+	defer cleanup()
+	l, err := Open(path)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	l.Record(Entry{Time: time.Now(), Method: "GET", URL: "http://localhost/", Source: "127.0.0.1:40000", Code: 200})
+	l.Record(Entry{Time: time.Now(), Method: "GET", URL: "http://localhost/admin", Error: "timeout"})
+	first := l.Head()
+	l.Close()
+
+	// Reopening continues the chain
+	l, err = Open(path)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	l.Record(Entry{Time: time.Now(), Method: "POST", URL: "http://localhost/login", Code: 403})
+	l.Close()
+	data, _ := ioutil.ReadFile(path)
+	count, head, err := Verify(bytes.NewReader(data))
+	if err != nil || count != 3 || head == first || head != l.Head() {
+		t.Errorf("Expected 3 verified entries ending %s, got %d, %s, %v", l.Head(), count, head, err)
+	}
+	if !strings.Contains(string(data), `"prev":"`+first+`"`) {
+		t.Errorf("Expected the third entry to follow the second, got %s", data)
+	}
+}
+
+func TestVerify_Tampered(t *testing.T) {
+	path, cleanup := tempLog(t)
+	defer cleanup()
+	l, _ := Open(path)
+	for _, code := range []int{200, 404, 500} {
+		l.Record(Entry{Method: "GET", URL: "http://localhost/", Code: code})
+	}
+	l.Close()
+	data, _ := ioutil.ReadFile(path)
+	lines := strings.SplitAfter(string(data), "\n")
+	for _, c := range []struct {
+		name, log, expected string
+	}{
+		{"edited", strings.Replace(string(data), `"code":404`, `"code":403`, 1), "Entry 2: Hash"},
+		{"removed", lines[0] + lines[2], "Entry 2: Doesn't follow"},
+		{"reordered", lines[1] + lines[0], "Entry 1: Doesn't follow"},
+		{"corrupt", lines[0] + "{\n", "Entry 2:"},
+	} {
+		if _, _, err := Verify(strings.NewReader(c.log)); err == nil || !strings.Contains(err.Error(), c.expected) {
+			t.Errorf("Expected %s log to fail with %q, got %v", c.name, c.expected, err)
+		}
+	}
+	ioutil.WriteFile(path, []byte(lines[1]), 0600)
+	if _, err := Open(path); err == nil || !strings.Contains(err.Error(), "damaged") {
+		t.Errorf("Expected not to append to a damaged log, got %v", err)
+	}
+}
+
+func TestRunCommand(t *testing.T) {
+	path, cleanup := tempLog(t)
+	defer cleanup()
+	l, _ := Open(path)
+	l.Record(Entry{Method: "GET", URL: "http://localhost/"})
+	l.Close()
+	var out bytes.Buffer
+	if err := RunCommand([]string{"verify", path}, &out); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if expected := path + "\t1 entries\t" + l.Head() + "\n"; out.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, out.String())
+	}
+	if err := RunCommand([]string{"verify"}, &out); err == nil {
+		t.Errorf("Expected usage error.")
+	}
+	var nilLog *Log
+	if err := nilLog.Record(Entry{}); err != nil || nilLog.Head() != "" {
+		t.Errorf("Expected a nil log to record nothing, got %v", err)
+	}
+}
@@ -0,0 +1,61 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"github.com/Matir/gobuster/audit"
+	"github.com/Matir/gobuster/logging"
+	"net/http"
+	"net/http/httptrace"
+	"sync/atomic"
+	"time"
+)
+
+// An auditTransport records each request it sends, including redirects and
+// authentication retries, in an audit log.
+type auditTransport struct {
+	next http.RoundTripper
+	log  *audit.Log
+}
+
+func (t *auditTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var source atomic.Value
+	trace := &httptrace.ClientTrace{
+		GotConn: func(info httptrace.GotConnInfo) {
+			source.Store(info.Conn.LocalAddr().String())
+		},
+	}
+	entry := audit.Entry{Time: time.Now(), Method: req.Method, URL: req.URL.String()}
+	resp, err := t.next.RoundTrip(req.WithContext(httptrace.WithClientTrace(req.Context(), trace)))
+	if addr, ok := source.Load().(string); ok {
+		entry.Source = addr
+	}
+	if resp != nil {
+		entry.Code = resp.StatusCode
+	}
+	if err != nil {
+		entry.Error = err.Error()
+	}
+	if err := t.log.Record(entry); err != nil {
+		logging.Logf(logging.LogError, "Unable to record %s in the audit log: %s", req.URL, err.Error())
+	}
+	return resp, err
+}
+
+func (t *auditTransport) CloseIdleConnections() {
+	if closer, ok := t.next.(interface{ CloseIdleConnections() }); ok {
+		closer.CloseIdleConnections()
+	}
+}
@@ -0,0 +1,76 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"bufio"
+	"encoding/json"
+	"github.com/Matir/gobuster/audit"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHTTPClient_AuditLog(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/old" {
+			http.Redirect(w, r, "/new", http.StatusFound)
+			return
+		}
+		http.NotFound(w, r)
+	}))
+	defer server.Close()
+	dir, err := ioutil.TempDir("", "gobuster-audit")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "audit.log")
+	log, err := audit.Open(path)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	fac, _ := NewProxyClientFactory(nil, time.Second, "")
+	fac.SetAuditLog(log)
+	u, _ := url.Parse(server.URL + "/old")
+	resp, err := fac.Get().RequestURL(u)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	resp.Body.Close()
+	log.Close()
+
+	fp, _ := os.Open(path)
+	defer fp.Close()
+	var got []string
+	scanner := bufio.NewScanner(fp)
+	for scanner.Scan() {
+		var e audit.Entry
+		json.Unmarshal(scanner.Bytes(), &e)
+		if !strings.HasPrefix(e.Source, "127.0.0.1:") {
+			t.Errorf("Expected a local source address, got %q", e.Source)
+		}
+		got = append(got, e.Method+" "+strings.TrimPrefix(e.URL, server.URL)+" "+http.StatusText(e.Code))
+	}
+	if res := strings.Join(got, ", "); res != "GET /old Found, GET /new Not Found" {
+		t.Errorf("Expected the request and its redirect, got %s", res)
+	}
+}
@@ -0,0 +1,167 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"fmt"
+	"github.com/Matir/gobuster/logging"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// HostCredentials holds basic auth credentials for each target host.
+type HostCredentials map[string]*url.Userinfo
+
+// The host of credentials for every host.
+const AnyHost = "*"
+
+// Parse "host=user:password" entries.  The host may include a port.  An
+// entry of just "user:password" is for every host without its own.
+func ParseCredentials(entries []string) (HostCredentials, error) {
+	creds := make(HostCredentials)
+	for _, entry := range entries {
+		// Text before "=" is a host only if it can't be part of user:password,
+		// so passwords may contain "="
+		if host := strings.SplitN(entry, "=", 2)[0]; host == entry || strings.Contains(host, ":") {
+			entry = AnyHost + "=" + entry
+		}
+		pieces := strings.SplitN(entry, "=", 2)
+		if len(pieces) != 2 || pieces[0] == "" {
+			return nil, fmt.Errorf("Invalid credentials, expected host=user:password: %s", entry)
+		}
+		userpass := strings.SplitN(pieces[1], ":", 2)
+		if len(userpass) != 2 {
+			return nil, fmt.Errorf("Invalid credentials for %s, expected user:password.", pieces[0])
+		}
+		creds[strings.ToLower(pieces[0])] = url.UserPassword(userpass[0], userpass[1])
+	}
+	return creds, nil
+}
+
+// Find the credentials for a URL, if any.  Credentials for "*" are used for
+// hosts without their own.
+func (c HostCredentials) For(u *url.URL) *url.Userinfo {
+	if creds, ok := c[strings.ToLower(u.Host)]; ok {
+		return creds
+	}
+	if creds, ok := c[strings.ToLower(u.Hostname())]; ok {
+		return creds
+	}
+	return c[AnyHost]
+}
+
+// Add the credentials for the request's host, if any.
+func (c HostCredentials) Apply(req *http.Request) {
+	if creds := c.For(req.URL); creds != nil {
+		pass, _ := creds.Password()
+		req.SetBasicAuth(creds.Username(), pass)
+	}
+}
+
+// Parse "name=value" cookies, several to an entry if separated by ";".
+func ParseCookies(entries []string) ([]*http.Cookie, error) {
+	var cookies []*http.Cookie
+	for _, entry := range entries {
+		for _, part := range strings.Split(entry, ";") {
+			if part = strings.TrimSpace(part); part == "" {
+				continue
+			}
+			pieces := strings.SplitN(part, "=", 2)
+			name := strings.TrimSpace(pieces[0])
+			if len(pieces) != 2 || name == "" {
+				return nil, fmt.Errorf("Invalid cookie, expected name=value: %s", part)
+			}
+			cookies = append(cookies, &http.Cookie{Name: name, Value: strings.TrimSpace(pieces[1])})
+		}
+	}
+	return cookies, nil
+}
+
+// Add static cookies to the request, except those the jar has a cookie of
+// the same name for, such as a session the server has replaced.
+func addCookies(req *http.Request, cookies []*http.Cookie, jars *HostJars) {
+	replaced := make(map[string]bool)
+	if jars != nil {
+		for _, c := range jars.Cookies(req.URL) {
+			replaced[c.Name] = true
+		}
+	}
+	for _, c := range cookies {
+		if !replaced[c.Name] {
+			req.AddCookie(c)
+		}
+	}
+}
+
+// HostJars keeps a separate cookie jar for each host, so that sessions for
+// one target are never sent to another, even if a cookie's domain would
+// allow it.
+type HostJars struct {
+	sync.Mutex
+	jars map[string]*cookiejar.Jar
+}
+
+func NewHostJars() *HostJars {
+	return &HostJars{jars: make(map[string]*cookiejar.Jar)}
+}
+
+func (j *HostJars) jar(u *url.URL) *cookiejar.Jar {
+	j.Lock()
+	defer j.Unlock()
+	key := strings.ToLower(u.Host)
+	jar, ok := j.jars[key]
+	if !ok {
+		// Options are only needed for a public suffix list, which doesn't
+		// matter with a jar per host.
+		jar, _ = cookiejar.New(nil)
+		j.jars[key] = jar
+	}
+	return jar
+}
+
+func (j *HostJars) SetCookies(u *url.URL, cookies []*http.Cookie) {
+	j.jar(u).SetCookies(u, cookies)
+}
+
+func (j *HostJars) Cookies(u *url.URL) []*http.Cookie {
+	return j.jar(u).Cookies(u)
+}
+
+// Whether there is a session to send with a request for u.
+func (j *HostJars) HasSession(u *url.URL) bool {
+	if j == nil {
+		return false
+	}
+	j.Lock()
+	jar, ok := j.jars[strings.ToLower(u.Host)]
+	j.Unlock()
+	return ok && len(jar.Cookies(u)) > 0
+}
+
+// Forget the session for one host.
+func (j *HostJars) Reset(host string) {
+	if j == nil {
+		return
+	}
+	j.Lock()
+	defer j.Unlock()
+	if _, ok := j.jars[strings.ToLower(host)]; ok {
+		logging.Logf(logging.LogDebug, "Clearing cookies for %s.", host)
+		delete(j.jars, strings.ToLower(host))
+	}
+}
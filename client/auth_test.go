@@ -0,0 +1,189 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestParseCredentials(t *testing.T) {
+	creds, err := ParseCredentials([]string{"Example.com=admin:pa:ss", "localhost=user:"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	u := creds.For(&url.URL{Host: "example.com:443"})
+	if u == nil || u.Username() != "admin" {
+		t.Fatalf("Expected admin for example.com, got %v", u)
+	}
+	if pass, _ := u.Password(); pass != "pa:ss" {
+		t.Errorf("Expected password pa:ss, got %s", pass)
+	}
+	if creds.For(&url.URL{Host: "localhost:8080"}) == nil {
+		t.Error("Expected credentials for localhost:8080.")
+	}
+	if creds.For(&url.URL{Host: "other:8080"}) != nil {
+		t.Error("Expected no credentials for other:8080.")
+	}
+	for _, bad := range []string{"example.com", "=user:pass", "example.com=user"} {
+		if _, err := ParseCredentials([]string{bad}); err == nil {
+			t.Errorf("Expected error for %s", bad)
+		}
+	}
+}
+
+func TestParseCredentials_AnyHost(t *testing.T) {
+	creds, err := ParseCredentials([]string{"admin:secret", "example.com=user:pass"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if u := creds.For(&url.URL{Host: "example.com"}); u == nil || u.Username() != "user" {
+		t.Errorf("Expected user for example.com, got %v", u)
+	}
+	if u := creds.For(&url.URL{Host: "localhost:8080"}); u == nil || u.Username() != "admin" {
+		t.Errorf("Expected admin for other hosts, got %v", u)
+	}
+}
+
+func TestParseCredentials_EqualsInPassword(t *testing.T) {
+	creds, err := ParseCredentials([]string{"admin:pa=ss,word"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	u := creds.For(&url.URL{Host: "example.com"})
+	if u == nil || u.Username() != "admin" {
+		t.Fatalf("Expected admin for any host, got %v", u)
+	}
+	if pass, _ := u.Password(); pass != "pa=ss,word" {
+		t.Errorf("Expected password pa=ss,word, got %s", pass)
+	}
+}
+
+func TestParseCookies(t *testing.T) {
+	cookies, err := ParseCookies([]string{"session=abc; theme=dark", " lang = en ", "token=a=b"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	var got []string
+	for _, c := range cookies {
+		got = append(got, c.Name+"="+c.Value)
+	}
+	if strings.Join(got, " ") != "session=abc theme=dark lang=en token=a=b" {
+		t.Errorf("Expected 4 cookies, got %v", got)
+	}
+	for _, bad := range []string{"session", "=abc", "a=b; c"} {
+		if _, err := ParseCookies([]string{bad}); err == nil {
+			t.Errorf("Expected error for %s", bad)
+		}
+	}
+}
+
+func TestMakeRequest_Cookies(t *testing.T) {
+	cookies, _ := ParseCookies([]string{"session=static; theme=dark"})
+	jars := NewHostJars()
+	c := &httpClient{cookies: cookies, jars: jars}
+	u := &url.URL{Scheme: "http", Host: "localhost", Path: "/"}
+	if got := c.makeRequest(u).Header.Get("Cookie"); got != "session=static; theme=dark" {
+		t.Errorf("Expected static cookies, got %s", got)
+	}
+	jars.SetCookies(u, []*http.Cookie{{Name: "session", Value: "renewed"}})
+	if got := c.makeRequest(u).Header.Get("Cookie"); got != "theme=dark" {
+		t.Errorf("Expected the jar's session to replace the static one, got %s", got)
+	}
+}
+
+func TestHostJars(t *testing.T) {
+	jars := NewHostJars()
+	a := &url.URL{Scheme: "http", Host: "a.example.com", Path: "/"}
+	b := &url.URL{Scheme: "http", Host: "b.example.com", Path: "/"}
+	jars.SetCookies(a, []*http.Cookie{{Name: "session", Value: "secret", Domain: "example.com"}})
+	if len(jars.Cookies(a)) != 1 {
+		t.Error("Expected cookie for a.example.com.")
+	}
+	if len(jars.Cookies(b)) != 0 {
+		t.Error("Expected cookie not to leak to b.example.com.")
+	}
+	jars.Reset("A.example.com")
+	if len(jars.Cookies(a)) != 0 {
+		t.Error("Expected cookies to be cleared.")
+	}
+	if jars.HasSession(a) {
+		t.Error("Expected no session after reset.")
+	}
+	jars.SetCookies(b, []*http.Cookie{{Name: "session", Value: "secret"}})
+	if !jars.HasSession(b) {
+		t.Error("Expected a session for b.example.com.")
+	}
+	var nilJars *HostJars
+	nilJars.Reset("a.example.com")
+	if nilJars.HasSession(a) {
+		t.Error("Expected no session without jars.")
+	}
+}
+
+func TestHTTPClient_AuthAndCookies(t *testing.T) {
+	var sawAuth bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, pass, ok := r.BasicAuth()
+		sawAuth = ok && user == "admin" && pass == "secret"
+		if r.URL.Path == "/expire" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		http.SetCookie(w, &http.Cookie{Name: "session", Value: "1"})
+	}))
+	defer server.Close()
+	u, _ := url.Parse(server.URL)
+	creds, _ := ParseCredentials([]string{u.Hostname() + "=admin:secret"})
+	factory, _ := NewProxyClientFactory(nil, 0, "test")
+	factory.SetCredentials(creds)
+	jars := NewHostJars()
+	factory.SetCookieJars(jars)
+	cl := factory.Get()
+	resp, err := cl.RequestURL(u)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	resp.Body.Close()
+	if !sawAuth {
+		t.Error("Expected basic auth to be sent.")
+	}
+	if len(jars.Cookies(u)) != 1 {
+		t.Error("Expected session cookie to be kept.")
+	}
+	resp, err = cl.RequestURL(u.ResolveReference(&url.URL{Path: "/expire"}))
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	resp.Body.Close()
+	if len(jars.Cookies(u)) != 0 {
+		t.Error("Expected session to be cleared after 401.")
+	}
+
+	// A 401 for a request that didn't carry the session leaves it alone
+	app := u.ResolveReference(&url.URL{Path: "/app/"})
+	jars.SetCookies(app, []*http.Cookie{{Name: "session", Value: "2", Path: "/app"}})
+	resp, err = cl.RequestURL(u.ResolveReference(&url.URL{Path: "/expire"}))
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	resp.Body.Close()
+	if len(jars.Cookies(app)) != 1 {
+		t.Error("Expected session to be kept after a 401 without it.")
+	}
+}
@@ -0,0 +1,215 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"github.com/Matir/gobuster/logging"
+	"golang.org/x/net/dns/dnsmessage"
+	"io"
+	"math/rand"
+	"net"
+	"strings"
+	"time"
+)
+
+// DNSRecord is a resource record from a zone transfer.
+type DNSRecord struct {
+	// Owner name, without the trailing dot
+	Name string
+	// Type, such as A or MX
+	Type string
+	// Data in zone file presentation, e.g. "10 mail.example.com" for MX
+	Value string
+}
+
+// Most records kept from one zone transfer, and the longest one may take
+// however quickly its messages arrive.
+var (
+	maxZoneRecords  = 50000
+	maxZoneTransfer = 2 * time.Minute
+)
+
+// A function dialing connections, as for the DialContext of a net.Dialer.
+type DialFunc func(context.Context, string, string) (net.Conn, error)
+
+// Build a function dialing like the scan's DNS lookups: names are resolved
+// through server, if set, and connections are made from local, if set.
+func NewDNSDialer(server string, local *LocalAddrs, timeout time.Duration) DialFunc {
+	dialer := &net.Dialer{Timeout: timeout}
+	if server != "" {
+		dialer.Resolver = newResolver(server, timeout)
+	}
+	return local.DialContext(dialer)
+}
+
+// Request a transfer of zone from a nameserver (host or host:port), dialed
+// with dial or directly if nil.  Most nameservers refuse, so an error is the
+// usual outcome.
+func ZoneTransfer(dial DialFunc, server, zone string, timeout time.Duration) ([]DNSRecord, error) {
+	if _, _, err := net.SplitHostPort(server); err != nil {
+		server = net.JoinHostPort(server, "53")
+	}
+	if dial == nil {
+		dial = (&net.Dialer{Timeout: timeout}).DialContext
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	conn, err := dial(ctx, "tcp", server)
+	cancel()
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	end := time.Now().Add(maxZoneTransfer)
+	setDeadline := func() {
+		deadline := time.Now().Add(timeout)
+		if deadline.After(end) {
+			deadline = end
+		}
+		conn.SetDeadline(deadline)
+	}
+	setDeadline()
+	id := uint16(rand.Intn(1 << 16))
+	query, err := axfrQuery(id, zone)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := conn.Write(query); err != nil {
+		return nil, err
+	}
+	var records []DNSRecord
+	soas := 0
+	// The zone is sent between two copies of its SOA record, over as many
+	// messages as it takes
+	for soas < 2 {
+		var length [2]byte
+		if _, err := io.ReadFull(conn, length[:]); err != nil {
+			return nil, err
+		}
+		msg := make([]byte, binary.BigEndian.Uint16(length[:]))
+		if _, err := io.ReadFull(conn, msg); err != nil {
+			return nil, err
+		}
+		// Reading a large zone may take longer than one query
+		setDeadline()
+		answers, err := parseDNSMessage(msg, id)
+		if err != nil {
+			return nil, err
+		}
+		if len(answers) == 0 {
+			return nil, fmt.Errorf("Zone transfer of %s ended early.", zone)
+		}
+		for _, rr := range answers {
+			if rr.Type == "SOA" {
+				soas++
+				if soas == 2 {
+					break
+				}
+			}
+			if len(records) == maxZoneRecords {
+				logging.Logf(logging.LogWarning, "Zone transfer of %s from %s stopped after %d records.", zone, server, maxZoneRecords)
+				return records, nil
+			}
+			records = append(records, rr)
+		}
+	}
+	return records, nil
+}
+
+// A DNS query message for an AXFR of zone, prefixed with its length for TCP.
+func axfrQuery(id uint16, zone string) ([]byte, error) {
+	name, err := dnsmessage.NewName(strings.TrimSuffix(zone, ".") + ".")
+	if err != nil {
+		return nil, fmt.Errorf("Invalid zone name %s.", zone)
+	}
+	b := dnsmessage.NewBuilder(make([]byte, 2, 514), dnsmessage.Header{ID: id})
+	b.EnableCompression()
+	if err := b.StartQuestions(); err != nil {
+		return nil, err
+	}
+	if err := b.Question(dnsmessage.Question{Name: name, Type: dnsmessage.TypeAXFR, Class: dnsmessage.ClassINET}); err != nil {
+		return nil, fmt.Errorf("Invalid zone name %s.", zone)
+	}
+	msg, err := b.Finish()
+	if err != nil {
+		return nil, err
+	}
+	binary.BigEndian.PutUint16(msg, uint16(len(msg)-2))
+	return msg, nil
+}
+
+// The answers in a response to the query with id.
+func parseDNSMessage(msg []byte, id uint16) ([]DNSRecord, error) {
+	var p dnsmessage.Parser
+	header, err := p.Start(msg)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid DNS message: %s", err.Error())
+	}
+	if header.ID != id {
+		return nil, fmt.Errorf("DNS message is for another query.")
+	}
+	if header.RCode != dnsmessage.RCodeSuccess {
+		return nil, fmt.Errorf("Zone transfer refused (rcode %d).", header.RCode)
+	}
+	if err := p.SkipAllQuestions(); err != nil {
+		return nil, fmt.Errorf("Invalid DNS message: %s", err.Error())
+	}
+	answers, err := p.AllAnswers()
+	if err != nil {
+		return nil, fmt.Errorf("Invalid DNS record: %s", err.Error())
+	}
+	records := make([]DNSRecord, 0, len(answers))
+	for _, rr := range answers {
+		rtype, value := dnsRecordValue(rr)
+		records = append(records, DNSRecord{Name: dnsName(rr.Header.Name), Type: rtype, Value: value})
+	}
+	return records, nil
+}
+
+// The type of a record and its data in presentation format, for the common
+// types.
+func dnsRecordValue(rr dnsmessage.Resource) (string, string) {
+	rtype := strings.TrimPrefix(rr.Header.Type.String(), "Type")
+	switch body := rr.Body.(type) {
+	case *dnsmessage.AResource:
+		return rtype, net.IP(body.A[:]).String()
+	case *dnsmessage.AAAAResource:
+		return rtype, net.IP(body.AAAA[:]).String()
+	case *dnsmessage.NSResource:
+		return rtype, dnsName(body.NS)
+	case *dnsmessage.CNAMEResource:
+		return rtype, dnsName(body.CNAME)
+	case *dnsmessage.PTRResource:
+		return rtype, dnsName(body.PTR)
+	case *dnsmessage.MXResource:
+		return rtype, fmt.Sprintf("%d %s", body.Pref, dnsName(body.MX))
+	case *dnsmessage.SRVResource:
+		return rtype, fmt.Sprintf("%d %d %d %s", body.Priority, body.Weight, body.Port, dnsName(body.Target))
+	case *dnsmessage.TXTResource:
+		return rtype, strings.Join(body.TXT, "")
+	case *dnsmessage.SOAResource:
+		return rtype, dnsName(body.NS)
+	case *dnsmessage.UnknownResource:
+		return fmt.Sprintf("TYPE%d", uint16(rr.Header.Type)), fmt.Sprintf("%x", body.Data)
+	}
+	return rtype, ""
+}
+
+// A name without its trailing dot.
+func dnsName(n dnsmessage.Name) string {
+	return strings.TrimSuffix(n.String(), ".")
+}
@@ -0,0 +1,145 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"context"
+	"encoding/binary"
+	"golang.org/x/net/dns/dnsmessage"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+// A resource record for example.com, the first name in every message, with
+// its owner compressed.
+func fakeRecord(label string, rtype dnsmessage.Type, data []byte) []byte {
+	var rr []byte
+	if label != "" {
+		rr = append(rr, byte(len(label)))
+		rr = append(rr, label...)
+	}
+	rr = append(rr, 0xc0, 12, byte(rtype>>8), byte(rtype), 0, 1, 0, 0, 0, 60, byte(len(data)>>8), byte(len(data)))
+	return append(rr, data...)
+}
+
+// Serve a zone transfer of example.com over two messages, or refuse it.
+func fakeAXFRServer(t *testing.T, refuse bool) (string, func()) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Skipf("Unable to listen: %v", err)
+	}
+	go func() {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		var length [2]byte
+		io.ReadFull(conn, length[:])
+		query := make([]byte, binary.BigEndian.Uint16(length[:]))
+		io.ReadFull(conn, query)
+		soa := fakeRecord("", dnsmessage.TypeSOA, append([]byte("\x03ns1\xc0\x0c\x05admin\xc0\x0c"), make([]byte, 20)...))
+		messages := [][][]byte{
+			{soa, fakeRecord("", dnsmessage.TypeA, []byte{192, 0, 2, 1}), fakeRecord("", dnsmessage.TypeMX, []byte("\x00\x0a\x04mail\xc0\x0c"))},
+			{fakeRecord("intranet", dnsmessage.TypeA, []byte{10, 0, 0, 5}), fakeRecord("", dnsmessage.TypeTXT, []byte("\x0av=spf1 -al\x01l")), soa},
+		}
+		if refuse {
+			messages = [][][]byte{nil}
+		}
+		for _, answers := range messages {
+			msg := append([]byte{}, query...)
+			msg[2], msg[3] = 0x84, 0x00
+			if refuse {
+				msg[3] = 5
+			}
+			binary.BigEndian.PutUint16(msg[6:], uint16(len(answers)))
+			for _, rr := range answers {
+				msg = append(msg, rr...)
+			}
+			binary.BigEndian.PutUint16(length[:], uint16(len(msg)))
+			conn.Write(append(length[:], msg...))
+		}
+	}()
+	return l.Addr().String(), func() { l.Close() }
+}
+
+func TestZoneTransfer(t *testing.T) {
+	server, stop := fakeAXFRServer(t, false)
+	defer stop()
+	records, err := ZoneTransfer(nil, server, "example.com", time.Second)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	var got []string
+	for _, rr := range records {
+		got = append(got, rr.Name+" "+rr.Type+" "+rr.Value)
+	}
+	expected := "example.com SOA ns1.example.com|example.com A 192.0.2.1|example.com MX 10 mail.example.com|intranet.example.com A 10.0.0.5|example.com TXT v=spf1 -all"
+	if strings.Join(got, "|") != expected {
+		t.Errorf("Expected %s, got %s", expected, strings.Join(got, "|"))
+	}
+}
+
+func TestZoneTransfer_Refused(t *testing.T) {
+	server, stop := fakeAXFRServer(t, true)
+	defer stop()
+	if _, err := ZoneTransfer(nil, server, "example.com", time.Second); err == nil || !strings.Contains(err.Error(), "refused") {
+		t.Errorf("Expected refusal, got %v", err)
+	}
+}
+
+func TestZoneTransfer_MaxRecords(t *testing.T) {
+	defer func(max int) { maxZoneRecords = max }(maxZoneRecords)
+	maxZoneRecords = 2
+	server, stop := fakeAXFRServer(t, false)
+	defer stop()
+	records, err := ZoneTransfer(nil, server, "example.com", time.Second)
+	if err != nil || len(records) != 2 {
+		t.Errorf("Expected the first 2 records, got %v, %v", records, err)
+	}
+}
+
+func TestZoneTransfer_Dial(t *testing.T) {
+	server, stop := fakeAXFRServer(t, false)
+	defer stop()
+	var dialed string
+	dial := func(ctx context.Context, network, addr string) (net.Conn, error) {
+		dialed = addr
+		return (&net.Dialer{}).DialContext(ctx, network, server)
+	}
+	if _, err := ZoneTransfer(dial, "ns1.example.com", "example.com", time.Second); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if dialed != "ns1.example.com:53" {
+		t.Errorf("Expected the nameserver dialed through dial, got %s", dialed)
+	}
+}
+
+func TestAXFRQuery(t *testing.T) {
+	query, err := axfrQuery(0x1234, "example.com.")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	expected := "\x00\x1d\x12\x34\x00\x00\x00\x01\x00\x00\x00\x00\x00\x00\x07example\x03com\x00\x00\xfc\x00\x01"
+	if string(query) != expected {
+		t.Errorf("Expected %q, got %q", expected, query)
+	}
+	if _, err := axfrQuery(1, "bad..example"); err == nil {
+		t.Error("Expected error for an empty label.")
+	}
+}
@@ -0,0 +1,114 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"sync/atomic"
+)
+
+// LocalAddrs are the local addresses to make connections from, for
+// multi-homed hosts or scans that must come from a known address.  Each
+// connection uses the next address of the right family.  A nil LocalAddrs
+// leaves the choice to the system.
+type LocalAddrs struct {
+	v4   []net.IP
+	v6   []net.IP
+	next uint32
+}
+
+// Parse local addresses, each an IP or the name of an interface whose
+// addresses are all used.
+func ParseLocalAddrs(specs []string) (*LocalAddrs, error) {
+	l := &LocalAddrs{}
+	for _, spec := range specs {
+		if ip := net.ParseIP(spec); ip != nil {
+			l.add(ip)
+			continue
+		}
+		iface, err := net.InterfaceByName(spec)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid local address %s, not an IP or interface.", spec)
+		}
+		addrs, err := iface.Addrs()
+		if err != nil {
+			return nil, err
+		}
+		found := false
+		for _, addr := range addrs {
+			if ipnet, ok := addr.(*net.IPNet); ok && !ipnet.IP.IsLinkLocalUnicast() {
+				l.add(ipnet.IP)
+				found = true
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("Interface %s has no usable addresses.", spec)
+		}
+	}
+	return l, nil
+}
+
+func (l *LocalAddrs) add(ip net.IP) {
+	if ip.To4() != nil {
+		l.v4 = append(l.v4, ip)
+	} else {
+		l.v6 = append(l.v6, ip)
+	}
+}
+
+// The next address to connect to host from.  For a hostname, any address
+// will do, since dialing only tries remote addresses of the same family.
+func (l *LocalAddrs) pick(host string) net.IP {
+	pool := append(append([]net.IP{}, l.v4...), l.v6...)
+	if ip := net.ParseIP(host); ip != nil {
+		if ip.To4() != nil {
+			pool = l.v4
+		} else {
+			pool = l.v6
+		}
+	}
+	if len(pool) == 0 {
+		return nil
+	}
+	n := atomic.AddUint32(&l.next, 1)
+	return pool[int(n-1)%len(pool)]
+}
+
+// Wrap dialer to bind each connection to the next local address.
+func (l *LocalAddrs) DialContext(dialer *net.Dialer) func(context.Context, string, string) (net.Conn, error) {
+	if l == nil {
+		return dialer.DialContext
+	}
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		host, _, err := net.SplitHostPort(addr)
+		if err != nil {
+			return nil, err
+		}
+		ip := l.pick(host)
+		if ip == nil {
+			return nil, fmt.Errorf("No local address to connect to %s from.", host)
+		}
+		bound := *dialer
+		if strings.HasPrefix(network, "udp") {
+			bound.LocalAddr = &net.UDPAddr{IP: ip}
+		} else {
+			bound.LocalAddr = &net.TCPAddr{IP: ip}
+		}
+		return bound.DialContext(ctx, network, addr)
+	}
+}
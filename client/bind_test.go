@@ -0,0 +1,98 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestParseLocalAddrs(t *testing.T) {
+	l, err := ParseLocalAddrs([]string{"10.0.0.1", "::1", "10.0.0.2"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(l.v4) != 2 || len(l.v6) != 1 {
+		t.Errorf("Expected 2 IPv4 and 1 IPv6 addresses, got %v and %v", l.v4, l.v6)
+	}
+	if _, err := ParseLocalAddrs([]string{"no-such-interface0"}); err == nil {
+		t.Errorf("Expected error for unknown interface.")
+	}
+	ifaces, _ := net.Interfaces()
+	for _, iface := range ifaces {
+		if iface.Flags&net.FlagLoopback == 0 {
+			continue
+		}
+		l, err := ParseLocalAddrs([]string{iface.Name})
+		if err != nil {
+			t.Errorf("Expected addresses for %s, got %v", iface.Name, err)
+		} else if len(l.v4)+len(l.v6) == 0 {
+			t.Errorf("Expected addresses for %s.", iface.Name)
+		}
+	}
+}
+
+func TestLocalAddrs_Pick(t *testing.T) {
+	l, _ := ParseLocalAddrs([]string{"10.0.0.1", "10.0.0.2", "::1"})
+	var got []string
+	for i := 0; i < 3; i++ {
+		got = append(got, l.pick("192.0.2.1").String())
+	}
+	if got[0] == got[1] || got[0] != got[2] {
+		t.Errorf("Expected IPv4 addresses in turn, got %v", got)
+	}
+	if ip := l.pick("2001:db8::1"); !ip.Equal(net.ParseIP("::1")) {
+		t.Errorf("Expected ::1 for an IPv6 host, got %s", ip)
+	}
+	v4, _ := ParseLocalAddrs([]string{"10.0.0.1"})
+	if ip := v4.pick("2001:db8::1"); ip != nil {
+		t.Errorf("Expected no address for an IPv6 host, got %s", ip)
+	}
+}
+
+func TestLocalAddrs_DialContext(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Skipf("Unable to listen: %v", err)
+	}
+	defer ln.Close()
+	l, _ := ParseLocalAddrs([]string{"127.0.0.1"})
+	dial := l.DialContext(&net.Dialer{Timeout: time.Second})
+	conn, err := dial(context.Background(), "tcp", ln.Addr().String())
+	if err != nil {
+		t.Fatalf("Expected connection, got %v", err)
+	}
+	defer conn.Close()
+	if ip := conn.LocalAddr().(*net.TCPAddr).IP; !ip.Equal(net.ParseIP("127.0.0.1")) {
+		t.Errorf("Expected connection from 127.0.0.1, got %s", ip)
+	}
+	if _, err := dial(context.Background(), "tcp", "[::1]:80"); err == nil {
+		t.Errorf("Expected error with no IPv6 local address.")
+	}
+	udp, err := dial(context.Background(), "udp", ln.Addr().String())
+	if err != nil {
+		t.Fatalf("Expected UDP socket, got %v", err)
+	}
+	defer udp.Close()
+	if ip := udp.LocalAddr().(*net.UDPAddr).IP; !ip.Equal(net.ParseIP("127.0.0.1")) {
+		t.Errorf("Expected UDP from 127.0.0.1, got %s", ip)
+	}
+	var none *LocalAddrs
+	if none.DialContext(&net.Dialer{}) == nil {
+		t.Errorf("Expected nil LocalAddrs to dial normally.")
+	}
+}
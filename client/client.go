@@ -16,29 +16,176 @@
 package client
 
 import (
+	"io"
+	"io/ioutil"
 	"net/http"
 	"net/url"
+	"strings"
 )
 
+// Most of an unused response body to read so its connection can be reused.
+const maxDiscard = 64 * 1024
+
 type Client interface {
 	RequestURL(*url.URL) (*http.Response, error)
 	SetCheckRedirect(func(*http.Request, []*http.Request) error)
 }
 
+// RangeClient is a Client that can request part of a resource.
+type RangeClient interface {
+	Client
+	// Request the given byte range, e.g. "bytes=-1024" for the last 1k.
+	RequestRange(*url.URL, string) (*http.Response, error)
+}
+
+// MethodClient is a Client that can send requests other than a plain GET.
+type MethodClient interface {
+	Client
+	// Request the URL with method, adding header and sending body if not
+	// empty.
+	RequestMethod(method string, u *url.URL, header http.Header, body string) (*http.Response, error)
+}
+
+// HostClient is a Client that can send a request to one host under the name
+// of another.
+type HostClient interface {
+	Client
+	// Request the URL with host in the Host header.
+	RequestHost(*url.URL, string) (*http.Response, error)
+}
+
 type httpClient struct {
 	http.Client
 	UserAgent string
+	// Limits bandwidth, if set
+	limiter *BandwidthLimiter
+	// Spaces out requests to each host, if set
+	rate *RateLimiter
+	// Works out each host's rate limit, if set
+	throttles *ThrottleProfiler
+	// Most bytes to decompress from a body, 0 for no limit
+	decompressBudget int64
+	// Slowest acceptable body in bytes per second, 0 for no limit
+	minThroughput int64
+	// Basic auth for each host
+	credentials HostCredentials
+	// Digest challenges from each host, if answered
+	digests *DigestSessions
+	// Cookies for each host, if kept
+	jars *HostJars
+	// Host header for every request, if not the URL's host
+	host string
+	// Headers for every request
+	headers http.Header
+	// Cookies for every request
+	cookies []*http.Cookie
 }
 
 func (c *httpClient) RequestURL(u *url.URL) (*http.Response, error) {
+	return c.do(c.makeRequest(u))
+}
+
+func (c *httpClient) RequestRange(u *url.URL, byteRange string) (*http.Response, error) {
+	req := c.makeRequest(u)
+	req.Header.Set("Range", byteRange)
+	return c.do(req)
+}
+
+func (c *httpClient) RequestHost(u *url.URL, host string) (*http.Response, error) {
 	req := c.makeRequest(u)
+	req.Host = host
+	return c.do(req)
+}
+
+func (c *httpClient) RequestMethod(method string, u *url.URL, header http.Header, body string) (*http.Response, error) {
+	req := c.makeRequest(u)
+	req.Method = method
+	for name, values := range header {
+		req.Header[name] = values
+	}
+	if body != "" {
+		req.ContentLength = int64(len(body))
+		req.GetBody = func() (io.ReadCloser, error) {
+			return ioutil.NopCloser(strings.NewReader(body)), nil
+		}
+		req.Body, _ = req.GetBody()
+	}
+	return c.do(req)
+}
+
+func (c *httpClient) do(req *http.Request) (*http.Response, error) {
+	creds := c.credentials.For(req.URL)
+	c.digests.Apply(req, creds)
+	session := c.jars.HasSession(req.URL)
+	resp, err := c.send(req)
+	if resp != nil && resp.StatusCode == http.StatusUnauthorized && creds != nil {
+		if session {
+			// The session has expired or was rejected, start over on this
+			// host
+			c.jars.Reset(req.URL.Host)
+		}
+		if req.Body == nil || req.GetBody != nil {
+			if c.digests.Challenged(resp) {
+				// Answer the challenge, or a fresh nonce, once
+				retry := replay(req)
+				c.digests.Apply(retry, creds)
+				discard(resp)
+				resp, err = c.send(retry)
+			} else if scheme := ntlmScheme(resp); scheme != "" {
+				discard(resp)
+				resp, err = c.ntlm(req, creds, scheme)
+			}
+		}
+	}
+	c.throttles.Record(resp)
+	var throughput *throughputBody
+	if resp != nil && c.minThroughput > 0 {
+		throughput = newThroughputBody(resp.Body, c.minThroughput)
+		resp.Body = throughput
+	}
+	if resp != nil && c.limiter != nil {
+		// Waiting on our own limit doesn't make the server slow
+		resp.Body = &throttledBody{ReadCloser: resp.Body, limiter: c.limiter, throughput: throughput}
+	}
+	if resp != nil {
+		DecodeBody(resp, c.decompressBudget)
+	}
+	return resp, err
+}
+
+// Send req once it is within the rate limit.
+func (c *httpClient) send(req *http.Request) (*http.Response, error) {
+	c.rate.Wait(req.URL.Host)
 	return c.Do(req)
 }
 
+// A copy of req to send again, which must have a body that can be read again.
+func replay(req *http.Request) *http.Request {
+	retry := req.Clone(req.Context())
+	if req.GetBody != nil {
+		retry.Body, _ = req.GetBody()
+	}
+	return retry
+}
+
+// Read through and close a response that won't be used, so its connection
+// can be reused.
+func discard(resp *http.Response) {
+	io.Copy(ioutil.Discard, io.LimitReader(resp.Body, maxDiscard))
+	resp.Body.Close()
+}
+
 func (c *httpClient) makeRequest(u *url.URL) *http.Request {
-	// TODO: support other methods
 	req, _ := http.NewRequest("GET", u.String(), nil)
 	req.Header.Set("User-Agent", c.UserAgent)
+	// Decompress ourselves to keep track of compression ratios
+	req.Header.Set("Accept-Encoding", "gzip, deflate")
+	if c.host != "" {
+		req.Host = c.host
+	}
+	applyHeaders(req, c.headers)
+	addCookies(req, c.cookies, c.jars)
+	c.credentials.Apply(req)
 	return req
 }
 
@@ -15,7 +15,10 @@
 package client
 
 import (
+	"fmt"
+	"io/ioutil"
 	"net/http"
+	"net/http/httptest"
 	"net/url"
 	"testing"
 )
@@ -27,9 +30,47 @@ func TestMakeRequest_Basic(t *testing.T) {
 	if req.URL.String() != u.String() {
 		t.Errorf("URL does not match requested: %s != %s", req.URL.String(), u.String())
 	}
+	if req.Header.Get("Accept-Encoding") != "gzip, deflate" {
+		t.Errorf("Unexpected Accept-Encoding: %s", req.Header.Get("Accept-Encoding"))
+	}
 }
 
 func TestSetCheckRedirect(_ *testing.T) {
 	c := &httpClient{}
 	c.SetCheckRedirect(func(_ *http.Request, _ []*http.Request) error { return nil })
 }
+
+func TestRequestMethod(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		fmt.Fprintf(w, "%s %s %s", r.Method, r.Header.Get("X-Test"), body)
+	}))
+	defer server.Close()
+	u, _ := url.Parse(server.URL + "/")
+	c := &httpClient{}
+	resp, err := c.RequestMethod("POST", u, http.Header{"X-Test": {"admin"}}, "user=admin")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	defer resp.Body.Close()
+	if body, _ := ioutil.ReadAll(resp.Body); string(body) != "POST admin user=admin" {
+		t.Errorf("Expected POST admin user=admin, got %s", body)
+	}
+}
+
+func TestRequestHost(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.Host))
+	}))
+	defer server.Close()
+	u, _ := url.Parse(server.URL + "/")
+	c := &httpClient{}
+	resp, err := c.RequestHost(u, "admin.example.com")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	defer resp.Body.Close()
+	if body, _ := ioutil.ReadAll(resp.Body); string(body) != "admin.example.com" {
+		t.Errorf("Expected Host admin.example.com, got %s", body)
+	}
+}
@@ -0,0 +1,130 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"bufio"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// Returned when a body decompresses to more than the allowed budget.
+var ErrDecompressionBudget = errors.New("Decompression budget exceeded.")
+
+// CompressionStats reports the size of a body on the wire and after
+// decompression.
+type CompressionStats interface {
+	CompressedBytes() int64
+	DecompressedBytes() int64
+}
+
+// DecodedBody decompresses a response body, counting bytes on either side
+// and refusing to produce more than its budget.
+type DecodedBody struct {
+	raw      io.ReadCloser
+	counter  *countingReader
+	encoding string
+	decoder  io.Reader
+	// Most bytes to decompress, 0 for no limit
+	budget  int64
+	decoded int64
+	err     error
+}
+
+type countingReader struct {
+	io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.Reader.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// Replace the body of a gzip or deflate encoded response with one that
+// decompresses it.  Other responses are left alone.
+func DecodeBody(resp *http.Response, budget int64) {
+	encoding := strings.ToLower(strings.TrimSpace(resp.Header.Get("Content-Encoding")))
+	if encoding != "gzip" && encoding != "deflate" {
+		return
+	}
+	resp.Body = &DecodedBody{
+		raw:      resp.Body,
+		counter:  &countingReader{Reader: resp.Body},
+		encoding: encoding,
+		budget:   budget,
+	}
+	resp.Header.Del("Content-Encoding")
+	resp.Header.Del("Content-Length")
+	resp.ContentLength = -1
+	resp.Uncompressed = true
+}
+
+func (b *DecodedBody) Read(p []byte) (int, error) {
+	if b.err != nil {
+		return 0, b.err
+	}
+	if b.decoder == nil {
+		if b.decoder, b.err = b.newDecoder(); b.err != nil {
+			return 0, b.err
+		}
+	}
+	if b.budget > 0 {
+		remaining := b.budget - b.decoded
+		if remaining <= 0 {
+			b.err = ErrDecompressionBudget
+			return 0, b.err
+		}
+		if int64(len(p)) > remaining {
+			p = p[:remaining]
+		}
+	}
+	n, err := b.decoder.Read(p)
+	b.decoded += int64(n)
+	if err != nil {
+		b.err = err
+	}
+	return n, err
+}
+
+func (b *DecodedBody) newDecoder() (io.Reader, error) {
+	if b.encoding == "gzip" {
+		return gzip.NewReader(b.counter)
+	}
+	// Deflate is meant to be zlib wrapped, but raw deflate is common
+	buffered := bufio.NewReader(b.counter)
+	if hdr, err := buffered.Peek(2); err == nil && hdr[0]&0x0f == 8 && (uint16(hdr[0])<<8|uint16(hdr[1]))%31 == 0 {
+		return zlib.NewReader(buffered)
+	}
+	return flate.NewReader(buffered), nil
+}
+
+func (b *DecodedBody) Close() error {
+	return b.raw.Close()
+}
+
+func (b *DecodedBody) CompressedBytes() int64 {
+	return b.counter.n
+}
+
+func (b *DecodedBody) DecompressedBytes() int64 {
+	return b.decoded
+}
@@ -0,0 +1,96 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func encodedResponse(encoding string, body []byte) *http.Response {
+	buf := &bytes.Buffer{}
+	var w io.WriteCloser
+	switch encoding {
+	case "gzip":
+		w = gzip.NewWriter(buf)
+	case "deflate":
+		w = zlib.NewWriter(buf)
+	case "raw-deflate":
+		w, _ = flate.NewWriter(buf, flate.DefaultCompression)
+		encoding = "deflate"
+	}
+	w.Write(body)
+	w.Close()
+	resp := &http.Response{
+		Header:        http.Header{},
+		Body:          ioutil.NopCloser(buf),
+		ContentLength: int64(buf.Len()),
+	}
+	resp.Header.Set("Content-Encoding", encoding)
+	return resp
+}
+
+func TestDecodeBody(t *testing.T) {
+	body := []byte(strings.Repeat("hello world ", 1000))
+	for _, encoding := range []string{"gzip", "deflate", "raw-deflate"} {
+		resp := encodedResponse(encoding, body)
+		DecodeBody(resp, 0)
+		got, err := ioutil.ReadAll(resp.Body)
+		if err != nil {
+			t.Errorf("Unexpected error decoding %s: %v", encoding, err)
+			continue
+		}
+		if !bytes.Equal(got, body) {
+			t.Errorf("Body mismatch decoding %s.", encoding)
+		}
+		stats := resp.Body.(CompressionStats)
+		if stats.DecompressedBytes() != int64(len(body)) {
+			t.Errorf("Expected %d decompressed bytes, got %d", len(body), stats.DecompressedBytes())
+		}
+		if c := stats.CompressedBytes(); c == 0 || c >= int64(len(body)) {
+			t.Errorf("Unexpected compressed size %d for %s", c, encoding)
+		}
+		if resp.ContentLength != -1 || resp.Header.Get("Content-Encoding") != "" {
+			t.Error("Expected encoding headers to be cleared.")
+		}
+	}
+}
+
+func TestDecodeBody_Budget(t *testing.T) {
+	resp := encodedResponse("gzip", make([]byte, 1<<20))
+	DecodeBody(resp, 4096)
+	got, err := ioutil.ReadAll(resp.Body)
+	if err != ErrDecompressionBudget {
+		t.Errorf("Expected budget error, got %v", err)
+	}
+	if len(got) != 4096 {
+		t.Errorf("Expected 4096 bytes before budget, got %d", len(got))
+	}
+}
+
+func TestDecodeBody_Identity(t *testing.T) {
+	resp := &http.Response{Header: http.Header{}, Body: ioutil.NopCloser(strings.NewReader("x"))}
+	DecodeBody(resp, 0)
+	if _, ok := resp.Body.(CompressionStats); ok {
+		t.Error("Expected unencoded body to be left alone.")
+	}
+}
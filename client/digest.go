@@ -0,0 +1,194 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// DigestSessions remembers the Digest challenge from each host, so that only
+// the first request to a host needs to be challenged.
+type DigestSessions struct {
+	sync.Mutex
+	challenges map[string]*digestChallenge
+}
+
+type digestChallenge struct {
+	realm     string
+	nonce     string
+	opaque    string
+	algorithm string
+	// Whether to use qop=auth, rather than the original RFC 2069 scheme
+	qop bool
+	// Requests made with the nonce
+	count int
+}
+
+// Makes the client nonce, replaced in tests.
+var digestCnonce = func() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+func NewDigestSessions() *DigestSessions {
+	return &DigestSessions{challenges: make(map[string]*digestChallenge)}
+}
+
+// Learn the Digest challenge in a 401 response, returning whether there was
+// one that can be answered.
+func (d *DigestSessions) Challenged(resp *http.Response) bool {
+	if d == nil || resp.StatusCode != http.StatusUnauthorized || resp.Request == nil {
+		return false
+	}
+	for _, header := range resp.Header["Www-Authenticate"] {
+		if c := parseDigestChallenge(header); c != nil {
+			d.Lock()
+			d.challenges[strings.ToLower(resp.Request.URL.Host)] = c
+			d.Unlock()
+			return true
+		}
+	}
+	return false
+}
+
+// Answer the challenge from the request's host, if there has been one.
+func (d *DigestSessions) Apply(req *http.Request, creds *url.Userinfo) {
+	if d == nil || creds == nil {
+		return
+	}
+	d.Lock()
+	defer d.Unlock()
+	c, ok := d.challenges[strings.ToLower(req.URL.Host)]
+	if !ok {
+		return
+	}
+	c.count++
+	pass, _ := creds.Password()
+	req.Header.Set("Authorization", c.authorization(req.Method, req.URL.RequestURI(), creds.Username(), pass, digestCnonce()))
+}
+
+// Parse a WWW-Authenticate header, returning nil unless it is a Digest
+// challenge this client can answer.
+func parseDigestChallenge(header string) *digestChallenge {
+	scheme := strings.SplitN(strings.TrimSpace(header), " ", 2)
+	if len(scheme) != 2 || !strings.EqualFold(scheme[0], "Digest") {
+		return nil
+	}
+	c := &digestChallenge{algorithm: "MD5"}
+	for _, param := range splitDigestParams(scheme[1]) {
+		pieces := strings.SplitN(param, "=", 2)
+		if len(pieces) != 2 {
+			continue
+		}
+		value := strings.Trim(strings.TrimSpace(pieces[1]), `"`)
+		switch strings.ToLower(strings.TrimSpace(pieces[0])) {
+		case "realm":
+			c.realm = value
+		case "nonce":
+			c.nonce = value
+		case "opaque":
+			c.opaque = value
+		case "algorithm":
+			c.algorithm = strings.ToUpper(value)
+		case "qop":
+			for _, qop := range strings.Split(value, ",") {
+				if strings.TrimSpace(qop) == "auth" {
+					c.qop = true
+				}
+			}
+			if !c.qop {
+				// Only auth-int, which would need the body hashed
+				return nil
+			}
+		}
+	}
+	if c.nonce == "" || c.newHash() == nil {
+		return nil
+	}
+	return c
+}
+
+// Split challenge parameters on commas outside quotes.
+func splitDigestParams(s string) []string {
+	var params []string
+	quoted := false
+	start := 0
+	for i, r := range s {
+		switch r {
+		case '"':
+			quoted = !quoted
+		case ',':
+			if !quoted {
+				params = append(params, s[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(params, s[start:])
+}
+
+func (c *digestChallenge) newHash() func() hash.Hash {
+	switch strings.TrimSuffix(c.algorithm, "-SESS") {
+	case "MD5":
+		return md5.New
+	case "SHA-256":
+		return sha256.New
+	}
+	return nil
+}
+
+func (c *digestChallenge) hash(parts ...string) string {
+	h := c.newHash()()
+	h.Write([]byte(strings.Join(parts, ":")))
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// The Authorization header answering the challenge, as in RFC 7616.
+func (c *digestChallenge) authorization(method, uri, user, pass, cnonce string) string {
+	ha1 := c.hash(user, c.realm, pass)
+	if strings.HasSuffix(c.algorithm, "-SESS") {
+		ha1 = c.hash(ha1, c.nonce, cnonce)
+	}
+	ha2 := c.hash(method, uri)
+	nc := fmt.Sprintf("%08x", c.count)
+	fields := []string{
+		fmt.Sprintf(`username="%s"`, user),
+		fmt.Sprintf(`realm="%s"`, c.realm),
+		fmt.Sprintf(`nonce="%s"`, c.nonce),
+		fmt.Sprintf(`uri="%s"`, uri),
+		"algorithm=" + c.algorithm,
+	}
+	if c.qop {
+		fields = append(fields,
+			fmt.Sprintf(`response="%s"`, c.hash(ha1, c.nonce, nc, cnonce, "auth", ha2)),
+			"qop=auth", "nc="+nc, fmt.Sprintf(`cnonce="%s"`, cnonce))
+	} else {
+		fields = append(fields, fmt.Sprintf(`response="%s"`, c.hash(ha1, c.nonce, ha2)))
+	}
+	if c.opaque != "" {
+		fields = append(fields, fmt.Sprintf(`opaque="%s"`, c.opaque))
+	}
+	return "Digest " + strings.Join(fields, ", ")
+}
@@ -0,0 +1,88 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestParseDigestChallenge(t *testing.T) {
+	c := parseDigestChallenge(`Digest realm="a, b", qop="auth,auth-int", nonce="abc", opaque="xyz", algorithm=SHA-256`)
+	if c == nil {
+		t.Fatal("Expected a challenge.")
+	}
+	if c.realm != "a, b" || c.nonce != "abc" || c.opaque != "xyz" || c.algorithm != "SHA-256" || !c.qop {
+		t.Errorf("Unexpected challenge: %+v", c)
+	}
+	for _, bad := range []string{
+		`Basic realm="a"`,
+		`Digest realm="a"`,
+		`Digest realm="a", nonce="abc", qop="auth-int"`,
+		`Digest realm="a", nonce="abc", algorithm=SHA-512-256`,
+	} {
+		if parseDigestChallenge(bad) != nil {
+			t.Errorf("Expected no challenge for %s", bad)
+		}
+	}
+}
+
+func TestDigestAuthorization(t *testing.T) {
+	// The example from RFC 2617
+	c := parseDigestChallenge(`Digest realm="testrealm@host.com", qop="auth,auth-int", nonce="dcd98b7102dd2f0e8b11d0f600bfb0c093", opaque="5ccc069c403ebaf9f0171e9517f40e41"`)
+	c.count = 1
+	auth := c.authorization("GET", "/dir/index.html", "Mufasa", "Circle Of Life", "0a4f113b")
+	if !strings.Contains(auth, `response="6629fae49393a05397450978507c4ef1"`) {
+		t.Errorf("Expected the RFC 2617 response, got %s", auth)
+	}
+	for _, field := range []string{`username="Mufasa"`, "nc=00000001", `cnonce="0a4f113b"`, `opaque="5ccc069c403ebaf9f0171e9517f40e41"`} {
+		if !strings.Contains(auth, field) {
+			t.Errorf("Expected %s in %s", field, auth)
+		}
+	}
+}
+
+func TestHTTPClient_Digest(t *testing.T) {
+	challenges := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasPrefix(r.Header.Get("Authorization"), "Digest ") {
+			challenges++
+			w.Header().Set("WWW-Authenticate", `Digest realm="test", qop="auth", nonce="n1"`)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+	u, _ := url.Parse(server.URL + "/secret")
+	creds, _ := ParseCredentials([]string{"user:pass"})
+	c := &httpClient{credentials: creds, digests: NewDigestSessions()}
+	for i := 0; i < 2; i++ {
+		resp, err := c.RequestURL(u)
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			t.Errorf("Expected 200, got %d", resp.StatusCode)
+		}
+	}
+	if challenges != 1 {
+		t.Errorf("Expected the challenge to be reused, got %d challenges", challenges)
+	}
+}
@@ -15,12 +15,16 @@
 package client
 
 import (
+	"context"
+	"crypto/tls"
 	"fmt"
+	"github.com/Matir/gobuster/audit"
 	"github.com/Matir/gobuster/logging"
 	"h12.me/socks"
-	"math/rand"
+	"net"
 	"net/http"
 	"net/url"
+	"sync/atomic"
 	"time"
 )
 
@@ -31,18 +35,72 @@ var proxyTypeMap = map[string]int{
 	"socks5":  socks.SOCKS5,
 }
 
+// HTTP proxies, such as Burp or a corporate proxy, which are sent requests
+// for HTTP targets and tunnel HTTPS with CONNECT.
+var httpProxySchemes = map[string]bool{
+	"http":  true,
+	"https": true,
+}
+
 // A ClientFactory allows constructing HTTP Clients based on various Dialers or
 // Transports.
 type ClientFactory interface {
 	Get() Client
 }
 
+// AnonymousClientFactory can also make clients that send no credentials, to
+// compare what is visible without them.
+type AnonymousClientFactory interface {
+	ClientFactory
+	Anonymous() ClientFactory
+}
+
 // ProxyClientFactory uses the h12.me/socks package to support SOCKS proxies
 // when transporting requests to the webserver.
 type ProxyClientFactory struct {
 	proxyURLs []*url.URL
 	timeout   time.Duration
 	userAgent string
+	// Optional resolver with pre-resolved addresses
+	resolver *Resolver
+	// Optional limit on bandwidth shared by all clients
+	limiter *BandwidthLimiter
+	// Optional limit on requests to each host, shared by all clients
+	rate *RateLimiter
+	// Optional record of throttling, shared by all clients
+	throttles *ThrottleProfiler
+	// Optional record of every request sent, shared by all clients
+	audit *audit.Log
+	// Most bytes to decompress from a body
+	decompressBudget int64
+	// Slowest acceptable body in bytes per second
+	minThroughput int64
+	// Basic auth for each host
+	credentials HostCredentials
+	// Digest challenges, shared by all clients
+	digests *DigestSessions
+	// Cookies for each host, shared by all clients
+	jars *HostJars
+	// Optional local addresses for direct connections
+	local *LocalAddrs
+	// Unix domain socket every direct connection goes to, if set
+	unixSocket string
+	// Host every request is for, whatever host its URL names, if set
+	host string
+	// Headers for every request
+	headers http.Header
+	// Cookies for every request
+	cookies []*http.Cookie
+	// Certificate for mutual TLS, if any
+	certificate *tls.Certificate
+	// Which HTTP versions clients speak
+	http2 HTTP2Mode
+	// Hosts HTTP/3 was tried with, if clients try it
+	http3 *http3Hosts
+	// Send each request through the next proxy, rather than each client
+	proxyPerRequest bool
+	// Index of the next proxy to give a client
+	nextProxy uint32
 }
 
 // Create a ProxyClientFactory for the provided list of proxies.
@@ -54,7 +112,7 @@ func NewProxyClientFactory(proxies []string, timeout time.Duration, agent string
 			logging.Logf(logging.LogWarning, "Unable to parse proxy: %s", proxy)
 			return nil, err
 		}
-		if _, ok := proxyTypeMap[u.Scheme]; !ok {
+		if _, ok := proxyTypeMap[u.Scheme]; !ok && !httpProxySchemes[u.Scheme] {
 			logging.Logf(logging.LogWarning, "Invalid proxy protocol: %s", u.Scheme)
 			return nil, fmt.Errorf("Invalid proxy protocol: %s", u.Scheme)
 		}
@@ -67,27 +125,236 @@ func NewProxyClientFactory(proxies []string, timeout time.Duration, agent string
 	return factory, nil
 }
 
+// Use the given resolver for direct (non-proxied) connections.
+func (factory *ProxyClientFactory) SetResolver(resolver *Resolver) {
+	factory.resolver = resolver
+}
+
+// Share a bandwidth limit across all clients from this factory.
+func (factory *ProxyClientFactory) SetBandwidthLimiter(limiter *BandwidthLimiter) {
+	factory.limiter = limiter
+}
+
+// Share a limit on the rate of requests to each host across all clients.
+func (factory *ProxyClientFactory) SetRateLimiter(rate *RateLimiter) {
+	factory.rate = rate
+}
+
+// Share a record of throttling between all clients.
+func (factory *ProxyClientFactory) SetThrottleProfiler(throttles *ThrottleProfiler) {
+	factory.throttles = throttles
+}
+
+// Record every request sent by any client in log.
+func (factory *ProxyClientFactory) SetAuditLog(log *audit.Log) {
+	factory.audit = log
+}
+
+// Limit how many bytes clients will decompress from a single body.
+func (factory *ProxyClientFactory) SetDecompressBudget(budget int64) {
+	factory.decompressBudget = budget
+}
+
+// Abort bodies that arrive slower than bytesPerSec.
+func (factory *ProxyClientFactory) SetMinThroughput(bytesPerSec int64) {
+	factory.minThroughput = bytesPerSec
+}
+
+// Send credentials to the hosts they are for, as basic auth unless a host
+// asks for digest auth.
+func (factory *ProxyClientFactory) SetCredentials(credentials HostCredentials) {
+	factory.credentials = credentials
+	factory.digests = NewDigestSessions()
+}
+
+// Keep cookies, separately for each host, across all clients.
+func (factory *ProxyClientFactory) SetCookieJars(jars *HostJars) {
+	factory.jars = jars
+}
+
+// Make direct connections from the given local addresses.  Connections
+// through a resolver set with SetResolver are bound by the resolver.
+func (factory *ProxyClientFactory) SetLocalAddrs(local *LocalAddrs) {
+	factory.local = local
+}
+
+// Send host in the Host header and TLS server name of every request, while
+// connecting to the host its URL names, such as a site's origin IP.
+func (factory *ProxyClientFactory) SetHost(host string) {
+	factory.host = host
+}
+
+// Send header with every request.
+func (factory *ProxyClientFactory) SetHeaders(header http.Header) {
+	factory.headers = header
+}
+
+// Send cookies with every request, along with any kept in cookie jars.
+func (factory *ProxyClientFactory) SetCookies(cookies []*http.Cookie) {
+	factory.cookies = cookies
+}
+
+// Present cert to servers that ask for a client certificate.
+func (factory *ProxyClientFactory) SetClientCertificate(cert tls.Certificate) {
+	factory.certificate = &cert
+}
+
+// Choose whether clients speak HTTP/2.
+func (factory *ProxyClientFactory) SetHTTP2(mode HTTP2Mode) {
+	factory.http2 = mode
+}
+
+// Try HTTP/3 first for https:// URLs, falling back to the other versions
+// for hosts it doesn't work with.  Clients through a proxy or Unix socket
+// don't, as neither can carry QUIC.
+func (factory *ProxyClientFactory) SetHTTP3(enabled bool) {
+	factory.http3 = nil
+	if enabled {
+		factory.http3 = newHTTP3Hosts()
+	}
+}
+
+// Connect to the Unix domain socket at path for every direct request,
+// whatever host its URL names, which still goes in the Host header.
+func (factory *ProxyClientFactory) SetUnixSocket(path string) {
+	factory.unixSocket = path
+}
+
+// Send each request through the next proxy in turn, rather than giving each
+// client one proxy for all its requests.
+func (factory *ProxyClientFactory) SetProxyPerRequest(perRequest bool) {
+	factory.proxyPerRequest = perRequest
+}
+
+// A factory for clients like these but without credentials, cookies,
+// client certificates or headers, which often carry credentials too.
+func (factory *ProxyClientFactory) Anonymous() ClientFactory {
+	anon := *factory
+	anon.credentials = nil
+	anon.digests = nil
+	anon.jars = nil
+	anon.headers = nil
+	anon.cookies = nil
+	anon.certificate = nil
+	return &anon
+}
+
 func (factory *ProxyClientFactory) Get() Client {
-	if len(factory.proxyURLs) == 0 {
-		return &httpClient{Client: http.Client{Timeout: factory.timeout}, UserAgent: factory.userAgent}
+	var cl *httpClient
+	switch len(factory.proxyURLs) {
+	case 0:
+		cl = &httpClient{Client: http.Client{Timeout: factory.timeout}, UserAgent: factory.userAgent}
+		if factory.unixSocket != "" {
+			// Not through a proxy from the environment, which would miss
+			// the socket
+			dialer := &net.Dialer{Timeout: factory.timeout}
+			cl.Transport = &http.Transport{
+				DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+					return dialer.DialContext(ctx, "unix", factory.unixSocket)
+				},
+			}
+		} else if factory.resolver != nil {
+			cl.Transport = &http.Transport{
+				Proxy: http.ProxyFromEnvironment,
+				Dial:  factory.resolver.Dial,
+			}
+		} else if factory.local != nil {
+			cl.Transport = &http.Transport{
+				Proxy:       http.ProxyFromEnvironment,
+				DialContext: factory.local.DialContext(&net.Dialer{Timeout: factory.timeout}),
+			}
+		}
+	case 1:
+		cl = clientForProxy(factory.proxyURLs[0], factory.timeout, factory.userAgent)
+	default:
+		// NTLM authenticates a connection, which must stay on one proxy
+		if factory.proxyPerRequest && factory.credentials == nil {
+			cl = &httpClient{Client: http.Client{Timeout: factory.timeout, Transport: newRotatingTransport(factory.proxyURLs)}, UserAgent: factory.userAgent}
+		} else {
+			// Clients take the proxies in turn
+			i := atomic.AddUint32(&factory.nextProxy, 1) - 1
+			proxy := factory.proxyURLs[int(i%uint32(len(factory.proxyURLs)))]
+			cl = clientForProxy(proxy, factory.timeout, factory.userAgent)
+		}
+	}
+	cl.limiter = factory.limiter
+	cl.rate = factory.rate
+	cl.throttles = factory.throttles
+	cl.decompressBudget = factory.decompressBudget
+	cl.minThroughput = factory.minThroughput
+	cl.credentials = factory.credentials
+	cl.digests = factory.digests
+	if factory.credentials != nil && cl.Transport == nil {
+		// NTLM authenticates connections, which shouldn't be shared.
+		// Proxied clients already have a transport of their own.
+		cl.Transport = http.DefaultTransport.(*http.Transport).Clone()
+	}
+	if factory.certificate != nil || factory.host != "" {
+		if cl.Transport == nil {
+			cl.Transport = http.DefaultTransport.(*http.Transport).Clone()
+		}
+		eachTransport(cl.Transport, func(transport *http.Transport) {
+			config := &tls.Config{}
+			if factory.certificate != nil {
+				config.Certificates = []tls.Certificate{*factory.certificate}
+			}
+			if factory.host != "" {
+				// Verified against the host presented, not the address
+				config.ServerName = (&url.URL{Host: factory.host}).Hostname()
+			}
+			transport.TLSClientConfig = config
+		})
+	}
+	if factory.http2 != HTTP2Default {
+		if cl.Transport == nil {
+			cl.Transport = http.DefaultTransport.(*http.Transport).Clone()
+		}
+		eachTransport(cl.Transport, factory.http2.configure)
+	}
+	if factory.http3 != nil && len(factory.proxyURLs) == 0 && factory.unixSocket == "" {
+		var dial func(context.Context, string, string) (net.Conn, error)
+		if factory.resolver != nil {
+			dial = func(_ context.Context, network, addr string) (net.Conn, error) {
+				return factory.resolver.Dial(network, addr)
+			}
+		} else {
+			dial = factory.local.DialContext(&net.Dialer{Timeout: factory.timeout})
+		}
+		cl.Transport = newHTTP3Transport(cl.Transport, factory.http3, dial)
+	}
+	if factory.audit != nil {
+		next := cl.Transport
+		if next == nil {
+			next = http.DefaultTransport
+		}
+		cl.Transport = &auditTransport{next: next, log: factory.audit}
 	}
-	if len(factory.proxyURLs) == 1 {
-		return clientForProxy(factory.proxyURLs[0], factory.timeout, factory.userAgent)
+	cl.host = factory.host
+	cl.headers = factory.headers
+	cl.cookies = factory.cookies
+	if factory.jars != nil {
+		cl.jars = factory.jars
+		cl.Jar = factory.jars
 	}
-	proxy := factory.proxyURLs[rand.Intn(len(factory.proxyURLs))]
-	return clientForProxy(proxy, factory.timeout, factory.userAgent)
+	return cl
 }
 
-func clientForProxy(proxy *url.URL, timeout time.Duration, agent string) Client {
-	proto := proxyTypeMap[proxy.Scheme]
-	dialer := socks.DialSocksProxy(proto, proxy.Host)
+func clientForProxy(proxy *url.URL, timeout time.Duration, agent string) *httpClient {
 	cl := &httpClient{
 		Client: http.Client{
-			Transport: &http.Transport{
-				Dial: dialer,
-			},
-			Timeout: timeout,
+			Transport: transportForProxy(proxy),
+			Timeout:   timeout,
 		},
 		UserAgent: agent}
 	return cl
 }
+
+func transportForProxy(proxy *url.URL) *http.Transport {
+	transport := &http.Transport{}
+	if httpProxySchemes[proxy.Scheme] {
+		transport.Proxy = http.ProxyURL(proxy)
+	} else {
+		transport.Dial = socks.DialSocksProxy(proxyTypeMap[proxy.Scheme], proxy.Host)
+	}
+	return transport
+}
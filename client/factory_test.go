@@ -15,6 +15,13 @@
 package client
 
 import (
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
 	"testing"
 	"time"
 )
@@ -85,3 +92,118 @@ func TestPCFGet_TwoProxies(t *testing.T) {
 		t.Errorf("Got nil client for two proxies.")
 	}
 }
+
+func TestPCFGet_HTTPProxy(t *testing.T) {
+	var proxied string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxied = r.URL.String()
+	}))
+	defer proxy.Close()
+	fac, err := NewProxyClientFactory([]string{proxy.URL}, time.Second, "")
+	if err != nil {
+		t.Fatalf("Unable to construct HTTP proxy client factory: %s", err)
+	}
+	u, _ := url.Parse("http://target.example/admin")
+	resp, err := fac.Get().RequestURL(u)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	resp.Body.Close()
+	if proxied != u.String() {
+		t.Errorf("Expected the proxy to be asked for %s, got %q", u, proxied)
+	}
+}
+
+func TestPCFGet_HTTP3(t *testing.T) {
+	fac, _ := NewProxyClientFactory([]string{}, time.Second, "")
+	fac.SetHTTP3(true)
+	if _, ok := fac.Get().(*httpClient).Transport.(*http3Transport); !ok {
+		t.Errorf("Expected an HTTP/3 transport.")
+	}
+	proxied, _ := NewProxyClientFactory([]string{"socks5://localhost"}, time.Second, "")
+	proxied.SetHTTP3(true)
+	if _, ok := proxied.Get().(*httpClient).Transport.(*http3Transport); ok {
+		t.Errorf("Expected no HTTP/3 through a proxy.")
+	}
+}
+
+func TestPCFGet_UnixSocket(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gobuster-unix")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "app.sock")
+	ln, err := net.Listen("unix", path)
+	if err != nil {
+		t.Skipf("Unable to listen on a Unix socket: %v", err)
+	}
+	var host string
+	server := &httptest.Server{
+		Listener: ln,
+		Config: &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			host = r.Host
+		})},
+	}
+	server.Start()
+	defer server.Close()
+	fac, _ := NewProxyClientFactory([]string{}, time.Second, "")
+	fac.SetUnixSocket(path)
+	fac.SetHTTP3(true)
+	u, _ := url.Parse("http://app.internal/health")
+	resp, err := fac.Get().RequestURL(u)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	resp.Body.Close()
+	if host != "app.internal" {
+		t.Errorf("Expected Host app.internal, got %q", host)
+	}
+}
+
+func TestPCFGet_Host(t *testing.T) {
+	var host, serverName string
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host, serverName = r.Host, r.TLS.ServerName
+	}))
+	defer server.Close()
+	fac, _ := NewProxyClientFactory([]string{}, time.Second, "")
+	fac.SetHost("example.com:8443")
+	cl := fac.Get().(*httpClient)
+	transport := cl.Transport.(*http.Transport)
+	if transport.TLSClientConfig.ServerName != "example.com" {
+		t.Errorf("Expected server name example.com, got %q", transport.TLSClientConfig.ServerName)
+	}
+	// Trust the test certificate, which is for example.com
+	transport.TLSClientConfig.RootCAs = server.Client().Transport.(*http.Transport).TLSClientConfig.RootCAs
+	u, _ := url.Parse(server.URL + "/admin/")
+	resp, err := cl.RequestURL(u)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	resp.Body.Close()
+	if host != "example.com:8443" || serverName != "example.com" {
+		t.Errorf("Expected example.com presented to %s, got Host %q and server name %q", u.Host, host, serverName)
+	}
+	if anon := fac.Anonymous().Get().(*httpClient); anon.host != "example.com:8443" {
+		t.Errorf("Expected anonymous clients to keep the host, got %q", anon.host)
+	}
+}
+
+func TestPCFAnonymous(t *testing.T) {
+	fac, _ := NewProxyClientFactory([]string{}, time.Second, "")
+	creds, _ := ParseCredentials([]string{"localhost=admin:secret"})
+	header, _ := ParseHeaders([]string{"X-Api-Key: abc"})
+	fac.SetCredentials(creds)
+	fac.SetHeaders(header)
+	fac.SetCookieJars(NewHostJars())
+	cookies, _ := ParseCookies([]string{"session=abc"})
+	fac.SetCookies(cookies)
+	anon := fac.Anonymous().Get().(*httpClient)
+	if anon.credentials != nil || anon.headers != nil || anon.cookies != nil || anon.jars != nil || anon.Jar != nil {
+		t.Errorf("Expected no credentials, headers or cookies, got %+v", anon)
+	}
+	if cl := fac.Get().(*httpClient); cl.credentials == nil || cl.headers == nil {
+		t.Error("Expected the original factory to keep its credentials.")
+	}
+}
@@ -0,0 +1,107 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"context"
+	"net"
+	"time"
+)
+
+// Delay between starting connection attempts (RFC 8305 section 5).
+const connectionAttemptDelay = 250 * time.Millisecond
+
+type dialResult struct {
+	conn net.Conn
+	err  error
+}
+
+// Order addresses alternating between address families, starting with IPv6
+// (RFC 8305 section 4).
+func interleaveAddrs(addrs []string) []string {
+	var v6, v4 []string
+	for _, a := range addrs {
+		if ip := net.ParseIP(a); ip != nil && ip.To4() == nil {
+			v6 = append(v6, a)
+		} else {
+			v4 = append(v4, a)
+		}
+	}
+	res := make([]string, 0, len(addrs))
+	for i := 0; i < len(v6) || i < len(v4); i++ {
+		if i < len(v6) {
+			res = append(res, v6[i])
+		}
+		if i < len(v4) {
+			res = append(res, v4[i])
+		}
+	}
+	return res
+}
+
+// Race connections to the given addresses, starting a new attempt every
+// connectionAttemptDelay or as soon as an attempt fails.  The first
+// successful connection wins, so a broken address family only costs a short
+// delay rather than a full connect timeout.
+func dialHappyEyeballs(dial func(context.Context, string, string) (net.Conn, error), network string, addrs []string, port string) (net.Conn, error) {
+	ordered := interleaveAddrs(addrs)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	results := make(chan dialResult, len(ordered))
+	next := 0
+	pending := 0
+	startNext := func() {
+		addr := net.JoinHostPort(ordered[next], port)
+		next++
+		pending++
+		go func() {
+			conn, err := dial(ctx, network, addr)
+			results <- dialResult{conn, err}
+		}()
+	}
+
+	startNext()
+	var lastErr error
+	for pending > 0 {
+		var delay <-chan time.Time
+		if next < len(ordered) {
+			delay = time.After(connectionAttemptDelay)
+		}
+		select {
+		case r := <-results:
+			pending--
+			if r.err == nil {
+				go closeLosers(results, pending)
+				return r.conn, nil
+			}
+			lastErr = r.err
+			if next < len(ordered) {
+				startNext()
+			}
+		case <-delay:
+			startNext()
+		}
+	}
+	return nil, lastErr
+}
+
+// Close any connections that complete after a winner has been chosen.
+func closeLosers(results <-chan dialResult, pending int) {
+	for ; pending > 0; pending-- {
+		if r := <-results; r.conn != nil {
+			r.conn.Close()
+		}
+	}
+}
@@ -0,0 +1,63 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestInterleaveAddrs(t *testing.T) {
+	addrs := []string{"10.0.0.1", "10.0.0.2", "::1", "fe80::1"}
+	expected := "::1,10.0.0.1,fe80::1,10.0.0.2"
+	if res := strings.Join(interleaveAddrs(addrs), ","); res != expected {
+		t.Errorf("Expected %s, got %s", expected, res)
+	}
+}
+
+func TestDialHappyEyeballs(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Skipf("Unable to listen: %v", err)
+	}
+	defer l.Close()
+	_, port, _ := net.SplitHostPort(l.Addr().String())
+	dialer := &net.Dialer{Timeout: 5 * time.Second}
+	start := time.Now()
+	// 192.0.2.1 is TEST-NET-1 and should never answer.
+	conn, err := dialHappyEyeballs(dialer.DialContext, "tcp", []string{"192.0.2.1", "127.0.0.1"}, port)
+	if err != nil {
+		t.Fatalf("Expected connection, got %v", err)
+	}
+	conn.Close()
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("Expected fallback to be fast, took %v", elapsed)
+	}
+}
+
+func TestDialHappyEyeballs_AllFail(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Skipf("Unable to listen: %v", err)
+	}
+	_, port, _ := net.SplitHostPort(l.Addr().String())
+	l.Close()
+	dialer := &net.Dialer{Timeout: time.Second}
+	if _, err := dialHappyEyeballs(dialer.DialContext, "tcp", []string{"127.0.0.1"}, port); err == nil {
+		t.Error("Expected error dialing closed port.")
+	}
+}
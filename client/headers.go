@@ -0,0 +1,47 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// Parse "Name: value" entries into headers.  Repeated names keep every value.
+func ParseHeaders(entries []string) (http.Header, error) {
+	header := make(http.Header)
+	for _, entry := range entries {
+		pieces := strings.SplitN(entry, ":", 2)
+		name := strings.TrimSpace(pieces[0])
+		if len(pieces) != 2 || name == "" || strings.ContainsAny(name, " \t") {
+			return nil, fmt.Errorf("Invalid header, expected Name: value: %s", entry)
+		}
+		header.Add(name, strings.TrimSpace(pieces[1]))
+	}
+	return header, nil
+}
+
+// Add header to the request, replacing any values it already has.  A Host
+// header names the host the request is for.
+func applyHeaders(req *http.Request, header http.Header) {
+	for name, values := range header {
+		if name == "Host" {
+			req.Host = values[0]
+			continue
+		}
+		req.Header[name] = values
+	}
+}
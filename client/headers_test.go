@@ -0,0 +1,56 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"net/url"
+	"testing"
+)
+
+func TestParseHeaders(t *testing.T) {
+	header, err := ParseHeaders([]string{"X-Api-Key: abc:123", "x-forwarded-for:127.0.0.1", "X-Forwarded-For: 10.0.0.1", "X-Empty:"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if got := header.Get("X-Api-Key"); got != "abc:123" {
+		t.Errorf("Expected abc:123, got %s", got)
+	}
+	if got := header["X-Forwarded-For"]; len(got) != 2 || got[1] != "10.0.0.1" {
+		t.Errorf("Expected both X-Forwarded-For values, got %v", got)
+	}
+	if _, ok := header["X-Empty"]; !ok {
+		t.Error("Expected a header with an empty value.")
+	}
+	for _, bad := range []string{"X-Api-Key", ": value", "X Api: value"} {
+		if _, err := ParseHeaders([]string{bad}); err == nil {
+			t.Errorf("Expected error for %s", bad)
+		}
+	}
+}
+
+func TestMakeRequest_Headers(t *testing.T) {
+	header, _ := ParseHeaders([]string{"User-Agent: Mozilla/5.0", "Host: internal.example.com", "Authorization: Bearer token"})
+	c := &httpClient{UserAgent: "GoBuster", headers: header}
+	req := c.makeRequest(&url.URL{Scheme: "http", Host: "localhost", Path: "/"})
+	if got := req.Header.Get("User-Agent"); got != "Mozilla/5.0" {
+		t.Errorf("Expected header to replace User-Agent, got %s", got)
+	}
+	if req.Host != "internal.example.com" {
+		t.Errorf("Expected Host internal.example.com, got %s", req.Host)
+	}
+	if got := req.Header.Get("Authorization"); got != "Bearer token" {
+		t.Errorf("Expected Authorization header, got %s", got)
+	}
+}
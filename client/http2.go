@@ -0,0 +1,53 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"net/http"
+)
+
+// HTTP2Mode is whether clients speak HTTP/2.
+type HTTP2Mode int
+
+const (
+	// Whatever the transport does by default
+	HTTP2Default HTTP2Mode = iota
+	// HTTP/1.1 only
+	HTTP2Disabled
+	// HTTP/2 where the server offers it over TLS, otherwise HTTP/1.1
+	HTTP2Negotiated
+	// HTTP/2 only, without waiting to be offered it, so also in cleartext
+	// (h2c) for http:// URLs
+	HTTP2PriorKnowledge
+)
+
+func (mode HTTP2Mode) configure(transport *http.Transport) {
+	protocols := new(http.Protocols)
+	switch mode {
+	case HTTP2Disabled:
+		protocols.SetHTTP1(true)
+	case HTTP2Negotiated:
+		protocols.SetHTTP1(true)
+		protocols.SetHTTP2(true)
+	case HTTP2PriorKnowledge:
+		protocols.SetHTTP2(true)
+		protocols.SetUnencryptedHTTP2(true)
+	default:
+		return
+	}
+	transport.Protocols = protocols
+	// Otherwise custom dialers and TLS configs keep to HTTP/1.1
+	transport.ForceAttemptHTTP2 = mode != HTTP2Disabled
+}
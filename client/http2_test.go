@@ -0,0 +1,84 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+// Protocol a client with mode used to request from server.
+func requestProto(t *testing.T, server *httptest.Server, mode HTTP2Mode) string {
+	factory, _ := NewProxyClientFactory(nil, 0, "test")
+	factory.SetHTTP2(mode)
+	cl := factory.Get().(*httpClient)
+	if server.TLS != nil {
+		roots := x509.NewCertPool()
+		roots.AddCert(server.Certificate())
+		cl.Transport.(*http.Transport).TLSClientConfig = &tls.Config{RootCAs: roots}
+	}
+	u, _ := url.Parse(server.URL)
+	resp, err := cl.RequestURL(u)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	resp.Body.Close()
+	return resp.Proto
+}
+
+func TestHTTP2Mode_TLS(t *testing.T) {
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	server.EnableHTTP2 = true
+	server.StartTLS()
+	defer server.Close()
+	for _, c := range []struct {
+		mode     HTTP2Mode
+		expected string
+	}{
+		{HTTP2Disabled, "HTTP/1.1"},
+		{HTTP2Negotiated, "HTTP/2.0"},
+		{HTTP2PriorKnowledge, "HTTP/2.0"},
+	} {
+		if proto := requestProto(t, server, c.mode); proto != c.expected {
+			t.Errorf("Expected %s in mode %d, got %s", c.expected, c.mode, proto)
+		}
+	}
+}
+
+func TestHTTP2Mode_Cleartext(t *testing.T) {
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	server.Config.Protocols = new(http.Protocols)
+	server.Config.Protocols.SetHTTP1(true)
+	server.Config.Protocols.SetUnencryptedHTTP2(true)
+	server.Start()
+	defer server.Close()
+	if proto := requestProto(t, server, HTTP2Negotiated); proto != "HTTP/1.1" {
+		t.Errorf("Expected HTTP/1.1 without TLS to negotiate over, got %s", proto)
+	}
+	if proto := requestProto(t, server, HTTP2PriorKnowledge); proto != "HTTP/2.0" {
+		t.Errorf("Expected h2c with prior knowledge, got %s", proto)
+	}
+}
+
+func TestHTTP2Mode_Default(t *testing.T) {
+	factory, _ := NewProxyClientFactory(nil, 0, "test")
+	if cl := factory.Get().(*httpClient); cl.Transport != nil {
+		t.Errorf("Expected the default transport, got %v", cl.Transport)
+	}
+}
@@ -0,0 +1,187 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"context"
+	"crypto/tls"
+	"github.com/Matir/gobuster/logging"
+	"github.com/quic-go/quic-go"
+	"github.com/quic-go/quic-go/http3"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// How long to wait for a QUIC handshake before falling back, much less than
+// a request timeout as UDP is often just dropped.
+var http3HandshakeTimeout = 3 * time.Second
+
+// http3Hosts remembers which hosts HTTP/3 didn't work with, for all clients
+// of a factory, so each host is only waited on once.
+type http3Hosts struct {
+	mu     sync.Mutex
+	failed map[string]bool
+}
+
+func newHTTP3Hosts() *http3Hosts {
+	return &http3Hosts{failed: make(map[string]bool)}
+}
+
+func (h *http3Hosts) isFailed(addr string) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.failed[addr]
+}
+
+// Record that HTTP/3 failed for addr, returning whether it is the first
+// time.
+func (h *http3Hosts) fail(addr string) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.failed[addr] {
+		return false
+	}
+	h.failed[addr] = true
+	return true
+}
+
+// An http3Transport sends https:// requests over HTTP/3, and everything else
+// through next: other requests, requests to hosts HTTP/3 doesn't work with,
+// and requests that fail before a response.
+type http3Transport struct {
+	next  http.RoundTripper
+	hosts *http3Hosts
+	dial  func(context.Context, string, string) (net.Conn, error)
+	h3    *http3.Transport
+}
+
+func newHTTP3Transport(next http.RoundTripper, hosts *http3Hosts, dial func(context.Context, string, string) (net.Conn, error)) *http3Transport {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	if dial == nil {
+		dial = (&net.Dialer{}).DialContext
+	}
+	t := &http3Transport{next: next, hosts: hosts, dial: dial}
+	t.h3 = &http3.Transport{Dial: t.dialQUIC}
+	// The same client certificate and server name, if any
+	if transport, ok := next.(*http.Transport); ok && transport.TLSClientConfig != nil {
+		t.h3.TLSClientConfig = transport.TLSClientConfig
+	}
+	return t
+}
+
+func (t *http3Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.URL.Scheme != "https" || t.hosts.isFailed(http3Addr(req)) {
+		return t.next.RoundTrip(req)
+	}
+	resp, err := t.h3.RoundTrip(req)
+	if err == nil || req.Context().Err() != nil {
+		return resp, err
+	}
+	// Try once more without HTTP/3, if the body can be sent again
+	logging.Logf(logging.LogDebug, "HTTP/3 request for %s failed: %s", req.URL, err.Error())
+	if req.Body != nil && req.Body != http.NoBody {
+		if req.GetBody == nil {
+			return nil, err
+		}
+		body, bodyErr := req.GetBody()
+		if bodyErr != nil {
+			return nil, err
+		}
+		req = req.Clone(req.Context())
+		req.Body = body
+	}
+	return t.next.RoundTrip(req)
+}
+
+func (t *http3Transport) CloseIdleConnections() {
+	t.h3.CloseIdleConnections()
+	if closer, ok := t.next.(interface{ CloseIdleConnections() }); ok {
+		closer.CloseIdleConnections()
+	}
+}
+
+// The host and port a request goes to, as quic-go names it.
+func http3Addr(req *http.Request) string {
+	port := req.URL.Port()
+	if port == "" {
+		port = "443"
+	}
+	return net.JoinHostPort(req.URL.Hostname(), port)
+}
+
+// Connect to addr for quic-go, remembering hosts that can't be connected to.
+func (t *http3Transport) dialQUIC(ctx context.Context, addr string, tlsConfig *tls.Config, config *quic.Config) (*quic.Conn, error) {
+	handshakeCtx, cancel := context.WithTimeout(ctx, http3HandshakeTimeout)
+	defer cancel()
+	conn, err := t.dialConn(handshakeCtx, addr, tlsConfig, config)
+	if err != nil && ctx.Err() == nil && t.hosts.fail(addr) {
+		logging.Logf(logging.LogInfo, "Falling back from HTTP/3 for %s: %s", addr, err.Error())
+	}
+	return conn, err
+}
+
+// Dial UDP like any other connection, so -bind and -resolver apply, and
+// handshake over it.
+func (t *http3Transport) dialConn(ctx context.Context, addr string, tlsConfig *tls.Config, config *quic.Config) (*quic.Conn, error) {
+	udp, err := t.dial(ctx, "udp", addr)
+	if err != nil {
+		return nil, err
+	}
+	conn, err := quic.Dial(ctx, connectedPacketConn{udp}, udp.RemoteAddr(), tlsConfig, config)
+	if err != nil {
+		udp.Close()
+		return nil, err
+	}
+	// quic-go leaves closing a socket it was given to the caller
+	go func() {
+		<-conn.Context().Done()
+		udp.Close()
+	}()
+	return conn, nil
+}
+
+// A connected UDP socket as the net.PacketConn quic-go expects, only ever
+// talking to its remote address.
+type connectedPacketConn struct {
+	net.Conn
+}
+
+func (c connectedPacketConn) ReadFrom(p []byte) (int, net.Addr, error) {
+	n, err := c.Read(p)
+	return n, c.RemoteAddr(), err
+}
+
+func (c connectedPacketConn) WriteTo(p []byte, _ net.Addr) (int, error) {
+	return c.Write(p)
+}
+
+// Let quic-go size the socket's buffers, as it warns it can't otherwise.
+func (c connectedPacketConn) SetReadBuffer(bytes int) error {
+	if udp, ok := c.Conn.(*net.UDPConn); ok {
+		return udp.SetReadBuffer(bytes)
+	}
+	return nil
+}
+
+func (c connectedPacketConn) SetWriteBuffer(bytes int) error {
+	if udp, ok := c.Conn.(*net.UDPConn); ok {
+		return udp.SetWriteBuffer(bytes)
+	}
+	return nil
+}
@@ -0,0 +1,156 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"github.com/quic-go/quic-go/http3"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// Serve handler over HTTP/3 on the same port as a TLS test server, as a
+// server advertising it would.
+func startHTTP3Server(t *testing.T, handler http.Handler) (*httptest.Server, *http3.Server) {
+	server := httptest.NewTLSServer(handler)
+	addr := server.Listener.Addr().(*net.TCPAddr)
+	udp, err := net.ListenUDP("udp", &net.UDPAddr{IP: addr.IP, Port: addr.Port})
+	if err != nil {
+		server.Close()
+		t.Skipf("Unable to listen for QUIC: %v", err)
+	}
+	h3 := &http3.Server{Handler: handler, TLSConfig: http3.ConfigureTLSConfig(server.TLS)}
+	go h3.Serve(udp)
+	return server, h3
+}
+
+func TestHTTP3Transport_Fallback(t *testing.T) {
+	oldTimeout := http3HandshakeTimeout
+	http3HandshakeTimeout = 200 * time.Millisecond
+	defer func() { http3HandshakeTimeout = oldTimeout }()
+	var mu sync.Mutex
+	var bodies []string
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		defer mu.Unlock()
+		bodies = append(bodies, string(body))
+	}))
+	defer server.Close()
+	// Nothing answers QUIC on the server's port
+	hosts := newHTTP3Hosts()
+	cl := &http.Client{Transport: newHTTP3Transport(server.Client().Transport, hosts, nil)}
+	for i := 0; i < 2; i++ {
+		resp, err := cl.Post(server.URL, "text/plain", strings.NewReader("body"))
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		resp.Body.Close()
+		if resp.Proto != "HTTP/1.1" {
+			t.Errorf("Expected a fallback to HTTP/1.1, got %s", resp.Proto)
+		}
+	}
+	u, _ := url.Parse(server.URL)
+	if !hosts.isFailed(u.Host) {
+		t.Errorf("Expected %s to be remembered as failed.", u.Host)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if expected := []string{"body", "body"}; !reflect.DeepEqual(bodies, expected) {
+		t.Errorf("Expected bodies %v, got %v", expected, bodies)
+	}
+}
+
+func TestHTTP3Transport_Cleartext(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+	hosts := newHTTP3Hosts()
+	cl := &http.Client{Transport: newHTTP3Transport(nil, hosts, nil)}
+	resp, err := cl.Get(server.URL)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	resp.Body.Close()
+	u, _ := url.Parse(server.URL)
+	if resp.Proto != "HTTP/1.1" || hosts.isFailed(u.Host) {
+		t.Errorf("Expected http:// to skip HTTP/3, got %s", resp.Proto)
+	}
+}
+
+func TestHTTP3Transport_QUIC(t *testing.T) {
+	var mu sync.Mutex
+	var bodies []string
+	server, h3 := startHTTP3Server(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		defer mu.Unlock()
+		bodies = append(bodies, r.Proto+" "+string(body))
+	}))
+	defer server.Close()
+	defer h3.Close()
+	hosts := newHTTP3Hosts()
+	cl := &http.Client{Transport: newHTTP3Transport(server.Client().Transport, hosts, nil)}
+	resp, err := cl.Post(server.URL, "text/plain", strings.NewReader("body"))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	resp.Body.Close()
+	if resp.Proto != "HTTP/3.0" {
+		t.Errorf("Expected HTTP/3.0, got %s", resp.Proto)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if expected := []string{"HTTP/3.0 body"}; !reflect.DeepEqual(bodies, expected) {
+		t.Errorf("Expected bodies %v, got %v", expected, bodies)
+	}
+}
+
+func TestHTTP3Transport_Tarpit(t *testing.T) {
+	defer func(g, i time.Duration) { tarpitGrace, tarpitCheckInterval = g, i }(tarpitGrace, tarpitCheckInterval)
+	tarpitGrace = 10 * time.Millisecond
+	tarpitCheckInterval = 5 * time.Millisecond
+	stall := make(chan bool)
+	server, h3 := startHTTP3Server(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("a"))
+		w.(http.Flusher).Flush()
+		<-stall
+	}))
+	defer server.Close()
+	defer h3.Close()
+	// Before closing the servers, which wait for the handler
+	defer close(stall)
+	cl := &httpClient{minThroughput: 100}
+	cl.Transport = newHTTP3Transport(server.Client().Transport, newHTTP3Hosts(), nil)
+	u, _ := url.Parse(server.URL)
+	resp, err := cl.RequestURL(u)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.Proto != "HTTP/3.0" {
+		t.Errorf("Expected HTTP/3.0, got %s", resp.Proto)
+	}
+	if _, err := ioutil.ReadAll(resp.Body); err != ErrTarpit {
+		t.Errorf("Expected ErrTarpit over HTTP/3, got %v", err)
+	}
+}
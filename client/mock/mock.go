@@ -33,7 +33,13 @@ type MockClientFactory struct {
 type MockClient struct {
 	ForeverResponse *http.Response
 	NextResponse    *http.Response
+	RangeResponse   *http.Response
 	Requests        []*url.URL
+	Ranges          []string
+	Hosts           []string
+	Methods         []string
+	Bodies          []string
+	Headers         []http.Header
 	Redir           *url.URL
 	CheckRedirect   func(*http.Request, []*http.Request) error
 }
@@ -69,6 +75,28 @@ func (c *MockClient) RequestURL(u *url.URL) (*http.Response, error) {
 	return r, nil
 }
 
+func (c *MockClient) RequestRange(u *url.URL, byteRange string) (*http.Response, error) {
+	c.Ranges = append(c.Ranges, byteRange)
+	if c.RangeResponse == nil {
+		return c.RequestURL(u)
+	}
+	r := c.RangeResponse
+	c.RangeResponse = nil
+	return r, nil
+}
+
+func (c *MockClient) RequestMethod(method string, u *url.URL, header http.Header, body string) (*http.Response, error) {
+	c.Methods = append(c.Methods, method)
+	c.Bodies = append(c.Bodies, body)
+	c.Headers = append(c.Headers, header)
+	return c.RequestURL(u)
+}
+
+func (c *MockClient) RequestHost(u *url.URL, host string) (*http.Response, error) {
+	c.Hosts = append(c.Hosts, host)
+	return c.RequestURL(u)
+}
+
 func (c *MockClient) SetCheckRedirect(f func(*http.Request, []*http.Request) error) {
 	c.CheckRedirect = f
 }
@@ -0,0 +1,247 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"golang.org/x/crypto/md4"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+	"unicode/utf16"
+)
+
+// NTLM authenticates a connection rather than a request, in three messages:
+// the client's negotiate message, the server's challenge and the client's
+// response.  Only NTLMv2 responses are sent.  Servers offering Negotiate
+// (SPNEGO) are answered with NTLM too, which IIS accepts when Kerberos
+// isn't available; Kerberos itself isn't supported.
+
+const ntlmSignature = "NTLMSSP\x00"
+
+const (
+	ntlmNegotiateUnicode       = 0x00000001
+	ntlmRequestTarget          = 0x00000004
+	ntlmNegotiateNTLM          = 0x00000200
+	ntlmNegotiateAlwaysSign    = 0x00008000
+	ntlmNegotiateExtendedSec   = 0x00080000
+	ntlmNegotiateTargetInfo    = 0x00800000
+	ntlmNegotiate128           = 0x20000000
+	ntlmNegotiate56            = 0x80000000
+	ntlmDefaultFlags           = ntlmNegotiateUnicode | ntlmRequestTarget | ntlmNegotiateNTLM | ntlmNegotiateAlwaysSign | ntlmNegotiateExtendedSec | ntlmNegotiateTargetInfo | ntlmNegotiate128 | ntlmNegotiate56
+	ntlmAvEOL                  = 0
+	ntlmAvTimestamp            = 7
+	ntlmWindowsEpochDifference = 116444736000000000
+)
+
+// Makes the client challenge, replaced in tests.
+var ntlmClientChallenge = func() []byte {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return b
+}
+
+// The server's challenge message.
+type ntlmChallenge struct {
+	flags      uint32
+	challenge  []byte
+	targetInfo []byte
+}
+
+// The connection-based scheme a 401 response offers, if any.  NTLM is
+// preferred, as a Negotiate server may expect Kerberos.
+func ntlmScheme(resp *http.Response) string {
+	scheme := ""
+	for _, header := range resp.Header["Www-Authenticate"] {
+		switch strings.ToLower(strings.TrimSpace(header)) {
+		case "ntlm":
+			return "NTLM"
+		case "negotiate":
+			scheme = "Negotiate"
+		}
+	}
+	return scheme
+}
+
+// Authenticate as creds with scheme and send req.  Every message has to go
+// over the same connection, so this relies on the client's transport reusing
+// it, which it does as each response is read through.
+func (c *httpClient) ntlm(req *http.Request, creds *url.Userinfo, scheme string) (*http.Response, error) {
+	negotiate := replay(req)
+	negotiate.Header.Set("Authorization", scheme+" "+base64.StdEncoding.EncodeToString(ntlmNegotiateMessage()))
+	resp, err := c.send(negotiate)
+	if err != nil {
+		return resp, err
+	}
+	challenge := ntlmChallengeFrom(resp, scheme)
+	if challenge == nil {
+		// Refused outright, so this is the answer
+		return resp, nil
+	}
+	discard(resp)
+	user, domain := splitDomainUser(creds.Username())
+	pass, _ := creds.Password()
+	authenticate := replay(req)
+	authenticate.Header.Set("Authorization", scheme+" "+base64.StdEncoding.EncodeToString(
+		challenge.authenticateMessage(user, domain, pass, ntlmClientChallenge())))
+	return c.send(authenticate)
+}
+
+// Split DOMAIN\user, or user@domain, into the user and domain.
+func splitDomainUser(name string) (string, string) {
+	if i := strings.Index(name, `\`); i >= 0 {
+		return name[i+1:], name[:i]
+	}
+	if i := strings.LastIndex(name, "@"); i >= 0 {
+		return name[:i], name[i+1:]
+	}
+	return name, ""
+}
+
+func ntlmNegotiateMessage() []byte {
+	msg := make([]byte, 32)
+	copy(msg, ntlmSignature)
+	binary.LittleEndian.PutUint32(msg[8:], 1)
+	binary.LittleEndian.PutUint32(msg[12:], ntlmDefaultFlags)
+	// No domain or workstation, so both are empty at offset 0
+	return msg
+}
+
+// Find the challenge message in a response, or nil if there isn't one.
+func ntlmChallengeFrom(resp *http.Response, scheme string) *ntlmChallenge {
+	if resp.StatusCode != http.StatusUnauthorized {
+		return nil
+	}
+	for _, header := range resp.Header["Www-Authenticate"] {
+		pieces := strings.SplitN(strings.TrimSpace(header), " ", 2)
+		if len(pieces) != 2 || !strings.EqualFold(pieces[0], scheme) {
+			continue
+		}
+		msg, err := base64.StdEncoding.DecodeString(strings.TrimSpace(pieces[1]))
+		if err != nil {
+			continue
+		}
+		if c, err := parseNTLMChallenge(msg); err == nil {
+			return c
+		}
+	}
+	return nil
+}
+
+func parseNTLMChallenge(msg []byte) (*ntlmChallenge, error) {
+	if len(msg) < 32 || string(msg[:8]) != ntlmSignature || binary.LittleEndian.Uint32(msg[8:]) != 2 {
+		return nil, fmt.Errorf("Not an NTLM challenge message.")
+	}
+	c := &ntlmChallenge{
+		flags:     binary.LittleEndian.Uint32(msg[20:]),
+		challenge: msg[24:32],
+	}
+	if len(msg) >= 48 {
+		length := int(binary.LittleEndian.Uint16(msg[40:]))
+		offset := int(binary.LittleEndian.Uint32(msg[44:]))
+		if offset+length > len(msg) {
+			return nil, fmt.Errorf("NTLM target info is out of bounds.")
+		}
+		c.targetInfo = msg[offset : offset+length]
+	}
+	return c, nil
+}
+
+// The server's timestamp from its target info, if it sent one.
+func (c *ntlmChallenge) timestamp() []byte {
+	for info := c.targetInfo; len(info) >= 4; {
+		id := binary.LittleEndian.Uint16(info)
+		length := int(binary.LittleEndian.Uint16(info[2:]))
+		if id == ntlmAvEOL || 4+length > len(info) {
+			break
+		}
+		if id == ntlmAvTimestamp && length == 8 {
+			return info[4:12]
+		}
+		info = info[4+length:]
+	}
+	return nil
+}
+
+func (c *ntlmChallenge) authenticateMessage(user, domain, pass string, clientChallenge []byte) []byte {
+	timestamp := c.timestamp()
+	serverTime := timestamp != nil
+	if !serverTime {
+		timestamp = make([]byte, 8)
+		binary.LittleEndian.PutUint64(timestamp, uint64(time.Now().UnixNano()/100+ntlmWindowsEpochDifference))
+	}
+	nt, lm := ntlmV2Response(ntowfV2(user, pass, domain), c.challenge, clientChallenge, timestamp, c.targetInfo)
+	if serverTime {
+		// With the server's time, the LM response is left out
+		lm = make([]byte, 24)
+	}
+	payloads := [][]byte{lm, nt, utf16LE(domain), utf16LE(user), nil, nil}
+	msg := make([]byte, 64)
+	copy(msg, ntlmSignature)
+	binary.LittleEndian.PutUint32(msg[8:], 3)
+	offset := len(msg)
+	for i, payload := range payloads {
+		binary.LittleEndian.PutUint16(msg[12+i*8:], uint16(len(payload)))
+		binary.LittleEndian.PutUint16(msg[14+i*8:], uint16(len(payload)))
+		binary.LittleEndian.PutUint32(msg[16+i*8:], uint32(offset))
+		offset += len(payload)
+	}
+	binary.LittleEndian.PutUint32(msg[60:], c.flags&ntlmDefaultFlags)
+	return append(msg, bytes.Join(payloads, nil)...)
+}
+
+// The NT hash of the password, keyed to the user and domain.
+func ntowfV2(user, pass, domain string) []byte {
+	h := md4.New()
+	h.Write(utf16LE(pass))
+	return hmacMD5(h.Sum(nil), utf16LE(strings.ToUpper(user)+domain))
+}
+
+// The NTLMv2 and LMv2 responses to the server's challenge.
+func ntlmV2Response(ntowf, serverChallenge, clientChallenge, timestamp, targetInfo []byte) ([]byte, []byte) {
+	blob := bytes.Join([][]byte{
+		{1, 1, 0, 0, 0, 0, 0, 0},
+		timestamp,
+		clientChallenge,
+		{0, 0, 0, 0},
+		targetInfo,
+		{0, 0, 0, 0},
+	}, nil)
+	proof := hmacMD5(ntowf, append(append([]byte{}, serverChallenge...), blob...))
+	lm := hmacMD5(ntowf, append(append([]byte{}, serverChallenge...), clientChallenge...))
+	return append(proof, blob...), append(lm, clientChallenge...)
+}
+
+func hmacMD5(key, data []byte) []byte {
+	mac := hmac.New(md5.New, key)
+	mac.Write(data)
+	return mac.Sum(nil)
+}
+
+func utf16LE(s string) []byte {
+	units := utf16.Encode([]rune(s))
+	b := make([]byte, len(units)*2)
+	for i, u := range units {
+		binary.LittleEndian.PutUint16(b[i*2:], u)
+	}
+	return b
+}
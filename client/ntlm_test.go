@@ -0,0 +1,141 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+// The AV pairs from the examples in MS-NLMP 4.2.4.
+func exampleTargetInfo() []byte {
+	var info []byte
+	for _, pair := range []struct {
+		id    uint16
+		value string
+	}{{2, "Domain"}, {1, "Server"}} {
+		value := utf16LE(pair.value)
+		header := make([]byte, 4)
+		binary.LittleEndian.PutUint16(header, pair.id)
+		binary.LittleEndian.PutUint16(header[2:], uint16(len(value)))
+		info = append(append(info, header...), value...)
+	}
+	return append(info, 0, 0, 0, 0)
+}
+
+func TestNTLMv2Response(t *testing.T) {
+	// The examples from MS-NLMP 4.2.4
+	ntowf := ntowfV2("User", "Password", "Domain")
+	if got := hex.EncodeToString(ntowf); got != "0c868a403bfd7a93a3001ef22ef02e3f" {
+		t.Errorf("Unexpected NTOWFv2: %s", got)
+	}
+	server, _ := hex.DecodeString("0123456789abcdef")
+	client := bytes.Repeat([]byte{0xaa}, 8)
+	nt, lm := ntlmV2Response(ntowf, server, client, make([]byte, 8), exampleTargetInfo())
+	if got := hex.EncodeToString(nt[:16]); got != "68cd0ab851e51c96aabc927bebef6a1c" {
+		t.Errorf("Unexpected NTProofStr: %s", got)
+	}
+	if got := hex.EncodeToString(lm); got != "86c35097ac9cec102554764a57cccc19aaaaaaaaaaaaaaaa" {
+		t.Errorf("Unexpected LMv2 response: %s", got)
+	}
+}
+
+func TestSplitDomainUser(t *testing.T) {
+	cases := map[string][2]string{
+		`CORP\alice`:       {"alice", "CORP"},
+		"bob@corp.example": {"bob", "corp.example"},
+		"carol":            {"carol", ""},
+	}
+	for in, want := range cases {
+		if user, domain := splitDomainUser(in); user != want[0] || domain != want[1] {
+			t.Errorf("Expected %v for %s, got %s, %s", want, in, user, domain)
+		}
+	}
+}
+
+// Build a challenge message like a server would.
+func ntlmChallengeMessage(challenge, targetInfo []byte) []byte {
+	msg := make([]byte, 48)
+	copy(msg, ntlmSignature)
+	binary.LittleEndian.PutUint32(msg[8:], 2)
+	binary.LittleEndian.PutUint32(msg[20:], ntlmDefaultFlags)
+	copy(msg[24:], challenge)
+	binary.LittleEndian.PutUint16(msg[40:], uint16(len(targetInfo)))
+	binary.LittleEndian.PutUint16(msg[42:], uint16(len(targetInfo)))
+	binary.LittleEndian.PutUint32(msg[44:], 48)
+	return append(msg, targetInfo...)
+}
+
+// A payload of an authenticate message, by its field's offset.
+func ntlmField(msg []byte, field int) []byte {
+	length := int(binary.LittleEndian.Uint16(msg[field:]))
+	offset := int(binary.LittleEndian.Uint32(msg[field+4:]))
+	return msg[offset : offset+length]
+}
+
+func TestHTTPClient_NTLM(t *testing.T) {
+	for _, scheme := range []string{"NTLM", "Negotiate"} {
+		challenge := []byte("12345678")
+		var negotiatedOn, authenticated string
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			auth := strings.SplitN(r.Header.Get("Authorization"), " ", 2)
+			if len(auth) != 2 || auth[0] != scheme {
+				w.Header().Add("WWW-Authenticate", scheme)
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			msg, _ := base64.StdEncoding.DecodeString(auth[1])
+			switch binary.LittleEndian.Uint32(msg[8:]) {
+			case 1:
+				negotiatedOn = r.RemoteAddr
+				w.Header().Set("WWW-Authenticate", scheme+" "+base64.StdEncoding.EncodeToString(ntlmChallengeMessage(challenge, exampleTargetInfo())))
+				w.WriteHeader(http.StatusUnauthorized)
+			case 3:
+				if r.RemoteAddr != negotiatedOn {
+					t.Errorf("Expected the same connection, got %s and %s", negotiatedOn, r.RemoteAddr)
+				}
+				user := ntlmField(msg, 36)
+				domain := ntlmField(msg, 28)
+				nt := ntlmField(msg, 20)
+				want := hmacMD5(ntowfV2("alice", "secret", "CORP"), append(append([]byte{}, challenge...), nt[16:]...))
+				if !bytes.Equal(user, utf16LE("alice")) || !bytes.Equal(domain, utf16LE("CORP")) || !bytes.Equal(nt[:16], want) {
+					w.WriteHeader(http.StatusUnauthorized)
+					return
+				}
+				authenticated = r.URL.Path
+			}
+		}))
+		u, _ := url.Parse(server.URL + "/intranet")
+		creds, _ := ParseCredentials([]string{`CORP\alice:secret`})
+		factory, _ := NewProxyClientFactory(nil, 0, "test")
+		factory.SetCredentials(creds)
+		resp, err := factory.Get().RequestURL(u)
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK || authenticated != "/intranet" {
+			t.Errorf("Expected %s authentication to succeed, got %d", scheme, resp.StatusCode)
+		}
+		server.Close()
+	}
+}
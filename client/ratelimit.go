@@ -0,0 +1,123 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"encoding/json"
+	"github.com/Matir/gobuster/logging"
+	"io/ioutil"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RateLimiter spaces out the requests to each host.  With a schedule file,
+// the spacing is shared with every other process using the same file, so
+// several scans of one host stay under the rate combined.  A nil RateLimiter
+// doesn't limit anything.
+type RateLimiter struct {
+	// Time between requests to a host
+	interval time.Duration
+	// Schedule shared with other processes, if any
+	path string
+	// Next free time for each host, when not shared
+	next map[string]time.Time
+	// Whether the schedule file has failed, to warn once
+	failed bool
+	sync.Mutex
+}
+
+func NewRateLimiter(perSec float64, path string) *RateLimiter {
+	return &RateLimiter{
+		interval: time.Duration(float64(time.Second) / perSec),
+		path:     path,
+		next:     make(map[string]time.Time),
+	}
+}
+
+// Wait for a turn to send a request to host.
+func (l *RateLimiter) Wait(host string) {
+	if l == nil {
+		return
+	}
+	time.Sleep(l.reserve(strings.ToLower(host), time.Now()))
+}
+
+// Reserve the next free time for host and return how long until then.
+func (l *RateLimiter) reserve(host string, now time.Time) time.Duration {
+	l.Lock()
+	defer l.Unlock()
+	if l.path != "" && !l.failed {
+		wait, err := l.reserveShared(host, now)
+		if err == nil {
+			return wait
+		}
+		logging.Logf(logging.LogWarning, "Unable to share the request rate through %s, limiting this scan alone: %s", l.path, err.Error())
+		l.failed = true
+	}
+	slot := l.next[host]
+	if slot.Before(now) {
+		slot = now
+	}
+	l.next[host] = slot.Add(l.interval)
+	return slot.Sub(now)
+}
+
+// Reserve a time in the schedule file, which maps hosts to the next free
+// time in Unix nanoseconds.  The file is locked throughout, so each process
+// sees the others' reservations.
+func (l *RateLimiter) reserveShared(host string, now time.Time) (time.Duration, error) {
+	fp, err := os.OpenFile(l.path, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return 0, err
+	}
+	defer fp.Close()
+	if err := lockFile(fp); err != nil {
+		return 0, err
+	}
+	defer unlockFile(fp)
+	data, err := ioutil.ReadAll(fp)
+	if err != nil {
+		return 0, err
+	}
+	schedule := make(map[string]int64)
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &schedule); err != nil {
+			return 0, err
+		}
+	}
+	slot := time.Unix(0, schedule[host])
+	if slot.Before(now) {
+		slot = now
+	}
+	for h, next := range schedule {
+		// Times already past constrain nobody
+		if next < now.UnixNano() {
+			delete(schedule, h)
+		}
+	}
+	schedule[host] = slot.Add(l.interval).UnixNano()
+	if data, err = json.Marshal(schedule); err != nil {
+		return 0, err
+	}
+	if err := fp.Truncate(0); err != nil {
+		return 0, err
+	}
+	if _, err := fp.WriteAt(data, 0); err != nil {
+		return 0, err
+	}
+	return slot.Sub(now), nil
+}
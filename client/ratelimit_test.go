@@ -0,0 +1,75 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestRateLimiter_Reserve(t *testing.T) {
+	l := NewRateLimiter(10, "")
+	now := time.Now()
+	for i, want := range []time.Duration{0, 100 * time.Millisecond, 200 * time.Millisecond} {
+		if got := l.reserve("example.com", now); got != want {
+			t.Errorf("Expected request %d to wait %s, got %s", i, want, got)
+		}
+	}
+	if got := l.reserve("other.example.com", now); got != 0 {
+		t.Errorf("Expected hosts to be limited separately, got %s", got)
+	}
+	if got := l.reserve("example.com", now.Add(time.Second)); got != 0 {
+		t.Errorf("Expected no wait once the schedule has passed, got %s", got)
+	}
+	var nilLimiter *RateLimiter
+	nilLimiter.Wait("example.com")
+}
+
+func TestRateLimiter_Shared(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gobuster-rate")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "rate.json")
+	// As if in two processes
+	a := NewRateLimiter(10, path)
+	b := NewRateLimiter(10, path)
+	now := time.Now()
+	var waits []time.Duration
+	for _, l := range []*RateLimiter{a, b, a, b} {
+		waits = append(waits, l.reserve("example.com", now))
+	}
+	for i, got := range waits {
+		if want := time.Duration(i) * 100 * time.Millisecond; got != want {
+			t.Errorf("Expected request %d to wait %s, got %s", i, want, got)
+		}
+	}
+	if a.failed || b.failed {
+		t.Error("Expected the schedule file to be shared.")
+	}
+}
+
+func TestRateLimiter_SharedFallback(t *testing.T) {
+	l := NewRateLimiter(10, filepath.Join(os.TempDir(), "gobuster-no-such-dir", "rate.json"))
+	now := time.Now()
+	l.reserve("example.com", now)
+	if got := l.reserve("example.com", now); got != 100*time.Millisecond || !l.failed {
+		t.Errorf("Expected to fall back to limiting alone, got %s", got)
+	}
+}
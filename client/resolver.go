@@ -0,0 +1,193 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Resolver resolves target hostnames ahead of time and caches the results, so
+// the first wave of requests doesn't serialize on DNS lookups.
+type Resolver struct {
+	// Cached addresses by hostname
+	addrs map[string][]string
+	// Function used for lookups
+	lookup func(string) ([]string, error)
+	// Timeout for dialing
+	timeout time.Duration
+	// Optional local addresses to dial from
+	local *LocalAddrs
+	sync.RWMutex
+}
+
+func NewResolver(timeout time.Duration) *Resolver {
+	return &Resolver{
+		addrs:   make(map[string][]string),
+		lookup:  net.LookupHost,
+		timeout: timeout,
+	}
+}
+
+// Resolve through a DNS server rather than the system resolver.
+func (r *Resolver) SetServer(server string) {
+	r.lookup = NewLookup(server, r.timeout)
+}
+
+// Dial from the given local addresses.
+func (r *Resolver) SetLocalAddrs(local *LocalAddrs) {
+	r.local = local
+}
+
+// Build a function looking up hosts through a DNS server (host or host:port),
+// or the system resolver if server is empty.
+func NewLookup(server string, timeout time.Duration) func(string) ([]string, error) {
+	if server == "" {
+		return net.LookupHost
+	}
+	resolver := newResolver(server, timeout)
+	return func(host string) ([]string, error) {
+		return resolver.LookupHost(context.Background(), host)
+	}
+}
+
+// Build a function looking up records of a type (CNAME, MX, NS, SRV or TXT)
+// for a name, like NewLookup.  Records are given as "TYPE data", e.g.
+// "MX 10 mail.example.com".
+func NewRecordLookup(server string, timeout time.Duration) func(string, string) ([]string, error) {
+	resolver := net.DefaultResolver
+	if server != "" {
+		resolver = newResolver(server, timeout)
+	}
+	return func(name, rtype string) ([]string, error) {
+		ctx := context.Background()
+		var records []string
+		switch rtype {
+		case "CNAME":
+			cname, err := resolver.LookupCNAME(ctx, name)
+			if err != nil {
+				return nil, err
+			}
+			// Names without an alias are their own canonical name
+			if cname = strings.TrimSuffix(cname, "."); cname != strings.TrimSuffix(name, ".") {
+				records = append(records, "CNAME "+cname)
+			}
+		case "MX":
+			mxs, err := resolver.LookupMX(ctx, name)
+			if err != nil {
+				return nil, err
+			}
+			for _, mx := range mxs {
+				records = append(records, fmt.Sprintf("MX %d %s", mx.Pref, strings.TrimSuffix(mx.Host, ".")))
+			}
+		case "NS":
+			nss, err := resolver.LookupNS(ctx, name)
+			if err != nil {
+				return nil, err
+			}
+			for _, ns := range nss {
+				records = append(records, "NS "+strings.TrimSuffix(ns.Host, "."))
+			}
+		case "SRV":
+			_, srvs, err := resolver.LookupSRV(ctx, "", "", name)
+			if err != nil {
+				return nil, err
+			}
+			for _, srv := range srvs {
+				records = append(records, fmt.Sprintf("SRV %d %d %d %s", srv.Priority, srv.Weight, srv.Port, strings.TrimSuffix(srv.Target, ".")))
+			}
+		case "TXT":
+			txts, err := resolver.LookupTXT(ctx, name)
+			if err != nil {
+				return nil, err
+			}
+			for _, txt := range txts {
+				records = append(records, "TXT "+txt)
+			}
+		default:
+			return nil, fmt.Errorf("Unsupported record type %s.", rtype)
+		}
+		return records, nil
+	}
+}
+
+// A resolver querying server (host or host:port).
+func newResolver(server string, timeout time.Duration) *net.Resolver {
+	if _, _, err := net.SplitHostPort(server); err != nil {
+		server = net.JoinHostPort(server, "53")
+	}
+	return &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, network, _ string) (net.Conn, error) {
+			dialer := &net.Dialer{Timeout: timeout}
+			return dialer.DialContext(ctx, network, server)
+		},
+	}
+}
+
+// Resolve all of the hosts concurrently, returning errors by hostname for any
+// that failed.
+func (r *Resolver) Resolve(hosts []string) map[string]error {
+	errs := make(map[string]error)
+	var wg sync.WaitGroup
+	var errLock sync.Mutex
+	for _, host := range hosts {
+		if net.ParseIP(host) != nil {
+			continue
+		}
+		wg.Add(1)
+		go func(host string) {
+			defer wg.Done()
+			addrs, err := r.lookup(host)
+			if err != nil {
+				errLock.Lock()
+				errs[host] = err
+				errLock.Unlock()
+				return
+			}
+			r.Lock()
+			r.addrs[host] = addrs
+			r.Unlock()
+		}(host)
+	}
+	wg.Wait()
+	return errs
+}
+
+// Get the cached addresses for a host, if any.
+func (r *Resolver) Addrs(host string) []string {
+	r.RLock()
+	defer r.RUnlock()
+	return r.addrs[host]
+}
+
+// Dial using cached addresses where available.  Satisfies the Dial field of
+// http.Transport.
+func (r *Resolver) Dial(network, addr string) (net.Conn, error) {
+	dial := r.local.DialContext(&net.Dialer{Timeout: r.timeout, FallbackDelay: connectionAttemptDelay})
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return dial(context.Background(), network, addr)
+	}
+	cached := r.Addrs(host)
+	if len(cached) == 0 {
+		return dial(context.Background(), network, addr)
+	}
+	return dialHappyEyeballs(dial, network, cached, port)
+}
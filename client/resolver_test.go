@@ -0,0 +1,134 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"errors"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestResolver_Resolve(t *testing.T) {
+	r := NewResolver(time.Second)
+	r.lookup = func(host string) ([]string, error) {
+		if host == "bad.example" {
+			return nil, errors.New("no such host")
+		}
+		return []string{"127.0.0.1"}, nil
+	}
+	errs := r.Resolve([]string{"good.example", "bad.example", "10.0.0.1"})
+	if len(errs) != 1 {
+		t.Errorf("Expected 1 error, got %v", errs)
+	}
+	if _, ok := errs["bad.example"]; !ok {
+		t.Error("Expected error for bad.example.")
+	}
+	if addrs := r.Addrs("good.example"); len(addrs) != 1 || addrs[0] != "127.0.0.1" {
+		t.Errorf("Expected cached address, got %v", addrs)
+	}
+	if addrs := r.Addrs("10.0.0.1"); addrs != nil {
+		t.Errorf("Expected IP addresses not to be resolved, got %v", addrs)
+	}
+}
+
+func TestResolver_Dial(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Skipf("Unable to listen: %v", err)
+	}
+	defer l.Close()
+	_, port, _ := net.SplitHostPort(l.Addr().String())
+	r := NewResolver(time.Second)
+	r.addrs["target.example"] = []string{"127.0.0.1"}
+	conn, err := r.Dial("tcp", net.JoinHostPort("target.example", port))
+	if err != nil {
+		t.Fatalf("Expected to dial cached address, got %v", err)
+	}
+	conn.Close()
+}
+
+// Answer A queries for found.example with 192.0.2.1 and MX queries with
+// mail.found.example, and everything else with no records.
+func fakeDNSServer(t *testing.T) (string, func()) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Skipf("Unable to listen: %v", err)
+	}
+	go func() {
+		buf := make([]byte, 512)
+		for {
+			n, addr, err := conn.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			query := buf[:n]
+			// Question name, then type and class
+			end := 12
+			for end < n && query[end] != 0 {
+				end += int(query[end]) + 1
+			}
+			end += 5
+			if end > n {
+				continue
+			}
+			qtype := int(query[end-4])<<8 | int(query[end-3])
+			found := strings.HasPrefix(string(query[12:end]), "\x05found\x07example\x00")
+			resp := append([]byte{}, query[:end]...)
+			resp[2], resp[3] = 0x81, 0x80
+			if !found {
+				resp[3] = 0x83
+			}
+			resp[6], resp[7], resp[10], resp[11] = 0, 0, 0, 0
+			if found && qtype == 1 {
+				resp[7] = 1
+				resp = append(resp, 0xc0, 12, 0, 1, 0, 1, 0, 0, 0, 60, 0, 4, 192, 0, 2, 1)
+			} else if found && qtype == 15 {
+				resp[7] = 1
+				resp = append(resp, 0xc0, 12, 0, 15, 0, 1, 0, 0, 0, 60, 0, 9, 0, 10, 4, 'm', 'a', 'i', 'l', 0xc0, 12)
+			}
+			conn.WriteTo(resp, addr)
+		}
+	}()
+	return conn.LocalAddr().String(), func() { conn.Close() }
+}
+
+func TestNewLookup(t *testing.T) {
+	server, stop := fakeDNSServer(t)
+	defer stop()
+	lookup := NewLookup(server, time.Second)
+	if addrs, err := lookup("found.example"); err != nil || len(addrs) != 1 || addrs[0] != "192.0.2.1" {
+		t.Errorf("Expected 192.0.2.1, got %v %v", addrs, err)
+	}
+	if addrs, err := lookup("missing.example"); err == nil {
+		t.Errorf("Expected error for missing host, got %v", addrs)
+	}
+}
+
+func TestNewRecordLookup(t *testing.T) {
+	server, stop := fakeDNSServer(t)
+	defer stop()
+	lookup := NewRecordLookup(server, time.Second)
+	if records, err := lookup("found.example", "MX"); err != nil || len(records) != 1 || records[0] != "MX 10 mail.found.example" {
+		t.Errorf("Expected MX 10 mail.found.example, got %v %v", records, err)
+	}
+	if records, err := lookup("missing.example", "MX"); err == nil {
+		t.Errorf("Expected error for missing host, got %v", records)
+	}
+	if _, err := lookup("found.example", "HINFO"); err == nil {
+		t.Error("Expected error for an unsupported type.")
+	}
+}
@@ -0,0 +1,61 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"net/http"
+	"net/url"
+	"sync/atomic"
+)
+
+// A rotatingTransport sends each request through the next of several proxies
+// in turn, so requests come from as many addresses as there are proxies.
+// Handshakes that authenticate a connection, like NTLM, can't work through
+// it.
+type rotatingTransport struct {
+	transports []*http.Transport
+	next       uint32
+}
+
+func newRotatingTransport(proxies []*url.URL) *rotatingTransport {
+	t := &rotatingTransport{}
+	for _, proxy := range proxies {
+		t.transports = append(t.transports, transportForProxy(proxy))
+	}
+	return t
+}
+
+func (t *rotatingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	i := atomic.AddUint32(&t.next, 1) - 1
+	return t.transports[int(i%uint32(len(t.transports)))].RoundTrip(req)
+}
+
+func (t *rotatingTransport) CloseIdleConnections() {
+	for _, transport := range t.transports {
+		transport.CloseIdleConnections()
+	}
+}
+
+// Configure rt, or each of the transports it rotates between.
+func eachTransport(rt http.RoundTripper, configure func(*http.Transport)) {
+	switch t := rt.(type) {
+	case *http.Transport:
+		configure(t)
+	case *rotatingTransport:
+		for _, transport := range t.transports {
+			configure(transport)
+		}
+	}
+}
@@ -0,0 +1,114 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// Two HTTP proxies that record which of them ("a" or "b") each request went
+// through.
+func rotationProxies(t *testing.T) (*ProxyClientFactory, func() string, func()) {
+	var mu sync.Mutex
+	var seen []string
+	handler := func(name string) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			mu.Lock()
+			defer mu.Unlock()
+			seen = append(seen, name)
+		})
+	}
+	a := httptest.NewServer(handler("a"))
+	b := httptest.NewServer(handler("b"))
+	fac, err := NewProxyClientFactory([]string{a.URL, b.URL}, time.Second, "")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	got := func() string {
+		mu.Lock()
+		defer mu.Unlock()
+		return strings.Join(seen, "")
+	}
+	return fac, got, func() {
+		a.Close()
+		b.Close()
+	}
+}
+
+func requestTimes(t *testing.T, cl Client, n int) {
+	u, _ := url.Parse("http://target.example/")
+	for i := 0; i < n; i++ {
+		resp, err := cl.RequestURL(u)
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		resp.Body.Close()
+	}
+}
+
+func TestPCFGet_ProxyPerClient(t *testing.T) {
+	fac, got, closer := rotationProxies(t)
+	defer closer()
+	requestTimes(t, fac.Get(), 2)
+	requestTimes(t, fac.Get(), 2)
+	requestTimes(t, fac.Get(), 1)
+	if res := got(); res != "aabba" {
+		t.Errorf("Expected aabba, got %s", res)
+	}
+}
+
+func TestPCFGet_ProxyPerRequest(t *testing.T) {
+	fac, got, closer := rotationProxies(t)
+	defer closer()
+	fac.SetProxyPerRequest(true)
+	requestTimes(t, fac.Get(), 3)
+	requestTimes(t, fac.Get(), 1)
+	if res := got(); res != "abaa" {
+		t.Errorf("Expected abaa, got %s", res)
+	}
+}
+
+func TestPCFGet_ProxyPerRequestWithCredentials(t *testing.T) {
+	fac, got, closer := rotationProxies(t)
+	defer closer()
+	fac.SetProxyPerRequest(true)
+	creds, _ := ParseCredentials([]string{"user:pass"})
+	fac.SetCredentials(creds)
+	requestTimes(t, fac.Get(), 3)
+	requestTimes(t, fac.Get(), 1)
+	if res := got(); res != "aaab" {
+		t.Errorf("Expected each client to keep its proxy, got %s", res)
+	}
+}
+
+func TestEachTransport(t *testing.T) {
+	proxies := []*url.URL{{Scheme: "http", Host: "a:8080"}, {Scheme: "socks5", Host: "b:1080"}}
+	rt := newRotatingTransport(proxies)
+	eachTransport(rt, func(transport *http.Transport) {
+		transport.TLSClientConfig = &tls.Config{ServerName: "target.example"}
+	})
+	for i, transport := range rt.transports {
+		if transport.TLSClientConfig == nil || transport.TLSClientConfig.ServerName != "target.example" {
+			t.Errorf("Expected TLS config on transport %d, got %+v", i, transport.TLSClientConfig)
+		}
+	}
+}
@@ -0,0 +1,123 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"errors"
+	"io"
+	"sync"
+	"time"
+)
+
+// Returned when a body arrives too slowly to be worth waiting for.
+var ErrTarpit = errors.New("Response body below minimum throughput.")
+
+// How long a body may take to get going before throughput is enforced.
+var tarpitGrace = 5 * time.Second
+
+// How often throughput is checked.
+var tarpitCheckInterval = time.Second
+
+// throughputBody aborts reads when the body falls below a minimum rate.  The
+// underlying body is closed from a watchdog so that blocked reads return.
+// Time spent paused, e.g. waiting on a bandwidth limit, does not count.
+type throughputBody struct {
+	io.ReadCloser
+	// Bytes per second
+	minRate int64
+	start   time.Time
+	read    int64
+	tarpit  bool
+	// Total time paused, and the start of the current pause
+	paused   time.Duration
+	pausedAt time.Time
+	done     chan bool
+	once     sync.Once
+	sync.Mutex
+}
+
+func newThroughputBody(body io.ReadCloser, minRate int64) *throughputBody {
+	b := &throughputBody{
+		ReadCloser: body,
+		minRate:    minRate,
+		start:      time.Now(),
+		done:       make(chan bool),
+	}
+	go b.watch()
+	return b
+}
+
+func (b *throughputBody) watch() {
+	ticker := time.NewTicker(tarpitCheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-b.done:
+			return
+		case <-ticker.C:
+			if b.tooSlow() {
+				b.ReadCloser.Close()
+				return
+			}
+		}
+	}
+}
+
+func (b *throughputBody) tooSlow() bool {
+	b.Lock()
+	defer b.Unlock()
+	elapsed := time.Since(b.start) - b.paused
+	if !b.pausedAt.IsZero() {
+		elapsed -= time.Since(b.pausedAt)
+	}
+	if elapsed < tarpitGrace {
+		return false
+	}
+	if float64(b.read) < elapsed.Seconds()*float64(b.minRate) {
+		b.tarpit = true
+	}
+	return b.tarpit
+}
+
+func (b *throughputBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.Lock()
+	defer b.Unlock()
+	b.read += int64(n)
+	if b.tarpit {
+		return n, ErrTarpit
+	}
+	return n, err
+}
+
+// Stop the clock while the reader is held up by something other than the
+// server.
+func (b *throughputBody) pause() {
+	b.Lock()
+	defer b.Unlock()
+	b.pausedAt = time.Now()
+}
+
+func (b *throughputBody) resume() {
+	b.Lock()
+	defer b.Unlock()
+	b.paused += time.Since(b.pausedAt)
+	b.pausedAt = time.Time{}
+}
+
+func (b *throughputBody) Close() error {
+	b.once.Do(func() { close(b.done) })
+	return b.ReadCloser.Close()
+}
@@ -0,0 +1,79 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"io"
+	"io/ioutil"
+	"strings"
+	"testing"
+	"time"
+)
+
+// A body that never produces anything until closed.
+type stalledBody struct {
+	closed chan bool
+}
+
+func (s *stalledBody) Read(_ []byte) (int, error) {
+	<-s.closed
+	return 0, io.ErrUnexpectedEOF
+}
+
+func (s *stalledBody) Close() error {
+	select {
+	case <-s.closed:
+	default:
+		close(s.closed)
+	}
+	return nil
+}
+
+func TestThroughputBody_Tarpit(t *testing.T) {
+	defer func(g, i time.Duration) { tarpitGrace, tarpitCheckInterval = g, i }(tarpitGrace, tarpitCheckInterval)
+	tarpitGrace = 10 * time.Millisecond
+	tarpitCheckInterval = 5 * time.Millisecond
+	body := newThroughputBody(&stalledBody{closed: make(chan bool)}, 100)
+	defer body.Close()
+	if _, err := ioutil.ReadAll(body); err != ErrTarpit {
+		t.Errorf("Expected tarpit error, got %v", err)
+	}
+}
+
+func TestThroughputBody_Fast(t *testing.T) {
+	body := newThroughputBody(ioutil.NopCloser(strings.NewReader("hello")), 100)
+	buf, err := ioutil.ReadAll(body)
+	if err != nil || string(buf) != "hello" {
+		t.Errorf("Unexpected read: %q, %v", buf, err)
+	}
+	body.Close()
+	body.Close()
+}
+
+func TestThroughputBody_Paused(t *testing.T) {
+	defer func(g time.Duration) { tarpitGrace = g }(tarpitGrace)
+	tarpitGrace = 10 * time.Millisecond
+	body := newThroughputBody(ioutil.NopCloser(strings.NewReader("hello")), 1)
+	defer body.Close()
+	body.pause()
+	time.Sleep(20 * time.Millisecond)
+	if body.tooSlow() {
+		t.Error("Expected time paused not to count.")
+	}
+	body.resume()
+	if body.tooSlow() {
+		t.Error("Expected time paused not to count after resuming.")
+	}
+}
@@ -0,0 +1,92 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"io"
+	"sync"
+	"time"
+)
+
+// BandwidthLimiter limits the total rate at which response bodies are read,
+// across all clients sharing it.  It is a token bucket holding at most one
+// second's worth of bytes.
+type BandwidthLimiter struct {
+	// Bytes per second
+	rate int64
+	// Bytes currently available (may go negative when reserved)
+	tokens float64
+	// Last refill
+	last time.Time
+	sync.Mutex
+}
+
+func NewBandwidthLimiter(bytesPerSec int64) *BandwidthLimiter {
+	return &BandwidthLimiter{
+		rate:   bytesPerSec,
+		tokens: float64(bytesPerSec),
+		last:   time.Now(),
+	}
+}
+
+// Account for n bytes, sleeping until they are within the limit.
+func (l *BandwidthLimiter) Wait(n int) {
+	time.Sleep(l.reserve(n))
+}
+
+// Reserve n bytes and return how long to wait before using them.
+func (l *BandwidthLimiter) reserve(n int) time.Duration {
+	l.Lock()
+	defer l.Unlock()
+	now := time.Now()
+	l.tokens += now.Sub(l.last).Seconds() * float64(l.rate)
+	if l.tokens > float64(l.rate) {
+		l.tokens = float64(l.rate)
+	}
+	l.last = now
+	l.tokens -= float64(n)
+	if l.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-l.tokens / float64(l.rate) * float64(time.Second))
+}
+
+// Wrap a body so reads from it are limited.
+func (l *BandwidthLimiter) Wrap(body io.ReadCloser) io.ReadCloser {
+	return &throttledBody{ReadCloser: body, limiter: l}
+}
+
+type throttledBody struct {
+	io.ReadCloser
+	limiter *BandwidthLimiter
+	// Throughput check to hold while waiting, if any
+	throughput *throughputBody
+}
+
+func (b *throttledBody) Read(p []byte) (int, error) {
+	// Keep individual reads small enough to smooth out the rate
+	if int64(len(p)) > b.limiter.rate {
+		p = p[:b.limiter.rate]
+	}
+	n, err := b.ReadCloser.Read(p)
+	if n > 0 {
+		if b.throughput != nil {
+			b.throughput.pause()
+			defer b.throughput.resume()
+		}
+		b.limiter.Wait(n)
+	}
+	return n, err
+}
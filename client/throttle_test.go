@@ -0,0 +1,53 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"bytes"
+	"io/ioutil"
+	"testing"
+	"time"
+)
+
+func TestBandwidthLimiter_Reserve(t *testing.T) {
+	l := NewBandwidthLimiter(100)
+	if d := l.reserve(100); d != 0 {
+		t.Errorf("Expected no wait within burst, got %v", d)
+	}
+	if d := l.reserve(50); d < 400*time.Millisecond {
+		t.Errorf("Expected to wait ~500ms, got %v", d)
+	}
+}
+
+func TestBandwidthLimiter_Wrap(t *testing.T) {
+	l := NewBandwidthLimiter(1024 * 1024)
+	body := ioutil.NopCloser(bytes.NewBufferString("hello world"))
+	data, err := ioutil.ReadAll(l.Wrap(body))
+	if err != nil {
+		t.Fatalf("Unexpected error reading: %v", err)
+	}
+	if string(data) != "hello world" {
+		t.Errorf("Unexpected body: %q", data)
+	}
+}
+
+func TestPCFGet_Limiter(t *testing.T) {
+	fac, _ := NewProxyClientFactory([]string{}, time.Nanosecond, "")
+	l := NewBandwidthLimiter(1)
+	fac.SetBandwidthLimiter(l)
+	if cli := fac.Get().(*httpClient); cli.limiter != l {
+		t.Error("Expected client to share factory limiter.")
+	}
+}
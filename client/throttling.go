@@ -0,0 +1,216 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"fmt"
+	"github.com/Matir/gobuster/logging"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Ways a host may count requests against its rate limit.
+const (
+	ThrottlePerIP      = "per-IP"
+	ThrottlePerSession = "per-session"
+)
+
+// ThrottleProfiler watches responses for throttling, a 429 or a 503 with
+// Retry-After, and works out the rate limit policy each host seems to have:
+// how many requests it allows, over what window, and whether it counts them
+// by address or by session.  Sessions are told apart by their cookies and
+// credentials.
+type ThrottleProfiler struct {
+	hosts map[string]*throttleHost
+	sync.Mutex
+}
+
+type throttleHost struct {
+	// Requests since throttling last ended, or since the first
+	count int
+	// Requests before the first throttle
+	threshold int
+	// Throttled responses
+	throttled int
+	// Whether the host is throttling now, and since when
+	throttling bool
+	since      time.Time
+	// Longest time throttling lasted before requests got through again
+	recovery time.Duration
+	// Sessions throttled, and those answered while others were throttled
+	throttledSessions map[string]bool
+	openSessions      map[string]bool
+	// Policy stated in response headers, if any
+	limit      int
+	window     time.Duration
+	retryAfter time.Duration
+}
+
+// ThrottlePolicy is what is known of one host's rate limit.
+type ThrottlePolicy struct {
+	Host string
+	// Throttled responses seen
+	Throttled int
+	// Requests allowed before throttling, 0 if unknown
+	Threshold int
+	// Period the requests are counted over, 0 if unknown
+	Window time.Duration
+	// ThrottlePerIP, ThrottlePerSession or empty if unknown
+	Scope string
+}
+
+func NewThrottleProfiler() *ThrottleProfiler {
+	return &ThrottleProfiler{hosts: make(map[string]*throttleHost)}
+}
+
+// Note the response to a request.
+func (p *ThrottleProfiler) Record(resp *http.Response) {
+	if p == nil || resp == nil || resp.Request == nil {
+		return
+	}
+	p.record(resp, time.Now())
+}
+
+func (p *ThrottleProfiler) record(resp *http.Response, now time.Time) {
+	p.Lock()
+	defer p.Unlock()
+	host := resp.Request.URL.Host
+	h, ok := p.hosts[host]
+	if !ok {
+		h = &throttleHost{throttledSessions: make(map[string]bool), openSessions: make(map[string]bool)}
+		p.hosts[host] = h
+	}
+	h.statedPolicy(resp.Header)
+	session := resp.Request.Header.Get("Cookie") + "\x00" + resp.Request.Header.Get("Authorization")
+	h.count++
+	if isThrottled(resp) {
+		if h.throttled == 0 {
+			h.threshold = h.count - 1
+			logging.Logf(logging.LogWarning, "%s is throttling requests, consider -max-rate.", host)
+		}
+		if !h.throttling {
+			h.throttling = true
+			h.since = now
+		}
+		h.throttled++
+		h.throttledSessions[session] = true
+		return
+	}
+	if !h.throttling {
+		return
+	}
+	if !h.throttledSessions[session] {
+		// Another session got through while this host was throttling
+		h.openSessions[session] = true
+		return
+	}
+	if d := now.Sub(h.since); d > h.recovery {
+		h.recovery = d
+	}
+	h.throttling = false
+	h.count = 0
+}
+
+// Take note of rate limit headers, as in the IETF RateLimit header fields
+// draft and the X-RateLimit headers that preceded them.
+func (h *throttleHost) statedPolicy(header http.Header) {
+	for _, name := range []string{"RateLimit-Limit", "X-RateLimit-Limit", "X-Rate-Limit-Limit"} {
+		if v := header.Get(name); v != "" {
+			// May be followed by policies, e.g. "10, 10;w=1"
+			if n, err := strconv.Atoi(strings.TrimSpace(strings.SplitN(v, ",", 2)[0])); err == nil && n > 0 {
+				h.limit = n
+			}
+			break
+		}
+	}
+	// e.g. "100;w=60"
+	if v := header.Get("RateLimit-Policy"); v != "" {
+		parts := strings.Split(strings.SplitN(v, ",", 2)[0], ";")
+		if n, err := strconv.Atoi(strings.TrimSpace(parts[0])); err == nil && n > 0 {
+			h.limit = n
+		}
+		for _, param := range parts[1:] {
+			param = strings.TrimSpace(param)
+			if strings.HasPrefix(param, "w=") {
+				if secs, err := strconv.Atoi(param[2:]); err == nil && secs > 0 {
+					h.window = time.Duration(secs) * time.Second
+				}
+			}
+		}
+	}
+	if v := header.Get("Retry-After"); v != "" {
+		if secs, err := strconv.Atoi(strings.TrimSpace(v)); err == nil && secs > 0 {
+			h.retryAfter = time.Duration(secs) * time.Second
+		}
+	}
+}
+
+func isThrottled(resp *http.Response) bool {
+	return resp.StatusCode == http.StatusTooManyRequests ||
+		(resp.StatusCode == http.StatusServiceUnavailable && resp.Header.Get("Retry-After") != "")
+}
+
+// Policies of the hosts that throttled requests, by host.
+func (p *ThrottleProfiler) Policies() []ThrottlePolicy {
+	if p == nil {
+		return nil
+	}
+	p.Lock()
+	defer p.Unlock()
+	var policies []ThrottlePolicy
+	for host, h := range p.hosts {
+		if h.throttled == 0 {
+			continue
+		}
+		policy := ThrottlePolicy{Host: host, Throttled: h.throttled, Threshold: h.threshold, Window: h.window}
+		if h.limit > 0 {
+			policy.Threshold = h.limit
+		}
+		if policy.Window == 0 {
+			// How long it took to recover is the best guess, then how long
+			// the host asked for
+			policy.Window = h.recovery
+			if policy.Window == 0 {
+				policy.Window = h.retryAfter
+			}
+		}
+		if len(h.openSessions) > 0 {
+			policy.Scope = ThrottlePerSession
+		} else if len(h.throttledSessions) > 1 {
+			policy.Scope = ThrottlePerIP
+		}
+		policies = append(policies, policy)
+	}
+	sort.Slice(policies, func(i, j int) bool { return policies[i].Host < policies[j].Host })
+	return policies
+}
+
+func (p ThrottlePolicy) String() string {
+	threshold, window, scope := "unknown", "unknown", "unknown"
+	if p.Threshold > 0 {
+		threshold = fmt.Sprintf("%d requests", p.Threshold)
+	}
+	if p.Window > 0 {
+		window = p.Window.Round(time.Second).String()
+	}
+	if p.Scope != "" {
+		scope = p.Scope
+	}
+	return fmt.Sprintf("%s throttled %d requests: threshold %s, window %s, counted %s", p.Host, p.Throttled, threshold, window, scope)
+}
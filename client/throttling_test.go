@@ -0,0 +1,111 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func throttleResponse(code int, cookie string, header http.Header) *http.Response {
+	req, _ := http.NewRequest("GET", "http://target.example/", nil)
+	if cookie != "" {
+		req.Header.Set("Cookie", cookie)
+	}
+	if header == nil {
+		header = make(http.Header)
+	}
+	return &http.Response{StatusCode: code, Header: header, Request: req}
+}
+
+func TestThrottleProfiler_Observed(t *testing.T) {
+	p := NewThrottleProfiler()
+	start := time.Now()
+	for i := 0; i < 5; i++ {
+		p.record(throttleResponse(200, "s=a", nil), start)
+	}
+	p.record(throttleResponse(429, "s=a", nil), start.Add(time.Second))
+	p.record(throttleResponse(429, "s=b", nil), start.Add(2*time.Second))
+	p.record(throttleResponse(200, "s=a", nil), start.Add(31*time.Second))
+	policies := p.Policies()
+	if len(policies) != 1 {
+		t.Fatalf("Expected 1 policy, got %v", policies)
+	}
+	expected := ThrottlePolicy{Host: "target.example", Throttled: 2, Threshold: 5, Window: 30 * time.Second, Scope: ThrottlePerIP}
+	if policies[0] != expected {
+		t.Errorf("Expected %+v, got %+v", expected, policies[0])
+	}
+	if s := policies[0].String(); s != "target.example throttled 2 requests: threshold 5 requests, window 30s, counted per-IP" {
+		t.Errorf("Unexpected description: %s", s)
+	}
+}
+
+func TestThrottleProfiler_Stated(t *testing.T) {
+	p := NewThrottleProfiler()
+	now := time.Now()
+	header := http.Header{"Ratelimit-Policy": {"100;w=60"}, "Retry-After": {"5"}}
+	p.record(throttleResponse(200, "s=a", nil), now)
+	p.record(throttleResponse(503, "s=a", header), now)
+	// Another session isn't throttled
+	p.record(throttleResponse(200, "s=b", nil), now)
+	policies := p.Policies()
+	expected := ThrottlePolicy{Host: "target.example", Throttled: 1, Threshold: 100, Window: time.Minute, Scope: ThrottlePerSession}
+	if len(policies) != 1 || policies[0] != expected {
+		t.Errorf("Expected %+v, got %+v", expected, policies)
+	}
+}
+
+func TestThrottleProfiler_Unthrottled(t *testing.T) {
+	p := NewThrottleProfiler()
+	p.record(throttleResponse(200, "", nil), time.Now())
+	// Without Retry-After, a 503 is just an error
+	p.record(throttleResponse(503, "", nil), time.Now())
+	if policies := p.Policies(); len(policies) != 0 {
+		t.Errorf("Expected no policies, got %v", policies)
+	}
+	var nilProfiler *ThrottleProfiler
+	nilProfiler.Record(throttleResponse(429, "", nil))
+	if policies := nilProfiler.Policies(); policies != nil {
+		t.Errorf("Expected no policies, got %v", policies)
+	}
+	desc := ThrottlePolicy{Host: "h", Throttled: 1}.String()
+	if desc != "h throttled 1 requests: threshold unknown, window unknown, counted unknown" {
+		t.Errorf("Unexpected description: %s", desc)
+	}
+}
+
+func TestHTTPClient_RecordsThrottling(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "10")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+	fac, _ := NewProxyClientFactory(nil, time.Second, "")
+	throttles := NewThrottleProfiler()
+	fac.SetThrottleProfiler(throttles)
+	u, _ := url.Parse(server.URL)
+	resp, err := fac.Get().RequestURL(u)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	resp.Body.Close()
+	policies := throttles.Policies()
+	if len(policies) != 1 || policies[0].Window != 10*time.Second || policies[0].Threshold != 0 {
+		t.Errorf("Expected a 10s window and no threshold, got %+v", policies)
+	}
+}
@@ -0,0 +1,74 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"software.sslmate.com/src/go-pkcs12"
+)
+
+// Load a client certificate for mutual TLS.  certPath is either a PEM
+// certificate, with its key in keyPath or the same file, or a PKCS#12 file
+// unlocked with password.
+func LoadClientCertificate(certPath, keyPath, password string) (tls.Certificate, error) {
+	data, err := ioutil.ReadFile(certPath)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	if !bytes.Contains(data, []byte("-----BEGIN")) {
+		return pkcs12Certificate(data, password)
+	}
+	if keyPath == "" {
+		keyPath = certPath
+	}
+	return tls.LoadX509KeyPair(certPath, keyPath)
+}
+
+// The certificate for the key in a PKCS#12 file, followed by the rest of
+// its chain.
+func pkcs12Certificate(data []byte, password string) (tls.Certificate, error) {
+	key, first, chain, err := pkcs12.DecodeChain(data, password)
+	if err != nil {
+		return tls.Certificate{}, fmt.Errorf("Unable to read PKCS#12 file: %s", err.Error())
+	}
+	// The leaf is usually first, but is found by its key to be sure
+	certs := append([]*x509.Certificate{first}, chain...)
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return tls.Certificate{}, fmt.Errorf("Unsupported key type in PKCS#12 file.")
+	}
+	public, err := x509.MarshalPKIXPublicKey(signer.Public())
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	cert := tls.Certificate{PrivateKey: key}
+	for _, c := range certs {
+		if bytes.Equal(c.RawSubjectPublicKeyInfo, public) {
+			cert.Leaf = c
+			cert.Certificate = append([][]byte{c.Raw}, cert.Certificate...)
+		} else {
+			cert.Certificate = append(cert.Certificate, c.Raw)
+		}
+	}
+	if cert.Leaf == nil {
+		return tls.Certificate{}, fmt.Errorf("No certificate in the PKCS#12 file matches its key.")
+	}
+	return cert, nil
+}
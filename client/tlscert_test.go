@@ -0,0 +1,83 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+func TestLoadClientCertificate(t *testing.T) {
+	cases := []struct {
+		cert, key, password string
+	}{
+		{"testdata/client.pem", "testdata/client-key.pem", ""},
+		{"testdata/client-aes.p12", "", "secret"},
+		{"testdata/client-3des.p12", "", "secret"},
+	}
+	for _, c := range cases {
+		cert, err := LoadClientCertificate(c.cert, c.key, c.password)
+		if err != nil {
+			t.Errorf("Unexpected error loading %s: %s", c.cert, err)
+			continue
+		}
+		leaf, err := x509.ParseCertificate(cert.Certificate[0])
+		if err != nil || leaf.Subject.CommonName != "gobuster-client" {
+			t.Errorf("Expected the client certificate from %s, got %v", c.cert, err)
+		}
+	}
+	if _, err := LoadClientCertificate("testdata/client.pem", "", ""); err == nil {
+		t.Error("Expected error for a PEM certificate without its key.")
+	}
+	if _, err := LoadClientCertificate("testdata/client-aes.p12", "", "wrong"); err == nil {
+		t.Error("Expected error for the wrong password.")
+	}
+}
+
+func TestHTTPClient_ClientCertificate(t *testing.T) {
+	var presented string
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		presented = r.TLS.PeerCertificates[0].Subject.CommonName
+	}))
+	server.TLS = &tls.Config{ClientAuth: tls.RequireAnyClientCert}
+	server.StartTLS()
+	defer server.Close()
+	cert, err := LoadClientCertificate("testdata/client-aes.p12", "", "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	factory, _ := NewProxyClientFactory(nil, 0, "test")
+	factory.SetClientCertificate(cert)
+	cl := factory.Get().(*httpClient)
+	roots := x509.NewCertPool()
+	roots.AddCert(server.Certificate())
+	cl.Transport.(*http.Transport).TLSClientConfig.RootCAs = roots
+	u, _ := url.Parse(server.URL)
+	resp, err := cl.RequestURL(u)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	resp.Body.Close()
+	if presented != "gobuster-client" {
+		t.Errorf("Expected the client certificate to be presented, got %q", presented)
+	}
+	if anon := factory.Anonymous().Get().(*httpClient); anon.Transport != nil {
+		t.Error("Expected anonymous clients not to present the certificate.")
+	}
+}
@@ -0,0 +1,243 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package filter
+
+import (
+	"fmt"
+	"github.com/Matir/gobuster/results"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+var apiVersionRE = regexp.MustCompile(`^(v\d+(\.\d+)*|alpha|beta|latest)$`)
+
+// APIVersions permutes the version segment of API paths (e.g. /api/v1/) so
+// that old or unreleased versions are scanned too, and notes where the
+// versions differ.
+type APIVersions struct {
+	sync.Mutex
+	versions []string
+	// Codes seen for each version, keyed by URL with the version replaced
+	codes map[string]map[string]int
+	// Versions already expanded, keyed like codes
+	expanded map[string]map[string]bool
+}
+
+// VersionDifference is an endpoint that responds differently between API
+// versions.
+type VersionDifference struct {
+	// URL with {version} in place of the version
+	Pattern string
+	// Response code by version
+	Codes map[string]int
+	// A version of the endpoint that found something
+	URL *url.URL
+}
+
+func NewAPIVersions(versions []string) *APIVersions {
+	v := &APIVersions{codes: make(map[string]map[string]int), expanded: make(map[string]map[string]bool)}
+	for _, version := range versions {
+		if version = strings.Trim(version, " /"); version != "" {
+			v.versions = append(v.versions, version)
+		}
+	}
+	return v
+}
+
+// Split a path around its API version segment.  The version is empty if
+// the path is the API root itself.
+func splitAPIVersion(p string, versions []string) (prefix, version, rest string, ok bool) {
+	segments := strings.Split(p, "/")
+	for i, seg := range segments {
+		if strings.ToLower(seg) != "api" {
+			continue
+		}
+		prefix = strings.Join(segments[:i+1], "/") + "/"
+		if i+1 >= len(segments) || segments[i+1] == "" {
+			return prefix, "", "", true
+		}
+		next := segments[i+1]
+		if !apiVersionRE.MatchString(strings.ToLower(next)) && !containsString(versions, next) {
+			return "", "", "", false
+		}
+		return prefix, next, strings.Join(segments[i+2:], "/"), true
+	}
+	return "", "", "", false
+}
+
+// Get the same directory in the other API versions.  The API root gets a
+// directory for each version.  Each directory is permuted once, so covered
+// is set for a version of it that was already returned, whose children have
+// been expanded with the rest.
+func (v *APIVersions) Permute(dir *url.URL) (permuted []*url.URL, covered bool) {
+	if v == nil || len(v.versions) == 0 {
+		return nil, false
+	}
+	prefix, version, rest, ok := splitAPIVersion(dir.Path, v.versions)
+	if !ok {
+		return nil, false
+	}
+	key := versionKey(dir, prefix, rest)
+	v.Lock()
+	defer v.Unlock()
+	expanded := v.expanded[key]
+	if expanded == nil {
+		expanded = make(map[string]bool)
+		v.expanded[key] = expanded
+	}
+	if expanded[version] {
+		return nil, true
+	}
+	expanded[version] = true
+	for _, alt := range v.versions {
+		if expanded[alt] {
+			continue
+		}
+		expanded[alt] = true
+		u := *dir
+		u.Path = prefix + alt + "/" + rest
+		permuted = append(permuted, &u)
+	}
+	return permuted, false
+}
+
+// URL with {version} in place of the version, for matching across versions.
+func versionKey(u *url.URL, prefix, rest string) string {
+	return fmt.Sprintf("%s://%s%s{version}/%s", u.Scheme, u.Host, prefix, rest)
+}
+
+// Record the code for a result, if it's within a versioned API.
+func (v *APIVersions) Observe(u *url.URL, code int) {
+	if v == nil || code == 0 {
+		return
+	}
+	prefix, version, rest, ok := splitAPIVersion(u.Path, v.versions)
+	if !ok || version == "" {
+		return
+	}
+	key := versionKey(u, prefix, rest)
+	v.Lock()
+	defer v.Unlock()
+	if v.codes[key] == nil {
+		v.codes[key] = make(map[string]int)
+	}
+	v.codes[key][version] = code
+}
+
+// Observe results as they pass through.  Once they are all in, each
+// endpoint that differs between versions is reported as a result of its own.
+func (v *APIVersions) Watch(src <-chan results.Result) <-chan results.Result {
+	if v == nil || len(v.versions) == 0 {
+		return src
+	}
+	c := make(chan results.Result, cap(src))
+	go func() {
+		for res := range src {
+			if res.URL != nil && res.Error == nil {
+				v.Observe(res.URL, res.Code)
+			}
+			c <- res
+		}
+		for _, diff := range v.Differences() {
+			c <- results.Result{
+				URL:     diff.URL,
+				Code:    diff.Codes[versionOf(diff.URL, v.versions)],
+				Anomaly: fmt.Sprintf("differs between API versions: %s", diff.codeString()),
+			}
+		}
+		close(c)
+	}()
+	return c
+}
+
+func versionOf(u *url.URL, versions []string) string {
+	_, version, _, _ := splitAPIVersion(u.Path, versions)
+	return version
+}
+
+// Get the endpoints that were found in at least one version and respond
+// differently across versions, sorted by pattern.
+func (v *APIVersions) Differences() []VersionDifference {
+	v.Lock()
+	defer v.Unlock()
+	var diffs []VersionDifference
+	for pattern, codes := range v.codes {
+		if len(codes) < 2 {
+			continue
+		}
+		found, differ := false, false
+		first := -1
+		for _, code := range codes {
+			if results.FoundSomething(code) {
+				found = true
+			}
+			if first == -1 {
+				first = code
+			} else if code != first {
+				differ = true
+			}
+		}
+		if found && differ {
+			diffs = append(diffs, VersionDifference{Pattern: pattern, Codes: codes, URL: foundURL(pattern, codes)})
+		}
+	}
+	sort.Slice(diffs, func(i, j int) bool { return diffs[i].Pattern < diffs[j].Pattern })
+	return diffs
+}
+
+// The URL of the first version, in sorted order, that found something.
+func foundURL(pattern string, codes map[string]int) *url.URL {
+	for _, version := range sortedVersions(codes) {
+		if results.FoundSomething(codes[version]) {
+			u, _ := url.Parse(strings.Replace(pattern, "{version}", version, 1))
+			return u
+		}
+	}
+	return nil
+}
+
+func sortedVersions(codes map[string]int) []string {
+	versions := make([]string, 0, len(codes))
+	for version := range codes {
+		versions = append(versions, version)
+	}
+	sort.Strings(versions)
+	return versions
+}
+
+func (d VersionDifference) codeString() string {
+	versions := sortedVersions(d.Codes)
+	parts := make([]string, len(versions))
+	for i, version := range versions {
+		parts[i] = fmt.Sprintf("%s=%d", version, d.Codes[version])
+	}
+	return strings.Join(parts, " ")
+}
+
+func (d VersionDifference) String() string {
+	return fmt.Sprintf("%s (%s)", d.Pattern, d.codeString())
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,106 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package filter
+
+import (
+	"errors"
+	"github.com/Matir/gobuster/results"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestAPIVersions_Permute(t *testing.T) {
+	cases := []struct {
+		path     string
+		expected string
+	}{
+		{"/api/", "/api/v1/ /api/v1.1/ /api/beta/"},
+		{"/app/api/v2/users/", "/app/api/v1/users/ /app/api/v1.1/users/ /app/api/beta/users/"},
+		{"/api/v1/", "/api/v1.1/ /api/beta/"},
+		{"/api/users/", ""},
+		{"/static/", ""},
+	}
+	for _, c := range cases {
+		v := NewAPIVersions([]string{"v1", "v1.1", "beta", ""})
+		permuted, _ := v.Permute(&url.URL{Path: c.path})
+		var paths []string
+		for _, u := range permuted {
+			paths = append(paths, u.Path)
+		}
+		if res := strings.Join(paths, " "); res != c.expected {
+			t.Errorf("Expected %q for %s, got %q", c.expected, c.path, res)
+		}
+	}
+	var nilVersions *APIVersions
+	if permuted, _ := nilVersions.Permute(&url.URL{Path: "/api/"}); permuted != nil {
+		t.Error("Expected no permutations from nil.")
+	}
+}
+
+func TestAPIVersions_PermuteOnce(t *testing.T) {
+	v := NewAPIVersions([]string{"v1", "v2", "v3"})
+	if permuted, covered := v.Permute(&url.URL{Path: "/api/v1/users/"}); len(permuted) != 2 || covered {
+		t.Errorf("Expected 2 permutations, got %v (covered %v)", permuted, covered)
+	}
+	// Found under a permuted version and spidered
+	if permuted, covered := v.Permute(&url.URL{Path: "/api/v2/users/"}); len(permuted) != 0 || !covered {
+		t.Errorf("Expected a permuted version to be covered, got %v (covered %v)", permuted, covered)
+	}
+	// A directory only found under one version is permuted
+	if permuted, covered := v.Permute(&url.URL{Path: "/api/v2/users/admin/"}); len(permuted) != 2 || covered {
+		t.Errorf("Expected 2 permutations of a new directory, got %v (covered %v)", permuted, covered)
+	}
+}
+
+func TestAPIVersions_Differences(t *testing.T) {
+	v := NewAPIVersions([]string{"v1", "v2"})
+	src := make(chan results.Result, 8)
+	parse := func(s string) *url.URL {
+		u, _ := url.Parse(s)
+		return u
+	}
+	src <- results.Result{URL: parse("http://localhost/api/v1/users"), Code: 200}
+	src <- results.Result{URL: parse("http://localhost/api/v2/users"), Code: 404}
+	src <- results.Result{URL: parse("http://localhost/api/v1/same"), Code: 200}
+	src <- results.Result{URL: parse("http://localhost/api/v2/same"), Code: 200}
+	src <- results.Result{URL: parse("http://localhost/api/v1/gone"), Code: 404}
+	src <- results.Result{URL: parse("http://localhost/api/v2/gone"), Code: 410}
+	src <- results.Result{URL: parse("http://localhost/api/v2/broken"), Error: errors.New("Failed.")}
+	src <- results.Result{URL: parse("http://localhost/other"), Code: 200}
+	close(src)
+	var out []results.Result
+	for res := range v.Watch(src) {
+		out = append(out, res)
+	}
+	if len(out) != 9 {
+		t.Fatalf("Expected all results to pass through and a difference, got %d", len(out))
+	}
+	diff := out[8]
+	if diff.URL.String() != "http://localhost/api/v1/users" || diff.Code != 200 {
+		t.Errorf("Expected difference reported for the found version, got %s (%d)", diff.URL, diff.Code)
+	}
+	if diff.Anomaly != "differs between API versions: v1=200 v2=404" {
+		t.Errorf("Unexpected anomaly: %q", diff.Anomaly)
+	}
+	diffs := v.Differences()
+	if len(diffs) != 1 {
+		t.Fatalf("Expected 1 difference, got %v", diffs)
+	}
+	expected := "http://localhost/api/{version}/users (v1=200 v2=404)"
+	if res := diffs[0].String(); res != expected {
+		t.Errorf("Expected %s, got %s", expected, res)
+	}
+}
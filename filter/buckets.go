@@ -0,0 +1,75 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package filter
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Bucket names are 3 to 63 characters long, of lowercase letters, digits,
+// hyphens and dots, and for GCS underscores too.  Dots are left out, since
+// they break TLS for buckets addressed as subdomains and need a verified
+// domain on GCS.
+const (
+	minBucketName = 3
+	maxBucketName = 63
+)
+
+var (
+	s3UnsafeRE  = regexp.MustCompile(`[^a-z0-9]+`)
+	gcsUnsafeRE = regexp.MustCompile(`[^a-z0-9_]+`)
+)
+
+// Make word into a valid S3 bucket name, or "" if it can't be one.
+func S3BucketName(word string) string {
+	return bucketName(s3UnsafeRE, word)
+}
+
+// Make word into a valid GCS bucket name, or "" if it can't be one.  Names
+// mentioning Google are reserved.
+func GCSBucketName(word string) string {
+	name := bucketName(gcsUnsafeRE, word)
+	if strings.HasPrefix(name, "goog") || strings.Contains(name, "google") {
+		return ""
+	}
+	return name
+}
+
+func bucketName(unsafe *regexp.Regexp, word string) string {
+	name := strings.Trim(unsafe.ReplaceAllString(strings.ToLower(word), "-"), "-_")
+	if len(name) < minBucketName || len(name) > maxBucketName {
+		return ""
+	}
+	return name
+}
+
+// Candidate bucket names for word, made valid by name.  With a keyword, such
+// as a company name, the word is also tried joined to either side of it.
+func BucketNames(word, keyword string, name func(string) string) []string {
+	candidates := []string{word}
+	if keyword != "" {
+		candidates = append(candidates, keyword+"-"+word, word+"-"+keyword, keyword+word)
+	}
+	var names []string
+	seen := make(map[string]bool)
+	for _, c := range candidates {
+		if n := name(c); n != "" && !seen[n] {
+			seen[n] = true
+			names = append(names, n)
+		}
+	}
+	return names
+}
@@ -0,0 +1,60 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package filter
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestS3BucketName(t *testing.T) {
+	for word, expected := range map[string]string{
+		"Backups":               "backups",
+		"static_assets.old":     "static-assets-old",
+		"--dev--":               "dev",
+		"ab":                    "",
+		"_":                     "",
+		strings.Repeat("a", 64): "",
+	} {
+		if got := S3BucketName(word); got != expected {
+			t.Errorf("Expected %q for %q, got %q", expected, word, got)
+		}
+	}
+}
+
+func TestGCSBucketName(t *testing.T) {
+	for word, expected := range map[string]string{
+		"static_assets.old": "static_assets-old",
+		"_dev_":             "dev",
+		"googledata":        "",
+		"my-google-bucket":  "",
+	} {
+		if got := GCSBucketName(word); got != expected {
+			t.Errorf("Expected %q for %q, got %q", expected, word, got)
+		}
+	}
+}
+
+func TestBucketNames(t *testing.T) {
+	if got := strings.Join(BucketNames("Dev", "", S3BucketName), ","); got != "dev" {
+		t.Errorf("Expected dev, got %s", got)
+	}
+	if got := strings.Join(BucketNames("dev", "acme", S3BucketName), ","); got != "dev,acme-dev,dev-acme,acmedev" {
+		t.Errorf("Expected keyword combinations, got %s", got)
+	}
+	if got := strings.Join(BucketNames("x", "ac", S3BucketName), ","); got != "ac-x,x-ac,acx" {
+		t.Errorf("Expected short names dropped, got %s", got)
+	}
+}
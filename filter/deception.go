@@ -0,0 +1,128 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package filter
+
+import (
+	"fmt"
+	"github.com/Matir/gobuster/results"
+	"github.com/Matir/gobuster/util"
+	"github.com/Matir/gobuster/workqueue"
+	"strings"
+	"sync"
+)
+
+// Wordlist guesses on a host before its hit rates mean anything
+const deceptionMinTries = 100
+
+// Share of wordlist guesses found that no real site comes close to
+const deceptionFoundRate = 0.9
+
+// Wordlist directories on a host before their hit rate means anything
+const deceptionMinDirs = 50
+
+// Share of wordlist directories found that no real site comes close to
+const deceptionDirRate = 0.5
+
+// Prefix of secrets found by the canary token rule
+const canaryTokenSecret = "Canary Token: "
+
+// DeceptionDetector watches results for signs that a host is a honeypot or
+// other deception environment: nearly every guess being found, absurdly many
+// directories, or canary tokens in bodies.  A scan of such a host reports
+// little but false positives, so the operator is told as soon as possible.
+type DeceptionDetector struct {
+	sync.Mutex
+	hosts map[string]*hostDeception
+	// Called once for each host that looks deceptive
+	onDetect func(host, reason string)
+}
+
+type hostDeception struct {
+	tried     int
+	found     int
+	dirsTried int
+	dirsFound int
+	detected  bool
+}
+
+func NewDeceptionDetector(onDetect func(host, reason string)) *DeceptionDetector {
+	return &DeceptionDetector{hosts: make(map[string]*hostDeception), onDetect: onDetect}
+}
+
+// Count a result, returning why its host looks deceptive the first time it
+// does, or "" otherwise.  Only wordlist guesses count towards hit rates, since
+// links and the like are found by construction.
+func (d *DeceptionDetector) Observe(r results.Result) string {
+	if d == nil || r.URL == nil || r.Error != nil {
+		return ""
+	}
+	d.Lock()
+	defer d.Unlock()
+	h, ok := d.hosts[r.URL.Host]
+	if !ok {
+		h = &hostDeception{}
+		d.hosts[r.URL.Host] = h
+	}
+	if h.detected {
+		return ""
+	}
+	found := results.FoundSomething(r.Code)
+	if r.Source == string(workqueue.SourceWordlist) {
+		h.tried++
+		if found {
+			h.found++
+		}
+		if util.URLIsDir(r.URL) {
+			h.dirsTried++
+			if found {
+				h.dirsFound++
+			}
+		}
+	}
+	reason := ""
+	for _, secret := range r.Secrets {
+		if strings.HasPrefix(secret, canaryTokenSecret) {
+			reason = fmt.Sprintf("canary token %s in %s", strings.TrimPrefix(secret, canaryTokenSecret), r.URL)
+		}
+	}
+	if reason == "" && h.tried >= deceptionMinTries && float64(h.found) >= deceptionFoundRate*float64(h.tried) {
+		reason = fmt.Sprintf("%d of %d guesses found", h.found, h.tried)
+	}
+	if reason == "" && h.dirsTried >= deceptionMinDirs && float64(h.dirsFound) >= deceptionDirRate*float64(h.dirsTried) {
+		reason = fmt.Sprintf("%d of %d guessed directories found", h.dirsFound, h.dirsTried)
+	}
+	if reason != "" {
+		h.detected = true
+	}
+	return reason
+}
+
+// Observe results as they pass through, calling back for deceptive hosts.
+func (d *DeceptionDetector) Watch(src <-chan results.Result) <-chan results.Result {
+	if d == nil {
+		return src
+	}
+	c := make(chan results.Result, cap(src))
+	go func() {
+		for res := range src {
+			if reason := d.Observe(res); reason != "" && d.onDetect != nil {
+				d.onDetect(res.URL.Host, reason)
+			}
+			c <- res
+		}
+		close(c)
+	}()
+	return c
+}
@@ -0,0 +1,102 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package filter
+
+import (
+	"fmt"
+	"github.com/Matir/gobuster/results"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func guess(host, p string, code int) results.Result {
+	return results.Result{
+		URL:    &url.URL{Scheme: "http", Host: host, Path: p},
+		Code:   code,
+		Source: "wordlist",
+	}
+}
+
+func TestDeceptionDetector_FoundRate(t *testing.T) {
+	d := NewDeceptionDetector(nil)
+	for i := 0; i < deceptionMinTries; i++ {
+		code := 404
+		if i%20 == 0 {
+			code = 200
+		}
+		if reason := d.Observe(guess("real", fmt.Sprintf("/f%d", i), code)); reason != "" {
+			t.Fatalf("Expected a real site not to look deceptive, got %s", reason)
+		}
+	}
+	var reason string
+	for i := 0; i < deceptionMinTries && reason == ""; i++ {
+		reason = d.Observe(guess("honeypot", fmt.Sprintf("/f%d", i), 200))
+	}
+	if reason != "100 of 100 guesses found" {
+		t.Errorf("Expected every guess found, got %q", reason)
+	}
+	if reason := d.Observe(guess("honeypot", "/more", 200)); reason != "" {
+		t.Errorf("Expected a host to be reported once, got %s", reason)
+	}
+	// Links are found by construction
+	link := guess("linked", "/page", 200)
+	link.Source = "link"
+	for i := 0; i < 2*deceptionMinTries; i++ {
+		if reason := d.Observe(link); reason != "" {
+			t.Fatalf("Expected links not to count, got %s", reason)
+		}
+	}
+}
+
+func TestDeceptionDetector_Dirs(t *testing.T) {
+	d := NewDeceptionDetector(nil)
+	var reason string
+	for i := 0; i < deceptionMinDirs && reason == ""; i++ {
+		reason = d.Observe(guess("honeypot", fmt.Sprintf("/d%d/", i), 403))
+		d.Observe(guess("honeypot", fmt.Sprintf("/f%d", i), 404))
+		d.Observe(guess("honeypot", fmt.Sprintf("/g%d", i), 404))
+	}
+	if reason != "50 of 50 guessed directories found" {
+		t.Errorf("Expected every directory found, got %q", reason)
+	}
+}
+
+func TestDeceptionDetector_Watch(t *testing.T) {
+	var hosts []string
+	d := NewDeceptionDetector(func(host, reason string) {
+		hosts = append(hosts, host+": "+reason)
+	})
+	src := make(chan results.Result, 2)
+	r := guess("honeypot", "/backup.sql", 200)
+	r.Secrets = []string{"Canary Token: abc.canarytokens.com"}
+	src <- r
+	src <- guess("honeypot", "/other", 404)
+	close(src)
+	count := 0
+	for range d.Watch(src) {
+		count++
+	}
+	if count != 2 {
+		t.Errorf("Expected both results passed on, got %d", count)
+	}
+	if len(hosts) != 1 || !strings.Contains(hosts[0], "canary token abc.canarytokens.com") {
+		t.Errorf("Expected canary token reported once, got %v", hosts)
+	}
+	var nilDetector *DeceptionDetector
+	if nilDetector.Observe(r) != "" {
+		t.Error("Expected nil detector never to report.")
+	}
+}
@@ -15,7 +15,11 @@
 package filter
 
 import (
+	"fmt"
+	"github.com/Matir/gobuster/logging"
+	ss "github.com/Matir/gobuster/settings"
 	"github.com/Matir/gobuster/util"
+	"github.com/Matir/gobuster/wordlist"
 	"github.com/Matir/gobuster/workqueue"
 	"net/url"
 	"strings"
@@ -24,44 +28,312 @@ import (
 // An Expander is responsible for taking input URLs and expanding them to
 // include all of the words in the wordlist.
 type Expander struct {
-	// List of words to expand
-	Wordlist *[]string
+	// Words to expand, read as they are needed
+	Words *wordlist.Stream
 	// Function to count new instances
 	Adder workqueue.QueueAddCount
+	// Optional tracker of directory completion
+	Dirs *workqueue.DirectoryTracker
+	// Optional permutation of API versions
+	Versions *APIVersions
+	// Optional record of progress, also used to skip words when resuming
+	State *workqueue.StateTracker
+	// Expand hostnames rather than paths, with each word as a subdomain
+	Subdomains bool
+	// Domain subdomains are made under, instead of each URL's host
+	Domain string
+	// Optional record of which directories exist, to expand only those
+	Existence *workqueue.DirExistence
+	// Substitute each word for the fuzz marker rather than appending it
+	Fuzz bool
+	// Also keep the word in the fragment, for markers outside the URL
+	FuzzFragment bool
 }
 
 // Update the wordlist to contain directory & non-directory entries
-func (e *Expander) ProcessWordlist() {
-	newList := make([]string, 0)
-	for _, w := range *e.Wordlist {
-		newList = append(newList, w)
-		if strings.Contains(w, ".") {
-			continue
-		}
-		if w[len(w)-1] == byte('/') {
-			continue
+func (e *Expander) ProcessWordlist() error {
+	words, err := e.Words.Map(func(w string) []string {
+		if strings.Contains(w, ".") || w[len(w)-1] == byte('/') {
+			return []string{w}
 		}
-		newList = append(newList, w+"/")
+		return []string{w, w + "/"}
+	})
+	if err != nil {
+		return err
+	}
+	e.Words = words
+	return nil
+}
+
+// Update the wordlist to contain bucket names made from each entry, made
+// valid by name
+func (e *Expander) ProcessBucketNames(keyword string, name func(string) string) error {
+	words, err := e.Words.Map(func(w string) []string {
+		return BucketNames(w, keyword, name)
+	})
+	if err != nil {
+		return err
 	}
-	e.Wordlist = &newList
+	e.Words = words
+	return nil
 }
 
+// Update the wordlist to contain each word followed by its permutations
+func (e *Expander) ProcessPermutations(rules PermutationRules) error {
+	words, err := e.Words.Map(rules.Permute)
+	if err != nil {
+		return err
+	}
+	e.Words = words
+	return nil
+}
+
+// Expand each URL into itself followed by its children.  Expansions of URLs
+// on different hosts are interleaved, so scanning several targets at once
+// makes progress on all of them.
 func (E *Expander) Expand(in <-chan *url.URL) <-chan *url.URL {
 	out := make(chan *url.URL, cap(in))
 	go func() {
-		for e := range in {
-			out <- e
-			E.Adder(len(*E.Wordlist))
-			for _, word := range *E.Wordlist {
-				out <- ExtendURL(e, word)
+		defer close(out)
+		pending := &expansionQueue{hosts: make(map[string]*hostExpansions)}
+		for in != nil || !pending.empty() {
+			// Take in everything available first, so all hosts are served
+			var e *url.URL
+			var ok bool
+			if pending.empty() {
+				e, ok = <-in
+			} else {
+				select {
+				case e, ok = <-in:
+				default:
+					next := pending.peek(E)
+					if next == nil {
+						// The wordlist couldn't be read
+						pending.advance()
+						continue
+					}
+					select {
+					case e, ok = <-in:
+					case out <- next:
+						pending.advance()
+						continue
+					}
+				}
+			}
+			if !ok {
+				// Never selected again
+				in = nil
+				continue
 			}
+			pending.push(E.start(e))
 		}
-		close(out)
 	}()
 
 	return out
 }
 
+// The remaining output of expanding one URL.
+type expansion struct {
+	e     *url.URL
+	bases []*url.URL
+	// Opened when the first word is needed
+	words *wordlist.Iterator
+	// Index of the next word to expand, and the number of words
+	next  int
+	total int
+	// Whether e itself has been sent
+	sent bool
+	// Children of the last word expanded, not yet sent
+	children []*url.URL
+}
+
+// Expansions of one host, in order.
+type hostExpansions struct {
+	host string
+	jobs []*expansion
+}
+
+// Expansions waiting to be sent, served round-robin by host.
+type expansionQueue struct {
+	hosts  map[string]*hostExpansions
+	active []*hostExpansions
+	next   int
+}
+
+// Count the children of e and prepare to send them.
+func (E *Expander) start(e *url.URL) *expansion {
+	bases := []*url.URL{e}
+	appends := !E.Subdomains && !E.Fuzz
+	total := E.Words.Len()
+	if util.URLIsDir(e) && appends && !E.Existence.Ready(e) {
+		// Only e itself, until it is found and queued again
+		return &expansion{e: e, bases: bases}
+	}
+	if util.URLIsDir(e) && appends {
+		permuted, covered := E.Versions.Permute(e)
+		if covered {
+			// Expanded already as another version's permutation
+			return &expansion{e: e, bases: bases}
+		}
+		bases = append(bases, permuted...)
+	}
+	skip := E.State.Offset(e)
+	if skip > 0 {
+		skip = E.resumeAt(e, skip, E.State.OffsetWord(e))
+	}
+	if skip > total {
+		skip = total
+	}
+	count := (total - skip) * len(bases)
+	// Count the children before e can be finished with, so the work is never
+	// thought to be done in between
+	E.Adder(count)
+	E.Dirs.Expect(e, count)
+	return &expansion{e: e, bases: bases, next: skip, total: total}
+}
+
+// Find where a resumed expansion of e continues.  The restored offset is
+// trusted if the word before it is the one the state recorded, otherwise the
+// expansion continues after the first occurrence of that word, or starts over
+// if it isn't in the wordlist.
+func (E *Expander) resumeAt(e *url.URL, offset int, word string) int {
+	if word == "" {
+		return offset
+	}
+	it, err := E.Words.Iterate(0)
+	if err != nil {
+		return offset
+	}
+	defer it.Close()
+	found := 0
+	for i := 1; it.Scan(); i++ {
+		if it.Word() != word {
+			continue
+		}
+		if i == offset {
+			return offset
+		}
+		if found == 0 {
+			found = i
+		}
+		if i > offset {
+			break
+		}
+	}
+	if found == 0 {
+		logging.Logf(logging.LogWarning, "Unable to find where %s stopped in the wordlist, starting it over.", e)
+	} else {
+		logging.Logf(logging.LogWarning, "Continuing %s after word %d (%s) instead of %d.", e, found, word, offset)
+	}
+	E.State.Realign(e, found)
+	return found
+}
+
+// The next URL to send, expanding the next word if needed.  Nil if the rest
+// of the expansion was given up on.
+func (x *expansion) peek(E *Expander) *url.URL {
+	if !x.sent {
+		return x.e
+	}
+	if len(x.children) == 0 {
+		word, ok := x.read(E)
+		if !ok {
+			return nil
+		}
+		for _, base := range x.bases {
+			extended := E.extend(base, word)
+			E.Dirs.Track(x.e, extended)
+			x.children = append(x.children, extended)
+		}
+		E.State.Expand(x.e, x.next, word, x.children...)
+		x.next++
+	}
+	return x.children[0]
+}
+
+// Read the next word.  If the wordlist can't be read, the rest of the
+// expansion is given up on and uncounted.
+func (x *expansion) read(E *Expander) (string, bool) {
+	var err error
+	if x.words == nil {
+		x.words, err = E.Words.Iterate(x.next)
+	}
+	if err == nil && x.words.Scan() {
+		return x.words.Word(), true
+	}
+	if err == nil {
+		if err = x.words.Close(); err == nil {
+			err = fmt.Errorf("Wordlist ended early.")
+		}
+		x.words = nil
+	}
+	logging.Logf(logging.LogError, "Unable to expand %s: %s", x.e, err.Error())
+	remaining := (x.total - x.next) * len(x.bases)
+	E.Adder(-remaining)
+	E.Dirs.Expect(x.e, -remaining)
+	x.next = x.total
+	return "", false
+}
+
+// Move past the URL returned by peek, returning whether anything is left.
+func (x *expansion) advance() bool {
+	if !x.sent {
+		x.sent = true
+	} else if len(x.children) > 0 {
+		x.children = x.children[1:]
+	}
+	if len(x.children) == 0 && x.next >= x.total && x.words != nil {
+		x.words.Close()
+		x.words = nil
+	}
+	return len(x.children) > 0 || x.next < x.total
+}
+
+func (q *expansionQueue) empty() bool {
+	return len(q.active) == 0
+}
+
+func (q *expansionQueue) push(x *expansion) {
+	hx, ok := q.hosts[x.e.Host]
+	if !ok {
+		hx = &hostExpansions{host: x.e.Host}
+		q.hosts[x.e.Host] = hx
+		q.active = append(q.active, hx)
+	}
+	hx.jobs = append(hx.jobs, x)
+}
+
+func (q *expansionQueue) peek(E *Expander) *url.URL {
+	return q.active[q.next].jobs[0].peek(E)
+}
+
+// Move past the URL returned by peek, then on to the next host.
+func (q *expansionQueue) advance() {
+	hx := q.active[q.next]
+	if !hx.jobs[0].advance() {
+		hx.jobs = hx.jobs[1:]
+	}
+	if len(hx.jobs) == 0 {
+		delete(q.hosts, hx.host)
+		q.active = append(q.active[:q.next], q.active[q.next+1:]...)
+	} else {
+		q.next++
+	}
+	if q.next >= len(q.active) {
+		q.next = 0
+	}
+}
+
+func (E *Expander) extend(u *url.URL, word string) *url.URL {
+	if E.Subdomains {
+		return SubdomainURL(u, word, E.Domain)
+	}
+	if E.Fuzz {
+		return FuzzURL(u, word, E.FuzzFragment)
+	}
+	return ExtendURL(u, word)
+}
+
 func ExtendURL(u *url.URL, tail string) *url.URL {
 	extended := *u
 	if !util.URLIsDir(u) {
@@ -71,3 +343,35 @@ func ExtendURL(u *url.URL, tail string) *url.URL {
 	}
 	return &extended
 }
+
+// Prefix the host of u, or domain if given, with a subdomain.  The port of u
+// is kept either way.
+func SubdomainURL(u *url.URL, sub, domain string) *url.URL {
+	extended := *u
+	if domain == "" {
+		extended.Host = sub + "." + u.Host
+	} else if port := u.Port(); port != "" {
+		extended.Host = sub + "." + domain + ":" + port
+	} else {
+		extended.Host = sub + "." + domain
+	}
+	return &extended
+}
+
+// Substitute word for the fuzz marker in u.  Words that don't fit in a URL
+// as they are get escaped.  With inFragment, the word is also kept in the
+// fragment, to be substituted into the rest of the request.
+func FuzzURL(u *url.URL, word string, inFragment bool) *url.URL {
+	fuzzed := *u
+	if raw := u.String(); strings.Contains(raw, ss.FuzzMarker) {
+		parsed, err := url.Parse(strings.Replace(raw, ss.FuzzMarker, word, -1))
+		if err != nil {
+			parsed, _ = url.Parse(strings.Replace(raw, ss.FuzzMarker, url.PathEscape(word), -1))
+		}
+		fuzzed = *parsed
+	}
+	if inFragment {
+		fuzzed.Fragment = word
+	}
+	return &fuzzed
+}
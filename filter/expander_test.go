@@ -15,28 +15,43 @@
 package filter
 
 import (
+	"github.com/Matir/gobuster/wordlist"
+	"github.com/Matir/gobuster/workqueue"
+	"io/ioutil"
 	"net/url"
+	"os"
+	"strings"
 	"testing"
 )
 
 func TestProcessWordlist(t *testing.T) {
 	wl := []string{"a", "b/", "c.txt"}
 	expected := []string{"a", "a/", "b/", "c.txt"}
-	expander := &Expander{Wordlist: &wl}
-	expander.ProcessWordlist()
-	if len(*expander.Wordlist) != len(expected) {
-		t.Fatalf("Length of wordlist not expected: %d vs %d", len(*expander.Wordlist), len(expected))
+	expander := &Expander{Words: wordlist.NewStream(wl)}
+	if err := expander.ProcessWordlist(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
 	}
-	for i, e := range expected {
-		if (*expander.Wordlist)[i] != e {
-			t.Errorf("Wordlist element mismatch: %s %s", e, (*expander.Wordlist)[i])
+	if expander.Words.Len() != len(expected) {
+		t.Fatalf("Length of wordlist not expected: %d vs %d", expander.Words.Len(), len(expected))
+	}
+	it, err := expander.Words.Iterate(0)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	defer it.Close()
+	for _, e := range expected {
+		if !it.Scan() {
+			t.Fatalf("Wordlist ended before %s", e)
+		}
+		if it.Word() != e {
+			t.Errorf("Wordlist element mismatch: %s %s", e, it.Word())
 		}
 	}
 }
 
 func TestExpand(t *testing.T) {
 	wl := []string{"a", "b"}
-	expander := &Expander{Wordlist: &wl, Adder: func(_ int) {}}
+	expander := &Expander{Words: wordlist.NewStream(wl), Adder: func(_ int) {}}
 	ch := make(chan *url.URL, 5)
 	paths := []string{"/foo", "/bar/"}
 	expected := []string{"/foo", "/foo/a", "/foo/b", "/bar/", "/bar/a", "/bar/b"}
@@ -58,3 +73,277 @@ func TestExpand(t *testing.T) {
 		t.Errorf("Expected closed channel, read an item!")
 	}
 }
+
+func TestExpand_Interleaved(t *testing.T) {
+	wl := []string{"a", "b"}
+	expander := &Expander{Words: wordlist.NewStream(wl), Adder: func(_ int) {}}
+	ch := make(chan *url.URL, 3)
+	ch <- &url.URL{Scheme: "http", Host: "one", Path: "/"}
+	ch <- &url.URL{Scheme: "http", Host: "two", Path: "/"}
+	ch <- &url.URL{Scheme: "http", Host: "one", Path: "/x/"}
+	close(ch)
+	var got []string
+	for u := range expander.Expand(ch) {
+		got = append(got, u.Host+u.Path)
+	}
+	expected := "one/ two/ one/a two/a one/b two/b one/x/ one/x/a one/x/b"
+	if res := strings.Join(got, " "); res != expected {
+		t.Errorf("Expected %s, got %s", expected, res)
+	}
+}
+
+func TestExpand_Directories(t *testing.T) {
+	wl := []string{"a", "b"}
+	completed := 0
+	dirs := workqueue.NewDirectoryTracker(func(_ workqueue.DirectorySummary) {
+		completed++
+	})
+	expander := &Expander{Words: wordlist.NewStream(wl), Adder: func(_ int) {}, Dirs: dirs}
+	ch := make(chan *url.URL, 1)
+	ch <- &url.URL{Scheme: "http", Host: "localhost", Path: "/foo/"}
+	close(ch)
+	for u := range expander.Expand(ch) {
+		dirs.Done(u)
+	}
+	if completed != 1 {
+		t.Errorf("Expected 1 directory completed, got %d", completed)
+	}
+}
+
+func TestExpand_APIVersions(t *testing.T) {
+	wl := []string{"users"}
+	added := 0
+	expander := &Expander{
+		Words:    wordlist.NewStream(wl),
+		Adder:    func(c int) { added += c },
+		Versions: NewAPIVersions([]string{"v1", "v2"}),
+	}
+	ch := make(chan *url.URL, 1)
+	ch <- &url.URL{Path: "/api/v1/"}
+	close(ch)
+	var paths []string
+	for u := range expander.Expand(ch) {
+		paths = append(paths, u.Path)
+	}
+	expected := "/api/v1/ /api/v1/users /api/v2/users"
+	if res := strings.Join(paths, " "); res != expected {
+		t.Errorf("Expected %s, got %s", expected, res)
+	}
+	if added != 2 {
+		t.Errorf("Expected 2 added, got %d", added)
+	}
+	// The other version's directory was covered by the first
+	ch = make(chan *url.URL, 1)
+	ch <- &url.URL{Path: "/api/v2/"}
+	close(ch)
+	paths = nil
+	for u := range expander.Expand(ch) {
+		paths = append(paths, u.Path)
+	}
+	if res := strings.Join(paths, " "); res != "/api/v2/" {
+		t.Errorf("Expected only /api/v2/ itself, got %s", res)
+	}
+}
+
+func TestExpand_Resume(t *testing.T) {
+	wl := []string{"a", "b", "c"}
+	state := workqueue.NewStateTracker("", 0, "", "", nil)
+	state.Restore(&workqueue.ScanState{Offsets: map[string]int{"http://localhost/foo/": 2}})
+	added := 0
+	expander := &Expander{Words: wordlist.NewStream(wl), Adder: func(n int) { added += n }, State: state}
+	ch := make(chan *url.URL, 1)
+	ch <- &url.URL{Scheme: "http", Host: "localhost", Path: "/foo/"}
+	close(ch)
+	var got []string
+	for u := range expander.Expand(ch) {
+		got = append(got, u.Path)
+		state.Done(u)
+	}
+	if strings.Join(got, " ") != "/foo/ /foo/c" {
+		t.Errorf("Expected words before the offset to be skipped, got %v", got)
+	}
+	if added != 1 {
+		t.Errorf("Expected 1 added, got %d", added)
+	}
+	if offset := state.Offset(&url.URL{Scheme: "http", Host: "localhost", Path: "/foo/"}); offset != 3 {
+		t.Errorf("Expected offset 3, got %d", offset)
+	}
+}
+
+func TestExpand_UnreadableWordlist(t *testing.T) {
+	fp, err := ioutil.TempFile("", "gobuster-wordlist")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fp.WriteString("a\nb\n")
+	fp.Close()
+	words, err := wordlist.OpenWordlist(fp.Name())
+	os.Remove(fp.Name())
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	added := 0
+	expander := &Expander{Words: words, Adder: func(n int) { added += n }}
+	ch := make(chan *url.URL, 1)
+	ch <- &url.URL{Path: "/foo/"}
+	close(ch)
+	var got []string
+	for u := range expander.Expand(ch) {
+		got = append(got, u.Path)
+	}
+	if strings.Join(got, " ") != "/foo/" {
+		t.Errorf("Expected only /foo/, got %v", got)
+	}
+	if added != 0 {
+		t.Errorf("Expected children to be uncounted, got %d", added)
+	}
+}
+
+func TestExpand_Subdomains(t *testing.T) {
+	wl := []string{"www", "mail"}
+	expander := &Expander{Words: wordlist.NewStream(wl), Adder: func(_ int) {}, Subdomains: true}
+	ch := make(chan *url.URL, 1)
+	ch <- &url.URL{Scheme: "http", Host: "example.com:8080", Path: "/"}
+	close(ch)
+	var got []string
+	for u := range expander.Expand(ch) {
+		got = append(got, u.String())
+	}
+	expected := "http://example.com:8080/ http://www.example.com:8080/ http://mail.example.com:8080/"
+	if res := strings.Join(got, " "); res != expected {
+		t.Errorf("Expected %s, got %s", expected, res)
+	}
+}
+
+func TestExpand_Existence(t *testing.T) {
+	existence := workqueue.NewDirExistence()
+	root := &url.URL{Scheme: "http", Host: "localhost", Path: "/"}
+	linked := &url.URL{Scheme: "http", Host: "localhost", Path: "/linked/"}
+	existence.Assume(root)
+	expander := &Expander{Words: wordlist.NewStream([]string{"a"}), Adder: func(_ int) {}, Existence: existence}
+	expand := func(urls ...*url.URL) string {
+		ch := make(chan *url.URL, len(urls))
+		for _, u := range urls {
+			ch <- u
+		}
+		close(ch)
+		var got []string
+		for u := range expander.Expand(ch) {
+			got = append(got, u.Path)
+		}
+		return strings.Join(got, " ")
+	}
+	if got := expand(root, linked); !strings.Contains(got, "/linked/") || strings.Contains(got, "/linked/a") || !strings.Contains(got, "/a") {
+		t.Errorf("Expected /linked/ held back, got %s", got)
+	}
+	existence.Record(linked, true)
+	if got := expand(linked); got != "/linked/ /linked/a" {
+		t.Errorf("Expected /linked/ expanded once found, got %s", got)
+	}
+}
+
+func TestSubdomainURL(t *testing.T) {
+	for _, c := range []struct {
+		host, domain, expected string
+	}{
+		{"example.com", "", "www.example.com"},
+		{"10.0.0.1:8080", "example.com", "www.example.com:8080"},
+		{"10.0.0.1", "example.com", "www.example.com"},
+	} {
+		u := SubdomainURL(&url.URL{Scheme: "http", Host: c.host, Path: "/"}, "www", c.domain)
+		if u.Host != c.expected {
+			t.Errorf("Expected %s, got %s", c.expected, u.Host)
+		}
+	}
+}
+
+func TestProcessBucketNames(t *testing.T) {
+	expander := &Expander{Words: wordlist.NewStream([]string{"Dev", "x", "logs"})}
+	if err := expander.ProcessBucketNames("acme", S3BucketName); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	it, err := expander.Words.Iterate(0)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	defer it.Close()
+	var got []string
+	for it.Scan() {
+		got = append(got, it.Word())
+	}
+	expected := "dev acme-dev dev-acme acmedev acme-x x-acme acmex logs acme-logs logs-acme acmelogs"
+	if res := strings.Join(got, " "); res != expected {
+		t.Errorf("Expected %s, got %s", expected, res)
+	}
+}
+
+func TestProcessPermutations(t *testing.T) {
+	expander := &Expander{Words: wordlist.NewStream([]string{"API", "www"})}
+	if err := expander.ProcessPermutations(PermutationRules{"dev-{word}", "{word}.staging"}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	it, err := expander.Words.Iterate(0)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	defer it.Close()
+	var got []string
+	for it.Scan() {
+		got = append(got, it.Word())
+	}
+	expected := "api dev-api api.staging www dev-www www.staging"
+	if res := strings.Join(got, " "); res != expected {
+		t.Errorf("Expected %s, got %s", expected, res)
+	}
+}
+
+func TestFuzzURL(t *testing.T) {
+	for _, c := range []struct {
+		template, word string
+		inFragment     bool
+		expected       string
+	}{
+		{"http://localhost/FUZZ", "admin", false, "http://localhost/admin"},
+		{"http://localhost/?id=FUZZ&q=FUZZ", "1", false, "http://localhost/?id=1&q=1"},
+		{"http://FUZZ.example.com/", "dev", false, "http://dev.example.com/"},
+		{"http://localhost/login", "admin", true, "http://localhost/login#admin"},
+		{"http://localhost/FUZZ", "%zz", false, "http://localhost/%25zz"},
+	} {
+		u, _ := url.Parse(c.template)
+		if got := FuzzURL(u, c.word, c.inFragment).String(); got != c.expected {
+			t.Errorf("Expected %s, got %s", c.expected, got)
+		}
+	}
+}
+
+func TestExpand_ResumeRealign(t *testing.T) {
+	dir := &url.URL{Scheme: "http", Host: "localhost", Path: "/foo/"}
+	for _, c := range []struct {
+		word, expected string
+	}{
+		{"c", "/foo/ /foo/d"},
+		{"b", "/foo/ /foo/c /foo/d"},
+		{"zzz", "/foo/ /foo/a /foo/b /foo/c /foo/d"},
+	} {
+		state := workqueue.NewStateTracker("", 0, "", "", nil)
+		state.Restore(&workqueue.ScanState{
+			Offsets: map[string]int{dir.String(): 3},
+			Words:   map[string]string{dir.String(): c.word},
+		})
+		expander := &Expander{Words: wordlist.NewStream([]string{"a", "b", "c", "d"}), Adder: func(_ int) {}, State: state}
+		ch := make(chan *url.URL, 1)
+		ch <- dir
+		close(ch)
+		var got []string
+		for u := range expander.Expand(ch) {
+			got = append(got, u.Path)
+			state.Done(u)
+		}
+		if res := strings.Join(got, " "); res != c.expected {
+			t.Errorf("Expected %s after %s, got %s", c.expected, c.word, res)
+		}
+		if offset := state.Offset(dir); offset != 4 {
+			t.Errorf("Expected offset 4 after %s, got %d", c.word, offset)
+		}
+	}
+}
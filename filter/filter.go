@@ -37,6 +37,14 @@ type WorkFilter struct {
 	exclusions []*url.URL
 	// Count the work that has been dropped
 	counter workqueue.QueueDoneFunc
+	// Optional tracker of directory completion
+	dirs *workqueue.DirectoryTracker
+	// Optional record of progress for resuming
+	state *workqueue.StateTracker
+	// Optional record of how URLs were found
+	provenance *workqueue.ProvenanceTracker
+	// Optional slice of the scan to keep to
+	shard *Shard
 }
 
 func NewWorkFilter(settings *ss.ScanSettings, counter workqueue.QueueDoneFunc) *WorkFilter {
@@ -70,6 +78,10 @@ func (f *WorkFilter) RunFilter(src <-chan *url.URL) <-chan *url.URL {
 					continue taskLoop
 				}
 			}
+			if !f.shard.Includes(task) {
+				f.reject(task, "in another shard")
+				continue
+			}
 			c <- task
 		}
 		close(c)
@@ -77,6 +89,33 @@ func (f *WorkFilter) RunFilter(src <-chan *url.URL) <-chan *url.URL {
 	return c
 }
 
+// Report dropped URLs to a directory tracker.
+func (f *WorkFilter) SetDirectoryTracker(dirs *workqueue.DirectoryTracker) {
+	f.dirs = dirs
+}
+
+// Record dropped URLs as done for resuming.
+func (f *WorkFilter) SetStateTracker(state *workqueue.StateTracker) {
+	f.state = state
+}
+
+// Forget how dropped URLs were found.
+func (f *WorkFilter) SetProvenanceTracker(provenance *workqueue.ProvenanceTracker) {
+	f.provenance = provenance
+}
+
+// Only pass URLs in one slice of the scan.
+func (f *WorkFilter) SetShard(shard *Shard) {
+	f.shard = shard
+}
+
+// Treat URLs as already done, e.g. by an interrupted scan being resumed.
+func (f *WorkFilter) MarkDone(urls ...string) {
+	for _, u := range urls {
+		f.done[u] = true
+	}
+}
+
 // Add another URL to filter
 func (f *WorkFilter) FilterURL(u *url.URL) {
 	f.exclusions = append(f.exclusions, u)
@@ -103,5 +142,8 @@ func (f *WorkFilter) AddRobotsFilter(scope []*url.URL, clientFactory client.Clie
 // Task that can't be used, but should be counted as terminated.
 func (f *WorkFilter) reject(u *url.URL, reason string) {
 	logging.Logf(logging.LogDebug, "Filter rejected %s: %s.", u.String(), reason)
+	f.dirs.Done(u)
+	f.state.Done(u)
+	f.provenance.Done(u)
 	f.counter(1)
 }
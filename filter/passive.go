@@ -0,0 +1,122 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package filter
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"github.com/Matir/gobuster/client"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// Where crt.sh is queried, replaced in tests.
+var crtShURL = "https://crt.sh/"
+
+// Hostnames seen in certificates for domain and its subdomains, as logged
+// by certificate transparency and searched by crt.sh.
+func CrtShNames(cl client.Client, domain string) ([]string, error) {
+	u, err := url.Parse(crtShURL)
+	if err != nil {
+		return nil, err
+	}
+	u.RawQuery = url.Values{"q": {"%." + domain}, "output": {"json"}}.Encode()
+	resp, err := cl.RequestURL(u)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("Unexpected status from crt.sh: %s", resp.Status)
+	}
+	var certs []struct {
+		NameValue string `json:"name_value"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&certs); err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, cert := range certs {
+		// One name per line, for each name in the certificate
+		names = append(names, strings.Split(cert.NameValue, "\n")...)
+	}
+	return names, nil
+}
+
+func LoadPassiveNames(filename string) ([]string, error) {
+	fp, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer fp.Close()
+	return ParsePassiveNames(fp)
+}
+
+// Hostnames from the output of tools like amass and subfinder, either a
+// name at the start of each line or JSON lines with a name or host field.
+func ParsePassiveNames(rdr io.Reader) ([]string, error) {
+	var names []string
+	scanner := bufio.NewScanner(rdr)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if strings.HasPrefix(line, "{") {
+			var entry struct {
+				Name string `json:"name"`
+				Host string `json:"host"`
+			}
+			if err := json.Unmarshal([]byte(line), &entry); err != nil {
+				return nil, fmt.Errorf("Line %d: %s", lineNo, err.Error())
+			}
+			if entry.Name == "" {
+				entry.Name = entry.Host
+			}
+			names = append(names, entry.Name)
+			continue
+		}
+		names = append(names, strings.Fields(line)[0])
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return names, nil
+}
+
+// The words that make names under domain, e.g. "mail" for mail.example.com,
+// in order without duplicates.  Wildcards are dropped and names outside
+// domain are ignored.
+func SubdomainWords(names []string, domain string) []string {
+	suffix := "." + strings.ToLower(strings.TrimSuffix(domain, "."))
+	var words []string
+	seen := make(map[string]bool)
+	for _, name := range names {
+		name = strings.TrimPrefix(strings.ToLower(strings.TrimSuffix(strings.TrimSpace(name), ".")), "*.")
+		word := strings.TrimSuffix(name, suffix)
+		if word == name || word == "" || seen[word] || !validHostname(word) {
+			continue
+		}
+		seen[word] = true
+		words = append(words, word)
+	}
+	return words
+}
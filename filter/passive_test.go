@@ -0,0 +1,75 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package filter
+
+import (
+	"github.com/Matir/gobuster/client"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCrtShNames(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if q := r.URL.Query().Get("q"); q != "%.example.com" {
+			t.Errorf("Expected query %%.example.com, got %s", q)
+		}
+		w.Write([]byte(`[{"name_value":"example.com\nwww.example.com"},{"name_value":"*.dev.example.com"}]`))
+	}))
+	defer server.Close()
+	defer func(orig string) { crtShURL = orig }(crtShURL)
+	crtShURL = server.URL + "/"
+	factory, err := client.NewProxyClientFactory(nil, time.Second, "test")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	names, err := CrtShNames(factory.Get(), "example.com")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if got := strings.Join(names, " "); got != "example.com www.example.com *.dev.example.com" {
+		t.Errorf("Expected 3 names, got %s", got)
+	}
+}
+
+func TestParsePassiveNames(t *testing.T) {
+	text := `# subfinder
+api.example.com
+mail.example.com 10.0.0.1,10.0.0.2
+{"name":"vpn.example.com","domain":"example.com"}
+{"host":"cdn.example.com","source":"crtsh"}
+`
+	names, err := ParsePassiveNames(strings.NewReader(text))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expected := "api.example.com mail.example.com vpn.example.com cdn.example.com"
+	if got := strings.Join(names, " "); got != expected {
+		t.Errorf("Expected %s, got %s", expected, got)
+	}
+	if _, err := ParsePassiveNames(strings.NewReader("{oops\n")); err == nil || !strings.Contains(err.Error(), "Line 1") {
+		t.Errorf("Expected error on line 1, got %v", err)
+	}
+}
+
+func TestSubdomainWords(t *testing.T) {
+	names := []string{"www.example.com", "WWW.Example.com.", "*.dev.example.com", "a.b.example.com", "example.com", "other.org", "bad_name.example.com"}
+	expected := "www dev a.b"
+	if got := strings.Join(SubdomainWords(names, "example.com"), " "); got != expected {
+		t.Errorf("Expected %s, got %s", expected, got)
+	}
+}
@@ -0,0 +1,125 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package filter
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// PermutationRules make variants of each subdomain word, like altdns does,
+// to find hosts such as dev-api or api-old next to api.  Rules are written
+// one per line as a pattern with {word} where the word goes, and optionally
+// {year} for this year and last year, e.g.:
+//
+//	dev-{word}
+//	{word}-old
+//	{word}.staging
+//	{word}{year}
+type PermutationRules []string
+
+// Environment, age and region affixes, used without a rules file.
+var DefaultPermutationRules = PermutationRules{
+	"dev-{word}", "{word}-dev", "{word}.dev",
+	"staging-{word}", "{word}-staging", "{word}.staging",
+	"stage-{word}", "{word}-stage",
+	"test-{word}", "{word}-test",
+	"qa-{word}", "{word}-qa",
+	"uat-{word}", "{word}-uat",
+	"preprod-{word}", "{word}-preprod",
+	"prod-{word}", "{word}-prod",
+	"int-{word}", "{word}-internal",
+	"{word}-old", "{word}-new", "{word}-backup", "{word}-legacy",
+	"{word}2", "{word}-v2",
+	"{word}{year}", "{word}-{year}",
+	"us-{word}", "{word}-us", "eu-{word}", "{word}-eu",
+	"{word}.us-east-1", "{word}.eu-west-1",
+}
+
+var hostLabelRE = regexp.MustCompile(`^[a-z0-9]([a-z0-9-]{0,61}[a-z0-9])?$`)
+
+func LoadPermutationRules(filename string) (PermutationRules, error) {
+	fp, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer fp.Close()
+	return ParsePermutationRules(fp)
+}
+
+func ParsePermutationRules(rdr io.Reader) (PermutationRules, error) {
+	rules := make(PermutationRules, 0)
+	scanner := bufio.NewScanner(rdr)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := strings.ToLower(strings.TrimSpace(strings.SplitN(scanner.Text(), "#", 2)[0]))
+		if line == "" {
+			continue
+		}
+		if !strings.Contains(line, "{word}") {
+			return nil, fmt.Errorf("Line %d: Expected {word} in %s", lineNo, line)
+		}
+		rules = append(rules, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return rules, nil
+}
+
+// The word followed by its variants.  Variants that aren't valid hostnames
+// are left out.
+func (r PermutationRules) Permute(word string) []string {
+	return r.permute(word, time.Now().Year())
+}
+
+func (r PermutationRules) permute(word string, year int) []string {
+	word = strings.ToLower(word)
+	words := []string{word}
+	seen := map[string]bool{word: true}
+	years := []string{strconv.Itoa(year), strconv.Itoa(year - 1)}
+	for _, rule := range r {
+		variants := []string{strings.Replace(rule, "{word}", word, -1)}
+		if strings.Contains(rule, "{year}") {
+			variants = nil
+			for _, y := range years {
+				variants = append(variants, strings.Replace(strings.Replace(rule, "{word}", word, -1), "{year}", y, -1))
+			}
+		}
+		for _, v := range variants {
+			if !seen[v] && validHostname(v) {
+				seen[v] = true
+				words = append(words, v)
+			}
+		}
+	}
+	return words
+}
+
+func validHostname(name string) bool {
+	for _, label := range strings.Split(name, ".") {
+		if !hostLabelRE.MatchString(label) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,68 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package filter
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParsePermutationRules(t *testing.T) {
+	text := `# Environments
+Dev-{word}
+{word}-old  # retired
+
+{word}{year}
+`
+	rules, err := ParsePermutationRules(strings.NewReader(text))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expected := "dev-{word} {word}-old {word}{year}"
+	if got := strings.Join(rules, " "); got != expected {
+		t.Errorf("Expected %s, got %s", expected, got)
+	}
+	if _, err := ParsePermutationRules(strings.NewReader("dev-\n")); err == nil || !strings.Contains(err.Error(), "Line 1") {
+		t.Errorf("Expected error on line 1, got %v", err)
+	}
+}
+
+func TestPermute(t *testing.T) {
+	rules := PermutationRules{"dev-{word}", "{word}-{year}", "{word}.eu-west-1", "-{word}", "{word}_old", "{word}-dev", "dev-{word}"}
+	got := strings.Join(rules.permute("API", 2024), " ")
+	expected := "api dev-api api-2024 api-2023 api.eu-west-1 api-dev"
+	if got != expected {
+		t.Errorf("Expected %s, got %s", expected, got)
+	}
+}
+
+func TestDefaultPermutationRules(t *testing.T) {
+	words := DefaultPermutationRules.Permute("www")
+	if words[0] != "www" {
+		t.Errorf("Expected www first, got %s", words[0])
+	}
+	if len(words) < len(DefaultPermutationRules) {
+		t.Errorf("Expected at least %d words, got %d", len(DefaultPermutationRules), len(words))
+	}
+	for _, want := range []string{"dev-www", "www-old", "www.staging", "www.us-east-1"} {
+		found := false
+		for _, w := range words {
+			found = found || w == want
+		}
+		if !found {
+			t.Errorf("Expected %s in %v", want, words)
+		}
+	}
+}
@@ -0,0 +1,172 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package filter
+
+import (
+	"bufio"
+	"fmt"
+	"github.com/Matir/gobuster/logging"
+	"github.com/Matir/gobuster/results"
+	"github.com/Matir/gobuster/settings"
+	"io"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// ResultRule declares results that are expected (and so boring) for an
+// extension.  Rules are written one per line as:
+//
+//	.ext:codes [unless length>N|length<N]
+//
+// where codes is a comma-separated list of status codes or "*", and N may
+// have a K, M or G suffix.  For example:
+//
+//	.js:200 unless length>100k
+//	.png:*
+type ResultRule struct {
+	// Extension including the dot
+	Extension string
+	// Matching codes, empty means any
+	Codes []int
+	// Exception on length, if unlessOp is set
+	unlessOp     byte
+	unlessLength int64
+}
+
+type ResultRules []ResultRule
+
+func LoadResultRules(filename string) (ResultRules, error) {
+	fp, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer fp.Close()
+	return ParseResultRules(fp)
+}
+
+func ParseResultRules(rdr io.Reader) (ResultRules, error) {
+	rules := make(ResultRules, 0)
+	scanner := bufio.NewScanner(rdr)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(strings.SplitN(scanner.Text(), "#", 2)[0])
+		if line == "" {
+			continue
+		}
+		rule, err := parseResultRule(line)
+		if err != nil {
+			return nil, fmt.Errorf("Line %d: %s", lineNo, err.Error())
+		}
+		rules = append(rules, rule)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return rules, nil
+}
+
+func parseResultRule(line string) (ResultRule, error) {
+	rule := ResultRule{}
+	fields := strings.Fields(line)
+	spec := strings.SplitN(fields[0], ":", 2)
+	if len(spec) != 2 || !strings.HasPrefix(spec[0], ".") {
+		return rule, fmt.Errorf("Expected .ext:codes, got %s", fields[0])
+	}
+	rule.Extension = strings.ToLower(spec[0])
+	if spec[1] != "*" {
+		for _, c := range strings.Split(spec[1], ",") {
+			code, err := strconv.Atoi(strings.TrimSpace(c))
+			if err != nil {
+				return rule, fmt.Errorf("Invalid status code %s", c)
+			}
+			rule.Codes = append(rule.Codes, code)
+		}
+	}
+	switch {
+	case len(fields) == 1:
+		return rule, nil
+	case len(fields) == 3 && fields[1] == "unless":
+		cond := strings.ToLower(fields[2])
+		if !strings.HasPrefix(cond, "length") || len(cond) < 8 {
+			return rule, fmt.Errorf("Invalid condition %s", fields[2])
+		}
+		rule.unlessOp = cond[6]
+		if rule.unlessOp != '>' && rule.unlessOp != '<' {
+			return rule, fmt.Errorf("Invalid operator in %s", fields[2])
+		}
+		n, err := settings.ParseByteSize(cond[7:])
+		if err != nil {
+			return rule, err
+		}
+		rule.unlessLength = n
+		return rule, nil
+	}
+	return rule, fmt.Errorf("Unable to parse rule %s", line)
+}
+
+// Check if a result is expected by this rule.
+func (r ResultRule) Matches(res results.Result) bool {
+	if res.URL == nil || strings.ToLower(path.Ext(res.URL.Path)) != r.Extension {
+		return false
+	}
+	if len(r.Codes) > 0 {
+		found := false
+		for _, c := range r.Codes {
+			if c == res.Code {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	switch r.unlessOp {
+	case '>':
+		return res.Length <= r.unlessLength
+	case '<':
+		return res.Length >= r.unlessLength
+	}
+	return true
+}
+
+// A result is boring if any rule matches it.
+func (rules ResultRules) Boring(res results.Result) bool {
+	for _, r := range rules {
+		if r.Matches(res) {
+			return true
+		}
+	}
+	return false
+}
+
+// Filter boring results out of a results channel.
+func (rules ResultRules) FilterResults(src <-chan results.Result) <-chan results.Result {
+	c := make(chan results.Result, cap(src))
+	go func() {
+		for res := range src {
+			if rules.Boring(res) {
+				logging.Logf(logging.LogDebug, "Suppressing expected result %s (%d).", res.URL, res.Code)
+				continue
+			}
+			c <- res
+		}
+		close(c)
+	}()
+	return c
+}
@@ -0,0 +1,91 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package filter
+
+import (
+	"github.com/Matir/gobuster/results"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestParseResultRules(t *testing.T) {
+	text := `# Assets
+.js:200 unless length>100k
+.png:*
+.css:200,304
+`
+	rules, err := ParseResultRules(strings.NewReader(text))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(rules) != 3 {
+		t.Fatalf("Expected 3 rules, got %d", len(rules))
+	}
+	if rules[0].unlessOp != '>' || rules[0].unlessLength != 100*1024 {
+		t.Errorf("Unexpected condition: %c %d", rules[0].unlessOp, rules[0].unlessLength)
+	}
+	if len(rules[2].Codes) != 2 {
+		t.Errorf("Expected 2 codes, got %v", rules[2].Codes)
+	}
+}
+
+func TestParseResultRules_Invalid(t *testing.T) {
+	for _, text := range []string{"js:200", ".js:abc", ".js:200 unless size>1", ".js:200 if length>1"} {
+		if _, err := ParseResultRules(strings.NewReader(text)); err == nil {
+			t.Errorf("Expected error parsing %q", text)
+		}
+	}
+}
+
+func TestResultRules_Boring(t *testing.T) {
+	rules, _ := ParseResultRules(strings.NewReader(".js:200 unless length>100k\n"))
+	mk := func(p string, code int, length int64) results.Result {
+		return results.Result{URL: &url.URL{Path: p}, Code: code, Length: length}
+	}
+	cases := []struct {
+		res      results.Result
+		expected bool
+	}{
+		{mk("/app.js", 200, 1000), true},
+		{mk("/app.JS", 200, 1000), true},
+		{mk("/app.js", 200, 200*1024), false},
+		{mk("/app.js", 403, 1000), false},
+		{mk("/app.php", 200, 1000), false},
+	}
+	for _, c := range cases {
+		if rules.Boring(c.res) != c.expected {
+			t.Errorf("Expected %v for %s %d %d", c.expected, c.res.URL, c.res.Code, c.res.Length)
+		}
+	}
+}
+
+func TestResultRules_FilterResults(t *testing.T) {
+	rules, _ := ParseResultRules(strings.NewReader(".png:*\n"))
+	src := make(chan results.Result, 2)
+	src <- results.Result{URL: &url.URL{Path: "/a.png"}, Code: 200}
+	src <- results.Result{URL: &url.URL{Path: "/b.php"}, Code: 200}
+	close(src)
+	count := 0
+	for res := range rules.FilterResults(src) {
+		count++
+		if res.URL.Path != "/b.php" {
+			t.Errorf("Unexpected result %s", res.URL.Path)
+		}
+	}
+	if count != 1 {
+		t.Errorf("Expected 1 result, got %d", count)
+	}
+}
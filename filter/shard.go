@@ -0,0 +1,84 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package filter
+
+import (
+	"hash/fnv"
+	"net/url"
+	"strings"
+)
+
+// Shard is one of several slices of a scan, for splitting it across machines
+// without coordination.  Only the URLs directly under each target (or, in dns
+// mode, its subdomains) are divided up, by a hash of their name, since every
+// slice finds the same ones.  Anything deeper is only reached through one of
+// those, so stays in the slice that found it.  A nil Shard includes
+// everything.
+type Shard struct {
+	// Slice to scan, from 0
+	index uint32
+	count uint32
+	scope []*url.URL
+}
+
+// Create slice n (from 1) of m of the URL space under scope.
+func NewShard(n, m int, scope []*url.URL) *Shard {
+	if m <= 1 {
+		return nil
+	}
+	return &Shard{index: uint32(n - 1), count: uint32(m), scope: scope}
+}
+
+// Whether u belongs to this slice.
+func (s *Shard) Includes(u *url.URL) bool {
+	if s == nil {
+		return true
+	}
+	key, ok := s.key(u)
+	if !ok {
+		return true
+	}
+	h := fnv.New32a()
+	h.Write([]byte(key))
+	return h.Sum32()%s.count == s.index
+}
+
+// What u is divided up by, if it is divided up at all.  A word and its
+// directory, such as /admin and /admin/, share a key.
+func (s *Shard) key(u *url.URL) (string, bool) {
+	for _, base := range s.scope {
+		if u.Scheme != base.Scheme {
+			continue
+		}
+		if u.Host == base.Host {
+			dir := base.Path
+			if !strings.HasSuffix(dir, "/") {
+				dir += "/"
+			}
+			if !strings.HasPrefix(u.Path, dir) {
+				continue
+			}
+			name := strings.TrimSuffix(u.Path[len(dir):], "/")
+			if name == "" || strings.Contains(name, "/") {
+				return "", false
+			}
+			return u.Host + "/" + name, true
+		}
+		if strings.HasSuffix(u.Host, "."+base.Host) && u.Path == base.Path {
+			return u.Host, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,83 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package filter
+
+import (
+	"fmt"
+	"net/url"
+	"testing"
+)
+
+func shardsIncluding(shards []*Shard, u *url.URL) int {
+	n := 0
+	for _, s := range shards {
+		if s.Includes(u) {
+			n++
+		}
+	}
+	return n
+}
+
+func TestShard_Includes(t *testing.T) {
+	scope := []*url.URL{
+		{Scheme: "http", Host: "example.com", Path: "/"},
+		{Scheme: "http", Host: "other.com", Path: "/app"},
+	}
+	var shards []*Shard
+	for n := 1; n <= 4; n++ {
+		shards = append(shards, NewShard(n, 4, scope))
+	}
+	perShard := make([]int, 4)
+	for i := 0; i < 100; i++ {
+		word := fmt.Sprintf("word%d", i)
+		for _, raw := range []string{"http://example.com/" + word, "http://other.com/app/" + word, "http://" + word + ".example.com/"} {
+			u, _ := url.Parse(raw)
+			if n := shardsIncluding(shards, u); n != 1 {
+				t.Errorf("Expected %s in exactly 1 shard, got %d", raw, n)
+			}
+		}
+		file, _ := url.Parse("http://example.com/" + word)
+		dir, _ := url.Parse("http://example.com/" + word + "/")
+		for j, s := range shards {
+			if s.Includes(file) != s.Includes(dir) {
+				t.Errorf("Expected %s and its directory in the same shard.", file)
+			}
+			if s.Includes(file) {
+				perShard[j]++
+			}
+		}
+	}
+	for j, n := range perShard {
+		if n == 0 {
+			t.Errorf("Expected shard %d to get some words.", j+1)
+		}
+	}
+	for _, raw := range []string{"http://example.com/", "http://example.com/a/b", "http://other.com/app", "http://elsewhere.com/a"} {
+		u, _ := url.Parse(raw)
+		if n := shardsIncluding(shards, u); n != 4 {
+			t.Errorf("Expected %s in every shard, got %d", raw, n)
+		}
+	}
+}
+
+func TestShard_Nil(t *testing.T) {
+	if s := NewShard(1, 1, nil); s != nil {
+		t.Errorf("Expected no shard for 1/1, got %v", s)
+	}
+	var s *Shard
+	if !s.Includes(&url.URL{Path: "/a"}) {
+		t.Errorf("Expected nil shard to include everything.")
+	}
+}
@@ -0,0 +1,109 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package history keeps a local record of completed scans, so the same scan
+// isn't accidentally run against a target twice.
+package history
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Entry records one completed scan of one target.
+type Entry struct {
+	Target       string    `json:"target"`
+	WordlistHash string    `json:"wordlist_hash"`
+	SettingsHash string    `json:"settings_hash"`
+	Completed    time.Time `json:"completed"`
+}
+
+// History is stored as one JSON entry per line, appended as scans complete.
+type History struct {
+	path    string
+	entries []Entry
+	sync.Mutex
+}
+
+// Load history from path.  A missing file is an empty history.
+func Load(path string) (*History, error) {
+	h := &History{path: path}
+	fp, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return h, nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer fp.Close()
+	scanner := bufio.NewScanner(fp)
+	for scanner.Scan() {
+		var e Entry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			// Skip corrupt lines rather than losing the whole history
+			continue
+		}
+		h.entries = append(h.entries, e)
+	}
+	return h, scanner.Err()
+}
+
+// Find the most recent matching scan completed within window, if any.
+func (h *History) FindRecent(target, wordlistHash, settingsHash string, window time.Duration) *Entry {
+	h.Lock()
+	defer h.Unlock()
+	cutoff := time.Now().Add(-window)
+	var found *Entry
+	for i := range h.entries {
+		e := &h.entries[i]
+		if e.Target != target || e.WordlistHash != wordlistHash || e.SettingsHash != settingsHash {
+			continue
+		}
+		if e.Completed.After(cutoff) && (found == nil || e.Completed.After(found.Completed)) {
+			found = e
+		}
+	}
+	return found
+}
+
+// Record a completed scan.
+func (h *History) Record(e Entry) error {
+	h.Lock()
+	defer h.Unlock()
+	line, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	fp, err := os.OpenFile(h.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	defer fp.Close()
+	if _, err := fp.Write(append(line, '\n')); err != nil {
+		return err
+	}
+	h.entries = append(h.entries, e)
+	return nil
+}
+
+// Hash a wordlist for comparison between scans.
+func HashWordlist(words []string) string {
+	sum := sha256.Sum256([]byte(strings.Join(words, "\n")))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,63 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package history
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestHistory_RecordAndFind(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gobuster-history")
+	if err != nil {
+		t.Fatalf("Unable to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "history")
+
+	h, err := Load(path)
+	if err != nil {
+		t.Fatalf("Expected empty history, got error %v", err)
+	}
+	if e := h.FindRecent("http://localhost/", "a", "b", time.Hour); e != nil {
+		t.Errorf("Expected no entry, got %v", e)
+	}
+	h.Record(Entry{Target: "http://localhost/", WordlistHash: "a", SettingsHash: "b", Completed: time.Now()})
+	h.Record(Entry{Target: "http://old/", WordlistHash: "a", SettingsHash: "b", Completed: time.Now().Add(-48 * time.Hour)})
+
+	// Reload from disk
+	h, err = Load(path)
+	if err != nil {
+		t.Fatalf("Error loading history: %v", err)
+	}
+	if e := h.FindRecent("http://localhost/", "a", "b", time.Hour); e == nil {
+		t.Error("Expected to find recent scan.")
+	}
+	if e := h.FindRecent("http://localhost/", "a", "c", time.Hour); e != nil {
+		t.Error("Expected differing settings not to match.")
+	}
+	if e := h.FindRecent("http://old/", "a", "b", time.Hour); e != nil {
+		t.Error("Expected old scan outside window not to match.")
+	}
+}
+
+func TestHashWordlist(t *testing.T) {
+	if HashWordlist([]string{"a", "b"}) == HashWordlist([]string{"ab"}) {
+		t.Error("Expected distinct hashes.")
+	}
+}
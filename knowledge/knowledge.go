@@ -0,0 +1,227 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package knowledge keeps everything ever found on each target across scans,
+// so later scans of the same target can skip what is already known or report
+// only what changed.
+package knowledge
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/Matir/gobuster/results"
+	"github.com/Matir/gobuster/util"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Entry is what is known about one path.
+type Entry struct {
+	Code         int       `json:"code"`
+	Length       int64     `json:"length"`
+	BodyHash     string    `json:"body_hash,omitempty"`
+	ContentType  string    `json:"content_type,omitempty"`
+	ETag         string    `json:"etag,omitempty"`
+	LastModified string    `json:"last_modified,omitempty"`
+	Words        int       `json:"words,omitempty"`
+	Lines        int       `json:"lines,omitempty"`
+	FirstSeen    time.Time `json:"first_seen"`
+	LastSeen     time.Time `json:"last_seen"`
+}
+
+// Whether a result differs from what is known.
+func (e *Entry) Changed(r results.Result) bool {
+	if e.Code != r.Code {
+		return true
+	}
+	if e.BodyHash != "" && r.BodyHash != "" {
+		return e.BodyHash != r.BodyHash
+	}
+	return e.Length != r.Length
+}
+
+// Target holds the known paths of one origin.
+type Target struct {
+	Paths map[string]*Entry `json:"paths"`
+}
+
+// Base is the knowledge base, stored as a single JSON file keyed by origin.
+// A nil Base knows nothing and records nothing.
+type Base struct {
+	path    string
+	mu      sync.Mutex
+	Targets map[string]*Target `json:"targets"`
+}
+
+// Load the knowledge base from path.  A missing file is an empty base.
+func Load(path string) (*Base, error) {
+	b := &Base{path: path, Targets: make(map[string]*Target)}
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return b, nil
+	} else if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, b); err != nil {
+		return nil, fmt.Errorf("Invalid knowledge base %s: %s", path, err.Error())
+	}
+	if b.Targets == nil {
+		b.Targets = make(map[string]*Target)
+	}
+	return b, nil
+}
+
+// Key of the target a URL belongs to.
+func origin(u *url.URL) string {
+	return u.Scheme + "://" + u.Host
+}
+
+func pathOf(u *url.URL) string {
+	if u.RawQuery != "" {
+		return u.EscapedPath() + "?" + u.RawQuery
+	}
+	return u.EscapedPath()
+}
+
+// Look up what is known about a URL, nil if nothing.
+func (b *Base) Lookup(u *url.URL) *Entry {
+	if b == nil {
+		return nil
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	t, ok := b.Targets[origin(u)]
+	if !ok {
+		return nil
+	}
+	return t.Paths[pathOf(u)]
+}
+
+// Known URLs under any of the scopes, sorted.
+func (b *Base) Known(scope ...*url.URL) []*url.URL {
+	if b == nil {
+		return nil
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	var known []*url.URL
+	seen := make(map[string]bool)
+	for _, s := range scope {
+		t, ok := b.Targets[origin(s)]
+		if !ok {
+			continue
+		}
+		paths := make([]string, 0, len(t.Paths))
+		for p := range t.Paths {
+			paths = append(paths, p)
+		}
+		sort.Strings(paths)
+		for _, p := range paths {
+			u, err := s.Parse(p)
+			if err != nil || seen[u.String()] || !util.URLIsSubpath(s, u) {
+				continue
+			}
+			seen[u.String()] = true
+			known = append(known, u)
+		}
+	}
+	return known
+}
+
+// Record a result, returning whether it is new or changed.  Only reportable
+// results are kept.
+func (b *Base) Record(r results.Result) bool {
+	if b == nil || r.URL == nil || !results.ReportResult(r) {
+		return false
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	key := origin(r.URL)
+	t, ok := b.Targets[key]
+	if !ok {
+		t = &Target{Paths: make(map[string]*Entry)}
+		b.Targets[key] = t
+	}
+	now := time.Now()
+	p := pathOf(r.URL)
+	prev := t.Paths[p]
+	e := &Entry{
+		Code:         r.Code,
+		Length:       r.Length,
+		BodyHash:     r.BodyHash,
+		ContentType:  r.ContentType,
+		ETag:         r.ETag,
+		LastModified: r.LastModified,
+		Words:        r.Words,
+		Lines:        r.Lines,
+		FirstSeen:    now,
+		LastSeen:     now,
+	}
+	if prev != nil {
+		e.FirstSeen = prev.FirstSeen
+	}
+	t.Paths[p] = e
+	return prev == nil || prev.Changed(r)
+}
+
+// Record results as they pass through.  With onlyNew, results already known
+// and unchanged are dropped.
+func (b *Base) Watch(in <-chan results.Result, onlyNew bool) <-chan results.Result {
+	if b == nil {
+		return in
+	}
+	out := make(chan results.Result, cap(in))
+	go func() {
+		defer close(out)
+		for r := range in {
+			if !b.Record(r) && onlyNew && results.ReportResult(r) {
+				continue
+			}
+			out <- r
+		}
+	}()
+	return out
+}
+
+// Write the knowledge base back to its file, replacing it atomically.
+func (b *Base) Save() error {
+	if b == nil {
+		return nil
+	}
+	b.mu.Lock()
+	data, err := json.Marshal(b)
+	b.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	tmp, err := ioutil.TempFile(filepath.Dir(b.path), ".gobuster-kb")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), b.path)
+}
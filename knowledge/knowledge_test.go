@@ -0,0 +1,158 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package knowledge
+
+import (
+	"github.com/Matir/gobuster/results"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func mustParse(t *testing.T, s string) *url.URL {
+	u, err := url.Parse(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return u
+}
+
+func tempBase(t *testing.T) (*Base, func()) {
+	dir, err := ioutil.TempDir("", "gobuster-kb")
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := Load(filepath.Join(dir, "kb.json"))
+	if err != nil {
+		os.RemoveAll(dir)
+		t.Fatal(err)
+	}
+	return b, func() { os.RemoveAll(dir) }
+}
+
+func TestRecord(t *testing.T) {
+	b, cleanup := tempBase(t)
+	defer cleanup()
+	u := mustParse(t, "http://localhost/admin/")
+	r := results.Result{URL: u, Code: 200, Length: 10, BodyHash: "abc"}
+	if !b.Record(r) {
+		t.Errorf("Expected first result to be new.")
+	}
+	if b.Record(r) {
+		t.Errorf("Expected repeated result to be known.")
+	}
+	r.BodyHash = "def"
+	if !b.Record(r) {
+		t.Errorf("Expected changed body to be new.")
+	}
+	if b.Record(results.Result{URL: mustParse(t, "http://localhost/missing"), Code: 404}) {
+		t.Errorf("Expected 404 not to be recorded.")
+	}
+	if e := b.Lookup(u); e == nil || e.BodyHash != "def" {
+		t.Errorf("Expected latest entry, got %v", e)
+	}
+	if e := b.Lookup(mustParse(t, "https://localhost/admin/")); e != nil {
+		t.Errorf("Expected other origin to be unknown, got %v", e)
+	}
+}
+
+func TestKnown(t *testing.T) {
+	b, cleanup := tempBase(t)
+	defer cleanup()
+	for _, s := range []string{"http://localhost/b/", "http://localhost/a/x", "http://localhost/c", "http://other/a"} {
+		b.Record(results.Result{URL: mustParse(t, s), Code: 200})
+	}
+	known := b.Known(mustParse(t, "http://localhost/a/"), mustParse(t, "http://localhost/"))
+	expected := []string{"http://localhost/a/x", "http://localhost/b/", "http://localhost/c"}
+	if len(known) != len(expected) {
+		t.Fatalf("Expected %v, got %v", expected, known)
+	}
+	for i, u := range known {
+		if u.String() != expected[i] {
+			t.Errorf("Expected %s, got %s", expected[i], u)
+		}
+	}
+}
+
+func TestSaveLoad(t *testing.T) {
+	b, cleanup := tempBase(t)
+	defer cleanup()
+	u := mustParse(t, "http://localhost/index.php?id=1")
+	b.Record(results.Result{URL: u, Code: 200, ContentType: "text/html"})
+	if err := b.Save(); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := Load(b.path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e := loaded.Lookup(u); e == nil || e.ContentType != "text/html" || e.FirstSeen.IsZero() {
+		t.Errorf("Expected saved entry, got %v", e)
+	}
+}
+
+func TestLoad_Invalid(t *testing.T) {
+	fp, err := ioutil.TempFile("", "gobuster-kb")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(fp.Name())
+	fp.WriteString("not json")
+	fp.Close()
+	if _, err := Load(fp.Name()); err == nil {
+		t.Errorf("Expected error for invalid knowledge base.")
+	}
+}
+
+func TestWatch(t *testing.T) {
+	b, cleanup := tempBase(t)
+	defer cleanup()
+	known := results.Result{URL: mustParse(t, "http://localhost/old"), Code: 200}
+	b.Record(known)
+	in := make(chan results.Result, 3)
+	in <- known
+	in <- results.Result{URL: mustParse(t, "http://localhost/new"), Code: 200}
+	in <- results.Result{URL: mustParse(t, "http://localhost/gone"), Code: 404}
+	close(in)
+	var got []string
+	for r := range b.Watch(in, true) {
+		got = append(got, r.URL.Path)
+	}
+	if len(got) != 2 || got[0] != "/new" || got[1] != "/gone" {
+		t.Errorf("Expected only new and unreported results, got %v", got)
+	}
+	if b.Lookup(mustParse(t, "http://localhost/new")) == nil {
+		t.Errorf("Expected new result to be recorded.")
+	}
+}
+
+func TestNil(t *testing.T) {
+	var b *Base
+	in := make(chan results.Result)
+	if b.Watch(in, true) != (<-chan results.Result)(in) {
+		t.Errorf("Expected nil base to pass results through.")
+	}
+	if b.Record(results.Result{URL: mustParse(t, "http://localhost/"), Code: 200}) {
+		t.Errorf("Expected nil base to record nothing.")
+	}
+	if b.Lookup(mustParse(t, "http://localhost/")) != nil || b.Known(mustParse(t, "http://localhost/")) != nil {
+		t.Errorf("Expected nil base to know nothing.")
+	}
+	if err := b.Save(); err != nil {
+		t.Errorf("Expected no error, got %s", err)
+	}
+}
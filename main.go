@@ -16,27 +16,52 @@
 package main
 
 import (
-	"github.com/Matir/gobuster/client"
-	"github.com/Matir/gobuster/filter"
+	"context"
+	"github.com/Matir/gobuster/audit"
 	"github.com/Matir/gobuster/logging"
-	"github.com/Matir/gobuster/results"
+	"github.com/Matir/gobuster/scan"
 	ss "github.com/Matir/gobuster/settings"
 	"github.com/Matir/gobuster/util"
 	"github.com/Matir/gobuster/wordlist"
-	"github.com/Matir/gobuster/worker"
-	"github.com/Matir/gobuster/workqueue"
+	"os"
+	"os/signal"
 	"runtime"
 )
 
-// This is the main runner for gobuster.
-// TODO: separate the actual scanning from all of the setup steps
+// This is the main runner for gobuster, which sets up and leaves the scanning
+// to the scan package.
 func main() {
 	util.EnableStackTraces()
 
+	if len(os.Args) > 1 && os.Args[1] == "wordlist" {
+		if err := wordlist.RunCommand(os.Args[2:], os.Stdout); err != nil {
+			logging.Logf(logging.LogFatal, "%s", err)
+		}
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "audit" {
+		if err := audit.RunCommand(os.Args[2:], os.Stdout); err != nil {
+			logging.Logf(logging.LogFatal, "%s", err)
+		}
+		return
+	}
+
 	// Load scan settings
 	settings, err := ss.GetScanSettings()
 	if err != nil {
-		logging.Logf(logging.LogFatal, err.Error())
+		logging.Logf(logging.LogFatal, "%s", err)
+		return
+	}
+	if settings.Completion != "" {
+		if err := settings.WriteCompletion(os.Stdout, settings.Completion); err != nil {
+			logging.Logf(logging.LogFatal, "%s", err)
+		}
+		return
+	}
+	if settings.Wizard {
+		if err := ss.RunWizard(os.Stdin, os.Stdout, ss.UserConfigPath()); err != nil {
+			logging.Logf(logging.LogFatal, "%s", err)
+		}
 		return
 	}
 	logging.ResetLog(settings.LogfilePath, settings.LogLevel)
@@ -52,81 +77,27 @@ func main() {
 	logging.Logf(logging.LogDebug, "Setting GOMAXPROCS to %d.", settings.Threads)
 	runtime.GOMAXPROCS(settings.Threads)
 
-	// Load wordlist
-	var words []string
-	words, err = wordlist.LoadWordlist(settings.WordlistPath)
-	if err != nil {
-		logging.Logf(logging.LogFatal, "Unable to load wordlist: %s", err.Error())
-		return
+	// With a state file, Ctrl+C saves it so the scan can be resumed, and a
+	// second Ctrl+C exits at once
+	ctx := context.Background()
+	if settings.StatePath != "" || settings.ResumePath != "" {
+		var stop context.CancelFunc
+		ctx, stop = signal.NotifyContext(ctx, os.Interrupt)
+		go func() {
+			<-ctx.Done()
+			stop()
+		}()
 	}
-
-	// Build an HTTP Client Factory
-	logging.Logf(logging.LogDebug, "Creating Client Factory...")
-	clientFactory, err := client.NewProxyClientFactory(settings.Proxies, settings.Timeout, settings.UserAgent)
-	if err != nil {
-		logging.Logf(logging.LogFatal, "Unable to build client factory: %s", err.Error())
-		return
-	}
-
-	// Starting point
-	scope, err := settings.GetScopes()
-	if err != nil {
-		logging.Logf(logging.LogFatal, err.Error())
-		return
+	err = scan.Run(ctx, settings, nil)
+	if cpuProfStop != nil {
+		cpuProfStop()
 	}
-
-	// Setup the main workqueue
-	logging.Logf(logging.LogDebug, "Starting work queue...")
-	queue := workqueue.NewWorkQueue(settings.QueueSize, scope, settings.AllowHTTPSUpgrade)
-	queue.RunInBackground()
-
-	logging.Logf(logging.LogDebug, "Creating expander and filter...")
-	expander := filter.Expander{Wordlist: &words, Adder: queue.GetAddCount()}
-	expander.ProcessWordlist()
-	filter := filter.NewWorkFilter(settings, queue.GetDoneFunc())
-
-	// Check robots mode
-	if settings.RobotsMode == ss.ObeyRobots {
-		filter.AddRobotsFilter(scope, clientFactory)
+	if err == scan.ErrPaused || err == scan.ErrStopped {
+		os.Exit(1)
 	}
-
-	work := filter.RunFilter(expander.Expand(queue.GetWorkChan()))
-
-	logging.Logf(logging.LogDebug, "Creating results manager...")
-	rchan := make(chan results.Result, settings.QueueSize)
-	resultsManager, err := results.GetResultsManager(settings)
 	if err != nil {
-		logging.Logf(logging.LogFatal, "Unable to start results manager: %s", err.Error())
+		logging.Logf(logging.LogFatal, "%s", err.Error())
 		return
 	}
-
-	logging.Logf(logging.LogDebug, "Starting %d workers...", settings.Workers)
-	worker.StartWorkers(settings, clientFactory, work, queue.GetAddFunc(), queue.GetDoneFunc(), rchan)
-
-	logging.Logf(logging.LogDebug, "Starting results manager...")
-	resultsManager.Run(rchan)
-
-	// Kick things off with the seed URL
-	logging.Logf(logging.LogDebug, "Adding starting URLs: %v", scope)
-	queue.AddURLs(scope...)
-
-	// Potentially seed from robots
-	if settings.RobotsMode == ss.SeedRobots {
-		queue.SeedFromRobots(scope, clientFactory)
-	}
-
-	// Wait for work to be done
-	logging.Logf(logging.LogDebug, "Main goroutine waiting for work...")
-	queue.WaitPipe()
-	logging.Logf(logging.LogDebug, "Work done.")
-
-	// Cleanup
-	queue.InputFinished()
-	close(rchan)
-
-	resultsManager.Wait()
-	if cpuProfStop != nil {
-		cpuProfStop()
-	}
 	logging.Logf(logging.LogDebug, "Done!")
 }
@@ -0,0 +1,230 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package pipeline assembles the results pipeline declared in the config
+// file: filters drop results, annotators add to them, and writers output
+// them.
+package pipeline
+
+import (
+	"fmt"
+	"github.com/Matir/gobuster/logging"
+	"github.com/Matir/gobuster/redact"
+	"github.com/Matir/gobuster/results"
+	ss "github.com/Matir/gobuster/settings"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Filter decides which results continue down the pipeline.
+type Filter interface {
+	Keep(results.Result) bool
+}
+
+// Annotator adds information to results.
+type Annotator interface {
+	Annotate(*results.Result)
+}
+
+// Options of a pipeline stage, with helpers for parsing them.
+type Options map[string]string
+
+// FilterFactory builds a Filter from its options.
+type FilterFactory func(Options) (Filter, error)
+
+// AnnotatorFactory builds an Annotator from its options.
+type AnnotatorFactory func(Options) (Annotator, error)
+
+var filters = make(map[string]FilterFactory)
+var annotators = make(map[string]AnnotatorFactory)
+
+// Register a filter type.  Should be called from an init function.
+func RegisterFilter(name string, factory FilterFactory) error {
+	if _, ok := filters[name]; ok {
+		return fmt.Errorf("Filter %s already registered.", name)
+	}
+	filters[name] = factory
+	return nil
+}
+
+// Register an annotator type.  Should be called from an init function.
+func RegisterAnnotator(name string, factory AnnotatorFactory) error {
+	if _, ok := annotators[name]; ok {
+		return fmt.Errorf("Annotator %s already registered.", name)
+	}
+	annotators[name] = factory
+	return nil
+}
+
+// Names of the registered filters and annotators, sorted.
+func FilterNames() []string {
+	names := make([]string, 0, len(filters))
+	for name := range filters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func AnnotatorNames() []string {
+	names := make([]string, 0, len(annotators))
+	for name := range annotators {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Pipeline runs results through filters, then annotators, then sends them to
+// every writer.  It is itself a ResultsManager.
+type Pipeline struct {
+	filters    []Filter
+	annotators []Annotator
+	writer     results.ResultsManager
+}
+
+// Build the pipeline declared in settings.  Filters and annotators run in the
+// order they are declared, filters first.  If no writers are declared, the
+// outputs from the command line are used.
+func New(settings *ss.ScanSettings) (*Pipeline, error) {
+	p := &Pipeline{}
+	var writers []results.ResultsManager
+	for _, stage := range settings.Pipeline {
+		opts := Options(stage.Options)
+		switch stage.Kind {
+		case "filter":
+			factory, ok := filters[stage.Type]
+			if !ok {
+				return nil, fmt.Errorf("Unknown filter %s, expected one of %s.", stage.Type, strings.Join(FilterNames(), ", "))
+			}
+			f, err := factory(opts)
+			if err != nil {
+				return nil, fmt.Errorf("Filter %s: %s", stage.Type, err.Error())
+			}
+			p.filters = append(p.filters, f)
+		case "annotate":
+			factory, ok := annotators[stage.Type]
+			if !ok {
+				return nil, fmt.Errorf("Unknown annotator %s, expected one of %s.", stage.Type, strings.Join(AnnotatorNames(), ", "))
+			}
+			a, err := factory(opts)
+			if err != nil {
+				return nil, fmt.Errorf("Annotator %s: %s", stage.Type, err.Error())
+			}
+			p.annotators = append(p.annotators, a)
+		case "write":
+			rm, err := results.NewResultsManager(stage.Type, opts["path"], settings)
+			if err != nil {
+				return nil, fmt.Errorf("Writer %s: %s", stage.Type, err.Error())
+			}
+			writers = append(writers, rm)
+		default:
+			return nil, fmt.Errorf("Unknown pipeline stage %s.", stage.Kind)
+		}
+	}
+	// Last, so that every other annotation is masked too
+	redactor, err := redact.New(settings.Redact, settings.RedactPatterns)
+	if err != nil {
+		return nil, err
+	}
+	if redactor != nil {
+		p.annotators = append(p.annotators, &redactAnnotator{redactor: redactor})
+	}
+	switch len(writers) {
+	case 0:
+		rm, err := results.GetResultsManager(settings)
+		if err != nil {
+			return nil, err
+		}
+		p.writer = rm
+	case 1:
+		p.writer = writers[0]
+	default:
+		p.writer = results.NewMultiResultsManager(writers...)
+	}
+	return p, nil
+}
+
+func (p *Pipeline) Run(src <-chan results.Result) {
+	out := make(chan results.Result, cap(src))
+	p.writer.Run(out)
+	go func() {
+		defer close(out)
+		for r := range src {
+			if p.Process(&r) {
+				out <- r
+			}
+		}
+	}()
+}
+
+func (p *Pipeline) Wait() {
+	p.writer.Wait()
+}
+
+// Run a result through the filters and annotators, returning whether it is
+// kept.
+func (p *Pipeline) Process(r *results.Result) bool {
+	for _, f := range p.filters {
+		if !f.Keep(*r) {
+			logging.Logf(logging.LogDebug, "Pipeline dropped %s (%d).", r.URL, r.Code)
+			return false
+		}
+	}
+	for _, a := range p.annotators {
+		a.Annotate(r)
+	}
+	return true
+}
+
+// Get a required option.
+func (o Options) Required(name string) (string, error) {
+	v, ok := o[name]
+	if !ok || v == "" {
+		return "", fmt.Errorf("missing option %s", name)
+	}
+	return v, nil
+}
+
+// Get a comma-separated list of status codes, or nil if not given.
+func (o Options) Codes(name string) ([]int, error) {
+	v := o[name]
+	if v == "" {
+		return nil, nil
+	}
+	var codes []int
+	for _, c := range strings.Split(v, ",") {
+		code, err := strconv.Atoi(c)
+		if err != nil {
+			return nil, fmt.Errorf("invalid status code %s in %s", c, name)
+		}
+		codes = append(codes, code)
+	}
+	return codes, nil
+}
+
+// Check that only the named options were given.
+func (o Options) Only(names ...string) error {
+	for k := range o {
+		found := false
+		for _, n := range names {
+			found = found || k == n
+		}
+		if !found {
+			return fmt.Errorf("unknown option %s", k)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,152 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pipeline
+
+import (
+	"github.com/Matir/gobuster/results"
+	ss "github.com/Matir/gobuster/settings"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func testResult(path string, code int) results.Result {
+	return results.Result{URL: &url.URL{Scheme: "http", Host: "localhost", Path: path}, Code: code}
+}
+
+func TestNew_Writers(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gobuster-pipeline")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	jsonPath := filepath.Join(dir, "out.json")
+	csvPath := filepath.Join(dir, "out.csv")
+	settings := &ss.ScanSettings{
+		BaseURLs: []string{"http://localhost/"},
+		Pipeline: []ss.PipelineStage{
+			{Kind: "filter", Type: "status", Options: map[string]string{"deny": "403"}},
+			{Kind: "annotate", Type: "tag", Options: map[string]string{"name": "admin", "path": "^/admin"}},
+			{Kind: "write", Type: "json", Options: map[string]string{"path": jsonPath}},
+			{Kind: "write", Type: "csv", Options: map[string]string{"path": csvPath}},
+		},
+	}
+	p, err := New(settings)
+	if err != nil {
+		t.Fatalf("Expected no error, got %s", err)
+	}
+	rchan := make(chan results.Result)
+	p.Run(rchan)
+	rchan <- testResult("/admin/", 200)
+	rchan <- testResult("/secret", 403)
+	rchan <- testResult("/index.html", 200)
+	close(rchan)
+	p.Wait()
+
+	data, _ := ioutil.ReadFile(jsonPath)
+	out := string(data)
+	if !strings.Contains(out, `"metadata":{"tags":"admin"}`) {
+		t.Errorf("Expected tagged admin result, got %s", out)
+	}
+	if strings.Contains(out, "/secret") {
+		t.Errorf("Expected 403 to be filtered, got %s", out)
+	}
+	if !strings.Contains(out, "/index.html") {
+		t.Errorf("Expected index result, got %s", out)
+	}
+	if data, _ := ioutil.ReadFile(csvPath); !strings.Contains(string(data), "/index.html") {
+		t.Errorf("Expected CSV output, got %s", data)
+	}
+}
+
+func TestNew_Redact(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gobuster-pipeline")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	jsonPath := filepath.Join(dir, "out.json")
+	settings := &ss.ScanSettings{
+		BaseURLs:       []string{"http://localhost/"},
+		Redact:         true,
+		RedactPatterns: []string{"prod"},
+		Pipeline: []ss.PipelineStage{
+			{Kind: "annotate", Type: "set", Options: map[string]string{"key": "env", "value": "prod"}},
+			{Kind: "write", Type: "json", Options: map[string]string{"path": jsonPath}},
+		},
+	}
+	p, err := New(settings)
+	if err != nil {
+		t.Fatalf("Expected no error, got %s", err)
+	}
+	rchan := make(chan results.Result)
+	p.Run(rchan)
+	r := testResult("/reset", 200)
+	r.URL.RawQuery = "token=abc123"
+	rchan <- r
+	close(rchan)
+	p.Wait()
+	data, _ := ioutil.ReadFile(jsonPath)
+	if out := string(data); strings.Contains(out, "abc123") || strings.Contains(out, "prod") {
+		t.Errorf("Expected the token and annotation masked, got %s", out)
+	}
+}
+
+func TestNew_Errors(t *testing.T) {
+	for _, stage := range []ss.PipelineStage{
+		{Kind: "filter", Type: "nope"},
+		{Kind: "annotate", Type: "nope"},
+		{Kind: "write", Type: "nope"},
+		{Kind: "filter", Type: "status", Options: map[string]string{"allow": "ok"}},
+		{Kind: "filter", Type: "status", Options: map[string]string{"color": "red"}},
+		{Kind: "frobnicate", Type: "status"},
+	} {
+		settings := &ss.ScanSettings{Pipeline: []ss.PipelineStage{stage}}
+		if _, err := New(settings); err == nil {
+			t.Errorf("Expected error for %s", stage)
+		}
+	}
+}
+
+func TestProcess_Order(t *testing.T) {
+	p := &Pipeline{}
+	f, _ := newStatusFilter(Options{"allow": "200"})
+	a, _ := newSetAnnotator(Options{"key": "env", "value": "prod"})
+	p.filters = []Filter{f}
+	p.annotators = []Annotator{a}
+	r := testResult("/", 404)
+	if p.Process(&r) {
+		t.Errorf("Expected 404 to be dropped.")
+	}
+	if r.Metadata != nil {
+		t.Errorf("Expected dropped result not to be annotated, got %v", r.Metadata)
+	}
+	r = testResult("/", 200)
+	if !p.Process(&r) || r.Metadata["env"] != "prod" {
+		t.Errorf("Expected annotated result, got %v", r.Metadata)
+	}
+}
+
+func TestRegisterFilter_Duplicate(t *testing.T) {
+	if err := RegisterFilter("status", newStatusFilter); err == nil {
+		t.Errorf("Expected error registering duplicate filter.")
+	}
+	if err := RegisterAnnotator("tag", newTagAnnotator); err == nil {
+		t.Errorf("Expected error registering duplicate annotator.")
+	}
+}
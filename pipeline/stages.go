@@ -0,0 +1,276 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pipeline
+
+import (
+	"fmt"
+	"github.com/Matir/gobuster/filter"
+	"github.com/Matir/gobuster/redact"
+	"github.com/Matir/gobuster/results"
+	"regexp"
+)
+
+func init() {
+	RegisterFilter("rules", newRulesFilter)
+	RegisterFilter("status", newStatusFilter)
+	RegisterFilter("path", newPathFilter)
+	RegisterFilter("found", newFoundFilter)
+	RegisterFilter("dedupe", newDedupeFilter)
+	RegisterAnnotator("tag", newTagAnnotator)
+	RegisterAnnotator("set", newSetAnnotator)
+	RegisterAnnotator("redact", newRedactAnnotator)
+}
+
+// rules file=PATH drops results expected by a result rules file.
+type rulesFilter struct {
+	rules filter.ResultRules
+}
+
+func newRulesFilter(opts Options) (Filter, error) {
+	if err := opts.Only("file"); err != nil {
+		return nil, err
+	}
+	path, err := opts.Required("file")
+	if err != nil {
+		return nil, err
+	}
+	rules, err := filter.LoadResultRules(path)
+	if err != nil {
+		return nil, err
+	}
+	return &rulesFilter{rules: rules}, nil
+}
+
+func (f *rulesFilter) Keep(r results.Result) bool {
+	return !f.rules.Boring(r)
+}
+
+// status allow=CODES deny=CODES keeps results by status code.
+type statusFilter struct {
+	allow []int
+	deny  []int
+}
+
+func newStatusFilter(opts Options) (Filter, error) {
+	if err := opts.Only("allow", "deny"); err != nil {
+		return nil, err
+	}
+	f := &statusFilter{}
+	var err error
+	if f.allow, err = opts.Codes("allow"); err != nil {
+		return nil, err
+	}
+	if f.deny, err = opts.Codes("deny"); err != nil {
+		return nil, err
+	}
+	if f.allow == nil && f.deny == nil {
+		return nil, fmt.Errorf("one of allow or deny is required")
+	}
+	return f, nil
+}
+
+func (f *statusFilter) Keep(r results.Result) bool {
+	if containsCode(f.deny, r.Code) {
+		return false
+	}
+	return f.allow == nil || containsCode(f.allow, r.Code)
+}
+
+// path match=REGEX exclude=REGEX keeps results by URL path.
+type pathFilter struct {
+	match   *regexp.Regexp
+	exclude *regexp.Regexp
+}
+
+func newPathFilter(opts Options) (Filter, error) {
+	if err := opts.Only("match", "exclude"); err != nil {
+		return nil, err
+	}
+	f := &pathFilter{}
+	var err error
+	if f.match, err = optionalRegexp(opts, "match"); err != nil {
+		return nil, err
+	}
+	if f.exclude, err = optionalRegexp(opts, "exclude"); err != nil {
+		return nil, err
+	}
+	if f.match == nil && f.exclude == nil {
+		return nil, fmt.Errorf("one of match or exclude is required")
+	}
+	return f, nil
+}
+
+func (f *pathFilter) Keep(r results.Result) bool {
+	if r.URL == nil {
+		return false
+	}
+	if f.exclude != nil && f.exclude.MatchString(r.URL.Path) {
+		return false
+	}
+	return f.match == nil || f.match.MatchString(r.URL.Path)
+}
+
+// found drops errors and not found responses, which some writers include.
+type foundFilter struct{}
+
+func newFoundFilter(opts Options) (Filter, error) {
+	if err := opts.Only(); err != nil {
+		return nil, err
+	}
+	return foundFilter{}, nil
+}
+
+func (foundFilter) Keep(r results.Result) bool {
+	return results.ReportResult(r)
+}
+
+// dedupe drops results with the same body as an earlier one.
+type dedupeFilter struct {
+	seen map[string]bool
+}
+
+func newDedupeFilter(opts Options) (Filter, error) {
+	if err := opts.Only(); err != nil {
+		return nil, err
+	}
+	return &dedupeFilter{seen: make(map[string]bool)}, nil
+}
+
+func (f *dedupeFilter) Keep(r results.Result) bool {
+	if r.BodyHash == "" {
+		return true
+	}
+	if f.seen[r.BodyHash] {
+		return false
+	}
+	f.seen[r.BodyHash] = true
+	return true
+}
+
+// tag name=TAG path=REGEX status=CODES adds TAG to the "tags" metadata of
+// results matching all of the given conditions.
+type tagAnnotator struct {
+	name  string
+	path  *regexp.Regexp
+	codes []int
+}
+
+func newTagAnnotator(opts Options) (Annotator, error) {
+	if err := opts.Only("name", "path", "status"); err != nil {
+		return nil, err
+	}
+	a := &tagAnnotator{}
+	var err error
+	if a.name, err = opts.Required("name"); err != nil {
+		return nil, err
+	}
+	if a.path, err = optionalRegexp(opts, "path"); err != nil {
+		return nil, err
+	}
+	if a.codes, err = opts.Codes("status"); err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+func (a *tagAnnotator) Annotate(r *results.Result) {
+	if a.path != nil && (r.URL == nil || !a.path.MatchString(r.URL.Path)) {
+		return
+	}
+	if a.codes != nil && !containsCode(a.codes, r.Code) {
+		return
+	}
+	tags := r.Metadata["tags"]
+	if tags != "" {
+		tags += ","
+	}
+	setMetadata(r, "tags", tags+a.name)
+}
+
+// set key=KEY value=VALUE adds fixed metadata to every result.
+type setAnnotator struct {
+	key, value string
+}
+
+func newSetAnnotator(opts Options) (Annotator, error) {
+	if err := opts.Only("key", "value"); err != nil {
+		return nil, err
+	}
+	key, err := opts.Required("key")
+	if err != nil {
+		return nil, err
+	}
+	return &setAnnotator{key: key, value: opts["value"]}, nil
+}
+
+func (a *setAnnotator) Annotate(r *results.Result) {
+	setMetadata(r, a.key, a.value)
+}
+
+// redact pattern=REGEX masks text matching REGEX, or credentials and tokens
+// if no pattern is given.
+type redactAnnotator struct {
+	redactor *redact.Redactor
+}
+
+func newRedactAnnotator(opts Options) (Annotator, error) {
+	if err := opts.Only("pattern"); err != nil {
+		return nil, err
+	}
+	var patterns []string
+	if opts["pattern"] != "" {
+		patterns = append(patterns, opts["pattern"])
+	}
+	r, err := redact.New(len(patterns) == 0, patterns)
+	if err != nil {
+		return nil, err
+	}
+	return &redactAnnotator{redactor: r}, nil
+}
+
+func (a *redactAnnotator) Annotate(r *results.Result) {
+	*r = r.Redacted(a.redactor)
+}
+
+// Set metadata on a copy of the map, as the original may be shared.
+func setMetadata(r *results.Result, key, value string) {
+	metadata := make(map[string]string, len(r.Metadata)+1)
+	for k, v := range r.Metadata {
+		metadata[k] = v
+	}
+	metadata[key] = value
+	r.Metadata = metadata
+}
+
+func optionalRegexp(opts Options, name string) (*regexp.Regexp, error) {
+	v := opts[name]
+	if v == "" {
+		return nil, nil
+	}
+	re, err := regexp.Compile(v)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s: %s", name, err.Error())
+	}
+	return re, nil
+}
+
+func containsCode(codes []int, code int) bool {
+	for _, c := range codes {
+		if c == code {
+			return true
+		}
+	}
+	return false
+}
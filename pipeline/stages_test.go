@@ -0,0 +1,156 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pipeline
+
+import (
+	"errors"
+	"github.com/Matir/gobuster/results"
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+func TestStatusFilter(t *testing.T) {
+	f, err := newStatusFilter(Options{"allow": "200,301", "deny": "301"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for code, keep := range map[int]bool{200: true, 301: false, 404: false} {
+		if f.Keep(testResult("/", code)) != keep {
+			t.Errorf("Expected keep=%v for %d", keep, code)
+		}
+	}
+	if _, err := newStatusFilter(Options{}); err == nil {
+		t.Errorf("Expected error without allow or deny.")
+	}
+}
+
+func TestPathFilter(t *testing.T) {
+	f, err := newPathFilter(Options{"match": "^/api/", "exclude": `\.map$`})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for path, keep := range map[string]bool{"/api/users": true, "/api/app.js.map": false, "/index": false} {
+		if f.Keep(testResult(path, 200)) != keep {
+			t.Errorf("Expected keep=%v for %s", keep, path)
+		}
+	}
+	if _, err := newPathFilter(Options{"match": "("}); err == nil {
+		t.Errorf("Expected error for invalid regexp.")
+	}
+}
+
+func TestRulesFilter(t *testing.T) {
+	fp, err := ioutil.TempFile("", "gobuster-rules")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(fp.Name())
+	fp.WriteString(".png:*\n")
+	fp.Close()
+	f, err := newRulesFilter(Options{"file": fp.Name()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f.Keep(testResult("/logo.png", 200)) || !f.Keep(testResult("/index.php", 200)) {
+		t.Errorf("Expected only the png to be dropped.")
+	}
+	if _, err := newRulesFilter(Options{}); err == nil {
+		t.Errorf("Expected error without file.")
+	}
+}
+
+func TestFoundFilter(t *testing.T) {
+	f, _ := newFoundFilter(Options{})
+	failed := testResult("/", 0)
+	failed.Error = errors.New("Timed out.")
+	if f.Keep(failed) || f.Keep(testResult("/", 404)) || !f.Keep(testResult("/", 200)) {
+		t.Errorf("Expected only found results to be kept.")
+	}
+}
+
+func TestDedupeFilter(t *testing.T) {
+	f, _ := newDedupeFilter(Options{})
+	a := testResult("/a", 200)
+	a.BodyHash = "abc"
+	b := testResult("/b", 200)
+	b.BodyHash = "abc"
+	c := testResult("/c", 200)
+	if !f.Keep(a) || f.Keep(b) || !f.Keep(c) || !f.Keep(c) {
+		t.Errorf("Expected only repeated bodies to be dropped.")
+	}
+}
+
+func TestTagAnnotator(t *testing.T) {
+	a, err := newTagAnnotator(Options{"name": "auth", "status": "401,403"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, _ := newTagAnnotator(Options{"name": "admin", "path": "^/admin"})
+	shared := map[string]string{"title": "Admin"}
+	r := testResult("/admin", 403)
+	r.Metadata = shared
+	a.Annotate(&r)
+	b.Annotate(&r)
+	if r.Metadata["tags"] != "auth,admin" || r.Metadata["title"] != "Admin" {
+		t.Errorf("Expected both tags, got %v", r.Metadata)
+	}
+	if _, ok := shared["tags"]; ok {
+		t.Errorf("Expected original metadata to be left alone.")
+	}
+	r = testResult("/", 200)
+	a.Annotate(&r)
+	if r.Metadata != nil {
+		t.Errorf("Expected no tags, got %v", r.Metadata)
+	}
+	if _, err := newTagAnnotator(Options{}); err == nil {
+		t.Errorf("Expected error without name.")
+	}
+}
+
+func TestSetAnnotator(t *testing.T) {
+	a, err := newSetAnnotator(Options{"key": "env", "value": "staging"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := results.Result{}
+	a.Annotate(&r)
+	if r.Metadata["env"] != "staging" {
+		t.Errorf("Expected env=staging, got %v", r.Metadata)
+	}
+}
+
+func TestRedactAnnotator(t *testing.T) {
+	a, err := newRedactAnnotator(Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := testResult("/reset", 200)
+	r.URL.RawQuery = "token=abc123"
+	a.Annotate(&r)
+	if r.URL.RawQuery != "token=[REDACTED]" {
+		t.Errorf("Expected the token masked, got %s", r.URL)
+	}
+	b, _ := newRedactAnnotator(Options{"pattern": "^/internal-[a-z]+"})
+	r = testResult("/internal-wiki", 200)
+	r.URL.RawQuery = "token=abc123"
+	b.Annotate(&r)
+	if r.URL.Path != "[REDACTED]" || r.URL.RawQuery != "token=abc123" {
+		t.Errorf("Expected only the pattern masked, got %s", r.URL)
+	}
+	if _, err := newRedactAnnotator(Options{"pattern": "("}); err == nil {
+		t.Errorf("Expected error for an invalid pattern.")
+	}
+}
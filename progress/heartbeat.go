@@ -0,0 +1,159 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package progress
+
+import (
+	"fmt"
+	"github.com/Matir/gobuster/logging"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Heartbeat signals an external watchdog that the scan is alive and making
+// progress.  Targets are "file:PATH" to touch a file, an http(s) URL to ping,
+// or "systemd" for sd_notify.  Beats stop once no work has been done for
+// stallIntervals intervals, so the watchdog can restart a stalled scan.
+type Heartbeat struct {
+	beat     func() error
+	interval time.Duration
+	// Source of the amount of work done
+	done func() int64
+	stop chan bool
+	wg   sync.WaitGroup
+}
+
+// Intervals without progress after which beats stop.
+const stallIntervals = 10
+
+var heartbeatTimeout = 10 * time.Second
+
+func NewHeartbeat(target string, interval time.Duration, done func() int64) (*Heartbeat, error) {
+	hb := &Heartbeat{interval: interval, done: done, stop: make(chan bool)}
+	switch {
+	case strings.HasPrefix(target, "file:"):
+		path := strings.TrimPrefix(target, "file:")
+		hb.beat = func() error { return touchFile(path) }
+	case strings.HasPrefix(target, "http://") || strings.HasPrefix(target, "https://"):
+		client := &http.Client{Timeout: heartbeatTimeout}
+		hb.beat = func() error { return pingURL(client, target) }
+	case target == "systemd":
+		socket := os.Getenv("NOTIFY_SOCKET")
+		if socket == "" {
+			return nil, fmt.Errorf("NOTIFY_SOCKET is not set.")
+		}
+		if err := sdNotify(socket, "READY=1"); err != nil {
+			return nil, err
+		}
+		hb.beat = func() error { return sdNotify(socket, "WATCHDOG=1") }
+		// Beat at least twice per watchdog period
+		if usec, err := strconv.ParseInt(os.Getenv("WATCHDOG_USEC"), 10, 64); err == nil && usec > 0 {
+			if period := time.Duration(usec) * time.Microsecond / 2; hb.interval <= 0 || period < hb.interval {
+				hb.interval = period
+			}
+		}
+	default:
+		return nil, fmt.Errorf("Unknown heartbeat target: %s", target)
+	}
+	if hb.interval <= 0 {
+		return nil, fmt.Errorf("Heartbeat interval must be positive.")
+	}
+	return hb, nil
+}
+
+func (hb *Heartbeat) Start() {
+	hb.wg.Add(1)
+	go hb.run()
+}
+
+func (hb *Heartbeat) Stop() {
+	close(hb.stop)
+	hb.wg.Wait()
+}
+
+func (hb *Heartbeat) run() {
+	defer hb.wg.Done()
+	ticker := time.NewTicker(hb.interval)
+	defer ticker.Stop()
+	last := hb.done()
+	idle := 0
+	hb.send()
+	for {
+		select {
+		case <-hb.stop:
+			return
+		case <-ticker.C:
+			if done := hb.done(); done != last {
+				last = done
+				idle = 0
+			} else {
+				idle++
+			}
+			if idle == stallIntervals {
+				logging.Logf(logging.LogWarning, "No progress for %s, stopping heartbeat.", hb.interval*stallIntervals)
+			}
+			if idle < stallIntervals {
+				hb.send()
+			}
+		}
+	}
+}
+
+func (hb *Heartbeat) send() {
+	if err := hb.beat(); err != nil {
+		logging.Logf(logging.LogWarning, "Unable to send heartbeat: %s", err.Error())
+	}
+}
+
+func touchFile(path string) error {
+	fp, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE, 0644)
+	if err != nil {
+		return err
+	}
+	fp.Close()
+	now := time.Now()
+	return os.Chtimes(path, now, now)
+}
+
+func pingURL(client *http.Client, target string) error {
+	resp, err := client.Get(target)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 400 {
+		return fmt.Errorf("Heartbeat URL returned %d.", resp.StatusCode)
+	}
+	return nil
+}
+
+// Send a state to systemd, as sd_notify(3) does.
+func sdNotify(socket, state string) error {
+	if strings.HasPrefix(socket, "@") {
+		// Abstract namespace
+		socket = "\x00" + socket[1:]
+	}
+	conn, err := net.DialUnix("unixgram", nil, &net.UnixAddr{Name: socket, Net: "unixgram"})
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	_, err = conn.Write([]byte(state))
+	return err
+}
@@ -0,0 +1,111 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package progress
+
+import (
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestHeartbeat_File(t *testing.T) {
+	dir, err := ioutil.TempDir("", "heartbeat")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "alive")
+	hb, err := NewHeartbeat("file:"+path, time.Hour, func() int64 { return 0 })
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	hb.Start()
+	hb.Stop()
+	if _, err := os.Stat(path); err != nil {
+		t.Errorf("Expected heartbeat file to exist: %s", err)
+	}
+}
+
+func TestHeartbeat_HTTPStall(t *testing.T) {
+	var pings int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&pings, 1)
+	}))
+	defer server.Close()
+	hb, err := NewHeartbeat(server.URL, time.Millisecond, func() int64 { return 0 })
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	hb.Start()
+	// The first beat plus one per interval until stalled
+	deadline := time.Now().Add(5 * time.Second)
+	for atomic.LoadInt32(&pings) < stallIntervals && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	time.Sleep(20 * time.Millisecond)
+	hb.Stop()
+	if n := atomic.LoadInt32(&pings); n != stallIntervals {
+		t.Errorf("Expected %d pings before stalling, got %d", stallIntervals, n)
+	}
+}
+
+func TestHeartbeat_Systemd(t *testing.T) {
+	dir, err := ioutil.TempDir("", "heartbeat")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	socket := filepath.Join(dir, "notify")
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: socket, Net: "unixgram"})
+	if err != nil {
+		t.Skipf("Unix datagram sockets unavailable: %s", err)
+	}
+	defer conn.Close()
+	os.Setenv("NOTIFY_SOCKET", socket)
+	defer os.Unsetenv("NOTIFY_SOCKET")
+	hb, err := NewHeartbeat("systemd", time.Hour, func() int64 { return 0 })
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	hb.Start()
+	hb.Stop()
+	buf := make([]byte, 64)
+	for _, expected := range []string{"READY=1", "WATCHDOG=1"} {
+		conn.SetReadDeadline(time.Now().Add(time.Second))
+		n, err := conn.Read(buf)
+		if err != nil || string(buf[:n]) != expected {
+			t.Errorf("Expected %s, got %q (%v)", expected, string(buf[:n]), err)
+		}
+	}
+}
+
+func TestNewHeartbeat_Errors(t *testing.T) {
+	os.Unsetenv("NOTIFY_SOCKET")
+	for _, target := range []string{"bogus", "systemd", "file:/tmp/x"} {
+		interval := time.Second
+		if target == "file:/tmp/x" {
+			interval = 0
+		}
+		if _, err := NewHeartbeat(target, interval, func() int64 { return 0 }); err == nil {
+			t.Errorf("Expected error for %s", target)
+		}
+	}
+}
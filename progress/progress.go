@@ -0,0 +1,208 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package progress renders scan progress to a terminal or log.
+package progress
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Stats is a snapshot of scan progress.
+type Stats struct {
+	// Units of work done
+	Done int64
+	// Units of work known about
+	Todo int64
+	// Directories completed and known about
+	DirsDone  int
+	DirsTotal int
+}
+
+type StatsFunc func() Stats
+
+// Reporter periodically renders progress.  On a terminal, a single status
+// line is rewritten in place and fit to the current terminal width.  When not
+// attached to a terminal, plain lines are written at increasing intervals so
+// that log files of long unattended runs stay reviewable.
+type Reporter struct {
+	out io.Writer
+	// File descriptor for width queries
+	fd uintptr
+	// Whether out is a terminal
+	tty bool
+	// How often to render
+	interval time.Duration
+	// Source of stats
+	stats StatsFunc
+	// When reporting started
+	start time.Time
+	// Length of the last status line written, for clearing
+	lastLen int
+	stop    chan bool
+	wg      sync.WaitGroup
+}
+
+// Default width when it can't be determined.
+const defaultWidth = 80
+
+// Elapsed times at which to report when not on a terminal.  After the last,
+// the elapsed time between reports keeps doubling.
+var unattendedCheckpoints = []time.Duration{
+	time.Minute,
+	2 * time.Minute,
+	5 * time.Minute,
+	10 * time.Minute,
+	20 * time.Minute,
+	30 * time.Minute,
+	time.Hour,
+}
+
+func NewReporter(out *os.File, interval time.Duration, stats StatsFunc) *Reporter {
+	return &Reporter{
+		out:      out,
+		fd:       out.Fd(),
+		tty:      IsTerminal(out),
+		interval: interval,
+		stats:    stats,
+		stop:     make(chan bool),
+	}
+}
+
+func (r *Reporter) Start() {
+	r.start = time.Now()
+	r.wg.Add(1)
+	go r.run()
+}
+
+// Stop reporting and render a final update.
+func (r *Reporter) Stop() {
+	close(r.stop)
+	r.wg.Wait()
+}
+
+func (r *Reporter) run() {
+	defer r.wg.Done()
+	timer := time.NewTimer(r.nextDelay())
+	defer timer.Stop()
+	resize, cancelResize := watchResize()
+	defer cancelResize()
+	for {
+		select {
+		case <-r.stop:
+			r.render()
+			if r.tty {
+				io.WriteString(r.out, "\n")
+			}
+			return
+		case <-timer.C:
+			r.render()
+			timer.Reset(r.nextDelay())
+		case <-resize:
+			r.render()
+		}
+	}
+}
+
+// How long until the next render.
+func (r *Reporter) nextDelay() time.Duration {
+	if r.tty {
+		return r.interval
+	}
+	elapsed := time.Since(r.start)
+	return nextCheckpoint(elapsed) - elapsed
+}
+
+// The first unattended checkpoint after elapsed.
+func nextCheckpoint(elapsed time.Duration) time.Duration {
+	for _, c := range unattendedCheckpoints {
+		if c > elapsed {
+			return c
+		}
+	}
+	c := unattendedCheckpoints[len(unattendedCheckpoints)-1]
+	for c <= elapsed {
+		c *= 2
+	}
+	return c
+}
+
+func (r *Reporter) render() {
+	line := FormatStats(r.stats(), time.Since(r.start))
+	if !r.tty {
+		io.WriteString(r.out, line+"\n")
+		return
+	}
+	// Leave the last column free so the cursor never wraps
+	width := terminalWidth(r.fd) - 1
+	if width < 1 {
+		width = defaultWidth - 1
+	}
+	if len(line) > width {
+		line = line[:width]
+	}
+	// Pad with spaces rather than using ANSI escapes, so this works on
+	// consoles without ANSI support.
+	pad := r.lastLen - len(line)
+	if pad < 0 {
+		pad = 0
+	}
+	io.WriteString(r.out, "\r"+line+strings.Repeat(" ", pad))
+	if pad > 0 {
+		io.WriteString(r.out, "\r"+line)
+	}
+	r.lastLen = len(line)
+}
+
+func FormatStats(s Stats, elapsed time.Duration) string {
+	pct := 0.0
+	if s.Todo > 0 {
+		pct = float64(s.Done) * 100 / float64(s.Todo)
+	}
+	elapsed -= elapsed % time.Second
+	var dirs string
+	if s.DirsTotal > 0 {
+		dirs = fmt.Sprintf(", %d/%d dirs", s.DirsDone, s.DirsTotal)
+	}
+	return fmt.Sprintf("Progress: %d/%d (%.1f%%)%s, %s elapsed", s.Done, s.Todo, pct, dirs, elapsed)
+}
+
+// Whether to report progress to f: always if asked to, otherwise in
+// summaries only if f isn't a terminal and unattended summaries are wanted.
+func ShouldReport(f *os.File, always, unattended bool) bool {
+	return always || unattended && !IsTerminal(f)
+}
+
+// Check if a file is attached to a terminal.
+func IsTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0 && isatty(f.Fd())
+}
+
+// Width from $COLUMNS, or the default.
+func envWidth() int {
+	if cols, err := strconv.Atoi(os.Getenv("COLUMNS")); err == nil && cols > 0 {
+		return cols
+	}
+	return defaultWidth
+}
@@ -0,0 +1,109 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package progress
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFormatStats(t *testing.T) {
+	s := FormatStats(Stats{Done: 5, Todo: 10}, 1500*time.Millisecond)
+	expected := "Progress: 5/10 (50.0%), 1s elapsed"
+	if s != expected {
+		t.Errorf("Expected %q, got %q", expected, s)
+	}
+	if s := FormatStats(Stats{}, 0); !strings.Contains(s, "0.0%") {
+		t.Errorf("Expected 0%% for empty stats, got %q", s)
+	}
+	s = FormatStats(Stats{Done: 5, Todo: 10, DirsDone: 1, DirsTotal: 3}, time.Second)
+	expected = "Progress: 5/10 (50.0%), 1/3 dirs, 1s elapsed"
+	if s != expected {
+		t.Errorf("Expected %q, got %q", expected, s)
+	}
+}
+
+func TestNextCheckpoint(t *testing.T) {
+	cases := []struct {
+		elapsed, expected time.Duration
+	}{
+		{0, time.Minute},
+		{time.Minute, 2 * time.Minute},
+		{3 * time.Minute, 5 * time.Minute},
+		{45 * time.Minute, time.Hour},
+		{time.Hour, 2 * time.Hour},
+		{3 * time.Hour, 4 * time.Hour},
+	}
+	for _, c := range cases {
+		if n := nextCheckpoint(c.elapsed); n != c.expected {
+			t.Errorf("Expected %v after %v, got %v", c.expected, c.elapsed, n)
+		}
+	}
+}
+
+func TestRender_Plain(t *testing.T) {
+	buf := &bytes.Buffer{}
+	r := &Reporter{out: buf, stats: func() Stats { return Stats{Done: 1, Todo: 2} }, start: time.Now()}
+	r.render()
+	r.render()
+	if lines := strings.Split(strings.TrimSpace(buf.String()), "\n"); len(lines) != 2 {
+		t.Errorf("Expected 2 plain lines, got %q", buf.String())
+	}
+}
+
+func TestRender_TTY(t *testing.T) {
+	os.Setenv("COLUMNS", "20")
+	defer os.Unsetenv("COLUMNS")
+	buf := &bytes.Buffer{}
+	// An invalid fd forces the $COLUMNS fallback
+	r := &Reporter{out: buf, fd: ^uintptr(0), tty: true, stats: func() Stats { return Stats{Done: 1, Todo: 2} }, start: time.Now()}
+	r.render()
+	out := buf.String()
+	if strings.Contains(out, "\n") {
+		t.Errorf("Expected no newline on a TTY, got %q", out)
+	}
+	if len(out) != 20 {
+		t.Errorf("Expected line fit to width (\\r + 19), got %d: %q", len(out), out)
+	}
+}
+
+func TestShouldReport(t *testing.T) {
+	fp, err := os.Create(os.DevNull)
+	if err != nil {
+		t.Skipf("Unable to open %s: %v", os.DevNull, err)
+	}
+	defer fp.Close()
+	if IsTerminal(fp) {
+		t.Errorf("Expected %s not to be a terminal.", os.DevNull)
+	}
+	if !ShouldReport(fp, false, true) || ShouldReport(fp, false, false) || !ShouldReport(fp, true, false) {
+		t.Error("Expected summaries unless turned off, or progress when asked for.")
+	}
+}
+
+func TestReporter_StartStop(t *testing.T) {
+	fp, err := os.Create(os.DevNull)
+	if err != nil {
+		t.Skipf("Unable to open %s: %v", os.DevNull, err)
+	}
+	defer fp.Close()
+	r := NewReporter(fp, time.Millisecond, func() Stats { return Stats{} })
+	r.Start()
+	time.Sleep(5 * time.Millisecond)
+	r.Stop()
+}
@@ -0,0 +1,37 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !darwin,!dragonfly,!freebsd,!linux,!netbsd,!openbsd
+
+package progress
+
+import (
+	"os"
+)
+
+// Without a portable way to query the console, use $COLUMNS.  It is
+// re-read on every render, so changes are still picked up.
+func terminalWidth(_ uintptr) int {
+	return envWidth()
+}
+
+// Without a way to ask, trust that a character device is a console.
+func isatty(_ uintptr) bool {
+	return true
+}
+
+// No resize notifications, the periodic render handles width changes.
+func watchResize() (<-chan os.Signal, func()) {
+	return nil, func() {}
+}
@@ -0,0 +1,211 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package redact masks credentials in scan output, so that reports and saved
+// bodies can be shared without leaking them.
+package redact
+
+import (
+	"fmt"
+	"io"
+	"net/url"
+	"regexp"
+	"sort"
+)
+
+// Text put in place of whatever is redacted.
+const Mask = "[REDACTED]"
+
+// Rule is a named pattern of text to redact.  If the pattern has a group,
+// only what the first group matches is masked, so the text around it stays
+// readable.
+type Rule struct {
+	Name    string
+	Pattern *regexp.Regexp
+}
+
+var DefaultRules = []Rule{
+	{"Cookie", regexp.MustCompile(`(?i)\b(?:set-)?cookie\s*:\s*([^\r\n]+)`)},
+	{"Authorization", regexp.MustCompile(`(?i)\b(?:proxy-)?authorization\s*:\s*([^\r\n]+)`)},
+	{"Bearer Token", regexp.MustCompile(`(?i)\bbearer\s+([A-Za-z0-9._~+/-]+=*)`)},
+	{"URL Password", regexp.MustCompile(`(?i)\b[a-z][a-z0-9+.-]*://[^\s:@/]+:([^\s@/]+)@`)},
+	{"Token Parameter", regexp.MustCompile(`(?i)[?&;](?:access_token|id_token|refresh_token|token|auth|code|api_?key|key|secret|password|passwd|pwd|session(?:_?id)?|sid|sig|signature|x-amz-signature|x-amz-credential|x-goog-signature|x-goog-credential)=([^&#\s"'<>]+)`)},
+	{"API Key", regexp.MustCompile(`(?i)(?:api[_-]?key|secret[_-]?key|access[_-]?token|client[_-]?secret|password)["']?\s*[:=]\s*["']([^"'\r\n]{6,})["']`)},
+	{"JWT", regexp.MustCompile(`\beyJ[A-Za-z0-9_-]{10,}\.eyJ[A-Za-z0-9_-]{10,}\.[A-Za-z0-9_-]{10,}`)},
+	{"AWS Access Key", regexp.MustCompile(`\b(?:AKIA|ASIA)[0-9A-Z]{16}\b`)},
+	{"Private Key", regexp.MustCompile(`-----BEGIN [A-Z ]*PRIVATE KEY(?: BLOCK)?-----[\s\S]*?-----END [A-Z ]*PRIVATE KEY(?: BLOCK)?-----`)},
+}
+
+// Redactor masks whatever matches its rules.  A nil Redactor masks nothing.
+type Redactor struct {
+	rules []Rule
+}
+
+// Build a Redactor from the default rules, if defaults is set, and patterns.
+// Returns nil if there are no rules at all.
+func New(defaults bool, patterns []string) (*Redactor, error) {
+	var rules []Rule
+	if defaults {
+		rules = append(rules, DefaultRules...)
+	}
+	for _, p := range patterns {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid redaction pattern %s: %s", p, err.Error())
+		}
+		rules = append(rules, Rule{Name: p, Pattern: re})
+	}
+	if len(rules) == 0 {
+		return nil, nil
+	}
+	return &Redactor{rules: rules}, nil
+}
+
+// A span of text to mask, from start up to end.
+type span struct {
+	start, end int
+}
+
+// The spans of b to mask, in order and not overlapping.
+func (r *Redactor) spans(b []byte) []span {
+	var spans []span
+	for _, rule := range r.rules {
+		for _, m := range rule.Pattern.FindAllSubmatchIndex(b, -1) {
+			if len(m) >= 4 && m[2] >= 0 {
+				m = m[2:]
+			}
+			if m[1] > m[0] {
+				spans = append(spans, span{m[0], m[1]})
+			}
+		}
+	}
+	sort.Slice(spans, func(i, j int) bool {
+		return spans[i].start < spans[j].start
+	})
+	merged := spans[:0]
+	for _, s := range spans {
+		if n := len(merged); n > 0 && s.start <= merged[n-1].end {
+			if s.end > merged[n-1].end {
+				merged[n-1].end = s.end
+			}
+			continue
+		}
+		merged = append(merged, s)
+	}
+	return merged
+}
+
+// Append b to out with spans masked.
+func appendMasked(out, b []byte, spans []span) []byte {
+	last := 0
+	for _, s := range spans {
+		out = append(out, b[last:s.start]...)
+		out = append(out, Mask...)
+		last = s.end
+	}
+	return append(out, b[last:]...)
+}
+
+func (r *Redactor) Bytes(b []byte) []byte {
+	if r == nil {
+		return b
+	}
+	spans := r.spans(b)
+	if len(spans) == 0 {
+		return b
+	}
+	return appendMasked(nil, b, spans)
+}
+
+func (r *Redactor) String(s string) string {
+	if r == nil {
+		return s
+	}
+	return string(r.Bytes([]byte(s)))
+}
+
+// A copy of u with its password and anything matching in its path, query or
+// fragment masked.
+func (r *Redactor) URL(u *url.URL) *url.URL {
+	if r == nil || u == nil {
+		return u
+	}
+	masked := *u
+	if _, ok := u.User.Password(); ok {
+		masked.User = url.UserPassword(u.User.Username(), Mask)
+	}
+	masked.Path = r.String(u.Path)
+	masked.RawPath = ""
+	// Queries are matched with the separator before them, as in full URLs
+	if q := r.String("?" + u.RawQuery); u.RawQuery != "" {
+		masked.RawQuery = q[1:]
+	}
+	masked.Fragment = r.String(u.Fragment)
+	masked.RawFragment = ""
+	return &masked
+}
+
+// Bytes held back by a Writer, so that text split between writes is still
+// matched whole.  Long enough for a 4096-bit private key.
+const overlap = 8192
+
+// Writer masks what is written to it before passing it on.  It must be
+// closed to write the last of it.
+func (r *Redactor) Writer(w io.Writer) io.WriteCloser {
+	return &writer{r: r, w: w}
+}
+
+type writer struct {
+	r   *Redactor
+	w   io.Writer
+	buf []byte
+}
+
+func (w *writer) Write(p []byte) (int, error) {
+	if w.r == nil {
+		return w.w.Write(p)
+	}
+	w.buf = append(w.buf, p...)
+	if len(w.buf) < 2*overlap {
+		return len(p), nil
+	}
+	spans := w.r.spans(w.buf)
+	// Write all but the tail, without splitting anything masked
+	cut := len(w.buf) - overlap
+	kept := spans[:0]
+	for _, s := range spans {
+		if s.start >= cut {
+			break
+		}
+		if s.end > cut {
+			cut = s.start
+			break
+		}
+		kept = append(kept, s)
+	}
+	if _, err := w.w.Write(appendMasked(nil, w.buf[:cut], kept)); err != nil {
+		return 0, err
+	}
+	w.buf = append(w.buf[:0], w.buf[cut:]...)
+	return len(p), nil
+}
+
+func (w *writer) Close() error {
+	if len(w.buf) == 0 {
+		return nil
+	}
+	_, err := w.w.Write(w.r.Bytes(w.buf))
+	w.buf = nil
+	return err
+}
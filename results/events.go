@@ -0,0 +1,152 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package results
+
+import (
+	"encoding/json"
+	"github.com/Matir/gobuster/logging"
+	"github.com/Matir/gobuster/redact"
+	"io"
+	"net/url"
+	"os"
+	"sync"
+	"time"
+)
+
+// Kinds of events in an event stream.
+const (
+	EventRequest  = "request"
+	EventResponse = "response"
+	EventError    = "error"
+	EventResult   = "result"
+	// All of a directory's wordlist children have been tried
+	EventDirectory = "directory"
+)
+
+// EventStream writes every request attempt and result as soon as it happens,
+// one JSON object per line, so that a scan can be followed in real time.  A
+// nil EventStream discards events.
+type EventStream struct {
+	mu       sync.Mutex
+	encoder  *json.Encoder
+	out      io.Closer
+	redactor *redact.Redactor
+}
+
+// Event is a single line of an event stream.
+type Event struct {
+	Time   time.Time `json:"time"`
+	Event  string    `json:"event"`
+	Worker int       `json:"worker"`
+	URL    string    `json:"url"`
+	Code   int       `json:"code,omitempty"`
+	// Milliseconds from request to response
+	Elapsed float64     `json:"elapsed_ms,omitempty"`
+	Error   string      `json:"error,omitempty"`
+	Result  *jsonResult `json:"result,omitempty"`
+	// Summary of a finished directory
+	Children int         `json:"children,omitempty"`
+	Found    int         `json:"found,omitempty"`
+	Codes    map[int]int `json:"codes,omitempty"`
+}
+
+func NewEventStream(writer io.Writer, closer io.Closer) *EventStream {
+	return &EventStream{encoder: json.NewEncoder(writer), out: closer}
+}
+
+// Open an event stream to target: "-" for stdout, a named pipe or unix
+// socket, or a file.
+func OpenEventStream(target string) (*EventStream, error) {
+	switch {
+	case target == "-":
+		return NewEventStream(os.Stdout, nil), nil
+	case IsStreamTarget(target):
+		out := newStreamOutput(target)
+		return NewEventStream(out, out), nil
+	}
+	fp, err := os.Create(target)
+	if err != nil {
+		return nil, err
+	}
+	return NewEventStream(fp, fp), nil
+}
+
+// Mask whatever r matches in events from now on.
+func (s *EventStream) SetRedactor(r *redact.Redactor) {
+	if s != nil {
+		s.redactor = r
+	}
+}
+
+// Note that a worker is requesting u.
+func (s *EventStream) Request(worker int, u *url.URL) {
+	if s == nil {
+		return
+	}
+	s.write(&Event{Event: EventRequest, Worker: worker, URL: s.redactor.URL(u).String()})
+}
+
+// Note the response to a request, or the error in place of one.
+func (s *EventStream) Response(worker int, u *url.URL, code int, elapsed time.Duration, err error) {
+	if s == nil {
+		return
+	}
+	ev := &Event{
+		Event:   EventResponse,
+		Worker:  worker,
+		URL:     s.redactor.URL(u).String(),
+		Code:    code,
+		Elapsed: float64(elapsed) / float64(time.Millisecond),
+	}
+	if err != nil {
+		ev.Event = EventError
+		ev.Error = s.redactor.String(err.Error())
+	}
+	s.write(ev)
+}
+
+// Note a result sent for reporting.
+func (s *EventStream) Result(worker int, r Result) {
+	if s == nil {
+		return
+	}
+	jr := newJSONResult(r.Redacted(s.redactor))
+	s.write(&Event{Event: EventResult, Worker: worker, URL: jr.URL, Code: r.Code, Result: &jr})
+}
+
+// Note that all the children of directory u have been tried, with found
+// of them turning something up and codes counting their status codes.
+func (s *EventStream) Directory(u *url.URL, children, found int, codes map[int]int) {
+	if s == nil {
+		return
+	}
+	s.write(&Event{Event: EventDirectory, URL: s.redactor.URL(u).String(), Children: children, Found: found, Codes: codes})
+}
+
+func (s *EventStream) write(ev *Event) {
+	ev.Time = time.Now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.encoder.Encode(ev); err != nil {
+		logging.Logf(logging.LogWarning, "Unable to write event: %s", err.Error())
+	}
+}
+
+func (s *EventStream) Close() error {
+	if s == nil || s.out == nil {
+		return nil
+	}
+	return s.out.Close()
+}
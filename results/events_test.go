@@ -0,0 +1,133 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package results
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"github.com/Matir/gobuster/redact"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEventStream(t *testing.T) {
+	buf := &bytes.Buffer{}
+	s := NewEventStream(buf, nil)
+	u := &url.URL{Scheme: "http", Host: "localhost", Path: "/a"}
+	s.Request(2, u)
+	s.Response(2, u, 200, 1500*time.Microsecond, nil)
+	s.Response(3, u, 0, time.Second, errors.New("Timed out."))
+	s.Result(2, Result{URL: u, Code: 200, Length: 5})
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 4 {
+		t.Fatalf("Expected 4 events, got %d: %s", len(lines), buf.String())
+	}
+	var events []Event
+	for _, line := range lines {
+		var ev Event
+		if err := json.Unmarshal([]byte(line), &ev); err != nil {
+			t.Fatalf("Expected JSON, got %s: %s", err, line)
+		}
+		if ev.Time.IsZero() || ev.URL != "http://localhost/a" {
+			t.Errorf("Expected time and URL, got %s", line)
+		}
+		events = append(events, ev)
+	}
+	expected := []struct {
+		kind   string
+		worker int
+	}{{EventRequest, 2}, {EventResponse, 2}, {EventError, 3}, {EventResult, 2}}
+	for i, e := range expected {
+		if events[i].Event != e.kind || events[i].Worker != e.worker {
+			t.Errorf("Expected %s from worker %d, got %s from %d", e.kind, e.worker, events[i].Event, events[i].Worker)
+		}
+	}
+	if events[1].Elapsed != 1.5 {
+		t.Errorf("Expected 1.5ms, got %v", events[1].Elapsed)
+	}
+	if events[2].Error != "Timed out." {
+		t.Errorf("Expected error, got %q", events[2].Error)
+	}
+	if events[3].Result == nil || *events[3].Result.Length != 5 {
+		t.Errorf("Expected result with length, got %s", lines[3])
+	}
+}
+
+func TestEventStream_Directory(t *testing.T) {
+	buf := &bytes.Buffer{}
+	s := NewEventStream(buf, nil)
+	s.Directory(&url.URL{Scheme: "http", Host: "localhost", Path: "/admin/"}, 10, 2, map[int]int{200: 2, 404: 8})
+	var ev Event
+	if err := json.Unmarshal(buf.Bytes(), &ev); err != nil {
+		t.Fatalf("Expected JSON, got %s: %s", err, buf.String())
+	}
+	if ev.Event != EventDirectory || ev.URL != "http://localhost/admin/" {
+		t.Errorf("Expected directory event, got %s", buf.String())
+	}
+	if ev.Children != 10 || ev.Found != 2 || ev.Codes[404] != 8 {
+		t.Errorf("Unexpected summary: %s", buf.String())
+	}
+}
+
+func TestEventStream_Redact(t *testing.T) {
+	buf := &bytes.Buffer{}
+	s := NewEventStream(buf, nil)
+	r, _ := redact.New(true, nil)
+	s.SetRedactor(r)
+	u := &url.URL{Scheme: "http", Host: "localhost", Path: "/a", RawQuery: "token=abc123"}
+	s.Request(1, u)
+	s.Response(1, u, 0, time.Second, errors.New(`Get "http://localhost/a?token=abc123": timeout`))
+	s.Result(1, Result{URL: u, Code: 200})
+	if strings.Contains(buf.String(), "abc123") || strings.Count(buf.String(), "token=[REDACTED]") != 5 {
+		t.Errorf("Expected the token masked in every event, got %s", buf.String())
+	}
+}
+
+func TestEventStream_Nil(t *testing.T) {
+	var s *EventStream
+	u := &url.URL{Scheme: "http", Host: "localhost", Path: "/"}
+	s.Request(0, u)
+	s.Response(0, u, 200, 0, nil)
+	s.Result(0, Result{URL: u})
+	if err := s.Close(); err != nil {
+		t.Errorf("Expected no error, got %s", err)
+	}
+}
+
+func TestOpenEventStream_File(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gobuster-events")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "events.json")
+	s, err := OpenEventStream(path)
+	if err != nil {
+		t.Fatalf("Expected no error, got %s", err)
+	}
+	s.Request(0, &url.URL{Scheme: "http", Host: "localhost", Path: "/"})
+	s.Close()
+	data, _ := ioutil.ReadFile(path)
+	if !strings.Contains(string(data), `"event":"request"`) {
+		t.Errorf("Expected request event, got %s", data)
+	}
+}
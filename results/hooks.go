@@ -0,0 +1,71 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package results
+
+import (
+	"net/http"
+	"net/url"
+)
+
+// Hooks let a program embedding the scanner, through scan.Run, watch and
+// steer a scan.  Any of the functions may be nil, as may a Hooks.  They are
+// called from many workers at once, so must be safe for concurrent use, and
+// hold up the scan while they run.
+type Hooks struct {
+	// Before each request, returning false skips the URL
+	OnRequest func(u *url.URL) bool
+	// With each response, before its body is read.  The body is not for the
+	// hook to read or close.  DNS scans have none, and the probes virtual
+	// host and soft 404 checks compare against aren't passed on.
+	OnResponse func(u *url.URL, resp *http.Response)
+	// With each result, returning false leaves it out of the report
+	OnFinding func(r Result) bool
+	// As each URL in scope is queued, returning false drops it.  These are
+	// the targets and what is found from them, such as links, not the
+	// wordlist's guesses, which OnRequest sees.
+	OnQueueAdd func(u *url.URL) bool
+}
+
+// Whether u is to be requested.
+func (h *Hooks) Request(u *url.URL) bool {
+	if h == nil || h.OnRequest == nil {
+		return true
+	}
+	return h.OnRequest(u)
+}
+
+// Pass on the response to a request for u.
+func (h *Hooks) Response(u *url.URL, resp *http.Response) {
+	if h == nil || h.OnResponse == nil {
+		return
+	}
+	h.OnResponse(u, resp)
+}
+
+// Whether r is to be reported.
+func (h *Hooks) Finding(r Result) bool {
+	if h == nil || h.OnFinding == nil {
+		return true
+	}
+	return h.OnFinding(r)
+}
+
+// Whether u is to be queued.
+func (h *Hooks) QueueAdd(u *url.URL) bool {
+	if h == nil || h.OnQueueAdd == nil {
+		return true
+	}
+	return h.OnQueueAdd(u)
+}
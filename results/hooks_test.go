@@ -0,0 +1,57 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package results
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestHooks_Nil(t *testing.T) {
+	u := &url.URL{Scheme: "http", Host: "localhost", Path: "/a"}
+	for _, h := range []*Hooks{nil, &Hooks{}} {
+		if !h.Request(u) || !h.Finding(Result{URL: u}) || !h.QueueAdd(u) {
+			t.Errorf("Expected everything let through by %v", h)
+		}
+		h.Response(u, &http.Response{})
+	}
+}
+
+func TestHooks(t *testing.T) {
+	var seen []string
+	private := func(u *url.URL) bool { return !strings.HasPrefix(u.Path, "/private") }
+	h := &Hooks{
+		OnRequest: private,
+		OnResponse: func(u *url.URL, resp *http.Response) {
+			seen = append(seen, resp.Header.Get("Server"))
+		},
+		OnFinding:  func(r Result) bool { return r.Code != 403 },
+		OnQueueAdd: private,
+	}
+	open := &url.URL{Scheme: "http", Host: "localhost", Path: "/a"}
+	closed := &url.URL{Scheme: "http", Host: "localhost", Path: "/private/a"}
+	if !h.Request(open) || h.Request(closed) || !h.QueueAdd(open) || h.QueueAdd(closed) {
+		t.Errorf("Expected only /private left out")
+	}
+	h.Response(open, &http.Response{Header: http.Header{"Server": {"nginx"}}})
+	if len(seen) != 1 || seen[0] != "nginx" {
+		t.Errorf("Expected the response passed on, got %v", seen)
+	}
+	if !h.Finding(Result{URL: open, Code: 200}) || h.Finding(Result{URL: open, Code: 403}) {
+		t.Errorf("Expected only the 403 left out")
+	}
+}
@@ -0,0 +1,66 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package results
+
+import (
+	"errors"
+	"github.com/Matir/gobuster/redact"
+	"strings"
+)
+
+// A copy of the result with whatever r matches masked.  Secrets found are
+// masked whatever they are, keeping only the name of their rule.
+func (res Result) Redacted(r *redact.Redactor) Result {
+	if r == nil {
+		return res
+	}
+	res.URL = r.URL(res.URL)
+	res.Redir = r.URL(res.Redir)
+	res.Parent = r.URL(res.Parent)
+	res.Target = r.String(res.Target)
+	if res.Error != nil {
+		res.Error = errors.New(r.String(res.Error.Error()))
+	}
+	if res.Secrets != nil {
+		secrets := make([]string, len(res.Secrets))
+		for i, s := range res.Secrets {
+			name := strings.SplitN(s, ": ", 2)[0]
+			secrets[i] = name + ": " + redact.Mask
+		}
+		res.Secrets = secrets
+	}
+	if res.Metadata != nil {
+		metadata := make(map[string]string, len(res.Metadata))
+		for k, v := range res.Metadata {
+			metadata[k] = r.String(v)
+		}
+		res.Metadata = metadata
+	}
+	res.SourceFiles = redactStrings(r, res.SourceFiles)
+	res.SourcePaths = redactStrings(r, res.SourcePaths)
+	res.Records = redactStrings(r, res.Records)
+	return res
+}
+
+func redactStrings(r *redact.Redactor, in []string) []string {
+	if in == nil {
+		return nil
+	}
+	out := make([]string, len(in))
+	for i, s := range in {
+		out[i] = r.String(s)
+	}
+	return out
+}
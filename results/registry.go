@@ -0,0 +1,324 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package results
+
+import (
+	"fmt"
+	"github.com/Matir/gobuster/logging"
+	ss "github.com/Matir/gobuster/settings"
+	"io"
+	"time"
+)
+
+// ResultsManagerFactory builds a ResultsManager writing to writer.  closer, if
+// not nil, should be closed when output is finished.
+type ResultsManagerFactory func(writer io.Writer, closer io.Closer, settings *ss.ScanSettings) (ResultsManager, error)
+
+// ResultsWriter is a simpler interface for output destinations that handle
+// one result at a time.  Register one with RegisterResultsWriter.
+type ResultsWriter interface {
+	// Write a single result.
+	WriteResult(Result) error
+	// Finish output.  The underlying file is closed afterwards.
+	Close() error
+}
+
+const (
+	// Most results held for a single output that is failing or slow
+	maxPendingResults = 10000
+)
+
+// Initial and maximum delay between retries of a failing ResultsWriter, and
+// the longest to keep retrying once all results are in.
+var (
+	writeRetryDelay    = time.Second
+	maxWriteRetryDelay = 30 * time.Second
+	finalWriteTimeout  = 30 * time.Second
+)
+
+// ResultsWriterFactory builds a ResultsWriter writing to writer.
+type ResultsWriterFactory func(writer io.Writer, settings *ss.ScanSettings) (ResultsWriter, error)
+
+// PathResultsManagerFactory builds a ResultsManager that manages the output
+// at path itself, e.g. a database.
+type PathResultsManagerFactory func(path string, settings *ss.ScanSettings) (ResultsManager, error)
+
+var resultsManagers = make(map[string]ResultsManagerFactory)
+var pathResultsManagers = make(map[string]PathResultsManagerFactory)
+
+// Register an output format.  Should be called from an init function so the
+// format is available when flags are set up.
+func RegisterResultsManager(name string, factory ResultsManagerFactory) error {
+	if err := addOutputFormat(name); err != nil {
+		return err
+	}
+	resultsManagers[name] = factory
+	return nil
+}
+
+// Register an output format that opens its output path itself.  Such
+// formats require an output path.
+func RegisterPathResultsManager(name string, factory PathResultsManagerFactory) error {
+	if err := addOutputFormat(name); err != nil {
+		return err
+	}
+	pathResultsManagers[name] = factory
+	return nil
+}
+
+func addOutputFormat(name string) error {
+	_, ok := resultsManagers[name]
+	if _, pathOK := pathResultsManagers[name]; ok || pathOK {
+		return fmt.Errorf("Output format %s already registered.", name)
+	}
+	OutputFormats = append(OutputFormats, name)
+	ss.SetOutputFormats(OutputFormats)
+	return nil
+}
+
+// Register an output format implemented by a ResultsWriter.
+func RegisterResultsWriter(name string, factory ResultsWriterFactory) error {
+	return RegisterResultsManager(name, func(writer io.Writer, closer io.Closer, settings *ss.ScanSettings) (ResultsManager, error) {
+		out := &resumeWriter{dest: writer}
+		rw, err := factory(out, settings)
+		if err != nil {
+			return nil, err
+		}
+		return &writerResultsManager{writer: rw, out: out, fp: closer}, nil
+	})
+}
+
+// writerResultsManager adapts a ResultsWriter to the ResultsManager interface.
+// Results that fail to write are held and retried with backoff, so a flaky
+// destination neither loses results nor blocks the scan.
+type writerResultsManager struct {
+	baseResultsManager
+	writer ResultsWriter
+	// Destination the writer writes to, if known
+	out *resumeWriter
+	fp  io.Closer
+	// Results waiting to be written, oldest first
+	pending []Result
+	// Current delay between retries, zero when healthy
+	retryDelay time.Duration
+	retryAt    time.Time
+	dropped    int
+}
+
+func (rm *writerResultsManager) Run(res <-chan Result) {
+	rm.start()
+	go func() {
+		defer func() {
+			if err := rm.writer.Close(); err != nil {
+				logging.Logf(logging.LogWarning, "Error closing output: %s", err.Error())
+			}
+			if rm.fp != nil {
+				rm.fp.Close()
+			}
+			rm.done()
+		}()
+		ticker := time.NewTicker(writeRetryDelay)
+		defer ticker.Stop()
+		for res != nil {
+			select {
+			case r, ok := <-res:
+				if !ok {
+					res = nil
+					break
+				}
+				rm.enqueue(r)
+			case <-ticker.C:
+			}
+			rm.flush(false)
+		}
+		deadline := time.Now().Add(finalWriteTimeout)
+		for rm.unwritten() {
+			wait := rm.retryDelay
+			if left := deadline.Sub(time.Now()); left <= 0 {
+				break
+			} else if wait > left {
+				wait = left
+			}
+			time.Sleep(wait)
+			rm.flush(true)
+		}
+		if lost := len(rm.pending) + rm.dropped; lost > 0 {
+			logging.Logf(logging.LogError, "Unable to write %d results to output.", lost)
+		}
+		if rm.out != nil && len(rm.out.partial) > 0 {
+			logging.Logf(logging.LogError, "Output ends with a partially written result.")
+		}
+	}()
+}
+
+func (rm *writerResultsManager) enqueue(r Result) {
+	if len(rm.pending) >= maxPendingResults {
+		rm.pending = rm.pending[1:]
+		rm.dropped++
+	}
+	rm.pending = append(rm.pending, r)
+}
+
+// Write as many pending results as possible, backing off on failure.
+func (rm *writerResultsManager) flush(force bool) {
+	if !force && time.Now().Before(rm.retryAt) {
+		return
+	}
+	for {
+		// Finish any partly written result before starting the next
+		if rm.out != nil {
+			if err := rm.out.resume(); err != nil {
+				rm.backoff(err)
+				return
+			}
+		}
+		if len(rm.pending) == 0 {
+			break
+		}
+		if err := rm.writer.WriteResult(rm.pending[0]); err != nil {
+			rm.backoff(err)
+			return
+		}
+		rm.pending = rm.pending[1:]
+	}
+	if rm.retryDelay != 0 {
+		logging.Logf(logging.LogInfo, "Output recovered.")
+		rm.retryDelay = 0
+	}
+}
+
+func (rm *writerResultsManager) backoff(err error) {
+	if rm.retryDelay == 0 {
+		logging.Logf(logging.LogWarning, "Error writing result, will retry: %s", err.Error())
+		rm.retryDelay = writeRetryDelay
+	} else if rm.retryDelay *= 2; rm.retryDelay > maxWriteRetryDelay {
+		rm.retryDelay = maxWriteRetryDelay
+	}
+	rm.retryAt = time.Now().Add(rm.retryDelay)
+}
+
+// Whether anything is still waiting to be written.
+func (rm *writerResultsManager) unwritten() bool {
+	return len(rm.pending) > 0 || (rm.out != nil && len(rm.out.partial) > 0)
+}
+
+// resumeWriter tracks how much of each write reached the destination.  When
+// a write fails part way, the rest is kept and written before anything else,
+// so retrying never repeats the part already written.
+type resumeWriter struct {
+	dest io.Writer
+	// Bytes accepted from the ResultsWriter but not yet written
+	partial []byte
+}
+
+func (w *resumeWriter) Write(p []byte) (int, error) {
+	if err := w.resume(); err != nil {
+		return 0, err
+	}
+	n, err := w.dest.Write(p)
+	if err != nil && n > 0 {
+		// Part of p is out, so it can't be retried as a whole.  Take the
+		// rest on and report success; resume reports the error.
+		w.partial = append([]byte(nil), p[n:]...)
+		return len(p), nil
+	}
+	return n, err
+}
+
+// Write out what is left of a partial write.
+func (w *resumeWriter) resume() error {
+	for len(w.partial) > 0 {
+		n, err := w.dest.Write(w.partial)
+		w.partial = w.partial[n:]
+		if err != nil {
+			return err
+		}
+		if n == 0 {
+			return io.ErrShortWrite
+		}
+	}
+	return nil
+}
+
+// MultiResultsManager sends every result to each of several ResultsManagers.
+// Each gets its own buffer so a slow output does not hold up the others.
+type MultiResultsManager struct {
+	managers []ResultsManager
+}
+
+func NewMultiResultsManager(managers ...ResultsManager) *MultiResultsManager {
+	return &MultiResultsManager{managers: managers}
+}
+
+func (m *MultiResultsManager) Run(res <-chan Result) {
+	chans := make([]chan Result, len(m.managers))
+	for i, rm := range m.managers {
+		chans[i] = make(chan Result)
+		rm.Run(bufferResults(chans[i], maxPendingResults))
+	}
+	go func() {
+		for r := range res {
+			for _, c := range chans {
+				c <- r
+			}
+		}
+		for _, c := range chans {
+			close(c)
+		}
+	}()
+}
+
+func (m *MultiResultsManager) Wait() {
+	for _, rm := range m.managers {
+		rm.Wait()
+	}
+}
+
+// Relay results from in, holding up to limit of them if the reader falls
+// behind.  The oldest results are dropped beyond that.
+func bufferResults(in <-chan Result, limit int) <-chan Result {
+	out := make(chan Result)
+	go func() {
+		defer close(out)
+		var buf []Result
+		dropped := 0
+		for in != nil || len(buf) > 0 {
+			var send chan Result
+			var next Result
+			if len(buf) > 0 {
+				send = out
+				next = buf[0]
+			}
+			select {
+			case r, ok := <-in:
+				if !ok {
+					in = nil
+					continue
+				}
+				if len(buf) >= limit {
+					buf = buf[1:]
+					dropped++
+				}
+				buf = append(buf, r)
+			case send <- next:
+				buf = buf[1:]
+			}
+		}
+		if dropped > 0 {
+			logging.Logf(logging.LogError, "Output fell behind, dropped %d results.", dropped)
+		}
+	}()
+	return out
+}
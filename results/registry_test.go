@@ -0,0 +1,318 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package results
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"github.com/Matir/gobuster/settings"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+type countingWriter struct {
+	writer io.Writer
+	closed bool
+}
+
+func (c *countingWriter) WriteResult(r Result) error {
+	_, err := fmt.Fprintf(c.writer, "%d\n", r.Code)
+	return err
+}
+
+func (c *countingWriter) Close() error {
+	c.closed = true
+	return nil
+}
+
+func TestRegisterResultsWriter(t *testing.T) {
+	var cw *countingWriter
+	err := RegisterResultsWriter("test-codes", func(w io.Writer, _ *settings.ScanSettings) (ResultsWriter, error) {
+		cw = &countingWriter{writer: w}
+		return cw, nil
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error registering: %v", err)
+	}
+	if err := RegisterResultsWriter("test-codes", nil); err == nil {
+		t.Error("Expected error registering duplicate format.")
+	}
+	found := false
+	for _, f := range OutputFormats {
+		found = found || f == "test-codes"
+	}
+	if !found {
+		t.Error("Expected registered format in OutputFormats.")
+	}
+	factory := resultsManagers["test-codes"]
+	buf := &bytes.Buffer{}
+	rm, err := factory(buf, nil, &settings.ScanSettings{})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	rchan := make(chan Result, 3)
+	for _, r := range makeTestResults() {
+		rchan <- r
+	}
+	close(rchan)
+	rm.Run(rchan)
+	rm.Wait()
+	if buf.String() != "200\n404\n301\n" {
+		t.Errorf("Unexpected output: %q", buf.String())
+	}
+	if !cw.closed {
+		t.Error("Expected writer to be closed.")
+	}
+}
+
+func TestGetResultsManager_Extra(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gobuster-results")
+	if err != nil {
+		t.Fatalf("Unable to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	textPath := filepath.Join(dir, "out.txt")
+	csvPath := filepath.Join(dir, "out.csv")
+	s := &settings.ScanSettings{
+		OutputFormat: "text",
+		OutputPath:   textPath,
+		ExtraOutputs: []string{"csv:" + csvPath},
+	}
+	rm, err := GetResultsManager(s)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	rchan := make(chan Result, 3)
+	for _, r := range makeTestResults() {
+		rchan <- r
+	}
+	close(rchan)
+	rm.Run(rchan)
+	rm.Wait()
+	for _, p := range []string{textPath, csvPath} {
+		if buf, err := ioutil.ReadFile(p); err != nil {
+			t.Errorf("Unable to read %s: %v", p, err)
+		} else if !strings.Contains(string(buf), "http://localhost/") {
+			t.Errorf("Expected result in %s, got %q", p, string(buf))
+		}
+	}
+	s.ExtraOutputs = []string{"csv"}
+	if _, err := GetResultsManager(s); err == nil {
+		t.Error("Expected error for extra output without path.")
+	}
+}
+
+type flakyWriter struct {
+	failures int
+	codes    []int
+}
+
+func (f *flakyWriter) WriteResult(r Result) error {
+	if f.failures > 0 {
+		f.failures--
+		return errors.New("Unavailable.")
+	}
+	f.codes = append(f.codes, r.Code)
+	return nil
+}
+
+func (f *flakyWriter) Close() error {
+	return nil
+}
+
+func TestWriterResultsManager_Retry(t *testing.T) {
+	defer func(d time.Duration) { writeRetryDelay = d }(writeRetryDelay)
+	writeRetryDelay = time.Millisecond
+	fw := &flakyWriter{failures: 2}
+	rm := &writerResultsManager{writer: fw}
+	rchan := make(chan Result, 3)
+	for _, r := range makeTestResults() {
+		rchan <- r
+	}
+	close(rchan)
+	rm.Run(rchan)
+	rm.Wait()
+	if len(fw.codes) != 3 || fw.codes[0] != 200 || fw.codes[2] != 301 {
+		t.Errorf("Expected all results in order after retries, got %v", fw.codes)
+	}
+}
+
+// Writes at most limit bytes per call, failing once it has written cut.
+type shortWriter struct {
+	buf   bytes.Buffer
+	cut   int
+	limit int
+}
+
+func (s *shortWriter) Write(p []byte) (int, error) {
+	if s.cut > 0 {
+		n := len(p)
+		if n > s.cut {
+			n = s.cut
+		}
+		s.cut -= n
+		s.buf.Write(p[:n])
+		if s.cut == 0 {
+			return n, errors.New("Disk full.")
+		}
+		return n, nil
+	}
+	if s.limit > 0 {
+		s.limit--
+		return s.buf.Write(p)
+	}
+	return 0, errors.New("Disk full.")
+}
+
+type lineWriter struct {
+	w io.Writer
+}
+
+func (l *lineWriter) WriteResult(r Result) error {
+	_, err := fmt.Fprintf(l.w, "%d %s\n", r.Code, r.URL.String())
+	return err
+}
+
+func (l *lineWriter) Close() error {
+	return nil
+}
+
+func TestWriterResultsManager_PartialWrite(t *testing.T) {
+	defer func(d time.Duration) { writeRetryDelay = d }(writeRetryDelay)
+	writeRetryDelay = time.Millisecond
+	sw := &shortWriter{cut: 5, limit: 10}
+	out := &resumeWriter{dest: sw}
+	rm := &writerResultsManager{writer: &lineWriter{out}, out: out}
+	rchan := make(chan Result, 3)
+	for _, r := range makeTestResults() {
+		rchan <- r
+	}
+	close(rchan)
+	rm.Run(rchan)
+	rm.Wait()
+	want := &bytes.Buffer{}
+	for _, r := range makeTestResults() {
+		fmt.Fprintf(want, "%d %s\n", r.Code, r.URL.String())
+	}
+	if sw.buf.String() != want.String() {
+		t.Errorf("Expected %q, got %q", want.String(), sw.buf.String())
+	}
+}
+
+func TestWriterResultsManager_ShutdownBounded(t *testing.T) {
+	defer func(d, f time.Duration) {
+		writeRetryDelay, finalWriteTimeout = d, f
+	}(writeRetryDelay, finalWriteTimeout)
+	writeRetryDelay = time.Millisecond
+	finalWriteTimeout = 50 * time.Millisecond
+	rm := &writerResultsManager{writer: &flakyWriter{failures: 1 << 30}}
+	rchan := make(chan Result, 3)
+	for _, r := range makeTestResults() {
+		rchan <- r
+	}
+	close(rchan)
+	start := time.Now()
+	rm.Run(rchan)
+	rm.Wait()
+	if d := time.Since(start); d > 5*time.Second {
+		t.Errorf("Expected shutdown bounded by the final timeout, took %s", d)
+	}
+}
+
+type stuckResultsManager struct {
+	baseResultsManager
+	release chan bool
+}
+
+func (s *stuckResultsManager) Run(res <-chan Result) {
+	s.start()
+	go func() {
+		<-s.release
+		for range res {
+		}
+		s.done()
+	}()
+}
+
+type chanWriter struct {
+	codes chan int
+}
+
+func (c *chanWriter) WriteResult(r Result) error {
+	c.codes <- r.Code
+	return nil
+}
+
+func (c *chanWriter) Close() error {
+	return nil
+}
+
+func TestMultiResultsManager_Independent(t *testing.T) {
+	stuck := &stuckResultsManager{release: make(chan bool)}
+	cw := &chanWriter{codes: make(chan int, 3)}
+	m := NewMultiResultsManager(stuck, &writerResultsManager{writer: cw})
+	rchan := make(chan Result)
+	m.Run(rchan)
+	for _, r := range makeTestResults() {
+		select {
+		case rchan <- r:
+		case <-time.After(time.Second):
+			t.Fatal("Stuck output blocked the scan.")
+		}
+	}
+	close(rchan)
+	for i := 0; i < 3; i++ {
+		select {
+		case <-cw.codes:
+		case <-time.After(time.Second):
+			t.Fatal("Stuck output blocked other outputs.")
+		}
+	}
+	close(stuck.release)
+	m.Wait()
+}
+
+func TestRegisterPathResultsManager(t *testing.T) {
+	var gotPath string
+	err := RegisterPathResultsManager("test-path", func(path string, _ *settings.ScanSettings) (ResultsManager, error) {
+		gotPath = path
+		return &PlainResultsManager{writer: ioutil.Discard}, nil
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error registering: %v", err)
+	}
+	defer func() {
+		delete(pathResultsManagers, "test-path")
+		OutputFormats = OutputFormats[:len(OutputFormats)-1]
+	}()
+	if err := RegisterResultsManager("test-path", nil); err == nil {
+		t.Error("Expected error registering duplicate format.")
+	}
+	if _, err := NewResultsManager("test-path", "", &settings.ScanSettings{}); err == nil {
+		t.Error("Expected error without an output path.")
+	}
+	if _, err := NewResultsManager("test-path", "results.db", &settings.ScanSettings{}); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	if gotPath != "results.db" {
+		t.Errorf("Expected path results.db, got %s", gotPath)
+	}
+}
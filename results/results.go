@@ -23,6 +23,7 @@ import (
 	"net/http"
 	"net/url"
 	"os"
+	"strings"
 )
 
 // This is the result emitted by the worker for each URL tested.
@@ -31,14 +32,77 @@ type Result struct {
 	URL *url.URL
 	// HTTP Status Code
 	Code int
+	// Protocol the response came over, e.g. HTTP/2.0
+	Protocol string
 	// Error if one occurred
 	Error error
 	// Redirect URL
 	Redir *url.URL
 	// Content length
 	Length int64
+	// ETag header, if any
+	ETag string
+	// Last-Modified header, if any
+	LastModified string
+	// SHA-256 of the body
+	BodyHash string
+	// Number of words in the body
+	Words int
+	// Number of lines in the body
+	Lines int
+	// Content-Type declared by the server
+	ContentType string
+	// Content type sniffed from the body
+	SniffedType string
+	// Whether the declared and sniffed types disagree
+	TypeMismatch bool
+	// Secrets found in the body
+	Secrets []string
+	// Metadata from downloadable documents
+	Metadata map[string]string
+	// How the URL entered the queue, e.g. wordlist or link
+	Source string
+	// URL that led to this one being scanned
+	Parent *url.URL
+	// Base URL of the target this result belongs to
+	Target string
+	// Bytes on the wire, if the body was compressed
+	CompressedLength int64
+	// Whether the body decompressed suspiciously well
+	CompressionAnomaly bool
+	// Whether only the start and end of the body were read
+	Sampled bool
+	// Whether the body was cut off at -max-body-read
+	Truncated bool
+	// How the response stands out from others in its directory, if it does
+	Anomaly string
+	// Status and length without credentials, in differential scans
+	AnonCode   int
+	AnonLength int64
+	// Original file names from a sourcemap
+	SourceFiles []string
+	// Paths mentioned in the sources of a sourcemap
+	SourcePaths []string
+	// Addresses the hostname resolved to, in dns mode
+	Addrs []string
+	// Other records for the hostname, as "TYPE data", in dns mode
+	Records []string
+	// Nameserver that allowed a transfer of the zone at this name, if any
+	ZoneTransfer string
+	// Access to the bucket found, in s3 or gcs mode
+	Bucket string
 }
 
+// Access to a bucket found in s3 or gcs mode.
+const (
+	// Anyone can list the bucket
+	BucketPublic = "public"
+	// The bucket exists but listing it is denied
+	BucketPrivate = "private"
+	// The bucket exists behind another endpoint, such as in another region
+	BucketMoved = "moved"
+)
+
 // ResultsManager provides an interface for reading results from a channel and
 // writing them to some form of output.
 type ResultsManager interface {
@@ -54,11 +118,38 @@ type baseResultsManager struct {
 	finished chan bool
 }
 
-// Available output formats as strings.
-var OutputFormats = []string{"text", "csv", "html"}
+// Available output formats as strings, in order of registration.
+var OutputFormats []string
 
 func init() {
-	ss.SetOutputFormats(OutputFormats)
+	RegisterResultsManager("text", func(writer io.Writer, closer io.Closer, settings *ss.ScanSettings) (ResultsManager, error) {
+		return &PlainResultsManager{writer: writer, fp: closer, redirs: settings.IncludeRedirects, info: commentInfo(settings)}, nil
+	})
+	RegisterResultsManager("csv", func(writer io.Writer, closer io.Closer, settings *ss.ScanSettings) (ResultsManager, error) {
+		return &CSVResultsManager{writer: csv.NewWriter(writer), out: writer, fp: closer, info: commentInfo(settings)}, nil
+	})
+	RegisterResultsManager("json", func(writer io.Writer, closer io.Closer, settings *ss.ScanSettings) (ResultsManager, error) {
+		return &JSONResultsManager{writer: writer, fp: closer, info: NewScanInfo(settings)}, nil
+	})
+	RegisterResultsManager("report", func(writer io.Writer, closer io.Closer, settings *ss.ScanSettings) (ResultsManager, error) {
+		return &ReportResultsManager{writer: writer, fp: closer, info: NewScanInfo(settings)}, nil
+	})
+	RegisterResultsManager("sarif", func(writer io.Writer, closer io.Closer, settings *ss.ScanSettings) (ResultsManager, error) {
+		return &SARIFResultsManager{writer: writer, fp: closer, info: NewScanInfo(settings)}, nil
+	})
+	RegisterResultsManager("html", func(writer io.Writer, closer io.Closer, settings *ss.ScanSettings) (ResultsManager, error) {
+		// TODO: do more than the first
+		return &HTMLResultsManager{writer: writer, fp: closer, BaseURL: settings.BaseURLs[0], info: NewScanInfo(settings)}, nil
+	})
+}
+
+// Scan info for formats that can only carry it as comments, which are opt-in
+// so the output stays plain for other tools.
+func commentInfo(settings *ss.ScanSettings) *ScanInfo {
+	if !settings.ScanComments {
+		return nil
+	}
+	return NewScanInfo(settings)
 }
 
 // Returns true if this is a "useful" result
@@ -71,38 +162,100 @@ func FoundSomething(code int) bool {
 		code != http.StatusGatewayTimeout)
 }
 
-// Returns true if this result should be included in reports
+// Returns true if this result should be included in reports.  Anomalies are
+// reported even when their status says there's nothing there, as are paths
+// only found without credentials.
 func ReportResult(res Result) bool {
-	return res.Error == nil && FoundSomething(res.Code)
+	return res.Error == nil && (FoundSomething(res.Code) || len(res.Addrs) > 0 || len(res.Records) > 0 || res.Anomaly != "" || FoundSomething(res.AnonCode))
+}
+
+// Whether the response without credentials differs from the one with them.
+// Lengths must differ by more than a tenth, as pages often vary a little
+// between requests.
+func (res Result) AccessDiffers() bool {
+	if res.AnonCode == 0 {
+		return false
+	}
+	if res.AnonCode != res.Code {
+		return true
+	}
+	diff, longest := res.Length-res.AnonLength, res.Length
+	if diff < 0 {
+		diff, longest = -diff, res.AnonLength
+	}
+	return diff*10 > longest
 }
 
 // Construct a ResultsManager for the given settings in the ss.ScanSettings.
-// Returns an object satisfying the ResultsManager interface or an error.
+// Returns an object satisfying the ResultsManager interface or an error.  If
+// extra outputs are requested, the returned ResultsManager writes to all of
+// them.
 func GetResultsManager(settings *ss.ScanSettings) (ResultsManager, error) {
-	var writer io.Writer
-	var fp *os.File
-	var err error
+	primary, err := NewResultsManager(settings.OutputFormat, settings.OutputPath, settings)
+	if err != nil {
+		return nil, err
+	}
+	if len(settings.ExtraOutputs) == 0 {
+		return primary, nil
+	}
+	managers := []ResultsManager{primary}
+	for _, extra := range settings.ExtraOutputs {
+		pieces := strings.SplitN(extra, ":", 2)
+		if len(pieces) != 2 || pieces[1] == "" {
+			return nil, fmt.Errorf("Invalid extra output %s, expected format:path.", extra)
+		}
+		rm, err := NewResultsManager(pieces[0], pieces[1], settings)
+		if err != nil {
+			return nil, err
+		}
+		managers = append(managers, rm)
+	}
+	return NewMultiResultsManager(managers...), nil
+}
+
+// Formats written as a single document, which a reader joining a stream part
+// way through could not parse.
+var documentFormats = map[string]bool{
+	"html":   true,
+	"report": true,
+	"sarif":  true,
+}
 
-	format := settings.OutputFormat
-	if settings.OutputPath == "" {
-		writer = os.Stdout
+// Construct a ResultsManager for a single output format, writing to path, or
+// stdout if path is empty.
+func NewResultsManager(format, path string, settings *ss.ScanSettings) (ResultsManager, error) {
+	if pathFactory, ok := pathResultsManagers[format]; ok {
+		if path == "" || IsStreamTarget(path) {
+			return nil, fmt.Errorf("Output format %s requires an output file.", format)
+		}
+		return pathFactory(path, settings)
+	}
+	factory, ok := resultsManagers[format]
+	if !ok {
+		return nil, fmt.Errorf("Invalid output type: %s", format)
+	}
+	if path == "" {
+		return factory(os.Stdout, nil, settings)
+	}
+	var out io.WriteCloser
+	if IsStreamTarget(path) {
+		if documentFormats[format] {
+			return nil, fmt.Errorf("Output format %s is a single document and can't be streamed to %s.", format, path)
+		}
+		out = newStreamOutput(path)
 	} else {
-		if fp, err = os.Create(settings.OutputPath); err != nil {
+		fp, err := os.Create(path)
+		if err != nil {
 			return nil, err
-		} else {
-			writer = fp
 		}
+		out = fp
 	}
-	switch {
-	case format == "text":
-		return &PlainResultsManager{writer: writer, fp: fp, redirs: settings.IncludeRedirects}, nil
-	case format == "csv":
-		return &CSVResultsManager{writer: csv.NewWriter(writer), fp: fp}, nil
-	case format == "html":
-		// TODO: do more than the first
-		return &HTMLResultsManager{writer: writer, fp: fp, BaseURL: settings.BaseURLs[0]}, nil
+	rm, err := factory(out, out, settings)
+	if err != nil {
+		out.Close()
+		return nil, err
 	}
-	return nil, fmt.Errorf("Invalid output type: %s", format)
+	return rm, nil
 }
 
 func (b *baseResultsManager) start() {
@@ -17,30 +17,37 @@ package results
 import (
 	"encoding/csv"
 	"fmt"
+	"io"
 	"net/url"
-	"os"
 )
 
 // CSVResultsManager writes a CSV containing all of the results.
 type CSVResultsManager struct {
 	baseResultsManager
 	writer *csv.Writer
-	fp     *os.File
+	// Underlying output, for comments
+	out io.Writer
+	fp  io.Closer
+	// Written as comments before and after the results, if set
+	info *ScanInfo
 }
 
 func (rm *CSVResultsManager) Run(res <-chan Result) {
+	rm.start()
 	go func() {
-		rm.start()
 		defer func() {
 			rm.writer.Flush()
+			rm.info.finish()
+			rm.writeComments(rm.info.footerLines())
 			if rm.fp != nil {
 				rm.fp.Close()
 			}
 			rm.done()
 		}()
 
+		rm.writeComments(rm.info.headerLines())
 		// Header line
-		rm.writer.Write([]string{"code", "url", "content_length", "redirect_url"})
+		rm.writer.Write([]string{"url", "status", "length", "redirect", "error", "etag", "last_modified", "source", "parent_url", "target"})
 
 		for r := range res {
 			rm.runOne(r)
@@ -49,20 +56,44 @@ func (rm *CSVResultsManager) Run(res <-chan Result) {
 }
 
 func (rm *CSVResultsManager) runOne(res Result) {
-	if !ReportResult(res) {
+	// Errors are included so failures are visible
+	if !ReportResult(res) && res.Error == nil {
 		return
 	}
-	var clen string
-	if res.Length >= 0 {
+	var code, clen, errString string
+	if res.Code != 0 {
+		code = fmt.Sprintf("%d", res.Code)
+	}
+	if res.Error != nil {
+		errString = res.Error.Error()
+	} else if res.Length >= 0 {
 		clen = fmt.Sprintf("%d", res.Length)
 	}
 	record := []string{
-		fmt.Sprintf("%d", res.Code),
 		res.URL.String(),
+		code,
 		clen,
 		maybeStringURL(res.Redir),
+		errString,
+		res.ETag,
+		res.LastModified,
+		res.Source,
+		maybeStringURL(res.Parent),
+		res.Target,
 	}
 	rm.writer.Write(record)
+	// Keep streamed output current
+	rm.writer.Flush()
+}
+
+// Write lines starting with #, which many CSV readers skip as comments.
+func (rm *CSVResultsManager) writeComments(lines []string) {
+	if rm.out == nil {
+		return
+	}
+	for _, line := range lines {
+		fmt.Fprintf(rm.out, "# %s\n", line)
+	}
 }
 
 func maybeStringURL(u *url.URL) string {
@@ -17,6 +17,8 @@ package results
 import (
 	"bytes"
 	"encoding/csv"
+	"errors"
+	"net/url"
 	"strings"
 	"testing"
 )
@@ -28,7 +30,11 @@ func TestWriteCSV(t *testing.T) {
 	mgr := CSVResultsManager{
 		writer: csv.NewWriter(&buf),
 	}
-	res := makeTestResults()
+	res := append(makeTestResults(), Result{
+		URL:    &url.URL{Scheme: "http", Host: "localhost", Path: "/err"},
+		Error:  errors.New("Timed out."),
+		Target: "http://localhost/",
+	})
 	mgr.Run(rchan)
 	for _, r := range res {
 		rchan <- r
@@ -36,19 +42,23 @@ func TestWriteCSV(t *testing.T) {
 	close(rchan)
 	mgr.Wait()
 	lines := strings.Split(buf.String(), "\n")
-	if len(lines) != 4 {
-		t.Fatalf("Expected 2 lines of output, got %d.", len(lines))
+	if len(lines) != 5 {
+		t.Fatalf("Expected 4 lines of output, got %d.", len(lines))
 	}
-	hdr := "code,url,content_length,redirect_url"
+	hdr := "url,status,length,redirect,error,etag,last_modified,source,parent_url,target"
 	if lines[0] != hdr {
 		t.Errorf("Expected header \"%s\", got header \"%s\".", hdr, lines[0])
 	}
-	resStr := "200,http://localhost/,0,"
+	resStr := "http://localhost/,200,0,,,\"\"\"abc\"\"\",\"Mon, 02 Jan 2006 15:04:05 GMT\",seed,,"
 	if lines[1] != resStr {
 		t.Errorf("Expected result string \"%s\", got result string \"%s\".", resStr, lines[1])
 	}
-	resStr = "301,http://localhost/.git,0,https://localhost/.git"
+	resStr = "http://localhost/.git,301,0,https://localhost/.git,,,,redirect,http://localhost/,"
 	if lines[2] != resStr {
-		t.Errorf("Expected result string \"%s\", got result string \"%s\".", resStr, lines[1])
+		t.Errorf("Expected result string \"%s\", got result string \"%s\".", resStr, lines[2])
+	}
+	resStr = "http://localhost/err,,,,Timed out.,,,,,http://localhost/"
+	if lines[3] != resStr {
+		t.Errorf("Expected result string \"%s\", got result string \"%s\".", resStr, lines[3])
 	}
 }
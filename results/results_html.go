@@ -18,23 +18,25 @@ import (
 	"github.com/Matir/gobuster/logging"
 	"html/template"
 	"io"
-	"os"
 )
 
 // HTMLResultsManager writes an HTML file containing the results.
 type HTMLResultsManager struct {
 	baseResultsManager
 	writer  io.Writer
-	fp      *os.File
+	fp      io.Closer
 	BaseURL string
+	// Shown before and after the results, if set
+	info *ScanInfo
 }
 
 func (rm *HTMLResultsManager) Run(res <-chan Result) {
+	rm.start()
 	go func() {
-		rm.start()
 		rm.writeHeader()
 
 		defer func() {
+			rm.info.finish()
 			rm.writeFooter()
 			if rm.fp != nil {
 				rm.fp.Close()
@@ -55,15 +57,17 @@ func (rm *HTMLResultsManager) Run(res <-chan Result) {
 }
 
 func (rm *HTMLResultsManager) writeHeader() {
-	header := `{{define "HEAD"}}<html><head><title>gobuster: {{.BaseURL}}</title></head><h2>Results for <a href="{{.BaseURL}}">{{.BaseURL}}</a></h2><table><tr><th>Code</th><th>URL</th><th>Size</th></tr>{{end}}`
+	header := `{{define "HEAD"}}<html><head><title>gobuster: {{.BaseURL}}</title></head><h2>Results for <a href="{{.BaseURL}}">{{.BaseURL}}</a></h2>{{if .Info}}<p class="scan">{{range .Info}}{{.}}<br>{{end}}</p>{{end}}<table><tr><th>Code</th><th>URL</th><th>Size</th></tr>{{end}}`
 	t, err := template.New("htmlResultsManager").Parse(header)
 	if err != nil {
 		logging.Logf(logging.LogWarning, "Error parsing a template: %s", err.Error())
 	}
 	data := struct {
 		BaseURL string
+		Info    []string
 	}{
 		BaseURL: rm.BaseURL,
+		Info:    rm.info.headerLines(),
 	}
 	err = t.ExecuteTemplate(rm.writer, "HEAD", data)
 	if err != nil {
@@ -72,12 +76,12 @@ func (rm *HTMLResultsManager) writeHeader() {
 }
 
 func (rm *HTMLResultsManager) writeFooter() {
-	footer := `{{define "FOOTER"}}</table></html>{{end}}`
+	footer := `{{define "FOOTER"}}</table>{{if .}}<p class="scan">{{range .}}{{.}}<br>{{end}}</p>{{end}}</html>{{end}}`
 	t, err := template.New("htmlResultsManager").Parse(footer)
 	if err != nil {
 		logging.Logf(logging.LogWarning, "Error parsing a template: %s", err.Error())
 	}
-	err = t.ExecuteTemplate(rm.writer, "FOOTER", nil)
+	err = t.ExecuteTemplate(rm.writer, "FOOTER", rm.info.footerLines())
 	if err != nil {
 		logging.Logf(logging.LogWarning, "Error writing template output: %s", err.Error())
 	}
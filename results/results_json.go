@@ -0,0 +1,201 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package results
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/Matir/gobuster/logging"
+	"io"
+	"net/url"
+)
+
+// JSONResultsManager writes one JSON object per result, one per line.
+type JSONResultsManager struct {
+	baseResultsManager
+	writer io.Writer
+	fp     io.Closer
+	// Written as {"scan": ...} before and after the results, if set
+	info *ScanInfo
+}
+
+type jsonResult struct {
+	URL                string            `json:"url"`
+	Code               int               `json:"code,omitempty"`
+	Protocol           string            `json:"protocol,omitempty"`
+	Length             *int64            `json:"length,omitempty"`
+	Redirect           string            `json:"redirect,omitempty"`
+	Error              string            `json:"error,omitempty"`
+	ContentType        string            `json:"content_type,omitempty"`
+	ETag               string            `json:"etag,omitempty"`
+	LastModified       string            `json:"last_modified,omitempty"`
+	BodyHash           string            `json:"body_hash,omitempty"`
+	Source             string            `json:"source,omitempty"`
+	Parent             string            `json:"parent,omitempty"`
+	Target             string            `json:"target,omitempty"`
+	Secrets            []string          `json:"secrets,omitempty"`
+	Metadata           map[string]string `json:"metadata,omitempty"`
+	SourceFiles        []string          `json:"source_files,omitempty"`
+	SourcePaths        []string          `json:"source_paths,omitempty"`
+	TypeMismatch       bool              `json:"type_mismatch,omitempty"`
+	CompressionAnomaly bool              `json:"compression_anomaly,omitempty"`
+	Sampled            bool              `json:"sampled,omitempty"`
+	Truncated          bool              `json:"truncated,omitempty"`
+	Addrs              []string          `json:"addrs,omitempty"`
+	Records            []string          `json:"records,omitempty"`
+	ZoneTransfer       string            `json:"zone_transfer,omitempty"`
+	Bucket             string            `json:"bucket,omitempty"`
+	Anomaly            string            `json:"anomaly,omitempty"`
+	AnonCode           int               `json:"anon_code,omitempty"`
+	AnonLength         int64             `json:"anon_length,omitempty"`
+}
+
+func (rm *JSONResultsManager) Run(res <-chan Result) {
+	rm.start()
+	go func() {
+		encoder := json.NewEncoder(rm.writer)
+		defer func() {
+			if rm.info != nil {
+				rm.info.finish()
+				rm.writeInfo(encoder)
+			}
+			if rm.fp != nil {
+				rm.fp.Close()
+			}
+			rm.done()
+		}()
+
+		if rm.info != nil {
+			rm.writeInfo(encoder)
+		}
+		for r := range res {
+			// Errors are included so tooling can see what failed
+			if !ReportResult(r) && r.Error == nil {
+				continue
+			}
+			if err := encoder.Encode(newJSONResult(r)); err != nil {
+				logging.Logf(logging.LogWarning, "Unable to write result: %s", err.Error())
+			}
+		}
+	}()
+}
+
+func (rm *JSONResultsManager) writeInfo(encoder *json.Encoder) {
+	if err := encoder.Encode(map[string]*ScanInfo{"scan": rm.info}); err != nil {
+		logging.Logf(logging.LogWarning, "Unable to write scan info: %s", err.Error())
+	}
+}
+
+func newJSONResult(r Result) jsonResult {
+	jr := jsonResult{
+		URL:                maybeStringURL(r.URL),
+		Code:               r.Code,
+		Protocol:           r.Protocol,
+		Redirect:           maybeStringURL(r.Redir),
+		ContentType:        r.ContentType,
+		ETag:               r.ETag,
+		LastModified:       r.LastModified,
+		BodyHash:           r.BodyHash,
+		Source:             r.Source,
+		Parent:             maybeStringURL(r.Parent),
+		Target:             r.Target,
+		Secrets:            r.Secrets,
+		Metadata:           r.Metadata,
+		SourceFiles:        r.SourceFiles,
+		SourcePaths:        r.SourcePaths,
+		TypeMismatch:       r.TypeMismatch,
+		CompressionAnomaly: r.CompressionAnomaly,
+		Sampled:            r.Sampled,
+		Truncated:          r.Truncated,
+		Addrs:              r.Addrs,
+		Records:            r.Records,
+		ZoneTransfer:       r.ZoneTransfer,
+		Bucket:             r.Bucket,
+		Anomaly:            r.Anomaly,
+		AnonCode:           r.AnonCode,
+		AnonLength:         r.AnonLength,
+	}
+	if r.Error != nil {
+		jr.Error = r.Error.Error()
+	} else if r.Length >= 0 {
+		length := r.Length
+		jr.Length = &length
+	}
+	return jr
+}
+
+// Encode a result as a line of JSON output would.
+func EncodeResult(r Result) ([]byte, error) {
+	return json.Marshal(newJSONResult(r))
+}
+
+// Decode a result written by EncodeResult.  Counts not included in JSON
+// output, such as words and lines, are lost.
+func DecodeResult(data []byte) (Result, error) {
+	var jr jsonResult
+	if err := json.Unmarshal(data, &jr); err != nil {
+		return Result{}, err
+	}
+	r := Result{
+		Code:               jr.Code,
+		Protocol:           jr.Protocol,
+		Length:             -1,
+		ContentType:        jr.ContentType,
+		ETag:               jr.ETag,
+		LastModified:       jr.LastModified,
+		BodyHash:           jr.BodyHash,
+		Source:             jr.Source,
+		Target:             jr.Target,
+		Secrets:            jr.Secrets,
+		Metadata:           jr.Metadata,
+		SourceFiles:        jr.SourceFiles,
+		SourcePaths:        jr.SourcePaths,
+		TypeMismatch:       jr.TypeMismatch,
+		CompressionAnomaly: jr.CompressionAnomaly,
+		Sampled:            jr.Sampled,
+		Truncated:          jr.Truncated,
+		Addrs:              jr.Addrs,
+		Records:            jr.Records,
+		ZoneTransfer:       jr.ZoneTransfer,
+		Bucket:             jr.Bucket,
+		Anomaly:            jr.Anomaly,
+		AnonCode:           jr.AnonCode,
+		AnonLength:         jr.AnonLength,
+	}
+	if jr.Length != nil {
+		r.Length = *jr.Length
+	}
+	if jr.Error != "" {
+		r.Error = errors.New(jr.Error)
+	}
+	var err error
+	if r.URL, err = url.Parse(jr.URL); err != nil {
+		return Result{}, err
+	}
+	if r.Redir, err = maybeParseURL(jr.Redirect); err != nil {
+		return Result{}, err
+	}
+	if r.Parent, err = maybeParseURL(jr.Parent); err != nil {
+		return Result{}, err
+	}
+	return r, nil
+}
+
+func maybeParseURL(s string) (*url.URL, error) {
+	if s == "" {
+		return nil, nil
+	}
+	return url.Parse(s)
+}
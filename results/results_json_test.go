@@ -0,0 +1,102 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package results
+
+import (
+	"bytes"
+	"errors"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestWriteJSON(t *testing.T) {
+	rchan := make(chan Result)
+	buf := bytes.Buffer{}
+	mgr := JSONResultsManager{writer: &buf}
+	res := append(makeTestResults(), Result{
+		URL:   &url.URL{Scheme: "http", Host: "localhost", Path: "/err"},
+		Error: errors.New("Timed out."),
+	})
+	mgr.Run(rchan)
+	for _, r := range res {
+		rchan <- r
+	}
+	close(rchan)
+	mgr.Wait()
+	lines := strings.Split(buf.String(), "\n")
+	expected := []string{
+		`{"url":"http://localhost/","code":200,"length":0,"etag":"\"abc\"","last_modified":"Mon, 02 Jan 2006 15:04:05 GMT","source":"seed"}`,
+		`{"url":"http://localhost/.git","code":301,"length":0,"redirect":"https://localhost/.git","source":"redirect","parent":"http://localhost/"}`,
+		`{"url":"http://localhost/err","error":"Timed out."}`,
+		"",
+	}
+	if len(lines) != len(expected) {
+		t.Fatalf("Expected %d lines of output, got %d: %s", len(expected), len(lines), buf.String())
+	}
+	for i, e := range expected {
+		if lines[i] != e {
+			t.Errorf("Expected %s, got %s", e, lines[i])
+		}
+	}
+}
+
+func TestEncodeDecodeResult(t *testing.T) {
+	for _, r := range append(makeTestResults(), Result{
+		URL:      &url.URL{Scheme: "http", Host: "localhost", Path: "/err"},
+		Error:    errors.New("Timed out."),
+		Length:   -1,
+		Metadata: map[string]string{"title": "x"},
+		Target:   "http://localhost/",
+	}, Result{
+		URL:    &url.URL{Scheme: "https", Host: "logs.s3.amazonaws.com", Path: "/"},
+		Code:   200,
+		Bucket: BucketPublic,
+	}, Result{
+		URL:     &url.URL{Scheme: "http", Host: "localhost", Path: "/debug"},
+		Code:    404,
+		Length:  5120,
+		Anomaly: "5120 bytes, usually 312",
+	}, Result{
+		URL:        &url.URL{Scheme: "http", Host: "localhost", Path: "/admin/"},
+		Code:       200,
+		Protocol:   "HTTP/2.0",
+		Length:     812,
+		AnonCode:   302,
+		AnonLength: 0,
+	}, Result{
+		URL:          &url.URL{Scheme: "http", Host: "example.com", Path: "/"},
+		Length:       -1,
+		Addrs:        []string{"192.0.2.1"},
+		Records:      []string{"NS ns1.example.com"},
+		ZoneTransfer: "ns1.example.com",
+	}) {
+		data, err := EncodeResult(r)
+		if err != nil {
+			t.Fatalf("Expected no error, got %s", err)
+		}
+		decoded, err := DecodeResult(data)
+		if err != nil {
+			t.Fatalf("Expected no error, got %s", err)
+		}
+		again, _ := EncodeResult(decoded)
+		if string(again) != string(data) {
+			t.Errorf("Expected %s after round trip, got %s", data, again)
+		}
+	}
+	if _, err := DecodeResult([]byte("{")); err == nil {
+		t.Errorf("Expected error for invalid JSON.")
+	}
+}
@@ -17,7 +17,8 @@ package results
 import (
 	"fmt"
 	"io"
-	"os"
+	"sort"
+	"strings"
 )
 
 // PlainResultsManager is designed to output a very basic output that is good
@@ -26,29 +27,85 @@ import (
 type PlainResultsManager struct {
 	baseResultsManager
 	writer io.Writer
-	fp     *os.File
+	fp     io.Closer
 	redirs bool
+	// Written as comments before and after the results, if set
+	info *ScanInfo
 }
 
 func (rm *PlainResultsManager) Run(res <-chan Result) {
+	rm.start()
 	go func() {
-		rm.start()
 		defer func() {
+			rm.info.finish()
+			for _, line := range rm.info.footerLines() {
+				fmt.Fprintf(rm.writer, "# %s\n", line)
+			}
 			if rm.fp != nil {
 				rm.fp.Close()
 			}
 			rm.done()
 		}()
 
+		for _, line := range rm.info.headerLines() {
+			fmt.Fprintf(rm.writer, "# %s\n", line)
+		}
 		for r := range res {
 			if !ReportResult(r) {
 				continue
 			}
-			if r.Redir == nil {
+			if len(r.Addrs) > 0 || len(r.Records) > 0 {
+				var note string
+				if len(r.Records) > 0 {
+					note = fmt.Sprintf(" [%s]", strings.Join(r.Records, "; "))
+				}
+				if r.ZoneTransfer != "" {
+					note += fmt.Sprintf(" [zone transfer allowed by %s]", r.ZoneTransfer)
+				}
+				fmt.Fprintf(rm.writer, "DNS %s (%s)%s\n", r.URL.Hostname(), strings.Join(r.Addrs, ", "), note)
+			} else if r.Bucket != "" {
+				fmt.Fprintf(rm.writer, "Bucket %s (%s)\n", r.URL.String(), r.Bucket)
+			} else if r.Redir == nil {
+				var note string
+				if r.TypeMismatch {
+					note = fmt.Sprintf(" [type mismatch: declared %s, sniffed %s]", r.ContentType, r.SniffedType)
+				}
+				if r.CompressionAnomaly {
+					note += fmt.Sprintf(" [compression anomaly: %d compressed bytes]", r.CompressedLength)
+				}
+				if r.Sampled {
+					note += " [sampled]"
+				}
+				if r.Truncated {
+					note += " [truncated]"
+				}
+				if r.Anomaly != "" {
+					note += fmt.Sprintf(" [anomaly: %s]", r.Anomaly)
+				}
+				if r.AccessDiffers() {
+					note += fmt.Sprintf(" [anonymous: %d, %d bytes]", r.AnonCode, r.AnonLength)
+				}
 				if r.Length >= 0 {
-					fmt.Fprintf(rm.writer, "%d %s (%d bytes)\n", r.Code, r.URL.String(), r.Length)
+					fmt.Fprintf(rm.writer, "%d %s (%d bytes)%s\n", r.Code, r.URL.String(), r.Length, note)
 				} else {
-					fmt.Fprintf(rm.writer, "%d %s\n", r.Code, r.URL.String())
+					fmt.Fprintf(rm.writer, "%d %s%s\n", r.Code, r.URL.String(), note)
+				}
+				for _, secret := range r.Secrets {
+					fmt.Fprintf(rm.writer, "    secret: %s\n", secret)
+				}
+				for _, source := range r.SourceFiles {
+					fmt.Fprintf(rm.writer, "    source file: %s\n", source)
+				}
+				for _, p := range r.SourcePaths {
+					fmt.Fprintf(rm.writer, "    source path: %s\n", p)
+				}
+				keys := make([]string, 0, len(r.Metadata))
+				for k := range r.Metadata {
+					keys = append(keys, k)
+				}
+				sort.Strings(keys)
+				for _, k := range keys {
+					fmt.Fprintf(rm.writer, "    metadata: %s=%s\n", k, r.Metadata[k])
 				}
 			} else if rm.redirs {
 				fmt.Fprintf(rm.writer, "%d %s -> %s\n", r.Code, r.URL.String(), r.Redir.String())
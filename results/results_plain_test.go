@@ -16,6 +16,7 @@ package results
 
 import (
 	"bytes"
+	"net/url"
 	"strings"
 	"testing"
 )
@@ -39,3 +40,126 @@ func TestPlainResultsManager_Basic(t *testing.T) {
 		t.Fatalf("Expected 3 lines of output, got %d", len(lines))
 	}
 }
+
+func TestPlainResultsManager_TypeMismatch(t *testing.T) {
+	buf := bytes.Buffer{}
+	mgr := &PlainResultsManager{writer: &buf}
+	rchan := make(chan Result)
+	mgr.Run(rchan)
+	rchan <- Result{
+		URL:          &url.URL{Scheme: "http", Host: "localhost", Path: "/a.jpg"},
+		Code:         200,
+		Length:       -1,
+		ContentType:  "image/jpeg",
+		SniffedType:  "application/x-httpd-php",
+		TypeMismatch: true,
+	}
+	close(rchan)
+	mgr.Wait()
+	expected := "200 http://localhost/a.jpg [type mismatch: declared image/jpeg, sniffed application/x-httpd-php]\n"
+	if buf.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, buf.String())
+	}
+}
+
+func TestPlainResultsManager_DNS(t *testing.T) {
+	buf := bytes.Buffer{}
+	mgr := &PlainResultsManager{writer: &buf}
+	rchan := make(chan Result)
+	mgr.Run(rchan)
+	rchan <- Result{
+		URL:    &url.URL{Scheme: "http", Host: "www.example.com", Path: "/"},
+		Length: -1,
+		Addrs:  []string{"192.0.2.1", "2001:db8::1"},
+	}
+	close(rchan)
+	mgr.Wait()
+	expected := "DNS www.example.com (192.0.2.1, 2001:db8::1)\n"
+	if buf.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, buf.String())
+	}
+}
+
+func TestPlainResultsManager_DNSRecords(t *testing.T) {
+	buf := bytes.Buffer{}
+	mgr := &PlainResultsManager{writer: &buf}
+	rchan := make(chan Result)
+	mgr.Run(rchan)
+	rchan <- Result{
+		URL:          &url.URL{Scheme: "http", Host: "example.com", Path: "/"},
+		Length:       -1,
+		Addrs:        []string{"192.0.2.1"},
+		Records:      []string{"MX 10 mail.example.com", "TXT v=spf1 -all"},
+		ZoneTransfer: "ns1.example.com",
+	}
+	rchan <- Result{
+		URL:     &url.URL{Scheme: "http", Host: "_sip._tcp.example.com", Path: "/"},
+		Length:  -1,
+		Records: []string{"SRV 10 60 5060 sip.example.com"},
+	}
+	close(rchan)
+	mgr.Wait()
+	expected := "DNS example.com (192.0.2.1) [MX 10 mail.example.com; TXT v=spf1 -all] [zone transfer allowed by ns1.example.com]\n" +
+		"DNS _sip._tcp.example.com () [SRV 10 60 5060 sip.example.com]\n"
+	if buf.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, buf.String())
+	}
+}
+
+func TestPlainResultsManager_Bucket(t *testing.T) {
+	buf := bytes.Buffer{}
+	mgr := &PlainResultsManager{writer: &buf}
+	rchan := make(chan Result)
+	mgr.Run(rchan)
+	rchan <- Result{
+		URL:    &url.URL{Scheme: "https", Host: "backups.s3.amazonaws.com", Path: "/"},
+		Code:   403,
+		Length: 243,
+		Bucket: BucketPrivate,
+	}
+	close(rchan)
+	mgr.Wait()
+	expected := "Bucket https://backups.s3.amazonaws.com/ (private)\n"
+	if buf.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, buf.String())
+	}
+}
+
+func TestPlainResultsManager_Anomaly(t *testing.T) {
+	buf := bytes.Buffer{}
+	mgr := &PlainResultsManager{writer: &buf}
+	rchan := make(chan Result)
+	mgr.Run(rchan)
+	rchan <- Result{
+		URL:     &url.URL{Scheme: "http", Host: "localhost", Path: "/debug"},
+		Code:    404,
+		Length:  5120,
+		Anomaly: "5120 bytes, usually 312",
+	}
+	close(rchan)
+	mgr.Wait()
+	expected := "404 http://localhost/debug (5120 bytes) [anomaly: 5120 bytes, usually 312]\n"
+	if buf.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, buf.String())
+	}
+}
+
+func TestPlainResultsManager_AccessDiffers(t *testing.T) {
+	buf := bytes.Buffer{}
+	mgr := &PlainResultsManager{writer: &buf}
+	rchan := make(chan Result)
+	mgr.Run(rchan)
+	rchan <- Result{
+		URL:        &url.URL{Scheme: "http", Host: "localhost", Path: "/admin/"},
+		Code:       200,
+		Length:     812,
+		AnonCode:   403,
+		AnonLength: 9,
+	}
+	close(rchan)
+	mgr.Wait()
+	expected := "200 http://localhost/admin/ (812 bytes) [anonymous: 403, 9 bytes]\n"
+	if buf.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, buf.String())
+	}
+}
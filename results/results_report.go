@@ -0,0 +1,234 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package results
+
+import (
+	"fmt"
+	"github.com/Matir/gobuster/logging"
+	"html/template"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"sort"
+	"strings"
+)
+
+// ReportResultsManager collects all results and renders them as a single
+// self-contained HTML report, with sortable tables grouped by status code
+// and by directory.
+type ReportResultsManager struct {
+	baseResultsManager
+	writer io.Writer
+	fp     io.Closer
+	// Shown at the top of the report, if set
+	info    *ScanInfo
+	results []Result
+}
+
+type reportRow struct {
+	Code     int
+	URL      string
+	Length   int64
+	Type     string
+	Redirect string
+	Source   string
+	Notes    []string
+}
+
+type reportGroup struct {
+	Title string
+	ID    string
+	Rows  []reportRow
+}
+
+type reportSection struct {
+	Title  string
+	Groups []reportGroup
+}
+
+var reportTemplate = template.Must(template.New("report").Parse(`<!DOCTYPE html>
+<html><head><meta charset="utf-8"><title>gobuster report</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+table { border-collapse: collapse; margin-bottom: 2em; width: 100%; }
+th, td { border: 1px solid #ccc; padding: 0.3em 0.6em; text-align: left; }
+th { background: #eee; cursor: pointer; user-select: none; }
+tr:nth-child(even) td { background: #fafafa; }
+.info { color: #555; }
+.notes { color: #a00; font-size: 0.9em; }
+nav a { margin-right: 1em; }
+</style></head><body>
+<h1>gobuster report</h1>
+{{if .Info}}<p class="info">{{range .Info}}{{.}}<br>{{end}}</p>{{end}}
+<p>{{.Count}} results.</p>
+{{range $section := .Sections}}
+<h2>{{$section.Title}}</h2>
+<nav>{{range $section.Groups}}<a href="#{{.ID}}">{{.Title}} ({{len .Rows}})</a>{{end}}</nav>
+{{range $section.Groups}}
+<h3 id="{{.ID}}">{{.Title}}</h3>
+<table class="sortable"><thead><tr><th>Code</th><th>URL</th><th>Size</th><th>Type</th><th>Redirect</th><th>Source</th><th>Notes</th></tr></thead><tbody>
+{{range .Rows}}<tr><td>{{.Code}}</td><td><a href="{{.URL}}">{{.URL}}</a></td><td>{{if ge .Length 0}}{{.Length}}{{end}}</td><td>{{.Type}}</td><td>{{.Redirect}}</td><td>{{.Source}}</td><td class="notes">{{range .Notes}}{{.}}<br>{{end}}</td></tr>
+{{end}}</tbody></table>
+{{end}}
+{{end}}
+<script>
+document.querySelectorAll("table.sortable").forEach(function(table) {
+  table.querySelectorAll("th").forEach(function(th, col) {
+    var asc = true;
+    th.addEventListener("click", function() {
+      var body = table.tBodies[0];
+      var rows = Array.prototype.slice.call(body.rows);
+      rows.sort(function(a, b) {
+        var x = a.cells[col].textContent, y = b.cells[col].textContent;
+        var nx = parseFloat(x), ny = parseFloat(y);
+        var cmp = (!isNaN(nx) && !isNaN(ny)) ? nx - ny : x.localeCompare(y);
+        return asc ? cmp : -cmp;
+      });
+      asc = !asc;
+      rows.forEach(function(row) { body.appendChild(row); });
+    });
+  });
+});
+</script>
+</body></html>
+`))
+
+func (rm *ReportResultsManager) Run(res <-chan Result) {
+	rm.start()
+	go func() {
+		defer func() {
+			rm.info.finish()
+			rm.render()
+			if rm.fp != nil {
+				rm.fp.Close()
+			}
+			rm.done()
+		}()
+
+		for r := range res {
+			if ReportResult(r) {
+				rm.results = append(rm.results, r)
+			}
+		}
+	}()
+}
+
+func (rm *ReportResultsManager) render() {
+	byCode := make(map[int][]reportRow)
+	byDir := make(map[string][]reportRow)
+	sort.Slice(rm.results, func(i, j int) bool {
+		return rm.results[i].URL.String() < rm.results[j].URL.String()
+	})
+	for _, r := range rm.results {
+		row := newReportRow(r)
+		byCode[r.Code] = append(byCode[r.Code], row)
+		dir := reportDirectory(r.URL)
+		byDir[dir] = append(byDir[dir], row)
+	}
+	var codeGroups, dirGroups []reportGroup
+	codes := make([]int, 0, len(byCode))
+	for code := range byCode {
+		codes = append(codes, code)
+	}
+	sort.Ints(codes)
+	for _, code := range codes {
+		title := strings.TrimSpace(fmt.Sprintf("%d %s", code, http.StatusText(code)))
+		codeGroups = append(codeGroups, reportGroup{Title: title, ID: fmt.Sprintf("code-%d", code), Rows: byCode[code]})
+	}
+	dirs := make([]string, 0, len(byDir))
+	for dir := range byDir {
+		dirs = append(dirs, dir)
+	}
+	sort.Strings(dirs)
+	for i, dir := range dirs {
+		dirGroups = append(dirGroups, reportGroup{Title: dir, ID: fmt.Sprintf("dir-%d", i), Rows: byDir[dir]})
+	}
+	data := struct {
+		Info     []string
+		Count    int
+		Sections []reportSection
+	}{
+		Info:  append(rm.info.headerLines(), rm.info.footerLines()...),
+		Count: len(rm.results),
+		Sections: []reportSection{
+			{"By status code", codeGroups},
+			{"By directory", dirGroups},
+		},
+	}
+	if err := reportTemplate.Execute(rm.writer, data); err != nil {
+		logging.Logf(logging.LogWarning, "Error writing report: %s", err.Error())
+	}
+}
+
+func newReportRow(r Result) reportRow {
+	row := reportRow{
+		Code:     r.Code,
+		URL:      r.URL.String(),
+		Length:   r.Length,
+		Type:     r.ContentType,
+		Redirect: maybeStringURL(r.Redir),
+		Source:   r.Source,
+	}
+	if r.TypeMismatch {
+		row.Notes = append(row.Notes, "type mismatch: sniffed "+r.SniffedType)
+	}
+	if r.CompressionAnomaly {
+		row.Notes = append(row.Notes, "compression anomaly")
+	}
+	if r.Sampled {
+		row.Notes = append(row.Notes, "sampled")
+	}
+	if r.Truncated {
+		row.Notes = append(row.Notes, "truncated")
+	}
+	if r.Anomaly != "" {
+		row.Notes = append(row.Notes, "anomaly: "+r.Anomaly)
+	}
+	if r.AccessDiffers() {
+		row.Notes = append(row.Notes, fmt.Sprintf("anonymous: %d, %d bytes", r.AnonCode, r.AnonLength))
+	}
+	for _, s := range r.Secrets {
+		row.Notes = append(row.Notes, "secret: "+s)
+	}
+	keys := make([]string, 0, len(r.Metadata))
+	for k := range r.Metadata {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		row.Notes = append(row.Notes, "metadata: "+k+"="+r.Metadata[k])
+	}
+	for _, s := range r.SourceFiles {
+		row.Notes = append(row.Notes, "source file: "+s)
+	}
+	return row
+}
+
+// The directory containing a result, or the directory itself.
+func reportDirectory(u *url.URL) string {
+	dir := u.Path
+	if !strings.HasSuffix(dir, "/") {
+		dir = path.Dir(dir)
+		if !strings.HasSuffix(dir, "/") {
+			dir += "/"
+		}
+	}
+	d := *u
+	d.Path = dir
+	d.RawQuery = ""
+	d.Fragment = ""
+	return d.String()
+}
@@ -0,0 +1,59 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package results
+
+import (
+	"bytes"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestReportResultsManager(t *testing.T) {
+	buf := &bytes.Buffer{}
+	runManager(&ReportResultsManager{writer: buf, info: testScanInfo()})
+	out := buf.String()
+	for _, expected := range []string{
+		"<h3 id=\"code-200\">200 OK</h3>",
+		"<h3 id=\"code-301\">301 Moved Permanently</h3>",
+		"<h3 id=\"dir-0\">http://localhost/</h3>",
+		"<td><a href=\"http://localhost/.git\">http://localhost/.git</a></td>",
+		"finished: ",
+		"table.sortable",
+	} {
+		if !strings.Contains(out, expected) {
+			t.Errorf("Expected report to contain %q", expected)
+		}
+	}
+	// 404s aren't reported
+	if strings.Contains(out, "http://localhost/x") {
+		t.Error("Expected unreported results to be left out.")
+	}
+}
+
+func TestReportDirectory(t *testing.T) {
+	cases := map[string]string{
+		"http://localhost/":          "http://localhost/",
+		"http://localhost/a/b.php?x": "http://localhost/a/",
+		"http://localhost/a/b/":      "http://localhost/a/b/",
+		"http://localhost/file":      "http://localhost/",
+	}
+	for in, expected := range cases {
+		u, _ := url.Parse(in)
+		if res := reportDirectory(u); res != expected {
+			t.Errorf("Expected %s for %s, got %s", expected, in, res)
+		}
+	}
+}
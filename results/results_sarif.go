@@ -0,0 +1,226 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package results
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/Matir/gobuster/logging"
+	"io"
+	"time"
+)
+
+// SARIFResultsManager collects findings and writes them as a single SARIF 2.1
+// log, for security dashboards that consume SARIF.
+type SARIFResultsManager struct {
+	baseResultsManager
+	writer io.Writer
+	fp     io.Closer
+	// Recorded as the run's invocation and properties, if set
+	info    *ScanInfo
+	results []sarifResult
+}
+
+const (
+	sarifVersion = "2.1.0"
+	sarifSchema  = "https://json.schemastore.org/sarif-2.1.0.json"
+)
+
+type sarifRule struct {
+	ID               string       `json:"id"`
+	ShortDescription sarifMessage `json:"shortDescription"`
+	DefaultConfig    struct {
+		Level string `json:"level"`
+	} `json:"defaultConfiguration"`
+}
+
+// Rules for each kind of finding, in the order they are listed in the log.
+var sarifRules = []struct {
+	id, level, description string
+}{
+	{"resource-found", "note", "A resource was found on the server."},
+	{"secret-exposed", "error", "A response body contains what looks like a secret."},
+	{"sourcemap-exposed", "warning", "A sourcemap reveals original source files."},
+	{"content-type-mismatch", "note", "The declared content type does not match the body."},
+	{"compression-anomaly", "warning", "A body decompressed suspiciously well."},
+	{"response-anomaly", "note", "A response stands out from others in its directory."},
+	{"access-difference", "warning", "A resource responds differently with and without credentials."},
+	{"zone-transfer", "warning", "A nameserver allows anyone to transfer a zone."},
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation struct {
+		ArtifactLocation struct {
+			URI string `json:"uri"`
+		} `json:"artifactLocation"`
+	} `json:"physicalLocation"`
+}
+
+type sarifResult struct {
+	RuleID     string                 `json:"ruleId"`
+	RuleIndex  int                    `json:"ruleIndex"`
+	Level      string                 `json:"level"`
+	Message    sarifMessage           `json:"message"`
+	Locations  []sarifLocation        `json:"locations"`
+	Properties map[string]interface{} `json:"properties,omitempty"`
+}
+
+type sarifInvocation struct {
+	ExecutionSuccessful bool   `json:"executionSuccessful"`
+	StartTimeUTC        string `json:"startTimeUtc,omitempty"`
+	EndTimeUTC          string `json:"endTimeUtc,omitempty"`
+}
+
+type sarifRun struct {
+	Tool struct {
+		Driver struct {
+			Name           string      `json:"name"`
+			Version        string      `json:"version,omitempty"`
+			InformationURI string      `json:"informationUri"`
+			Rules          []sarifRule `json:"rules"`
+		} `json:"driver"`
+	} `json:"tool"`
+	Invocations []sarifInvocation      `json:"invocations,omitempty"`
+	Results     []sarifResult          `json:"results"`
+	Properties  map[string]interface{} `json:"properties,omitempty"`
+}
+
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+func (rm *SARIFResultsManager) Run(res <-chan Result) {
+	rm.start()
+	go func() {
+		defer func() {
+			rm.info.finish()
+			encoder := json.NewEncoder(rm.writer)
+			encoder.SetIndent("", "  ")
+			if err := encoder.Encode(rm.log()); err != nil {
+				logging.Logf(logging.LogWarning, "Unable to write SARIF log: %s", err.Error())
+			}
+			if rm.fp != nil {
+				rm.fp.Close()
+			}
+			rm.done()
+		}()
+		for r := range res {
+			if !ReportResult(r) || r.Redir != nil {
+				continue
+			}
+			rm.results = append(rm.results, sarifResults(r)...)
+		}
+	}()
+}
+
+func (rm *SARIFResultsManager) log() *sarifLog {
+	run := sarifRun{Results: rm.results}
+	if run.Results == nil {
+		run.Results = []sarifResult{}
+	}
+	driver := &run.Tool.Driver
+	driver.Name = "gobuster"
+	driver.InformationURI = "https://github.com/Matir/gobuster"
+	for _, r := range sarifRules {
+		rule := sarifRule{ID: r.id, ShortDescription: sarifMessage{r.description}}
+		rule.DefaultConfig.Level = r.level
+		driver.Rules = append(driver.Rules, rule)
+	}
+	if rm.info != nil {
+		driver.Version = rm.info.Version
+		inv := sarifInvocation{
+			ExecutionSuccessful: true,
+			StartTimeUTC:        rm.info.Start.UTC().Format(time.RFC3339),
+		}
+		if rm.info.End != nil {
+			inv.EndTimeUTC = rm.info.End.UTC().Format(time.RFC3339)
+		}
+		run.Invocations = []sarifInvocation{inv}
+		run.Properties = map[string]interface{}{
+			"settingsHash": rm.info.SettingsHash,
+			"wordlists":    rm.info.Wordlists,
+			"targets":      rm.info.Targets,
+		}
+	}
+	return &sarifLog{Schema: sarifSchema, Version: sarifVersion, Runs: []sarifRun{run}}
+}
+
+// The SARIF results for a single scan result: one for the resource itself,
+// and one for each notable thing about it.
+func sarifResults(r Result) []sarifResult {
+	uri := r.URL.String()
+	props := map[string]interface{}{"status": r.Code}
+	if r.Length >= 0 {
+		props["length"] = r.Length
+	}
+	if r.ContentType != "" {
+		props["contentType"] = r.ContentType
+	}
+	if r.Source != "" {
+		props["source"] = r.Source
+	}
+	if r.Target != "" {
+		props["target"] = r.Target
+	}
+	if len(r.Metadata) > 0 {
+		props["metadata"] = r.Metadata
+	}
+	found := newSARIFResult(0, uri, fmt.Sprintf("Found %s (%d).", uri, r.Code))
+	found.Properties = props
+	results := []sarifResult{found}
+	for _, secret := range r.Secrets {
+		results = append(results, newSARIFResult(1, uri, fmt.Sprintf("Possible secret in %s: %s", uri, secret)))
+	}
+	if len(r.SourceFiles) > 0 {
+		res := newSARIFResult(2, uri, fmt.Sprintf("Sourcemap %s names %d source files.", uri, len(r.SourceFiles)))
+		res.Properties = map[string]interface{}{"sourceFiles": r.SourceFiles}
+		results = append(results, res)
+	}
+	if r.TypeMismatch {
+		results = append(results, newSARIFResult(3, uri, fmt.Sprintf("%s is declared as %s but looks like %s.", uri, r.ContentType, r.SniffedType)))
+	}
+	if r.CompressionAnomaly {
+		results = append(results, newSARIFResult(4, uri, fmt.Sprintf("%s decompressed from %d to %d bytes.", uri, r.CompressedLength, r.Length)))
+	}
+	if r.Anomaly != "" {
+		results = append(results, newSARIFResult(5, uri, fmt.Sprintf("%s stands out from its directory: %s.", uri, r.Anomaly)))
+	}
+	if r.AccessDiffers() {
+		results = append(results, newSARIFResult(6, uri, fmt.Sprintf("%s responds %d (%d bytes) with credentials and %d (%d bytes) without.", uri, r.Code, r.Length, r.AnonCode, r.AnonLength)))
+	}
+	if r.ZoneTransfer != "" {
+		results = append(results, newSARIFResult(7, uri, fmt.Sprintf("%s allows transfers of the %s zone.", r.ZoneTransfer, r.URL.Hostname())))
+	}
+	return results
+}
+
+func newSARIFResult(rule int, uri, message string) sarifResult {
+	res := sarifResult{
+		RuleID:    sarifRules[rule].id,
+		RuleIndex: rule,
+		Level:     sarifRules[rule].level,
+		Message:   sarifMessage{message},
+	}
+	var loc sarifLocation
+	loc.PhysicalLocation.ArtifactLocation.URI = uri
+	res.Locations = []sarifLocation{loc}
+	return res
+}
@@ -0,0 +1,102 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package results
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/url"
+	"testing"
+)
+
+func TestWriteSARIF(t *testing.T) {
+	rchan := make(chan Result)
+	buf := bytes.Buffer{}
+	mgr := SARIFResultsManager{writer: &buf, info: testScanInfo()}
+	res := append(makeTestResults(), Result{
+		URL:          &url.URL{Scheme: "http", Host: "localhost", Path: "/config.js"},
+		Code:         200,
+		Length:       100,
+		ContentType:  "text/plain",
+		SniffedType:  "application/javascript",
+		TypeMismatch: true,
+		Secrets:      []string{"AWS key"},
+	}, Result{
+		URL:          &url.URL{Scheme: "http", Host: "example.com", Path: "/"},
+		Length:       -1,
+		Addrs:        []string{"192.0.2.1"},
+		ZoneTransfer: "ns1.example.com",
+	})
+	mgr.Run(rchan)
+	for _, r := range res {
+		rchan <- r
+	}
+	close(rchan)
+	mgr.Wait()
+
+	var log sarifLog
+	if err := json.Unmarshal(buf.Bytes(), &log); err != nil {
+		t.Fatalf("Expected valid JSON, got %s: %s", err, buf.String())
+	}
+	if log.Version != "2.1.0" || len(log.Runs) != 1 {
+		t.Fatalf("Expected one SARIF 2.1.0 run, got %s", buf.String())
+	}
+	run := log.Runs[0]
+	if run.Tool.Driver.Name != "gobuster" || len(run.Tool.Driver.Rules) != len(sarifRules) {
+		t.Errorf("Unexpected driver: %v", run.Tool.Driver)
+	}
+	if len(run.Invocations) != 1 || run.Invocations[0].EndTimeUTC == "" {
+		t.Errorf("Expected a finished invocation, got %v", run.Invocations)
+	}
+	expected := []struct {
+		rule, uri string
+	}{
+		{"resource-found", "http://localhost/"},
+		{"resource-found", "http://localhost/config.js"},
+		{"secret-exposed", "http://localhost/config.js"},
+		{"content-type-mismatch", "http://localhost/config.js"},
+		{"resource-found", "http://example.com/"},
+		{"zone-transfer", "http://example.com/"},
+	}
+	if len(run.Results) != len(expected) {
+		t.Fatalf("Expected %d results, got %d: %s", len(expected), len(run.Results), buf.String())
+	}
+	for i, e := range expected {
+		r := run.Results[i]
+		if r.RuleID != e.rule || r.Locations[0].PhysicalLocation.ArtifactLocation.URI != e.uri {
+			t.Errorf("Expected %s at %s, got %s at %s", e.rule, e.uri, r.RuleID, r.Locations[0].PhysicalLocation.ArtifactLocation.URI)
+		}
+		if sarifRules[r.RuleIndex].id != r.RuleID {
+			t.Errorf("Rule index %d does not match %s", r.RuleIndex, r.RuleID)
+		}
+	}
+}
+
+func TestWriteSARIF_Empty(t *testing.T) {
+	rchan := make(chan Result)
+	buf := bytes.Buffer{}
+	mgr := SARIFResultsManager{writer: &buf}
+	mgr.Run(rchan)
+	close(rchan)
+	mgr.Wait()
+	var log map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &log); err != nil {
+		t.Fatalf("Expected valid JSON, got %s", err)
+	}
+	results := log["runs"].([]interface{})[0].(map[string]interface{})["results"]
+	if r, ok := results.([]interface{}); !ok || len(r) != 0 {
+		t.Errorf("Expected empty results array, got %v", results)
+	}
+}
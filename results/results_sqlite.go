@@ -0,0 +1,156 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build sqlite
+
+package results
+
+import (
+	"database/sql"
+	"github.com/Matir/gobuster/logging"
+	ss "github.com/Matir/gobuster/settings"
+	_ "github.com/mattn/go-sqlite3"
+	"strings"
+	"time"
+)
+
+// Results are committed in batches of this many.
+const sqliteBatchSize = 500
+
+var sqliteSchema = []string{
+	`CREATE TABLE IF NOT EXISTS scans (
+		id INTEGER PRIMARY KEY,
+		version TEXT,
+		settings_hash TEXT,
+		wordlists TEXT,
+		targets TEXT,
+		started TEXT,
+		finished TEXT
+	)`,
+	`CREATE TABLE IF NOT EXISTS results (
+		id INTEGER PRIMARY KEY,
+		scan_id INTEGER NOT NULL REFERENCES scans(id),
+		url TEXT NOT NULL,
+		code INTEGER,
+		length INTEGER,
+		redirect TEXT,
+		error TEXT,
+		content_type TEXT,
+		etag TEXT,
+		last_modified TEXT,
+		body_hash TEXT,
+		source TEXT,
+		parent TEXT
+	)`,
+	`CREATE INDEX IF NOT EXISTS results_url ON results(url)`,
+}
+
+// SQLiteResultsManager stores each scan and its results in an SQLite
+// database, so runs against the same target can be compared later.  Only
+// built with the sqlite build tag, as it needs cgo and go-sqlite3.
+type SQLiteResultsManager struct {
+	baseResultsManager
+	db     *sql.DB
+	info   *ScanInfo
+	scanID int64
+}
+
+func init() {
+	RegisterPathResultsManager("sqlite", func(path string, settings *ss.ScanSettings) (ResultsManager, error) {
+		return NewSQLiteResultsManager(path, NewScanInfo(settings))
+	})
+}
+
+func NewSQLiteResultsManager(path string, info *ScanInfo) (*SQLiteResultsManager, error) {
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		return nil, err
+	}
+	for _, stmt := range sqliteSchema {
+		if _, err := db.Exec(stmt); err != nil {
+			db.Close()
+			return nil, err
+		}
+	}
+	res, err := db.Exec(`INSERT INTO scans (version, settings_hash, wordlists, targets, started) VALUES (?, ?, ?, ?, ?)`,
+		info.Version, info.SettingsHash, strings.Join(info.Wordlists, " "), strings.Join(info.Targets, " "), info.Start.Format(time.RFC3339))
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	scanID, err := res.LastInsertId()
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &SQLiteResultsManager{db: db, info: info, scanID: scanID}, nil
+}
+
+func (rm *SQLiteResultsManager) Run(res <-chan Result) {
+	rm.start()
+	go func() {
+		defer func() {
+			rm.info.finish()
+			if _, err := rm.db.Exec(`UPDATE scans SET finished = ? WHERE id = ?`, rm.info.End.Format(time.RFC3339), rm.scanID); err != nil {
+				logging.Logf(logging.LogWarning, "Unable to record scan end: %s", err.Error())
+			}
+			rm.db.Close()
+			rm.done()
+		}()
+
+		var tx *sql.Tx
+		var stmt *sql.Stmt
+		pending := 0
+		commit := func() {
+			if tx == nil {
+				return
+			}
+			stmt.Close()
+			if err := tx.Commit(); err != nil {
+				logging.Logf(logging.LogWarning, "Unable to save results: %s", err.Error())
+			}
+			tx, stmt, pending = nil, nil, 0
+		}
+		defer commit()
+		for r := range res {
+			if !ReportResult(r) && r.Error == nil {
+				continue
+			}
+			if tx == nil {
+				var err error
+				if tx, err = rm.db.Begin(); err != nil {
+					logging.Logf(logging.LogWarning, "Unable to save results: %s", err.Error())
+					continue
+				}
+				if stmt, err = tx.Prepare(`INSERT INTO results (scan_id, url, code, length, redirect, error, content_type, etag, last_modified, body_hash, source, parent) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`); err != nil {
+					logging.Logf(logging.LogWarning, "Unable to save results: %s", err.Error())
+					tx.Rollback()
+					tx = nil
+					continue
+				}
+			}
+			var errString string
+			if r.Error != nil {
+				errString = r.Error.Error()
+			}
+			if _, err := stmt.Exec(rm.scanID, maybeStringURL(r.URL), r.Code, r.Length, maybeStringURL(r.Redir), errString,
+				r.ContentType, r.ETag, r.LastModified, r.BodyHash, r.Source, maybeStringURL(r.Parent)); err != nil {
+				logging.Logf(logging.LogWarning, "Unable to save result for %s: %s", r.URL, err.Error())
+			}
+			if pending++; pending >= sqliteBatchSize {
+				commit()
+			}
+		}
+	}()
+}
@@ -0,0 +1,56 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build sqlite
+
+package results
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSQLiteResultsManager(t *testing.T) {
+	dir, err := ioutil.TempDir("", "sqlite")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "results.db")
+	// Two runs are kept side by side
+	for i := 0; i < 2; i++ {
+		mgr, err := NewSQLiteResultsManager(path, testScanInfo())
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		runManager(mgr)
+	}
+	mgr, err := NewSQLiteResultsManager(path, testScanInfo())
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	defer mgr.db.Close()
+	var scans, finished, results int
+	mgr.db.QueryRow(`SELECT COUNT(*), COUNT(finished) FROM scans`).Scan(&scans, &finished)
+	mgr.db.QueryRow(`SELECT COUNT(*) FROM results`).Scan(&results)
+	if scans != 3 || finished != 2 {
+		t.Errorf("Expected 3 scans with 2 finished, got %d and %d", scans, finished)
+	}
+	// Two reportable results per run
+	if results != 4 {
+		t.Errorf("Expected 4 results, got %d", results)
+	}
+}
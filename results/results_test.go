@@ -23,17 +23,22 @@ import (
 func makeTestResults() []Result {
 	return []Result{
 		Result{
-			URL:  &url.URL{Scheme: "http", Host: "localhost", Path: "/"},
-			Code: 200,
+			URL:          &url.URL{Scheme: "http", Host: "localhost", Path: "/"},
+			Code:         200,
+			ETag:         `"abc"`,
+			LastModified: "Mon, 02 Jan 2006 15:04:05 GMT",
+			Source:       "seed",
 		},
 		Result{
 			URL:  &url.URL{Scheme: "http", Host: "localhost", Path: "/x"},
 			Code: 404,
 		},
 		Result{
-			URL:   &url.URL{Scheme: "http", Host: "localhost", Path: "/.git"},
-			Code:  301,
-			Redir: &url.URL{Scheme: "https", Host: "localhost", Path: "/.git"},
+			URL:    &url.URL{Scheme: "http", Host: "localhost", Path: "/.git"},
+			Code:   301,
+			Redir:  &url.URL{Scheme: "https", Host: "localhost", Path: "/.git"},
+			Source: "redirect",
+			Parent: &url.URL{Scheme: "http", Host: "localhost", Path: "/"},
 		},
 	}
 
@@ -71,6 +76,35 @@ func TestReportResult(t *testing.T) {
 	if !ReportResult(r) {
 		t.Error("Expected to report a result of 200.")
 	}
+	if !ReportResult(Result{Addrs: []string{"192.0.2.1"}}) {
+		t.Error("Expected to report a resolved host.")
+	}
+	if !ReportResult(Result{Records: []string{"TXT v=spf1 -all"}}) {
+		t.Error("Expected DNS records to be reported.")
+	}
+	if !ReportResult(Result{Code: 404, Anomaly: "5120 bytes, usually 312"}) {
+		t.Error("Expected to report an anomalous 404.")
+	}
+	if !ReportResult(Result{Code: 404, AnonCode: 200}) {
+		t.Error("Expected to report a path only found without credentials.")
+	}
+}
+
+func TestAccessDiffers(t *testing.T) {
+	for _, c := range []struct {
+		r       Result
+		differs bool
+	}{
+		{Result{Code: 200, Length: 1000}, false},
+		{Result{Code: 200, Length: 1000, AnonCode: 403, AnonLength: 1000}, true},
+		{Result{Code: 200, Length: 1000, AnonCode: 200, AnonLength: 950}, false},
+		{Result{Code: 200, Length: 1000, AnonCode: 200, AnonLength: 300}, true},
+		{Result{Code: 200, Length: 300, AnonCode: 200, AnonLength: 1000}, true},
+	} {
+		if got := c.r.AccessDiffers(); got != c.differs {
+			t.Errorf("Expected %v for %+v, got %v", c.differs, c.r, got)
+		}
+	}
 }
 
 func TestBaseFunctions(_ *testing.T) {
@@ -0,0 +1,85 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package results
+
+import (
+	"fmt"
+	"github.com/Matir/gobuster/redact"
+	ss "github.com/Matir/gobuster/settings"
+	"strings"
+	"time"
+)
+
+// ScanInfo describes a scan, so that output is self-describing and the scan
+// can be reproduced.
+type ScanInfo struct {
+	Version      string     `json:"version"`
+	SettingsHash string     `json:"settings_hash"`
+	Wordlists    []string   `json:"wordlists"`
+	Targets      []string   `json:"targets"`
+	Start        time.Time  `json:"start"`
+	End          *time.Time `json:"end,omitempty"`
+}
+
+func NewScanInfo(settings *ss.ScanSettings) *ScanInfo {
+	wordlist := settings.WordlistPath
+	if wordlist == "" {
+		wordlist = "built-in"
+	}
+	// Already validated
+	redactor, _ := redact.New(settings.Redact, settings.RedactPatterns)
+	targets := make([]string, len(settings.BaseURLs))
+	for i, target := range settings.BaseURLs {
+		targets[i] = redactor.String(target)
+	}
+	return &ScanInfo{
+		Version:      ss.Version,
+		SettingsHash: settings.Fingerprint(),
+		Wordlists:    []string{wordlist},
+		Targets:      targets,
+		Start:        time.Now(),
+	}
+}
+
+// Note the end of the scan.
+func (i *ScanInfo) finish() {
+	if i == nil {
+		return
+	}
+	now := time.Now()
+	i.End = &now
+}
+
+// Lines describing the scan, for the top of text outputs.
+func (i *ScanInfo) headerLines() []string {
+	if i == nil {
+		return nil
+	}
+	return []string{
+		fmt.Sprintf("gobuster %s", i.Version),
+		fmt.Sprintf("settings: %s", i.SettingsHash),
+		fmt.Sprintf("wordlists: %s", strings.Join(i.Wordlists, " ")),
+		fmt.Sprintf("targets: %s", strings.Join(i.Targets, " ")),
+		fmt.Sprintf("started: %s", i.Start.Format(time.RFC3339)),
+	}
+}
+
+// Lines for the end of text outputs.
+func (i *ScanInfo) footerLines() []string {
+	if i == nil || i.End == nil {
+		return nil
+	}
+	return []string{fmt.Sprintf("finished: %s", i.End.Format(time.RFC3339))}
+}
@@ -0,0 +1,138 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package results
+
+import (
+	"github.com/Matir/gobuster/logging"
+	"io"
+	"net"
+	"os"
+	"strings"
+	"time"
+)
+
+// Prefix for output paths naming a unix socket.
+const unixSocketPrefix = "unix:"
+
+// How long to wait between attempts to reach a stream's reader.
+var streamReconnectDelay = time.Second
+
+// Most bytes held for a stream while it has no reader.
+var maxStreamBuffer = 1024 * 1024
+
+// Check whether an output path is a unix socket or named pipe.
+func IsStreamTarget(path string) bool {
+	if strings.HasPrefix(path, unixSocketPrefix) {
+		return true
+	}
+	info, err := os.Stat(path)
+	return err == nil && info.Mode()&os.ModeNamedPipe != 0
+}
+
+// streamOutput writes to a named pipe or unix socket for a live consumer.
+// While there is no reader, output is held until one connects rather than
+// stopping the scan.  Past maxStreamBuffer the oldest writes are dropped.
+type streamOutput struct {
+	path string
+	conn io.WriteCloser
+	// Writes waiting for a reader, oldest first
+	held      [][]byte
+	heldBytes int
+	dropped   int
+	// Last time we tried to reach a reader
+	lastAttempt time.Time
+	// Whether a reader has been lost, to log only changes
+	lost bool
+}
+
+func newStreamOutput(path string) *streamOutput {
+	return &streamOutput{path: path}
+}
+
+func (s *streamOutput) Write(p []byte) (int, error) {
+	s.hold(p)
+	s.flush()
+	return len(p), nil
+}
+
+func (s *streamOutput) hold(p []byte) {
+	s.held = append(s.held, append([]byte(nil), p...))
+	s.heldBytes += len(p)
+	for s.heldBytes > maxStreamBuffer && len(s.held) > 1 {
+		if s.dropped == 0 {
+			logging.Logf(logging.LogWarning, "No reader for %s, dropping the oldest output.", s.path)
+		}
+		s.heldBytes -= len(s.held[0])
+		s.held = s.held[1:]
+		s.dropped++
+	}
+}
+
+// Send held writes to the reader, if there is one.
+func (s *streamOutput) flush() {
+	if s.conn == nil && !s.connect() {
+		return
+	}
+	for len(s.held) > 0 {
+		if _, err := s.conn.Write(s.held[0]); err != nil {
+			logging.Logf(logging.LogWarning, "Lost reader of %s: %s", s.path, err.Error())
+			s.conn.Close()
+			s.conn = nil
+			s.lost = true
+			return
+		}
+		s.heldBytes -= len(s.held[0])
+		s.held = s.held[1:]
+	}
+}
+
+func (s *streamOutput) connect() bool {
+	if time.Since(s.lastAttempt) < streamReconnectDelay {
+		return false
+	}
+	s.lastAttempt = time.Now()
+	var err error
+	if strings.HasPrefix(s.path, unixSocketPrefix) {
+		s.conn, err = net.Dial("unix", strings.TrimPrefix(s.path, unixSocketPrefix))
+	} else {
+		s.conn, err = openFIFO(s.path)
+	}
+	if err != nil {
+		s.conn = nil
+		if !s.lost {
+			logging.Logf(logging.LogWarning, "No reader for %s, holding output until one connects: %s", s.path, err.Error())
+			s.lost = true
+		}
+		return false
+	}
+	if s.lost {
+		logging.Logf(logging.LogInfo, "Reader connected to %s.", s.path)
+		s.lost = false
+	}
+	return true
+}
+
+func (s *streamOutput) Close() error {
+	// One last chance for a reader to get what is held
+	s.lastAttempt = time.Time{}
+	s.flush()
+	if lost := len(s.held) + s.dropped; lost > 0 {
+		logging.Logf(logging.LogWarning, "No reader took %d writes to %s.", lost, s.path)
+	}
+	if s.conn == nil {
+		return nil
+	}
+	return s.conn.Close()
+}
@@ -0,0 +1,110 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package results
+
+import (
+	"bufio"
+	"github.com/Matir/gobuster/settings"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestIsStreamTarget(t *testing.T) {
+	if !IsStreamTarget("unix:/tmp/results.sock") {
+		t.Error("Expected unix: path to be a stream target.")
+	}
+	fp, err := ioutil.TempFile("", "gobuster-results")
+	if err != nil {
+		t.Fatalf("Unable to create temp file: %v", err)
+	}
+	fp.Close()
+	defer os.Remove(fp.Name())
+	if IsStreamTarget(fp.Name()) {
+		t.Error("Expected regular file not to be a stream target.")
+	}
+}
+
+func TestStreamOutput_HoldLimit(t *testing.T) {
+	defer func(n int) { maxStreamBuffer = n }(maxStreamBuffer)
+	maxStreamBuffer = 10
+	out := newStreamOutput(unixSocketPrefix + "/nonexistent/results.sock")
+	for _, p := range []string{"first\n", "second\n", "third\n"} {
+		out.Write([]byte(p))
+	}
+	if len(out.held) != 1 || string(out.held[0]) != "third\n" || out.dropped != 2 {
+		t.Errorf("Expected only the newest write held, got %q (%d dropped)", out.held, out.dropped)
+	}
+}
+
+func TestNewResultsManager_StreamDocument(t *testing.T) {
+	s := &settings.ScanSettings{BaseURLs: []string{"http://localhost/"}}
+	if _, err := NewResultsManager("sarif", "unix:/tmp/results.sock", s); err == nil {
+		t.Error("Expected error streaming a document format.")
+	}
+}
+
+func TestStreamOutput_Socket(t *testing.T) {
+	defer func(d time.Duration) { streamReconnectDelay = d }(streamReconnectDelay)
+	streamReconnectDelay = 0
+	dir, err := ioutil.TempDir("", "gobuster-stream")
+	if err != nil {
+		t.Fatalf("Unable to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	sockPath := filepath.Join(dir, "results.sock")
+	out := newStreamOutput(unixSocketPrefix + sockPath)
+	defer out.Close()
+
+	// No reader yet: held without error
+	if n, err := out.Write([]byte("held\n")); err != nil || n != 5 {
+		t.Errorf("Expected output held, got %d, %v", n, err)
+	}
+
+	listener, err := net.Listen("unix", sockPath)
+	if err != nil {
+		t.Skipf("Unix sockets unavailable: %v", err)
+	}
+	defer listener.Close()
+	accepted := make(chan net.Conn, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err == nil {
+			accepted <- conn
+		}
+	}()
+	if _, err := out.Write([]byte("hello\n")); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	conn := <-accepted
+	rdr := bufio.NewReader(conn)
+	for _, expected := range []string{"held\n", "hello\n"} {
+		line, err := rdr.ReadString('\n')
+		if err != nil || line != expected {
+			t.Errorf("Expected %q, got %q, %v", expected, line, err)
+		}
+	}
+
+	// Reader goes away; writes continue without error
+	conn.Close()
+	for i := 0; i < 3; i++ {
+		if _, err := out.Write([]byte("more\n")); err != nil {
+			t.Errorf("Expected writes to continue after disconnect, got %v", err)
+		}
+	}
+}
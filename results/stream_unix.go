@@ -0,0 +1,37 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !windows
+
+package results
+
+import (
+	"io"
+	"os"
+	"syscall"
+)
+
+// Open a named pipe for writing without waiting for a reader to appear.
+func openFIFO(path string) (io.WriteCloser, error) {
+	fp, err := os.OpenFile(path, os.O_WRONLY|syscall.O_NONBLOCK, 0)
+	if err != nil {
+		return nil, err
+	}
+	// Block on writes once a reader is there
+	if err := syscall.SetNonblock(int(fp.Fd()), false); err != nil {
+		fp.Close()
+		return nil, err
+	}
+	return fp, nil
+}
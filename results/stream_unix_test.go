@@ -0,0 +1,63 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !windows
+
+package results
+
+import (
+	"bufio"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+)
+
+func TestStreamOutput_FIFO(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gobuster-stream")
+	if err != nil {
+		t.Fatalf("Unable to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	fifoPath := filepath.Join(dir, "results.fifo")
+	if err := syscall.Mkfifo(fifoPath, 0600); err != nil {
+		t.Skipf("Unable to create FIFO: %v", err)
+	}
+	if !IsStreamTarget(fifoPath) {
+		t.Error("Expected FIFO to be a stream target.")
+	}
+	out := newStreamOutput(fifoPath)
+	defer out.Close()
+	if _, err := out.Write([]byte("held\n")); err != nil {
+		t.Errorf("Expected output held without a reader, got %v", err)
+	}
+	reader, err := os.OpenFile(fifoPath, os.O_RDONLY|syscall.O_NONBLOCK, 0)
+	if err != nil {
+		t.Fatalf("Unable to open FIFO for reading: %v", err)
+	}
+	defer reader.Close()
+	syscall.SetNonblock(int(reader.Fd()), false)
+	out.lastAttempt = out.lastAttempt.Add(-streamReconnectDelay)
+	if _, err := out.Write([]byte("hello\n")); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	rdr := bufio.NewReader(reader)
+	for _, expected := range []string{"held\n", "hello\n"} {
+		line, err := rdr.ReadString('\n')
+		if err != nil || line != expected {
+			t.Errorf("Expected %q, got %q, %v", expected, line, err)
+		}
+	}
+}
@@ -0,0 +1,566 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Service for orchestration platforms to follow a running scan: its results
+// as they are found, its progress, and a way to stop it.
+
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.12
+// 	protoc        (unknown)
+// source: gobuster.proto
+
+package rpc
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type ResultsRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Include results that found nothing, e.g. 404s.
+	IncludeAll    bool `protobuf:"varint,1,opt,name=include_all,json=includeAll,proto3" json:"include_all,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ResultsRequest) Reset() {
+	*x = ResultsRequest{}
+	mi := &file_gobuster_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ResultsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ResultsRequest) ProtoMessage() {}
+
+func (x *ResultsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_gobuster_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ResultsRequest.ProtoReflect.Descriptor instead.
+func (*ResultsRequest) Descriptor() ([]byte, []int) {
+	return file_gobuster_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *ResultsRequest) GetIncludeAll() bool {
+	if x != nil {
+		return x.IncludeAll
+	}
+	return false
+}
+
+type Result struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Url           string                 `protobuf:"bytes,1,opt,name=url,proto3" json:"url,omitempty"`
+	Code          int32                  `protobuf:"varint,2,opt,name=code,proto3" json:"code,omitempty"`
+	Error         string                 `protobuf:"bytes,3,opt,name=error,proto3" json:"error,omitempty"`
+	RedirectUrl   string                 `protobuf:"bytes,4,opt,name=redirect_url,json=redirectUrl,proto3" json:"redirect_url,omitempty"`
+	Length        int64                  `protobuf:"varint,5,opt,name=length,proto3" json:"length,omitempty"`
+	ContentType   string                 `protobuf:"bytes,6,opt,name=content_type,json=contentType,proto3" json:"content_type,omitempty"`
+	BodyHash      string                 `protobuf:"bytes,7,opt,name=body_hash,json=bodyHash,proto3" json:"body_hash,omitempty"`
+	Source        string                 `protobuf:"bytes,8,opt,name=source,proto3" json:"source,omitempty"`
+	ParentUrl     string                 `protobuf:"bytes,9,opt,name=parent_url,json=parentUrl,proto3" json:"parent_url,omitempty"`
+	Secrets       []string               `protobuf:"bytes,10,rep,name=secrets,proto3" json:"secrets,omitempty"`
+	Metadata      map[string]string      `protobuf:"bytes,11,rep,name=metadata,proto3" json:"metadata,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	Target        string                 `protobuf:"bytes,12,opt,name=target,proto3" json:"target,omitempty"`
+	Protocol      string                 `protobuf:"bytes,13,opt,name=protocol,proto3" json:"protocol,omitempty"`
+	Words         int32                  `protobuf:"varint,14,opt,name=words,proto3" json:"words,omitempty"`
+	Lines         int32                  `protobuf:"varint,15,opt,name=lines,proto3" json:"lines,omitempty"`
+	SniffedType   string                 `protobuf:"bytes,16,opt,name=sniffed_type,json=sniffedType,proto3" json:"sniffed_type,omitempty"`
+	Anomaly       string                 `protobuf:"bytes,17,opt,name=anomaly,proto3" json:"anomaly,omitempty"`
+	Addrs         []string               `protobuf:"bytes,18,rep,name=addrs,proto3" json:"addrs,omitempty"`
+	Records       []string               `protobuf:"bytes,19,rep,name=records,proto3" json:"records,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Result) Reset() {
+	*x = Result{}
+	mi := &file_gobuster_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Result) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Result) ProtoMessage() {}
+
+func (x *Result) ProtoReflect() protoreflect.Message {
+	mi := &file_gobuster_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Result.ProtoReflect.Descriptor instead.
+func (*Result) Descriptor() ([]byte, []int) {
+	return file_gobuster_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *Result) GetUrl() string {
+	if x != nil {
+		return x.Url
+	}
+	return ""
+}
+
+func (x *Result) GetCode() int32 {
+	if x != nil {
+		return x.Code
+	}
+	return 0
+}
+
+func (x *Result) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+func (x *Result) GetRedirectUrl() string {
+	if x != nil {
+		return x.RedirectUrl
+	}
+	return ""
+}
+
+func (x *Result) GetLength() int64 {
+	if x != nil {
+		return x.Length
+	}
+	return 0
+}
+
+func (x *Result) GetContentType() string {
+	if x != nil {
+		return x.ContentType
+	}
+	return ""
+}
+
+func (x *Result) GetBodyHash() string {
+	if x != nil {
+		return x.BodyHash
+	}
+	return ""
+}
+
+func (x *Result) GetSource() string {
+	if x != nil {
+		return x.Source
+	}
+	return ""
+}
+
+func (x *Result) GetParentUrl() string {
+	if x != nil {
+		return x.ParentUrl
+	}
+	return ""
+}
+
+func (x *Result) GetSecrets() []string {
+	if x != nil {
+		return x.Secrets
+	}
+	return nil
+}
+
+func (x *Result) GetMetadata() map[string]string {
+	if x != nil {
+		return x.Metadata
+	}
+	return nil
+}
+
+func (x *Result) GetTarget() string {
+	if x != nil {
+		return x.Target
+	}
+	return ""
+}
+
+func (x *Result) GetProtocol() string {
+	if x != nil {
+		return x.Protocol
+	}
+	return ""
+}
+
+func (x *Result) GetWords() int32 {
+	if x != nil {
+		return x.Words
+	}
+	return 0
+}
+
+func (x *Result) GetLines() int32 {
+	if x != nil {
+		return x.Lines
+	}
+	return 0
+}
+
+func (x *Result) GetSniffedType() string {
+	if x != nil {
+		return x.SniffedType
+	}
+	return ""
+}
+
+func (x *Result) GetAnomaly() string {
+	if x != nil {
+		return x.Anomaly
+	}
+	return ""
+}
+
+func (x *Result) GetAddrs() []string {
+	if x != nil {
+		return x.Addrs
+	}
+	return nil
+}
+
+func (x *Result) GetRecords() []string {
+	if x != nil {
+		return x.Records
+	}
+	return nil
+}
+
+type StatusRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StatusRequest) Reset() {
+	*x = StatusRequest{}
+	mi := &file_gobuster_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StatusRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StatusRequest) ProtoMessage() {}
+
+func (x *StatusRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_gobuster_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StatusRequest.ProtoReflect.Descriptor instead.
+func (*StatusRequest) Descriptor() ([]byte, []int) {
+	return file_gobuster_proto_rawDescGZIP(), []int{2}
+}
+
+type StatusResponse struct {
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	Done      int64                  `protobuf:"varint,1,opt,name=done,proto3" json:"done,omitempty"`
+	Todo      int64                  `protobuf:"varint,2,opt,name=todo,proto3" json:"todo,omitempty"`
+	DirsDone  int32                  `protobuf:"varint,3,opt,name=dirs_done,json=dirsDone,proto3" json:"dirs_done,omitempty"`
+	DirsTotal int32                  `protobuf:"varint,4,opt,name=dirs_total,json=dirsTotal,proto3" json:"dirs_total,omitempty"`
+	// Whether Stop was called or the scan stopped itself.
+	Stopping      bool `protobuf:"varint,5,opt,name=stopping,proto3" json:"stopping,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StatusResponse) Reset() {
+	*x = StatusResponse{}
+	mi := &file_gobuster_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StatusResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StatusResponse) ProtoMessage() {}
+
+func (x *StatusResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_gobuster_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StatusResponse.ProtoReflect.Descriptor instead.
+func (*StatusResponse) Descriptor() ([]byte, []int) {
+	return file_gobuster_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *StatusResponse) GetDone() int64 {
+	if x != nil {
+		return x.Done
+	}
+	return 0
+}
+
+func (x *StatusResponse) GetTodo() int64 {
+	if x != nil {
+		return x.Todo
+	}
+	return 0
+}
+
+func (x *StatusResponse) GetDirsDone() int32 {
+	if x != nil {
+		return x.DirsDone
+	}
+	return 0
+}
+
+func (x *StatusResponse) GetDirsTotal() int32 {
+	if x != nil {
+		return x.DirsTotal
+	}
+	return 0
+}
+
+func (x *StatusResponse) GetStopping() bool {
+	if x != nil {
+		return x.Stopping
+	}
+	return false
+}
+
+type StopRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StopRequest) Reset() {
+	*x = StopRequest{}
+	mi := &file_gobuster_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StopRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StopRequest) ProtoMessage() {}
+
+func (x *StopRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_gobuster_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StopRequest.ProtoReflect.Descriptor instead.
+func (*StopRequest) Descriptor() ([]byte, []int) {
+	return file_gobuster_proto_rawDescGZIP(), []int{4}
+}
+
+type StopResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StopResponse) Reset() {
+	*x = StopResponse{}
+	mi := &file_gobuster_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StopResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StopResponse) ProtoMessage() {}
+
+func (x *StopResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_gobuster_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StopResponse.ProtoReflect.Descriptor instead.
+func (*StopResponse) Descriptor() ([]byte, []int) {
+	return file_gobuster_proto_rawDescGZIP(), []int{5}
+}
+
+var File_gobuster_proto protoreflect.FileDescriptor
+
+const file_gobuster_proto_rawDesc = "" +
+	"\n" +
+	"\x0egobuster.proto\x12\bgobuster\"1\n" +
+	"\x0eResultsRequest\x12\x1f\n" +
+	"\vinclude_all\x18\x01 \x01(\bR\n" +
+	"includeAll\"\xd6\x04\n" +
+	"\x06Result\x12\x10\n" +
+	"\x03url\x18\x01 \x01(\tR\x03url\x12\x12\n" +
+	"\x04code\x18\x02 \x01(\x05R\x04code\x12\x14\n" +
+	"\x05error\x18\x03 \x01(\tR\x05error\x12!\n" +
+	"\fredirect_url\x18\x04 \x01(\tR\vredirectUrl\x12\x16\n" +
+	"\x06length\x18\x05 \x01(\x03R\x06length\x12!\n" +
+	"\fcontent_type\x18\x06 \x01(\tR\vcontentType\x12\x1b\n" +
+	"\tbody_hash\x18\a \x01(\tR\bbodyHash\x12\x16\n" +
+	"\x06source\x18\b \x01(\tR\x06source\x12\x1d\n" +
+	"\n" +
+	"parent_url\x18\t \x01(\tR\tparentUrl\x12\x18\n" +
+	"\asecrets\x18\n" +
+	" \x03(\tR\asecrets\x12:\n" +
+	"\bmetadata\x18\v \x03(\v2\x1e.gobuster.Result.MetadataEntryR\bmetadata\x12\x16\n" +
+	"\x06target\x18\f \x01(\tR\x06target\x12\x1a\n" +
+	"\bprotocol\x18\r \x01(\tR\bprotocol\x12\x14\n" +
+	"\x05words\x18\x0e \x01(\x05R\x05words\x12\x14\n" +
+	"\x05lines\x18\x0f \x01(\x05R\x05lines\x12!\n" +
+	"\fsniffed_type\x18\x10 \x01(\tR\vsniffedType\x12\x18\n" +
+	"\aanomaly\x18\x11 \x01(\tR\aanomaly\x12\x14\n" +
+	"\x05addrs\x18\x12 \x03(\tR\x05addrs\x12\x18\n" +
+	"\arecords\x18\x13 \x03(\tR\arecords\x1a;\n" +
+	"\rMetadataEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"\x0f\n" +
+	"\rStatusRequest\"\x90\x01\n" +
+	"\x0eStatusResponse\x12\x12\n" +
+	"\x04done\x18\x01 \x01(\x03R\x04done\x12\x12\n" +
+	"\x04todo\x18\x02 \x01(\x03R\x04todo\x12\x1b\n" +
+	"\tdirs_done\x18\x03 \x01(\x05R\bdirsDone\x12\x1d\n" +
+	"\n" +
+	"dirs_total\x18\x04 \x01(\x05R\tdirsTotal\x12\x1a\n" +
+	"\bstopping\x18\x05 \x01(\bR\bstopping\"\r\n" +
+	"\vStopRequest\"\x0e\n" +
+	"\fStopResponse2\xb6\x01\n" +
+	"\aScanner\x127\n" +
+	"\aResults\x12\x18.gobuster.ResultsRequest\x1a\x10.gobuster.Result0\x01\x12;\n" +
+	"\x06Status\x12\x17.gobuster.StatusRequest\x1a\x18.gobuster.StatusResponse\x125\n" +
+	"\x04Stop\x12\x15.gobuster.StopRequest\x1a\x16.gobuster.StopResponseB\x1fZ\x1dgithub.com/Matir/gobuster/rpcb\x06proto3"
+
+var (
+	file_gobuster_proto_rawDescOnce sync.Once
+	file_gobuster_proto_rawDescData []byte
+)
+
+func file_gobuster_proto_rawDescGZIP() []byte {
+	file_gobuster_proto_rawDescOnce.Do(func() {
+		file_gobuster_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_gobuster_proto_rawDesc), len(file_gobuster_proto_rawDesc)))
+	})
+	return file_gobuster_proto_rawDescData
+}
+
+var file_gobuster_proto_msgTypes = make([]protoimpl.MessageInfo, 7)
+var file_gobuster_proto_goTypes = []any{
+	(*ResultsRequest)(nil), // 0: gobuster.ResultsRequest
+	(*Result)(nil),         // 1: gobuster.Result
+	(*StatusRequest)(nil),  // 2: gobuster.StatusRequest
+	(*StatusResponse)(nil), // 3: gobuster.StatusResponse
+	(*StopRequest)(nil),    // 4: gobuster.StopRequest
+	(*StopResponse)(nil),   // 5: gobuster.StopResponse
+	nil,                    // 6: gobuster.Result.MetadataEntry
+}
+var file_gobuster_proto_depIdxs = []int32{
+	6, // 0: gobuster.Result.metadata:type_name -> gobuster.Result.MetadataEntry
+	0, // 1: gobuster.Scanner.Results:input_type -> gobuster.ResultsRequest
+	2, // 2: gobuster.Scanner.Status:input_type -> gobuster.StatusRequest
+	4, // 3: gobuster.Scanner.Stop:input_type -> gobuster.StopRequest
+	1, // 4: gobuster.Scanner.Results:output_type -> gobuster.Result
+	3, // 5: gobuster.Scanner.Status:output_type -> gobuster.StatusResponse
+	5, // 6: gobuster.Scanner.Stop:output_type -> gobuster.StopResponse
+	4, // [4:7] is the sub-list for method output_type
+	1, // [1:4] is the sub-list for method input_type
+	1, // [1:1] is the sub-list for extension type_name
+	1, // [1:1] is the sub-list for extension extendee
+	0, // [0:1] is the sub-list for field type_name
+}
+
+func init() { file_gobuster_proto_init() }
+func file_gobuster_proto_init() {
+	if File_gobuster_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_gobuster_proto_rawDesc), len(file_gobuster_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   7,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_gobuster_proto_goTypes,
+		DependencyIndexes: file_gobuster_proto_depIdxs,
+		MessageInfos:      file_gobuster_proto_msgTypes,
+	}.Build()
+	File_gobuster_proto = out.File
+	file_gobuster_proto_goTypes = nil
+	file_gobuster_proto_depIdxs = nil
+}
@@ -0,0 +1,243 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package rpc serves a running scan over gRPC, so orchestration platforms
+// can follow its results as they are found and stop it.
+package rpc
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative gobuster.proto
+
+import (
+	"context"
+	"github.com/Matir/gobuster/logging"
+	"github.com/Matir/gobuster/results"
+	"google.golang.org/grpc"
+	"net"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// Results a client may fall behind by before it misses some
+const streamBuffer = 1024
+
+// How long to let clients read the last results before closing
+var closeTimeout = 5 * time.Second
+
+// A Server answers the Scanner service for one scan.
+type Server struct {
+	UnimplementedScannerServer
+	server   *grpc.Server
+	listener net.Listener
+	status   func() *StatusResponse
+	stop     func()
+	// Clients streaming results
+	mu       sync.Mutex
+	streams  map[*resultStream]bool
+	finished bool
+}
+
+type resultStream struct {
+	includeAll bool
+	results    chan *Result
+	dropped    int
+}
+
+// Listen on addr and serve the Scanner service.  Status reports progress,
+// and stop is called when a client asks the scan to stop.
+func Listen(addr string, status func() *StatusResponse, stop func()) (*Server, error) {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	s := &Server{
+		server:   grpc.NewServer(),
+		listener: listener,
+		status:   status,
+		stop:     stop,
+		streams:  make(map[*resultStream]bool),
+	}
+	RegisterScannerServer(s.server, s)
+	logging.Logf(logging.LogInfo, "Serving gRPC on %s.", listener.Addr().String())
+	go func() {
+		if err := s.server.Serve(listener); err != nil {
+			logging.Logf(logging.LogWarning, "gRPC server stopped: %s", err.Error())
+		}
+	}()
+	return s, nil
+}
+
+// The address being served on.
+func (s *Server) Addr() net.Addr {
+	return s.listener.Addr()
+}
+
+// Send results to clients as they pass through.  Streams end once src is
+// closed.
+func (s *Server) Watch(src <-chan results.Result) <-chan results.Result {
+	if s == nil {
+		return src
+	}
+	c := make(chan results.Result, cap(src))
+	go func() {
+		defer close(c)
+		for r := range src {
+			s.publish(r)
+			c <- r
+		}
+		s.finish()
+	}()
+	return c
+}
+
+// Stop serving, giving clients a little time to read what is left.
+func (s *Server) Close() {
+	if s == nil {
+		return
+	}
+	s.finish()
+	done := make(chan bool)
+	go func() {
+		s.server.GracefulStop()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(closeTimeout):
+		s.server.Stop()
+	}
+}
+
+func (s *Server) Results(req *ResultsRequest, stream grpc.ServerStreamingServer[Result]) error {
+	rs := s.subscribe(req.IncludeAll)
+	defer s.unsubscribe(rs)
+	for {
+		select {
+		case r, ok := <-rs.results:
+			if !ok {
+				return nil
+			}
+			if err := stream.Send(r); err != nil {
+				return err
+			}
+		case <-stream.Context().Done():
+			return stream.Context().Err()
+		}
+	}
+}
+
+func (s *Server) Status(context.Context, *StatusRequest) (*StatusResponse, error) {
+	return s.status(), nil
+}
+
+func (s *Server) Stop(context.Context, *StopRequest) (*StopResponse, error) {
+	logging.Logf(logging.LogWarning, "Stopping the scan at the request of a gRPC client.")
+	s.stop()
+	return &StopResponse{}, nil
+}
+
+func (s *Server) subscribe(includeAll bool) *resultStream {
+	rs := &resultStream{includeAll: includeAll, results: make(chan *Result, streamBuffer)}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.finished {
+		close(rs.results)
+	} else {
+		s.streams[rs] = true
+	}
+	return rs
+}
+
+func (s *Server) unsubscribe(rs *resultStream) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.streams, rs)
+}
+
+// Queue a result for every client that wants it.  Clients too far behind
+// miss results rather than hold up the scan.
+func (s *Server) publish(r results.Result) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.streams) == 0 {
+		return
+	}
+	reported := results.ReportResult(r)
+	msg := NewResult(r)
+	for rs := range s.streams {
+		if !reported && !rs.includeAll {
+			continue
+		}
+		select {
+		case rs.results <- msg:
+		default:
+			if rs.dropped == 0 {
+				logging.Logf(logging.LogWarning, "A gRPC client is falling behind, it will miss some results.")
+			}
+			rs.dropped++
+		}
+	}
+}
+
+// End every stream once their results are sent.
+func (s *Server) finish() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.finished {
+		return
+	}
+	s.finished = true
+	for rs := range s.streams {
+		close(rs.results)
+		if rs.dropped > 0 {
+			logging.Logf(logging.LogWarning, "A gRPC client missed %d results.", rs.dropped)
+		}
+	}
+	s.streams = make(map[*resultStream]bool)
+}
+
+// Convert a result to its message.
+func NewResult(r results.Result) *Result {
+	msg := &Result{
+		Url:         stringURL(r.URL),
+		Code:        int32(r.Code),
+		RedirectUrl: stringURL(r.Redir),
+		Length:      r.Length,
+		ContentType: r.ContentType,
+		BodyHash:    r.BodyHash,
+		Source:      r.Source,
+		ParentUrl:   stringURL(r.Parent),
+		Secrets:     r.Secrets,
+		Metadata:    r.Metadata,
+		Target:      r.Target,
+		Protocol:    r.Protocol,
+		Words:       int32(r.Words),
+		Lines:       int32(r.Lines),
+		SniffedType: r.SniffedType,
+		Anomaly:     r.Anomaly,
+		Addrs:       r.Addrs,
+		Records:     r.Records,
+	}
+	if r.Error != nil {
+		msg.Error = r.Error.Error()
+	}
+	return msg
+}
+
+func stringURL(u *url.URL) string {
+	if u == nil {
+		return ""
+	}
+	return u.String()
+}
@@ -0,0 +1,85 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rpc
+
+import (
+	"context"
+	"github.com/Matir/gobuster/results"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"io"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestServer(t *testing.T) {
+	var stopped int32
+	status := func() *StatusResponse {
+		return &StatusResponse{Done: 3, Todo: 5, Stopping: atomic.LoadInt32(&stopped) != 0}
+	}
+	s, err := Listen("127.0.0.1:0", status, func() { atomic.StoreInt32(&stopped, 1) })
+	if err != nil {
+		t.Fatalf("Unable to listen: %v", err)
+	}
+	defer s.Close()
+	conn, err := grpc.NewClient(s.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("Unable to connect: %v", err)
+	}
+	defer conn.Close()
+	client := NewScannerClient(conn)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	stream, err := client.Results(ctx, &ResultsRequest{})
+	if err != nil {
+		t.Fatalf("Unable to stream results: %v", err)
+	}
+	// Results are only sent to clients already streaming
+	for subscribed := false; !subscribed; time.Sleep(10 * time.Millisecond) {
+		s.mu.Lock()
+		subscribed = len(s.streams) == 1
+		s.mu.Unlock()
+	}
+	src := make(chan results.Result, 2)
+	out := s.Watch(src)
+	src <- results.Result{URL: &url.URL{Scheme: "http", Host: "localhost", Path: "/missing"}, Code: 404}
+	src <- results.Result{URL: &url.URL{Scheme: "http", Host: "localhost", Path: "/admin"}, Code: 200, Length: 12}
+	close(src)
+	for range out {
+	}
+	r, err := stream.Recv()
+	if err != nil {
+		t.Fatalf("Expected a result, got %v", err)
+	}
+	if r.Url != "http://localhost/admin" || r.Code != 200 || r.Length != 12 {
+		t.Errorf("Expected only the found result, got %v", r)
+	}
+	if _, err := stream.Recv(); err != io.EOF {
+		t.Errorf("Expected the stream to end with the results, got %v", err)
+	}
+
+	if st, err := client.Status(ctx, &StatusRequest{}); err != nil || st.Done != 3 || st.Todo != 5 {
+		t.Errorf("Expected status 3 of 5, got %v (%v)", st, err)
+	}
+	if _, err := client.Stop(ctx, &StopRequest{}); err != nil {
+		t.Errorf("Unable to stop the scan: %v", err)
+	}
+	if st, err := client.Status(ctx, &StatusRequest{}); err != nil || !st.Stopping {
+		t.Errorf("Expected the scan stopping, got %v (%v)", st, err)
+	}
+}
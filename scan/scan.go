@@ -0,0 +1,702 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package scan runs a scan from its settings, for the command line and for
+// programs embedding the scanner.
+package scan
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/Matir/gobuster/audit"
+	"github.com/Matir/gobuster/client"
+	"github.com/Matir/gobuster/filter"
+	"github.com/Matir/gobuster/history"
+	"github.com/Matir/gobuster/knowledge"
+	"github.com/Matir/gobuster/logging"
+	"github.com/Matir/gobuster/pipeline"
+	"github.com/Matir/gobuster/progress"
+	"github.com/Matir/gobuster/results"
+	"github.com/Matir/gobuster/rpc"
+	ss "github.com/Matir/gobuster/settings"
+	"github.com/Matir/gobuster/util"
+	"github.com/Matir/gobuster/wordlist"
+	"github.com/Matir/gobuster/worker"
+	"github.com/Matir/gobuster/workqueue"
+	"net/url"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ErrPaused is returned when the scan stopped early, with its state saved, to
+// be continued with -resume.
+var ErrPaused = errors.New("Scan paused.")
+
+// ErrStopped is returned when a gRPC client stopped the scan early, and it
+// has no state file to save.
+var ErrStopped = errors.New("Scan stopped.")
+
+// Run a scan with settings, as from ss.GetScanSettings, until it is done or
+// ctx is cancelled.  Hooks, which may be nil, are called from the workers of
+// every mode and as URLs are queued.  Cancelling ctx, or a gRPC client
+// calling Stop, stops handing out work; with a state file the state is saved
+// and ErrPaused returned, as when the scan pauses itself, and otherwise the
+// context's error or ErrStopped is.  The settings are not changed.
+func Run(ctx context.Context, settings *ss.ScanSettings, hooks *results.Hooks) error {
+	// Targets and the state file may come from a resumed scan, and phases
+	// change settings as they go
+	copied := *settings
+	settings = &copied
+	// gRPC clients can stop the scan too
+	ctx, stop := context.WithCancelCause(ctx)
+	defer stop(nil)
+
+	// Open wordlist, which is read as it is needed
+	words, err := openWords(settings)
+	if err != nil {
+		return fmt.Errorf("Unable to load wordlist: %s", err.Error())
+	}
+
+	// Build an HTTP Client Factory
+	logging.Logf(logging.LogDebug, "Creating Client Factory...")
+	clientFactory, err := client.NewProxyClientFactory(settings.Proxies, settings.Timeout, settings.UserAgent)
+	if err != nil {
+		return fmt.Errorf("Unable to build client factory: %s", err.Error())
+	}
+
+	clientFactory.SetProxyPerRequest(settings.ProxyRotation == ss.ProxyPerRequest)
+	switch settings.HTTP2 {
+	case ss.HTTP2Off:
+		clientFactory.SetHTTP2(client.HTTP2Disabled)
+	case ss.HTTP2On:
+		clientFactory.SetHTTP2(client.HTTP2Negotiated)
+	case ss.HTTP2PriorKnowledge:
+		clientFactory.SetHTTP2(client.HTTP2PriorKnowledge)
+	}
+	clientFactory.SetHTTP3(settings.HTTP3)
+	clientFactory.SetUnixSocket(settings.UnixSocket)
+	clientFactory.SetHost(settings.Host)
+
+	var local *client.LocalAddrs
+	if len(settings.Bind) > 0 {
+		if local, err = client.ParseLocalAddrs(settings.Bind); err != nil {
+			return err
+		}
+		clientFactory.SetLocalAddrs(local)
+	}
+	// Remote wordlists go through the same proxies and local addresses, but
+	// not to the scan's host or with its credentials
+	fetchFactory, err := client.NewProxyClientFactory(settings.Proxies, 5*time.Minute, settings.UserAgent)
+	if err != nil {
+		return fmt.Errorf("Unable to build client factory: %s", err.Error())
+	}
+	fetchFactory.SetLocalAddrs(local)
+	wordlist.Factory = fetchFactory
+	if settings.MaxBandwidth > 0 {
+		clientFactory.SetBandwidthLimiter(client.NewBandwidthLimiter(settings.MaxBandwidth))
+	}
+	if settings.MaxRate > 0 {
+		clientFactory.SetRateLimiter(client.NewRateLimiter(settings.MaxRate, settings.RateFile))
+	}
+	var auditLog *audit.Log
+	if settings.AuditLogPath != "" {
+		if auditLog, err = audit.Open(settings.AuditLogPath); err != nil {
+			return fmt.Errorf("Unable to open audit log: %s", err.Error())
+		}
+		defer auditLog.Close()
+		clientFactory.SetAuditLog(auditLog)
+	}
+	throttles := client.NewThrottleProfiler()
+	clientFactory.SetThrottleProfiler(throttles)
+	clientFactory.SetDecompressBudget(settings.DecompressBudget)
+	clientFactory.SetMinThroughput(settings.MinThroughput)
+	if len(settings.Credentials) > 0 {
+		credentials, err := client.ParseCredentials(settings.Credentials)
+		if err != nil {
+			return err
+		}
+		clientFactory.SetCredentials(credentials)
+	}
+	if settings.ClientCert != "" {
+		cert, err := client.LoadClientCertificate(settings.ClientCert, settings.ClientKey, settings.ClientCertPassword)
+		if err != nil {
+			return fmt.Errorf("Unable to load client certificate: %s", err.Error())
+		}
+		clientFactory.SetClientCertificate(cert)
+	}
+	if len(settings.Headers) > 0 {
+		headers, err := client.ParseHeaders(settings.Headers)
+		if err != nil {
+			return err
+		}
+		clientFactory.SetHeaders(headers)
+	}
+	if len(settings.Cookies) > 0 {
+		cookies, err := client.ParseCookies(settings.Cookies)
+		if err != nil {
+			return err
+		}
+		clientFactory.SetCookies(cookies)
+	}
+	if settings.KeepCookies || len(settings.Credentials) > 0 {
+		clientFactory.SetCookieJars(client.NewHostJars())
+	}
+
+	// Continue an interrupted scan
+	var resumeState *workqueue.ScanState
+	if settings.ResumePath != "" {
+		if resumeState, err = workqueue.LoadState(settings.ResumePath); err != nil {
+			return fmt.Errorf("Unable to load scan state: %s", err.Error())
+		}
+		if resumeState.WordlistHash != words.Hash() {
+			return fmt.Errorf("The wordlist differs from the one used by the interrupted scan.")
+		}
+		if resumeState.SettingsHash != settings.Fingerprint() {
+			logging.Logf(logging.LogWarning, "Settings differ from those of the interrupted scan.")
+		}
+		if len(settings.BaseURLs) == 0 {
+			settings.BaseURLs = resumeState.Targets
+		}
+		if settings.StatePath == "" {
+			settings.StatePath = settings.ResumePath
+		}
+	}
+
+	// Starting point
+	scope, err := settings.GetScopes()
+	if err != nil {
+		return err
+	}
+
+	// Check for duplicate scans
+	var scanHistory *history.History
+	var wordlistHash, settingsHash string
+	if settings.HistoryPath != "" {
+		if scanHistory, err = history.Load(settings.HistoryPath); err != nil {
+			return fmt.Errorf("Unable to load history: %s", err.Error())
+		}
+		wordlistHash = words.Hash()
+		settingsHash = settings.Fingerprint()
+		kept := scope[:0]
+		keptBase := make([]string, 0, len(settings.BaseURLs))
+		for i, u := range scope {
+			if prev := scanHistory.FindRecent(u.String(), wordlistHash, settingsHash, settings.HistoryWindow); prev != nil {
+				logging.Logf(logging.LogWarning, "%s was already scanned with these settings at %s.", u, prev.Completed.Format(time.RFC3339))
+				if settings.SkipDuplicates {
+					continue
+				}
+			}
+			kept = append(kept, u)
+			keptBase = append(keptBase, settings.BaseURLs[i])
+		}
+		scope = kept
+		settings.BaseURLs = keptBase
+		if len(scope) == 0 {
+			return fmt.Errorf("All targets were skipped as duplicates.")
+		}
+	}
+
+	// Load what earlier scans found
+	var kb *knowledge.Base
+	var knownURLs []*url.URL
+	if settings.KnowledgePath != "" {
+		if kb, err = knowledge.Load(settings.KnowledgePath); err != nil {
+			return fmt.Errorf("Unable to load knowledge base: %s", err.Error())
+		}
+		if settings.KnowledgeMode == ss.KnowledgeGaps {
+			knownURLs = kb.Known(scope...)
+			logging.Logf(logging.LogInfo, "Skipping %d known URLs.", len(knownURLs))
+		}
+	}
+
+	// Resolve targets up front
+	if settings.PreResolve && len(settings.Proxies) == 0 && settings.UnixSocket == "" {
+		resolver := client.NewResolver(settings.Timeout)
+		if settings.Resolver != "" {
+			resolver.SetServer(settings.Resolver)
+		}
+		resolver.SetLocalAddrs(local)
+		hosts := make([]string, 0, len(scope))
+		for _, u := range scope {
+			hosts = append(hosts, u.Hostname())
+		}
+		logging.Logf(logging.LogDebug, "Resolving %d hosts...", len(hosts))
+		for host, err := range resolver.Resolve(hosts) {
+			logging.Logf(logging.LogWarning, "Unable to resolve %s: %s", host, err.Error())
+		}
+		clientFactory.SetResolver(resolver)
+	}
+
+	// Setup the main workqueue, run once everything it feeds is ready
+	logging.Logf(logging.LogDebug, "Creating work queue...")
+	queue := workqueue.NewWorkQueue(settings.QueueSize, scope, settings.AllowHTTPSUpgrade)
+	queue.AllowOrigins(settings.AllowCrossOrigin...)
+	if settings.ScopeSubdomains {
+		queue.AllowOrigins(workqueue.SubdomainPatterns(scope)...)
+	}
+	var state *workqueue.StateTracker
+	if settings.StatePath != "" {
+		state = workqueue.NewStateTracker(settings.StatePath, settings.StateInterval, settings.Fingerprint(), words.Hash(), settings.BaseURLs)
+		state.Restore(resumeState)
+		queue.SetStateTracker(state)
+	}
+	queue.SetHooks(hooks)
+
+	logging.Logf(logging.LogDebug, "Creating expander and filter...")
+	// Opened below, once nothing else can fail
+	var events *results.EventStream
+	// Directories brute-forced, for later phases to start from
+	var phaseDirs []*url.URL
+	var phaseDirsMu sync.Mutex
+	phaseDirsSeen := make(map[string]bool)
+	dirs := workqueue.NewDirectoryTracker(func(s workqueue.DirectorySummary) {
+		logging.Logf(logging.LogInfo, "Directory finished: %s", s)
+		events.Directory(s.URL, s.Children, s.Found, s.Codes)
+		if len(settings.Phases) > 1 && util.URLIsDir(s.URL) {
+			phaseDirsMu.Lock()
+			if !phaseDirsSeen[s.URL.String()] {
+				phaseDirsSeen[s.URL.String()] = true
+				phaseDirs = append(phaseDirs, s.URL)
+			}
+			phaseDirsMu.Unlock()
+		}
+	})
+	apiVersions := filter.NewAPIVersions(settings.APIVersions)
+	dirMode := settings.Mode == ss.ModeDir
+	// GCS buckets are paths, the other modes brute-force hostnames
+	subdomains := !dirMode && settings.Mode != ss.ModeGCS
+	var existence *workqueue.DirExistence
+	if settings.VerifyDirs && dirMode {
+		// Targets and known directories are expanded without checking
+		existence = workqueue.NewDirExistence()
+		existence.Assume(scope...)
+		existence.Assume(knownURLs...)
+	}
+	// Scans in phases start with the first
+	restorePhase := func() {}
+	if len(settings.Phases) > 0 {
+		if restorePhase, words, err = startPhase(settings, settings.Phases[0]); err != nil {
+			return err
+		}
+	}
+	expander := filter.Expander{Words: words, Adder: queue.GetAddCount(), Dirs: dirs, Versions: apiVersions, State: state, Subdomains: subdomains, Domain: settings.VHostDomain, Existence: existence}
+	if settings.Mode == ss.ModeFuzz {
+		expander.Subdomains = false
+		expander.Fuzz = true
+		expander.FuzzFragment = settings.FuzzesRequest()
+	}
+	var wordErr error
+	switch settings.Mode {
+	case ss.ModeDir:
+		wordErr = expander.ProcessWordlist()
+	case ss.ModeS3:
+		wordErr = expander.ProcessBucketNames(settings.BucketKeyword, filter.S3BucketName)
+	case ss.ModeGCS:
+		wordErr = expander.ProcessBucketNames(settings.BucketKeyword, filter.GCSBucketName)
+	case ss.ModeDNS, ss.ModeVHost:
+		if len(settings.PassiveSources) > 0 {
+			// Target credentials aren't for passive sources
+			seeds := passiveWords(settings, scope, clientFactory.Anonymous().Get())
+			logging.Logf(logging.LogInfo, "Trying %d subdomains from passive sources first.", len(seeds))
+			expander.Words, wordErr = expander.Words.Prepend(seeds)
+		}
+		if settings.Permute && wordErr == nil {
+			rules := filter.DefaultPermutationRules
+			if settings.PermutationRulesPath != "" {
+				if rules, err = filter.LoadPermutationRules(settings.PermutationRulesPath); err != nil {
+					return fmt.Errorf("Unable to load permutation rules: %s", err.Error())
+				}
+			}
+			wordErr = expander.ProcessPermutations(rules)
+		}
+	}
+	if wordErr != nil {
+		return fmt.Errorf("Unable to load wordlist: %s", wordErr.Error())
+	}
+	workFilter := filter.NewWorkFilter(settings, queue.GetDoneFunc())
+	workFilter.SetDirectoryTracker(dirs)
+	workFilter.SetStateTracker(state)
+	workFilter.SetProvenanceTracker(queue.Provenance())
+	if resumeState != nil {
+		workFilter.MarkDone(resumeState.Done...)
+	}
+	for _, u := range knownURLs {
+		workFilter.MarkDone(u.String())
+	}
+	if n, m, err := settings.ShardSlice(); err == nil && m > 1 {
+		logging.Logf(logging.LogInfo, "Scanning shard %d of %d.", n, m)
+		workFilter.SetShard(filter.NewShard(n, m, scope))
+	}
+
+	// Check robots mode
+	if settings.RobotsMode == ss.ObeyRobots && dirMode {
+		workFilter.AddRobotsFilter(scope, clientFactory)
+	}
+
+	// Load what else the scan needs, before anything is opened or started
+	var resultRules filter.ResultRules
+	if settings.ResultRulesPath != "" {
+		if resultRules, err = filter.LoadResultRules(settings.ResultRulesPath); err != nil {
+			return fmt.Errorf("Unable to load result rules: %s", err.Error())
+		}
+	}
+	var queuedURLs []*url.URL
+	var queuedProvs []workqueue.Provenance
+	if resumeState != nil {
+		if queuedURLs, queuedProvs, err = resumeState.QueuedURLs(); err != nil {
+			return fmt.Errorf("Invalid scan state: %s", err.Error())
+		}
+	}
+	var heartbeat *progress.Heartbeat
+	if settings.Heartbeat != "" {
+		heartbeat, err = progress.NewHeartbeat(settings.Heartbeat, settings.HeartbeatInterval, func() int64 {
+			done, _ := queue.Counts()
+			return done
+		})
+		if err != nil {
+			return fmt.Errorf("Unable to start heartbeat: %s", err.Error())
+		}
+	}
+	// Set when the scan stops early to be resumed later
+	var paused int32
+	// Set when ctx is cancelled
+	var interrupted int32
+	var rpcServer *rpc.Server
+	if settings.GRPCListen != "" {
+		status := func() *rpc.StatusResponse {
+			done, todo := queue.Counts()
+			dirsDone, dirsTotal := dirs.Counts()
+			return &rpc.StatusResponse{
+				Done:      done,
+				Todo:      todo,
+				DirsDone:  int32(dirsDone),
+				DirsTotal: int32(dirsTotal),
+				Stopping:  atomic.LoadInt32(&paused) != 0 || atomic.LoadInt32(&interrupted) != 0,
+			}
+		}
+		if rpcServer, err = rpc.Listen(settings.GRPCListen, status, func() { stop(ErrStopped) }); err != nil {
+			return fmt.Errorf("Unable to serve gRPC: %s", err.Error())
+		}
+	}
+	if events, err = worker.OpenEventStream(settings); err != nil {
+		rpcServer.Close()
+		return fmt.Errorf("Unable to open event stream: %s", err.Error())
+	}
+	store, err := worker.OpenBodyStore(settings)
+	if err != nil {
+		events.Close()
+		rpcServer.Close()
+		return fmt.Errorf("Unable to open body store: %s", err.Error())
+	}
+	logging.Logf(logging.LogDebug, "Creating results manager...")
+	resultsManager, err := pipeline.New(settings)
+	if err != nil {
+		if store != nil {
+			store.Close()
+		}
+		events.Close()
+		rpcServer.Close()
+		return fmt.Errorf("Unable to start results manager: %s", err.Error())
+	}
+
+	logging.Logf(logging.LogDebug, "Starting work queue...")
+	queue.RunInBackground()
+	work := workFilter.RunFilter(expander.Expand(queue.GetWorkChan()))
+	rchan := make(chan results.Result, settings.QueueSize)
+
+	logging.Logf(logging.LogDebug, "Starting %d workers...", settings.Workers)
+	switch settings.Mode {
+	case ss.ModeDNS:
+		worker.StartDNSWorkers(settings, work, queue.Provenance(), dirs, state, hooks, queue.GetDoneFunc(), rchan)
+	case ss.ModeVHost:
+		worker.StartVHostWorkers(settings, clientFactory, work, queue.Provenance(), dirs, state, hooks, queue.GetDoneFunc(), rchan)
+	case ss.ModeS3, ss.ModeGCS:
+		worker.StartBucketWorkers(settings, clientFactory, work, queue.Provenance(), dirs, state, hooks, queue.GetDoneFunc(), rchan)
+	case ss.ModeFuzz:
+		worker.StartFuzzWorkers(settings, clientFactory, work, queue.Provenance(), dirs, state, hooks, queue.GetDoneFunc(), rchan)
+	default:
+		worker.StartWorkers(settings, clientFactory, work, queue.GetAddFunc(), queue.Provenance(), dirs, state, existence, store, events, hooks, queue.GetDoneFunc(), rchan)
+	}
+
+	var resultsChan <-chan results.Result = rchan
+	if resultRules != nil {
+		resultsChan = resultRules.FilterResults(rchan)
+	}
+	resultsChan = apiVersions.Watch(resultsChan)
+	if settings.DetectDeception && dirMode {
+		deception := filter.NewDeceptionDetector(func(host, reason string) {
+			logging.Logf(logging.LogWarning, "%s looks like a deception environment (%s), its results are likely false positives.", host, reason)
+			if settings.DeceptionPause && atomic.CompareAndSwapInt32(&paused, 0, 1) {
+				logging.Logf(logging.LogWarning, "Pausing, saving scan state to %s.  Check the target, then continue with -resume.", settings.StatePath)
+				queue.Cancel()
+			}
+		})
+		resultsChan = deception.Watch(resultsChan)
+	}
+	resultsChan = state.Watch(resultsChan)
+	resultsChan = kb.Watch(resultsChan, settings.KnowledgeMode == ss.KnowledgeNew)
+	resultsChan = rpcServer.Watch(resultsChan)
+
+	logging.Logf(logging.LogDebug, "Starting results manager...")
+	resultsManager.Run(resultsChan)
+
+	if resumeState != nil {
+		// Report earlier results again, then pick up the queue where it was
+		for _, data := range resumeState.Results {
+			if r, err := results.DecodeResult(data); err == nil {
+				rchan <- r
+			}
+		}
+		logging.Logf(logging.LogInfo, "Resuming with %d queued URLs, %d done.", len(queuedURLs), len(resumeState.Done))
+		// They were queued to be expanded, so already passed any check
+		existence.Assume(queuedURLs...)
+		for i, u := range queuedURLs {
+			queue.AddURLsFrom(queuedProvs[i], u)
+		}
+	} else {
+		// Kick things off with the seed URL
+		logging.Logf(logging.LogDebug, "Adding starting URLs: %v", scope)
+		queue.AddURLsFrom(workqueue.Provenance{Source: workqueue.SourceSeed}, scope...)
+
+		// Potentially seed from robots
+		if settings.RobotsMode == ss.SeedRobots && dirMode {
+			queue.SeedFromRobots(scope, clientFactory)
+		}
+		if settings.WellKnown && dirMode {
+			queue.SeedWellKnown(scope)
+		}
+
+		// Known directories aren't requested again, but their contents may
+		// have gaps
+		for _, u := range knownURLs {
+			if util.URLIsDir(u) && !urlInScope(u, scope) {
+				queue.AddURLsFrom(workqueue.Provenance{Source: workqueue.SourceKnowledge}, u)
+			}
+		}
+	}
+
+	state.Start()
+	// Stop handing out work when cancelled, pausing if the state is saved
+	finished := make(chan bool)
+	defer close(finished)
+	go func() {
+		select {
+		case <-ctx.Done():
+		case <-finished:
+			return
+		}
+		atomic.StoreInt32(&interrupted, 1)
+		if state != nil && atomic.CompareAndSwapInt32(&paused, 0, 1) {
+			logging.Logf(logging.LogWarning, "Interrupted, saving scan state to %s.", settings.StatePath)
+		} else {
+			logging.Logf(logging.LogWarning, "Interrupted, finishing requests already made.")
+		}
+		queue.Cancel()
+	}()
+
+	var reporter *progress.Reporter
+	if progress.ShouldReport(os.Stderr, settings.Progress, settings.ProgressLog) {
+		reporter = progress.NewReporter(os.Stderr, settings.ProgressInterval, func() progress.Stats {
+			done, todo := queue.Counts()
+			dirsDone, dirsTotal := dirs.Counts()
+			return progress.Stats{Done: done, Todo: todo, DirsDone: dirsDone, DirsTotal: dirsTotal}
+		})
+		reporter.Start()
+	}
+	if heartbeat != nil {
+		heartbeat.Start()
+	}
+
+	// Wait for work to be done
+	logging.Logf(logging.LogDebug, "Main goroutine waiting for work...")
+	queue.WaitPipe()
+	restorePhase()
+	var phaseErr error
+	if len(settings.Phases) > 1 {
+		// Later phases start over from the targets and every directory
+		// found, and the filter skips what was already tried
+		for _, phase := range settings.Phases[1:] {
+			if atomic.LoadInt32(&paused) != 0 || atomic.LoadInt32(&interrupted) != 0 {
+				break
+			}
+			if restorePhase, expander.Words, phaseErr = startPhase(settings, phase); phaseErr == nil {
+				phaseErr = expander.ProcessWordlist()
+			}
+			if phaseErr != nil {
+				break
+			}
+			phaseDirsMu.Lock()
+			seeds := uniqueURLs(append(append([]*url.URL{}, scope...), phaseDirs...))
+			phaseDirsMu.Unlock()
+			existence.Assume(seeds...)
+			queue.AddURLsFrom(workqueue.Provenance{Source: workqueue.SourceSeed}, seeds...)
+			queue.WaitPipe()
+			restorePhase()
+		}
+	}
+	logging.Logf(logging.LogDebug, "Work done.")
+	if reporter != nil {
+		reporter.Stop()
+	}
+	if heartbeat != nil {
+		heartbeat.Stop()
+	}
+
+	// Cleanup
+	queue.InputFinished()
+	close(rchan)
+
+	resultsManager.Wait()
+	rpcServer.Close()
+	if store != nil {
+		if err := store.Close(); err != nil {
+			logging.Logf(logging.LogWarning, "Unable to close body store: %s", err.Error())
+		}
+	}
+	if err := events.Close(); err != nil {
+		logging.Logf(logging.LogWarning, "Unable to close event stream: %s", err.Error())
+	}
+	// Only a whole scan is finished with
+	complete := phaseErr == nil && atomic.LoadInt32(&paused) == 0 && atomic.LoadInt32(&interrupted) == 0
+	state.Stop(complete)
+	if err := kb.Save(); err != nil {
+		logging.Logf(logging.LogWarning, "Unable to save knowledge base: %s", err.Error())
+	}
+	if auditLog != nil {
+		logging.Logf(logging.LogInfo, "Audit log %s ends with hash %s.", settings.AuditLogPath, auditLog.Head())
+	}
+	for _, policy := range throttles.Policies() {
+		logging.Logf(logging.LogWarning, "Rate limit: %s", policy)
+	}
+	if scanHistory != nil && complete {
+		for _, u := range scope {
+			entry := history.Entry{
+				Target:       u.String(),
+				WordlistHash: wordlistHash,
+				SettingsHash: settingsHash,
+				Completed:    time.Now(),
+			}
+			if err := scanHistory.Record(entry); err != nil {
+				logging.Logf(logging.LogWarning, "Unable to record scan history: %s", err.Error())
+			}
+		}
+	}
+	switch {
+	case phaseErr != nil:
+		return phaseErr
+	case atomic.LoadInt32(&paused) != 0:
+		return ErrPaused
+	case atomic.LoadInt32(&interrupted) != 0:
+		return context.Cause(ctx)
+	}
+	return nil
+}
+
+// Open the wordlist, with the hand-picked common paths first or only its first
+// words if the settings say to.
+func openWords(settings *ss.ScanSettings) (*wordlist.Stream, error) {
+	words, err := wordlist.OpenWordlist(settings.WordlistPath)
+	if err == nil && settings.FrequentFirst {
+		words, err = words.FrequentFirst()
+	}
+	if err == nil && settings.MaxWords > 0 {
+		words, err = words.Head(settings.MaxWords)
+	}
+	return words, err
+}
+
+// Apply a phase of the scan and open its wordlist, returning a function that
+// puts the settings back.
+func startPhase(settings *ss.ScanSettings, phase ss.Phase) (func(), *wordlist.Stream, error) {
+	restore, err := settings.ApplyPhase(phase)
+	if err != nil {
+		return nil, nil, err
+	}
+	logging.Logf(logging.LogInfo, "Starting phase %s.", phase)
+	words, err := openWords(settings)
+	if err != nil {
+		restore()
+		return nil, nil, fmt.Errorf("Unable to load wordlist for phase %s: %s", phase.Name, err.Error())
+	}
+	return restore, words, nil
+}
+
+// URLs in order without duplicates.
+func uniqueURLs(urls []*url.URL) []*url.URL {
+	var unique []*url.URL
+	seen := make(map[string]bool)
+	for _, u := range urls {
+		if !seen[u.String()] {
+			seen[u.String()] = true
+			unique = append(unique, u)
+		}
+	}
+	return unique
+}
+
+// Words for the subdomains passive sources know of under each target's
+// domain, in order without duplicates.  Sources that can't be read are
+// skipped with a warning.
+func passiveWords(settings *ss.ScanSettings, scope []*url.URL, cl client.Client) []string {
+	var domains []string
+	if settings.VHostDomain != "" {
+		domains = append(domains, settings.VHostDomain)
+	} else {
+		for _, u := range scope {
+			domains = append(domains, u.Hostname())
+		}
+	}
+	var names []string
+	for _, source := range settings.PassiveSources {
+		if source != ss.PassiveCrtSh {
+			found, err := filter.LoadPassiveNames(source)
+			if err != nil {
+				logging.Logf(logging.LogWarning, "Unable to read passive source %s: %s", source, err.Error())
+			}
+			names = append(names, found...)
+			continue
+		}
+		for _, domain := range domains {
+			found, err := filter.CrtShNames(cl, domain)
+			if err != nil {
+				logging.Logf(logging.LogWarning, "Unable to search crt.sh for %s: %s", domain, err.Error())
+			}
+			names = append(names, found...)
+		}
+	}
+	var words []string
+	seen := make(map[string]bool)
+	for _, domain := range domains {
+		for _, w := range filter.SubdomainWords(names, domain) {
+			if !seen[w] {
+				seen[w] = true
+				words = append(words, w)
+			}
+		}
+	}
+	return words
+}
+
+// Whether u is one of the scope URLs themselves.
+func urlInScope(u *url.URL, scope []*url.URL) bool {
+	for _, s := range scope {
+		if s.String() == u.String() {
+			return true
+		}
+	}
+	return false
+}
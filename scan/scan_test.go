@@ -0,0 +1,198 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scan
+
+import (
+	"context"
+	"github.com/Matir/gobuster/results"
+	"github.com/Matir/gobuster/rpc"
+	ss "github.com/Matir/gobuster/settings"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+var defaultSettings struct {
+	once     sync.Once
+	settings *ss.ScanSettings
+}
+
+// Default settings for a scan writing its files to dir.  The defaults
+// register command line flags, so are only made once.
+func newTestSettings(dir string) *ss.ScanSettings {
+	defaultSettings.once.Do(func() {
+		defaultSettings.settings = ss.NewScanSettings()
+	})
+	settings := *defaultSettings.settings
+	settings.WordlistPath = filepath.Join(dir, "words")
+	settings.OutputPath = filepath.Join(dir, "out")
+	settings.ProgressLog = false
+	return &settings
+}
+
+func TestRun_Hooks(t *testing.T) {
+	var mu sync.Mutex
+	var requested, found []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requested = append(requested, r.URL.Path)
+		mu.Unlock()
+		switch r.URL.Path {
+		case "/":
+			w.Header().Set("Content-Type", "text/html")
+			w.Write([]byte(`<a href="/linked">Linked</a>`))
+		case "/admin", "/secret", "/linked":
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+	dir, err := ioutil.TempDir("", "gobuster-scan")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	settings := newTestSettings(dir)
+	settings.BaseURLs = []string{server.URL + "/"}
+	settings.Workers = 2
+	settings.Extensions = nil
+	settings.Mangle = false
+	if err := ioutil.WriteFile(settings.WordlistPath, []byte("admin\nsecret\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	hooks := &results.Hooks{
+		OnQueueAdd: func(u *url.URL) bool { return u.Path != "/linked" },
+		OnRequest:  func(u *url.URL) bool { return !strings.HasPrefix(u.Path, "/secret") },
+		OnFinding: func(r results.Result) bool {
+			mu.Lock()
+			defer mu.Unlock()
+			found = append(found, r.URL.Path)
+			return true
+		},
+	}
+	if err := Run(context.Background(), settings, hooks); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	sort.Strings(requested)
+	for _, path := range requested {
+		if strings.HasPrefix(path, "/secret") || path == "/linked" {
+			t.Errorf("Expected hooks to keep %s from being requested, got %v", path, requested)
+		}
+	}
+	if !strings.Contains(strings.Join(found, " "), "/admin") {
+		t.Errorf("Expected /admin found, got %v", found)
+	}
+}
+
+func TestRun_Cancel(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	defer server.Close()
+	dir, err := ioutil.TempDir("", "gobuster-scan")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	settings := newTestSettings(dir)
+	settings.BaseURLs = []string{server.URL + "/"}
+	settings.StatePath = filepath.Join(dir, "state")
+	if err := ioutil.WriteFile(settings.WordlistPath, []byte("admin\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := Run(ctx, settings, nil); err != ErrPaused {
+		t.Errorf("Expected the scan paused, got %v", err)
+	}
+	if _, err := os.Stat(settings.StatePath); err != nil {
+		t.Errorf("Expected the state saved: %v", err)
+	}
+	// Resuming fills in the targets and state file, but not the caller's
+	resume := newTestSettings(dir)
+	resume.ResumePath = settings.StatePath
+	if err := Run(context.Background(), resume, nil); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(resume.BaseURLs) != 0 || resume.StatePath != "" {
+		t.Errorf("Expected settings left alone, got %v and %q", resume.BaseURLs, resume.StatePath)
+	}
+}
+
+func TestRun_GRPC(t *testing.T) {
+	// A free port for the scan to serve on
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := l.Addr().String()
+	l.Close()
+	conn, err := grpc.NewClient(addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	client := rpc.NewScannerClient(conn)
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	// Follow the results and stop the scan as soon as it starts
+	var once sync.Once
+	streams := make(chan rpc.Scanner_ResultsClient, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		once.Do(func() {
+			stream, err := client.Results(ctx, &rpc.ResultsRequest{})
+			if err == nil {
+				_, err = client.Stop(ctx, &rpc.StopRequest{})
+			}
+			if err != nil {
+				t.Errorf("gRPC calls failed: %v", err)
+			}
+			streams <- stream
+		})
+	}))
+	defer server.Close()
+	dir, err := ioutil.TempDir("", "gobuster-scan")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	settings := newTestSettings(dir)
+	settings.BaseURLs = []string{server.URL + "/"}
+	settings.GRPCListen = addr
+	settings.Workers = 1
+	if err := ioutil.WriteFile(settings.WordlistPath, []byte("admin\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := Run(context.Background(), settings, nil); err != ErrStopped {
+		t.Errorf("Expected the scan stopped, got %v", err)
+	}
+	stream := <-streams
+	if stream == nil {
+		return
+	}
+	r, err := stream.Recv()
+	if err != nil || r.Url != server.URL+"/" {
+		t.Errorf("Expected the first result streamed, got %v (%v)", r, err)
+	}
+}
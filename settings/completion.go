@@ -0,0 +1,192 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package settings
+
+import (
+	"flag"
+	"fmt"
+	"github.com/Matir/gobuster/logging"
+	"github.com/Matir/gobuster/wordlist"
+	"io"
+	"sort"
+	"strings"
+)
+
+// Shells that completion scripts can be written for.
+var CompletionShells = []string{"bash", "zsh", "fish"}
+
+// A flag as seen by a completion script.
+type completionFlag struct {
+	name string
+	help string
+	// Whether the flag takes no value
+	isBool bool
+	// Suggested values, if any
+	values []string
+	// Whether files are also suggested
+	files bool
+}
+
+// Flags that name a file.
+var completionFileFlags = map[string]bool{
+	"wordlist":     true,
+	"url-file":     true,
+	"proxy-file":   true,
+	"config":       true,
+	"outfile":      true,
+	"events":       true,
+	"logfile":      true,
+	"audit-log":    true,
+	"history":      true,
+	"state":        true,
+	"resume":       true,
+	"kb":           true,
+	"result-rules": true,
+	"secret-rules": true,
+	"save-bodies":  true,
+}
+
+// Values to suggest for each flag, from the registries of profiles, wordlists
+// and output formats.
+func (settings *ScanSettings) CompletionValues() map[string][]string {
+	return map[string][]string{
+		"profile":        settings.ProfileNames(),
+		"wordlist":       append(wordlist.BuiltinWordlistNames(), wordlist.RemoteWordlistNames()...),
+		"format":         OutputFormatNames(),
+		"output-format":  OutputFormatNames(),
+		"robots-mode":    robotsModeStrings[:],
+		"kb-mode":        KnowledgeModes,
+		"proxy-rotation": ProxyRotations,
+		"http2":          HTTP2Modes,
+		"mode":           ScanModes,
+		"loglevel":       logging.LogLevelStrings[:],
+		"completion":     CompletionShells,
+	}
+}
+
+func (settings *ScanSettings) completionFlags() []completionFlag {
+	values := settings.CompletionValues()
+	var flags []completionFlag
+	settings.flagSet().VisitAll(func(f *flag.Flag) {
+		_, help := flag.UnquoteUsage(f)
+		cf := completionFlag{
+			name:   f.Name,
+			help:   strings.TrimSuffix(strings.SplitN(help, ".  ", 2)[0], "."),
+			values: values[f.Name],
+			files:  completionFileFlags[f.Name],
+		}
+		if b, ok := f.Value.(interface {
+			IsBoolFlag() bool
+		}); ok && b.IsBoolFlag() {
+			cf.isBool = true
+		}
+		flags = append(flags, cf)
+	})
+	sort.Slice(flags, func(i, j int) bool { return flags[i].name < flags[j].name })
+	return flags
+}
+
+// Write a completion script for shell.
+func (settings *ScanSettings) WriteCompletion(w io.Writer, shell string) error {
+	settings.InitFlags()
+	flags := settings.completionFlags()
+	switch shell {
+	case "bash":
+		writeBashCompletion(w, flags)
+	case "zsh":
+		writeZshCompletion(w, flags)
+	case "fish":
+		writeFishCompletion(w, flags)
+	default:
+		return fmt.Errorf("Unknown shell %s, expected one of %s.", shell, strings.Join(CompletionShells, ", "))
+	}
+	return nil
+}
+
+func writeBashCompletion(w io.Writer, flags []completionFlag) {
+	var names []string
+	fmt.Fprintf(w, "# bash completion for gobuster\n")
+	fmt.Fprintf(w, "_gobuster() {\n")
+	fmt.Fprintf(w, "    local cur=\"${COMP_WORDS[COMP_CWORD]}\" prev=\"${COMP_WORDS[COMP_CWORD-1]}\"\n")
+	fmt.Fprintf(w, "    case \"$prev\" in\n")
+	for _, f := range flags {
+		names = append(names, "-"+f.name)
+		if len(f.values) == 0 {
+			continue
+		}
+		fmt.Fprintf(w, "        -%s|--%s)\n", f.name, f.name)
+		fmt.Fprintf(w, "            COMPREPLY=($(compgen -W %s -- \"$cur\"))\n", shellQuote(strings.Join(f.values, " ")))
+		if f.files {
+			fmt.Fprintf(w, "            COMPREPLY+=($(compgen -f -- \"$cur\"))\n")
+		}
+		fmt.Fprintf(w, "            return;;\n")
+	}
+	fmt.Fprintf(w, "    esac\n")
+	fmt.Fprintf(w, "    if [[ \"$cur\" == -* ]]; then\n")
+	fmt.Fprintf(w, "        COMPREPLY=($(compgen -W %s -- \"$cur\"))\n", shellQuote(strings.Join(names, " ")))
+	fmt.Fprintf(w, "    fi\n")
+	fmt.Fprintf(w, "}\n")
+	fmt.Fprintf(w, "complete -o default -F _gobuster gobuster\n")
+}
+
+func writeZshCompletion(w io.Writer, flags []completionFlag) {
+	escape := strings.NewReplacer(`[`, `\[`, `]`, `\]`, `:`, `\:`, `'`, `'\''`)
+	fmt.Fprintf(w, "#compdef gobuster\n")
+	fmt.Fprintf(w, "_arguments \\\n")
+	for _, f := range flags {
+		spec := fmt.Sprintf("-%s[%s]", f.name, escape.Replace(f.help))
+		switch {
+		case f.isBool:
+		case len(f.values) > 0 && f.files:
+			spec += fmt.Sprintf(":%s:{compadd -- %s; _files}", f.name, escape.Replace(strings.Join(f.values, " ")))
+		case len(f.values) > 0:
+			spec += fmt.Sprintf(":%s:(%s)", f.name, escape.Replace(strings.Join(f.values, " ")))
+		case f.files:
+			spec += fmt.Sprintf(":%s:_files", f.name)
+		default:
+			spec += fmt.Sprintf(":%s:", f.name)
+		}
+		fmt.Fprintf(w, "  '%s' \\\n", spec)
+	}
+	fmt.Fprintf(w, "  '*:url:_urls'\n")
+}
+
+func writeFishCompletion(w io.Writer, flags []completionFlag) {
+	fmt.Fprintf(w, "# fish completion for gobuster\n")
+	for _, f := range flags {
+		line := fmt.Sprintf("complete -c gobuster -o %s -d %s", f.name, fishQuote(f.help))
+		switch {
+		case f.isBool:
+		case f.files:
+			line += " -r"
+		default:
+			line += " -x"
+		}
+		if len(f.values) > 0 {
+			line += " -a " + fishQuote(strings.Join(f.values, " "))
+		}
+		fmt.Fprintln(w, line)
+	}
+}
+
+// Quote s for bash or zsh.
+func shellQuote(s string) string {
+	return "'" + strings.Replace(s, "'", `'\''`, -1) + "'"
+}
+
+// Quote s for fish, which allows escapes within single quotes.
+func fishQuote(s string) string {
+	return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(s) + "'"
+}
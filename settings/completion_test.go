@@ -0,0 +1,73 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package settings
+
+import (
+	"bytes"
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+func TestWriteCompletion(t *testing.T) {
+	defer SetOutputFormats(outputFormats)
+	SetOutputFormats([]string{"text", "csv"})
+	s := testScanSettings()
+	if err := s.loadConfig(strings.NewReader("[profile internal]\nworkers = 2\n")); err != nil {
+		t.Fatal(err)
+	}
+	for _, shell := range CompletionShells {
+		buf := &bytes.Buffer{}
+		if err := s.WriteCompletion(buf, shell); err != nil {
+			t.Fatalf("Expected no error for %s, got %s", shell, err)
+		}
+		out := buf.String()
+		for _, want := range []string{"stealth", "internal", "wordpress", "text csv", "obey", "spider-codes"} {
+			if !strings.Contains(out, want) {
+				t.Errorf("Expected %q in %s completion:\n%s", want, shell, out)
+			}
+		}
+	}
+	if err := s.WriteCompletion(&bytes.Buffer{}, "csh"); err == nil {
+		t.Errorf("Expected error for unknown shell.")
+	}
+}
+
+func TestWriteCompletion_Syntax(t *testing.T) {
+	for _, shell := range []string{"bash", "zsh", "fish"} {
+		path, err := exec.LookPath(shell)
+		if err != nil {
+			continue
+		}
+		buf := &bytes.Buffer{}
+		if err := testScanSettings().WriteCompletion(buf, shell); err != nil {
+			t.Fatal(err)
+		}
+		cmd := exec.Command(path, "-n")
+		cmd.Stdin = buf
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Errorf("Expected valid %s syntax, got %s: %s", shell, err, out)
+		}
+	}
+}
+
+func TestProfileNames(t *testing.T) {
+	s := testScanSettings()
+	s.profiles = map[string]Profile{"stealth": {}, "custom": {}}
+	names := strings.Join(s.ProfileNames(), ",")
+	if names != "aggressive,api,custom,normal,stealth,wordpress" {
+		t.Errorf("Unexpected profile names: %s", names)
+	}
+}
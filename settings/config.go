@@ -0,0 +1,324 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package settings
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// Profile is a named set of flag values, applied after any config file but
+// before command line flags.
+type Profile map[string]string
+
+var builtinProfiles = map[string]Profile{
+	"stealth": {
+		"workers":    "1",
+		"sleep":      "2s",
+		"wordlist":   "short",
+		"extensions": "html,php",
+		"mangle":     "false",
+	},
+	"normal": {},
+	"aggressive": {
+		"workers":    "64",
+		"sleep":      "0s",
+		"extensions": "html,php,asp,aspx,jsp,txt,bak,old,zip",
+		"mangle":     "true",
+	},
+	"api": {
+		"extensions":   "json,xml",
+		"html":         "false",
+		"mangle":       "false",
+		"spider-types": "application/json,application/xml",
+	},
+	"wordpress": {
+		"wordlist":   "wordpress",
+		"extensions": "php,bak,old,txt",
+		"exclude":    "/wp-content/uploads/",
+	},
+}
+
+// Names of the built-in profiles, sorted.
+func BuiltinProfileNames() []string {
+	names := make([]string, 0, len(builtinProfiles))
+	for name := range builtinProfiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Names of all available profiles, built-in and from the config file, sorted.
+func (settings *ScanSettings) ProfileNames() []string {
+	names := BuiltinProfileNames()
+	for name := range settings.profiles {
+		if _, ok := builtinProfiles[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Apply the named profile, preferring user-defined profiles from the config
+// file over built-in ones.
+func (settings *ScanSettings) ApplyProfile(name string) error {
+	profile, ok := settings.profiles[name]
+	if !ok {
+		if profile, ok = builtinProfiles[name]; !ok {
+			return fmt.Errorf("Unknown profile: %s", name)
+		}
+	}
+	fs := settings.flagSet()
+	for key, value := range profile {
+		if err := fs.Set(key, value); err != nil {
+			return fmt.Errorf("Profile %s: unable to set %s: %s", name, key, err.Error())
+		}
+	}
+	settings.Profile = name
+	return nil
+}
+
+// A setting read from a config file, in any format.
+type configEntry struct {
+	line int
+	// Empty for settings, "profile", "phase" or "pipeline"
+	section string
+	// Name of the profile or phase, if in one
+	name  string
+	key   string
+	value string
+	// Items of a list value, which is also given joined by commas
+	list []string
+}
+
+// Config files are a series of "name = value" lines, where name is the name
+// of any command line flag.  A "[profile NAME]" line starts the definition of
+// a profile, and subsequent values belong to that profile.  A "[phase NAME]"
+// line likewise starts a phase of the scan, with phases run in the order
+// they are declared.  A "[pipeline]" line starts the definition of the
+// results pipeline, as "kind = type option=value ..." lines.
+func (settings *ScanSettings) loadConfig(rdr io.Reader) error {
+	entries, err := parseConfig(rdr)
+	if err != nil {
+		return err
+	}
+	return settings.applyConfig(entries)
+}
+
+func parseConfig(rdr io.Reader) ([]configEntry, error) {
+	var entries []configEntry
+	section, name := "", ""
+	scanner := bufio.NewScanner(rdr)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || line[0] == '#' || line[0] == ';' {
+			continue
+		}
+		if line[0] == '[' && line[len(line)-1] == ']' {
+			fields := strings.Fields(line[1 : len(line)-1])
+			if len(fields) == 1 && fields[0] == "pipeline" {
+				section, name = "pipeline", ""
+				continue
+			}
+			if len(fields) != 2 || fields[0] != "profile" && fields[0] != "phase" {
+				return nil, fmt.Errorf("Line %d: unknown section %s", lineNo, line)
+			}
+			section, name = fields[0], fields[1]
+			entries = append(entries, configEntry{line: lineNo, section: section, name: name})
+			continue
+		}
+		pieces := strings.SplitN(line, "=", 2)
+		if len(pieces) != 2 {
+			return nil, fmt.Errorf("Line %d: expected name = value", lineNo)
+		}
+		entries = append(entries, configEntry{
+			line:    lineNo,
+			section: section,
+			name:    name,
+			key:     strings.TrimSpace(pieces[0]),
+			value:   strings.TrimSpace(pieces[1]),
+		})
+	}
+	return entries, scanner.Err()
+}
+
+// Apply parsed config entries.  An entry with no key only declares a profile
+// or phase.
+func (settings *ScanSettings) applyConfig(entries []configEntry) error {
+	fs := settings.flagSet()
+	for _, e := range entries {
+		var profile Profile
+		if e.section == "profile" {
+			if settings.profiles == nil {
+				settings.profiles = make(map[string]Profile)
+			}
+			if profile = settings.profiles[e.name]; profile == nil {
+				profile = make(Profile)
+				settings.profiles[e.name] = profile
+			}
+			if e.key == "" {
+				continue
+			}
+		}
+		if e.section == "phase" {
+			phase := settings.declarePhase(e.name)
+			if e.key == "" {
+				continue
+			}
+			if fs.Lookup(e.key) == nil {
+				return fmt.Errorf("Line %d: unknown setting %s", e.line, e.key)
+			}
+			if phaseFlags[e.key] == nil {
+				return fmt.Errorf("Line %d: %s can't change between phases, only %s can", e.line, e.key, strings.Join(PhaseFlagNames(), ", "))
+			}
+			phase.Values[e.key] = e.value
+			continue
+		}
+		if e.section == "pipeline" {
+			stage, err := parsePipelineStage(e.key, e.value)
+			if err != nil {
+				return fmt.Errorf("Line %d: %s", e.line, err.Error())
+			}
+			settings.Pipeline = append(settings.Pipeline, stage)
+			continue
+		}
+		if fs.Lookup(e.key) == nil || e.key == "config" || e.key == "profile" && profile != nil {
+			return fmt.Errorf("Line %d: unknown setting %s", e.line, e.key)
+		}
+		repeated := len(e.list) > 1 && appendsValues(fs.Lookup(e.key))
+		if profile != nil {
+			if repeated {
+				return fmt.Errorf("Line %d: %s can only be given once in a profile", e.line, e.key)
+			}
+			profile[e.key] = e.value
+			continue
+		}
+		if !repeated {
+			if err := fs.Set(e.key, e.value); err != nil {
+				return fmt.Errorf("Line %d: %s", e.line, err.Error())
+			}
+			continue
+		}
+		// Values of repeated flags may contain commas, so each is set alone
+		for _, item := range e.list {
+			if err := fs.Set(e.key, item); err != nil {
+				return fmt.Errorf("Line %d: %s", e.line, err.Error())
+			}
+		}
+	}
+	return nil
+}
+
+// Whether a flag adds to its values each time it is set, rather than
+// replacing them.
+func appendsValues(f *flag.Flag) bool {
+	switch f.Value.(type) {
+	case RepeatedStringFlag, RepeatedStringSliceFlag:
+		return true
+	}
+	return false
+}
+
+// The phase with the given name, added after the others if it is new.
+func (settings *ScanSettings) declarePhase(name string) *Phase {
+	for i := range settings.Phases {
+		if settings.Phases[i].Name == name {
+			return &settings.Phases[i]
+		}
+	}
+	settings.Phases = append(settings.Phases, Phase{Name: name, Values: make(Profile)})
+	return &settings.Phases[len(settings.Phases)-1]
+}
+
+// Find the last value given for a flag in args, without parsing them.
+func findFlagValue(args []string, name string) string {
+	var value string
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" {
+			break
+		}
+		trimmed := strings.TrimLeft(arg, "-")
+		if trimmed == arg {
+			continue
+		}
+		if trimmed == name && i+1 < len(args) {
+			value = args[i+1]
+			i++
+		} else if strings.HasPrefix(trimmed, name+"=") {
+			value = trimmed[len(name)+1:]
+		}
+	}
+	return value
+}
+
+// Kinds of results pipeline stage, in the order they run.
+var PipelineKinds = []string{"filter", "annotate", "write"}
+
+// PipelineStage is one step of the results pipeline from the config file.
+type PipelineStage struct {
+	// One of PipelineKinds
+	Kind string
+	// Which filter, annotator or output format
+	Type string
+	// Stage specific options
+	Options map[string]string
+}
+
+func (s PipelineStage) String() string {
+	keys := make([]string, 0, len(s.Options))
+	for k := range s.Options {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	parts := []string{s.Type}
+	for _, k := range keys {
+		parts = append(parts, k+"="+s.Options[k])
+	}
+	return s.Kind + " = " + strings.Join(parts, " ")
+}
+
+// Parse a "kind = type option=value ..." pipeline line.
+func parsePipelineStage(kind, value string) (PipelineStage, error) {
+	stage := PipelineStage{Kind: kind, Options: make(map[string]string)}
+	known := false
+	for _, k := range PipelineKinds {
+		known = known || k == kind
+	}
+	if !known {
+		return stage, fmt.Errorf("unknown pipeline stage %s, expected one of %s", kind, strings.Join(PipelineKinds, ", "))
+	}
+	fields := strings.Fields(value)
+	if len(fields) == 0 {
+		return stage, fmt.Errorf("missing type for %s stage", kind)
+	}
+	stage.Type = fields[0]
+	for _, opt := range fields[1:] {
+		pieces := strings.SplitN(opt, "=", 2)
+		if len(pieces) != 2 || pieces[0] == "" {
+			return stage, fmt.Errorf("expected option=value, got %s", opt)
+		}
+		stage.Options[pieces[0]] = pieces[1]
+	}
+	return stage, nil
+}
@@ -0,0 +1,455 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package settings
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// YAML and TOML config files hold the same settings as the plain format:
+// flag names as keys, with lists joined by commas.  Lists for flags that can
+// be repeated, such as header, give each item as if the flag were repeated.  Profiles live under
+// "profiles", phases under "phases" in the order they run, and the results
+// pipeline is a list of single "kind: type options" entries, e.g. in YAML:
+//
+//	workers: 4
+//	extensions: [php, html]
+//	profiles:
+//	  quiet:
+//	    workers: 1
+//	phases:
+//	  quick:
+//	    max-words: 1000
+//	pipeline:
+//	  - filter: status codes=200
+//
+// and in TOML:
+//
+//	workers = 4
+//	extensions = ["php", "html"]
+//	[profiles.quiet]
+//	workers = 1
+//	[phases.quick]
+//	max-words = 1000
+//	[[pipeline]]
+//	filter = "status codes=200"
+//
+// Only this subset of each language is understood.
+
+// Parse a config file in the format suggested by its name.
+func parseConfigFormat(path string, rdr io.Reader) ([]configEntry, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		return parseYAMLConfig(rdr)
+	case ".toml":
+		return parseTOMLConfig(rdr)
+	}
+	return parseConfig(rdr)
+}
+
+// A line of YAML with its indentation.
+type yamlLine struct {
+	num    int
+	indent int
+	text   string
+}
+
+// A parsed YAML value: exactly one of a scalar, list or mapping.
+type yamlNode struct {
+	line   int
+	scalar *string
+	list   []*yamlNode
+	keys   []string
+	fields map[string]*yamlNode
+}
+
+func parseYAMLConfig(rdr io.Reader) ([]configEntry, error) {
+	var lines []*yamlLine
+	scanner := bufio.NewScanner(rdr)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		raw := scanner.Text()
+		text := strings.TrimSpace(stripComment(raw))
+		if text == "" || text == "---" {
+			continue
+		}
+		trimmed := strings.TrimLeft(raw, " ")
+		if strings.HasPrefix(trimmed, "\t") {
+			return nil, fmt.Errorf("Line %d: tabs can't be used for indentation", lineNo)
+		}
+		lines = append(lines, &yamlLine{num: lineNo, indent: len(raw) - len(trimmed), text: text})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(lines) == 0 {
+		return nil, nil
+	}
+	p := &yamlParser{lines: lines}
+	root, err := p.parse(lines[0].indent)
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(lines) {
+		return nil, fmt.Errorf("Line %d: unexpected indentation", lines[p.pos].num)
+	}
+	if root.fields == nil {
+		return nil, fmt.Errorf("Line %d: expected name: value", root.line)
+	}
+	return yamlEntries(root)
+}
+
+type yamlParser struct {
+	lines []*yamlLine
+	pos   int
+}
+
+// Parse the block starting at the current line, which has the given indent.
+func (p *yamlParser) parse(indent int) (*yamlNode, error) {
+	first := p.lines[p.pos]
+	node := &yamlNode{line: first.num}
+	if isListItem(first.text) {
+		for p.pos < len(p.lines) && p.lines[p.pos].indent == indent && isListItem(p.lines[p.pos].text) {
+			line := p.lines[p.pos]
+			rest := strings.TrimSpace(line.text[1:])
+			var item *yamlNode
+			var err error
+			if rest == "" {
+				p.pos++
+				if p.pos >= len(p.lines) || p.lines[p.pos].indent <= indent {
+					return nil, fmt.Errorf("Line %d: empty list item", line.num)
+				}
+				item, err = p.parse(p.lines[p.pos].indent)
+			} else {
+				// Treat the rest of the line as the start of a nested block
+				line.indent = indent + len(line.text) - len(rest)
+				line.text = rest
+				item, err = p.parse(line.indent)
+			}
+			if err != nil {
+				return nil, err
+			}
+			node.list = append(node.list, item)
+		}
+		return node, nil
+	}
+	key, value, ok := splitYAMLKey(first.text)
+	if !ok {
+		scalar, err := yamlScalar(first.text)
+		if err != nil {
+			return nil, fmt.Errorf("Line %d: %s", first.num, err.Error())
+		}
+		p.pos++
+		node.scalar = &scalar
+		return node, nil
+	}
+	node.fields = make(map[string]*yamlNode)
+	for p.pos < len(p.lines) && p.lines[p.pos].indent == indent {
+		line := p.lines[p.pos]
+		if key, value, ok = splitYAMLKey(line.text); !ok {
+			return nil, fmt.Errorf("Line %d: expected name: value", line.num)
+		}
+		if _, dup := node.fields[key]; dup {
+			return nil, fmt.Errorf("Line %d: duplicate key %s", line.num, key)
+		}
+		p.pos++
+		var child *yamlNode
+		var err error
+		if value != "" {
+			child, err = yamlValue(line.num, value)
+		} else if p.pos < len(p.lines) && (p.lines[p.pos].indent > indent || p.lines[p.pos].indent == indent && isListItem(p.lines[p.pos].text)) {
+			child, err = p.parse(p.lines[p.pos].indent)
+		} else {
+			empty := ""
+			child = &yamlNode{line: line.num, scalar: &empty}
+		}
+		if err != nil {
+			return nil, err
+		}
+		node.keys = append(node.keys, key)
+		node.fields[key] = child
+	}
+	return node, nil
+}
+
+func isListItem(text string) bool {
+	return text == "-" || strings.HasPrefix(text, "- ")
+}
+
+// Split "key: value", unless the text is a quoted scalar.
+func splitYAMLKey(text string) (string, string, bool) {
+	if text[0] == '"' || text[0] == '\'' || text[0] == '[' {
+		return "", "", false
+	}
+	if strings.HasSuffix(text, ":") {
+		return strings.TrimSpace(text[:len(text)-1]), "", true
+	}
+	if i := strings.Index(text, ": "); i > 0 {
+		return strings.TrimSpace(text[:i]), strings.TrimSpace(text[i+2:]), true
+	}
+	return "", "", false
+}
+
+// An inline value: a scalar or a flow list such as [a, b].
+func yamlValue(line int, text string) (*yamlNode, error) {
+	node := &yamlNode{line: line}
+	if text[0] == '[' {
+		if text[len(text)-1] != ']' {
+			return nil, fmt.Errorf("Line %d: unterminated list", line)
+		}
+		for _, item := range splitOutsideQuotes(text[1:len(text)-1], ',') {
+			if item = strings.TrimSpace(item); item == "" {
+				continue
+			}
+			scalar, err := yamlScalar(item)
+			if err != nil {
+				return nil, fmt.Errorf("Line %d: %s", line, err.Error())
+			}
+			node.list = append(node.list, &yamlNode{line: line, scalar: &scalar})
+		}
+		if node.list == nil {
+			node.list = []*yamlNode{}
+		}
+		return node, nil
+	}
+	scalar, err := yamlScalar(text)
+	if err != nil {
+		return nil, fmt.Errorf("Line %d: %s", line, err.Error())
+	}
+	node.scalar = &scalar
+	return node, nil
+}
+
+func yamlScalar(text string) (string, error) {
+	if len(text) >= 2 && text[0] == '\'' && text[len(text)-1] == '\'' {
+		return strings.Replace(text[1:len(text)-1], "''", "'", -1), nil
+	}
+	if text[0] == '"' {
+		return strconv.Unquote(text)
+	}
+	if text[0] == '{' || text[0] == '&' || text[0] == '*' || text[0] == '|' || text[0] == '>' {
+		return "", fmt.Errorf("unsupported YAML value %s", text)
+	}
+	return text, nil
+}
+
+// Flatten a scalar or list of scalars to a flag value, and the items of a
+// list.
+func (n *yamlNode) flagValue() (string, []string, error) {
+	if n.scalar != nil {
+		return *n.scalar, nil, nil
+	}
+	if n.list == nil {
+		return "", nil, fmt.Errorf("Line %d: expected a value or list", n.line)
+	}
+	values := make([]string, 0, len(n.list))
+	for _, item := range n.list {
+		if item.scalar == nil {
+			return "", nil, fmt.Errorf("Line %d: expected a value", item.line)
+		}
+		values = append(values, *item.scalar)
+	}
+	return strings.Join(values, ","), values, nil
+}
+
+func yamlEntries(root *yamlNode) ([]configEntry, error) {
+	var entries []configEntry
+	for _, key := range root.keys {
+		node := root.fields[key]
+		switch key {
+		case "profiles", "phases":
+			section := strings.TrimSuffix(key, "s")
+			if node.fields == nil {
+				return nil, fmt.Errorf("Line %d: expected %s by name", node.line, key)
+			}
+			for _, name := range node.keys {
+				named := node.fields[name]
+				entries = append(entries, configEntry{line: named.line, section: section, name: name})
+				if named.scalar != nil && *named.scalar == "" {
+					continue
+				}
+				if named.fields == nil {
+					return nil, fmt.Errorf("Line %d: expected settings for %s %s", named.line, section, name)
+				}
+				for _, k := range named.keys {
+					value, list, err := named.fields[k].flagValue()
+					if err != nil {
+						return nil, err
+					}
+					entries = append(entries, configEntry{line: named.fields[k].line, section: section, name: name, key: k, value: value, list: list})
+				}
+			}
+		case "pipeline":
+			if node.list == nil {
+				return nil, fmt.Errorf("Line %d: expected a list of stages", node.line)
+			}
+			for _, stage := range node.list {
+				if len(stage.keys) != 1 {
+					return nil, fmt.Errorf("Line %d: expected kind: type options", stage.line)
+				}
+				value, _, err := stage.fields[stage.keys[0]].flagValue()
+				if err != nil {
+					return nil, err
+				}
+				entries = append(entries, configEntry{line: stage.line, section: "pipeline", key: stage.keys[0], value: value})
+			}
+		default:
+			value, list, err := node.flagValue()
+			if err != nil {
+				return nil, err
+			}
+			entries = append(entries, configEntry{line: node.line, key: key, value: value, list: list})
+		}
+	}
+	return entries, nil
+}
+
+func parseTOMLConfig(rdr io.Reader) ([]configEntry, error) {
+	var entries []configEntry
+	section, name := "", ""
+	scanner := bufio.NewScanner(rdr)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(stripComment(scanner.Text()))
+		if line == "" {
+			continue
+		}
+		if strings.HasPrefix(line, "[[") && strings.HasSuffix(line, "]]") {
+			if name := strings.TrimSpace(line[2 : len(line)-2]); name != "pipeline" {
+				return nil, fmt.Errorf("Line %d: unknown table %s", lineNo, line)
+			}
+			section, name = "pipeline", ""
+			continue
+		}
+		if line[0] == '[' && line[len(line)-1] == ']' {
+			table := strings.TrimSpace(line[1 : len(line)-1])
+			pieces := strings.SplitN(table, ".", 2)
+			if len(pieces) != 2 || pieces[1] == "" || pieces[0] != "profiles" && pieces[0] != "phases" {
+				return nil, fmt.Errorf("Line %d: unknown table %s", lineNo, line)
+			}
+			section, name = strings.TrimSuffix(pieces[0], "s"), strings.Trim(pieces[1], "\"")
+			entries = append(entries, configEntry{line: lineNo, section: section, name: name})
+			continue
+		}
+		pieces := strings.SplitN(line, "=", 2)
+		if len(pieces) != 2 {
+			return nil, fmt.Errorf("Line %d: expected name = value", lineNo)
+		}
+		key := strings.Trim(strings.TrimSpace(pieces[0]), "\"")
+		raw := strings.TrimSpace(pieces[1])
+		// Arrays may continue over several lines
+		start := lineNo
+		for strings.HasPrefix(raw, "[") && !strings.HasSuffix(raw, "]") && scanner.Scan() {
+			lineNo++
+			raw += " " + strings.TrimSpace(stripComment(scanner.Text()))
+		}
+		value, list, err := tomlValue(raw)
+		if err != nil {
+			return nil, fmt.Errorf("Line %d: %s", start, err.Error())
+		}
+		entries = append(entries, configEntry{line: start, section: section, name: name, key: key, value: value, list: list})
+	}
+	return entries, scanner.Err()
+}
+
+// Convert a TOML value to a flag value, joining arrays with commas, and the
+// items of an array.
+func tomlValue(raw string) (string, []string, error) {
+	if raw == "" {
+		return "", nil, fmt.Errorf("missing value")
+	}
+	if raw[0] == '[' {
+		if raw[len(raw)-1] != ']' {
+			return "", nil, fmt.Errorf("unterminated array")
+		}
+		values := []string{}
+		for _, item := range splitOutsideQuotes(raw[1:len(raw)-1], ',') {
+			if item = strings.TrimSpace(item); item == "" {
+				continue
+			}
+			value, _, err := tomlValue(item)
+			if err != nil {
+				return "", nil, err
+			}
+			values = append(values, value)
+		}
+		return strings.Join(values, ","), values, nil
+	}
+	switch raw[0] {
+	case '"':
+		value, err := strconv.Unquote(raw)
+		return value, nil, err
+	case '\'':
+		if len(raw) < 2 || raw[len(raw)-1] != '\'' {
+			return "", nil, fmt.Errorf("unterminated string")
+		}
+		return raw[1 : len(raw)-1], nil, nil
+	case '{':
+		return "", nil, fmt.Errorf("inline tables are not supported")
+	}
+	return raw, nil, nil
+}
+
+// Remove a trailing # comment that isn't inside quotes.
+func stripComment(line string) string {
+	var quote byte
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case quote != 0:
+			if c == '\\' && quote == '"' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '#' && (i == 0 || line[i-1] == ' ' || line[i-1] == '\t'):
+			return line[:i]
+		}
+	}
+	return line
+}
+
+// Split s on sep, except within quotes.
+func splitOutsideQuotes(s string, sep byte) []string {
+	var parts []string
+	var quote byte
+	start := 0
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote != 0:
+			if c == '\\' && quote == '"' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == sep:
+			parts = append(parts, s[start:i])
+			start = i + 1
+		}
+	}
+	return append(parts, s[start:])
+}
@@ -0,0 +1,216 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package settings
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+const yamlConfig = `# Shared scan settings
+---
+workers: 3
+sleep: "1s"  # between requests
+extensions: [php, 'html']
+exclude:
+  - /static/
+  - "/tmp/"
+header:
+  - "Accept: text/html, application/json"
+  - "X-Test: 1"
+profiles:
+  quiet:
+    workers: 1
+  empty:
+phases:
+  quick:
+    max-words: 1000
+    extensions: []
+  full:
+    wordlist: default
+pipeline:
+  - filter: status codes=200
+  - write: json
+`
+
+const tomlConfig = `# Shared scan settings
+workers = 3
+sleep = "1s"  # between requests
+extensions = ["php", 'html']
+exclude = [
+  "/static/",
+  "/tmp/",
+]
+header = ["Accept: text/html, application/json", "X-Test: 1"]
+
+[profiles.quiet]
+workers = 1
+
+[profiles.empty]
+
+[phases.quick]
+max-words = 1000
+extensions = []
+
+[phases.full]
+wordlist = "default"
+
+[[pipeline]]
+filter = "status codes=200"
+
+[[pipeline]]
+write = "json"
+`
+
+func checkLoadedConfig(t *testing.T, ss *ScanSettings) {
+	if !reflect.DeepEqual(ss.Headers, []string{"Accept: text/html, application/json", "X-Test: 1"}) {
+		t.Errorf("Expected each header whole, got %v", ss.Headers)
+	}
+	if ss.Workers != 3 || ss.SleepTime != time.Second {
+		t.Errorf("Expected workers=3 sleep=1s, got %d %v", ss.Workers, ss.SleepTime)
+	}
+	if !reflect.DeepEqual(ss.Extensions, []string{"php", "html"}) {
+		t.Errorf("Expected extensions php,html, got %v", ss.Extensions)
+	}
+	if !reflect.DeepEqual(ss.ExcludePaths, []string{"/static/", "/tmp/"}) {
+		t.Errorf("Expected exclusions /static/,/tmp/, got %v", ss.ExcludePaths)
+	}
+	if p := ss.profiles["quiet"]; p["workers"] != "1" {
+		t.Errorf("Expected quiet profile, got %v", ss.profiles)
+	}
+	if _, ok := ss.profiles["empty"]; !ok {
+		t.Errorf("Expected empty profile to be declared, got %v", ss.profiles)
+	}
+	expected := []Phase{
+		{Name: "quick", Values: Profile{"max-words": "1000", "extensions": ""}},
+		{Name: "full", Values: Profile{"wordlist": "default"}},
+	}
+	if !reflect.DeepEqual(ss.Phases, expected) {
+		t.Errorf("Expected phases %v, got %v", expected, ss.Phases)
+	}
+	if len(ss.Pipeline) != 2 || ss.Pipeline[0].Kind != "filter" || ss.Pipeline[0].Type != "status" || ss.Pipeline[1].Kind != "write" {
+		t.Errorf("Expected filter and write stages, got %v", ss.Pipeline)
+	}
+}
+
+func TestParseConfigFormat_YAML(t *testing.T) {
+	ss := testScanSettings()
+	entries, err := parseConfigFormat("scan.yaml", strings.NewReader(yamlConfig))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := ss.applyConfig(entries); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	checkLoadedConfig(t, ss)
+}
+
+func TestParseConfigFormat_TOML(t *testing.T) {
+	ss := testScanSettings()
+	entries, err := parseConfigFormat("scan.toml", strings.NewReader(tomlConfig))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := ss.applyConfig(entries); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	checkLoadedConfig(t, ss)
+}
+
+func TestParseConfigFormat_Errors(t *testing.T) {
+	for _, c := range []struct {
+		path, config string
+	}{
+		{"a.yaml", "workers"},
+		{"a.yaml", "workers: 1\nworkers: 2"},
+		{"a.yaml", "workers: 1\n  sleep: 1s"},
+		{"a.yaml", "\tworkers: 1"},
+		{"a.yaml", "pipeline:\n  - filter: status\n    write: json"},
+		{"a.yaml", "profiles: quiet"},
+		{"a.yaml", "extensions: [php"},
+		{"a.yaml", "headers: {a: b}"},
+		{"a.toml", "workers"},
+		{"a.toml", "[profile]"},
+		{"a.toml", "[[stages]]"},
+		{"a.toml", "[phases.]"},
+		{"a.toml", "sleep = \"1s"},
+		{"a.toml", "headers = {a = \"b\"}"},
+	} {
+		if _, err := parseConfigFormat(c.path, strings.NewReader(c.config)); err == nil {
+			t.Errorf("Expected error for %s config %q", c.path, c.config)
+		}
+	}
+}
+
+func TestApplyConfig_RepeatedInProfile(t *testing.T) {
+	entries, err := parseConfigFormat("a.yaml", strings.NewReader("profiles:\n  auth:\n    header: [\"A: 1\", \"B: 2\"]\n"))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	ss := testScanSettings()
+	if err := ss.applyConfig(entries); err == nil {
+		t.Error("Expected error for several headers in a profile.")
+	}
+}
+
+func TestParseArgs_Config(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gobuster-config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "scan.yml")
+	if err := ioutil.WriteFile(path, []byte(yamlConfig), 0644); err != nil {
+		t.Fatal(err)
+	}
+	ss := testScanSettings()
+	if err := ss.parseArgs([]string{"-config", path, "-profile", "quiet", "-sleep", "5s", "http://localhost/"}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if ss.Workers != 1 {
+		t.Errorf("Expected profile from config to set workers=1, got %d", ss.Workers)
+	}
+	if ss.SleepTime != 5*time.Second {
+		t.Errorf("Expected command line to override config sleep, got %v", ss.SleepTime)
+	}
+	if !reflect.DeepEqual(ss.Extensions, []string{"php", "html"}) {
+		t.Errorf("Expected extensions from config, got %v", ss.Extensions)
+	}
+
+	ss = testScanSettings()
+	if err := ss.parseArgs([]string{"-config", filepath.Join(dir, "missing.toml")}); err == nil {
+		t.Errorf("Expected error for missing config file.")
+	}
+}
+
+func TestStripComment(t *testing.T) {
+	for in, out := range map[string]string{
+		"a = 1 # comment":   "a = 1 ",
+		"# comment":         "",
+		"a = \"#1\" # c":    "a = \"#1\" ",
+		"a = 'x # y'":       "a = 'x # y'",
+		"a = \"\\\" # \" #": "a = \"\\\" # \" ",
+		"url: http://x/#a":  "url: http://x/#a",
+	} {
+		if got := stripComment(in); got != out {
+			t.Errorf("Expected %q, got %q", out, got)
+		}
+	}
+}
@@ -0,0 +1,215 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package settings
+
+import (
+	"flag"
+	"io/ioutil"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testScanSettings() *ScanSettings {
+	return newScanSettingsWithFlags(flag.NewFlagSet("test", flag.ContinueOnError))
+}
+
+func TestBuiltinProfiles_Valid(t *testing.T) {
+	for _, name := range BuiltinProfileNames() {
+		ss := testScanSettings()
+		if err := ss.ApplyProfile(name); err != nil {
+			t.Errorf("Error applying builtin profile %s: %v", name, err)
+		}
+	}
+}
+
+func TestApplyProfile_Unknown(t *testing.T) {
+	ss := testScanSettings()
+	if err := ss.ApplyProfile("nope"); err == nil {
+		t.Error("Expected error applying unknown profile.")
+	}
+}
+
+func TestLoadConfig(t *testing.T) {
+	config := `# Comment
+workers = 3
+sleep = 1s
+
+[profile mine]
+workers = 7
+`
+	ss := testScanSettings()
+	if err := ss.loadConfig(strings.NewReader(config)); err != nil {
+		t.Fatalf("Unexpected error loading config: %v", err)
+	}
+	if ss.Workers != 3 || ss.SleepTime != time.Second {
+		t.Errorf("Expected workers=3 sleep=1s, got %d %v", ss.Workers, ss.SleepTime)
+	}
+	if err := ss.parseArgs([]string{"-profile", "mine", "http://localhost/"}); err != nil {
+		t.Fatalf("Unexpected error parsing args: %v", err)
+	}
+	if ss.Workers != 7 {
+		t.Errorf("Expected profile to set workers=7, got %d", ss.Workers)
+	}
+	if ss.Profile != "mine" {
+		t.Errorf("Expected profile mine, got %s", ss.Profile)
+	}
+}
+
+func TestLoadConfig_Errors(t *testing.T) {
+	for _, config := range []string{
+		"nosuchflag = 1",
+		"workers",
+		"workers = many",
+		"[section]",
+		"[phase quick]\nnosuchflag = 1",
+		"[phase quick]\nworkers = 2",
+	} {
+		ss := testScanSettings()
+		if err := ss.loadConfig(strings.NewReader(config)); err == nil {
+			t.Errorf("Expected error for config %q", config)
+		}
+	}
+}
+
+func TestLoadConfig_Phases(t *testing.T) {
+	config := `extensions = php
+[phase quick]
+max-words = 1000
+extensions =
+[phase full]
+frequent-first = false
+[phase quick]
+mangle = false
+`
+	ss := testScanSettings()
+	if err := ss.loadConfig(strings.NewReader(config)); err != nil {
+		t.Fatalf("Unexpected error loading config: %v", err)
+	}
+	if len(ss.Phases) != 2 || ss.Phases[0].Name != "quick" || ss.Phases[1].Name != "full" {
+		t.Fatalf("Expected phases quick and full, got %v", ss.Phases)
+	}
+	if got := ss.Phases[0].String(); got != "quick (extensions=, mangle=false, max-words=1000)" {
+		t.Errorf("Expected both sections of quick merged, got %s", got)
+	}
+	if len(ss.Extensions) != 1 || ss.Extensions[0] != "php" {
+		t.Errorf("Expected phases to leave the settings alone, got extensions %v", ss.Extensions)
+	}
+}
+
+func TestParseArgs_Invalid(t *testing.T) {
+	ss := testScanSettings()
+	ss.flags.SetOutput(ioutil.Discard)
+	if err := ss.parseArgs([]string{"-profile", "stealth", "-workers", "many"}); err == nil {
+		t.Error("Expected an error for -workers many.")
+	}
+}
+
+func TestParseArgs_ProfileOverride(t *testing.T) {
+	ss := testScanSettings()
+	if err := ss.parseArgs([]string{"-workers=2", "--profile=stealth", "-sleep", "5s"}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if ss.Workers != 2 {
+		t.Errorf("Expected command line to override profile workers, got %d", ss.Workers)
+	}
+	if ss.SleepTime != 5*time.Second {
+		t.Errorf("Expected command line sleep of 5s, got %v", ss.SleepTime)
+	}
+	if ss.WordlistPath != "short" {
+		t.Errorf("Expected profile wordlist, got %s", ss.WordlistPath)
+	}
+}
+
+func TestFindFlagValue(t *testing.T) {
+	cases := []struct {
+		args     []string
+		expected string
+	}{
+		{[]string{"-profile", "a"}, "a"},
+		{[]string{"--profile=b"}, "b"},
+		{[]string{"-profile"}, ""},
+		{[]string{"--", "-profile", "c"}, ""},
+		{[]string{"profile", "d"}, ""},
+	}
+	for _, c := range cases {
+		if v := findFlagValue(c.args, "profile"); v != c.expected {
+			t.Errorf("Expected %q for %v, got %q", c.expected, c.args, v)
+		}
+	}
+}
+
+func TestFingerprint(t *testing.T) {
+	a := testScanSettings()
+	b := testScanSettings()
+	b.parseArgs([]string{"-outfile", "foo", "-loglevel", "debug"})
+	if a.Fingerprint() != b.Fingerprint() {
+		t.Error("Expected output settings not to change fingerprint.")
+	}
+	for _, format := range []string{"json", "csv"} {
+		b.parseArgs([]string{"-format", format, "-output-extra", format + ":out." + format})
+		if a.Fingerprint() != b.Fingerprint() {
+			t.Errorf("Expected -format %s not to change fingerprint.", format)
+		}
+	}
+	b.parseArgs([]string{"-workers", "99"})
+	if a.Fingerprint() == b.Fingerprint() {
+		t.Error("Expected workers to change fingerprint.")
+	}
+	c := testScanSettings()
+	c.Phases = []Phase{{Name: "quick", Values: Profile{"max-words": "100"}}}
+	if a.Fingerprint() == c.Fingerprint() {
+		t.Error("Expected phases to change fingerprint.")
+	}
+}
+
+func TestLoadConfig_Pipeline(t *testing.T) {
+	config := `workers = 3
+[pipeline]
+filter = status deny=404,500
+annotate = tag name=admin path=/admin
+write = json path=out.json
+`
+	ss := testScanSettings()
+	if err := ss.loadConfig(strings.NewReader(config)); err != nil {
+		t.Fatalf("Unexpected error loading config: %v", err)
+	}
+	if len(ss.Pipeline) != 3 {
+		t.Fatalf("Expected 3 stages, got %v", ss.Pipeline)
+	}
+	expected := []string{
+		"filter = status deny=404,500",
+		"annotate = tag name=admin path=/admin",
+		"write = json path=out.json",
+	}
+	for i, e := range expected {
+		if ss.Pipeline[i].String() != e {
+			t.Errorf("Expected %s, got %s", e, ss.Pipeline[i])
+		}
+	}
+}
+
+func TestLoadConfig_PipelineErrors(t *testing.T) {
+	for _, config := range []string{
+		"[pipeline]\ntransform = upper",
+		"[pipeline]\nfilter =",
+		"[pipeline]\nfilter = status deny",
+	} {
+		ss := testScanSettings()
+		if err := ss.loadConfig(strings.NewReader(config)); err == nil {
+			t.Errorf("Expected error for config %q", config)
+		}
+	}
+}
@@ -0,0 +1,89 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package settings
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Phase is one pass of a scan in phases, declared in the config file.  Each
+// phase runs with the scan's settings plus its own values, e.g. the most
+// common words first, then the full wordlist, then backup extensions.  Later
+// phases start from the targets and every directory earlier phases
+// brute-forced, and skip every URL they tried.
+type Phase struct {
+	Name   string
+	Values Profile
+}
+
+func (p Phase) String() string {
+	keys := make([]string, 0, len(p.Values))
+	for k := range p.Values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	parts := make([]string, len(keys))
+	for i, k := range keys {
+		parts[i] = k + "=" + p.Values[k]
+	}
+	return p.Name + " (" + strings.Join(parts, ", ") + ")"
+}
+
+// Flags a phase may set, each with how to put its value back from saved.
+// The rest stay the same for the whole scan.
+var phaseFlags = map[string]func(settings, saved *ScanSettings){
+	"wordlist":       func(settings, saved *ScanSettings) { settings.WordlistPath = saved.WordlistPath },
+	"frequent-first": func(settings, saved *ScanSettings) { settings.FrequentFirst = saved.FrequentFirst },
+	"max-words":      func(settings, saved *ScanSettings) { settings.MaxWords = saved.MaxWords },
+	"extensions":     func(settings, saved *ScanSettings) { settings.Extensions = saved.Extensions },
+	"mangle":         func(settings, saved *ScanSettings) { settings.Mangle = saved.Mangle },
+}
+
+// Names of the flags a phase may set, sorted.
+func PhaseFlagNames() []string {
+	names := make([]string, 0, len(phaseFlags))
+	for name := range phaseFlags {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Apply the values of a phase, returning a function that puts the settings
+// back as they were.
+func (settings *ScanSettings) ApplyPhase(phase Phase) (func(), error) {
+	saved := *settings
+	restore := func() {
+		for _, put := range phaseFlags {
+			put(settings, &saved)
+		}
+		settings.phase = saved.phase
+	}
+	fs := settings.flagSet()
+	for key, value := range phase.Values {
+		if phaseFlags[key] == nil {
+			restore()
+			return nil, fmt.Errorf("Phase %s: %s can't change between phases", phase.Name, key)
+		}
+		if err := fs.Set(key, value); err != nil {
+			restore()
+			return nil, fmt.Errorf("Phase %s: unable to set %s: %s", phase.Name, key, err.Error())
+		}
+	}
+	settings.phase = phase.Name
+	return restore, nil
+}
@@ -0,0 +1,73 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package settings
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestApplyPhase(t *testing.T) {
+	ss := testScanSettings()
+	ss.InitFlags()
+	ss.WordlistPath = "default"
+	phase := Phase{Name: "quick", Values: Profile{
+		"wordlist":       "short",
+		"frequent-first": "true",
+		"max-words":      "1000",
+		"extensions":     "",
+		"mangle":         "false",
+	}}
+	restore, err := ss.ApplyPhase(phase)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if ss.WordlistPath != "short" || !ss.FrequentFirst || ss.MaxWords != 1000 || len(ss.Extensions) != 0 || ss.Mangle {
+		t.Errorf("Expected the phase's values, got %s", ss)
+	}
+	restore()
+	if ss.WordlistPath != "default" || ss.FrequentFirst || ss.MaxWords != 0 || !reflect.DeepEqual(ss.Extensions, []string{"html", "php", "asp", "aspx"}) || !ss.Mangle {
+		t.Errorf("Expected the settings put back, got %s", ss)
+	}
+}
+
+func TestApplyPhase_Errors(t *testing.T) {
+	ss := testScanSettings()
+	ss.InitFlags()
+	workers := ss.Workers
+	for _, values := range []Profile{
+		{"workers": "2"},
+		{"max-words": "many"},
+	} {
+		restore, err := ss.ApplyPhase(Phase{Name: "bad", Values: values})
+		if err == nil || restore != nil || !strings.HasPrefix(err.Error(), "Phase bad: ") {
+			t.Errorf("Expected error for %v, got %v", values, err)
+		}
+		if ss.Workers != workers || ss.MaxWords != 0 {
+			t.Errorf("Expected the settings left alone, got %s", ss)
+		}
+	}
+}
+
+func TestPhaseFlagNames(t *testing.T) {
+	ss := testScanSettings()
+	ss.InitFlags()
+	for _, name := range PhaseFlagNames() {
+		if ss.flagSet().Lookup(name) == nil {
+			t.Errorf("Expected a flag for %s.", name)
+		}
+	}
+}
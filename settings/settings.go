@@ -16,10 +16,13 @@
 package settings
 
 import (
-	"errors"
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
 	"flag"
 	"fmt"
 	"github.com/Matir/gobuster/logging"
+	"io"
 	"net/url"
 	"os"
 	"runtime"
@@ -34,6 +37,8 @@ import (
 type ScanSettings struct {
 	// Starting point and scope of scan
 	BaseURLs []string
+	// File of further targets, one per line, or - for stdin
+	URLFile string
 	// Number of threads to run
 	Threads int
 	// Number of workers to run
@@ -42,44 +47,247 @@ type ScanSettings struct {
 	ExcludePaths []string
 	// Proxies
 	Proxies []string
+	// File of more proxies, one per line
+	ProxyFile string
+	// Whether each worker or each request takes the next proxy, one of
+	// ProxyRotations
+	ProxyRotation string
+	// Whether to speak HTTP/2, one of HTTP2Modes
+	HTTP2 string
+	// Try HTTP/3 first for https:// URLs
+	HTTP3 bool
+	// Local IPs or interfaces to connect from, in turn
+	Bind []string
+	// Unix domain socket to send every request to, whatever the URL's host
+	UnixSocket string
 	// Parse HTML for links?
 	ParseHTML bool
+	// Whether to follow URLs referenced in response headers
+	HeaderLinks bool
 	// Time to sleep between requests, per thread
 	SleepTime time.Duration
+	// Maximum bytes per second read across all workers
+	MaxBandwidth int64
+	// Maximum requests per second to each host, 0 for no limit
+	MaxRate float64
+	// File to share MaxRate with other processes through
+	RateFile string
+	// Most bytes to decompress from one body
+	DecompressBudget int64
+	// Flag decompression ratios above this
+	MaxCompressionRatio float64
+	// Slowest acceptable response body in bytes per second
+	MinThroughput int64
+	// Slow responses before a host is treated as a tarpit
+	TarpitLimit int
+	// Most bytes of bodies to save
+	MaxSavedBytes int64
+	// Stop saving bodies below this much free disk
+	MinFreeDisk int64
+	// Stop following links above this much heap
+	MaxMemory int64
+	// Bodies larger than this are only sampled
+	SampleThreshold int64
+	// Bytes to read from each end of a sampled body
+	SampleSize int64
+	// Most of each body to read
+	MaxBodyRead int64
+	// Target p95 latency for pacing, 0 to disable
+	PaceLatency time.Duration
 	// Log file path
 	LogfilePath string
+	// Tamper-evident record of every request sent, if set
+	AuditLogPath string
 	// Level of logging
 	LogLevel string
 	// Wordlist for scanning
 	WordlistPath string
+	// Try the wordlist's words on a built-in list of common paths first
+	FrequentFirst bool
+	// Most entries of the wordlist to use, 0 for all
+	MaxWords int
 	// Extensions for mangling
 	Extensions []string
+	// Misses without a hit before an extension is no longer tried on a host
+	ExtensionMisses int
 	// Whether or not to mangle
 	Mangle bool
+	// Whether to probe Unicode variants of found paths
+	UnicodeProbe bool
 	// How long should internal queues be sized
 	QueueSize int
 	// Timeout for network requests
 	Timeout time.Duration
+	// Resolve target hostnames before starting
+	PreResolve bool
+	// Other record types to look up for hosts found in dns mode
+	DNSRecordTypes []string
+	// Whether to try zone transfers from each domain's nameservers
+	ZoneTransfer bool
+	// DNS server to resolve through, empty for the system resolver
+	Resolver string
+	// What to brute-force, one of ScanModes
+	Mode string
+	// Domain virtual host names are made under, instead of the target's
+	VHostDomain string
+	// Name combined with each word into bucket names in s3 or gcs mode
+	BucketKeyword string
+	// Try environment, age and region variants of each word in dns or vhost mode
+	Permute bool
+	// Rules for those variants, instead of the built-in ones
+	PermutationRulesPath string
+	// Where to find known subdomains to try first in dns or vhost mode, files
+	// of amass or subfinder output or PassiveCrtSh
+	PassiveSources []string
+	// Request method in fuzz mode, empty to pick by FuzzData
+	FuzzMethod string
+	// Headers to send in fuzz mode, as "Name: value"
+	FuzzHeaders []string
+	// Request body in fuzz mode
+	FuzzData string
+	// Request method in dir mode, empty to pick by Data.  HEAD reads no
+	// bodies.
+	Method string
+	// Request body in dir mode, with FuzzMarker replaced by the last part of
+	// each path
+	Data string
+	// Content-Type of request bodies
+	ContentType string
+	// Slice of the URL space to scan as N/M, empty for all of it
+	Shard string
 	// Output type
 	OutputFormat string
 	// Output path
 	OutputPath string
+	// Additional outputs as format:path
+	ExtraOutputs []string
+	// Where to stream request and result events
+	Events string
+	// Address to serve results and scan control over gRPC on
+	GRPCListen string
+	// Host patterns that may be scanned outside of the scope
+	AllowCrossOrigin []string
+	// Whether sibling subdomains of targets are in scope
+	ScopeSubdomains bool
+	// Directory to save response bodies in
+	SaveBodiesPath string
+	// Whether to mask credentials and tokens in outputs
+	Redact bool
+	// Further patterns to mask in outputs
+	RedactPatterns []string
 	// User-Agent for requests
 	UserAgent string
+	// Headers to send with every request, as "Name: value"
+	Headers []string
+	// Host to present in every request instead of the target's host
+	Host string
+	// Cookies to send with every request, as "name=value"
+	Cookies []string
+	// Basic, digest or NTLM auth credentials, as host=user:password, or
+	// user:password for any host
+	Credentials []string
+	// Whether to keep cookies, separately for each host
+	KeepCookies bool
+	// Client certificate for mutual TLS, as PEM or PKCS#12
+	ClientCert string
+	// Key for a PEM client certificate, if not in the same file
+	ClientKey string
+	// Password for a PKCS#12 client certificate
+	ClientCertPassword string
 	// Whether to include redirects in reporting
 	IncludeRedirects bool
+	// Describe the scan in comment lines of text and CSV output
+	ScanComments bool
+	// Rules file for suppressing expected results
+	ResultRulesPath string
 	// How to handle Robots.txt
 	RobotsMode int
 	// Whether to allow upgrade from http to https
 	AllowHTTPSUpgrade bool
 	// Spider which http response codes
 	SpiderCodes []int
+	// Whether to check directories exist before brute-forcing inside them
+	VerifyDirs bool
+	// Spider only responses with these content types
+	SpiderContentTypes []string
+	// Elements to follow links from
+	SpiderElements []string
+	// API versions to try under /api/
+	APIVersions []string
+	// Largest body to parse for links
+	MaxParseSize int64
+	// Whether to learn per-directory not found pages
+	DetectSoft404 bool
+	// Whether to flag responses unlike others in their directory
+	DetectAnomalies bool
+	// Whether to request each URL again without credentials and compare
+	CompareAnonymous bool
+	// Whether to warn about hosts that look like honeypots
+	DetectDeception bool
+	// Whether to save state and stop when a host looks like a honeypot
+	DeceptionPause bool
+	// Whether to sniff content types from bodies
+	SniffTypes bool
+	// Whether to look for secrets in bodies
+	ScanSecrets bool
+	// Additional secret patterns
+	SecretRulesPath string
+	// Whether to follow and parse JavaScript sourcemaps
+	SourceMaps bool
+	// Whether to check and parse /.well-known/ resources
+	WellKnown bool
+	// Whether to extract metadata from documents
+	ExtractMetadata bool
+	// File recording completed scans
+	HistoryPath string
+	// How long a completed scan counts as recent
+	HistoryWindow time.Duration
+	// Skip targets recently scanned with the same settings
+	SkipDuplicates bool
+	// File to save scan progress in
+	StatePath string
+	// How often to save progress
+	StateInterval time.Duration
+	// State file of an interrupted scan to continue
+	ResumePath string
+	// File of everything found on earlier scans
+	KnowledgePath string
+	// How the knowledge base is used, one of KnowledgeModes
+	KnowledgeMode string
+	// Whether to show progress
+	Progress bool
+	// Whether to log progress summaries when stderr isn't a terminal
+	ProgressLog bool
+	// How often to show progress
+	ProgressInterval time.Duration
+	// Where to send liveness signals for a watchdog
+	Heartbeat string
+	// How often to send them
+	HeartbeatInterval time.Duration
 	// Whether or not to do CPU Profiling
 	DebugCPUProf bool
+	// Selected profile
+	Profile string
+	// Config file given on the command line
+	ConfigFile string
+	// Interactively write a config file instead of scanning
+	Wizard bool
+	// Shell to print a completion script for instead of scanning
+	Completion string
+	// Results pipeline from the config file
+	Pipeline []PipelineStage
+	// Phases to scan in, in order, from the config file
+	Phases []Phase
 	// Config file used when loading (for debugging only)
 	configPath string
+	// Profiles defined in the config file
+	profiles map[string]Profile
+	// Flags are registered here, defaults to flag.CommandLine
+	flags *flag.FlagSet
 	// Have flags been set up?
 	flagsSet bool
+	// Name of the phase applied, if any
+	phase string
 }
 
 // We handle Robots.txt in various ways
@@ -96,7 +304,81 @@ var robotsModeStrings = [...]string{
 	"seed",
 }
 
-var DefaultUserAgent = "GoBuster 0.01"
+// Ways of using the knowledge base
+const (
+	// Only record what is found
+	KnowledgeRecord = "record"
+	// Only report results that are new or changed
+	KnowledgeNew = "new"
+	// Don't request paths that are already known
+	KnowledgeGaps = "gaps"
+)
+
+var KnowledgeModes = []string{KnowledgeRecord, KnowledgeNew, KnowledgeGaps}
+
+// How requests are spread over several proxies
+const (
+	// Each worker sends all its requests through one proxy
+	ProxyPerWorker = "worker"
+	// Each request goes through the next proxy
+	ProxyPerRequest = "request"
+)
+
+var ProxyRotations = []string{ProxyPerWorker, ProxyPerRequest}
+
+// Whether to speak HTTP/2
+const (
+	// Let the transport decide
+	HTTP2Auto = "auto"
+	// HTTP/1.1 only
+	HTTP2Off = "off"
+	// HTTP/2 when offered over TLS
+	HTTP2On = "on"
+	// HTTP/2 only, including h2c for http:// URLs
+	HTTP2PriorKnowledge = "prior-knowledge"
+)
+
+var HTTP2Modes = []string{HTTP2Auto, HTTP2Off, HTTP2On, HTTP2PriorKnowledge}
+
+// What a scan brute-forces
+const (
+	// Paths under each target URL
+	ModeDir = "dir"
+	// Subdomains of each target, by resolving them
+	ModeDNS = "dns"
+	// Virtual hosts of each target, by requesting it under other names
+	ModeVHost = "vhost"
+	// S3 buckets, as subdomains of an S3 endpoint
+	ModeS3 = "s3"
+	// Google Cloud Storage buckets, as paths under a GCS endpoint
+	ModeGCS = "gcs"
+	// Anything, by substituting each word for FuzzMarker in a request
+	ModeFuzz = "fuzz"
+)
+
+var ScanModes = []string{ModeDir, ModeDNS, ModeVHost, ModeS3, ModeGCS, ModeFuzz}
+
+// Record types that can be looked up for hosts found in dns mode.
+var RecordTypes = []string{"CNAME", "MX", "NS", "SRV", "TXT"}
+
+// Passive source that searches certificate transparency logs, instead of a
+// file of subdomains.
+const PassiveCrtSh = "crt.sh"
+
+// Where words go in the URL, headers or body of a request in fuzz mode.
+const FuzzMarker = "FUZZ"
+
+// Endpoints to look for buckets under when no target is given in s3 or gcs
+// mode.
+const (
+	DefaultS3Endpoint  = "https://s3.amazonaws.com/"
+	DefaultGCSEndpoint = "https://storage.googleapis.com/"
+)
+
+// Version of gobuster, for reports and the default User-Agent.
+const Version = "0.01"
+
+var DefaultUserAgent = "GoBuster " + Version
 var outputFormats []string
 
 // StringSliceFlag is a flag.Value that takes a comma-separated string and turns
@@ -114,10 +396,38 @@ func (f StringSliceFlag) String() string {
 }
 
 func (f StringSliceFlag) Set(value string) error {
+	if value == "" {
+		// No values, rather than one empty one
+		*f.slice = nil
+		return nil
+	}
 	*f.slice = strings.Split(value, ",")
 	return nil
 }
 
+// RepeatedStringSliceFlag is a StringSliceFlag that appends, so the flag can
+// be given several times.  Commas within braces don't split, so targets like
+// http://{www,api}.example.com stay whole.
+type RepeatedStringSliceFlag struct {
+	StringSliceFlag
+}
+
+func (f RepeatedStringSliceFlag) Set(value string) error {
+	*f.slice = append(*f.slice, splitTargets(value)...)
+	return nil
+}
+
+// RepeatedStringFlag is a StringSliceFlag that appends each value whole, for
+// flags whose values may contain commas.
+type RepeatedStringFlag struct {
+	StringSliceFlag
+}
+
+func (f RepeatedStringFlag) Set(value string) error {
+	*f.slice = append(*f.slice, value)
+	return nil
+}
+
 // IntSliceFlag is a flag.Value that takes a comma-separated string and turns
 // it into a slice of ints.
 type IntSliceFlag struct {
@@ -171,6 +481,58 @@ func (f DurationFlag) Set(value string) error {
 	return nil
 }
 
+// ByteSizeFlag is a flag.Value that takes a size in bytes with an optional
+// K, M or G suffix (powers of 1024).
+type ByteSizeFlag struct {
+	size *int64
+}
+
+var byteSizeSuffixes = []struct {
+	suffix string
+	mult   int64
+}{
+	{"G", 1 << 30},
+	{"M", 1 << 20},
+	{"K", 1 << 10},
+}
+
+func (f ByteSizeFlag) String() string {
+	if f.size == nil {
+		return ""
+	}
+	for _, s := range byteSizeSuffixes {
+		if *f.size != 0 && *f.size%s.mult == 0 {
+			return strconv.FormatInt(*f.size/s.mult, 10) + s.suffix
+		}
+	}
+	return strconv.FormatInt(*f.size, 10)
+}
+
+// Parse a size as ByteSizeFlag does, such as 512, 100K or 2M.
+func ParseByteSize(value string) (int64, error) {
+	var size int64
+	err := ByteSizeFlag{&size}.Set(value)
+	return size, err
+}
+
+func (f ByteSizeFlag) Set(value string) error {
+	value = strings.ToUpper(strings.TrimSpace(value))
+	mult := int64(1)
+	for _, s := range byteSizeSuffixes {
+		if strings.HasSuffix(value, s.suffix) {
+			mult = s.mult
+			value = strings.TrimSuffix(value, s.suffix)
+			break
+		}
+	}
+	n, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || n < 0 {
+		return fmt.Errorf("Unable to parse %s as a size.", value)
+	}
+	*f.size = n * mult
+	return nil
+}
+
 // RobotsFlag is a RobotsMode as a flag
 type robotsFlag struct {
 	mode *int
@@ -195,14 +557,45 @@ func (f robotsFlag) Set(value string) error {
 
 // Constructs a ScanSettings struct with all of the defaults to be used.
 func NewScanSettings() *ScanSettings {
+	return newScanSettingsWithFlags(flag.CommandLine)
+}
+
+func newScanSettingsWithFlags(fs *flag.FlagSet) *ScanSettings {
 	settings := &ScanSettings{
-		Threads:     runtime.NumCPU(),
-		Extensions:  []string{"html", "php", "asp", "aspx"},
-		Mangle:      true,
-		QueueSize:   1024,
-		Timeout:     30 * time.Second,
-		LogLevel:    "WARNING",
-		SpiderCodes: []int{200},
+		flags:               fs,
+		Threads:             runtime.NumCPU(),
+		Extensions:          []string{"html", "php", "asp", "aspx"},
+		Mangle:              true,
+		QueueSize:           1024,
+		Timeout:             30 * time.Second,
+		ProgressInterval:    time.Second,
+		ProgressLog:         true,
+		HeartbeatInterval:   30 * time.Second,
+		DecompressBudget:    10 * 1024 * 1024,
+		MaxCompressionRatio: 100,
+		ExtensionMisses:     500,
+		DetectDeception:     true,
+		MinFreeDisk:         512 * 1024 * 1024,
+		SampleThreshold:     10 * 1024 * 1024,
+		SampleSize:          64 * 1024,
+		MaxBodyRead:         2 * 1024 * 1024,
+		HistoryWindow:       24 * time.Hour,
+		StateInterval:       30 * time.Second,
+		KnowledgeMode:       KnowledgeRecord,
+		ProxyRotation:       ProxyPerWorker,
+		HTTP2:               HTTP2Auto,
+		Mode:                ModeDir,
+		LogLevel:            "WARNING",
+		SpiderCodes:         []int{200},
+		VerifyDirs:          true,
+		MaxParseSize:        1024 * 1024,
+		SpiderElements:      []string{"a", "img", "script", "style", "link", "iframe", "frame", "object", "embed", "form", "srcset"},
+		SpiderContentTypes: []string{
+			"text/html",
+			"application/xhtml+xml",
+			"application/xml",
+			"text/xml",
+		},
 	}
 	settings.InitFlags()
 	return settings
@@ -214,7 +607,18 @@ func NewScanSettings() *ScanSettings {
 func GetScanSettings() (*ScanSettings, error) {
 	settings := NewScanSettings()
 	settings.LoadFromDefaultConfigFiles()
-	settings.ParseFlags()
+	if err := settings.ParseFlags(); err != nil {
+		return nil, err
+	}
+	if settings.Wizard || settings.Completion != "" {
+		return settings, nil
+	}
+	if err := settings.LoadTargets(os.Stdin); err != nil {
+		return nil, err
+	}
+	if err := settings.LoadProxies(); err != nil {
+		return nil, err
+	}
 	if err := settings.Validate(); err != nil {
 		return nil, err
 	}
@@ -226,43 +630,172 @@ func (settings *ScanSettings) InitFlags() {
 	if settings.flagsSet {
 		return
 	}
+	fs := settings.flagSet()
 
-	baseUrlValue := StringSliceFlag{&settings.BaseURLs}
-	flag.Var(baseUrlValue, "url", "Starting `URL` & scopes.")
-	flag.IntVar(&settings.Threads, "threads", runtime.NumCPU(), "Number of worker `threads`.")
-	flag.IntVar(&settings.Workers, "workers", runtime.NumCPU()*2, "Number of `workers`.")
+	baseUrlValue := RepeatedStringSliceFlag{StringSliceFlag{&settings.BaseURLs}}
+	fs.Var(baseUrlValue, "url", "Starting `URL` & scopes.  May be repeated to scan several targets at once.")
+	fs.Var(baseUrlValue, "u", "Shorthand for -url.  Use - to read targets from stdin.")
+	fs.StringVar(&settings.URLFile, "url-file", "", "Read targets from `file`, one per line (- for stdin).")
+	fs.IntVar(&settings.Threads, "threads", runtime.NumCPU(), "Number of worker `threads`.")
+	fs.IntVar(&settings.Workers, "workers", runtime.NumCPU()*2, "Number of `workers`.")
 	excludePathValue := StringSliceFlag{&settings.ExcludePaths}
-	flag.Var(excludePathValue, "exclude", "List of `paths` to exclude from search.")
-	flag.BoolVar(&settings.ParseHTML, "html", true, "Parse HTML documents for links to follow.")
-	flag.BoolVar(&settings.AllowHTTPSUpgrade, "allow-upgrade", false, "Allow HTTP->HTTPS upgrades.")
+	fs.Var(excludePathValue, "exclude", "List of `paths` to exclude from search.")
+	fs.BoolVar(&settings.ParseHTML, "html", true, "Parse HTML documents for links to follow.")
+	fs.BoolVar(&settings.HeaderLinks, "header-links", true, "Follow URLs referenced by headers such as Link and Content-Location.")
+	fs.BoolVar(&settings.AllowHTTPSUpgrade, "allow-upgrade", false, "Allow HTTP->HTTPS upgrades.")
 	sleepTimeValue := DurationFlag{&settings.SleepTime}
-	flag.Var(sleepTimeValue, "sleep", "Time (as `duration`) to sleep between requests.")
-	flag.StringVar(&settings.LogfilePath, "logfile", "", "Logfile `filename` (defaults to stderr)")
-	flag.StringVar(&settings.WordlistPath, "wordlist", "", "Wordlist `filename` to use (default built-in)")
+	fs.Var(sleepTimeValue, "sleep", "Time (as `duration`) to sleep between requests.")
+	paceLatencyValue := DurationFlag{&settings.PaceLatency}
+	fs.Var(paceLatencyValue, "pace-latency", "Reduce concurrency to keep p95 latency under this `duration`.")
+	decompressBudgetValue := ByteSizeFlag{&settings.DecompressBudget}
+	fs.Var(decompressBudgetValue, "decompress-budget", "Most `bytes` to decompress from a single response.")
+	fs.Float64Var(&settings.MaxCompressionRatio, "max-compression-ratio", settings.MaxCompressionRatio, "Flag responses that decompress more than this `ratio`.")
+	minThroughputValue := ByteSizeFlag{&settings.MinThroughput}
+	fs.Var(minThroughputValue, "min-throughput", "Abort response bodies slower than this many `bytes` per second (0 to disable).")
+	fs.IntVar(&settings.TarpitLimit, "tarpit-limit", settings.TarpitLimit, "Skip a host after this many bodies are aborted by -min-throughput (0 to disable).")
+	maxBandwidthValue := ByteSizeFlag{&settings.MaxBandwidth}
+	fs.Var(maxBandwidthValue, "max-bandwidth", "Maximum `bytes` per second to read (K/M/G suffixes allowed).")
+	fs.Float64Var(&settings.MaxRate, "max-rate", 0, "Maximum `requests` per second to each host (0 for no limit).")
+	fs.StringVar(&settings.RateFile, "rate-file", "", "Share -max-rate through this `file` with other gobuster processes using it, so their combined requests to a host stay under it.")
+	fs.StringVar(&settings.LogfilePath, "logfile", "", "Logfile `filename` (defaults to stderr)")
+	fs.StringVar(&settings.AuditLogPath, "audit-log", "", "Append every HTTP request sent (time, method, URL, source address, status) to `file`, hash-chained so edits show.  Check one with \"gobuster audit verify file\".")
+	fs.StringVar(&settings.WordlistPath, "wordlist", "", "Wordlist `filename`, built-in name, seclists: alias or URL to use (default built-in)")
+	fs.BoolVar(&settings.FrequentFirst, "frequent-first", false, "Try the words of the wordlist that are on a short, hand-picked list of common paths, such as admin, backup and .git, before the rest.")
+	fs.IntVar(&settings.MaxWords, "max-words", 0, "Use only the first `N` entries of the wordlist, e.g. with -frequent-first for the hand-picked paths (0 for all).")
 	extensionValue := StringSliceFlag{&settings.Extensions}
-	flag.Var(extensionValue, "extensions", "List of `extensions` to mangle with.")
-	flag.BoolVar(&settings.Mangle, "mangle", true, "Mangle by adding extensions.")
+	fs.Var(extensionValue, "extensions", "List of `extensions` to mangle with.")
+	fs.IntVar(&settings.ExtensionMisses, "extension-misses", settings.ExtensionMisses, "Stop trying an extension on a host after this many misses without a hit (0 to try every extension).")
+	fs.BoolVar(&settings.Mangle, "mangle", true, "Mangle by adding extensions.")
+	fs.BoolVar(&settings.UnicodeProbe, "unicode-probe", false, "Probe Unicode normalization variants of found paths.")
 	proxyValue := StringSliceFlag{&settings.Proxies}
-	flag.Var(proxyValue, "proxy", "Proxy or `proxies` to use.")
+	fs.Var(proxyValue, "proxy", "Proxy or `proxies` to use, as socks5://, socks4:// or http:// URLs.  HTTPS through an intercepting proxy such as Burp needs its CA trusted, e.g. with SSL_CERT_FILE.")
+	fs.StringVar(&settings.HTTP2, "http2", HTTP2Auto, fmt.Sprintf("Whether to speak HTTP/2: off, on when the server offers it over TLS, or prior-knowledge to insist on it, including h2c for http:// URLs.  Options: [%s]", strings.Join(HTTP2Modes, ", ")))
+	fs.BoolVar(&settings.HTTP3, "http3", false, "Experimental: try HTTP/3 over QUIC first for https:// URLs, falling back to HTTP/1.1 or HTTP/2 for hosts it doesn't work with")
+	fs.StringVar(&settings.ProxyFile, "proxy-file", "", "Read more proxies from `file`, one per line.")
+	fs.StringVar(&settings.ProxyRotation, "proxy-rotation", ProxyPerWorker, fmt.Sprintf("Whether each worker or each request takes the next of several proxies.  Per request spreads requests widest, but breaks NTLM authentication.  Options: [%s]", strings.Join(ProxyRotations, ", ")))
+	bindValue := StringSliceFlag{&settings.Bind}
+	fs.Var(bindValue, "bind", "Local `addresses` or interfaces to connect from, used in turn.")
+	fs.StringVar(&settings.UnixSocket, "unix-socket", "", "Send every request to the HTTP service listening on the Unix socket at `path`, with the target URL's host as the Host header.")
 	timeoutValue := DurationFlag{&settings.Timeout}
-	flag.Var(timeoutValue, "timeout", "Network connection timeout (`duration`).")
+	fs.Var(timeoutValue, "timeout", "Network connection timeout (`duration`).")
+	fs.BoolVar(&settings.PreResolve, "pre-resolve", true, "Resolve target hostnames before scanning.")
+	dnsRecordsValue := StringSliceFlag{&settings.DNSRecordTypes}
+	fs.Var(dnsRecordsValue, "dns-records", fmt.Sprintf("Comma-separated record `types` to look up for each host found in dns mode.  Options: [%s]", strings.Join(RecordTypes, ", ")))
+	fs.BoolVar(&settings.ZoneTransfer, "axfr", false, "Try zone transfers (AXFR) from the nameservers of each domain and subdomain found in dns mode.")
+	fs.StringVar(&settings.Resolver, "resolver", "", "DNS `server` (host or host:port) to resolve through, defaults to the system resolver.")
+	fs.StringVar(&settings.Shard, "shard", "", "Scan only slice `N/M` of the URL space, to split a scan across M machines.")
+	fs.StringVar(&settings.Mode, "mode", ModeDir, fmt.Sprintf("What to brute-force: paths (dir), subdomains (dns), virtual hosts (vhost), S3 buckets (s3), GCS buckets (gcs) or anything marked FUZZ in a request (fuzz).  Options: [%s]", strings.Join(ScanModes, ", ")))
+	fs.StringVar(&settings.BucketKeyword, "bucket-keyword", "", "`Name`, such as a company's, to combine with each word into bucket names in s3 or gcs mode.")
+	fs.BoolVar(&settings.Permute, "permute", false, "Also try variants of each word, such as dev-api and api-old, in dns or vhost mode.")
+	fs.StringVar(&settings.PermutationRulesPath, "permutation-rules", "", "`File` of -permute patterns, one per line with {word} and optionally {year}, e.g. dev-{word}.")
+	passiveValue := StringSliceFlag{&settings.PassiveSources}
+	fs.Var(passiveValue, "passive", fmt.Sprintf("Comma-separated `sources` of known subdomains to try before the wordlist in dns or vhost mode: %s for certificate transparency, or amass or subfinder output files.", PassiveCrtSh))
+	fs.StringVar(&settings.FuzzMethod, "fuzz-method", "", "HTTP `method` in fuzz mode, defaults to POST with -fuzz-data and GET otherwise.")
+	fuzzHeaderValue := RepeatedStringFlag{StringSliceFlag{&settings.FuzzHeaders}}
+	fs.Var(fuzzHeaderValue, "fuzz-header", "`Header` (Name: value) to send in fuzz mode, may contain FUZZ.  May be repeated.")
+	fs.StringVar(&settings.FuzzData, "fuzz-data", "", "Request `body` to send in fuzz mode, may contain FUZZ.")
+	fs.StringVar(&settings.Method, "method", "", "HTTP `method` in dir mode, such as HEAD for lighter scans that don't need bodies, defaults to POST with -data and GET otherwise.")
+	fs.StringVar(&settings.Data, "data", "", "Request `body` to send in dir mode, with FUZZ replaced by the last part of each path.")
+	fs.StringVar(&settings.ContentType, "content-type", "application/x-www-form-urlencoded", "Content-Type of request bodies from -data or -fuzz-data.")
+	fs.StringVar(&settings.VHostDomain, "vhost-domain", "", "`Domain` to make virtual host names under in vhost mode, defaults to the target's hostname.")
 	if len(outputFormats) > 1 {
 		formatHelp := fmt.Sprintf("Output `format`.  Options: [%s]", strings.Join(outputFormats, ", "))
-		flag.StringVar(&settings.OutputFormat, "format", outputFormats[0], formatHelp)
+		fs.StringVar(&settings.OutputFormat, "format", outputFormats[0], formatHelp)
+		fs.StringVar(&settings.OutputFormat, "output-format", outputFormats[0], "Same as -format.")
 	}
-	flag.StringVar(&settings.OutputPath, "outfile", "", "Output `file`, defaults to stdout.")
+	fs.StringVar(&settings.OutputPath, "outfile", "", "Output `file`, defaults to stdout.  A named pipe or unix:SOCKET streams line formats (text, csv, json), holding output until a reader connects.")
+	fs.StringVar(&settings.GRPCListen, "grpc", "", "Serve results as they are found, progress and a stop control over gRPC on `address`, e.g. localhost:9000.")
+	fs.StringVar(&settings.Events, "events", "", "Stream every request, result and finished directory as JSON lines to `target`: - for stdout, a file, named pipe or unix:SOCKET.")
+	extraOutputsValue := StringSliceFlag{&settings.ExtraOutputs}
+	fs.Var(extraOutputsValue, "output-extra", "Additional `outputs` written at the same time, as comma-separated format:path.")
+	fs.StringVar(&settings.SaveBodiesPath, "save-bodies", "", "`Directory` to save response bodies in.")
+	maxSavedValue := ByteSizeFlag{&settings.MaxSavedBytes}
+	fs.Var(maxSavedValue, "save-bodies-max", "Stop saving bodies after this many `bytes`.")
+	minFreeValue := ByteSizeFlag{&settings.MinFreeDisk}
+	fs.Var(minFreeValue, "min-free-disk", "Stop saving bodies when free disk drops below this many `bytes`.")
+	fs.BoolVar(&settings.Redact, "redact", false, "Mask cookies, authorization headers, passwords and tokens in reports, events and saved bodies.")
+	redactPatternsValue := RepeatedStringFlag{StringSliceFlag{&settings.RedactPatterns}}
+	fs.Var(redactPatternsValue, "redact-pattern", "Also mask text matching `regex` in outputs, or just its first group if it has one.  May be repeated.")
+	sampleThresholdValue := ByteSizeFlag{&settings.SampleThreshold}
+	fs.Var(sampleThresholdValue, "sample-threshold", "Only sample the start and end of bodies larger than this many `bytes` (0 to disable).")
+	sampleSizeValue := ByteSizeFlag{&settings.SampleSize}
+	fs.Var(sampleSizeValue, "sample-size", "`Bytes` to read from each end of a sampled body.")
+	maxBodyReadValue := ByteSizeFlag{&settings.MaxBodyRead}
+	fs.Var(maxBodyReadValue, "max-body-read", "Most `bytes` of each body to read, for hashes, counts and secrets. Longer bodies are reported truncated and not hashed or saved.")
+	maxMemoryValue := ByteSizeFlag{&settings.MaxMemory}
+	fs.Var(maxMemoryValue, "max-memory", "Stop following new links when heap use exceeds this many `bytes`.")
 	loglevelHelp := fmt.Sprintf("Log `level`.  Options: [%s]", strings.Join(logging.LogLevelStrings[:], ", "))
-	flag.StringVar(&settings.LogLevel, "loglevel", settings.LogLevel, loglevelHelp)
-	flag.StringVar(&settings.UserAgent, "user-agent", DefaultUserAgent, "`User-Agent` for requests")
-	flag.BoolVar(&settings.IncludeRedirects, "include-redirects", false, "Include redirects in reports.")
+	fs.StringVar(&settings.LogLevel, "loglevel", settings.LogLevel, loglevelHelp)
+	fs.StringVar(&settings.UserAgent, "user-agent", DefaultUserAgent, "`User-Agent` for requests")
+	headerValue := RepeatedStringFlag{StringSliceFlag{&settings.Headers}}
+	fs.Var(headerValue, "header", "`Header` (Name: value) to send with every request, such as an API key.  May be repeated.")
+	fs.Var(headerValue, "H", "Shorthand for -header.")
+	fs.StringVar(&settings.Host, "host", "", "`Host` to send in the Host header and TLS server name of every request, while connecting to the target URL's host, e.g. to scan a site's origin IP as its production hostname.")
+	cookieValue := RepeatedStringFlag{StringSliceFlag{&settings.Cookies}}
+	fs.Var(cookieValue, "cookie", "`Cookie` (name=value, or several separated by ;) to send with every request, such as a session.  May be repeated.")
+	credentialsValue := RepeatedStringFlag{StringSliceFlag{&settings.Credentials}}
+	fs.Var(credentialsValue, "auth", "Basic, digest or NTLM auth `credentials`, as host=user:password for one host (on any port) or user:password for the rest.  NTLM users may be given as DOMAIN\\user.  May be repeated.")
+	fs.BoolVar(&settings.KeepCookies, "cookies", false, "Keep cookies, separately for each host.")
+	fs.StringVar(&settings.ClientCert, "client-cert", "", "Client certificate `file` for mutual TLS, as PEM or PKCS#12 (.p12/.pfx).")
+	fs.StringVar(&settings.ClientKey, "client-key", "", "Key `file` for a PEM -client-cert, if not in the certificate file.")
+	fs.StringVar(&settings.ClientCertPassword, "client-cert-password", "", "`Password` for a PKCS#12 -client-cert.")
+	fs.BoolVar(&settings.IncludeRedirects, "include-redirects", false, "Include redirects in reports.")
+	fs.BoolVar(&settings.ScanComments, "scan-comments", false, "Describe the scan in # comment lines around text and CSV output.")
+	fs.StringVar(&settings.ResultRulesPath, "result-rules", "", "Rules `file` of expected results to suppress.")
 	robotsModeHelp := fmt.Sprintf("Robots `mode`.  Options: [%s]", strings.Join(robotsModeStrings[:], ", "))
 	robotsModeVar := robotsFlag{&settings.RobotsMode}
 	spiderCodesValue := IntSliceFlag{&settings.SpiderCodes}
-	flag.Var(spiderCodesValue, "spider-codes", "HTTP Response Codes to Continue Spidering On.")
-	flag.Var(robotsModeVar, "robots-mode", robotsModeHelp)
+	fs.Var(spiderCodesValue, "spider-codes", "HTTP Response Codes to Continue Spidering On.")
+	fs.BoolVar(&settings.VerifyDirs, "verify-dirs", true, "Brute-force inside linked or otherwise found directories only once a request shows they exist.")
+	crossOriginValue := StringSliceFlag{&settings.AllowCrossOrigin}
+	fs.Var(crossOriginValue, "allow-cross-origin", "Comma-separated host `patterns` (e.g. *.example.com) that links and redirects may lead to.")
+	fs.BoolVar(&settings.ScopeSubdomains, "scope-subdomains", false, "Treat subdomains of each target's domain (e.g. *.example.com) as in scope.")
+	spiderTypesValue := StringSliceFlag{&settings.SpiderContentTypes}
+	fs.Var(spiderTypesValue, "spider-types", "Content `types` to continue spidering on (type/* allowed, !type to deny).")
+	maxParseSizeValue := ByteSizeFlag{&settings.MaxParseSize}
+	fs.Var(maxParseSizeValue, "spider-max-size", "Largest body, in `bytes`, to parse for links.")
+	apiVersionsValue := StringSliceFlag{&settings.APIVersions}
+	fs.Var(apiVersionsValue, "api-versions", "API `versions` (e.g. v1,v2,beta) to also try for each directory under /api/, reporting endpoints that differ between them.")
+	spiderElementsValue := StringSliceFlag{&settings.SpiderElements}
+	fs.Var(spiderElementsValue, "spider-elements", "Link `sources` to follow.  Options: [a, img, script, style, link, iframe, frame, object, embed, form, srcset]")
+	fs.BoolVar(&settings.DetectSoft404, "detect-soft404", false, "Learn per-directory not found pages.")
+	fs.BoolVar(&settings.CompareAnonymous, "compare-anonymous", false, "Request each URL again without credentials, cookies or -header headers, and report where the responses differ.")
+	fs.BoolVar(&settings.DetectDeception, "detect-deception", true, "Warn about hosts that look like honeypots: nearly every guess found, absurdly many directories, or canary tokens (with -secrets).")
+	fs.BoolVar(&settings.DeceptionPause, "deception-pause", false, "Save the scan state and stop when a host looks like a honeypot, to continue with -resume once checked.")
+	fs.BoolVar(&settings.DetectAnomalies, "detect-anomalies", false, "Learn the usual length and response time of each status in each directory, and report responses far from them.")
+	fs.BoolVar(&settings.SniffTypes, "sniff-types", true, "Sniff content types, flag mismatches and spider and parse by the sniffed type.")
+	fs.BoolVar(&settings.ScanSecrets, "secrets", false, "Scan response bodies for secrets.")
+	fs.StringVar(&settings.SecretRulesPath, "secret-rules", "", "`File` of additional \"name: regex\" secret patterns.")
+	fs.BoolVar(&settings.WellKnown, "well-known", false, "Check /.well-known/ resources such as security.txt and parse them for endpoints.")
+	fs.BoolVar(&settings.SourceMaps, "sourcemaps", false, "Follow JavaScript sourcemaps and report original source files and paths.")
+	fs.BoolVar(&settings.ExtractMetadata, "extract-metadata", false, "Extract author and software metadata from PDF and Office documents.")
+	fs.Var(robotsModeVar, "robots-mode", robotsModeHelp)
+
+	fs.StringVar(&settings.HistoryPath, "history", "", "History `file` used to detect duplicate scans.")
+	historyWindowValue := DurationFlag{&settings.HistoryWindow}
+	fs.Var(historyWindowValue, "history-window", "How long (`duration`) a completed scan counts as recent.")
+	fs.BoolVar(&settings.SkipDuplicates, "skip-duplicates", false, "Skip targets recently scanned with the same settings.")
+	fs.StringVar(&settings.StatePath, "state", "", "Periodically save scan progress to `file` so the scan can be resumed.")
+	stateIntervalValue := DurationFlag{&settings.StateInterval}
+	fs.Var(stateIntervalValue, "state-interval", "How often (`duration`) to save scan progress.")
+	fs.StringVar(&settings.ResumePath, "resume", "", "Continue the interrupted scan saved in state `file`.")
+	fs.StringVar(&settings.KnowledgePath, "kb", "", "Knowledge base `file` of everything found on each target, kept across scans.")
+	fs.StringVar(&settings.KnowledgeMode, "kb-mode", KnowledgeRecord, fmt.Sprintf("How to use the knowledge base.  Options: [%s]", strings.Join(KnowledgeModes, ", ")))
+	fs.BoolVar(&settings.Progress, "progress", false, "Show scan progress on stderr, updated in place on a terminal.")
+	fs.BoolVar(&settings.ProgressLog, "progress-log", settings.ProgressLog, "Log a progress summary at growing intervals (1m, 2m, 5m, ...) when stderr isn't a terminal, e.g. under nohup.")
+	progressIntervalValue := DurationFlag{&settings.ProgressInterval}
+	fs.Var(progressIntervalValue, "progress-interval", "How often (`duration`) to update progress on a terminal.")
+	fs.StringVar(&settings.Heartbeat, "heartbeat", "", "Send liveness signals to `target`: file:PATH to touch, an http(s) URL to ping, or systemd.")
+	heartbeatIntervalValue := DurationFlag{&settings.HeartbeatInterval}
+	fs.Var(heartbeatIntervalValue, "heartbeat-interval", "How often (`duration`) to send heartbeats.")
+	profileHelp := fmt.Sprintf("Scan `profile`.  Built-in: [%s]", strings.Join(BuiltinProfileNames(), ", "))
+	fs.StringVar(&settings.Profile, "profile", "", profileHelp)
+	fs.StringVar(&settings.ConfigFile, "config", "", "Config `file` to load before other flags (.yaml, .toml or name = value lines).")
+	fs.BoolVar(&settings.Wizard, "wizard", false, "Interactively write a config file, then exit.")
+	fs.StringVar(&settings.Completion, "completion", "", fmt.Sprintf("Print a completion script for `shell`, then exit.  Options: [%s]", strings.Join(CompletionShells, ", ")))
 
 	// Debugging flags
-	flag.BoolVar(&settings.DebugCPUProf, "debug-cpuprof", false, "[DEBUG] CPU Profiling")
+	fs.BoolVar(&settings.DebugCPUProf, "debug-cpuprof", false, "[DEBUG] CPU Profiling")
 
 	settings.flagsSet = true
 }
@@ -282,44 +815,125 @@ func (settings *ScanSettings) LoadFromDefaultConfigFiles() {
 
 // Load from the specified file
 func (settings *ScanSettings) LoadFromConfigFile(path string) {
-	settings.InitFlags()
-	// TODO: load
-	settings.configPath = path
+	if err := settings.loadConfigFile(path); err != nil {
+		logging.Logf(logging.LogWarning, "%s", err)
+	}
 }
 
-// Parse command line flags into settings
-func (settings *ScanSettings) ParseFlags() {
+// Load a config file, in the format suggested by its extension.
+func (settings *ScanSettings) loadConfigFile(path string) error {
 	settings.InitFlags()
-	flag.Parse()
-	for i := 0; i < flag.NArg(); i++ {
-		settings.BaseURLs = append(settings.BaseURLs, flag.Arg(i))
+	settings.configPath = path
+	fp, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("Unable to open config file %s: %s", path, err.Error())
 	}
+	defer fp.Close()
+	entries, err := parseConfigFormat(path, fp)
+	if err == nil {
+		err = settings.applyConfig(entries)
+	}
+	if err != nil {
+		return fmt.Errorf("Error in config file %s: %s", path, err.Error())
+	}
+	return nil
+}
+
+// Parse command line flags into settings.  A config file and then a profile,
+// if given, are applied first so that other flags override them.
+func (settings *ScanSettings) ParseFlags() error {
+	return settings.parseArgs(os.Args[1:])
 }
 
-// Validate settings
-func (settings *ScanSettings) Validate() error {
-	flagError := func(str string) error {
-		os.Stderr.WriteString("Usage:\n")
-		flag.PrintDefaults()
-		return errors.New(str)
+func (settings *ScanSettings) parseArgs(args []string) error {
+	settings.InitFlags()
+	if path := findFlagValue(args, "config"); path != "" {
+		if err := settings.loadConfigFile(path); err != nil {
+			return err
+		}
 	}
-	if len(settings.BaseURLs) == 0 {
-		return flagError("URL is required.")
+	if name := findFlagValue(args, "profile"); name != "" {
+		if err := settings.ApplyProfile(name); err != nil {
+			return err
+		}
+	}
+	fs := settings.flagSet()
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	for i := 0; i < fs.NArg(); i++ {
+		settings.BaseURLs = append(settings.BaseURLs, fs.Arg(i))
 	}
 	return nil
 }
 
+func (settings *ScanSettings) flagSet() *flag.FlagSet {
+	if settings.flags == nil {
+		settings.flags = flag.CommandLine
+	}
+	return settings.flags
+}
+
 // Printable config
 func (settings *ScanSettings) String() string {
 	flags := make([]string, 0)
 
-	flag.VisitAll(func(f *flag.Flag) {
+	settings.flagSet().VisitAll(func(f *flag.Flag) {
 		flags = append(flags, fmt.Sprintf("-%s=%s", f.Name, f.Value.String()))
 	})
 
 	return strings.Join(flags, " ")
 }
 
+// Flags that don't change what a scan does, and so aren't part of the
+// fingerprint.
+var fingerprintIgnored = map[string]bool{
+	"url":                true,
+	"u":                  true,
+	"url-file":           true,
+	"outfile":            true,
+	"format":             true,
+	"output-format":      true,
+	"output-extra":       true,
+	"scan-comments":      true,
+	"events":             true,
+	"grpc":               true,
+	"logfile":            true,
+	"audit-log":          true,
+	"loglevel":           true,
+	"history":            true,
+	"history-window":     true,
+	"skip-duplicates":    true,
+	"state":              true,
+	"state-interval":     true,
+	"resume":             true,
+	"kb":                 true,
+	"kb-mode":            true,
+	"progress":           true,
+	"progress-interval":  true,
+	"progress-log":       true,
+	"heartbeat":          true,
+	"heartbeat-interval": true,
+	"config":             true,
+	"wizard":             true,
+	"completion":         true,
+	"debug-cpuprof":      true,
+}
+
+// Hash of the settings affecting scan behavior, for detecting repeated scans.
+func (settings *ScanSettings) Fingerprint() string {
+	h := sha256.New()
+	settings.flagSet().VisitAll(func(f *flag.Flag) {
+		if !fingerprintIgnored[f.Name] {
+			fmt.Fprintf(h, "%s=%s\n", f.Name, f.Value.String())
+		}
+	})
+	for _, phase := range settings.Phases {
+		fmt.Fprintf(h, "phase %s\n", phase)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
 // Convert BaseURL strings to URLs
 func (settings *ScanSettings) GetScopes() ([]*url.URL, error) {
 	scopes := make([]*url.URL, len(settings.BaseURLs))
@@ -337,7 +951,148 @@ func (settings *ScanSettings) GetScopes() ([]*url.URL, error) {
 	return scopes, nil
 }
 
+// Parse the shard setting into the slice to scan (1-based) and the number of
+// slices.  Without a shard, the whole scan is slice 1 of 1.
+func (settings *ScanSettings) ShardSlice() (int, int, error) {
+	if settings.Shard == "" {
+		return 1, 1, nil
+	}
+	pieces := strings.SplitN(settings.Shard, "/", 2)
+	if len(pieces) == 2 {
+		n, nErr := strconv.Atoi(pieces[0])
+		m, mErr := strconv.Atoi(pieces[1])
+		if nErr == nil && mErr == nil && n >= 1 && n <= m {
+			return n, m, nil
+		}
+	}
+	return 0, 0, fmt.Errorf("Invalid shard %s, expected N/M with 1 <= N <= M.", settings.Shard)
+}
+
+// Whether FuzzMarker is in the headers or body of fuzz mode requests, rather
+// than only in the URL.
+func (settings *ScanSettings) FuzzesRequest() bool {
+	if strings.Contains(settings.FuzzData, FuzzMarker) {
+		return true
+	}
+	for _, header := range settings.FuzzHeaders {
+		if strings.Contains(header, FuzzMarker) {
+			return true
+		}
+	}
+	return false
+}
+
+// Replace a "-" target with the targets read from stdin, then add those from
+// the URL file.  Lines are trimmed, blank lines and # comments are skipped,
+// and bare hosts, as printed by subdomain enumeration tools, become http://
+// URLs, as do bare domains given in any mode but dir.  Finally, ranges in
+// targets are expanded (see ExpandTarget), and s3 or gcs mode with no targets
+// looks under the provider's default endpoint.
+func (settings *ScanSettings) LoadTargets(stdin io.Reader) error {
+	usedStdin := false
+	readStdin := func() ([]string, error) {
+		if usedStdin {
+			return nil, nil
+		}
+		usedStdin = true
+		return readTargets(stdin)
+	}
+	targets := make([]string, 0, len(settings.BaseURLs))
+	for _, base := range settings.BaseURLs {
+		if base != "-" {
+			targets = append(targets, base)
+			continue
+		}
+		read, err := readStdin()
+		if err != nil {
+			return fmt.Errorf("Unable to read targets from stdin: %s", err.Error())
+		}
+		targets = append(targets, read...)
+	}
+	if settings.URLFile != "" {
+		var read []string
+		var err error
+		if settings.URLFile == "-" {
+			read, err = readStdin()
+		} else if fp, openErr := os.Open(settings.URLFile); openErr != nil {
+			err = openErr
+		} else {
+			read, err = readTargets(fp)
+			fp.Close()
+		}
+		if err != nil {
+			return fmt.Errorf("Unable to read targets from %s: %s", settings.URLFile, err.Error())
+		}
+		targets = append(targets, read...)
+	}
+	settings.BaseURLs = make([]string, 0, len(targets))
+	for _, target := range targets {
+		if settings.Mode != ModeDir && !strings.Contains(target, "://") {
+			target = "http://" + target
+		}
+		expanded, err := ExpandTarget(target)
+		if err != nil {
+			return err
+		}
+		settings.BaseURLs = append(settings.BaseURLs, expanded...)
+	}
+	if len(settings.BaseURLs) == 0 && settings.ResumePath == "" {
+		switch settings.Mode {
+		case ModeS3:
+			settings.BaseURLs = []string{DefaultS3Endpoint}
+		case ModeGCS:
+			settings.BaseURLs = []string{DefaultGCSEndpoint}
+		}
+	}
+	return nil
+}
+
+// Add the proxies in the proxy file, skipping blank lines and # comments.
+func (settings *ScanSettings) LoadProxies() error {
+	if settings.ProxyFile == "" {
+		return nil
+	}
+	fp, err := os.Open(settings.ProxyFile)
+	if err != nil {
+		return fmt.Errorf("Unable to read proxies from %s: %s", settings.ProxyFile, err.Error())
+	}
+	defer fp.Close()
+	scanner := bufio.NewScanner(fp)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || line[0] == '#' {
+			continue
+		}
+		settings.Proxies = append(settings.Proxies, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("Unable to read proxies from %s: %s", settings.ProxyFile, err.Error())
+	}
+	return nil
+}
+
+func readTargets(rdr io.Reader) ([]string, error) {
+	var targets []string
+	scanner := bufio.NewScanner(rdr)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || line[0] == '#' {
+			continue
+		}
+		if !strings.Contains(line, "://") {
+			line = "http://" + line
+		}
+		targets = append(targets, line)
+	}
+	return targets, scanner.Err()
+}
+
 // Init output formats
 func SetOutputFormats(formats []string) {
 	outputFormats = formats
 }
+
+// Output formats registered with SetOutputFormats.
+func OutputFormatNames() []string {
+	return outputFormats
+}
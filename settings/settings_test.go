@@ -16,6 +16,9 @@ package settings
 
 import (
 	"github.com/Matir/gobuster/logging"
+	"io/ioutil"
+	"os"
+	"strings"
 	"testing"
 	"time"
 )
@@ -68,6 +71,160 @@ func TestStringSliceFlag(t *testing.T) {
 	if f.String() != s {
 		t.Errorf("Differing strings: \"%s\" vs \"%s\".", f.String(), s)
 	}
+	if err := f.Set(""); err != nil || len(*f.slice) != 0 {
+		t.Errorf("Expected no values from an empty string, got %q", *f.slice)
+	}
+}
+
+func TestParseArgs_RepeatedURL(t *testing.T) {
+	ss := testScanSettings()
+	if err := ss.parseArgs([]string{"-url", "http://a/", "-u", "http://b/,http://c/", "http://d/"}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expected := "http://a/ http://b/ http://c/ http://d/"
+	if got := strings.Join(ss.BaseURLs, " "); got != expected {
+		t.Errorf("Expected %s, got %s", expected, got)
+	}
+}
+
+func TestParseArgs_OutputFormat(t *testing.T) {
+	defer SetOutputFormats(outputFormats)
+	SetOutputFormats([]string{"text", "csv", "json"})
+	for _, name := range []string{"-format", "-output-format"} {
+		ss := testScanSettings()
+		if err := ss.parseArgs([]string{name, "json", "http://localhost/"}); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if ss.OutputFormat != "json" {
+			t.Errorf("Expected json from %s, got %s", name, ss.OutputFormat)
+		}
+	}
+}
+
+func TestParseArgs_FuzzHeaders(t *testing.T) {
+	ss := testScanSettings()
+	if err := ss.parseArgs([]string{"-fuzz-header", "Accept: text/html, */*", "-fuzz-header", "X-User: FUZZ"}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(ss.FuzzHeaders) != 2 || ss.FuzzHeaders[0] != "Accept: text/html, */*" {
+		t.Errorf("Expected headers kept whole, got %q", ss.FuzzHeaders)
+	}
+	if !ss.FuzzesRequest() {
+		t.Errorf("Expected FUZZ in a header to fuzz the request.")
+	}
+	ss.FuzzHeaders = nil
+	if ss.FuzzesRequest() {
+		t.Errorf("Expected no FUZZ outside the URL.")
+	}
+	ss.FuzzData = "user=FUZZ"
+	if !ss.FuzzesRequest() {
+		t.Errorf("Expected FUZZ in the body to fuzz the request.")
+	}
+}
+
+func TestLoadProxies(t *testing.T) {
+	fp, err := ioutil.TempFile("", "gobuster-proxies")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(fp.Name())
+	fp.WriteString("# residential\nsocks5://10.0.0.1:1080\n\n  http://10.0.0.2:8080 \n")
+	fp.Close()
+	ss := testScanSettings()
+	if err := ss.parseArgs([]string{"-proxy", "socks5://127.0.0.1:1080", "-proxy-file", fp.Name()}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := ss.LoadProxies(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expected := "socks5://127.0.0.1:1080 socks5://10.0.0.1:1080 http://10.0.0.2:8080"
+	if got := strings.Join(ss.Proxies, " "); got != expected {
+		t.Errorf("Expected %s, got %s", expected, got)
+	}
+	ss.ProxyFile = fp.Name() + ".missing"
+	if err := ss.LoadProxies(); err == nil {
+		t.Errorf("Expected error for missing proxy file.")
+	}
+}
+
+func TestLoadTargets(t *testing.T) {
+	fp, err := ioutil.TempFile("", "gobuster-targets")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(fp.Name())
+	fp.WriteString("# from a file\nhttps://c/\n\n")
+	fp.Close()
+	ss := testScanSettings()
+	if err := ss.parseArgs([]string{"-u", "http://a/", "-u", "-", "-url-file", fp.Name()}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := ss.LoadTargets(strings.NewReader("  b.example.com \nhttp://d/\n")); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expected := "http://a/ http://b.example.com http://d/ https://c/"
+	if got := strings.Join(ss.BaseURLs, " "); got != expected {
+		t.Errorf("Expected %s, got %s", expected, got)
+	}
+
+	ss = testScanSettings()
+	ss.BaseURLs = []string{"-"}
+	ss.URLFile = "-"
+	if err := ss.LoadTargets(strings.NewReader("e{1..2}\n")); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if got := strings.Join(ss.BaseURLs, " "); got != "http://e1 http://e2" {
+		t.Errorf("Expected stdin to be read once, got %s", got)
+	}
+
+	ss.URLFile = fp.Name() + ".missing"
+	if err := ss.LoadTargets(strings.NewReader("")); err == nil {
+		t.Errorf("Expected error for missing URL file.")
+	}
+
+	ss = testScanSettings()
+	ss.Mode = ModeDNS
+	ss.BaseURLs = []string{"example.com", "https://example.org/"}
+	if err := ss.LoadTargets(strings.NewReader("")); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if got := strings.Join(ss.BaseURLs, " "); got != "http://example.com https://example.org/" {
+		t.Errorf("Expected bare domains to become URLs in dns mode, got %s", got)
+	}
+
+	ss = testScanSettings()
+	ss.Mode = ModeS3
+	if err := ss.LoadTargets(strings.NewReader("")); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if got := strings.Join(ss.BaseURLs, " "); got != DefaultS3Endpoint {
+		t.Errorf("Expected the default endpoint in s3 mode, got %s", got)
+	}
+	ss.BaseURLs = nil
+	ss.Mode = ModeGCS
+	if err := ss.LoadTargets(strings.NewReader("")); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if got := strings.Join(ss.BaseURLs, " "); got != DefaultGCSEndpoint {
+		t.Errorf("Expected the default endpoint in gcs mode, got %s", got)
+	}
+}
+
+func TestShardSlice(t *testing.T) {
+	ss := testScanSettings()
+	if n, m, err := ss.ShardSlice(); n != 1 || m != 1 || err != nil {
+		t.Errorf("Expected 1/1 without a shard, got %d/%d %v", n, m, err)
+	}
+	ss.Shard = "3/10"
+	if n, m, err := ss.ShardSlice(); n != 3 || m != 10 || err != nil {
+		t.Errorf("Expected 3/10, got %d/%d %v", n, m, err)
+	}
+	for _, shard := range []string{"3", "0/10", "4/3", "a/b", "1/2/3"} {
+		ss.Shard = shard
+		if _, _, err := ss.ShardSlice(); err == nil {
+			t.Errorf("Expected error for shard %s", shard)
+		}
+	}
 }
 
 func TestIntSliceFlag(t *testing.T) {
@@ -122,6 +279,36 @@ func TestDurationFlag_Set_Invalid(t *testing.T) {
 	}
 }
 
+func TestParseByteSize(t *testing.T) {
+	if n, err := ParseByteSize("100k"); err != nil || n != 100*1024 {
+		t.Errorf("Expected 102400, got %d (%v)", n, err)
+	}
+	if _, err := ParseByteSize("lots"); err == nil {
+		t.Error("Expected an error for lots.")
+	}
+}
+
+func TestByteSizeFlag(t *testing.T) {
+	f := ByteSizeFlag{}
+	if f.String() != "" {
+		t.Error("Expected empty string for empty ByteSizeFlag.")
+	}
+	var size int64
+	f.size = &size
+	if err := f.Set("512k"); err != nil {
+		t.Errorf("Error setting ByteSizeFlag: %v", err)
+	}
+	if size != 512*1024 {
+		t.Errorf("Expected 524288, got %d", size)
+	}
+	if f.String() != "512K" {
+		t.Errorf("Expected \"512K\", got \"%s\"", f.String())
+	}
+	if err := f.Set("lots"); err == nil {
+		t.Error("Expected error setting invalid ByteSizeFlag.")
+	}
+}
+
 func TestRobotsFlag_Empty(t *testing.T) {
 	f := robotsFlag{}
 	if f.String() != "ignore" {
@@ -0,0 +1,216 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package settings
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Refuse to expand one target into more than this many.
+const maxExpandedTargets = 65536
+
+var (
+	braceNumberRe = regexp.MustCompile(`^(\d+)\.\.(\d+)$`)
+	braceLetterRe = regexp.MustCompile(`^([a-zA-Z])\.\.([a-zA-Z])$`)
+)
+
+// Expand a target into individual targets.  Brace expressions such as
+// {1..9}, {01..10}, {a..f} and {dev,staging} produce one target per value, and
+// an IP address followed by a prefix length, as in http://10.0.0.0/24, produces
+// one target per address.  Any path after the prefix length is kept.  The
+// network and broadcast addresses of IPv4 ranges larger than /31 are skipped.
+func ExpandTarget(target string) ([]string, error) {
+	braced, err := expandBraces(target)
+	if err != nil {
+		return nil, err
+	}
+	var targets []string
+	for _, t := range braced {
+		expanded, err := expandCIDR(t)
+		if err != nil {
+			return nil, err
+		}
+		targets = append(targets, expanded...)
+		if len(targets) > maxExpandedTargets {
+			return nil, fmt.Errorf("Target %s expands to more than %d targets.", target, maxExpandedTargets)
+		}
+	}
+	return targets, nil
+}
+
+// Expand the brace expressions in s.  Braces that aren't a range or list are
+// left as they are.
+func expandBraces(s string) ([]string, error) {
+	open := strings.IndexByte(s, '{')
+	if open < 0 {
+		return []string{s}, nil
+	}
+	length := strings.IndexByte(s[open:], '}')
+	if length < 0 {
+		return []string{s}, nil
+	}
+	prefix, body, rest := s[:open], s[open+1:open+length], s[open+length+1:]
+	values, err := braceValues(body)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid range in %s: %s", s, err.Error())
+	}
+	suffixes, err := expandBraces(rest)
+	if err != nil {
+		return nil, err
+	}
+	if values == nil {
+		values = []string{"{" + body + "}"}
+	}
+	if len(values)*len(suffixes) > maxExpandedTargets {
+		return nil, fmt.Errorf("Target %s expands to more than %d targets.", s, maxExpandedTargets)
+	}
+	results := make([]string, 0, len(values)*len(suffixes))
+	for _, v := range values {
+		for _, suffix := range suffixes {
+			results = append(results, prefix+v+suffix)
+		}
+	}
+	return results, nil
+}
+
+// Values of a brace expression, or nil if it isn't one.
+func braceValues(body string) ([]string, error) {
+	if m := braceNumberRe.FindStringSubmatch(body); m != nil {
+		start, startErr := strconv.Atoi(m[1])
+		end, endErr := strconv.Atoi(m[2])
+		if startErr != nil || endErr != nil || start > end {
+			return nil, fmt.Errorf("bad range {%s}", body)
+		}
+		if end-start >= maxExpandedTargets {
+			return nil, fmt.Errorf("range {%s} is too large", body)
+		}
+		width := 0
+		if len(m[1]) > 1 && m[1][0] == '0' {
+			width = len(m[1])
+		}
+		values := make([]string, 0, end-start+1)
+		for i := start; i <= end; i++ {
+			values = append(values, fmt.Sprintf("%0*d", width, i))
+		}
+		return values, nil
+	}
+	if m := braceLetterRe.FindStringSubmatch(body); m != nil {
+		start, end := m[1][0], m[2][0]
+		if start > end {
+			return nil, fmt.Errorf("bad range {%s}", body)
+		}
+		values := make([]string, 0, end-start+1)
+		for c := start; c <= end; c++ {
+			values = append(values, string(c))
+		}
+		return values, nil
+	}
+	if strings.Contains(body, ",") {
+		return strings.Split(body, ","), nil
+	}
+	return nil, nil
+}
+
+// Expand a URL whose host is an IP address and whose path starts with a
+// prefix length.
+func expandCIDR(target string) ([]string, error) {
+	u, err := url.Parse(target)
+	if err != nil || u.Host == "" {
+		return []string{target}, nil
+	}
+	ip := net.ParseIP(u.Hostname())
+	if ip == nil {
+		return []string{target}, nil
+	}
+	segments := strings.SplitN(strings.TrimPrefix(u.Path, "/"), "/", 2)
+	ones, err := strconv.Atoi(segments[0])
+	if err != nil {
+		return []string{target}, nil
+	}
+	_, network, err := net.ParseCIDR(u.Hostname() + "/" + strconv.Itoa(ones))
+	if err != nil {
+		// Just a numeric path
+		return []string{target}, nil
+	}
+	ones, bits := network.Mask.Size()
+	if bits-ones > 16 {
+		return nil, fmt.Errorf("Target %s expands to more than %d targets.", target, maxExpandedTargets)
+	}
+	path := "/"
+	if len(segments) == 2 {
+		path += segments[1]
+	}
+	count := 1 << uint(bits-ones)
+	first, last := 0, count-1
+	if bits == 32 && bits-ones > 1 {
+		first, last = 1, count-2
+	}
+	targets := make([]string, 0, last-first+1)
+	addr := make(net.IP, len(network.IP))
+	copy(addr, network.IP)
+	for i := 0; i < count; i++ {
+		if i >= first && i <= last {
+			expanded := *u
+			expanded.Path = path
+			expanded.RawPath = ""
+			expanded.Host = addr.String()
+			if addr.To4() == nil {
+				expanded.Host = "[" + expanded.Host + "]"
+			}
+			if port := u.Port(); port != "" {
+				expanded.Host += ":" + port
+			}
+			targets = append(targets, expanded.String())
+		}
+		incrementIP(addr)
+	}
+	return targets, nil
+}
+
+func incrementIP(ip net.IP) {
+	for i := len(ip) - 1; i >= 0; i-- {
+		ip[i]++
+		if ip[i] != 0 {
+			return
+		}
+	}
+}
+
+// Split a list of targets on commas outside of braces.
+func splitTargets(value string) []string {
+	var targets []string
+	depth, start := 0, 0
+	for i, c := range value {
+		switch c {
+		case '{':
+			depth++
+		case '}':
+			if depth > 0 {
+				depth--
+			}
+		case ',':
+			if depth == 0 {
+				targets = append(targets, value[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(targets, value[start:])
+}
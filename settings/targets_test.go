@@ -0,0 +1,66 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package settings
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestExpandTarget(t *testing.T) {
+	for target, expected := range map[string]string{
+		"http://example.com/":                "http://example.com/",
+		"https://app{1..3}.example.com":      "https://app1.example.com https://app2.example.com https://app3.example.com",
+		"http://web{08..10}/":                "http://web08/ http://web09/ http://web10/",
+		"http://{a..c}.example.com/{dev,qa}": "http://a.example.com/dev http://a.example.com/qa http://b.example.com/dev http://b.example.com/qa http://c.example.com/dev http://c.example.com/qa",
+		"http://example.com/{id}/":           "http://example.com/{id}/",
+		"http://10.0.0.0/30":                 "http://10.0.0.1/ http://10.0.0.2/",
+		"http://10.0.0.7/31":                 "http://10.0.0.6/ http://10.0.0.7/",
+		"https://192.168.1.0:8443/31/app/":   "https://192.168.1.0:8443/app/ https://192.168.1.1:8443/app/",
+		"http://[fd00::]/127":                "http://[fd00::]/ http://[fd00::1]/",
+		"http://10.0.0.1/":                   "http://10.0.0.1/",
+		"http://10.0.0.1/404":                "http://10.0.0.1/404",
+		"http://10.0.{1..2}.0/31":            "http://10.0.1.0/ http://10.0.1.1/ http://10.0.2.0/ http://10.0.2.1/",
+	} {
+		targets, err := ExpandTarget(target)
+		if err != nil {
+			t.Errorf("Unexpected error expanding %s: %v", target, err)
+			continue
+		}
+		if got := strings.Join(targets, " "); got != expected {
+			t.Errorf("Expected %s to expand to %s, got %s", target, expected, got)
+		}
+	}
+}
+
+func TestExpandTarget_Errors(t *testing.T) {
+	for _, target := range []string{
+		"http://app{9..1}.example.com/",
+		"http://10.0.0.0/8",
+		"http://h{0..99999}/",
+		"http://h{0..999}-{0..999}/",
+	} {
+		if _, err := ExpandTarget(target); err == nil {
+			t.Errorf("Expected error expanding %s", target)
+		}
+	}
+}
+
+func TestSplitTargets(t *testing.T) {
+	got := splitTargets("http://a/,http://{b,c}.example.com/,http://d/")
+	if strings.Join(got, " ") != "http://a/ http://{b,c}.example.com/ http://d/" {
+		t.Errorf("Expected commas in braces to be kept, got %q", got)
+	}
+}
@@ -0,0 +1,453 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package settings
+
+import (
+	"fmt"
+	"github.com/Matir/gobuster/logging"
+	"github.com/Matir/gobuster/wordlist"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// A ValidationError is a problem with the settings and how to fix it.
+type ValidationError struct {
+	Problem string
+	Fix     string
+}
+
+func (e *ValidationError) Error() string {
+	if e.Fix == "" {
+		return e.Problem
+	}
+	return fmt.Sprintf("%s (%s)", e.Problem, e.Fix)
+}
+
+// ValidationErrors are all of the problems found in one pass.
+type ValidationErrors []*ValidationError
+
+func (errs ValidationErrors) Error() string {
+	msgs := make([]string, 0, len(errs))
+	for _, e := range errs {
+		msgs = append(msgs, e.Error())
+	}
+	return fmt.Sprintf("%d problem(s) with settings:\n  %s", len(errs), strings.Join(msgs, "\n  "))
+}
+
+// Proxy schemes supported by the client factory
+var proxySchemes = []string{"socks", "socks4", "socks4a", "socks5", "http", "https"}
+
+// Validate settings, reporting every problem rather than just the first.
+func (settings *ScanSettings) Validate() error {
+	var errs ValidationErrors
+	problem := func(fix string, format string, args ...interface{}) {
+		errs = append(errs, &ValidationError{Problem: fmt.Sprintf(format, args...), Fix: fix})
+	}
+
+	if len(settings.BaseURLs) == 0 && settings.ResumePath == "" {
+		problem("pass one or more URLs as arguments", "URL is required.")
+	}
+	for _, base := range settings.BaseURLs {
+		u, err := url.Parse(base)
+		if err != nil {
+			problem("check the URL for typos", "Unable to parse URL %s: %s", base, err)
+			continue
+		}
+		if u.Scheme != "http" && u.Scheme != "https" {
+			problem("use an http:// or https:// URL", "Unsupported scheme for URL %s.", base)
+		} else if u.Host == "" {
+			problem("include the host, e.g. http://example.com/", "Missing host in URL %s.", base)
+		}
+	}
+
+	if settings.Workers <= 0 {
+		problem("set -workers to 1 or more", "No workers to run.")
+	}
+	if settings.Threads <= 0 {
+		problem("set -threads to 1 or more", "No threads to run.")
+	}
+	if settings.QueueSize <= 0 {
+		problem("set QueueSize to 1 or more in the config file", "Queue size must be positive.")
+	}
+	if settings.Timeout <= 0 {
+		problem("set -timeout to a positive duration such as 30s", "Timeout must be positive.")
+	}
+	if settings.SleepTime < 0 {
+		problem("set -sleep to 0 or a positive duration", "Sleep time is negative.")
+	}
+	if settings.MaxRate < 0 {
+		problem("set -max-rate to 0 or a positive number", "Maximum rate is negative.")
+	}
+	if settings.RateFile != "" && settings.MaxRate <= 0 {
+		problem("add -max-rate or drop -rate-file", "A rate file is given without a rate to share.")
+	}
+	if settings.MaxBodyRead <= 0 {
+		problem("set -max-body-read to a positive size", "Bodies would not be read at all.")
+	}
+	if settings.TarpitLimit > 0 && settings.MinThroughput <= 0 {
+		problem("add -min-throughput or drop -tarpit-limit", "Tarpits are only found by -min-throughput.")
+	}
+	if settings.SampleThreshold > 0 && settings.SampleSize*2 > settings.SampleThreshold {
+		problem("lower -sample-size or raise -sample-threshold", "Sampling %d bytes from each end reads more than the %d byte threshold.", settings.SampleSize, settings.SampleThreshold)
+	}
+
+	if !validLogLevel(settings.LogLevel) {
+		problem(fmt.Sprintf("use one of %s", strings.Join(logging.LogLevelStrings[:], ", ")), "Unknown log level %s.", settings.LogLevel)
+	}
+
+	checkReadable := func(flag, path string) {
+		if path == "" {
+			return
+		}
+		if fp, err := os.Open(path); err != nil {
+			problem(fmt.Sprintf("check the path given to -%s", flag), "Unable to read %s.", path)
+		} else {
+			fp.Close()
+		}
+	}
+	if settings.WordlistPath != "" && !wordlistAvailable(settings.WordlistPath) {
+		problem("use default, short, wordpress, a seclists: alias, a URL or the path to a file", "Unable to read wordlist %s.", settings.WordlistPath)
+	} else if tool, ok := wordlist.Decompressor(settings.WordlistPath); !ok {
+		problem(fmt.Sprintf("install %s or decompress the wordlist first", tool), "Reading wordlist %s needs %s, which isn't installed.", settings.WordlistPath, tool)
+	}
+	checkReadable("result-rules", settings.ResultRulesPath)
+	checkReadable("secret-rules", settings.SecretRulesPath)
+	checkReadable("permutation-rules", settings.PermutationRulesPath)
+	for _, source := range settings.PassiveSources {
+		if source != PassiveCrtSh {
+			checkReadable("passive", source)
+		}
+	}
+	checkReadable("resume", settings.ResumePath)
+	checkReadable("client-cert", settings.ClientCert)
+	checkReadable("client-key", settings.ClientKey)
+	if settings.ClientKey != "" && settings.ClientCert == "" {
+		problem("add -client-cert or drop -client-key", "A client key is given without a client certificate.")
+	}
+	if settings.StateInterval <= 0 && (settings.StatePath != "" || settings.ResumePath != "") {
+		problem("set -state-interval to a positive duration", "State interval must be positive.")
+	}
+	if settings.CompareAnonymous {
+		if settings.Mode != ModeDir {
+			problem("drop -compare-anonymous", "Anonymous requests are only compared in dir mode.")
+		}
+		if len(settings.Credentials) == 0 && len(settings.Headers) == 0 && len(settings.Cookies) == 0 && !settings.KeepCookies && settings.ClientCert == "" {
+			problem("add -auth, -header, -cookie, -cookies or -client-cert", "Nothing to compare anonymous requests against.")
+		}
+	}
+	if settings.DeceptionPause && (settings.StatePath == "" || !settings.DetectDeception) {
+		problem("add -state and -detect-deception, or drop -deception-pause", "Pausing on deception needs detection and a state file to save.")
+	}
+	for _, rtype := range settings.DNSRecordTypes {
+		if !stringInSlice(strings.ToUpper(rtype), RecordTypes) {
+			problem(fmt.Sprintf("use one of %s", strings.Join(RecordTypes, ", ")), "Unknown record type %s.", rtype)
+		}
+	}
+	if (len(settings.DNSRecordTypes) > 0 || settings.ZoneTransfer) && settings.Mode != ModeDNS {
+		problem("add -mode dns or drop -dns-records and -axfr", "DNS record options are given outside dns mode.")
+	}
+	if !stringInSlice(settings.KnowledgeMode, KnowledgeModes) {
+		problem(fmt.Sprintf("use one of %s", strings.Join(KnowledgeModes, ", ")), "Unknown knowledge base mode %s.", settings.KnowledgeMode)
+	} else if settings.KnowledgeMode != KnowledgeRecord && settings.KnowledgePath == "" {
+		problem("add -kb or drop -kb-mode", "Knowledge base mode %s needs a knowledge base.", settings.KnowledgeMode)
+	}
+	if _, _, err := settings.ShardSlice(); err != nil {
+		problem("give -shard as N/M, e.g. 3/10", "%s", err.Error())
+	}
+	if !stringInSlice(settings.Mode, ScanModes) {
+		problem(fmt.Sprintf("use one of %s", strings.Join(ScanModes, ", ")), "Unknown scan mode %s.", settings.Mode)
+	}
+	if settings.BucketKeyword != "" && settings.Mode != ModeS3 && settings.Mode != ModeGCS {
+		problem("add -mode s3 or -mode gcs, or drop -bucket-keyword", "A bucket keyword is given outside a bucket mode.")
+	}
+	if settings.FrequentFirst && settings.Mode != ModeDir && settings.Mode != ModeFuzz {
+		problem("add -mode dir or -mode fuzz, or drop -frequent-first", "Common paths can't be tried first outside dir or fuzz mode.")
+	}
+	if settings.Permute && settings.Mode != ModeDNS && settings.Mode != ModeVHost {
+		problem("add -mode dns or -mode vhost, or drop -permute", "Permutations are given outside dns or vhost mode.")
+	}
+	if len(settings.PassiveSources) > 0 && settings.Mode != ModeDNS && settings.Mode != ModeVHost {
+		problem("add -mode dns or -mode vhost, or drop -passive", "Passive sources are given outside dns or vhost mode.")
+	}
+	if settings.PermutationRulesPath != "" && !settings.Permute {
+		problem("add -permute or drop -permutation-rules", "Permutation rules are given but permutations are off.")
+	}
+	if settings.Mode == ModeFuzz {
+		for _, header := range settings.FuzzHeaders {
+			if !strings.Contains(header, ":") {
+				problem("use Name: value", "Invalid fuzz header %s.", header)
+			}
+		}
+		if !settings.FuzzesRequest() {
+			for _, base := range settings.BaseURLs {
+				if !strings.Contains(base, FuzzMarker) {
+					problem("put FUZZ in the URL, a -fuzz-header or -fuzz-data", "Nowhere to fuzz in %s.", base)
+				}
+			}
+		}
+	} else if settings.FuzzMethod != "" || len(settings.FuzzHeaders) > 0 || settings.FuzzData != "" {
+		problem("add -mode fuzz or drop the -fuzz- flags", "Fuzzing options are given outside fuzz mode.")
+	}
+	if settings.Mode != ModeDir && (settings.Method != "" || settings.Data != "") {
+		problem("use -fuzz-method and -fuzz-data in fuzz mode, or drop -method and -data", "Request body options are given outside dir mode.")
+	}
+	for _, method := range []string{settings.Method, settings.FuzzMethod} {
+		if method != "" && !validMethod(method) {
+			problem("use a method such as HEAD, GET, POST or OPTIONS", "Invalid HTTP method %s.", method)
+		}
+	}
+	if settings.Method == http.MethodHead && settings.Data != "" || settings.FuzzMethod == http.MethodHead && settings.FuzzData != "" {
+		problem("use another method or drop the request body", "HEAD requests can't carry a body.")
+	}
+	if settings.Method == http.MethodHead {
+		// Only the headers of each response are read
+		var needBodies []string
+		if settings.SaveBodiesPath != "" {
+			needBodies = append(needBodies, "-save-bodies")
+		}
+		if settings.ScanSecrets {
+			needBodies = append(needBodies, "-secrets")
+		}
+		if settings.SourceMaps {
+			needBodies = append(needBodies, "-sourcemaps")
+		}
+		if settings.ExtractMetadata {
+			needBodies = append(needBodies, "-extract-metadata")
+		}
+		if len(needBodies) > 0 {
+			problem("use -method GET or drop "+strings.Join(needBodies, ", "), "Responses to HEAD have no body for %s.", strings.Join(needBodies, ", "))
+		}
+	}
+	if settings.VHostDomain != "" {
+		if settings.Mode != ModeVHost {
+			problem("add -mode vhost or drop -vhost-domain", "A virtual host domain is given outside vhost mode.")
+		} else if len(settings.BaseURLs) > 1 {
+			problem("scan one target at a time with -vhost-domain", "Targets would share virtual host names under %s.", settings.VHostDomain)
+		}
+	}
+	if settings.SecretRulesPath != "" && !settings.ScanSecrets {
+		problem("add -secrets or drop -secret-rules", "Secret rules are given but secret scanning is off.")
+	}
+	for _, pattern := range settings.RedactPatterns {
+		if _, err := regexp.Compile(pattern); err != nil {
+			problem("check the -redact-pattern regex", "Invalid redaction pattern %s: %s", pattern, err)
+		}
+	}
+
+	for _, proxy := range settings.Proxies {
+		settings.validateProxy(proxy, problem)
+	}
+	if !stringInSlice(settings.HTTP2, HTTP2Modes) {
+		problem(fmt.Sprintf("use one of %s", strings.Join(HTTP2Modes, ", ")), "Unknown HTTP/2 mode %s.", settings.HTTP2)
+	}
+	if !stringInSlice(settings.ProxyRotation, ProxyRotations) {
+		problem(fmt.Sprintf("use one of %s", strings.Join(ProxyRotations, ", ")), "Unknown proxy rotation %s.", settings.ProxyRotation)
+	}
+	if settings.HTTP3 && len(settings.Proxies) > 0 {
+		problem("drop -http3 or -proxy", "HTTP/3 can't go through a proxy.")
+	}
+	if len(settings.Bind) > 0 && len(settings.Proxies) > 0 {
+		problem("drop -bind or -proxy", "Local addresses only apply to direct connections, not through a proxy.")
+	}
+	if settings.UnixSocket != "" {
+		if info, err := os.Stat(settings.UnixSocket); err != nil || info.Mode()&os.ModeSocket == 0 {
+			problem("check the path given to -unix-socket", "No Unix socket at %s.", settings.UnixSocket)
+		}
+		if len(settings.Proxies) > 0 || len(settings.Bind) > 0 || settings.HTTP3 {
+			problem("drop -proxy, -bind and -http3", "Requests to a Unix socket go straight to it.")
+		}
+		if settings.Mode == ModeDNS || settings.Mode == ModeS3 || settings.Mode == ModeGCS {
+			problem("use dir, vhost or fuzz mode", "A Unix socket can't be scanned in %s mode.", settings.Mode)
+		}
+	}
+
+	if len(outputFormats) > 0 && !knownOutputFormat(settings.OutputFormat) {
+		problem(fmt.Sprintf("use one of %s", strings.Join(outputFormats, ", ")), "Unknown output format %s.", settings.OutputFormat)
+	}
+	for _, extra := range settings.ExtraOutputs {
+		pieces := strings.SplitN(extra, ":", 2)
+		if len(pieces) != 2 || pieces[1] == "" {
+			problem("use format:path, e.g. csv:results.csv", "Invalid extra output %s.", extra)
+		} else if len(outputFormats) > 0 && !knownOutputFormat(pieces[0]) {
+			problem(fmt.Sprintf("use one of %s", strings.Join(outputFormats, ", ")), "Unknown output format %s.", pieces[0])
+		}
+	}
+
+	if settings.Events == "-" && settings.OutputPath == "" {
+		problem("set -outfile or send -events elsewhere", "Events and results would both be written to stdout.")
+	}
+
+	for _, header := range settings.Headers {
+		name := strings.TrimSpace(strings.SplitN(header, ":", 2)[0])
+		if !strings.Contains(header, ":") || name == "" || strings.ContainsAny(name, " \t") {
+			problem("use Name: value", "Invalid header %s.", header)
+		}
+	}
+	if settings.Host != "" {
+		if u, err := url.Parse("//" + settings.Host); err != nil || u.Host != settings.Host || u.Hostname() == "" {
+			problem("give -host as a hostname, optionally with a port", "Invalid host %s.", settings.Host)
+		}
+		for _, header := range settings.Headers {
+			if strings.EqualFold(strings.TrimSpace(strings.SplitN(header, ":", 2)[0]), "Host") {
+				problem("drop -host or the Host -header", "The Host header is given twice.")
+			}
+		}
+		if settings.Mode != ModeDir && settings.Mode != ModeFuzz {
+			problem("add -mode dir or -mode fuzz, or drop -host", "The Host header can't be fixed in %s mode.", settings.Mode)
+		}
+	}
+	for _, entry := range settings.Cookies {
+		for _, part := range strings.Split(entry, ";") {
+			if part = strings.TrimSpace(part); part != "" && (!strings.Contains(part, "=") || strings.TrimSpace(strings.SplitN(part, "=", 2)[0]) == "") {
+				problem("use name=value", "Invalid cookie %s.", part)
+			}
+		}
+	}
+	for _, entry := range settings.Credentials {
+		pieces := strings.SplitN(entry, "=", 2)
+		if len(pieces) == 1 {
+			pieces = []string{"*", entry}
+		}
+		if pieces[0] == "" || !strings.Contains(pieces[1], ":") {
+			problem("use host=user:password or user:password", "Invalid credentials %s.", entry)
+		}
+	}
+
+	if hb := settings.Heartbeat; hb != "" {
+		if !strings.HasPrefix(hb, "file:") && !strings.HasPrefix(hb, "http://") && !strings.HasPrefix(hb, "https://") && hb != "systemd" {
+			problem("use file:PATH, an http(s) URL or systemd", "Unknown heartbeat target %s.", hb)
+		}
+		if settings.HeartbeatInterval <= 0 {
+			problem("set -heartbeat-interval to a positive duration", "Heartbeat interval must be positive.")
+		}
+	}
+
+	if settings.MaxWords < 0 {
+		problem("set -max-words to 0 or more", "Negative number of words.")
+	}
+	if len(settings.Phases) > 0 {
+		if settings.Mode != ModeDir {
+			problem("add -mode dir, or drop the phases from the config file", "Scans in phases are only for dir mode.")
+		}
+		if settings.StatePath != "" || settings.ResumePath != "" {
+			problem("drop -state and -resume, or the phases from the config file", "Scans in phases can't be saved or resumed.")
+		}
+		// Each phase is checked with its values once the rest is valid
+		if len(errs) == 0 && settings.phase == "" {
+			for _, phase := range settings.Phases {
+				restore, err := settings.ApplyPhase(phase)
+				if err != nil {
+					problem("check the phase in the config file", "%s.", err.Error())
+					continue
+				}
+				err = settings.Validate()
+				restore()
+				if phaseErrs, ok := err.(ValidationErrors); ok {
+					for _, e := range phaseErrs {
+						problem(e.Fix, "Phase %s: %s", phase.Name, e.Problem)
+					}
+				}
+			}
+		}
+	}
+
+	if len(errs) == 0 {
+		return nil
+	}
+	if len(settings.BaseURLs) == 0 && settings.ResumePath == "" {
+		os.Stderr.WriteString("Usage:\n")
+		settings.flagSet().PrintDefaults()
+	}
+	return errs
+}
+
+// Check that a proxy parses and accepts connections.
+func (settings *ScanSettings) validateProxy(proxy string, problem func(string, string, ...interface{})) {
+	u, err := url.Parse(proxy)
+	if err != nil {
+		problem("use scheme://host:port, e.g. socks5://127.0.0.1:1080", "Unable to parse proxy %s.", proxy)
+		return
+	}
+	if !stringInSlice(u.Scheme, proxySchemes) {
+		problem(fmt.Sprintf("use one of %s", strings.Join(proxySchemes, ", ")), "Invalid proxy protocol %s.", u.Scheme)
+		return
+	}
+	if u.Host == "" {
+		problem("use scheme://host:port, e.g. socks5://127.0.0.1:1080", "Missing host for proxy %s.", proxy)
+		return
+	}
+	// HTTP proxies default to the scheme's port, as in the client
+	addr := u.Host
+	if u.Port() == "" && u.Scheme == "http" {
+		addr = net.JoinHostPort(u.Hostname(), "80")
+	} else if u.Port() == "" && u.Scheme == "https" {
+		addr = net.JoinHostPort(u.Hostname(), "443")
+	}
+	conn, err := net.DialTimeout("tcp", addr, settings.Timeout)
+	if err != nil {
+		problem("check the proxy is running or drop it from -proxy or -proxy-file", "Unable to reach proxy %s: %s", proxy, err)
+		return
+	}
+	conn.Close()
+}
+
+// Whether path names a built-in or remote wordlist or a readable file.
+func wordlistAvailable(path string) bool {
+	if _, err := wordlist.LoadBuiltinWordlist(path); err == nil || wordlist.IsRemote(path) {
+		return true
+	}
+	fp, err := os.Open(path)
+	if err != nil {
+		return false
+	}
+	fp.Close()
+	return true
+}
+
+// Whether method is a valid HTTP method token.
+func validMethod(method string) bool {
+	for _, c := range method {
+		if c > '~' || c <= ' ' || strings.ContainsRune(`"(),/:;<=>?@[\]{}`, c) {
+			return false
+		}
+	}
+	return method != ""
+}
+
+func validLogLevel(level string) bool {
+	for _, l := range logging.LogLevelStrings {
+		if strings.EqualFold(l, level) {
+			return true
+		}
+	}
+	return false
+}
+
+func knownOutputFormat(format string) bool {
+	return stringInSlice(format, outputFormats)
+}
+
+func stringInSlice(s string, slice []string) bool {
+	for _, v := range slice {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
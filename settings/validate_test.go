@@ -0,0 +1,576 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package settings
+
+import (
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func validSettings() *ScanSettings {
+	s := testScanSettings()
+	s.BaseURLs = []string{"http://localhost/"}
+	s.Workers = 1
+	return s
+}
+
+func TestValidate_Defaults(t *testing.T) {
+	if err := validSettings().Validate(); err != nil {
+		t.Errorf("Expected no error, got %s", err)
+	}
+}
+
+func TestValidate_ReportsAllProblems(t *testing.T) {
+	s := validSettings()
+	s.BaseURLs = []string{"ftp://localhost/", "http://"}
+	s.Workers = 0
+	s.Timeout = 0
+	s.WordlistPath = filepath.Join(os.TempDir(), "gobuster-no-such-wordlist")
+	s.SecretRulesPath = s.WordlistPath
+	s.Credentials = []string{"example.com=nopassword"}
+	s.ExtraOutputs = []string{"csv"}
+	s.Heartbeat = "carrier-pigeon"
+	err := s.Validate()
+	errs, ok := err.(ValidationErrors)
+	if !ok {
+		t.Fatalf("Expected ValidationErrors, got %v", err)
+	}
+	// Two URLs, workers, timeout, wordlist, secret rules (twice), credentials,
+	// extra output and heartbeat.
+	if len(errs) != 10 {
+		t.Errorf("Expected 10 problems, got %d: %s", len(errs), err)
+	}
+	for _, e := range errs {
+		if e.Fix == "" {
+			t.Errorf("Expected a fix for %s", e.Problem)
+		}
+	}
+}
+
+func TestValidate_Wordlist(t *testing.T) {
+	s := validSettings()
+	s.WordlistPath = "short"
+	if err := s.Validate(); err != nil {
+		t.Errorf("Expected built-in wordlist to be valid, got %s", err)
+	}
+	fp, err := ioutil.TempFile("", "gobuster-wordlist")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fp.Close()
+	defer os.Remove(fp.Name())
+	s.WordlistPath = fp.Name()
+	if err := s.Validate(); err != nil {
+		t.Errorf("Expected readable wordlist to be valid, got %s", err)
+	}
+
+	zst := fp.Name() + ".zst"
+	if err := ioutil.WriteFile(zst, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(zst)
+	defer os.Setenv("PATH", os.Getenv("PATH"))
+	os.Setenv("PATH", "")
+	s.WordlistPath = zst
+	if err := s.Validate(); err == nil || !strings.Contains(err.Error(), "needs zstd") {
+		t.Errorf("Expected missing decompressor, got %v", err)
+	}
+}
+
+func TestValidate_KnowledgeMode(t *testing.T) {
+	s := validSettings()
+	s.KnowledgeMode = KnowledgeGaps
+	if err := s.Validate(); err == nil || !strings.Contains(err.Error(), "needs a knowledge base") {
+		t.Errorf("Expected missing knowledge base, got %v", err)
+	}
+	s.KnowledgePath = filepath.Join(os.TempDir(), "gobuster-kb.json")
+	if err := s.Validate(); err != nil {
+		t.Errorf("Expected no error, got %s", err)
+	}
+	s.KnowledgeMode = "forget"
+	if err := s.Validate(); err == nil || !strings.Contains(err.Error(), "Unknown knowledge base mode") {
+		t.Errorf("Expected unknown mode, got %v", err)
+	}
+}
+
+func TestValidate_Mode(t *testing.T) {
+	s := validSettings()
+	s.Mode = ModeDNS
+	if err := s.Validate(); err != nil {
+		t.Errorf("Expected no error, got %s", err)
+	}
+	s.VHostDomain = "example.com"
+	if err := s.Validate(); err == nil || !strings.Contains(err.Error(), "outside vhost mode") {
+		t.Errorf("Expected domain outside vhost mode, got %v", err)
+	}
+	s.Mode = ModeVHost
+	if err := s.Validate(); err != nil {
+		t.Errorf("Expected no error, got %s", err)
+	}
+	s.BaseURLs = append(s.BaseURLs, "http://10.0.0.2/")
+	if err := s.Validate(); err == nil || !strings.Contains(err.Error(), "share virtual host names") {
+		t.Errorf("Expected shared names, got %v", err)
+	}
+	s.BucketKeyword = "acme"
+	if err := s.Validate(); err == nil || !strings.Contains(err.Error(), "outside a bucket mode") {
+		t.Errorf("Expected keyword outside s3 mode, got %v", err)
+	}
+	s.Mode = "subdir"
+	if err := s.Validate(); err == nil || !strings.Contains(err.Error(), "Unknown scan mode") {
+		t.Errorf("Expected unknown mode, got %v", err)
+	}
+}
+
+func TestValidate_FrequentFirst(t *testing.T) {
+	s := validSettings()
+	s.FrequentFirst = true
+	if err := s.Validate(); err != nil {
+		t.Errorf("Expected no error, got %s", err)
+	}
+	s.Mode = ModeDNS
+	if err := s.Validate(); err == nil || !strings.Contains(err.Error(), "outside dir or fuzz mode") {
+		t.Errorf("Expected -frequent-first outside dir mode to be invalid, got %v", err)
+	}
+}
+
+func TestValidate_Permute(t *testing.T) {
+	s := validSettings()
+	s.Permute = true
+	if err := s.Validate(); err == nil || !strings.Contains(err.Error(), "outside dns or vhost mode") {
+		t.Errorf("Expected permutations outside dns mode, got %v", err)
+	}
+	s.Mode = ModeDNS
+	s.PermutationRulesPath = "validate_test.go"
+	if err := s.Validate(); err != nil {
+		t.Errorf("Expected no error, got %s", err)
+	}
+	s.Permute = false
+	if err := s.Validate(); err == nil || !strings.Contains(err.Error(), "permutations are off") {
+		t.Errorf("Expected rules without -permute, got %v", err)
+	}
+	s.Permute = true
+	s.PermutationRulesPath = filepath.Join(os.TempDir(), "gobuster-no-such-rules")
+	if err := s.Validate(); err == nil || !strings.Contains(err.Error(), "-permutation-rules") {
+		t.Errorf("Expected unreadable rules, got %v", err)
+	}
+}
+
+func TestValidate_Passive(t *testing.T) {
+	s := validSettings()
+	s.PassiveSources = []string{PassiveCrtSh, "validate_test.go"}
+	if err := s.Validate(); err == nil || !strings.Contains(err.Error(), "outside dns or vhost mode") {
+		t.Errorf("Expected passive sources outside dns mode, got %v", err)
+	}
+	s.Mode = ModeVHost
+	if err := s.Validate(); err != nil {
+		t.Errorf("Expected no error, got %s", err)
+	}
+	s.PassiveSources = append(s.PassiveSources, filepath.Join(os.TempDir(), "gobuster-no-such-subdomains"))
+	if err := s.Validate(); err == nil || !strings.Contains(err.Error(), "-passive") {
+		t.Errorf("Expected unreadable source, got %v", err)
+	}
+}
+
+func TestValidate_DNSRecords(t *testing.T) {
+	s := validSettings()
+	s.DNSRecordTypes = []string{"mx", "TXT"}
+	s.ZoneTransfer = true
+	if err := s.Validate(); err == nil || !strings.Contains(err.Error(), "outside dns mode") {
+		t.Errorf("Expected record options outside dns mode, got %v", err)
+	}
+	s.Mode = ModeDNS
+	if err := s.Validate(); err != nil {
+		t.Errorf("Expected no error, got %s", err)
+	}
+	s.DNSRecordTypes = []string{"HINFO"}
+	if err := s.Validate(); err == nil || !strings.Contains(err.Error(), "Unknown record type HINFO") {
+		t.Errorf("Expected unknown record type, got %v", err)
+	}
+}
+
+func TestValidate_Fuzz(t *testing.T) {
+	s := validSettings()
+	s.FuzzData = "user=FUZZ"
+	if err := s.Validate(); err == nil || !strings.Contains(err.Error(), "outside fuzz mode") {
+		t.Errorf("Expected fuzzing options outside fuzz mode, got %v", err)
+	}
+	s.Mode = ModeFuzz
+	if err := s.Validate(); err != nil {
+		t.Errorf("Expected no error, got %s", err)
+	}
+	s.FuzzData = ""
+	if err := s.Validate(); err == nil || !strings.Contains(err.Error(), "Nowhere to fuzz") {
+		t.Errorf("Expected nowhere to fuzz, got %v", err)
+	}
+	s.BaseURLs = []string{"http://localhost/FUZZ"}
+	s.FuzzHeaders = []string{"X-Broken"}
+	if err := s.Validate(); err == nil || !strings.Contains(err.Error(), "Invalid fuzz header") {
+		t.Errorf("Expected invalid header, got %v", err)
+	}
+}
+
+func TestValidate_Body(t *testing.T) {
+	s := validSettings()
+	s.Method = "PUT"
+	s.Data = "name=FUZZ"
+	if err := s.Validate(); err != nil {
+		t.Errorf("Expected no error, got %s", err)
+	}
+	s.Mode = ModeFuzz
+	s.BaseURLs = []string{"http://localhost/FUZZ"}
+	if err := s.Validate(); err == nil || !strings.Contains(err.Error(), "outside dir mode") {
+		t.Errorf("Expected body options outside dir mode, got %v", err)
+	}
+}
+
+func TestValidate_TarpitLimit(t *testing.T) {
+	s := validSettings()
+	s.TarpitLimit = 3
+	if err := s.Validate(); err == nil || !strings.Contains(err.Error(), "-min-throughput") {
+		t.Errorf("Expected tarpit limit to need a throughput, got %v", err)
+	}
+	s.MinThroughput = 128
+	if err := s.Validate(); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+}
+
+func TestValidate_MaxBodyRead(t *testing.T) {
+	s := validSettings()
+	s.MaxBodyRead = 0
+	if err := s.Validate(); err == nil || !strings.Contains(err.Error(), "not be read at all") {
+		t.Errorf("Expected bodies not read, got %v", err)
+	}
+}
+
+func TestValidate_Method(t *testing.T) {
+	s := validSettings()
+	for _, method := range []string{"HEAD", "GET", "POST", "OPTIONS", "PROPFIND"} {
+		s.Method = method
+		if err := s.Validate(); err != nil {
+			t.Errorf("Expected no error for %s, got %s", method, err)
+		}
+	}
+	s.Method = "GET /"
+	if err := s.Validate(); err == nil || !strings.Contains(err.Error(), "Invalid HTTP method") {
+		t.Errorf("Expected invalid method, got %v", err)
+	}
+	s.Method = "HEAD"
+	s.Data = "name=FUZZ"
+	if err := s.Validate(); err == nil || !strings.Contains(err.Error(), "can't carry a body") {
+		t.Errorf("Expected no body with HEAD, got %v", err)
+	}
+	s.Data = ""
+	s.ScanSecrets = true
+	s.SourceMaps = true
+	if err := s.Validate(); err == nil || !strings.Contains(err.Error(), "no body for -secrets, -sourcemaps") {
+		t.Errorf("Expected no bodies to scan, got %v", err)
+	}
+}
+
+func TestValidate_Headers(t *testing.T) {
+	s := validSettings()
+	s.Headers = []string{"X-Api-Key: abc", "X-Empty:"}
+	if err := s.Validate(); err != nil {
+		t.Errorf("Expected no error, got %s", err)
+	}
+	s.Headers = []string{"X-Api-Key"}
+	if err := s.Validate(); err == nil || !strings.Contains(err.Error(), "Invalid header") {
+		t.Errorf("Expected invalid header, got %v", err)
+	}
+}
+
+func TestValidate_Credentials(t *testing.T) {
+	s := validSettings()
+	s.Credentials = []string{"admin:secret", "example.com=user:pass"}
+	if err := s.Validate(); err != nil {
+		t.Errorf("Expected no error, got %s", err)
+	}
+	s.Credentials = []string{"admin"}
+	if err := s.Validate(); err == nil || !strings.Contains(err.Error(), "Invalid credentials") {
+		t.Errorf("Expected invalid credentials, got %v", err)
+	}
+}
+
+func TestValidate_ClientCert(t *testing.T) {
+	s := validSettings()
+	s.ClientKey = filepath.Join(os.TempDir(), "gobuster-no-such-key")
+	err := s.Validate()
+	if errs, ok := err.(ValidationErrors); !ok || len(errs) != 2 {
+		t.Fatalf("Expected 2 problems, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "without a client certificate") || !strings.Contains(err.Error(), "Unable to read") {
+		t.Errorf("Expected unreadable key without a certificate, got %s", err)
+	}
+}
+
+func TestValidate_Cookies(t *testing.T) {
+	s := validSettings()
+	s.Cookies = []string{"session=abc; theme=dark;"}
+	if err := s.Validate(); err != nil {
+		t.Errorf("Expected no error, got %s", err)
+	}
+	s.Cookies = []string{"session=abc; theme"}
+	if err := s.Validate(); err == nil || !strings.Contains(err.Error(), "Invalid cookie theme") {
+		t.Errorf("Expected invalid cookie, got %v", err)
+	}
+}
+
+func TestValidate_CompareAnonymous(t *testing.T) {
+	s := validSettings()
+	s.CompareAnonymous = true
+	if err := s.Validate(); err == nil || !strings.Contains(err.Error(), "Nothing to compare") {
+		t.Errorf("Expected nothing to compare, got %v", err)
+	}
+	s.Headers = []string{"Authorization: Bearer abc"}
+	if err := s.Validate(); err != nil {
+		t.Errorf("Expected no error, got %s", err)
+	}
+	s.Mode = ModeDNS
+	if err := s.Validate(); err == nil || !strings.Contains(err.Error(), "only compared in dir mode") {
+		t.Errorf("Expected dir mode only, got %v", err)
+	}
+}
+
+func TestValidate_DeceptionPause(t *testing.T) {
+	s := validSettings()
+	s.DeceptionPause = true
+	if err := s.Validate(); err == nil || !strings.Contains(err.Error(), "Pausing on deception") {
+		t.Errorf("Expected pausing without a state file to be invalid, got %v", err)
+	}
+	s.StatePath = filepath.Join(os.TempDir(), "gobuster-state.json")
+	if err := s.Validate(); err != nil {
+		t.Errorf("Expected no error, got %s", err)
+	}
+}
+
+func TestValidate_Rate(t *testing.T) {
+	s := validSettings()
+	s.RateFile = filepath.Join(os.TempDir(), "gobuster-rate.json")
+	if err := s.Validate(); err == nil || !strings.Contains(err.Error(), "without a rate to share") {
+		t.Errorf("Expected rate file without a rate, got %v", err)
+	}
+	s.MaxRate = 2.5
+	if err := s.Validate(); err != nil {
+		t.Errorf("Expected no error, got %s", err)
+	}
+	s.MaxRate = -1
+	if err := s.Validate(); err == nil || !strings.Contains(err.Error(), "rate is negative") {
+		t.Errorf("Expected negative rate, got %v", err)
+	}
+}
+
+func TestValidate_Shard(t *testing.T) {
+	s := validSettings()
+	s.Shard = "3/10"
+	if err := s.Validate(); err != nil {
+		t.Errorf("Expected no error, got %s", err)
+	}
+	s.Shard = "11/10"
+	if err := s.Validate(); err == nil || !strings.Contains(err.Error(), "Invalid shard") {
+		t.Errorf("Expected invalid shard, got %v", err)
+	}
+}
+
+func TestValidate_Proxy(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := l.Addr().String()
+	s := validSettings()
+	s.Timeout = time.Second
+	s.Proxies = []string{"socks5://" + addr}
+	if err := s.Validate(); err != nil {
+		t.Errorf("Expected listening proxy to be valid, got %s", err)
+	}
+	s.Proxies = []string{"http://" + addr}
+	if err := s.Validate(); err != nil {
+		t.Errorf("Expected listening HTTP proxy to be valid, got %s", err)
+	}
+	l.Close()
+	s.Proxies = []string{"socks5://" + addr, "ftp://" + addr}
+	err = s.Validate()
+	if errs, ok := err.(ValidationErrors); !ok || len(errs) != 2 {
+		t.Fatalf("Expected 2 problems, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "Unable to reach proxy") {
+		t.Errorf("Expected unreachable proxy, got %s", err)
+	}
+	if !strings.Contains(err.Error(), "Invalid proxy protocol ftp") {
+		t.Errorf("Expected invalid protocol, got %s", err)
+	}
+}
+
+func TestValidate_HTTP2(t *testing.T) {
+	s := validSettings()
+	s.HTTP2 = HTTP2PriorKnowledge
+	if err := s.Validate(); err != nil {
+		t.Errorf("Expected no error, got %s", err)
+	}
+	s.HTTP2 = "h3"
+	if err := s.Validate(); err == nil || !strings.Contains(err.Error(), "Unknown HTTP/2 mode") {
+		t.Errorf("Expected unknown HTTP/2 mode, got %v", err)
+	}
+}
+
+func TestValidate_HTTP3(t *testing.T) {
+	s := validSettings()
+	s.HTTP3 = true
+	if err := s.Validate(); err != nil {
+		t.Errorf("Expected no error, got %s", err)
+	}
+	s.Proxies = []string{"socks5://127.0.0.1:1080"}
+	if err := s.Validate(); err == nil || !strings.Contains(err.Error(), "HTTP/3 can't go through a proxy") {
+		t.Errorf("Expected HTTP/3 proxy error, got %v", err)
+	}
+}
+
+func TestValidate_RedactPatterns(t *testing.T) {
+	s := validSettings()
+	s.RedactPatterns = []string{`employee=(\d+)`}
+	if err := s.Validate(); err != nil {
+		t.Errorf("Expected no error, got %s", err)
+	}
+	s.RedactPatterns = append(s.RedactPatterns, "(")
+	if err := s.Validate(); err == nil || !strings.Contains(err.Error(), "Invalid redaction pattern (") {
+		t.Errorf("Expected invalid redaction pattern, got %v", err)
+	}
+}
+
+func TestValidate_ProxyRotation(t *testing.T) {
+	s := validSettings()
+	s.ProxyRotation = ProxyPerRequest
+	if err := s.Validate(); err != nil {
+		t.Errorf("Expected no error, got %s", err)
+	}
+	s.ProxyRotation = "random"
+	if err := s.Validate(); err == nil || !strings.Contains(err.Error(), "Unknown proxy rotation") {
+		t.Errorf("Expected unknown proxy rotation, got %v", err)
+	}
+}
+
+func TestValidate_Bind(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	s := validSettings()
+	s.Bind = []string{"127.0.0.1"}
+	if err := s.Validate(); err != nil {
+		t.Errorf("Expected no error, got %s", err)
+	}
+	s.Proxies = []string{"socks5://" + l.Addr().String()}
+	if err := s.Validate(); err == nil || !strings.Contains(err.Error(), "only apply to direct connections") {
+		t.Errorf("Expected bind with proxy to be invalid, got %v", err)
+	}
+}
+
+func TestValidate_UnixSocket(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gobuster-unix")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "app.sock")
+	l, err := net.Listen("unix", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	s := validSettings()
+	s.UnixSocket = path
+	if err := s.Validate(); err != nil {
+		t.Errorf("Expected no error, got %s", err)
+	}
+	s.HTTP3 = true
+	if err := s.Validate(); err == nil || !strings.Contains(err.Error(), "go straight to it") {
+		t.Errorf("Expected a Unix socket with -http3 to be invalid, got %v", err)
+	}
+	s = validSettings()
+	s.UnixSocket = filepath.Join(dir, "missing.sock")
+	if err := s.Validate(); err == nil || !strings.Contains(err.Error(), "No Unix socket") {
+		t.Errorf("Expected a missing socket to be invalid, got %v", err)
+	}
+}
+
+func TestValidate_Host(t *testing.T) {
+	s := validSettings()
+	s.BaseURLs = []string{"https://203.0.113.10/"}
+	s.Host = "www.example.com"
+	if err := s.Validate(); err != nil {
+		t.Errorf("Expected no error, got %s", err)
+	}
+	s.Host = "www.example.com:8443"
+	if err := s.Validate(); err != nil {
+		t.Errorf("Expected no error with a port, got %s", err)
+	}
+	for _, bad := range []string{"https://www.example.com/", "www.example.com/admin", ":8443"} {
+		s.Host = bad
+		if err := s.Validate(); err == nil || !strings.Contains(err.Error(), "Invalid host") {
+			t.Errorf("Expected %s to be invalid, got %v", bad, err)
+		}
+	}
+	s.Host = "www.example.com"
+	s.Headers = []string{"host: other.example.com"}
+	if err := s.Validate(); err == nil || !strings.Contains(err.Error(), "given twice") {
+		t.Errorf("Expected -host with a Host header to be invalid, got %v", err)
+	}
+	s.Headers = nil
+	s.Mode = ModeVHost
+	if err := s.Validate(); err == nil || !strings.Contains(err.Error(), "can't be fixed in vhost mode") {
+		t.Errorf("Expected -host in vhost mode to be invalid, got %v", err)
+	}
+}
+
+func TestValidate_Phases(t *testing.T) {
+	s := validSettings()
+	s.Phases = []Phase{
+		{Name: "quick", Values: Profile{"max-words": "1000", "extensions": ""}},
+		{Name: "full", Values: Profile{"wordlist": "short"}},
+	}
+	if err := s.Validate(); err != nil {
+		t.Errorf("Expected no error, got %s", err)
+	}
+	s.Phases[1].Values["wordlist"] = "gobuster-no-such-wordlist"
+	if err := s.Validate(); err == nil || !strings.Contains(err.Error(), "Phase full: ") {
+		t.Errorf("Expected the full phase to be invalid, got %v", err)
+	}
+	if s.WordlistPath != "" {
+		t.Errorf("Expected validating phases to leave the settings alone, got wordlist %s", s.WordlistPath)
+	}
+	s.Phases[1].Values = Profile{"workers": "2"}
+	if err := s.Validate(); err == nil || !strings.Contains(err.Error(), "can't change between phases") {
+		t.Errorf("Expected workers in a phase to be invalid, got %v", err)
+	}
+	s.Phases[1].Values = nil
+	s.StatePath = filepath.Join(os.TempDir(), "gobuster-state")
+	if err := s.Validate(); err == nil || !strings.Contains(err.Error(), "can't be saved or resumed") {
+		t.Errorf("Expected phases with -state to be invalid, got %v", err)
+	}
+}
+
+func TestValidationError(t *testing.T) {
+	e := &ValidationError{Problem: "No workers to run.", Fix: "set -workers to 1 or more"}
+	if e.Error() != "No workers to run. (set -workers to 1 or more)" {
+		t.Errorf("Unexpected error string: %s", e.Error())
+	}
+}
@@ -0,0 +1,182 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package settings
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// A question asked by the wizard, answered with the value of a flag.
+type wizardQuestion struct {
+	flag   string
+	prompt string
+	help   string
+	check  func(string) error
+}
+
+var wizardQuestions = []wizardQuestion{
+	{
+		flag:   "url",
+		prompt: "Target URL",
+		help:   "Where to start scanning, e.g. https://example.com/.  Separate several with commas.",
+		check:  checkWizardURLs,
+	},
+	{
+		flag:   "wordlist",
+		prompt: "Wordlist",
+		help:   "A built-in list (default, short, wordpress) or the path to a file.",
+		check: func(value string) error {
+			if !wordlistAvailable(value) {
+				return fmt.Errorf("No built-in wordlist or readable file named %s.", value)
+			}
+			return nil
+		},
+	},
+	{
+		flag:   "workers",
+		prompt: "Concurrent requests",
+		help:   "Fewer is gentler on the target.",
+	},
+	{
+		flag:   "sleep",
+		prompt: "Delay between requests",
+		help:   "A duration such as 500ms or 2s, per worker.",
+	},
+	{
+		flag:   "extensions",
+		prompt: "Extensions",
+		help:   "Comma-separated extensions to try on each word, e.g. php,html.",
+	},
+	{
+		flag:   "exclude",
+		prompt: "Paths to exclude",
+		help:   "Comma-separated paths never to request, e.g. /logout.",
+	},
+	{
+		flag:   "spider-codes",
+		prompt: "Status codes to spider",
+		help:   "Comma-separated HTTP status codes whose pages are parsed for links.",
+	},
+}
+
+// Path of the per-user config file, or "" if there is none on this platform.
+func UserConfigPath() string {
+	if len(defaultConfigPaths) == 0 {
+		return ""
+	}
+	return defaultConfigPaths[0]
+}
+
+// Interactively build a config file at path.  Each answer is checked by
+// setting the corresponding flag, and blank answers keep the default.
+func RunWizard(in io.Reader, out io.Writer, path string) error {
+	if path == "" {
+		return fmt.Errorf("No config file path for this platform.")
+	}
+	scanner := bufio.NewScanner(in)
+	ask := func(prompt string) (string, error) {
+		fmt.Fprintf(out, "%s: ", prompt)
+		if !scanner.Scan() {
+			if err := scanner.Err(); err != nil {
+				return "", err
+			}
+			return "", fmt.Errorf("Wizard cancelled.")
+		}
+		return strings.TrimSpace(scanner.Text()), nil
+	}
+
+	fmt.Fprintf(out, "This will write a gobuster config file to %s.\n", path)
+	fmt.Fprintf(out, "Press enter to keep the default shown in brackets.\n\n")
+	scratch := newScanSettingsWithFlags(flag.NewFlagSet("wizard", flag.ContinueOnError))
+	fs := scratch.flagSet()
+	var lines []string
+	for _, q := range wizardQuestions {
+		f := fs.Lookup(q.flag)
+		fmt.Fprintf(out, "%s\n", q.help)
+		for {
+			value, err := ask(fmt.Sprintf("%s [%s]", q.prompt, f.Value.String()))
+			if err != nil {
+				return err
+			}
+			value = trimList(value)
+			if value == "" {
+				if q.flag == "url" {
+					fmt.Fprintf(out, "A target is required.\n")
+					continue
+				}
+				break
+			}
+			if q.check != nil {
+				if err := q.check(value); err != nil {
+					fmt.Fprintf(out, "%s\n", err)
+					continue
+				}
+			}
+			if err := fs.Set(q.flag, value); err != nil {
+				fmt.Fprintf(out, "Invalid value: %s\n", err)
+				continue
+			}
+			lines = append(lines, fmt.Sprintf("%s = %s", q.flag, value))
+			break
+		}
+		fmt.Fprintf(out, "\n")
+	}
+
+	if _, err := os.Stat(path); err == nil {
+		answer, err := ask(fmt.Sprintf("%s exists, overwrite? [y/N]", path))
+		if err != nil {
+			return err
+		}
+		if !strings.HasPrefix(strings.ToLower(answer), "y") {
+			return fmt.Errorf("Not overwriting %s.", path)
+		}
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	contents := "# Written by gobuster -wizard\n" + strings.Join(lines, "\n") + "\n"
+	if err := ioutil.WriteFile(path, []byte(contents), 0644); err != nil {
+		return err
+	}
+	fmt.Fprintf(out, "Wrote %s.  Run gobuster with no arguments to use it.\n", path)
+	return nil
+}
+
+func checkWizardURLs(value string) error {
+	for _, target := range strings.Split(value, ",") {
+		u, err := url.Parse(target)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("Not an http(s) URL: %s", target)
+		}
+	}
+	return nil
+}
+
+// Remove spaces around commas, which list flags would otherwise keep.
+func trimList(value string) string {
+	pieces := strings.Split(value, ",")
+	for i, p := range pieces {
+		pieces[i] = strings.TrimSpace(p)
+	}
+	return strings.Join(pieces, ",")
+}
@@ -0,0 +1,93 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package settings
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRunWizard(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gobuster-wizard")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "gobuster.conf")
+	answers := strings.Join([]string{
+		"",                     // url is required
+		"ftp://example.com/",   // and must be http(s)
+		"https://example.com/", // url
+		"/no/such/wordlist",    // wordlist, rejected
+		"short",                // wordlist
+		"four",                 // workers, rejected
+		"4",                    // workers
+		"",                     // sleep
+		"php, html",            // extensions
+		"",                     // exclude
+		"",                     // spider-codes
+	}, "\n") + "\n"
+	out := &bytes.Buffer{}
+	if err := RunWizard(strings.NewReader(answers), out, path); err != nil {
+		t.Fatalf("Expected no error, got %s\n%s", err, out.String())
+	}
+	for _, msg := range []string{"A target is required.", "Not an http(s) URL", "No built-in wordlist", "Invalid value"} {
+		if !strings.Contains(out.String(), msg) {
+			t.Errorf("Expected %q in output, got %s", msg, out.String())
+		}
+	}
+
+	s := testScanSettings()
+	s.LoadFromConfigFile(path)
+	if len(s.BaseURLs) != 1 || s.BaseURLs[0] != "https://example.com/" {
+		t.Errorf("Expected target URL, got %v", s.BaseURLs)
+	}
+	if s.WordlistPath != "short" {
+		t.Errorf("Expected short wordlist, got %s", s.WordlistPath)
+	}
+	if s.Workers != 4 {
+		t.Errorf("Expected 4 workers, got %d", s.Workers)
+	}
+	if len(s.Extensions) != 2 || s.Extensions[1] != "html" {
+		t.Errorf("Expected [php html], got %v", s.Extensions)
+	}
+}
+
+func TestRunWizard_NoOverwrite(t *testing.T) {
+	fp, err := ioutil.TempFile("", "gobuster-wizard")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fp.WriteString("workers = 1\n")
+	fp.Close()
+	defer os.Remove(fp.Name())
+	answers := "http://localhost/\n\n\n\n\n\n\nn\n"
+	if err := RunWizard(strings.NewReader(answers), ioutil.Discard, fp.Name()); err == nil {
+		t.Errorf("Expected error when declining to overwrite.")
+	}
+	if data, _ := ioutil.ReadFile(fp.Name()); string(data) != "workers = 1\n" {
+		t.Errorf("Expected config to be unchanged, got %q", data)
+	}
+}
+
+func TestRunWizard_Cancelled(t *testing.T) {
+	if err := RunWizard(strings.NewReader("http://localhost/\n"), ioutil.Discard, "unused"); err == nil {
+		t.Errorf("Expected error on end of input.")
+	}
+}
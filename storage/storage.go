@@ -0,0 +1,206 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package storage provides a content-addressed store for response bodies.
+// Bodies are written once per unique content hash, and an index maps each URL
+// to the hash of its body, so sites serving many identical pages don't fill
+// the disk.
+package storage
+
+import (
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/Matir/gobuster/logging"
+	"github.com/Matir/gobuster/redact"
+	"io"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// Name of the index file within the store directory
+const IndexName = "index.csv"
+
+// Bodies are stored under this subdirectory
+const objectsDir = "objects"
+
+// Returned once the store has stopped accepting bodies.
+var ErrStoreFull = errors.New("Body store is full.")
+
+type BodyStore struct {
+	// Base directory
+	dir string
+	// Index of URL to hash
+	index   *csv.Writer
+	indexFp *os.File
+	// Hashes already written
+	seen map[string]bool
+	// Most bytes of objects to write, 0 for no limit
+	maxBytes int64
+	// Least free disk space to leave, 0 for no limit
+	minFree int64
+	written int64
+	// Set once a limit is hit
+	full bool
+	// Masks credentials in bodies and the index, if set
+	redactor *redact.Redactor
+	sync.Mutex
+}
+
+// Create (or reopen) a store in the given directory.
+func NewBodyStore(dir string) (*BodyStore, error) {
+	if err := os.MkdirAll(filepath.Join(dir, objectsDir), 0755); err != nil {
+		return nil, err
+	}
+	fp, err := os.OpenFile(filepath.Join(dir, IndexName), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, err
+	}
+	store := &BodyStore{
+		dir:     dir,
+		index:   csv.NewWriter(fp),
+		indexFp: fp,
+		seen:    make(map[string]bool),
+	}
+	return store, nil
+}
+
+// Stop saving bodies after maxBytes have been stored, or when free space on
+// the disk drops below minFree.  Either may be 0 for no limit.
+func (s *BodyStore) SetLimits(maxBytes, minFree int64) {
+	s.Lock()
+	defer s.Unlock()
+	s.maxBytes = maxBytes
+	s.minFree = minFree
+}
+
+// Mask whatever r matches in bodies and URLs saved from now on.  Objects are
+// still named by the hash of the original body.
+func (s *BodyStore) SetRedactor(r *redact.Redactor) {
+	s.Lock()
+	defer s.Unlock()
+	s.redactor = r
+}
+
+// Stop accepting bodies.  Must hold the lock.
+func (s *BodyStore) stop(reason string) {
+	if !s.full {
+		logging.Logf(logging.LogWarning, "No longer saving bodies: %s.", reason)
+		s.full = true
+	}
+}
+
+// Save the body for a URL, returning the hex-encoded SHA-256 of the body.
+func (s *BodyStore) Save(u *url.URL, body []byte) (string, error) {
+	pending, err := s.NewPending()
+	if err != nil {
+		return "", err
+	}
+	if _, err := pending.Write(body); err != nil {
+		s.Abort(pending)
+		return "", err
+	}
+	sum := sha256.Sum256(body)
+	hash := hex.EncodeToString(sum[:])
+	return hash, s.Commit(pending, u, hash)
+}
+
+// PendingBody is a body being streamed into the store, before its hash is
+// known.
+type PendingBody struct {
+	fp *os.File
+	// Writes go through a redactor, which passes them on if nil
+	w io.WriteCloser
+}
+
+func (p *PendingBody) Write(b []byte) (int, error) {
+	return p.w.Write(b)
+}
+
+// Start streaming a new body into the store.  Must be followed by Commit or
+// Abort.
+func (s *BodyStore) NewPending() (*PendingBody, error) {
+	s.Lock()
+	if !s.full && s.minFree > 0 {
+		if free, ok := freeSpace(s.dir); ok && free < s.minFree {
+			s.stop(fmt.Sprintf("only %d bytes free on disk", free))
+		}
+	}
+	full := s.full
+	redactor := s.redactor
+	s.Unlock()
+	if full {
+		return nil, ErrStoreFull
+	}
+	fp, err := ioutil.TempFile(filepath.Join(s.dir, objectsDir), "pending-")
+	if err != nil {
+		return nil, err
+	}
+	return &PendingBody{fp: fp, w: redactor.Writer(fp)}, nil
+}
+
+// Finish a pending body, storing it under hash and indexing it for u.  The
+// data is discarded if an object with the same hash already exists.
+func (s *BodyStore) Commit(p *PendingBody, u *url.URL, hash string) error {
+	if err := p.w.Close(); err != nil {
+		p.fp.Close()
+		os.Remove(p.fp.Name())
+		return err
+	}
+	if err := p.fp.Close(); err != nil {
+		os.Remove(p.fp.Name())
+		return err
+	}
+	s.Lock()
+	defer s.Unlock()
+	path := s.ObjectPath(hash)
+	if _, err := os.Stat(path); s.seen[hash] || err == nil {
+		os.Remove(p.fp.Name())
+	} else if err := os.Rename(p.fp.Name(), path); err != nil {
+		os.Remove(p.fp.Name())
+		return err
+	} else if info, err := os.Stat(path); err == nil {
+		s.written += info.Size()
+		if s.maxBytes > 0 && s.written >= s.maxBytes {
+			s.stop(fmt.Sprintf("reached limit of %d bytes", s.maxBytes))
+		}
+	}
+	s.seen[hash] = true
+	s.index.Write([]string{s.redactor.URL(u).String(), hash})
+	s.index.Flush()
+	return s.index.Error()
+}
+
+// Discard a pending body.
+func (s *BodyStore) Abort(p *PendingBody) {
+	p.fp.Close()
+	os.Remove(p.fp.Name())
+}
+
+// Path to the object for a given hash.
+func (s *BodyStore) ObjectPath(hash string) string {
+	return filepath.Join(s.dir, objectsDir, hash)
+}
+
+func (s *BodyStore) Close() error {
+	s.Lock()
+	defer s.Unlock()
+	s.index.Flush()
+	return s.indexFp.Close()
+}
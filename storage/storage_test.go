@@ -0,0 +1,164 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+import (
+	"github.com/Matir/gobuster/redact"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestBodyStore_Dedupe(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gobuster-storage")
+	if err != nil {
+		t.Fatalf("Unable to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	store, err := NewBodyStore(dir)
+	if err != nil {
+		t.Fatalf("Unable to create store: %v", err)
+	}
+	a := &url.URL{Scheme: "http", Host: "localhost", Path: "/a"}
+	b := &url.URL{Scheme: "http", Host: "localhost", Path: "/b"}
+	hashA, err := store.Save(a, []byte("same"))
+	if err != nil {
+		t.Fatalf("Error saving body: %v", err)
+	}
+	hashB, _ := store.Save(b, []byte("same"))
+	if hashA != hashB {
+		t.Errorf("Expected identical hashes, got %s and %s", hashA, hashB)
+	}
+	store.Close()
+
+	objects, _ := ioutil.ReadDir(filepath.Join(dir, objectsDir))
+	if len(objects) != 1 {
+		t.Errorf("Expected 1 stored object, got %d", len(objects))
+	}
+	index, _ := ioutil.ReadFile(filepath.Join(dir, IndexName))
+	lines := strings.Split(strings.TrimSpace(string(index)), "\n")
+	if len(lines) != 2 {
+		t.Errorf("Expected 2 index lines, got %d", len(lines))
+	}
+}
+
+func TestNewBodyStore_Fail(t *testing.T) {
+	if _, err := NewBodyStore("/dev/null/nope"); err == nil {
+		t.Error("Expected error creating store under a file.")
+	}
+}
+
+func TestBodyStore_Pending(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gobuster-storage")
+	if err != nil {
+		t.Fatalf("Unable to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	store, err := NewBodyStore(dir)
+	if err != nil {
+		t.Fatalf("Unable to create store: %v", err)
+	}
+	defer store.Close()
+	pending, err := store.NewPending()
+	if err != nil {
+		t.Fatalf("Unable to create pending body: %v", err)
+	}
+	pending.Write([]byte("abc"))
+	u := &url.URL{Scheme: "http", Host: "localhost", Path: "/a"}
+	if err := store.Commit(pending, u, "deadbeef"); err != nil {
+		t.Fatalf("Error committing body: %v", err)
+	}
+	if data, err := ioutil.ReadFile(store.ObjectPath("deadbeef")); err != nil || string(data) != "abc" {
+		t.Errorf("Expected stored body \"abc\", got %q (%v)", data, err)
+	}
+
+	aborted, _ := store.NewPending()
+	store.Abort(aborted)
+	objects, _ := ioutil.ReadDir(filepath.Join(dir, objectsDir))
+	if len(objects) != 1 {
+		t.Errorf("Expected only the committed object, got %d entries", len(objects))
+	}
+}
+
+func TestBodyStore_Redact(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gobuster-storage")
+	if err != nil {
+		t.Fatalf("Unable to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	store, err := NewBodyStore(dir)
+	if err != nil {
+		t.Fatalf("Unable to create store: %v", err)
+	}
+	r, _ := redact.New(true, nil)
+	store.SetRedactor(r)
+	u := &url.URL{Scheme: "http", Host: "localhost", Path: "/debug", RawQuery: "token=abc123"}
+	hash, err := store.Save(u, []byte("Cookie: session=abc123\n"))
+	if err != nil {
+		t.Fatalf("Error saving body: %v", err)
+	}
+	store.Close()
+	if data, _ := ioutil.ReadFile(store.ObjectPath(hash)); string(data) != "Cookie: [REDACTED]\n" {
+		t.Errorf("Expected the cookie masked, got %q", data)
+	}
+	if index, _ := ioutil.ReadFile(filepath.Join(dir, IndexName)); strings.Contains(string(index), "abc123") {
+		t.Errorf("Expected the token masked in the index, got %s", index)
+	}
+}
+
+func TestBodyStore_Limits(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gobuster-storage")
+	if err != nil {
+		t.Fatalf("Unable to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	store, err := NewBodyStore(dir)
+	if err != nil {
+		t.Fatalf("Unable to create store: %v", err)
+	}
+	defer store.Close()
+	store.SetLimits(10, 0)
+	u := &url.URL{Scheme: "http", Host: "localhost", Path: "/a"}
+	if _, err := store.Save(u, []byte("0123456789")); err != nil {
+		t.Fatalf("Expected first save to succeed, got %v", err)
+	}
+	if _, err := store.Save(u, []byte("more")); err != ErrStoreFull {
+		t.Errorf("Expected store to be full, got %v", err)
+	}
+}
+
+func TestBodyStore_MinFree(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gobuster-storage")
+	if err != nil {
+		t.Fatalf("Unable to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	if _, ok := freeSpace(dir); !ok {
+		t.Skip("Free space unavailable on this platform.")
+	}
+	store, err := NewBodyStore(dir)
+	if err != nil {
+		t.Fatalf("Unable to create store: %v", err)
+	}
+	defer store.Close()
+	// No disk has this much free
+	store.SetLimits(0, 1<<62)
+	if _, err := store.NewPending(); err != ErrStoreFull {
+		t.Errorf("Expected store to refuse bodies on a full disk, got %v", err)
+	}
+}
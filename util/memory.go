@@ -0,0 +1,70 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util
+
+import (
+	"github.com/Matir/gobuster/logging"
+	"runtime"
+	"sync"
+	"time"
+)
+
+// How often MemoryGuard actually reads memory stats.
+var memoryCheckInterval = time.Second
+
+// MemoryGuard reports whether heap use has grown past a limit.  Reading
+// memory stats briefly stops the world, so checks are rate limited.  A nil
+// MemoryGuard is never exceeded.
+type MemoryGuard struct {
+	limit     uint64
+	last      time.Time
+	exceeded  bool
+	readStats func() uint64
+	sync.Mutex
+}
+
+func NewMemoryGuard(limit int64) *MemoryGuard {
+	return &MemoryGuard{limit: uint64(limit), readStats: heapInUse}
+}
+
+func heapInUse() uint64 {
+	var stats runtime.MemStats
+	runtime.ReadMemStats(&stats)
+	return stats.HeapInuse
+}
+
+// Check whether the heap is over the limit.
+func (g *MemoryGuard) Exceeded() bool {
+	if g == nil {
+		return false
+	}
+	g.Lock()
+	defer g.Unlock()
+	if time.Since(g.last) < memoryCheckInterval {
+		return g.exceeded
+	}
+	g.last = time.Now()
+	inUse := g.readStats()
+	exceeded := inUse > g.limit
+	if exceeded != g.exceeded {
+		if exceeded {
+			logging.Logf(logging.LogWarning, "Memory use of %d bytes is above the limit, shedding optional work.", inUse)
+		} else {
+			logging.Logf(logging.LogInfo, "Memory use back under the limit.")
+		}
+	}
+	g.exceeded = exceeded
+	return exceeded
+}
@@ -0,0 +1,54 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMemoryGuard(t *testing.T) {
+	defer func(d time.Duration) { memoryCheckInterval = d }(memoryCheckInterval)
+	memoryCheckInterval = 0
+	var inUse uint64 = 100
+	g := NewMemoryGuard(1000)
+	g.readStats = func() uint64 { return inUse }
+	if g.Exceeded() {
+		t.Error("Expected guard not to be exceeded.")
+	}
+	inUse = 2000
+	if !g.Exceeded() {
+		t.Error("Expected guard to be exceeded.")
+	}
+	inUse = 500
+	if g.Exceeded() {
+		t.Error("Expected guard to recover.")
+	}
+	var nilGuard *MemoryGuard
+	if nilGuard.Exceeded() {
+		t.Error("Expected nil guard never to be exceeded.")
+	}
+}
+
+func TestMemoryGuard_RateLimited(t *testing.T) {
+	calls := 0
+	g := NewMemoryGuard(1000)
+	g.readStats = func() uint64 { calls++; return 0 }
+	g.Exceeded()
+	g.Exceeded()
+	if calls != 1 {
+		t.Errorf("Expected 1 stats read, got %d", calls)
+	}
+}
@@ -0,0 +1,123 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package wordlist
+
+import (
+	"bytes"
+	"compress/bzip2"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// Formats without a decoder in the standard library are streamed through
+// the system's decompressor, which must be installed to read them.
+var decompressCommands = map[string]string{
+	".zst": "zstd",
+	".xz":  "xz",
+}
+
+// The decompressor a wordlist file needs, if any, and whether it is
+// installed.
+func Decompressor(path string) (string, bool) {
+	tool, ok := decompressCommands[strings.ToLower(filepath.Ext(path))]
+	if !ok {
+		return "", true
+	}
+	_, err := exec.LookPath(tool)
+	return tool, err == nil
+}
+
+// Open a wordlist file, decompressing it according to its extension.
+func openWordlistFile(path string) (io.ReadCloser, error) {
+	ext := strings.ToLower(filepath.Ext(path))
+	if tool, ok := decompressCommands[ext]; ok {
+		return openWithCommand(tool, path)
+	}
+	fp, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	switch ext {
+	case ".gz":
+		gz, err := gzip.NewReader(fp)
+		if err != nil {
+			fp.Close()
+			return nil, fmt.Errorf("Unable to read %s: %s", path, err.Error())
+		}
+		return &wrappedReader{Reader: gz, closers: []io.Closer{gz, fp}}, nil
+	case ".bz2":
+		return &wrappedReader{Reader: bzip2.NewReader(fp), closers: []io.Closer{fp}}, nil
+	}
+	return fp, nil
+}
+
+// A decompressing reader that closes what it wraps.
+type wrappedReader struct {
+	io.Reader
+	closers []io.Closer
+}
+
+func (r *wrappedReader) Close() error {
+	var err error
+	for _, c := range r.closers {
+		if cerr := c.Close(); err == nil {
+			err = cerr
+		}
+	}
+	return err
+}
+
+// Output of a decompressor, which reports its failure on Close.
+type commandReader struct {
+	io.ReadCloser
+	cmd    *exec.Cmd
+	stderr *bytes.Buffer
+	path   string
+}
+
+func openWithCommand(tool, path string) (io.ReadCloser, error) {
+	if _, err := os.Stat(path); err != nil {
+		return nil, err
+	}
+	cmd := exec.Command(tool, "-dc", path)
+	stderr := &bytes.Buffer{}
+	cmd.Stderr = stderr
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("Unable to run %s to read %s: %s", tool, path, err.Error())
+	}
+	return &commandReader{ReadCloser: stdout, cmd: cmd, stderr: stderr, path: path}, nil
+}
+
+func (r *commandReader) Close() error {
+	// Unblock the command if we stopped reading early
+	r.ReadCloser.Close()
+	err := r.cmd.Wait()
+	if err == nil {
+		return nil
+	}
+	if msg := strings.TrimSpace(r.stderr.String()); msg != "" {
+		return fmt.Errorf("Unable to decompress %s: %s", r.path, msg)
+	}
+	return fmt.Errorf("Unable to decompress %s: %s", r.path, err.Error())
+}
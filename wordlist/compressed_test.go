@@ -0,0 +1,77 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package wordlist
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestReadWordlistFile_Compressed(t *testing.T) {
+	for _, path := range []string{"testdata/testwl.gz", "testdata/testwl.bz2", "testdata/testwl.xz"} {
+		if tool, ok := decompressCommands[filepath.Ext(path)]; ok {
+			if _, err := exec.LookPath(tool); err != nil {
+				t.Logf("Skipping %s without %s.", path, tool)
+				continue
+			}
+		}
+		wl, err := ReadWordlistFile(path)
+		if err != nil {
+			t.Errorf("Expected no error reading %s, got %v", path, err)
+		} else if strings.Join(wl, ",") != "a,b,c" {
+			t.Errorf("Expected a,b,c from %s, got %v", path, wl)
+		}
+	}
+}
+
+func TestReadWordlistFile_Corrupt(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gobuster-wordlist")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	for _, name := range []string{"bad.gz", "bad.xz"} {
+		if tool, ok := decompressCommands[filepath.Ext(name)]; ok {
+			if _, err := exec.LookPath(tool); err != nil {
+				continue
+			}
+		}
+		path := filepath.Join(dir, name)
+		if err := ioutil.WriteFile(path, []byte("not compressed\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := ReadWordlistFile(path); err == nil {
+			t.Errorf("Expected error reading corrupt %s", name)
+		}
+	}
+	if _, err := ReadWordlistFile(filepath.Join(dir, "missing.zst")); err == nil {
+		t.Errorf("Expected error reading missing file.")
+	}
+}
+
+func TestDecompressor(t *testing.T) {
+	if tool, ok := Decompressor("words.gz"); tool != "" || !ok {
+		t.Errorf("Expected no decompressor for gzip, got %s %v", tool, ok)
+	}
+	defer os.Setenv("PATH", os.Getenv("PATH"))
+	os.Setenv("PATH", "")
+	if tool, ok := Decompressor("words.XZ"); tool != "xz" || ok {
+		t.Errorf("Expected xz missing, got %s %v", tool, ok)
+	}
+}
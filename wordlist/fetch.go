@@ -0,0 +1,312 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package wordlist
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"github.com/Matir/gobuster/client"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Remote wordlists are pinned to a SecLists release, so their contents don't
+// change under an alias.
+const secListsBase = "https://raw.githubusercontent.com/danielmiessler/SecLists/2024.1/Discovery/Web-Content/"
+
+// A well-known remote wordlist and the SHA-256 of its pinned contents.
+type remoteWordlist struct {
+	URL    string
+	SHA256 string
+}
+
+// Aliases of well-known remote wordlists.  An alias without a checksum here
+// is only fetched with one given as #sha256=HEX, so nothing unverified is
+// ever used under an alias.
+var remoteWordlists = map[string]remoteWordlist{
+	"seclists:common":                    {URL: secListsBase + "common.txt"},
+	"seclists:big":                       {URL: secListsBase + "big.txt"},
+	"seclists:quickhits":                 {URL: secListsBase + "quickhits.txt"},
+	"seclists:raft-small-words":          {URL: secListsBase + "raft-small-words.txt"},
+	"seclists:raft-medium-directories":   {URL: secListsBase + "raft-medium-directories.txt"},
+	"seclists:directory-list-2.3-medium": {URL: secListsBase + "directory-list-2.3-medium.txt"},
+}
+
+// Directory remote wordlists are cached in.  Empty for the user's cache
+// directory.
+var CacheDir = ""
+
+// Factory for the clients remote wordlists are fetched with, so they go
+// through the scan's proxies.  Nil for direct connections.
+var Factory client.ClientFactory
+
+// Longest a download may take, and the User-Agent it is made with.
+const (
+	fetchTimeout = 5 * time.Minute
+	fetchAgent   = "GoBuster"
+)
+
+// Names of the remote wordlist aliases, sorted.
+func RemoteWordlistNames() []string {
+	names := make([]string, 0, len(remoteWordlists))
+	for name := range remoteWordlists {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Whether ref names a remote wordlist: an alias or an http(s) URL, optionally
+// followed by #sha256=HEX to verify it.
+func IsRemote(ref string) bool {
+	ref = strings.SplitN(ref, "#", 2)[0]
+	if _, ok := remoteWordlists[ref]; ok {
+		return true
+	}
+	return strings.HasPrefix(ref, "http://") || strings.HasPrefix(ref, "https://")
+}
+
+// Split a remote reference into its URL and expected checksum, if any.
+func parseRemoteRef(ref string) (string, string, error) {
+	pieces := strings.SplitN(ref, "#", 2)
+	u := pieces[0]
+	alias, isAlias := remoteWordlists[u]
+	if isAlias {
+		u = alias.URL
+	} else if !IsRemote(u) {
+		return "", "", fmt.Errorf("Unknown wordlist %s.", ref)
+	}
+	var sum string
+	if len(pieces) == 2 {
+		if !strings.HasPrefix(pieces[1], "sha256=") {
+			return "", "", fmt.Errorf("Expected #sha256=HEX in %s.", ref)
+		}
+		sum = strings.ToLower(pieces[1][len("sha256="):])
+		if len(sum) != sha256.Size*2 {
+			return "", "", fmt.Errorf("Invalid SHA-256 in %s.", ref)
+		}
+	}
+	if isAlias {
+		switch {
+		case alias.SHA256 == "" && sum == "":
+			return "", "", fmt.Errorf("No checksum is pinned for %s; give one as %s#sha256=HEX.", pieces[0], pieces[0])
+		case alias.SHA256 != "" && sum != "" && sum != alias.SHA256:
+			return "", "", fmt.Errorf("Checksum in %s differs from the one pinned for it.", ref)
+		case alias.SHA256 != "":
+			sum = alias.SHA256
+		}
+	}
+	return u, sum, nil
+}
+
+// Fetcher downloads remote wordlists into a cache directory.  Each list is
+// stored next to a .sha256 file holding the checksum of its first download,
+// and is verified against it (and any expected checksum) on every use.
+type Fetcher struct {
+	Dir    string
+	Client client.Client
+}
+
+// Create a fetcher caching into dir, or the default cache directory if empty.
+func NewFetcher(dir string) (*Fetcher, error) {
+	if dir == "" {
+		dir = CacheDir
+	}
+	if dir == "" {
+		base, err := os.UserCacheDir()
+		if err != nil {
+			return nil, err
+		}
+		dir = filepath.Join(base, "gobuster", "wordlists")
+	}
+	factory := Factory
+	if factory == nil {
+		direct, err := client.NewProxyClientFactory(nil, fetchTimeout, fetchAgent)
+		if err != nil {
+			return nil, err
+		}
+		factory = direct
+	}
+	return &Fetcher{Dir: dir, Client: factory.Get()}, nil
+}
+
+// Path in the cache for a URL.
+func (f *Fetcher) cachePath(u string) string {
+	sum := sha256.Sum256([]byte(u))
+	name := path.Base(strings.SplitN(u, "?", 2)[0])
+	if name == "" || name == "/" || name == "." {
+		name = "wordlist.txt"
+	}
+	return filepath.Join(f.Dir, hex.EncodeToString(sum[:8])+"-"+name)
+}
+
+// Get the path of a cached remote wordlist, downloading it if needed.
+func (f *Fetcher) Resolve(ref string) (string, error) {
+	u, sum, err := parseRemoteRef(ref)
+	if err != nil {
+		return "", err
+	}
+	cached := f.cachePath(u)
+	if _, err := os.Stat(cached); err == nil {
+		return cached, verifyCached(cached, sum)
+	}
+	return f.download(u, cached, sum)
+}
+
+// Download a remote wordlist, replacing any cached copy.
+func (f *Fetcher) Fetch(ref string) (string, error) {
+	u, sum, err := parseRemoteRef(ref)
+	if err != nil {
+		return "", err
+	}
+	return f.download(u, f.cachePath(u), sum)
+}
+
+func (f *Fetcher) download(u, dest, expected string) (string, error) {
+	if err := os.MkdirAll(f.Dir, 0755); err != nil {
+		return "", err
+	}
+	parsed, err := url.Parse(u)
+	if err != nil {
+		return "", err
+	}
+	resp, err := f.Client.RequestURL(parsed)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("Unable to fetch %s: %s", u, resp.Status)
+	}
+	tmp, err := ioutil.TempFile(f.Dir, ".download")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(tmp.Name())
+	h := sha256.New()
+	_, err = io.Copy(io.MultiWriter(tmp, h), resp.Body)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return "", err
+	}
+	sum := hex.EncodeToString(h.Sum(nil))
+	if expected != "" && sum != expected {
+		return "", fmt.Errorf("Checksum mismatch for %s: expected %s, got %s.", u, expected, sum)
+	}
+	if err := ioutil.WriteFile(dest+".sha256", []byte(sum+"\n"), 0644); err != nil {
+		return "", err
+	}
+	if err := os.Rename(tmp.Name(), dest); err != nil {
+		return "", err
+	}
+	return dest, nil
+}
+
+// Check a cached wordlist against its recorded and expected checksums.
+func verifyCached(cached, expected string) error {
+	recorded, err := ioutil.ReadFile(cached + ".sha256")
+	if err != nil {
+		return fmt.Errorf("Missing checksum for cached wordlist %s; fetch it again.", cached)
+	}
+	fp, err := os.Open(cached)
+	if err != nil {
+		return err
+	}
+	defer fp.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, fp); err != nil {
+		return err
+	}
+	sum := hex.EncodeToString(h.Sum(nil))
+	if sum != strings.TrimSpace(string(recorded)) {
+		return fmt.Errorf("Cached wordlist %s is corrupt; fetch it again.", cached)
+	}
+	if expected != "" && sum != expected {
+		return fmt.Errorf("Checksum mismatch for cached wordlist %s: expected %s, got %s.", cached, expected, sum)
+	}
+	return nil
+}
+
+// Load a remote wordlist through the default cache.
+func LoadRemoteWordlist(ref string) ([]string, error) {
+	f, err := NewFetcher("")
+	if err != nil {
+		return nil, err
+	}
+	cached, err := f.Resolve(ref)
+	if err != nil {
+		return nil, err
+	}
+	return ReadWordlistFile(cached)
+}
+
+// Run the "wordlist" subcommand: "fetch REF..." downloads (or refreshes)
+// remote wordlists and "list" shows the known aliases.
+func RunCommand(args []string, out io.Writer) error {
+	if len(args) == 0 {
+		return fmt.Errorf("Usage: wordlist fetch [-cache dir] [-proxy url] REF... | wordlist list")
+	}
+	switch args[0] {
+	case "list":
+		for _, name := range RemoteWordlistNames() {
+			alias := remoteWordlists[name]
+			fmt.Fprintf(out, "%s\t%s\t%s\n", name, alias.URL, alias.SHA256)
+		}
+		return nil
+	case "fetch":
+		fs := flag.NewFlagSet("wordlist fetch", flag.ContinueOnError)
+		fs.SetOutput(out)
+		dir := fs.String("cache", "", "Cache `directory` for downloaded wordlists.")
+		proxy := fs.String("proxy", "", "`Proxy` to fetch through, as for scans.")
+		if err := fs.Parse(args[1:]); err != nil {
+			return err
+		}
+		if fs.NArg() == 0 {
+			return fmt.Errorf("Usage: wordlist fetch [-cache dir] [-proxy url] REF...")
+		}
+		if *proxy != "" {
+			factory, err := client.NewProxyClientFactory([]string{*proxy}, fetchTimeout, fetchAgent)
+			if err != nil {
+				return err
+			}
+			Factory = factory
+		}
+		f, err := NewFetcher(*dir)
+		if err != nil {
+			return err
+		}
+		for _, ref := range fs.Args() {
+			cached, err := f.Fetch(ref)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(out, "%s\t%s\n", ref, cached)
+		}
+		return nil
+	}
+	return fmt.Errorf("Unknown wordlist command %s.", args[0])
+}
@@ -0,0 +1,191 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package wordlist
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"github.com/Matir/gobuster/client/mock"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+)
+
+const remoteWords = "admin\nlogin\n"
+
+func remoteServer() (*httptest.Server, *int) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.URL.Path != "/words.txt" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(remoteWords))
+	}))
+	return server, &requests
+}
+
+func tempFetcher(t *testing.T) (*Fetcher, func()) {
+	dir, err := ioutil.TempDir("", "gobuster-wordlists")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f, err := NewFetcher(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return f, func() { os.RemoveAll(dir) }
+}
+
+func TestIsRemote(t *testing.T) {
+	for ref, remote := range map[string]bool{
+		"seclists:common":                 true,
+		"https://example.com/words.txt":   true,
+		"http://example.com/w#sha256=abc": true,
+		"default":                         false,
+		"words.txt":                       false,
+		"seclists:nope":                   false,
+	} {
+		if IsRemote(ref) != remote {
+			t.Errorf("Expected IsRemote(%s) = %v", ref, remote)
+		}
+	}
+}
+
+func TestParseRemoteRef(t *testing.T) {
+	full := strings.Repeat("AB", sha256.Size)
+	u, sum, err := parseRemoteRef("https://example.com/w.txt#sha256=" + full)
+	if err != nil || u != "https://example.com/w.txt" || sum != strings.ToLower(full) {
+		t.Errorf("Expected URL and checksum, got %s %s %v", u, sum, err)
+	}
+	for _, ref := range []string{"words.txt", "https://x/w#md5=abc", "https://x/w#sha256=abc"} {
+		if _, _, err := parseRemoteRef(ref); err == nil {
+			t.Errorf("Expected error for %s", ref)
+		}
+	}
+}
+
+func TestParseRemoteRef_Pinned(t *testing.T) {
+	pinned := strings.Repeat("ab", sha256.Size)
+	remoteWordlists["test:pinned"] = remoteWordlist{URL: "https://example.com/pinned.txt", SHA256: pinned}
+	remoteWordlists["test:unpinned"] = remoteWordlist{URL: "https://example.com/unpinned.txt"}
+	defer delete(remoteWordlists, "test:pinned")
+	defer delete(remoteWordlists, "test:unpinned")
+	if u, sum, err := parseRemoteRef("test:pinned"); err != nil || u != "https://example.com/pinned.txt" || sum != pinned {
+		t.Errorf("Expected the pinned checksum, got %s %s %v", u, sum, err)
+	}
+	if _, _, err := parseRemoteRef("test:pinned#sha256=" + strings.Repeat("0", sha256.Size*2)); err == nil {
+		t.Error("Expected error for a checksum differing from the pinned one.")
+	}
+	if _, _, err := parseRemoteRef("test:unpinned"); err == nil {
+		t.Error("Expected error for an alias with no checksum.")
+	}
+	if _, sum, err := parseRemoteRef("test:unpinned#sha256=" + pinned); err != nil || sum != pinned {
+		t.Errorf("Expected the given checksum, got %s %v", sum, err)
+	}
+}
+
+func TestNewFetcher_Factory(t *testing.T) {
+	fetched := &mock.MockClient{}
+	Factory = &mock.MockClientFactory{ForeverClient: fetched}
+	defer func() { Factory = nil }()
+	f, err := NewFetcher("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f.Client != fetched {
+		t.Errorf("Expected the client from Factory, got %v", f.Client)
+	}
+}
+
+func TestFetcher_ResolveCaches(t *testing.T) {
+	server, requests := remoteServer()
+	defer server.Close()
+	f, cleanup := tempFetcher(t)
+	defer cleanup()
+	sum := sha256.Sum256([]byte(remoteWords))
+	ref := server.URL + "/words.txt#sha256=" + hex.EncodeToString(sum[:])
+	for i := 0; i < 2; i++ {
+		cached, err := f.Resolve(ref)
+		if err != nil {
+			t.Fatalf("Expected no error, got %s", err)
+		}
+		words, err := ReadWordlistFile(cached)
+		if err != nil || strings.Join(words, ",") != "admin,login" {
+			t.Errorf("Expected cached words, got %v %v", words, err)
+		}
+	}
+	if *requests != 1 {
+		t.Errorf("Expected 1 download, got %d", *requests)
+	}
+	if _, err := f.Fetch(ref); err != nil || *requests != 2 {
+		t.Errorf("Expected fetch to download again, got %d requests, %v", *requests, err)
+	}
+}
+
+func TestFetcher_Checksums(t *testing.T) {
+	server, _ := remoteServer()
+	defer server.Close()
+	f, cleanup := tempFetcher(t)
+	defer cleanup()
+	wrong := server.URL + "/words.txt#sha256=" + strings.Repeat("0", sha256.Size*2)
+	if _, err := f.Resolve(wrong); err == nil || !strings.Contains(err.Error(), "Checksum mismatch") {
+		t.Errorf("Expected checksum mismatch, got %v", err)
+	}
+	cached, err := f.Resolve(server.URL + "/words.txt")
+	if err != nil {
+		t.Fatalf("Expected no error, got %s", err)
+	}
+	if err := ioutil.WriteFile(cached, []byte("tampered\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.Resolve(server.URL + "/words.txt"); err == nil || !strings.Contains(err.Error(), "corrupt") {
+		t.Errorf("Expected corrupt cache, got %v", err)
+	}
+	if _, err := f.Resolve(server.URL + "/missing.txt"); err == nil {
+		t.Errorf("Expected error for missing wordlist.")
+	}
+}
+
+func TestRunCommand(t *testing.T) {
+	server, _ := remoteServer()
+	defer server.Close()
+	dir, err := ioutil.TempDir("", "gobuster-wordlists")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	var out bytes.Buffer
+	if err := RunCommand([]string{"fetch", "-cache", dir, server.URL + "/words.txt"}, &out); err != nil {
+		t.Fatalf("Expected no error, got %s", err)
+	}
+	if !strings.Contains(out.String(), dir) {
+		t.Errorf("Expected cached path in output, got %s", out.String())
+	}
+	out.Reset()
+	if err := RunCommand([]string{"list"}, &out); err != nil || !strings.Contains(out.String(), "seclists:common") {
+		t.Errorf("Expected aliases to be listed, got %s %v", out.String(), err)
+	}
+	for _, args := range [][]string{nil, {"fetch"}, {"remove"}} {
+		if err := RunCommand(args, &out); err == nil {
+			t.Errorf("Expected error for %v", args)
+		}
+	}
+}
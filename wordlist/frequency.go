@@ -0,0 +1,270 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package wordlist
+
+import (
+	"bufio"
+	"io"
+	"strings"
+)
+
+// A hand-picked list of paths commonly worth trying early, such as admin
+// pages, backups and exposed source control, in the order they are tried.
+// It is a judgement call, not measured hit rates.  Words of a wordlist that
+// are here are tried before the rest with -frequent-first.
+var FrequentWords = `
+admin
+login
+images
+.git
+backup
+api
+js
+css
+wp-admin
+uploads
+.env
+assets
+static
+includes
+administrator
+config
+test
+robots.txt
+index
+dashboard
+img
+wp-content
+wp-includes
+.htaccess
+server-status
+phpmyadmin
+backups
+old
+dev
+files
+cgi-bin
+search
+user
+register
+logout
+media
+scripts
+data
+docs
+tmp
+temp
+private
+upload
+download
+downloads
+.svn
+web.config
+sitemap.xml
+account
+console
+manager
+api/v1
+v1
+graphql
+swagger
+actuator
+status
+health
+debug
+install
+setup
+db
+database
+log
+logs
+cache
+lib
+src
+vendor
+node_modules
+app
+public
+blog
+news
+home
+about
+contact
+help
+support
+shop
+cart
+store
+mail
+email
+webmail
+portal
+panel
+cpanel
+members
+users
+profile
+forum
+wiki
+server-info
+.DS_Store
+.gitignore
+.git/config
+.well-known
+crossdomain.xml
+phpinfo.php
+info.php
+test.php
+readme
+README.md
+changelog
+license
+composer.json
+package.json
+.npmrc
+.htpasswd
+config.php
+wp-config.php
+web
+www
+site
+secure
+security
+auth
+oauth
+sso
+signin
+signup
+password
+reset
+forgot
+session
+sessions
+error
+errors
+404
+500
+xmlrpc.php
+rss
+feed
+archive
+archives
+stats
+reports
+report
+export
+import
+bak
+old-site
+new
+beta
+staging
+demo
+sample
+examples
+internal
+intranet
+jenkins
+jira
+git
+svn
+sql
+dump
+`
+
+// Word of FrequentWords to its rank, lowest first
+var frequentRanks = func() map[string]int {
+	ranks := make(map[string]int)
+	for _, w := range strings.Fields(FrequentWords) {
+		if _, ok := ranks[w]; !ok {
+			ranks[w] = len(ranks)
+		}
+	}
+	return ranks
+}()
+
+// A stream of the same words, with those in FrequentWords moved to the front
+// in its order, so time-boxed scans try those paths early.  The rest keep
+// their order, and each word moved is tried once.
+func (s *Stream) FrequentFirst() (*Stream, error) {
+	rdr, err := s.open()
+	if err != nil {
+		return nil, err
+	}
+	found := make([]bool, len(frequentRanks))
+	scanner := bufio.NewScanner(rdr)
+	for scanner.Scan() {
+		if rank, ok := frequentRanks[scanner.Text()]; ok {
+			found[rank] = true
+		}
+	}
+	err = scanner.Err()
+	if closeErr := rdr.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return nil, err
+	}
+	ranked := make([]string, len(frequentRanks))
+	for w, rank := range frequentRanks {
+		ranked[rank] = w
+	}
+	var head []string
+	skip := make(map[string]bool)
+	for rank, ok := range found {
+		if ok {
+			head = append(head, ranked[rank])
+			skip[ranked[rank]] = true
+		}
+	}
+	if len(head) == 0 {
+		return s, nil
+	}
+	headText := strings.Join(head, "\n") + "\n"
+	open := func() (io.ReadCloser, error) {
+		rdr, err := s.open()
+		if err != nil {
+			return nil, err
+		}
+		rest := &skipLinesReader{rdr: bufio.NewReader(rdr), skip: skip}
+		return &prependedReader{Reader: io.MultiReader(strings.NewReader(headText), rest), Closer: rdr}, nil
+	}
+	return newStream(open, s.mapping)
+}
+
+// Reads lines, leaving out those in skip.
+type skipLinesReader struct {
+	rdr  *bufio.Reader
+	skip map[string]bool
+	// Kept line not yet read, and the error that ended it
+	buf []byte
+	err error
+}
+
+func (r *skipLinesReader) Read(p []byte) (int, error) {
+	for len(r.buf) == 0 {
+		if r.err != nil {
+			return 0, r.err
+		}
+		var line []byte
+		line, r.err = r.rdr.ReadBytes('\n')
+		if !r.skip[strings.TrimRight(string(line), "\r\n")] {
+			r.buf = line
+		}
+	}
+	n := copy(p, r.buf)
+	r.buf = r.buf[n:]
+	return n, nil
+}
@@ -0,0 +1,60 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package wordlist
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestStream_FrequentFirst(t *testing.T) {
+	s, err := NewStream([]string{"zebra", "backup", "foo{1-2}", "admin", "quux", ".git", "admin"}).FrequentFirst()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if got := strings.Join(readStream(t, s, 0), ","); got != "admin,.git,backup,zebra,foo1,foo2,quux" || s.Len() != 7 {
+		t.Errorf("Expected admin,.git,backup,zebra,foo1,foo2,quux, got %s (%d)", got, s.Len())
+	}
+	if got := strings.Join(readStream(t, s, 4), ","); got != "foo1,foo2,quux" {
+		t.Errorf("Expected foo1,foo2,quux after skipping 4, got %s", got)
+	}
+	plain := NewStream([]string{"zebra", "quux"})
+	if same, _ := plain.FrequentFirst(); same != plain {
+		t.Errorf("Expected the same stream without frequent words")
+	}
+}
+
+func TestStream_FrequentFirstCRLF(t *testing.T) {
+	s, err := newStream(stringOpener("zebra\r\nlogin\r\nquux"), nil)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if s, err = s.FrequentFirst(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if got := strings.Join(readStream(t, s, 0), ","); got != "login,zebra,quux" {
+		t.Errorf("Expected login first, got %q", got)
+	}
+}
+
+func TestFrequentWords(t *testing.T) {
+	words := strings.Fields(FrequentWords)
+	if len(frequentRanks) != len(words) {
+		t.Errorf("Expected no duplicate frequent words, got %d of %d unique", len(frequentRanks), len(words))
+	}
+	if frequentRanks["admin"] != 0 {
+		t.Errorf("Expected admin first, got rank %d", frequentRanks["admin"])
+	}
+}
@@ -0,0 +1,112 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package wordlist
+
+import (
+	"fmt"
+	"github.com/Matir/gobuster/logging"
+	"regexp"
+	"strconv"
+	"time"
+)
+
+// Ranges in wordlist entries look like {1-31} and are expanded into one word
+// per value.  A leading zero on the start value fixes the width, so {01-12}
+// produces 01, 02, ... 12.  Ranges of dates look like {2024-01-01-2024-01-31}
+// for days or {2023-11-2024-02} for months, and produce dates in the same
+// form.
+var rangeRe = regexp.MustCompile(`\{(\d{4}-\d{2}(?:-\d{2})?)-(\d{4}-\d{2}(?:-\d{2})?)\}|\{(\d+)-(\d+)\}`)
+
+// Refuse to expand a single word into more than this many.
+const maxExpandedWords = 100000
+
+// Expand all numeric and date ranges in a word.  Multiple ranges produce
+// every combination.  Invalid ranges are left as they are, as are words that
+// would expand into more than maxExpandedWords.
+func ExpandRanges(word string) []string {
+	locs := rangeRe.FindAllStringSubmatchIndex(word, -1)
+	if locs == nil {
+		return []string{word}
+	}
+	results := []string{""}
+	last := 0
+	for _, loc := range locs {
+		var values []string
+		if loc[2] >= 0 {
+			values = dateRange(word[loc[2]:loc[3]], word[loc[4]:loc[5]])
+		} else {
+			values = numberRange(word[loc[6]:loc[7]], word[loc[8]:loc[9]])
+		}
+		if values == nil {
+			values = []string{word[loc[0]:loc[1]]}
+		}
+		if len(results)*len(values) > maxExpandedWords {
+			logging.Logf(logging.LogWarning, "Not expanding %s, it makes more than %d words.", word, maxExpandedWords)
+			return []string{word}
+		}
+		between := word[last:loc[0]]
+		expanded := make([]string, 0, len(results)*len(values))
+		for _, r := range results {
+			for _, v := range values {
+				expanded = append(expanded, r+between+v)
+			}
+		}
+		results = expanded
+		last = loc[1]
+	}
+	for i := range results {
+		results[i] += word[last:]
+	}
+	return results
+}
+
+// The values from start to end, or nil if the range isn't valid.
+func numberRange(startStr, endStr string) []string {
+	start, startErr := strconv.Atoi(startStr)
+	end, endErr := strconv.Atoi(endStr)
+	if startErr != nil || endErr != nil || start > end || end-start >= maxExpandedWords {
+		return nil
+	}
+	width := 0
+	if len(startStr) > 1 && startStr[0] == '0' {
+		width = len(startStr)
+	}
+	values := make([]string, 0, end-start+1)
+	for i := start; i <= end; i++ {
+		values = append(values, fmt.Sprintf("%0*d", width, i))
+	}
+	return values
+}
+
+// The days or months from start to end, or nil if the range isn't valid.
+func dateRange(startStr, endStr string) []string {
+	layout, months := "2006-01-02", 0
+	if len(startStr) == len("2006-01") {
+		layout, months = "2006-01", 1
+	}
+	start, startErr := time.Parse(layout, startStr)
+	end, endErr := time.Parse(layout, endStr)
+	if startErr != nil || endErr != nil || len(startStr) != len(endStr) || start.After(end) {
+		return nil
+	}
+	var values []string
+	for d := start; !d.After(end); d = d.AddDate(0, months, 1-months) {
+		if len(values) >= maxExpandedWords {
+			return nil
+		}
+		values = append(values, d.Format(layout))
+	}
+	return values
+}
@@ -0,0 +1,95 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package wordlist
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestExpandRanges_NoRange(t *testing.T) {
+	res := ExpandRanges("admin")
+	if len(res) != 1 || res[0] != "admin" {
+		t.Errorf("Expected [admin], got %v", res)
+	}
+}
+
+func TestExpandRanges_Basic(t *testing.T) {
+	res := ExpandRanges("backup-{2019-2021}.zip")
+	expected := []string{"backup-2019.zip", "backup-2020.zip", "backup-2021.zip"}
+	if strings.Join(res, ",") != strings.Join(expected, ",") {
+		t.Errorf("Expected %v, got %v", expected, res)
+	}
+}
+
+func TestExpandRanges_Padded(t *testing.T) {
+	res := ExpandRanges("report-{08-10}")
+	expected := []string{"report-08", "report-09", "report-10"}
+	if strings.Join(res, ",") != strings.Join(expected, ",") {
+		t.Errorf("Expected %v, got %v", expected, res)
+	}
+}
+
+func TestExpandRanges_Multiple(t *testing.T) {
+	res := ExpandRanges("{1-2}-{1-3}")
+	if len(res) != 6 {
+		t.Fatalf("Expected 6 results, got %d: %v", len(res), res)
+	}
+	if res[0] != "1-1" || res[5] != "2-3" {
+		t.Errorf("Unexpected ordering: %v", res)
+	}
+}
+
+func TestExpandRanges_Invalid(t *testing.T) {
+	res := ExpandRanges("x{5-1}-{1-2}")
+	expected := []string{"x{5-1}-1", "x{5-1}-2"}
+	if strings.Join(res, ",") != strings.Join(expected, ",") {
+		t.Errorf("Expected %v, got %v", expected, res)
+	}
+}
+
+func TestExpandRanges_Dates(t *testing.T) {
+	res := ExpandRanges("backup-{2024-02-28-2024-03-01}.tar")
+	expected := "backup-2024-02-28.tar,backup-2024-02-29.tar,backup-2024-03-01.tar"
+	if strings.Join(res, ",") != expected {
+		t.Errorf("Expected %s, got %v", expected, res)
+	}
+	res = ExpandRanges("logs/{2023-11-2024-02}/")
+	expected = "logs/2023-11/,logs/2023-12/,logs/2024-01/,logs/2024-02/"
+	if strings.Join(res, ",") != expected {
+		t.Errorf("Expected %s, got %v", expected, res)
+	}
+	res = ExpandRanges("{2024-02-30-2024-03-01}")
+	if len(res) != 1 || res[0] != "{2024-02-30-2024-03-01}" {
+		t.Errorf("Expected an invalid date left as is, got %v", res)
+	}
+}
+
+func TestExpandRanges_TooMany(t *testing.T) {
+	word := "{1-1000}-{1-1000}"
+	if res := ExpandRanges(word); len(res) != 1 || res[0] != word {
+		t.Errorf("Expected %s left as is, got %d words", word, len(res))
+	}
+}
+
+func TestReadWordlist_Ranges(t *testing.T) {
+	wl, err := ReadWordlist(strings.NewReader("a\nb{1-3}\n"))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(wl) != 4 {
+		t.Errorf("Expected 4 words, got %v", wl)
+	}
+}
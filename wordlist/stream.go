@@ -0,0 +1,250 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package wordlist
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+)
+
+// A Stream reads the words of a wordlist on demand instead of holding them
+// in memory, so even multi-gigabyte lists cost a read buffer per reader.
+// The words are counted and hashed in one pass when the stream is created.
+type Stream struct {
+	open func() (io.ReadCloser, error)
+	// Optional mapping of each word to the words it stands for
+	mapping func(string) []string
+	count   int
+	hash    string
+}
+
+// Open a wordlist as a stream, resolving path the same way as LoadWordlist.
+func OpenWordlist(path string) (*Stream, error) {
+	if path == "" {
+		path = "default"
+	}
+	if IsRemote(path) {
+		f, err := NewFetcher("")
+		if err != nil {
+			return nil, err
+		}
+		cached, err := f.Resolve(path)
+		if err != nil {
+			return nil, err
+		}
+		return newStream(fileOpener(cached), nil)
+	}
+	if _, err := os.Stat(path); err != nil {
+		if words, ok := builtinWordlists[path]; ok {
+			return newStream(stringOpener(words), nil)
+		}
+	}
+	return newStream(fileOpener(path), nil)
+}
+
+// Create a stream over words already in memory.
+func NewStream(words []string) *Stream {
+	s, _ := newStream(stringOpener(strings.Join(words, "\n")), nil)
+	return s
+}
+
+func fileOpener(path string) func() (io.ReadCloser, error) {
+	return func() (io.ReadCloser, error) {
+		return openWordlistFile(path)
+	}
+}
+
+func stringOpener(words string) func() (io.ReadCloser, error) {
+	return func() (io.ReadCloser, error) {
+		return ioutil.NopCloser(strings.NewReader(words)), nil
+	}
+}
+
+func newStream(open func() (io.ReadCloser, error), mapping func(string) []string) (*Stream, error) {
+	s := &Stream{open: open, mapping: mapping}
+	it, err := s.Iterate(0)
+	if err != nil {
+		return nil, err
+	}
+	// Hashed the same way as the joined list, so hashes match between scans
+	h := sha256.New()
+	for it.Scan() {
+		if s.count > 0 {
+			h.Write([]byte{'\n'})
+		}
+		io.WriteString(h, it.Word())
+		s.count++
+	}
+	if err := it.Close(); err != nil {
+		return nil, err
+	}
+	s.hash = hex.EncodeToString(h.Sum(nil))
+	return s, nil
+}
+
+// A stream of the words f maps each word of s to, in order.
+func (s *Stream) Map(f func(string) []string) (*Stream, error) {
+	mapping := f
+	if s.mapping != nil {
+		mapping = func(w string) []string {
+			var words []string
+			for _, m := range s.mapping(w) {
+				words = append(words, f(m)...)
+			}
+			return words
+		}
+	}
+	return newStream(s.open, mapping)
+}
+
+// A stream of words followed by the words of s, mapped the same way.
+func (s *Stream) Prepend(words []string) (*Stream, error) {
+	if len(words) == 0 {
+		return s, nil
+	}
+	head := strings.Join(words, "\n") + "\n"
+	open := func() (io.ReadCloser, error) {
+		rdr, err := s.open()
+		if err != nil {
+			return nil, err
+		}
+		return &prependedReader{Reader: io.MultiReader(strings.NewReader(head), rdr), Closer: rdr}, nil
+	}
+	return newStream(open, s.mapping)
+}
+
+type prependedReader struct {
+	io.Reader
+	io.Closer
+}
+
+// A stream of the first n entries of s, counting an entry with ranges once.
+func (s *Stream) Head(n int) (*Stream, error) {
+	if n <= 0 || s.count <= n {
+		// Ranges only add words, so there are no more entries than words
+		return s, nil
+	}
+	open := func() (io.ReadCloser, error) {
+		rdr, err := s.open()
+		if err != nil {
+			return nil, err
+		}
+		head := &headReader{rdr: bufio.NewReader(rdr), left: n}
+		return &prependedReader{Reader: head, Closer: rdr}, nil
+	}
+	return newStream(open, s.mapping)
+}
+
+// Reads up to left non-empty lines.
+type headReader struct {
+	rdr  *bufio.Reader
+	left int
+	// Line not yet read
+	buf []byte
+}
+
+func (r *headReader) Read(p []byte) (int, error) {
+	for len(r.buf) == 0 {
+		if r.left == 0 {
+			return 0, io.EOF
+		}
+		line, err := r.rdr.ReadBytes('\n')
+		if len(strings.TrimRight(string(line), "\r\n")) > 0 {
+			r.left--
+		}
+		r.buf = line
+		if err != nil && len(line) == 0 {
+			return 0, err
+		}
+		if err != nil {
+			r.left = 0
+		}
+	}
+	n := copy(p, r.buf)
+	r.buf = r.buf[n:]
+	return n, nil
+}
+
+// Number of words in the stream.
+func (s *Stream) Len() int {
+	return s.count
+}
+
+// Hash of the words, for comparison between scans.
+func (s *Stream) Hash() string {
+	return s.hash
+}
+
+// Read the words in order, starting after the first skip.
+func (s *Stream) Iterate(skip int) (*Iterator, error) {
+	rdr, err := s.open()
+	if err != nil {
+		return nil, err
+	}
+	it := &Iterator{rdr: rdr, scanner: bufio.NewScanner(rdr), mapping: s.mapping}
+	for i := 0; i < skip && it.Scan(); i++ {
+	}
+	return it, nil
+}
+
+// An Iterator reads the words of a stream one at a time.
+type Iterator struct {
+	rdr     io.ReadCloser
+	scanner *bufio.Scanner
+	mapping func(string) []string
+	// Words expanded from the last line, not yet returned
+	pending []string
+	word    string
+}
+
+// Advance to the next word, returning false at the end or on error.
+func (it *Iterator) Scan() bool {
+	for len(it.pending) == 0 {
+		if !it.scanner.Scan() {
+			return false
+		}
+		if w := string(it.scanner.Bytes()); w != "" {
+			it.pending = ExpandRanges(w)
+			if it.mapping != nil {
+				var mapped []string
+				for _, p := range it.pending {
+					mapped = append(mapped, it.mapping(p)...)
+				}
+				it.pending = mapped
+			}
+		}
+	}
+	it.word, it.pending = it.pending[0], it.pending[1:]
+	return true
+}
+
+// The word found by the last call to Scan.
+func (it *Iterator) Word() string {
+	return it.word
+}
+
+// Close the underlying reader, returning the first error reading it.
+func (it *Iterator) Close() error {
+	err := it.scanner.Err()
+	if closeErr := it.rdr.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
@@ -0,0 +1,130 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package wordlist
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"testing"
+)
+
+func readStream(t *testing.T, s *Stream, skip int) []string {
+	it, err := s.Iterate(skip)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	var words []string
+	for it.Scan() {
+		words = append(words, it.Word())
+	}
+	if err := it.Close(); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	return words
+}
+
+func TestOpenWordlist(t *testing.T) {
+	for _, path := range []string{"testdata/testwl", "testdata/testwl.gz", "short", ""} {
+		s, err := OpenWordlist(path)
+		if err != nil {
+			t.Errorf("Expected no error opening %s, got %v", path, err)
+			continue
+		}
+		loaded, _ := LoadWordlist(path)
+		if got := readStream(t, s, 0); strings.Join(got, ",") != strings.Join(loaded, ",") {
+			t.Errorf("Expected stream of %s to match loaded wordlist, got %v", path, got)
+		}
+		if s.Len() != len(loaded) {
+			t.Errorf("Expected %d words in %s, got %d", len(loaded), path, s.Len())
+		}
+		sum := sha256.Sum256([]byte(strings.Join(loaded, "\n")))
+		if s.Hash() != hex.EncodeToString(sum[:]) {
+			t.Errorf("Expected hash of %s to match the joined wordlist.", path)
+		}
+	}
+	if _, err := OpenWordlist("this-doesnt-exist.txt"); err == nil {
+		t.Errorf("Expected error for non-existent wordlist.")
+	}
+}
+
+func TestStream_Iterate(t *testing.T) {
+	s := NewStream([]string{"a", "", "b{1-2}", "c"})
+	if s.Len() != 4 {
+		t.Errorf("Expected 4 words, got %d", s.Len())
+	}
+	if got := strings.Join(readStream(t, s, 2), ","); got != "b2,c" {
+		t.Errorf("Expected b2,c after skipping 2, got %s", got)
+	}
+	if got := readStream(t, s, 10); len(got) != 0 {
+		t.Errorf("Expected nothing after skipping past the end, got %v", got)
+	}
+}
+
+func TestStream_Map(t *testing.T) {
+	s, err := NewStream([]string{"a", "b"}).Map(func(w string) []string {
+		return []string{w, w + "/"}
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	s, err = s.Map(func(w string) []string {
+		if w == "b" {
+			return nil
+		}
+		return []string{w}
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if got := strings.Join(readStream(t, s, 0), ","); got != "a,a/,b/" || s.Len() != 3 {
+		t.Errorf("Expected a,a/,b/, got %s (%d)", got, s.Len())
+	}
+}
+
+func TestStream_Prepend(t *testing.T) {
+	s, err := NewStream([]string{"a", "b"}).Map(func(w string) []string {
+		return []string{w, w + "/"}
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	s, err = s.Prepend([]string{"x"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if got := strings.Join(readStream(t, s, 0), ","); got != "x,x/,a,a/,b,b/" || s.Len() != 6 {
+		t.Errorf("Expected x,x/,a,a/,b,b/, got %s (%d)", got, s.Len())
+	}
+	if same, _ := s.Prepend(nil); same != s {
+		t.Errorf("Expected the same stream with nothing to prepend")
+	}
+}
+
+func TestStream_Head(t *testing.T) {
+	s, err := NewStream([]string{"a", "", "b{1-2}", "c", "d"}).Head(2)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if got := strings.Join(readStream(t, s, 0), ","); got != "a,b1,b2" || s.Len() != 3 {
+		t.Errorf("Expected a,b1,b2, got %s (%d)", got, s.Len())
+	}
+	all := NewStream([]string{"a", "b"})
+	for _, n := range []int{0, 2, 5} {
+		if same, _ := all.Head(n); same != all {
+			t.Errorf("Expected the same stream for the first %d entries", n)
+		}
+	}
+}
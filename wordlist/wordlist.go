@@ -20,15 +20,19 @@ import (
 	"bufio"
 	"errors"
 	"io"
-	"os"
+	"sort"
 	"strings"
 )
 
-// First try loading from a file, then try loading from built-ins
+// First try loading from a file, then try loading from built-ins.  Remote
+// wordlists are loaded from the cache, downloading them if needed.
 func LoadWordlist(path string) ([]string, error) {
 	if path == "" {
 		return LoadBuiltinWordlist("default")
 	}
+	if IsRemote(path) {
+		return LoadRemoteWordlist(path)
+	}
 	wl, wl_err := ReadWordlistFile(path)
 	if wl_err == nil {
 		return wl, nil
@@ -39,14 +43,21 @@ func LoadWordlist(path string) ([]string, error) {
 	return nil, wl_err
 }
 
-// Load a Wordlist from a file.
+// Load a Wordlist from a file, which may be compressed with gzip (.gz),
+// bzip2 (.bz2), zstd (.zst) or xz (.xz).
 func ReadWordlistFile(path string) ([]string, error) {
-	if fp, err := os.Open(path); err != nil {
+	rdr, err := openWordlistFile(path)
+	if err != nil {
 		return nil, err
-	} else {
-		defer fp.Close()
-		return ReadWordlist(fp)
 	}
+	wl, err := ReadWordlist(rdr)
+	if closeErr := rdr.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return nil, err
+	}
+	return wl, nil
 }
 
 // Load a wordlist from a reader.
@@ -57,7 +68,7 @@ func ReadWordlist(rdr io.Reader) ([]string, error) {
 	for scanner.Scan() {
 		w := string(scanner.Bytes())
 		if w != "" {
-			wordlist = append(wordlist, w)
+			wordlist = append(wordlist, ExpandRanges(w)...)
 		}
 	}
 	if err := scanner.Err(); err != nil {
@@ -66,13 +77,26 @@ func ReadWordlist(rdr io.Reader) ([]string, error) {
 	return wordlist, nil
 }
 
+var builtinWordlists = map[string]string{
+	"default":   DefaultWordlist,
+	"short":     ShortWordlist,
+	"wordpress": WordpressWordlist,
+}
+
 // Loads a built-in wordlist for basic scans.
 func LoadBuiltinWordlist(which string) ([]string, error) {
-	switch which {
-	case "default":
-		return ReadWordlist(strings.NewReader(DefaultWordlist))
-	case "short":
-		return ReadWordlist(strings.NewReader(ShortWordlist))
+	if words, ok := builtinWordlists[which]; ok {
+		return ReadWordlist(strings.NewReader(words))
 	}
 	return nil, errors.New("No such built-in wordlist.")
 }
+
+// Names of the built-in wordlists, sorted.
+func BuiltinWordlistNames() []string {
+	names := make([]string, 0, len(builtinWordlists))
+	for name := range builtinWordlists {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
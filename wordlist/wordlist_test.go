@@ -15,11 +15,12 @@
 package wordlist
 
 import (
+	"strings"
 	"testing"
 )
 
 func TestLoadBuiltinWordlist(t *testing.T) {
-	for _, wl := range []string{"default", "short"} {
+	for _, wl := range []string{"default", "short", "wordpress"} {
 		if list, err := LoadBuiltinWordlist(wl); err != nil {
 			t.Errorf("Error when loading builtin wordlist %s: %v", wl, err)
 		} else if list == nil {
@@ -36,6 +37,13 @@ func TestLoadBuiltinWordlist(t *testing.T) {
 	}
 }
 
+func TestBuiltinWordlistNames(t *testing.T) {
+	names := BuiltinWordlistNames()
+	if strings.Join(names, ",") != "default,short,wordpress" {
+		t.Errorf("Expected default,short,wordpress, got %v", names)
+	}
+}
+
 func TestLoadWordlist_File(t *testing.T) {
 	if wl, err := LoadWordlist("testdata/testwl"); err != nil {
 		t.Errorf("Expected no error loading wordlist, got: %v", err)
@@ -0,0 +1,41 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package wordlist
+
+var WordpressWordlist = `
+wp-admin/
+wp-admin/admin-ajax.php
+wp-admin/install.php
+wp-admin/setup-config.php
+wp-config.php
+wp-config.php.bak
+wp-content/
+wp-content/backup-db/
+wp-content/debug.log
+wp-content/plugins/
+wp-content/themes/
+wp-content/uploads/
+wp-cron.php
+wp-includes/
+wp-json/
+wp-json/wp/v2/users
+wp-links-opml.php
+wp-login.php
+wp-signup.php
+wp-trackback.php
+xmlrpc.php
+readme.html
+license.txt
+`
@@ -0,0 +1,118 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package worker
+
+import (
+	"fmt"
+	"math"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Responses seen for a status in a directory before outliers are flagged
+const anomalyWarmup = 20
+
+// Standard deviations from the mean that make an outlier
+const anomalyDeviations = 4
+
+// AnomalyDetector learns the length and response time of each status code in
+// each directory, and flags responses far from the rest.  A page that is much
+// longer than every other 404 around it, or much slower than its siblings, is
+// worth a look even though no rule would report it.
+type AnomalyDetector struct {
+	stats map[string]*responseStats
+	sync.Mutex
+}
+
+// Running mean and variance of one status in one directory.
+type responseStats struct {
+	n      int
+	length runningStats
+	time   runningStats
+}
+
+// Welford's online mean and variance.
+type runningStats struct {
+	mean float64
+	m2   float64
+}
+
+func (r *runningStats) add(n int, x float64) {
+	delta := x - r.mean
+	r.mean += delta / float64(n)
+	r.m2 += delta * (x - r.mean)
+}
+
+func (r *runningStats) stddev(n int) float64 {
+	if n < 2 {
+		return 0
+	}
+	return math.Sqrt(r.m2 / float64(n-1))
+}
+
+func NewAnomalyDetector() *AnomalyDetector {
+	return &AnomalyDetector{stats: make(map[string]*responseStats)}
+}
+
+// Learn a response to u, returning why it stands out from the others in its
+// directory with the same status, or "" if it doesn't.  Lengths below 0 are
+// unknown and not counted.
+func (d *AnomalyDetector) Observe(u *url.URL, code int, length int64, elapsed time.Duration) string {
+	if d == nil {
+		return ""
+	}
+	d.Lock()
+	defer d.Unlock()
+	key := fmt.Sprintf("%s %s %d", u.Host, parentDir(u.Path), code)
+	s, ok := d.stats[key]
+	if !ok {
+		s = &responseStats{}
+		d.stats[key] = s
+	}
+	var reasons []string
+	if s.n >= anomalyWarmup {
+		if length >= 0 {
+			// Pages that echo the path vary a little in length
+			spread := math.Max(s.length.stddev(s.n), math.Max(s.length.mean*0.05, 16))
+			if math.Abs(float64(length)-s.length.mean) > anomalyDeviations*spread {
+				reasons = append(reasons, fmt.Sprintf("%d bytes, usually %.0f", length, s.length.mean))
+			}
+		}
+		// Only much slower responses are interesting, timing is noisy
+		seconds := elapsed.Seconds()
+		spread := math.Max(s.time.stddev(s.n), math.Max(s.time.mean*0.5, 0.05))
+		if seconds-s.time.mean > anomalyDeviations*spread {
+			usual := time.Duration(s.time.mean * float64(time.Second))
+			reasons = append(reasons, fmt.Sprintf("took %s, usually %s", elapsed.Round(time.Millisecond), usual.Round(time.Millisecond)))
+		}
+	}
+	if length >= 0 {
+		s.n++
+		s.length.add(s.n, float64(length))
+		s.time.add(s.n, elapsed.Seconds())
+	}
+	return strings.Join(reasons, ", ")
+}
+
+// The directory containing p, including the trailing slash.
+func parentDir(p string) string {
+	trimmed := strings.TrimSuffix(p, "/")
+	if i := strings.LastIndex(trimmed, "/"); i >= 0 {
+		return trimmed[:i+1]
+	}
+	return "/"
+}
@@ -0,0 +1,94 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package worker
+
+import (
+	"fmt"
+	"github.com/Matir/gobuster/client/mock"
+	"github.com/Matir/gobuster/results"
+	"github.com/Matir/gobuster/settings"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAnomalyDetector(t *testing.T) {
+	d := NewAnomalyDetector()
+	at := func(p string) *url.URL {
+		return &url.URL{Scheme: "http", Host: "localhost", Path: p}
+	}
+	for i := 0; i < anomalyWarmup; i++ {
+		// Not found pages that echo the path
+		if a := d.Observe(at(fmt.Sprintf("/app/word%d", i)), 404, int64(310+i%5), 20*time.Millisecond); a != "" {
+			t.Errorf("Expected nothing flagged while learning, got %s", a)
+		}
+	}
+	if a := d.Observe(at("/app/other"), 404, 318, 25*time.Millisecond); a != "" {
+		t.Errorf("Expected a usual response not to be flagged, got %s", a)
+	}
+	if a := d.Observe(at("/app/debug"), 404, 5120, 20*time.Millisecond); !strings.Contains(a, "5120 bytes") {
+		t.Errorf("Expected a long 404 to be flagged, got %q", a)
+	}
+	if a := d.Observe(at("/app/report"), 404, 312, 2*time.Second); !strings.Contains(a, "took 2s") {
+		t.Errorf("Expected a slow 404 to be flagged, got %q", a)
+	}
+	if a := d.Observe(at("/app/admin"), 200, 5120, 20*time.Millisecond); a != "" {
+		t.Errorf("Expected another status to be learned separately, got %s", a)
+	}
+	if a := d.Observe(at("/other/debug"), 404, 5120, 20*time.Millisecond); a != "" {
+		t.Errorf("Expected another directory to be learned separately, got %s", a)
+	}
+	var nilDetector *AnomalyDetector
+	if a := nilDetector.Observe(at("/app/debug"), 404, 5120, time.Second); a != "" {
+		t.Errorf("Expected nil detector never to flag, got %s", a)
+	}
+}
+
+func TestParentDir(t *testing.T) {
+	for p, want := range map[string]string{
+		"/":          "/",
+		"/a":         "/",
+		"/a/b.php":   "/a/",
+		"/a/b/":      "/a/",
+		"/a/b/c.txt": "/a/b/",
+	} {
+		if got := parentDir(p); got != want {
+			t.Errorf("Expected %s for %s, got %s", want, p, got)
+		}
+	}
+}
+
+func TestTryURL_Anomaly(t *testing.T) {
+	d := NewAnomalyDetector()
+	for i := 0; i < anomalyWarmup; i++ {
+		d.Observe(&url.URL{Scheme: "http", Host: "localhost", Path: fmt.Sprintf("/w%d", i)}, 404, 9, 0)
+	}
+	resp := mock.ResponseFromString(strings.Repeat("debug info ", 100))
+	resp.StatusCode = 404
+	resp.ContentLength = 1100
+	rchan := make(chan results.Result, 1)
+	w := &Worker{
+		client:    &mock.MockClient{ForeverResponse: resp},
+		settings:  &settings.ScanSettings{},
+		rchan:     rchan,
+		adder:     noopUrl,
+		anomalies: d,
+	}
+	w.TryURL(&url.URL{Scheme: "http", Host: "localhost", Path: "/debug"})
+	if r := <-rchan; r.Anomaly == "" || !results.ReportResult(r) {
+		t.Errorf("Expected an anomalous 404 to be reported, got %+v", r)
+	}
+}
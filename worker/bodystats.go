@@ -0,0 +1,85 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package worker
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"hash"
+	"unicode"
+	"unicode/utf8"
+)
+
+// BodyStats accumulates statistics about a response body as it is streamed,
+// so bodies never need to be buffered in memory.  It is an io.Writer meant to
+// be fed via io.TeeReader.
+type BodyStats struct {
+	// Number of bytes seen
+	Length int64
+	// Number of whitespace-separated words
+	Words int
+	// Number of lines
+	Lines int
+	hash  hash.Hash
+	// Whether the last byte seen was within a word
+	inWord bool
+	// Partial UTF-8 sequence carried between writes
+	partial []byte
+}
+
+func NewBodyStats() *BodyStats {
+	return &BodyStats{hash: sha256.New()}
+}
+
+func (s *BodyStats) Write(p []byte) (int, error) {
+	s.hash.Write(p)
+	s.Length += int64(len(p))
+	buf := p
+	if len(s.partial) > 0 {
+		buf = append(s.partial, p...)
+		s.partial = nil
+	}
+	for len(buf) > 0 {
+		r, size := utf8.DecodeRune(buf)
+		if r == utf8.RuneError && size == 1 && !utf8.FullRune(buf) {
+			s.partial = append([]byte(nil), buf...)
+			break
+		}
+		buf = buf[size:]
+		if r == '\n' {
+			s.Lines++
+		}
+		if unicode.IsSpace(r) {
+			s.inWord = false
+		} else if !s.inWord {
+			s.inWord = true
+			s.Words++
+		}
+	}
+	return len(p), nil
+}
+
+// Hex-encoded SHA-256 of the body so far.
+func (s *BodyStats) Sum() string {
+	return hex.EncodeToString(s.hash.Sum(nil))
+}
+
+// Total lines, counting a final unterminated line.
+func (s *BodyStats) LineCount() int {
+	if s.inWord {
+		return s.Lines + 1
+	}
+	return s.Lines
+}
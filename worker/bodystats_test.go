@@ -0,0 +1,54 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package worker
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestBodyStats(t *testing.T) {
+	body := "hello world\nthis is a test\nend"
+	s := NewBodyStats()
+	// Copy in tiny chunks to exercise streaming
+	buf := make([]byte, 3)
+	io.CopyBuffer(s, strings.NewReader(body), buf)
+	if s.Length != int64(len(body)) {
+		t.Errorf("Expected length %d, got %d", len(body), s.Length)
+	}
+	if s.Words != 7 {
+		t.Errorf("Expected 7 words, got %d", s.Words)
+	}
+	if s.LineCount() != 3 {
+		t.Errorf("Expected 3 lines, got %d", s.LineCount())
+	}
+	sum := sha256.Sum256([]byte(body))
+	if s.Sum() != hex.EncodeToString(sum[:]) {
+		t.Errorf("Hash mismatch: %s", s.Sum())
+	}
+}
+
+func TestBodyStats_SplitRune(t *testing.T) {
+	s := NewBodyStats()
+	b := []byte("café ok")
+	s.Write(b[:4])
+	s.Write(b[4:])
+	if s.Words != 2 {
+		t.Errorf("Expected 2 words, got %d", s.Words)
+	}
+}
@@ -0,0 +1,203 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package worker
+
+import (
+	"encoding/xml"
+	"github.com/Matir/gobuster/client"
+	"github.com/Matir/gobuster/logging"
+	"github.com/Matir/gobuster/results"
+	ss "github.com/Matir/gobuster/settings"
+	"github.com/Matir/gobuster/workqueue"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// Most of a bucket response to read for its error code
+const maxBucketErrorRead = 64 * 1024
+
+// BucketWorker brute-forces cloud storage buckets.  Each URL names a
+// candidate bucket under a storage endpoint, as a subdomain for S3 or a path
+// for GCS; listing it shows whether the bucket exists and whether anyone can
+// list it.
+type BucketWorker struct {
+	// Channel of bucket URLs to probe
+	src <-chan *url.URL
+	// Client to make requests with
+	client client.Client
+	// Function to mark work done
+	done workqueue.QueueDoneFunc
+	// Channel for scan results
+	rchan chan<- results.Result
+	// How URLs entered the queue
+	provenance *workqueue.ProvenanceTracker
+	// Completion of targets
+	dirs *workqueue.DirectoryTracker
+	// Progress for resuming
+	state *workqueue.StateTracker
+	// Endpoints, to say which target results belong to
+	targets []*url.URL
+	// Hooks of a program embedding the scanner, if any
+	hooks *results.Hooks
+	// Delay between requests
+	sleep time.Duration
+	// Channel to trigger stopping
+	stop chan bool
+}
+
+// The error document S3 and GCS send with failed requests.
+type bucketError struct {
+	Code string
+}
+
+func (w *BucketWorker) Run() {
+	for true {
+		select {
+		case <-w.stop:
+			return
+		case task, ok := <-w.src:
+			if !ok {
+				return
+			}
+			w.HandleURL(task)
+		}
+	}
+}
+
+func (w *BucketWorker) RunInBackground() {
+	go w.Run()
+}
+
+func (w *BucketWorker) Stop() {
+	w.stop <- true
+}
+
+func (w *BucketWorker) HandleURL(task *url.URL) {
+	target := workqueue.TargetOf(w.targets, task)
+	if target == nil {
+		target = domainTarget(w.targets, task.Hostname())
+	}
+	if target == nil || task.String() != target.String() {
+		logging.Logf(logging.LogInfo, "Probing bucket: %s", task)
+		w.probe(task, target)
+		if w.sleep != 0 {
+			time.Sleep(w.sleep)
+		}
+	}
+	w.dirs.Done(task)
+	w.state.Done(task)
+	w.provenance.Done(task)
+	w.done(1)
+}
+
+func (w *BucketWorker) probe(task, target *url.URL) {
+	if !w.hooks.Request(task) {
+		logging.Logf(logging.LogDebug, "Hook skipped %s.", task.String())
+		return
+	}
+	resp, err := w.client.RequestURL(task)
+	if err != nil {
+		logging.Logf(logging.LogWarning, "Error probing %s: %s", task, err.Error())
+		return
+	}
+	defer resp.Body.Close()
+	w.hooks.Response(task, resp)
+	body, err := ioutil.ReadAll(io.LimitReader(resp.Body, maxBucketErrorRead))
+	if err != nil {
+		logging.Logf(logging.LogWarning, "Error reading %s: %s", task, err.Error())
+		return
+	}
+	var bucketErr bucketError
+	xml.Unmarshal(body, &bucketErr)
+	bucket := bucketAccess(resp.StatusCode, bucketErr.Code)
+	if bucket == "" {
+		if resp.StatusCode == http.StatusNotFound || bucketErr.Code == "InvalidBucketName" {
+			logging.Logf(logging.LogDebug, "No bucket %s: %s", task, bucketErr.Code)
+		} else {
+			logging.Logf(logging.LogWarning, "Unexpected response for bucket %s: %d %s", task, resp.StatusCode, bucketErr.Code)
+		}
+		return
+	}
+	result := results.Result{
+		URL:      task,
+		Code:     resp.StatusCode,
+		Protocol: resp.Proto,
+		Length:   int64(len(body)),
+		Bucket:   bucket,
+		Source:   string(w.provenance.Lookup(task).Source),
+	}
+	if target != nil {
+		result.Target = target.String()
+	}
+	report(w.hooks, w.rchan, result)
+}
+
+// What a listing response says about a bucket, or "" if it doesn't exist.
+func bucketAccess(code int, errCode string) string {
+	switch {
+	case code == http.StatusOK:
+		return results.BucketPublic
+	case code == http.StatusForbidden || code == http.StatusUnauthorized:
+		return results.BucketPrivate
+	case code == http.StatusMovedPermanently || code == http.StatusTemporaryRedirect:
+		return results.BucketMoved
+	case code == http.StatusBadRequest && errCode == "AuthorizationHeaderMalformed":
+		// Asked S3 in the wrong region
+		return results.BucketMoved
+	}
+	return ""
+}
+
+// Starts a batch of bucket workers based on the relevant settings.
+func StartBucketWorkers(settings *ss.ScanSettings,
+	factory client.ClientFactory,
+	src <-chan *url.URL,
+	provenance *workqueue.ProvenanceTracker,
+	dirs *workqueue.DirectoryTracker,
+	state *workqueue.StateTracker,
+	hooks *results.Hooks,
+	done workqueue.QueueDoneFunc,
+	rchan chan<- results.Result) []*BucketWorker {
+	targets, err := settings.GetScopes()
+	if err != nil {
+		logging.Logf(logging.LogError, "Unable to label results by target: %s", err.Error())
+	}
+	workers := make([]*BucketWorker, settings.Workers)
+	for i := range workers {
+		c := factory.Get()
+		// A redirect to another endpoint already shows the bucket exists
+		c.SetCheckRedirect(func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		})
+		workers[i] = &BucketWorker{
+			src:        src,
+			client:     c,
+			done:       done,
+			rchan:      rchan,
+			provenance: provenance,
+			dirs:       dirs,
+			state:      state,
+			targets:    targets,
+			hooks:      hooks,
+			sleep:      settings.SleepTime,
+			stop:       make(chan bool),
+		}
+		workers[i].RunInBackground()
+	}
+	return workers
+}
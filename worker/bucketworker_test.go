@@ -0,0 +1,167 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package worker
+
+import (
+	"fmt"
+	"github.com/Matir/gobuster/results"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+type stubBucket struct {
+	code    int
+	errCode string
+}
+
+// Answers listings of the buckets it knows, by host and path, with their
+// status and error code, and everything else with NoSuchBucket.
+type bucketStub map[string]stubBucket
+
+func (s bucketStub) RequestURL(u *url.URL) (*http.Response, error) {
+	bucket, ok := s[u.Host+u.Path]
+	if !ok {
+		bucket.code, bucket.errCode = http.StatusNotFound, "NoSuchBucket"
+	}
+	body := "<ListBucketResult></ListBucketResult>"
+	if bucket.errCode != "" {
+		body = fmt.Sprintf("<Error><Code>%s</Code></Error>", bucket.errCode)
+	}
+	return &http.Response{StatusCode: bucket.code, Body: ioutil.NopCloser(strings.NewReader(body))}, nil
+}
+
+func (s bucketStub) SetCheckRedirect(func(*http.Request, []*http.Request) error) {}
+
+func TestBucketWorker_HandleURL_S3(t *testing.T) {
+	endpoint := &url.URL{Scheme: "https", Host: "s3.amazonaws.com", Path: "/"}
+	stub := bucketStub{
+		"logs.s3.amazonaws.com/":    {200, ""},
+		"backups.s3.amazonaws.com/": {403, "AccessDenied"},
+		"eu.s3.amazonaws.com/":      {301, "PermanentRedirect"},
+	}
+	rchan := make(chan results.Result, 10)
+	done := 0
+	w := &BucketWorker{
+		client:  stub,
+		done:    func(n int) { done += n },
+		rchan:   rchan,
+		targets: []*url.URL{endpoint},
+	}
+	w.HandleURL(endpoint)
+	for _, name := range []string{"logs", "backups", "nope", "eu"} {
+		w.HandleURL(&url.URL{Scheme: "https", Host: name + ".s3.amazonaws.com", Path: "/"})
+	}
+	close(rchan)
+	var found []string
+	for r := range rchan {
+		found = append(found, fmt.Sprintf("%s=%s@%s", r.URL.Hostname(), r.Bucket, r.Target))
+	}
+	expected := "logs.s3.amazonaws.com=public@https://s3.amazonaws.com/ backups.s3.amazonaws.com=private@https://s3.amazonaws.com/ eu.s3.amazonaws.com=moved@https://s3.amazonaws.com/"
+	if got := strings.Join(found, " "); got != expected {
+		t.Errorf("Expected %s, got %s", expected, got)
+	}
+	if done != 5 {
+		t.Errorf("Expected 5 done, got %d", done)
+	}
+}
+
+func TestBucketWorker_Hooks(t *testing.T) {
+	endpoint := &url.URL{Scheme: "https", Host: "s3.amazonaws.com", Path: "/"}
+	stub := bucketStub{
+		"logs.s3.amazonaws.com/":    {200, ""},
+		"backups.s3.amazonaws.com/": {403, "AccessDenied"},
+		"skip.s3.amazonaws.com/":    {200, ""},
+	}
+	rchan := make(chan results.Result, 10)
+	var responses []string
+	w := &BucketWorker{
+		client:  stub,
+		done:    func(int) {},
+		rchan:   rchan,
+		targets: []*url.URL{endpoint},
+		hooks: &results.Hooks{
+			OnRequest: func(u *url.URL) bool { return u.Hostname() != "skip.s3.amazonaws.com" },
+			OnResponse: func(u *url.URL, resp *http.Response) {
+				responses = append(responses, u.Hostname())
+			},
+			OnFinding: func(r results.Result) bool { return r.Bucket != results.BucketPrivate },
+		},
+	}
+	for _, name := range []string{"logs", "backups", "skip"} {
+		w.HandleURL(&url.URL{Scheme: "https", Host: name + ".s3.amazonaws.com", Path: "/"})
+	}
+	close(rchan)
+	var found []string
+	for r := range rchan {
+		found = append(found, r.URL.Hostname())
+	}
+	if got := strings.Join(found, " "); got != "logs.s3.amazonaws.com" {
+		t.Errorf("Expected only logs.s3.amazonaws.com reported, got %s", got)
+	}
+	if got := strings.Join(responses, " "); got != "logs.s3.amazonaws.com backups.s3.amazonaws.com" {
+		t.Errorf("Expected responses for logs and backups, got %s", got)
+	}
+}
+
+func TestBucketWorker_HandleURL_GCS(t *testing.T) {
+	endpoint := &url.URL{Scheme: "https", Host: "storage.googleapis.com", Path: "/"}
+	stub := bucketStub{
+		"storage.googleapis.com/open_data": {200, ""},
+		"storage.googleapis.com/secrets":   {401, "AccessDenied"},
+	}
+	rchan := make(chan results.Result, 10)
+	w := &BucketWorker{
+		client:  stub,
+		done:    func(int) {},
+		rchan:   rchan,
+		targets: []*url.URL{endpoint},
+	}
+	w.HandleURL(endpoint)
+	for _, name := range []string{"open_data", "secrets", "nope"} {
+		w.HandleURL(&url.URL{Scheme: "https", Host: "storage.googleapis.com", Path: "/" + name})
+	}
+	close(rchan)
+	var found []string
+	for r := range rchan {
+		found = append(found, fmt.Sprintf("%s=%s@%s", r.URL.Path, r.Bucket, r.Target))
+	}
+	expected := "/open_data=public@https://storage.googleapis.com/ /secrets=private@https://storage.googleapis.com/"
+	if got := strings.Join(found, " "); got != expected {
+		t.Errorf("Expected %s, got %s", expected, got)
+	}
+}
+
+func TestBucketAccess(t *testing.T) {
+	for _, c := range []struct {
+		code     int
+		errCode  string
+		expected string
+	}{
+		{200, "", results.BucketPublic},
+		{403, "AccessDenied", results.BucketPrivate},
+		{401, "", results.BucketPrivate},
+		{307, "TemporaryRedirect", results.BucketMoved},
+		{400, "AuthorizationHeaderMalformed", results.BucketMoved},
+		{400, "InvalidBucketName", ""},
+		{404, "NoSuchBucket", ""},
+	} {
+		if got := bucketAccess(c.code, c.errCode); got != c.expected {
+			t.Errorf("Expected %q for %d %s, got %q", c.expected, c.code, c.errCode, got)
+		}
+	}
+}
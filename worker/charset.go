@@ -0,0 +1,37 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package worker
+
+import (
+	"bufio"
+	"golang.org/x/net/html/charset"
+	"golang.org/x/text/encoding/unicode"
+	"golang.org/x/text/transform"
+	"io"
+)
+
+// How far into a document to look for a <meta> charset, as browsers do.
+const charsetPrescanLen = 1024
+
+// Wrap an HTML body so it reads as UTF-8.  The encoding is taken from a
+// byte order mark, then the Content-Type header, then a <meta> tag, in the
+// same order browsers use, and is windows-1252 if none of them say.
+func NewCharsetReader(body io.Reader, contentType string) io.Reader {
+	buf := bufio.NewReaderSize(body, charsetPrescanLen)
+	prefix, _ := buf.Peek(charsetPrescanLen)
+	enc, _, _ := charset.DetermineEncoding(prefix, contentType)
+	// Drop the byte order mark rather than passing it on to the parser
+	return transform.NewReader(buf, unicode.BOMOverride(enc.NewDecoder()))
+}
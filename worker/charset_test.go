@@ -0,0 +1,48 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package worker
+
+import (
+	"bytes"
+	"io/ioutil"
+	"testing"
+)
+
+func TestNewCharsetReader(t *testing.T) {
+	cases := []struct {
+		body        []byte
+		contentType string
+		expected    string
+	}{
+		{[]byte("<a href=\"/plain\">"), "text/html", "<a href=\"/plain\">"},
+		{append([]byte("<a href=\"/"), 0x83, 0x65, 0x83, 0x58, 0x83, 0x67, '"', '>'), "text/html; charset=shift_jis", "<a href=\"/テスト\">"},
+		{append([]byte("<meta charset=euc-jp><a href=\"/"), 0xa5, 0xc6, 0xa5, 0xb9, 0xa5, 0xc8, '"', '>'), "text/html", "<meta charset=euc-jp><a href=\"/テスト\">"},
+		{append([]byte("<a href=\"/"), 0xcf, 0xf0, 0xe8, 0xe2, 0xe5, 0xf2, '"', '>'), "text/html; charset=windows-1251", "<a href=\"/Привет\">"},
+		{[]byte("\xff\xfe<\x00a\x00 \x00/\x00\xe9\x00"), "text/html", "<a /é"},
+		{[]byte("\xfe\xff\x00<\xd8\x3d\xde\x00"), "text/html", "<😀"},
+		{[]byte("\xef\xbb\xbf<a>"), "text/html", "<a>"},
+		// Truncated double-byte character doesn't swallow the quote
+		{[]byte("/\x83\""), "text/html; charset=shift_jis", "/�\""},
+	}
+	for _, c := range cases {
+		res, err := ioutil.ReadAll(NewCharsetReader(bytes.NewReader(c.body), c.contentType))
+		if err != nil {
+			t.Errorf("Unexpected error: %s", err)
+		}
+		if string(res) != c.expected {
+			t.Errorf("Expected %q, got %q", c.expected, string(res))
+		}
+	}
+}
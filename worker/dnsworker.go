@@ -0,0 +1,350 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package worker
+
+import (
+	"fmt"
+	"github.com/Matir/gobuster/client"
+	"github.com/Matir/gobuster/logging"
+	"github.com/Matir/gobuster/results"
+	ss "github.com/Matir/gobuster/settings"
+	"github.com/Matir/gobuster/workqueue"
+	"math/rand"
+	"net"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DNSWorker brute-forces subdomains.  Rather than requesting each URL, it
+// resolves the URL's hostname and reports the hosts that exist.
+type DNSWorker struct {
+	// Channel of URLs whose hosts to resolve
+	src <-chan *url.URL
+	// Function used for lookups
+	lookup func(string) ([]string, error)
+	// Wildcard records, shared between workers
+	wildcards *DNSWildcards
+	// Other record types to look up for hosts found
+	recordTypes []string
+	// Function used to look up other records
+	records func(string, string) ([]string, error)
+	// Zone transfers tried, shared between workers, if trying them
+	transfers *ZoneTransfers
+	// Function to mark work done
+	done workqueue.QueueDoneFunc
+	// Channel for scan results
+	rchan chan<- results.Result
+	// How URLs entered the queue
+	provenance *workqueue.ProvenanceTracker
+	// Completion of targets
+	dirs *workqueue.DirectoryTracker
+	// Progress for resuming
+	state *workqueue.StateTracker
+	// Base URLs, to say which target results belong to
+	targets []*url.URL
+	// Hooks of a program embedding the scanner, if any
+	hooks *results.Hooks
+	// Delay between lookups
+	sleep time.Duration
+	// Channel to trigger stopping
+	stop chan bool
+}
+
+func (w *DNSWorker) Run() {
+	for true {
+		select {
+		case <-w.stop:
+			return
+		case task, ok := <-w.src:
+			if !ok {
+				return
+			}
+			w.HandleURL(task)
+		}
+	}
+}
+
+func (w *DNSWorker) RunInBackground() {
+	go w.Run()
+}
+
+func (w *DNSWorker) Stop() {
+	w.stop <- true
+}
+
+func (w *DNSWorker) HandleURL(task *url.URL) {
+	if w.hooks.Request(task) {
+		w.resolve(task)
+		if w.sleep != 0 {
+			time.Sleep(w.sleep)
+		}
+	} else {
+		logging.Logf(logging.LogDebug, "Hook skipped %s.", task.String())
+	}
+	w.dirs.Done(task)
+	w.state.Done(task)
+	w.provenance.Done(task)
+	w.done(1)
+}
+
+// Resolve the host task names, reporting it if it exists, and try transfers
+// of its zone and its target's.
+func (w *DNSWorker) resolve(task *url.URL) {
+	host := task.Hostname()
+	logging.Logf(logging.LogInfo, "Resolving: %s", host)
+	target := domainTarget(w.targets, host)
+	addrs, err := w.lookup(host)
+	if dnsErr, ok := err.(*net.DNSError); ok && dnsErr.IsNotFound {
+		logging.Logf(logging.LogDebug, "No such host %s.", host)
+	} else if err != nil {
+		logging.Logf(logging.LogWarning, "Unable to resolve %s: %s", host, err.Error())
+	} else if target != nil && target.Hostname() != host && w.wildcards.Matches(target.Hostname(), addrs) {
+		logging.Logf(logging.LogDebug, "Dropping wildcard match %s.", host)
+	} else {
+		result := results.Result{
+			URL:    task,
+			Length: -1,
+			Addrs:  addrs,
+			Source: string(w.provenance.Lookup(task).Source),
+		}
+		if target != nil {
+			result.Target = target.String()
+		}
+		result.Records = w.lookupRecords(host)
+		report(w.hooks, w.rchan, result)
+		if target != nil {
+			w.transferZone(host, target)
+		}
+	}
+	if target != nil {
+		w.transferZone(target.Hostname(), target)
+	}
+}
+
+// Look up the other record types for host.
+func (w *DNSWorker) lookupRecords(host string) []string {
+	var records []string
+	for _, rtype := range w.recordTypes {
+		found, err := w.records(host, rtype)
+		if dnsErr, ok := err.(*net.DNSError); ok && dnsErr.IsNotFound {
+			continue
+		} else if err != nil {
+			logging.Logf(logging.LogDebug, "Unable to look up %s records for %s: %s", rtype, host, err.Error())
+			continue
+		}
+		records = append(records, found...)
+	}
+	return records
+}
+
+// Try a transfer of zone, reporting every name in it if allowed.
+func (w *DNSWorker) transferZone(zone string, target *url.URL) {
+	records, server := w.transfers.Try(zone)
+	if records == nil {
+		return
+	}
+	logging.Logf(logging.LogWarning, "%s allows transfers of %s, reporting its %d records.", server, zone, len(records))
+	byName := make(map[string]*results.Result)
+	var names []string
+	for _, rr := range records {
+		name := strings.ToLower(rr.Name)
+		result, ok := byName[name]
+		if !ok {
+			u := *target
+			u.Host = name
+			if port := target.Port(); port != "" {
+				u.Host = net.JoinHostPort(name, port)
+			}
+			result = &results.Result{
+				URL:    &u,
+				Length: -1,
+				Source: string(workqueue.SourceZoneTransfer),
+				Target: target.String(),
+			}
+			if name == strings.ToLower(zone) {
+				result.ZoneTransfer = server
+			}
+			byName[name] = result
+			names = append(names, name)
+		}
+		if rr.Type == "A" || rr.Type == "AAAA" {
+			result.Addrs = append(result.Addrs, rr.Value)
+		} else {
+			result.Records = append(result.Records, rr.Type+" "+rr.Value)
+		}
+	}
+	for _, name := range names {
+		report(w.hooks, w.rchan, *byName[name])
+	}
+}
+
+// The target whose domain host is in, if any.
+func domainTarget(targets []*url.URL, host string) *url.URL {
+	var target *url.URL
+	for _, t := range targets {
+		domain := t.Hostname()
+		if host != domain && !strings.HasSuffix(host, "."+domain) {
+			continue
+		}
+		if target == nil || len(domain) > len(target.Hostname()) {
+			target = t
+		}
+	}
+	return target
+}
+
+// DNSWildcards holds the addresses that random names under each domain
+// resolve to, so hosts found only through a wildcard record aren't reported.
+type DNSWildcards struct {
+	lookup func(string) ([]string, error)
+	mu     sync.Mutex
+	addrs  map[string]map[string]bool
+}
+
+func NewDNSWildcards(lookup func(string) ([]string, error)) *DNSWildcards {
+	return &DNSWildcards{lookup: lookup, addrs: make(map[string]map[string]bool)}
+}
+
+// Whether addrs are all those of a wildcard record for domain.  Each domain
+// is checked once, by resolving a random name under it.
+func (d *DNSWildcards) Matches(domain string, addrs []string) bool {
+	d.mu.Lock()
+	wildcard, ok := d.addrs[domain]
+	if !ok {
+		wildcard = make(map[string]bool)
+		name := fmt.Sprintf("%x.%s", rand.Int63(), domain)
+		if found, err := d.lookup(name); err == nil {
+			logging.Logf(logging.LogInfo, "Wildcard DNS for *.%s: %s", domain, strings.Join(found, ", "))
+			for _, addr := range found {
+				wildcard[addr] = true
+			}
+		}
+		d.addrs[domain] = wildcard
+	}
+	d.mu.Unlock()
+	if len(wildcard) == 0 {
+		return false
+	}
+	for _, addr := range addrs {
+		if !wildcard[addr] {
+			return false
+		}
+	}
+	return true
+}
+
+// ZoneTransfers tries a transfer of each zone from each of its nameservers,
+// once per scan.  A nil ZoneTransfers doesn't try any.
+type ZoneTransfers struct {
+	// Function used to look up nameservers, as "NS host" records
+	records func(string, string) ([]string, error)
+	// Function used to request a transfer from a nameserver
+	transfer func(string, string) ([]client.DNSRecord, error)
+	mu       sync.Mutex
+	tried    map[string]bool
+}
+
+func NewZoneTransfers(records func(string, string) ([]string, error), transfer func(string, string) ([]client.DNSRecord, error)) *ZoneTransfers {
+	return &ZoneTransfers{records: records, transfer: transfer, tried: make(map[string]bool)}
+}
+
+// Try a transfer of zone from its nameservers, returning the records and the
+// nameserver that allowed it.  Nil if the zone has been tried before, has no
+// nameservers of its own or none of them allow it.
+func (z *ZoneTransfers) Try(zone string) ([]client.DNSRecord, string) {
+	if z == nil {
+		return nil, ""
+	}
+	zone = strings.ToLower(zone)
+	z.mu.Lock()
+	tried := z.tried[zone]
+	z.tried[zone] = true
+	z.mu.Unlock()
+	if tried {
+		return nil, ""
+	}
+	nss, err := z.records(zone, "NS")
+	if err != nil {
+		return nil, ""
+	}
+	for _, ns := range nss {
+		server := strings.TrimPrefix(ns, "NS ")
+		records, err := z.transfer(server, zone)
+		if err != nil {
+			logging.Logf(logging.LogDebug, "No transfer of %s from %s: %s", zone, server, err.Error())
+			continue
+		}
+		return records, server
+	}
+	return nil, ""
+}
+
+// Starts a batch of DNS workers based on the relevant settings.
+func StartDNSWorkers(settings *ss.ScanSettings,
+	src <-chan *url.URL,
+	provenance *workqueue.ProvenanceTracker,
+	dirs *workqueue.DirectoryTracker,
+	state *workqueue.StateTracker,
+	hooks *results.Hooks,
+	done workqueue.QueueDoneFunc,
+	rchan chan<- results.Result) []*DNSWorker {
+	lookup := client.NewLookup(settings.Resolver, settings.Timeout)
+	wildcards := NewDNSWildcards(lookup)
+	records := client.NewRecordLookup(settings.Resolver, settings.Timeout)
+	var transfers *ZoneTransfers
+	if settings.ZoneTransfer {
+		var local *client.LocalAddrs
+		if len(settings.Bind) > 0 {
+			// Already parsed without error when building the client factory
+			local, _ = client.ParseLocalAddrs(settings.Bind)
+		}
+		dial := client.NewDNSDialer(settings.Resolver, local, settings.Timeout)
+		transfers = NewZoneTransfers(records, func(server, zone string) ([]client.DNSRecord, error) {
+			return client.ZoneTransfer(dial, server, zone, settings.Timeout)
+		})
+	}
+	var recordTypes []string
+	for _, rtype := range settings.DNSRecordTypes {
+		recordTypes = append(recordTypes, strings.ToUpper(rtype))
+	}
+	targets, err := settings.GetScopes()
+	if err != nil {
+		logging.Logf(logging.LogError, "Unable to label results by target: %s", err.Error())
+	}
+	workers := make([]*DNSWorker, settings.Workers)
+	for i := range workers {
+		workers[i] = &DNSWorker{
+			src:         src,
+			lookup:      lookup,
+			wildcards:   wildcards,
+			recordTypes: recordTypes,
+			records:     records,
+			transfers:   transfers,
+			done:        done,
+			rchan:       rchan,
+			provenance:  provenance,
+			dirs:        dirs,
+			state:       state,
+			targets:     targets,
+			hooks:       hooks,
+			sleep:       settings.SleepTime,
+			stop:        make(chan bool),
+		}
+		workers[i].RunInBackground()
+	}
+	return workers
+}
@@ -0,0 +1,231 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package worker
+
+import (
+	"fmt"
+	"github.com/Matir/gobuster/client"
+	"github.com/Matir/gobuster/results"
+	"net"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func fakeLookup(records map[string][]string) func(string) ([]string, error) {
+	return func(host string) ([]string, error) {
+		if addrs, ok := records[host]; ok {
+			return addrs, nil
+		}
+		for name, addrs := range records {
+			if strings.HasPrefix(name, "*.") && strings.HasSuffix(host, name[1:]) {
+				return addrs, nil
+			}
+		}
+		return nil, &net.DNSError{Err: "no such host", Name: host, IsNotFound: true}
+	}
+}
+
+func TestDNSWorker_HandleURL(t *testing.T) {
+	lookup := fakeLookup(map[string][]string{
+		"example.com":       {"192.0.2.1"},
+		"www.example.com":   {"192.0.2.2"},
+		"*.wild.example":    {"192.0.2.9"},
+		"real.wild.example": {"192.0.2.10"},
+	})
+	rchan := make(chan results.Result, 10)
+	done := 0
+	w := &DNSWorker{
+		lookup:    lookup,
+		wildcards: NewDNSWildcards(lookup),
+		done:      func(n int) { done += n },
+		rchan:     rchan,
+		targets: []*url.URL{
+			{Scheme: "http", Host: "example.com", Path: "/"},
+			{Scheme: "http", Host: "wild.example", Path: "/"},
+		},
+	}
+	for _, host := range []string{"example.com", "www.example.com", "nope.example.com", "any.wild.example", "real.wild.example"} {
+		w.HandleURL(&url.URL{Scheme: "http", Host: host, Path: "/"})
+	}
+	close(rchan)
+	var found []string
+	for r := range rchan {
+		found = append(found, r.URL.Hostname()+"="+strings.Join(r.Addrs, ",")+"@"+r.Target)
+	}
+	expected := "example.com=192.0.2.1@http://example.com/ www.example.com=192.0.2.2@http://example.com/ real.wild.example=192.0.2.10@http://wild.example/"
+	if got := strings.Join(found, " "); got != expected {
+		t.Errorf("Expected %s, got %s", expected, got)
+	}
+	if done != 5 {
+		t.Errorf("Expected 5 done, got %d", done)
+	}
+}
+
+func TestDNSWorker_Hooks(t *testing.T) {
+	var looked []string
+	lookup := func(host string) ([]string, error) {
+		looked = append(looked, host)
+		return []string{"192.0.2.1"}, nil
+	}
+	rchan := make(chan results.Result, 10)
+	done := 0
+	w := &DNSWorker{
+		lookup:    lookup,
+		wildcards: NewDNSWildcards(func(string) ([]string, error) { return nil, fmt.Errorf("no wildcard") }),
+		done:      func(n int) { done += n },
+		rchan:     rchan,
+		targets:   []*url.URL{{Scheme: "http", Host: "example.com", Path: "/"}},
+		hooks: &results.Hooks{
+			OnRequest: func(u *url.URL) bool { return u.Hostname() != "skip.example.com" },
+			OnFinding: func(r results.Result) bool { return r.URL.Hostname() != "www.example.com" },
+		},
+	}
+	for _, host := range []string{"www.example.com", "skip.example.com", "mail.example.com"} {
+		w.HandleURL(&url.URL{Scheme: "http", Host: host, Path: "/"})
+	}
+	close(rchan)
+	var found []string
+	for r := range rchan {
+		found = append(found, r.URL.Hostname())
+	}
+	if got := strings.Join(found, " "); got != "mail.example.com" {
+		t.Errorf("Expected only mail.example.com reported, got %s", got)
+	}
+	if got := strings.Join(looked, " "); got != "www.example.com mail.example.com" {
+		t.Errorf("Expected skip.example.com not to be resolved, got %s", got)
+	}
+	if done != 3 {
+		t.Errorf("Expected 3 done, got %d", done)
+	}
+}
+
+func TestDNSWildcards_Matches(t *testing.T) {
+	lookups := 0
+	d := NewDNSWildcards(func(host string) ([]string, error) {
+		lookups++
+		if strings.HasSuffix(host, ".wild.example") {
+			return []string{"192.0.2.9", "192.0.2.8"}, nil
+		}
+		return nil, &net.DNSError{Err: "no such host", Name: host, IsNotFound: true}
+	})
+	if !d.Matches("wild.example", []string{"192.0.2.8"}) {
+		t.Errorf("Expected wildcard address to match.")
+	}
+	if d.Matches("wild.example", []string{"192.0.2.8", "192.0.2.1"}) {
+		t.Errorf("Expected other addresses not to match.")
+	}
+	if d.Matches("tame.example", []string{"192.0.2.8"}) {
+		t.Errorf("Expected no match without a wildcard.")
+	}
+	if lookups != 2 {
+		t.Errorf("Expected one lookup per domain, got %d", lookups)
+	}
+}
+
+func fakeRecords(records map[string][]string) func(string, string) ([]string, error) {
+	return func(name, rtype string) ([]string, error) {
+		var found []string
+		for _, r := range records[name] {
+			if strings.HasPrefix(r, rtype+" ") {
+				found = append(found, r)
+			}
+		}
+		if len(found) == 0 {
+			return nil, &net.DNSError{Err: "no such host", Name: name, IsNotFound: true}
+		}
+		return found, nil
+	}
+}
+
+func TestDNSWorker_Records(t *testing.T) {
+	rchan := make(chan results.Result, 1)
+	w := &DNSWorker{
+		lookup:      fakeLookup(map[string][]string{"example.com": {"192.0.2.1"}}),
+		recordTypes: []string{"MX", "TXT", "SRV"},
+		records: fakeRecords(map[string][]string{
+			"example.com": {"MX 10 mail.example.com", "TXT v=spf1 -all"},
+		}),
+		done:  noopInt,
+		rchan: rchan,
+	}
+	w.HandleURL(&url.URL{Scheme: "http", Host: "example.com", Path: "/"})
+	r := <-rchan
+	if got := strings.Join(r.Records, "; "); got != "MX 10 mail.example.com; TXT v=spf1 -all" {
+		t.Errorf("Expected MX and TXT records, got %s", got)
+	}
+}
+
+func TestDNSWorker_ZoneTransfer(t *testing.T) {
+	records := fakeRecords(map[string][]string{"example.com": {"NS ns1.example.com"}})
+	transfers := NewZoneTransfers(records, func(server, zone string) ([]client.DNSRecord, error) {
+		return []client.DNSRecord{
+			{Name: "example.com", Type: "SOA", Value: "ns1.example.com"},
+			{Name: "example.com", Type: "A", Value: "192.0.2.1"},
+			{Name: "Intranet.example.com", Type: "A", Value: "10.0.0.5"},
+		}, nil
+	})
+	rchan := make(chan results.Result, 10)
+	target := &url.URL{Scheme: "https", Host: "example.com:8443", Path: "/"}
+	w := &DNSWorker{
+		lookup:    fakeLookup(nil),
+		records:   records,
+		transfers: transfers,
+		done:      noopInt,
+		rchan:     rchan,
+		targets:   []*url.URL{target},
+	}
+	for _, host := range []string{"nope.example.com", "gone.example.com"} {
+		w.HandleURL(&url.URL{Scheme: "https", Host: host + ":8443", Path: "/"})
+	}
+	close(rchan)
+	var found []string
+	for r := range rchan {
+		found = append(found, fmt.Sprintf("%s %v %v %s %s", r.URL.Host, r.Addrs, r.Records, r.ZoneTransfer, r.Source))
+	}
+	expected := "example.com:8443 [192.0.2.1] [SOA ns1.example.com] ns1.example.com zonetransfer|intranet.example.com:8443 [10.0.0.5] []  zonetransfer"
+	if got := strings.Join(found, "|"); got != expected {
+		t.Errorf("Expected %s, got %s", expected, got)
+	}
+}
+
+func TestZoneTransfers_Try(t *testing.T) {
+	var tried []string
+	z := NewZoneTransfers(
+		fakeRecords(map[string][]string{"example.com": {"NS ns1.example.com", "NS ns2.example.com"}}),
+		func(server, zone string) ([]client.DNSRecord, error) {
+			tried = append(tried, server)
+			if server == "ns1.example.com" {
+				return nil, fmt.Errorf("Zone transfer refused (rcode 5).")
+			}
+			return []client.DNSRecord{{Name: zone, Type: "SOA"}}, nil
+		})
+	if records, server := z.Try("Example.com"); len(records) != 1 || server != "ns2.example.com" {
+		t.Errorf("Expected a transfer from ns2.example.com, got %v from %s", records, server)
+	}
+	if records, _ := z.Try("example.com"); records != nil {
+		t.Errorf("Expected each zone to be tried once, got %v", records)
+	}
+	if records, _ := z.Try("www.example.com"); records != nil {
+		t.Errorf("Expected no transfer without nameservers, got %v", records)
+	}
+	if strings.Join(tried, " ") != "ns1.example.com ns2.example.com" {
+		t.Errorf("Expected both nameservers to be tried, got %v", tried)
+	}
+	var nilTransfers *ZoneTransfers
+	if records, _ := nilTransfers.Try("example.com"); records != nil {
+		t.Error("Expected nil ZoneTransfers not to try anything.")
+	}
+}
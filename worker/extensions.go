@@ -0,0 +1,99 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package worker
+
+import (
+	"github.com/Matir/gobuster/logging"
+	"sort"
+	"sync"
+)
+
+// ExtensionStats counts hits and misses of each extension per host, so that
+// extensions that find things are tried first and extensions that never do
+// are dropped, like .jsp on a site that is all PHP.
+type ExtensionStats struct {
+	// Misses without a hit before an extension is dropped
+	limit  int
+	counts map[string]map[string]*extensionCount
+	sync.Mutex
+}
+
+type extensionCount struct {
+	hits   int
+	misses int
+}
+
+func NewExtensionStats(limit int) *ExtensionStats {
+	return &ExtensionStats{limit: limit, counts: make(map[string]map[string]*extensionCount)}
+}
+
+// Note whether trying ext on host found something.
+func (s *ExtensionStats) Record(host, ext string, hit bool) {
+	if s == nil {
+		return
+	}
+	s.Lock()
+	defer s.Unlock()
+	byExt, ok := s.counts[host]
+	if !ok {
+		byExt = make(map[string]*extensionCount)
+		s.counts[host] = byExt
+	}
+	c, ok := byExt[ext]
+	if !ok {
+		c = &extensionCount{}
+		byExt[ext] = c
+	}
+	if hit {
+		c.hits++
+		return
+	}
+	c.misses++
+	if c.hits == 0 && c.misses == s.limit {
+		logging.Logf(logging.LogInfo, "No hits for .%s on %s after %d tries, no longer trying it.", ext, host, c.misses)
+	}
+}
+
+// The extensions to try on host, best hit rate first and without those that
+// have been dropped.  Extensions with the same rate keep their order.
+func (s *ExtensionStats) Order(host string, exts []string) []string {
+	if s == nil {
+		return exts
+	}
+	s.Lock()
+	defer s.Unlock()
+	byExt := s.counts[host]
+	if byExt == nil {
+		return exts
+	}
+	rate := func(ext string) float64 {
+		c, ok := byExt[ext]
+		if !ok {
+			return 0
+		}
+		return float64(c.hits) / float64(c.hits+c.misses)
+	}
+	ordered := make([]string, 0, len(exts))
+	for _, ext := range exts {
+		if c, ok := byExt[ext]; ok && c.hits == 0 && c.misses >= s.limit {
+			continue
+		}
+		ordered = append(ordered, ext)
+	}
+	sort.SliceStable(ordered, func(i, j int) bool {
+		return rate(ordered[i]) > rate(ordered[j])
+	})
+	return ordered
+}
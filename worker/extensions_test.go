@@ -0,0 +1,78 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package worker
+
+import (
+	"github.com/Matir/gobuster/client/mock"
+	"github.com/Matir/gobuster/results"
+	"github.com/Matir/gobuster/settings"
+	"net/url"
+	"reflect"
+	"testing"
+)
+
+func TestExtensionStats(t *testing.T) {
+	exts := []string{"html", "php", "jsp"}
+	stats := NewExtensionStats(2)
+	if got := stats.Order("site:80", exts); !reflect.DeepEqual(got, exts) {
+		t.Errorf("Expected %v before any tries, got %v", exts, got)
+	}
+	stats.Record("site:80", "php", true)
+	stats.Record("site:80", "html", true)
+	stats.Record("site:80", "html", false)
+	stats.Record("site:80", "jsp", false)
+	if got := stats.Order("site:80", exts); !reflect.DeepEqual(got, []string{"php", "html", "jsp"}) {
+		t.Errorf("Expected extensions by hit rate, got %v", got)
+	}
+	stats.Record("site:80", "jsp", false)
+	if got := stats.Order("site:80", exts); !reflect.DeepEqual(got, []string{"php", "html"}) {
+		t.Errorf("Expected jsp to be dropped, got %v", got)
+	}
+	if got := stats.Order("other:80", exts); !reflect.DeepEqual(got, exts) {
+		t.Errorf("Expected %v on another host, got %v", exts, got)
+	}
+	var nilStats *ExtensionStats
+	nilStats.Record("site:80", "jsp", false)
+	if got := nilStats.Order("site:80", exts); !reflect.DeepEqual(got, exts) {
+		t.Errorf("Expected nil stats to keep %v, got %v", exts, got)
+	}
+}
+
+func TestHandleURL_DroppedExtension(t *testing.T) {
+	stats := NewExtensionStats(1)
+	stats.Record("localhost", "jsp", false)
+	resp := mock.ResponseFromString("")
+	resp.StatusCode = 404
+	mc := &mock.MockClient{ForeverResponse: resp}
+	w := &Worker{
+		client: mc,
+		settings: &settings.ScanSettings{
+			SpiderCodes: []int{200},
+			Extensions:  []string{"jsp", "php"},
+		},
+		rchan:      make(chan results.Result, 3),
+		adder:      noopUrl,
+		done:       noopInt,
+		extensions: stats,
+	}
+	w.HandleURL(&url.URL{Scheme: "http", Host: "localhost", Path: "/index"})
+	var paths []string
+	for _, u := range mc.Requests {
+		paths = append(paths, u.Path)
+	}
+	if !reflect.DeepEqual(paths, []string{"/index", "/index.php"}) {
+		t.Errorf("Expected /index and /index.php, got %v", paths)
+	}
+}
@@ -0,0 +1,243 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package worker
+
+import (
+	"github.com/Matir/gobuster/client"
+	"github.com/Matir/gobuster/logging"
+	"github.com/Matir/gobuster/results"
+	ss "github.com/Matir/gobuster/settings"
+	"github.com/Matir/gobuster/workqueue"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// FuzzWorker sends one request per word, built from a template with the word
+// substituted for the fuzz marker.  The expander has already substituted it
+// into the URL; when the marker is also in the headers or body, the word
+// comes in the URL's fragment.
+type FuzzWorker struct {
+	// Channel of fuzzed URLs
+	src <-chan *url.URL
+	// Client to make requests with
+	client client.MethodClient
+	// Request method
+	method string
+	// Header templates, as "Name: value"
+	headers []string
+	// Body template
+	body string
+	// Content-Type of the body, unless a header sets it
+	contentType string
+	// Most of each response body to read
+	maxBody int64
+	// Function to mark work done
+	done workqueue.QueueDoneFunc
+	// Channel for scan results
+	rchan chan<- results.Result
+	// How URLs entered the queue
+	provenance *workqueue.ProvenanceTracker
+	// Completion of targets
+	dirs *workqueue.DirectoryTracker
+	// Progress for resuming
+	state *workqueue.StateTracker
+	// Templates, which aren't requested themselves
+	targets []*url.URL
+	// Hooks of a program embedding the scanner, if any
+	hooks *results.Hooks
+	// Delay between requests
+	sleep time.Duration
+	// Channel to trigger stopping
+	stop chan bool
+}
+
+func (w *FuzzWorker) Run() {
+	for true {
+		select {
+		case <-w.stop:
+			return
+		case task, ok := <-w.src:
+			if !ok {
+				return
+			}
+			w.HandleURL(task)
+		}
+	}
+}
+
+func (w *FuzzWorker) RunInBackground() {
+	go w.Run()
+}
+
+func (w *FuzzWorker) Stop() {
+	w.stop <- true
+}
+
+func (w *FuzzWorker) HandleURL(task *url.URL) {
+	if !w.isTemplate(task) {
+		w.try(task)
+		if w.sleep != 0 {
+			time.Sleep(w.sleep)
+		}
+	}
+	w.dirs.Done(task)
+	w.state.Done(task)
+	w.provenance.Done(task)
+	w.done(1)
+}
+
+func (w *FuzzWorker) try(task *url.URL) {
+	word := task.Fragment
+	u := *task
+	u.Fragment = ""
+	header := make(http.Header)
+	for _, h := range w.headers {
+		pieces := strings.SplitN(fuzz(h, word), ":", 2)
+		header.Add(strings.TrimSpace(pieces[0]), strings.TrimSpace(pieces[1]))
+	}
+	if w.body != "" && w.contentType != "" && header.Get("Content-Type") == "" {
+		header.Set("Content-Type", w.contentType)
+	}
+	if !w.hooks.Request(task) {
+		logging.Logf(logging.LogDebug, "Hook skipped %s.", task.String())
+		return
+	}
+	logging.Logf(logging.LogInfo, "Fuzzing: %s", task.String())
+	result := results.Result{
+		URL:    task,
+		Source: string(w.provenance.Lookup(task).Source),
+		Target: w.targetOf(task),
+	}
+	resp, err := w.client.RequestMethod(w.method, &u, header, fuzz(w.body, word))
+	if err != nil {
+		result.Error = err
+		report(w.hooks, w.rchan, result)
+		return
+	}
+	defer resp.Body.Close()
+	w.hooks.Response(task, resp)
+	stats := NewBodyStats()
+	io.Copy(stats, io.LimitReader(resp.Body, bodyLimit(w.maxBody)))
+	result.Code = resp.StatusCode
+	result.Protocol = resp.Proto
+	result.Length = stats.Length
+	result.Words = stats.Words
+	result.Lines = stats.LineCount()
+	result.BodyHash = stats.Sum()
+	if w.method == http.MethodHead {
+		// Only the headers say how long the body would be
+		result.Length = resp.ContentLength
+		result.BodyHash = ""
+	}
+	result.ContentType = resp.Header.Get("Content-Type")
+	if location, err := resp.Location(); err == nil {
+		result.Redir = location
+	}
+	report(w.hooks, w.rchan, result)
+}
+
+// The method to send body with, if none is given.
+func requestMethod(method, body string) string {
+	if method != "" {
+		return method
+	}
+	if body != "" {
+		return "POST"
+	}
+	return "GET"
+}
+
+// Substitute word for the fuzz marker in template, if there is a word.
+func fuzz(template, word string) string {
+	if word == "" {
+		return template
+	}
+	return strings.Replace(template, ss.FuzzMarker, word, -1)
+}
+
+// Whether task is one of the templates rather than a fuzzed request.
+func (w *FuzzWorker) isTemplate(task *url.URL) bool {
+	for _, t := range w.targets {
+		if t.String() == task.String() {
+			return true
+		}
+	}
+	return false
+}
+
+// The target a fuzzed request came from.  With a single template, as is
+// usual, that's the one.
+func (w *FuzzWorker) targetOf(task *url.URL) string {
+	if len(w.targets) == 1 {
+		return w.targets[0].String()
+	}
+	if target := workqueue.TargetOf(w.targets, task); target != nil {
+		return target.String()
+	}
+	return ""
+}
+
+// Starts a batch of fuzz workers based on the relevant settings.
+func StartFuzzWorkers(settings *ss.ScanSettings,
+	factory client.ClientFactory,
+	src <-chan *url.URL,
+	provenance *workqueue.ProvenanceTracker,
+	dirs *workqueue.DirectoryTracker,
+	state *workqueue.StateTracker,
+	hooks *results.Hooks,
+	done workqueue.QueueDoneFunc,
+	rchan chan<- results.Result) []*FuzzWorker {
+	targets, err := settings.GetScopes()
+	if err != nil {
+		logging.Logf(logging.LogError, "Unable to label results by target: %s", err.Error())
+	}
+	method := requestMethod(settings.FuzzMethod, settings.FuzzData)
+	var workers []*FuzzWorker
+	for i := 0; i < settings.Workers; i++ {
+		c, ok := factory.Get().(client.MethodClient)
+		if !ok {
+			logging.Logf(logging.LogError, "Client can't build requests, not starting fuzz workers.")
+			return workers
+		}
+		// Redirects are reported, not followed
+		c.SetCheckRedirect(func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		})
+		w := &FuzzWorker{
+			src:         src,
+			client:      c,
+			method:      method,
+			headers:     settings.FuzzHeaders,
+			body:        settings.FuzzData,
+			contentType: settings.ContentType,
+			maxBody:     settings.MaxBodyRead,
+			done:        done,
+			rchan:       rchan,
+			provenance:  provenance,
+			dirs:        dirs,
+			state:       state,
+			targets:     targets,
+			hooks:       hooks,
+			sleep:       settings.SleepTime,
+			stop:        make(chan bool),
+		}
+		w.RunInBackground()
+		workers = append(workers, w)
+	}
+	return workers
+}
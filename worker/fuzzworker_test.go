@@ -0,0 +1,127 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package worker
+
+import (
+	"fmt"
+	"github.com/Matir/gobuster/client"
+	"github.com/Matir/gobuster/client/mock"
+	"github.com/Matir/gobuster/results"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFuzzWorker_HandleURL(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		if r.Method == "POST" && r.Header.Get("X-User") == "admin" && string(body) == "user=admin" {
+			w.Write([]byte("welcome back admin"))
+			return
+		}
+		http.Error(w, "denied", http.StatusForbidden)
+	}))
+	defer server.Close()
+	template, _ := url.Parse(server.URL + "/login")
+	factory, err := client.NewProxyClientFactory(nil, time.Second, "test")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	rchan := make(chan results.Result, 10)
+	done := 0
+	w := &FuzzWorker{
+		client:  factory.Get().(client.MethodClient),
+		method:  "POST",
+		headers: []string{"X-User: FUZZ"},
+		body:    "user=FUZZ",
+		done:    func(n int) { done += n },
+		rchan:   rchan,
+		targets: []*url.URL{template},
+	}
+	w.HandleURL(template)
+	for _, word := range []string{"guest", "admin"} {
+		u := *template
+		u.Fragment = word
+		w.HandleURL(&u)
+	}
+	close(rchan)
+	var found []string
+	for r := range rchan {
+		found = append(found, fmt.Sprintf("%s=%d/%d", r.URL.Fragment, r.Code, r.Words))
+		if r.Protocol != "HTTP/1.1" {
+			t.Errorf("Expected HTTP/1.1, got %q", r.Protocol)
+		}
+	}
+	if got := strings.Join(found, " "); got != "guest=403/1 admin=200/3" {
+		t.Errorf("Expected guest=403/1 admin=200/3, got %s", got)
+	}
+	if done != 3 {
+		t.Errorf("Expected 3 done, got %d", done)
+	}
+}
+
+func TestFuzz(t *testing.T) {
+	if got := fuzz("id=FUZZ&x=FUZZ", "1"); got != "id=1&x=1" {
+		t.Errorf("Expected id=1&x=1, got %s", got)
+	}
+	if got := fuzz("id=FUZZ", ""); got != "id=FUZZ" {
+		t.Errorf("Expected template unchanged without a word, got %s", got)
+	}
+}
+
+func TestFuzzWorker_ContentType(t *testing.T) {
+	template := &url.URL{Scheme: "http", Host: "localhost", Path: "/login"}
+	for _, c := range []struct {
+		headers []string
+		want    string
+	}{
+		{nil, "application/json"},
+		{[]string{"Content-Type: text/xml"}, "text/xml"},
+	} {
+		mc := &mock.MockClient{ForeverResponse: mock.ResponseFromString("")}
+		w := &FuzzWorker{
+			client:      mc,
+			method:      "POST",
+			headers:     c.headers,
+			body:        `{"user":"FUZZ"}`,
+			contentType: "application/json",
+			done:        noopInt,
+			rchan:       make(chan results.Result, 1),
+			targets:     []*url.URL{template},
+		}
+		u := *template
+		u.Fragment = "admin"
+		w.HandleURL(&u)
+		if len(mc.Headers) != 1 || mc.Headers[0].Get("Content-Type") != c.want {
+			t.Errorf("Expected Content-Type %s, got %v", c.want, mc.Headers)
+		}
+	}
+}
+
+func TestRequestMethod(t *testing.T) {
+	if got := requestMethod("", ""); got != "GET" {
+		t.Errorf("Expected GET without a body, got %s", got)
+	}
+	if got := requestMethod("", "a=b"); got != "POST" {
+		t.Errorf("Expected POST with a body, got %s", got)
+	}
+	if got := requestMethod("PUT", "a=b"); got != "PUT" {
+		t.Errorf("Expected PUT, got %s", got)
+	}
+}
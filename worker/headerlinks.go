@@ -0,0 +1,55 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package worker
+
+import (
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// Headers whose whole value is a reference to another resource.
+var referenceHeaders = []string{"Location", "Content-Location", "SourceMap", "X-SourceMap"}
+
+var linkHeaderRE = regexp.MustCompile(`<([^>]*)>`)
+
+// Find the URLs that response headers refer to, resolved against base.
+func HeaderLinks(base *url.URL, header http.Header) []*url.URL {
+	var refs []string
+	for _, name := range referenceHeaders {
+		refs = append(refs, header[http.CanonicalHeaderKey(name)]...)
+	}
+	for _, value := range header["Link"] {
+		for _, m := range linkHeaderRE.FindAllStringSubmatch(value, -1) {
+			refs = append(refs, m[1])
+		}
+	}
+	for _, value := range header["Refresh"] {
+		// e.g. "5; url=/next"
+		if i := strings.Index(strings.ToLower(value), "url="); i >= 0 {
+			refs = append(refs, strings.Trim(value[i+4:], `'" `))
+		}
+	}
+	seen := make(map[string]bool)
+	var links []*url.URL
+	for _, ref := range refs {
+		if u := resolveLink(base, ref); u != nil && !seen[u.String()] {
+			seen[u.String()] = true
+			links = append(links, u)
+		}
+	}
+	return links
+}
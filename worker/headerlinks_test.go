@@ -0,0 +1,52 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package worker
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestHeaderLinks(t *testing.T) {
+	base, _ := url.Parse("http://localhost/app/page")
+	header := http.Header{
+		"Link":             []string{`</style.css>; rel=preload; as=style, <//cdn.example.com/x.js>; rel=preload`, `<https://localhost/next#frag>; rel="next"`},
+		"Content-Location": []string{"page.json"},
+		"X-Sourcemap":      []string{"/app/page.js.map"},
+		"Refresh":          []string{"5; url='/later'"},
+		"Location":         []string{"mailto:admin@localhost"},
+		"Server":           []string{"</not-a-link>"},
+	}
+	var found []string
+	for _, u := range HeaderLinks(base, header) {
+		found = append(found, u.String())
+	}
+	expected := []string{
+		"http://localhost/app/page.json",
+		"http://localhost/app/page.js.map",
+		"http://localhost/style.css",
+		"http://cdn.example.com/x.js",
+		"https://localhost/next",
+		"http://localhost/later",
+	}
+	if strings.Join(found, " ") != strings.Join(expected, " ") {
+		t.Errorf("Expected %v, got %v", expected, found)
+	}
+	if links := HeaderLinks(base, http.Header{}); len(links) != 0 {
+		t.Errorf("Expected no links, got %v", links)
+	}
+}
@@ -15,11 +15,13 @@
 package worker
 
 import (
+	"fmt"
 	"github.com/Matir/gobuster/logging"
 	"github.com/Matir/gobuster/util"
 	"github.com/Matir/gobuster/workqueue"
 	"golang.org/x/net/html"
 	"io"
+	"mime"
 	"net/http"
 	"net/url"
 	"strings"
@@ -28,54 +30,217 @@ import (
 type HTMLWorker struct {
 	// Function to add future work
 	adder workqueue.QueueAddFunc
+	// Where to note that links were found
+	provenance *workqueue.ProvenanceTracker
+	// Stops following links when memory runs short
+	memory *util.MemoryGuard
+	// Names of the link sources to follow, or nil for all
+	sources []string
+	// Largest body to parse, or 0 for defaultMaxParseSize
+	maxSize int64
 }
 
-func NewHTMLWorker(adder workqueue.QueueAddFunc) *HTMLWorker {
-	return &HTMLWorker{adder: adder}
+const defaultMaxParseSize = 1024 * 1024
+
+// Content types that are parsed for links.
+var htmlContentTypes = []string{"text/html", "application/xhtml+xml"}
+
+type elementAttribute struct {
+	tag, attr string
+}
+
+// Where links are found, by the name used to enable them.  The srcset
+// source is handled separately as it holds a list of links.
+var linkSources = map[string][]elementAttribute{
+	"a":      {{"a", "href"}, {"area", "href"}},
+	"img":    {{"img", "src"}},
+	"script": {{"script", "src"}},
+	"style":  {{"style", "src"}},
+	"link":   {{"link", "href"}},
+	"iframe": {{"iframe", "src"}},
+	"frame":  {{"frame", "src"}},
+	"object": {{"object", "data"}},
+	"embed":  {{"embed", "src"}},
+	"form":   {{"form", "action"}, {"button", "formaction"}, {"input", "formaction"}},
+	"srcset": nil,
+}
+
+var srcsetElements = []string{"img", "source"}
+
+// All link sources, in the order they are collected.
+var DefaultLinkSources = []string{"a", "img", "script", "style", "link", "iframe", "frame", "object", "embed", "form", "srcset"}
+
+func NewHTMLWorker(adder workqueue.QueueAddFunc, provenance *workqueue.ProvenanceTracker) *HTMLWorker {
+	return &HTMLWorker{adder: adder, provenance: provenance}
 }
 
 // Work on this response
-func (w *HTMLWorker) Handle(URL *url.URL, body io.Reader) {
-	links := w.GetLinks(body)
-	foundURLs := make([]*url.URL, 0, len(links))
-	for _, l := range links {
-		u, err := url.Parse(l)
-		if err != nil {
-			logging.Logf(logging.LogInfo, "Error parsing URL (%s): %s", l, err.Error())
+func (w *HTMLWorker) Handle(URL *url.URL, body io.Reader, header http.Header) {
+	if w.memory.Exceeded() {
+		logging.Logf(logging.LogDebug, "Not following links from %s, memory limit reached.", URL.String())
+		return
+	}
+	// Bodies of unknown length are only parsed up to the limit
+	body = io.LimitReader(body, w.maxParseSize())
+	tree, err := html.Parse(NewCharsetReader(body, header.Get("Content-Type")))
+	if err != nil {
+		logging.Logf(logging.LogInfo, "Unable to parse HTML document: %s", err.Error())
+		return
+	}
+	base := documentBase(URL, tree)
+	seen := make(map[string]bool)
+	foundURLs := make([]*url.URL, 0)
+	addFound := func(u *url.URL) {
+		if key := u.String(); !seen[key] {
+			seen[key] = true
+			foundURLs = append(foundURLs, u)
+		}
+	}
+	for _, l := range w.linksFromTree(tree) {
+		resolved := resolveLink(base, l)
+		if resolved == nil {
 			continue
 		}
-		resolved := URL.ResolveReference(u)
-		foundURLs = append(foundURLs, resolved)
+		addFound(resolved)
 		// Include parents of the found URL.
-		// Worker will remove duplicates
-		foundURLs = append(foundURLs, util.GetParentPaths(resolved)...)
+		for _, parent := range util.GetParentPaths(resolved) {
+			addFound(parent)
+		}
 	}
+	w.provenance.Record(workqueue.Provenance{Source: workqueue.SourceLink, Parent: URL}, foundURLs...)
 	w.adder(foundURLs...)
 }
 
 // Check if this response can be handled by this worker
-func (*HTMLWorker) Eligible(resp *http.Response) bool {
-	ct := resp.Header.Get("Content-type")
-	if strings.ToLower(ct) != "text/html" {
+func (w *HTMLWorker) Eligible(contentType string, length int64) bool {
+	ct, _, err := mime.ParseMediaType(contentType)
+	if err != nil || !isHTMLType(ct) {
 		return false
 	}
-	return resp.ContentLength > 0 && resp.ContentLength < 1024*1024
+	// Unknown lengths (e.g. chunked or compressed) are truncated by Handle
+	if length < 0 {
+		return true
+	}
+	return length > 0 && length <= w.maxParseSize()
+}
+
+func isHTMLType(ct string) bool {
+	for _, t := range htmlContentTypes {
+		if ct == t {
+			return true
+		}
+	}
+	return false
+}
+
+func (w *HTMLWorker) maxParseSize() int64 {
+	if w.maxSize > 0 {
+		return w.maxSize
+	}
+	return defaultMaxParseSize
 }
 
 // Get the links for the body.
-func (*HTMLWorker) GetLinks(body io.Reader) []string {
+func (w *HTMLWorker) GetLinks(body io.Reader) []string {
 	tree, err := html.Parse(body)
 	if err != nil {
 		logging.Logf(logging.LogInfo, "Unable to parse HTML document: %s", err.Error())
 		return nil
 	}
-	links := collectElementAttributes(tree, "a", "href")
-	links = append(links, collectElementAttributes(tree, "img", "src")...)
-	links = append(links, collectElementAttributes(tree, "script", "src")...)
-	links = append(links, collectElementAttributes(tree, "style", "src")...)
+	return w.linksFromTree(tree)
+}
+
+// Check that each name is a known link source.
+func CheckLinkSources(sources []string) error {
+	for _, name := range sources {
+		if _, ok := linkSources[name]; !ok {
+			return fmt.Errorf("Unknown link source: %s", name)
+		}
+	}
+	return nil
+}
+
+func (w *HTMLWorker) linksFromTree(tree *html.Node) []string {
+	sources := w.sources
+	if sources == nil {
+		sources = DefaultLinkSources
+	}
+	links := make([]string, 0)
+	for _, name := range sources {
+		if name == "srcset" {
+			for _, tag := range srcsetElements {
+				for _, set := range collectElementAttributes(tree, tag, "srcset") {
+					links = append(links, parseSrcset(set)...)
+				}
+			}
+			continue
+		}
+		for _, ea := range linkSources[name] {
+			links = append(links, collectElementAttributes(tree, ea.tag, ea.attr)...)
+		}
+	}
 	return util.DedupeStrings(links)
 }
 
+// Get the URLs from a srcset, a comma-separated list of URLs each followed
+// by an optional descriptor.
+func parseSrcset(set string) []string {
+	var urls []string
+	for _, candidate := range strings.Split(set, ",") {
+		if fields := strings.Fields(candidate); len(fields) > 0 {
+			urls = append(urls, fields[0])
+		}
+	}
+	return urls
+}
+
+// Find the URL relative links are resolved against, honoring the first
+// <base> with an href.
+func documentBase(page *url.URL, tree *html.Node) *url.URL {
+	for _, el := range getElementsByTagName(tree, "base") {
+		href := getElementAttribute(el, "href")
+		if href == nil {
+			continue
+		}
+		u, err := url.Parse(strings.TrimSpace(*href))
+		if err != nil {
+			logging.Logf(logging.LogInfo, "Error parsing base URL (%s): %s", *href, err.Error())
+			break
+		}
+		if resolved := page.ResolveReference(u); isHTTPURL(resolved) {
+			return resolved
+		}
+		break
+	}
+	return page
+}
+
+// Resolve a link, returning nil for links that can't be fetched.  Fragments
+// are dropped so they don't produce duplicate work.
+func resolveLink(base *url.URL, link string) *url.URL {
+	link = strings.TrimSpace(link)
+	if link == "" || strings.HasPrefix(link, "#") {
+		// Only refers to the current document
+		return nil
+	}
+	u, err := url.Parse(link)
+	if err != nil {
+		logging.Logf(logging.LogInfo, "Error parsing URL (%s): %s", link, err.Error())
+		return nil
+	}
+	resolved := base.ResolveReference(u)
+	if !isHTTPURL(resolved) {
+		return nil
+	}
+	resolved.Fragment = ""
+	resolved.RawFragment = ""
+	return resolved
+}
+
+func isHTTPURL(u *url.URL) bool {
+	return (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
 func getElementsByTagName(root *html.Node, name string) []*html.Node {
 	results := make([]*html.Node, 0)
 	var handleNode func(*html.Node)
@@ -0,0 +1,152 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package worker
+
+import (
+	"golang.org/x/net/html"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestHTMLWorker_HandleResolution(t *testing.T) {
+	page, _ := url.Parse("http://localhost/a/page.html")
+	body := `<html><head><base href="/b/"></head><body>
+<a href="rel.html#frag">x</a>
+<a href="rel.html">x</a>
+<a href="//other/x">x</a>
+<a href="#top">x</a>
+<a href="javascript:void(0)">x</a>
+<a href="mailto:a@b">x</a>
+<img src="https://localhost/img.png">
+</body></html>`
+	var found []string
+	w := NewHTMLWorker(func(urls ...*url.URL) {
+		for _, u := range urls {
+			found = append(found, u.String())
+		}
+	}, nil)
+	w.Handle(page, strings.NewReader(body), http.Header{"Content-Type": []string{"text/html"}})
+	sort.Strings(found)
+	expected := []string{
+		"http://localhost/b",
+		"http://localhost/b/rel.html",
+		"http://other/x",
+		"https://localhost/img.png",
+	}
+	if strings.Join(found, " ") != strings.Join(expected, " ") {
+		t.Errorf("Expected %v, got %v", expected, found)
+	}
+}
+
+func TestDocumentBase(t *testing.T) {
+	page, _ := url.Parse("http://localhost/a/page.html")
+	cases := []struct {
+		body     string
+		expected string
+	}{
+		{`<a href="x">`, "http://localhost/a/page.html"},
+		{`<base href="http://cdn/static/">`, "http://cdn/static/"},
+		{`<base target="_blank"><base href="../c/">`, "http://localhost/c/"},
+		{`<base href="javascript:alert(1)">`, "http://localhost/a/page.html"},
+	}
+	for _, c := range cases {
+		tree, _ := html.Parse(strings.NewReader(c.body))
+		if res := documentBase(page, tree).String(); res != c.expected {
+			t.Errorf("Expected base %s, got %s", c.expected, res)
+		}
+	}
+}
+
+func TestHTMLWorker_LinkSources(t *testing.T) {
+	body := `<a href="/a"></a><iframe src="/iframe"></iframe>
+<object data="/object"></object><embed src="/embed"><link rel="stylesheet" href="/style.css">
+<form action="/form"></form><img src="/img" srcset="/small.png 1x, /large.png 2x">
+<script src="/script.js"></script>`
+	w := NewHTMLWorker(nil, nil)
+	links := w.GetLinks(strings.NewReader(body))
+	sort.Strings(links)
+	expected := "/a /embed /form /iframe /img /large.png /object /script.js /small.png /style.css"
+	if res := strings.Join(links, " "); res != expected {
+		t.Errorf("Expected %s, got %s", expected, res)
+	}
+	w.sources = []string{"iframe", "srcset"}
+	links = w.GetLinks(strings.NewReader(body))
+	sort.Strings(links)
+	expected = "/iframe /large.png /small.png"
+	if res := strings.Join(links, " "); res != expected {
+		t.Errorf("Expected %s, got %s", expected, res)
+	}
+	// Frames are only parsed inside a frameset
+	w.sources = nil
+	links = w.GetLinks(strings.NewReader(`<html><frameset><frame src="/frame"></frameset></html>`))
+	if res := strings.Join(links, " "); res != "/frame" {
+		t.Errorf("Expected /frame, got %s", res)
+	}
+}
+
+func TestCheckLinkSources(t *testing.T) {
+	if err := CheckLinkSources(DefaultLinkSources); err != nil {
+		t.Errorf("Expected defaults to be valid, got %s", err)
+	}
+	if err := CheckLinkSources([]string{"a", "blink"}); err == nil {
+		t.Error("Expected error for unknown source.")
+	}
+}
+
+func TestHTMLWorker_Eligible(t *testing.T) {
+	w := NewHTMLWorker(nil, nil)
+	cases := []struct {
+		contentType string
+		length      int64
+		maxSize     int64
+		expected    bool
+	}{
+		{"text/html", 100, 0, true},
+		{"text/html; charset=utf-8", 100, 0, true},
+		{"application/xhtml+xml", 100, 0, true},
+		{"text/plain", 100, 0, false},
+		{"", 100, 0, false},
+		{"text/html", 0, 0, false},
+		{"text/html", -1, 0, true},
+		{"text/html", 2 * 1024 * 1024, 0, false},
+		{"text/html", 2 * 1024 * 1024, 4 * 1024 * 1024, true},
+		{"text/html", 2048, 1024, false},
+	}
+	for _, c := range cases {
+		w.maxSize = c.maxSize
+		if res := w.Eligible(c.contentType, c.length); res != c.expected {
+			t.Errorf("Expected %v for %s (%d bytes, max %d), got %v", c.expected, c.contentType, c.length, c.maxSize, res)
+		}
+	}
+}
+
+func TestHTMLWorker_HandleTruncates(t *testing.T) {
+	page, _ := url.Parse("http://localhost/")
+	body := `<a href="/first">` + strings.Repeat(" ", 100) + `<a href="/second">`
+	var found []string
+	w := NewHTMLWorker(func(urls ...*url.URL) {
+		for _, u := range urls {
+			found = append(found, u.Path)
+		}
+	}, nil)
+	w.maxSize = 64
+	w.Handle(page, strings.NewReader(body), http.Header{})
+	if strings.Join(found, " ") != "/first" {
+		t.Errorf("Expected only /first, got %v", found)
+	}
+}
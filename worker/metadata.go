@@ -0,0 +1,210 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package worker
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/url"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// Kinds of documents we can extract metadata from.
+const (
+	docNone  = ""
+	docPDF   = "pdf"
+	docOOXML = "ooxml"
+)
+
+var ooxmlExtensions = map[string]bool{
+	".docx": true,
+	".xlsx": true,
+	".pptx": true,
+}
+
+// Determine the kind of document, if any, by extension or content type.
+func DocumentKind(u *url.URL, contentType string) string {
+	ext := strings.ToLower(path.Ext(u.Path))
+	ctype := mediaType(contentType)
+	switch {
+	case ext == ".pdf" || ctype == "application/pdf":
+		return docPDF
+	case ooxmlExtensions[ext] || strings.HasPrefix(ctype, "application/vnd.openxmlformats-officedocument."):
+		return docOOXML
+	}
+	return docNone
+}
+
+// Extract author, software and path metadata from a document body.
+func ExtractMetadata(kind string, data []byte) map[string]string {
+	var meta map[string]string
+	switch kind {
+	case docPDF:
+		meta = pdfMetadata(data)
+	case docOOXML:
+		meta = ooxmlMetadata(bytes.NewReader(data), int64(len(data)))
+	}
+	if len(meta) == 0 {
+		return nil
+	}
+	return meta
+}
+
+// Extract metadata from the head and tail of a body of size bytes, as
+// sampled from a large document.  Each end is read on its own, and archives
+// are read with the ends at their true offsets.
+func ExtractSampledMetadata(kind string, head, tail []byte, size int64) map[string]string {
+	var meta map[string]string
+	switch kind {
+	case docPDF:
+		// Updates to the info dictionary are appended, so the tail wins
+		meta = pdfMetadata(head)
+		for k, v := range pdfMetadata(tail) {
+			meta[k] = v
+		}
+	case docOOXML:
+		if tail != nil && int64(len(head)+len(tail)) <= size {
+			meta = ooxmlMetadata(&sampledBody{head: head, tail: tail, size: size}, size)
+		}
+	}
+	if len(meta) == 0 {
+		return nil
+	}
+	return meta
+}
+
+// Returned when reading a part of a sampled body that was never fetched.
+var errNotSampled = errors.New("Outside of the sampled body.")
+
+// sampledBody reads the two ends of a body as if it were whole.
+type sampledBody struct {
+	head, tail []byte
+	size       int64
+}
+
+func (b *sampledBody) ReadAt(p []byte, off int64) (int, error) {
+	end := off + int64(len(p))
+	switch tailStart := b.size - int64(len(b.tail)); {
+	case off < 0 || end > b.size:
+		return 0, errNotSampled
+	case end <= int64(len(b.head)):
+		return copy(p, b.head[off:]), nil
+	case off >= tailStart:
+		return copy(p, b.tail[off-tailStart:]), nil
+	}
+	return 0, errNotSampled
+}
+
+var pdfInfoRegexp = regexp.MustCompile(`/(Author|Creator|Producer|Title)\s*\(((?:[^()\\]|\\.)*)\)`)
+
+func pdfMetadata(data []byte) map[string]string {
+	meta := make(map[string]string)
+	for _, m := range pdfInfoRegexp.FindAllSubmatch(data, -1) {
+		key := strings.ToLower(string(m[1]))
+		if _, ok := meta[key]; ok {
+			continue
+		}
+		if val := pdfUnescape(string(m[2])); val != "" {
+			meta[key] = val
+		}
+	}
+	return meta
+}
+
+// Handle the common escapes in PDF literal strings.
+func pdfUnescape(s string) string {
+	r := strings.NewReplacer(`\(`, "(", `\)`, ")", `\\`, `\`, `\n`, " ", `\r`, " ")
+	return strings.TrimSpace(r.Replace(s))
+}
+
+type ooxmlCore struct {
+	Creator        string `xml:"creator"`
+	LastModifiedBy string `xml:"lastModifiedBy"`
+	Title          string `xml:"title"`
+}
+
+type ooxmlApp struct {
+	Application string `xml:"Application"`
+	Company     string `xml:"Company"`
+}
+
+type ooxmlWorkbook struct {
+	AbsPath struct {
+		URL string `xml:"url,attr"`
+	} `xml:"AlternateContent>Choice>absPath"`
+}
+
+func ooxmlMetadata(data io.ReaderAt, size int64) map[string]string {
+	archive, err := zip.NewReader(data, size)
+	if err != nil {
+		return nil
+	}
+	meta := make(map[string]string)
+	set := func(key, val string) {
+		if val = strings.TrimSpace(val); val != "" {
+			meta[key] = val
+		}
+	}
+	for _, f := range archive.File {
+		switch f.Name {
+		case "docProps/core.xml":
+			var core ooxmlCore
+			if readZipXML(f, &core) {
+				set("author", core.Creator)
+				set("last_modified_by", core.LastModifiedBy)
+				set("title", core.Title)
+			}
+		case "docProps/app.xml":
+			var app ooxmlApp
+			if readZipXML(f, &app) {
+				set("creator", app.Application)
+				set("company", app.Company)
+			}
+		case "xl/workbook.xml":
+			var wb ooxmlWorkbook
+			if readZipXML(f, &wb) {
+				set("path", wb.AbsPath.URL)
+			}
+		}
+	}
+	return meta
+}
+
+// Largest archive entry to decompress, so a zip bomb can't use up memory.
+// Property parts are a few KB.
+const maxZipEntry = 1024 * 1024
+
+func readZipXML(f *zip.File, v interface{}) bool {
+	if f.UncompressedSize64 > maxZipEntry {
+		return false
+	}
+	rdr, err := f.Open()
+	if err != nil {
+		return false
+	}
+	defer rdr.Close()
+	// The declared size may lie
+	buf, err := ioutil.ReadAll(io.LimitReader(rdr, maxZipEntry+1))
+	if err != nil || len(buf) > maxZipEntry {
+		return false
+	}
+	return xml.Unmarshal(buf, v) == nil
+}
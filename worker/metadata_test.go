@@ -0,0 +1,128 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package worker
+
+import (
+	"archive/zip"
+	"bytes"
+	"net/url"
+	"testing"
+)
+
+func TestDocumentKind(t *testing.T) {
+	cases := []struct {
+		path  string
+		ctype string
+		kind  string
+	}{
+		{"/files/report.PDF", "", docPDF},
+		{"/download", "application/pdf", docPDF},
+		{"/budget.xlsx", "application/octet-stream", docOOXML},
+		{"/x", "application/vnd.openxmlformats-officedocument.wordprocessingml.document", docOOXML},
+		{"/index.html", "text/html", docNone},
+	}
+	for _, c := range cases {
+		u := &url.URL{Scheme: "http", Host: "localhost", Path: c.path}
+		if kind := DocumentKind(u, c.ctype); kind != c.kind {
+			t.Errorf("Expected %q for %s, got %q", c.kind, c.path, kind)
+		}
+	}
+}
+
+func TestExtractMetadata_PDF(t *testing.T) {
+	pdf := []byte("%PDF-1.4\n1 0 obj\n<< /Author (J. Smith \\(IT\\)) /Producer (Acrobat Distiller 9.0) /Title () >>\nendobj\n")
+	meta := ExtractMetadata(docPDF, pdf)
+	if meta["author"] != "J. Smith (IT)" {
+		t.Errorf("Unexpected author: %q", meta["author"])
+	}
+	if meta["producer"] != "Acrobat Distiller 9.0" {
+		t.Errorf("Unexpected producer: %q", meta["producer"])
+	}
+	if _, ok := meta["title"]; ok {
+		t.Error("Expected empty title to be omitted.")
+	}
+}
+
+func TestExtractMetadata_OOXML(t *testing.T) {
+	buf := &bytes.Buffer{}
+	zw := zip.NewWriter(buf)
+	files := map[string]string{
+		"docProps/core.xml": `<cp:coreProperties xmlns:cp="http://schemas.openxmlformats.org/package/2006/metadata/core-properties" xmlns:dc="http://purl.org/dc/elements/1.1/"><dc:creator>jsmith</dc:creator><cp:lastModifiedBy>admin</cp:lastModifiedBy></cp:coreProperties>`,
+		"docProps/app.xml":  `<Properties xmlns="http://schemas.openxmlformats.org/officeDocument/2006/extended-properties"><Application>Microsoft Excel</Application></Properties>`,
+		"xl/workbook.xml":   `<workbook xmlns:mc="http://schemas.openxmlformats.org/markup-compatibility/2006" xmlns:x15ac="http://schemas.microsoft.com/office/spreadsheetml/2010/11/ac"><mc:AlternateContent><mc:Choice Requires="x15"><x15ac:absPath url="C:\Users\jsmith\Desktop\"/></mc:Choice></mc:AlternateContent></workbook>`,
+	}
+	for name, content := range files {
+		fw, _ := zw.Create(name)
+		fw.Write([]byte(content))
+	}
+	zw.Close()
+	meta := ExtractMetadata(docOOXML, buf.Bytes())
+	expected := map[string]string{
+		"author":           "jsmith",
+		"last_modified_by": "admin",
+		"creator":          "Microsoft Excel",
+		"path":             `C:\Users\jsmith\Desktop\`,
+	}
+	for k, v := range expected {
+		if meta[k] != v {
+			t.Errorf("Expected %s=%q, got %q", k, v, meta[k])
+		}
+	}
+	if ExtractMetadata(docOOXML, []byte("not a zip")) != nil {
+		t.Error("Expected nil metadata for invalid archive.")
+	}
+}
+
+func TestExtractMetadata_ZipBomb(t *testing.T) {
+	buf := &bytes.Buffer{}
+	zw := zip.NewWriter(buf)
+	fw, _ := zw.Create("docProps/core.xml")
+	fw.Write([]byte(`<cp:coreProperties xmlns:cp="http://schemas.openxmlformats.org/package/2006/metadata/core-properties" xmlns:dc="http://purl.org/dc/elements/1.1/"><dc:creator>jsmith</dc:creator>`))
+	fw.Write(bytes.Repeat([]byte(" "), 2*maxZipEntry))
+	fw.Write([]byte(`</cp:coreProperties>`))
+	zw.Close()
+	if meta := ExtractMetadata(docOOXML, buf.Bytes()); meta["author"] != "" {
+		t.Errorf("Expected the oversized entry skipped, got %v", meta)
+	}
+}
+
+func TestExtractSampledMetadata_OOXML(t *testing.T) {
+	buf := &bytes.Buffer{}
+	zw := zip.NewWriter(buf)
+	fw, _ := zw.Create("docProps/core.xml")
+	fw.Write([]byte(`<cp:coreProperties xmlns:cp="http://schemas.openxmlformats.org/package/2006/metadata/core-properties" xmlns:dc="http://purl.org/dc/elements/1.1/"><dc:creator>jsmith</dc:creator></cp:coreProperties>`))
+	fw, _ = zw.CreateHeader(&zip.FileHeader{Name: "word/media/image1.bin", Method: zip.Store})
+	fw.Write(bytes.Repeat([]byte{0xff}, 64*1024))
+	zw.Close()
+	data := buf.Bytes()
+	head, tail := data[:4096], data[len(data)-4096:]
+	meta := ExtractSampledMetadata(docOOXML, head, tail, int64(len(data)))
+	if meta["author"] != "jsmith" {
+		t.Errorf("Expected author from sampled archive, got %v", meta)
+	}
+	// Joined together the offsets are wrong
+	if meta := ExtractMetadata(docOOXML, append(append([]byte(nil), head...), tail...)); meta["author"] == "jsmith" {
+		t.Error("Expected a joined sample not to read as an archive.")
+	}
+}
+
+func TestExtractSampledMetadata_PDF(t *testing.T) {
+	head := []byte("%PDF-1.4\n<< /Author (Old Author) /Producer (Writer) >>")
+	tail := []byte("<< /Author (New Author) >>\ntrailer\n%%EOF")
+	meta := ExtractSampledMetadata(docPDF, head, tail, 1<<30)
+	if meta["author"] != "New Author" || meta["producer"] != "Writer" {
+		t.Errorf("Unexpected metadata: %v", meta)
+	}
+}
@@ -0,0 +1,212 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package worker
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"github.com/Matir/gobuster/client"
+	"github.com/Matir/gobuster/logging"
+	"github.com/Matir/gobuster/results"
+	ss "github.com/Matir/gobuster/settings"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"sync"
+)
+
+// NotFoundDetector learns what a "not found" page looks like for each
+// directory by requesting a name that should not exist.  Different
+// applications mounted under one host frequently have different error pages,
+// so signatures are kept per directory (and per extension) rather than
+// globally.
+type NotFoundDetector struct {
+	// client used for probes, separate from the worker clients so that
+	// redirect handling doesn't interfere
+	client client.Client
+	// method and body of the scan's requests, if set
+	settings *ss.ScanSettings
+	// signatures by directory & extension
+	sigs map[string]*notFoundSignature
+	sync.Mutex
+}
+
+// Longest body of a not found page compared without a Content-Length.
+const maxNotFoundRead = 64 * 1024
+
+type notFoundSignature struct {
+	once sync.Once
+	// nil if the directory returns real 404s
+	sig *responseSignature
+}
+
+type responseSignature struct {
+	code int
+	// length of the body, -1 if unknown
+	length int64
+	// hash of the body without the name requested, empty if unknown
+	hash string
+	// length of the name used for the probe, to allow for pages that
+	// reflect the requested name
+	nameLen int
+}
+
+func NewNotFoundDetector(factory client.ClientFactory) *NotFoundDetector {
+	return &NotFoundDetector{
+		client: factory.Get(),
+		sigs:   make(map[string]*notFoundSignature),
+	}
+}
+
+// Probe with the method and body the scan's requests use, as a page can
+// answer a POST or HEAD differently from a GET.
+func (d *NotFoundDetector) SetRequestSettings(settings *ss.ScanSettings) {
+	d.settings = settings
+}
+
+// Check if the response for u looks like the not found page for its
+// directory.
+func (d *NotFoundDetector) IsNotFound(u *url.URL, resp *http.Response) bool {
+	dir, ext := notFoundKey(u)
+	sig := d.getSignature(u, dir, ext)
+	if sig == nil || resp.StatusCode != sig.code {
+		return false
+	}
+	length := resp.ContentLength
+	if length < 0 {
+		// Without a length to go by, look at the body itself
+		var hash string
+		length, hash = peekBody(resp, path.Base(u.Path))
+		if hash != "" && hash == sig.hash {
+			return true
+		}
+	}
+	if length < 0 || sig.length < 0 {
+		// Nothing to tell them apart by, so keep it
+		return false
+	}
+	diff := length - sig.length
+	if diff < 0 {
+		diff = -diff
+	}
+	slack := len(path.Base(u.Path)) - sig.nameLen
+	if slack < 0 {
+		slack = -slack
+	}
+	return diff <= int64(slack)
+}
+
+func (d *NotFoundDetector) getSignature(u *url.URL, dir, ext string) *responseSignature {
+	key := u.Scheme + "://" + u.Host + dir + "|" + ext
+	d.Lock()
+	entry, ok := d.sigs[key]
+	if !ok {
+		entry = &notFoundSignature{}
+		d.sigs[key] = entry
+	}
+	d.Unlock()
+	entry.once.Do(func() {
+		entry.sig = d.probe(u, dir, ext)
+	})
+	return entry.sig
+}
+
+// Request a random name in the directory and build a signature.
+func (d *NotFoundDetector) probe(u *url.URL, dir, ext string) *responseSignature {
+	name := fmt.Sprintf("%x%x", rand.Int63(), rand.Int63()) + ext
+	probeURL := *u
+	probeURL.RawPath = ""
+	probeURL.Path = dir + name
+	logging.Logf(logging.LogDebug, "Probing not found page with %s", probeURL.String())
+	resp, err := sendRequest(d.settings, d.client, &probeURL)
+	if err != nil {
+		logging.Logf(logging.LogInfo, "Unable to probe not found page for %s: %s", dir, err.Error())
+		return nil
+	}
+	defer resp.Body.Close()
+	if !results.FoundSomething(resp.StatusCode) {
+		return nil
+	}
+	logging.Logf(logging.LogInfo, "Soft 404 detected for %s: %d", dir, resp.StatusCode)
+	length, hash := peekBody(resp, name)
+	if resp.ContentLength >= 0 {
+		length = resp.ContentLength
+	}
+	return &responseSignature{
+		code:    resp.StatusCode,
+		length:  length,
+		hash:    hash,
+		nameLen: len(name),
+	}
+}
+
+// Read up to maxNotFoundRead of the body of resp, leaving it to be read
+// again, and return its length and hash without name, which the page may
+// reflect.  The length is -1 and the hash empty for longer bodies, and for
+// responses to HEAD, which have none.
+func peekBody(resp *http.Response, name string) (int64, string) {
+	if resp.Request != nil && resp.Request.Method == http.MethodHead {
+		return -1, ""
+	}
+	head, _ := ioutil.ReadAll(io.LimitReader(resp.Body, maxNotFoundRead+1))
+	peeked := peekedBody{io.MultiReader(bytes.NewReader(head), resp.Body), resp.Body}
+	if cs, ok := resp.Body.(client.CompressionStats); ok {
+		resp.Body = &peekedStatsBody{peeked, cs}
+	} else {
+		resp.Body = &peeked
+	}
+	if len(head) > maxNotFoundRead {
+		return -1, ""
+	}
+	sum := sha256.Sum256(bytes.Replace(head, []byte(name), nil, -1))
+	return int64(len(head)), hex.EncodeToString(sum[:])
+}
+
+// A body read from the start again after peeking at it.
+type peekedBody struct {
+	io.Reader
+	body io.ReadCloser
+}
+
+func (b *peekedBody) Close() error {
+	return b.body.Close()
+}
+
+// A peeked body that still says how it was decompressed.
+type peekedStatsBody struct {
+	peekedBody
+	client.CompressionStats
+}
+
+// The directory containing u (always ending in /) and u's extension, if any.
+// A directory is considered to be in its parent.
+func notFoundKey(u *url.URL) (string, string) {
+	trimmed := strings.TrimRight(u.Path, "/")
+	spos := strings.LastIndex(trimmed, "/")
+	if spos == -1 {
+		return "/", ""
+	}
+	dir := trimmed[:spos+1]
+	if strings.HasSuffix(u.Path, "/") {
+		return dir, ""
+	}
+	return dir, path.Ext(trimmed)
+}
@@ -0,0 +1,135 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package worker
+
+import (
+	"github.com/Matir/gobuster/client/mock"
+	ss "github.com/Matir/gobuster/settings"
+	"io/ioutil"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestNotFoundKey(t *testing.T) {
+	cases := []struct {
+		path, dir, ext string
+	}{
+		{"/foo/bar.php", "/foo/", ".php"},
+		{"/foo/bar", "/foo/", ""},
+		{"/foo/bar/", "/foo/", ""},
+		{"/", "/", ""},
+	}
+	for _, c := range cases {
+		dir, ext := notFoundKey(&url.URL{Path: c.path})
+		if dir != c.dir || ext != c.ext {
+			t.Errorf("Expected (%s, %s) for %s, got (%s, %s)", c.dir, c.ext, c.path, dir, ext)
+		}
+	}
+}
+
+func TestNotFoundDetector_Soft404(t *testing.T) {
+	probe := mock.ResponseFromString("")
+	probe.StatusCode = 200
+	probe.ContentLength = 100
+	factory := &mock.MockClientFactory{NextClient: &mock.MockClient{ForeverResponse: probe}}
+	d := NewNotFoundDetector(factory)
+	u := &url.URL{Scheme: "http", Host: "localhost", Path: "/foo/bar"}
+
+	resp := mock.ResponseFromString("")
+	resp.StatusCode = 200
+	resp.ContentLength = 100
+	if !d.IsNotFound(u, resp) {
+		t.Error("Expected matching response to be not found.")
+	}
+	resp.ContentLength = 5000
+	if d.IsNotFound(u, resp) {
+		t.Error("Expected differing response to be found.")
+	}
+}
+
+func TestNotFoundDetector_Method(t *testing.T) {
+	probe := mock.ResponseFromString("")
+	probe.StatusCode = 404
+	client := &mock.MockClient{ForeverResponse: probe}
+	d := NewNotFoundDetector(&mock.MockClientFactory{NextClient: client})
+	d.SetRequestSettings(&ss.ScanSettings{Data: "user=FUZZ", ContentType: "application/x-www-form-urlencoded"})
+	u := &url.URL{Scheme: "http", Host: "localhost", Path: "/foo/bar"}
+	d.IsNotFound(u, mock.ResponseFromString(""))
+	if strings.Join(client.Methods, " ") != "POST" {
+		t.Errorf("Expected the probe to POST like the scan, got %v", client.Methods)
+	}
+	if len(client.Bodies) != 1 || !strings.HasPrefix(client.Bodies[0], "user=") || client.Bodies[0] == "user=FUZZ" {
+		t.Errorf("Expected the probe's name in its body, got %v", client.Bodies)
+	}
+}
+
+func TestNotFoundDetector_Real404(t *testing.T) {
+	probe := mock.ResponseFromString("")
+	probe.StatusCode = 404
+	client := &mock.MockClient{ForeverResponse: probe}
+	d := NewNotFoundDetector(&mock.MockClientFactory{NextClient: client})
+	u := &url.URL{Scheme: "http", Host: "localhost", Path: "/foo/bar"}
+	resp := mock.ResponseFromString("")
+	resp.StatusCode = 200
+	if d.IsNotFound(u, resp) {
+		t.Error("Expected response to be found.")
+	}
+	d.IsNotFound(&url.URL{Scheme: "http", Host: "localhost", Path: "/foo/baz"}, resp)
+	if len(client.Requests) != 1 {
+		t.Errorf("Expected one probe per directory, got %d", len(client.Requests))
+	}
+}
+
+func TestNotFoundDetector_UnknownLength(t *testing.T) {
+	probe := mock.ResponseFromString("Sorry, nothing here.")
+	probe.StatusCode = 200
+	probe.ContentLength = -1
+	d := NewNotFoundDetector(&mock.MockClientFactory{NextClient: &mock.MockClient{ForeverResponse: probe}})
+	u := &url.URL{Scheme: "http", Host: "localhost", Path: "/foo/bar"}
+
+	same := mock.ResponseFromString("Sorry, nothing here.")
+	same.StatusCode = 200
+	same.ContentLength = -1
+	if !d.IsNotFound(u, same) {
+		t.Error("Expected the same body to be not found.")
+	}
+	page := mock.ResponseFromString("<html>" + strings.Repeat("Welcome to the admin console. ", 20) + "</html>")
+	page.StatusCode = 200
+	page.ContentLength = -1
+	if d.IsNotFound(u, page) {
+		t.Error("Expected a different body to be found.")
+	}
+	if body, _ := ioutil.ReadAll(page.Body); !strings.HasPrefix(string(body), "<html>Welcome") {
+		t.Errorf("Expected the body left to read, got %q", body)
+	}
+}
+
+func TestNotFoundDetector_PerHost(t *testing.T) {
+	probe := mock.ResponseFromString("")
+	probe.StatusCode = 200
+	probe.ContentLength = 100
+	client := &mock.MockClient{ForeverResponse: probe}
+	d := NewNotFoundDetector(&mock.MockClientFactory{NextClient: client})
+	resp := mock.ResponseFromString("")
+	resp.StatusCode = 200
+	resp.ContentLength = 100
+	for _, host := range []string{"a.example.com", "b.example.com"} {
+		d.IsNotFound(&url.URL{Scheme: "http", Host: host, Path: "/foo/bar"}, resp)
+	}
+	if len(client.Requests) != 2 {
+		t.Errorf("Expected one probe per host, got %d", len(client.Requests))
+	}
+}
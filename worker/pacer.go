@@ -0,0 +1,113 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package worker
+
+import (
+	"github.com/Matir/gobuster/logging"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Number of latency samples used for each adjustment
+const pacerWindow = 20
+
+// LatencyPacer limits how many requests are in flight so that the target's
+// 95th percentile latency stays under a threshold.  The limit is halved when
+// the threshold is exceeded and grows by one while latency is acceptable.
+type LatencyPacer struct {
+	// p95 latency to stay under
+	target time.Duration
+	// Current and maximum concurrency
+	limit int
+	max   int
+	// Requests currently in flight
+	inFlight int
+	// Latencies since the last adjustment
+	samples []time.Duration
+	cond    *sync.Cond
+	sync.Mutex
+}
+
+func NewLatencyPacer(target time.Duration, max int) *LatencyPacer {
+	if max < 1 {
+		max = 1
+	}
+	p := &LatencyPacer{
+		target:  target,
+		limit:   max,
+		max:     max,
+		samples: make([]time.Duration, 0, pacerWindow),
+	}
+	p.cond = sync.NewCond(&p.Mutex)
+	return p
+}
+
+// Wait for a slot to make a request.
+func (p *LatencyPacer) Acquire() {
+	p.Lock()
+	defer p.Unlock()
+	for p.inFlight >= p.limit {
+		p.cond.Wait()
+	}
+	p.inFlight++
+}
+
+// Release a slot, recording the latency of the request made.
+func (p *LatencyPacer) Release(latency time.Duration) {
+	p.Lock()
+	defer p.Unlock()
+	p.inFlight--
+	p.samples = append(p.samples, latency)
+	if len(p.samples) >= pacerWindow {
+		p.adjust()
+	}
+	p.cond.Broadcast()
+}
+
+func (p *LatencyPacer) adjust() {
+	p95 := percentile(p.samples, 95)
+	p.samples = p.samples[:0]
+	if p95 > p.target {
+		if p.limit > 1 {
+			p.limit /= 2
+			logging.Logf(logging.LogInfo, "p95 latency %s over %s, reducing concurrency to %d", p95, p.target, p.limit)
+		}
+	} else if p.limit < p.max {
+		p.limit++
+		logging.Logf(logging.LogDebug, "p95 latency %s, increasing concurrency to %d", p95, p.limit)
+	}
+}
+
+// Current concurrency limit.
+func (p *LatencyPacer) Limit() int {
+	p.Lock()
+	defer p.Unlock()
+	return p.limit
+}
+
+func percentile(samples []time.Duration, pct int) time.Duration {
+	if len(samples) == 0 {
+		return 0
+	}
+	sorted := make([]time.Duration, len(samples))
+	copy(sorted, samples)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	idx := (len(sorted)*pct + 99) / 100
+	if idx > 0 {
+		idx--
+	}
+	return sorted[idx]
+}
@@ -0,0 +1,68 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package worker
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPercentile(t *testing.T) {
+	samples := make([]time.Duration, 0, 100)
+	for i := 100; i > 0; i-- {
+		samples = append(samples, time.Duration(i))
+	}
+	if p := percentile(samples, 95); p != 95 {
+		t.Errorf("Expected p95 of 95, got %d", p)
+	}
+	if p := percentile(nil, 95); p != 0 {
+		t.Errorf("Expected 0 for no samples, got %d", p)
+	}
+}
+
+func TestLatencyPacer_Adjust(t *testing.T) {
+	p := NewLatencyPacer(100*time.Millisecond, 8)
+	for i := 0; i < pacerWindow; i++ {
+		p.Acquire()
+		p.Release(time.Second)
+	}
+	if p.Limit() != 4 {
+		t.Errorf("Expected limit to halve to 4, got %d", p.Limit())
+	}
+	for i := 0; i < pacerWindow; i++ {
+		p.Acquire()
+		p.Release(time.Millisecond)
+	}
+	if p.Limit() != 5 {
+		t.Errorf("Expected limit to grow to 5, got %d", p.Limit())
+	}
+}
+
+func TestLatencyPacer_Blocks(t *testing.T) {
+	p := NewLatencyPacer(time.Second, 1)
+	p.Acquire()
+	acquired := make(chan bool)
+	go func() {
+		p.Acquire()
+		acquired <- true
+	}()
+	select {
+	case <-acquired:
+		t.Fatal("Expected second Acquire to block.")
+	case <-time.After(10 * time.Millisecond):
+	}
+	p.Release(0)
+	<-acquired
+}
@@ -0,0 +1,143 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package worker
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// SecretRule is a named pattern for secrets that may leak in bodies.
+type SecretRule struct {
+	Name    string
+	Pattern *regexp.Regexp
+}
+
+var DefaultSecretRules = []SecretRule{
+	{"AWS Access Key", regexp.MustCompile(`\b(?:AKIA|ASIA)[0-9A-Z]{16}\b`)},
+	{"Private Key", regexp.MustCompile(`-----BEGIN (?:RSA |EC |DSA |OPENSSH |PGP |ENCRYPTED )?PRIVATE KEY(?: BLOCK)?-----`)},
+	{"JWT", regexp.MustCompile(`\beyJ[A-Za-z0-9_-]{10,}\.eyJ[A-Za-z0-9_-]{10,}\.[A-Za-z0-9_-]{10,}`)},
+	{"Connection String", regexp.MustCompile(`\b(?:mongodb(?:\+srv)?|postgres(?:ql)?|mysql|redis|amqp)://[^\s:@/]+:[^\s@/]+@[^\s'"<>]+`)},
+	{"API Key", regexp.MustCompile(`(?i)(?:api[_-]?key|secret[_-]?key|access[_-]?token)["']?\s*[:=]\s*["'][A-Za-z0-9_\-]{16,}["']`)},
+	// Planted to catch intruders, a sign of a deception environment
+	{"Canary Token", regexp.MustCompile(`(?i)\b(?:[a-z0-9-]+\.)*canarytokens\.(?:com|net|org)\b[^\s'"<>]*`)},
+}
+
+// Load secret rules from a file with one "name: regex" per line.
+func LoadSecretRules(path string) ([]SecretRule, error) {
+	fp, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer fp.Close()
+	rules := make([]SecretRule, 0)
+	scanner := bufio.NewScanner(fp)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || line[0] == '#' {
+			continue
+		}
+		pieces := strings.SplitN(line, ":", 2)
+		if len(pieces) != 2 {
+			return nil, fmt.Errorf("Line %d: expected name: regex", lineNo)
+		}
+		re, err := regexp.Compile(strings.TrimSpace(pieces[1]))
+		if err != nil {
+			return nil, fmt.Errorf("Line %d: %s", lineNo, err.Error())
+		}
+		rules = append(rules, SecretRule{Name: strings.TrimSpace(pieces[0]), Pattern: re})
+	}
+	return rules, scanner.Err()
+}
+
+// Bytes carried over between writes so that secrets spanning two reads are
+// still found.  Matches ending within them wait for the next write, in case
+// it extends them.
+const secretOverlap = 1024
+
+// Most bytes carried over for a match that hasn't ended yet.
+const maxSecretWindow = 64 * 1024
+
+// SecretScanner is an io.Writer that looks for secrets as a body streams by.
+type SecretScanner struct {
+	rules []SecretRule
+	// Tail of the previous write
+	window []byte
+	// Matches found, as "name: match"
+	matches []string
+	seen    map[string]bool
+}
+
+func NewSecretScanner(rules []SecretRule) *SecretScanner {
+	return &SecretScanner{rules: rules, seen: make(map[string]bool)}
+}
+
+func (s *SecretScanner) Write(p []byte) (int, error) {
+	buf := append(s.window, p...)
+	settled := len(buf) - secretOverlap
+	if settled < 0 {
+		settled = 0
+	}
+	// Keep the overlap, and the start of any match that may go on
+	from := settled
+	for _, rule := range s.rules {
+		for _, loc := range rule.Pattern.FindAllIndex(buf, -1) {
+			if loc[1] <= settled {
+				s.add(rule.Name, buf[loc[0]:loc[1]])
+			} else if loc[0] < from {
+				from = loc[0]
+			}
+		}
+	}
+	if len(buf)-from > maxSecretWindow {
+		from = len(buf) - maxSecretWindow
+	}
+	s.window = append(s.window[:0], buf[from:]...)
+	return len(p), nil
+}
+
+// Take every match left in the window, as nothing follows it.
+func (s *SecretScanner) flush() {
+	for _, rule := range s.rules {
+		for _, m := range rule.Pattern.FindAll(s.window, -1) {
+			s.add(rule.Name, m)
+		}
+	}
+	s.window = s.window[:0]
+}
+
+func (s *SecretScanner) add(name string, match []byte) {
+	found := name + ": " + string(match)
+	if !s.seen[found] {
+		s.seen[found] = true
+		s.matches = append(s.matches, found)
+	}
+}
+
+// Note that part of the body was skipped, so no match spans the gap.
+func (s *SecretScanner) Skip() {
+	s.flush()
+}
+
+// The matches in everything written.  Call once the body is done.
+func (s *SecretScanner) Matches() []string {
+	s.flush()
+	return s.matches
+}
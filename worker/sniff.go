@@ -0,0 +1,103 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package worker
+
+import (
+	"bytes"
+	"mime"
+	"net/http"
+	"strings"
+)
+
+// Number of bytes needed to sniff a content type
+const sniffLen = 512
+
+// Signatures not recognized by http.DetectContentType, checked first.
+var extraSignatures = []struct {
+	prefix   []byte
+	mimeType string
+}{
+	{[]byte("<?php"), "application/x-httpd-php"},
+	{[]byte("<%@"), "application/x-aspx"},
+	{[]byte("#!/"), "text/x-shellscript"},
+}
+
+// Sniff the media type (without parameters) of a body from its first bytes.
+func SniffContentType(head []byte) string {
+	trimmed := bytes.TrimLeft(head, " \t\r\n")
+	for _, sig := range extraSignatures {
+		if bytes.HasPrefix(trimmed, sig.prefix) {
+			return sig.mimeType
+		}
+	}
+	return mediaType(http.DetectContentType(head))
+}
+
+// Text served under application types, which sniffs as text/plain or
+// text/xml.  Types ending in +json or +xml are text too.
+var textApplicationTypes = map[string]bool{
+	"application/json":         true,
+	"application/javascript":   true,
+	"application/x-javascript": true,
+	"application/ecmascript":   true,
+	"application/xml":          true,
+	"application/graphql":      true,
+	"application/x-ndjson":     true,
+}
+
+// Check if a declared content type disagrees with a sniffed one.  Only
+// differences in the top-level type count, as sniffing can't reliably tell
+// apart e.g. text/plain and text/css, and text served as JSON, JavaScript,
+// XML or SVG is text.  Server-side source is always a mismatch, as it should
+// never be served, and so is HTML served as anything but HTML or XML, as it
+// is parsed for links.
+func TypeMismatch(declared, sniffed string) bool {
+	declared = mediaType(declared)
+	if declared == "" || sniffed == "" || sniffed == "application/octet-stream" {
+		return false
+	}
+	if declared == sniffed {
+		return false
+	}
+	for _, sig := range extraSignatures {
+		if sniffed == sig.mimeType {
+			return true
+		}
+	}
+	if sniffed == "text/html" && !isHTMLType(declared) && !strings.HasSuffix(declared, "xml") {
+		return true
+	}
+	if isText(declared) && isText(sniffed) {
+		return false
+	}
+	return topLevelType(declared) != topLevelType(sniffed)
+}
+
+// Whether a media type is some kind of text.
+func isText(mt string) bool {
+	return topLevelType(mt) == "text" || textApplicationTypes[mt] ||
+		strings.HasSuffix(mt, "+json") || strings.HasSuffix(mt, "+xml")
+}
+
+func mediaType(contentType string) string {
+	if mt, _, err := mime.ParseMediaType(contentType); err == nil {
+		return mt
+	}
+	return strings.ToLower(strings.TrimSpace(strings.SplitN(contentType, ";", 2)[0]))
+}
+
+func topLevelType(mt string) string {
+	return strings.SplitN(mt, "/", 2)[0]
+}
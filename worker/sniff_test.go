@@ -0,0 +1,119 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package worker
+
+import (
+	"github.com/Matir/gobuster/client/mock"
+	"github.com/Matir/gobuster/results"
+	"github.com/Matir/gobuster/settings"
+	"net/url"
+	"testing"
+)
+
+func TestSniffContentType(t *testing.T) {
+	cases := []struct {
+		body, expected string
+	}{
+		{"<?php echo 1; ?>", "application/x-httpd-php"},
+		{"  <!DOCTYPE html><html>", "text/html"},
+		{"\x89PNG\r\n\x1a\n", "image/png"},
+		{"hello", "text/plain"},
+	}
+	for _, c := range cases {
+		if mt := SniffContentType([]byte(c.body)); mt != c.expected {
+			t.Errorf("Expected %s for %q, got %s", c.expected, c.body, mt)
+		}
+	}
+}
+
+func TestTypeMismatch(t *testing.T) {
+	cases := []struct {
+		declared, sniffed string
+		expected          bool
+	}{
+		{"image/jpeg", "application/x-httpd-php", true},
+		{"text/html", "application/x-httpd-php", true},
+		{"image/jpeg", "text/plain", true},
+		{"text/css", "text/plain", false},
+		{"text/html; charset=utf-8", "text/html", false},
+		{"", "text/html", false},
+		{"image/png", "application/octet-stream", false},
+		{"application/json", "text/plain", false},
+		{"application/javascript; charset=utf-8", "text/plain", false},
+		{"application/problem+json", "text/plain", false},
+		{"image/svg+xml", "text/xml", false},
+		{"application/xml", "text/xml", false},
+		{"application/json", "application/x-httpd-php", true},
+		{"application/pdf", "text/plain", true},
+		{"text/plain", "text/html", true},
+		{"application/xhtml+xml", "text/html", false},
+		{"text/xml", "text/html", false},
+	}
+	for _, c := range cases {
+		if m := TypeMismatch(c.declared, c.sniffed); m != c.expected {
+			t.Errorf("Expected %v for %s vs %s", c.expected, c.declared, c.sniffed)
+		}
+	}
+}
+
+func TestTryURL_Sniff(t *testing.T) {
+	resp := mock.ResponseFromString("<?php phpinfo(); ?>")
+	resp.StatusCode = 200
+	resp.Header = map[string][]string{"Content-Type": {"image/jpeg"}}
+	rchan := make(chan results.Result, 1)
+	w := &Worker{
+		client:   &mock.MockClient{NextResponse: resp},
+		settings: &settings.ScanSettings{SniffTypes: true},
+		rchan:    rchan,
+		adder:    noopUrl,
+	}
+	w.TryURL(&url.URL{Scheme: "http", Host: "localhost", Path: "/a.jpg"})
+	res := <-rchan
+	if !res.TypeMismatch || res.SniffedType != "application/x-httpd-php" || res.ContentType != "image/jpeg" {
+		t.Errorf("Expected type mismatch to be flagged, got %+v", res)
+	}
+	if ct := resp.Header.Get("Content-Type"); ct != "image/jpeg" {
+		t.Errorf("Expected the declared type left alone, got %s", ct)
+	}
+}
+
+func TestTryURL_SniffedHTML(t *testing.T) {
+	resp := mock.ResponseFromString(`<html><body><a href="/hidden/">x</a></body></html>`)
+	resp.StatusCode = 200
+	resp.ContentLength = -1
+	resp.Header = map[string][]string{"Content-Type": {"text/plain"}}
+	rchan := make(chan results.Result, 1)
+	var found []string
+	adder := func(urls ...*url.URL) {
+		for _, u := range urls {
+			found = append(found, u.Path)
+		}
+	}
+	w := &Worker{
+		client:   &mock.MockClient{NextResponse: resp},
+		settings: &settings.ScanSettings{SniffTypes: true},
+		rchan:    rchan,
+		adder:    noopUrl,
+	}
+	w.SetPageWorker(NewHTMLWorker(adder, nil))
+	w.TryURL(&url.URL{Scheme: "http", Host: "localhost", Path: "/notes.txt"})
+	res := <-rchan
+	if !res.TypeMismatch || res.SniffedType != "text/html" {
+		t.Errorf("Expected HTML served as text to be flagged, got %+v", res)
+	}
+	if len(found) != 1 || found[0] != "/hidden/" {
+		t.Errorf("Expected the link in the body to be followed, got %v", found)
+	}
+}
@@ -0,0 +1,118 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package worker
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/url"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Kinds of bodies involved in sourcemaps.
+const (
+	mapNone   = ""
+	mapScript = "script"
+	mapJSON   = "map"
+)
+
+var sourceMappingURLRE = regexp.MustCompile(`[#@]\s*sourceMappingURL=([^\s'"*]+)`)
+
+// Absolute paths in quoted strings, e.g. "/api/v1/users"
+var sourcePathRE = regexp.MustCompile(`["'` + "`" + `](/[A-Za-z0-9_\-.~%/]*[A-Za-z0-9_\-])["'` + "`" + `]`)
+
+// SourceMap holds what we report from a parsed sourcemap.
+type SourceMap struct {
+	// Names of the original source files
+	Sources []string
+	// Absolute paths mentioned in the original sources
+	Paths []string
+}
+
+// Determine whether a body is a script that may reference a sourcemap, or
+// a sourcemap itself.
+func SourceMapKind(u *url.URL, contentType string) string {
+	ext := strings.ToLower(path.Ext(u.Path))
+	ctype := mediaType(contentType)
+	switch {
+	case ext == ".map":
+		return mapJSON
+	case ext == ".js" || ext == ".mjs" || ext == ".css" || strings.HasSuffix(ctype, "javascript") || ctype == "text/css":
+		return mapScript
+	}
+	return mapNone
+}
+
+// Find the sourcemap reference in a script, which may be a data: URL.
+func SourceMappingURL(body []byte) string {
+	matches := sourceMappingURLRE.FindAllSubmatch(body, -1)
+	if len(matches) == 0 {
+		return ""
+	}
+	// The last reference wins
+	return string(matches[len(matches)-1][1])
+}
+
+// Decode a sourcemap embedded as a base64 data: URL.
+func InlineSourceMap(ref string) *SourceMap {
+	if !strings.HasPrefix(ref, "data:") {
+		return nil
+	}
+	comma := strings.Index(ref, ",")
+	if comma < 0 || !strings.HasSuffix(ref[:comma], ";base64") {
+		return nil
+	}
+	data, err := base64.StdEncoding.DecodeString(ref[comma+1:])
+	if err != nil {
+		return nil
+	}
+	return ParseSourceMap(data)
+}
+
+// Parse a sourcemap, returning nil if it isn't one.
+func ParseSourceMap(data []byte) *SourceMap {
+	var raw struct {
+		Version        int
+		SourceRoot     string
+		Sources        []string
+		SourcesContent []*string
+	}
+	if err := json.Unmarshal(data, &raw); err != nil || raw.Version == 0 || len(raw.Sources) == 0 {
+		return nil
+	}
+	sm := &SourceMap{}
+	for _, src := range raw.Sources {
+		sm.Sources = append(sm.Sources, raw.SourceRoot+src)
+	}
+	paths := make(map[string]bool)
+	for _, content := range raw.SourcesContent {
+		if content == nil {
+			continue
+		}
+		for _, m := range sourcePathRE.FindAllStringSubmatch(*content, -1) {
+			if len(m[1]) > 1 && !strings.HasPrefix(m[1], "//") {
+				paths[m[1]] = true
+			}
+		}
+	}
+	for p := range paths {
+		sm.Paths = append(sm.Paths, p)
+	}
+	sort.Strings(sm.Paths)
+	return sm
+}
@@ -0,0 +1,125 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package worker
+
+import (
+	"github.com/Matir/gobuster/client/mock"
+	"github.com/Matir/gobuster/results"
+	"github.com/Matir/gobuster/settings"
+	"github.com/Matir/gobuster/workqueue"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestSourceMapKind(t *testing.T) {
+	cases := []struct {
+		path        string
+		contentType string
+		expected    string
+	}{
+		{"/app.js", "", mapScript},
+		{"/app", "application/javascript; charset=utf-8", mapScript},
+		{"/style.css", "", mapScript},
+		{"/app.js.map", "application/json", mapJSON},
+		{"/index.html", "text/html", mapNone},
+	}
+	for _, c := range cases {
+		if res := SourceMapKind(&url.URL{Path: c.path}, c.contentType); res != c.expected {
+			t.Errorf("Expected %q for %s, got %q", c.expected, c.path, res)
+		}
+	}
+}
+
+func TestSourceMappingURL(t *testing.T) {
+	cases := map[string]string{
+		"var a=1;\n//# sourceMappingURL=app.js.map\n":            "app.js.map",
+		"var a=1;\n//@ sourceMappingURL=/old.map":                "/old.map",
+		"body{}\n/*# sourceMappingURL=style.css.map */":          "style.css.map",
+		"//# sourceMappingURL=a.map\n//# sourceMappingURL=b.map": "b.map",
+		"var a = 'no map here';":                                 "",
+	}
+	for body, expected := range cases {
+		if res := SourceMappingURL([]byte(body)); res != expected {
+			t.Errorf("Expected %q for %q, got %q", expected, body, res)
+		}
+	}
+}
+
+func TestParseSourceMap(t *testing.T) {
+	data := `{"version":3,"sourceRoot":"webpack:///","sources":["src/api.ts","src/admin/panel.ts"],
+"sourcesContent":["fetch('/api/v2/users'); fetch(\"/internal/debug\"); var u = \"//cdn.example.com\";", null]}`
+	sm := ParseSourceMap([]byte(data))
+	if sm == nil {
+		t.Fatal("Expected sourcemap to parse.")
+	}
+	if strings.Join(sm.Sources, " ") != "webpack:///src/api.ts webpack:///src/admin/panel.ts" {
+		t.Errorf("Unexpected sources: %v", sm.Sources)
+	}
+	if strings.Join(sm.Paths, " ") != "/api/v2/users /internal/debug" {
+		t.Errorf("Unexpected paths: %v", sm.Paths)
+	}
+	for _, bad := range []string{"not json", `{"version":3}`, `{"sources":["a.js"]}`} {
+		if ParseSourceMap([]byte(bad)) != nil {
+			t.Errorf("Expected no sourcemap for %s", bad)
+		}
+	}
+}
+
+func TestInlineSourceMap(t *testing.T) {
+	sm := InlineSourceMap("data:application/json;charset=utf-8;base64,eyJ2ZXJzaW9uIjogMywgInNvdXJjZXMiOiBbInNyYy9hLnRzIl19")
+	if sm == nil || len(sm.Sources) != 1 || sm.Sources[0] != "src/a.ts" {
+		t.Errorf("Expected inline sourcemap with src/a.ts, got %v", sm)
+	}
+	if InlineSourceMap("app.js.map") != nil {
+		t.Error("Expected nil for a non-data URL.")
+	}
+}
+
+func TestTryURL_SourceMap(t *testing.T) {
+	resp := mock.ResponseFromString("var a=1;\n//# sourceMappingURL=app.js.map\n")
+	resp.StatusCode = 200
+	resp.Header = http.Header{"Content-Type": []string{"application/javascript"}}
+	var added []*url.URL
+	rchan := make(chan results.Result, 1)
+	provenance := workqueue.NewProvenanceTracker()
+	w := &Worker{
+		client:     &mock.MockClient{NextResponse: resp},
+		settings:   &settings.ScanSettings{SourceMaps: true},
+		rchan:      rchan,
+		provenance: provenance,
+		adder: func(urls ...*url.URL) {
+			added = append(added, urls...)
+		},
+	}
+	w.TryURL(&url.URL{Scheme: "http", Host: "localhost", Path: "/static/app.js"})
+	<-rchan
+	if len(added) != 1 || added[0].String() != "http://localhost/static/app.js.map" {
+		t.Fatalf("Expected sourcemap to be queued, got %v", added)
+	}
+	if p := provenance.Lookup(added[0]); p.Source != workqueue.SourceSourceMap {
+		t.Errorf("Expected sourcemap provenance, got %s", p.Source)
+	}
+
+	resp = mock.ResponseFromString(`{"version":3,"sources":["src/app.ts"]}`)
+	resp.StatusCode = 200
+	w.client = &mock.MockClient{NextResponse: resp}
+	w.TryURL(added[0])
+	res := <-rchan
+	if len(res.SourceFiles) != 1 || res.SourceFiles[0] != "src/app.ts" {
+		t.Errorf("Expected source files to be reported, got %v", res.SourceFiles)
+	}
+}
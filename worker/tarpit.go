@@ -0,0 +1,60 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package worker
+
+import (
+	"errors"
+	"github.com/Matir/gobuster/logging"
+	"sync"
+)
+
+// Reported for URLs that aren't requested because their host is a tarpit.
+var ErrTarpitHost = errors.New("Skipped, host appears to be a tarpit.")
+
+// TarpitTracker counts tarpit responses per host and marks hosts that keep
+// producing them, so that workers stop waiting on them.
+type TarpitTracker struct {
+	// Tarpits before a host is marked
+	limit  int
+	counts map[string]int
+	sync.Mutex
+}
+
+func NewTarpitTracker(limit int) *TarpitTracker {
+	return &TarpitTracker{limit: limit, counts: make(map[string]int)}
+}
+
+// Note a tarpit response from host.
+func (t *TarpitTracker) Record(host string) {
+	if t == nil {
+		return
+	}
+	t.Lock()
+	defer t.Unlock()
+	t.counts[host]++
+	if t.counts[host] == t.limit {
+		logging.Logf(logging.LogWarning, "%s appears to be a tarpit, skipping further requests.", host)
+	}
+}
+
+// Whether host has been marked as a tarpit.
+func (t *TarpitTracker) Marked(host string) bool {
+	if t == nil {
+		return false
+	}
+	t.Lock()
+	defer t.Unlock()
+	return t.counts[host] >= t.limit
+}
@@ -0,0 +1,98 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package worker
+
+import (
+	"github.com/Matir/gobuster/client"
+	"github.com/Matir/gobuster/client/mock"
+	"github.com/Matir/gobuster/results"
+	"github.com/Matir/gobuster/settings"
+	"net/url"
+	"testing"
+)
+
+func TestTarpitTracker(t *testing.T) {
+	tracker := NewTarpitTracker(2)
+	tracker.Record("slow:80")
+	if tracker.Marked("slow:80") {
+		t.Error("Expected host not to be marked after one tarpit.")
+	}
+	tracker.Record("slow:80")
+	if !tracker.Marked("slow:80") {
+		t.Error("Expected host to be marked.")
+	}
+	if tracker.Marked("fast:80") {
+		t.Error("Expected other host not to be marked.")
+	}
+	var nilTracker *TarpitTracker
+	nilTracker.Record("slow:80")
+	if nilTracker.Marked("slow:80") {
+		t.Error("Expected nil tracker never to mark.")
+	}
+}
+
+func TestTryURL_TarpitHost(t *testing.T) {
+	tracker := NewTarpitTracker(1)
+	tracker.Record("localhost")
+	mc := &mock.MockClient{}
+	rchan := make(chan results.Result, 1)
+	w := &Worker{
+		client:   mc,
+		settings: &settings.ScanSettings{},
+		rchan:    rchan,
+		adder:    noopUrl,
+		tarpits:  tracker,
+	}
+	if w.TryURL(&url.URL{Scheme: "http", Host: "localhost", Path: "/"}) {
+		t.Error("Expected no further work for tarpit host.")
+	}
+	if len(mc.Requests) != 0 {
+		t.Errorf("Expected no requests to tarpit host, got %d", len(mc.Requests))
+	}
+	select {
+	case res := <-rchan:
+		if res.Error != ErrTarpitHost {
+			t.Errorf("Expected tarpit error result, got %v", res.Error)
+		}
+	default:
+		t.Error("Expected a result for the skipped URL.")
+	}
+}
+
+func TestTryURL_RecordsTarpit(t *testing.T) {
+	tracker := NewTarpitTracker(1)
+	w := &Worker{
+		client:   &mock.MockClient{},
+		settings: &settings.ScanSettings{},
+		rchan:    make(chan results.Result, 1),
+		adder:    noopUrl,
+		tarpits:  tracker,
+	}
+	task := &url.URL{Scheme: "http", Host: "localhost", Path: "/"}
+	w.noteTarpit(task, &url.Error{Op: "Get", URL: task.String(), Err: timeoutError{}})
+	if tracker.Marked("localhost") {
+		t.Error("Expected timeouts not to count as tarpits.")
+	}
+	w.noteTarpit(task, client.ErrTarpit)
+	if !tracker.Marked("localhost") {
+		t.Error("Expected host to be marked after tarpit error.")
+	}
+}
+
+type timeoutError struct{}
+
+func (timeoutError) Error() string   { return "timeout" }
+func (timeoutError) Timeout() bool   { return true }
+func (timeoutError) Temporary() bool { return true }
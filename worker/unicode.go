@@ -0,0 +1,133 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package worker
+
+import (
+	"github.com/Matir/gobuster/workqueue"
+	"net/url"
+	"strings"
+	"unicode/utf8"
+)
+
+// Precomposed (NFC) characters and their decomposed (NFD) equivalents.  This
+// only covers Latin-1, which is where lax servers are most commonly found.
+var nfdTable = map[rune]string{
+	'À': "A\u0300", 'Á': "A\u0301", 'Â': "A\u0302", 'Ã': "A\u0303", 'Ä': "A\u0308", 'Å': "A\u030a",
+	'Ç': "C\u0327", 'È': "E\u0300", 'É': "E\u0301", 'Ê': "E\u0302", 'Ë': "E\u0308",
+	'Ì': "I\u0300", 'Í': "I\u0301", 'Î': "I\u0302", 'Ï': "I\u0308", 'Ñ': "N\u0303",
+	'Ò': "O\u0300", 'Ó': "O\u0301", 'Ô': "O\u0302", 'Õ': "O\u0303", 'Ö': "O\u0308",
+	'Ù': "U\u0300", 'Ú': "U\u0301", 'Û': "U\u0302", 'Ü': "U\u0308", 'Ý': "Y\u0301",
+	'à': "a\u0300", 'á': "a\u0301", 'â': "a\u0302", 'ã': "a\u0303", 'ä': "a\u0308", 'å': "a\u030a",
+	'ç': "c\u0327", 'è': "e\u0300", 'é': "e\u0301", 'ê': "e\u0302", 'ë': "e\u0308",
+	'ì': "i\u0300", 'í': "i\u0301", 'î': "i\u0302", 'ï': "i\u0308", 'ñ': "n\u0303",
+	'ò': "o\u0300", 'ó': "o\u0301", 'ô': "o\u0302", 'õ': "o\u0303", 'ö': "o\u0308",
+	'ù': "u\u0300", 'ú': "u\u0301", 'û': "u\u0302", 'ü': "u\u0308", 'ý': "y\u0301", 'ÿ': "y\u0308",
+}
+
+var nfcTable map[string]rune
+
+func init() {
+	nfcTable = make(map[string]rune, len(nfdTable))
+	for composed, decomposed := range nfdTable {
+		nfcTable[decomposed] = composed
+	}
+}
+
+// Offset from printable ASCII to the Halfwidth and Fullwidth Forms block.
+const fullwidthOffset = 0xff01 - 0x21
+
+// Build Unicode variants of a basename that a server normalizing paths (NFC,
+// NFD or NFKC) may map back onto the original name.
+func UnicodeVariants(basename string) []string {
+	candidates := []string{
+		toNFD(basename),
+		toNFC(basename),
+		toFullwidth(basename, -1),
+		toFullwidth(basename, 1),
+	}
+	seen := map[string]bool{basename: true}
+	res := make([]string, 0, len(candidates))
+	for _, c := range candidates {
+		if !seen[c] {
+			seen[c] = true
+			res = append(res, c)
+		}
+	}
+	return res
+}
+
+func toNFD(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if d, ok := nfdTable[r]; ok {
+			b.WriteString(d)
+		} else {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+func toNFC(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); {
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if i+size < len(s) {
+			m, msize := utf8.DecodeRuneInString(s[i+size:])
+			if c, ok := nfcTable[string(r)+string(m)]; ok {
+				b.WriteRune(c)
+				i += size + msize
+				continue
+			}
+		}
+		b.WriteRune(r)
+		i += size
+	}
+	return b.String()
+}
+
+// Convert up to limit printable ASCII characters to their fullwidth form.  A
+// negative limit converts all of them.
+func toFullwidth(s string, limit int) string {
+	var b strings.Builder
+	for _, r := range s {
+		if limit != 0 && r > 0x20 && r < 0x7f && r != '/' {
+			b.WriteRune(r + fullwidthOffset)
+			limit--
+		} else {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// Try the Unicode variants of the last path component of a URL.
+func (w *Worker) TryUnicodeURL(task *url.URL) {
+	if !w.settings.UnicodeProbe {
+		return
+	}
+	spos := strings.LastIndex(strings.TrimRight(task.Path, "/"), "/")
+	if spos == -1 {
+		return
+	}
+	dirname := task.Path[:spos]
+	basename := task.Path[spos+1:]
+	for _, variant := range UnicodeVariants(basename) {
+		clone := *task
+		clone.RawPath = ""
+		clone.Path = dirname + "/" + variant
+		w.tryURL(&clone, workqueue.Provenance{Source: workqueue.SourceMangle, Parent: task})
+	}
+}
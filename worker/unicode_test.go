@@ -0,0 +1,77 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package worker
+
+import (
+	"github.com/Matir/gobuster/client/mock"
+	"github.com/Matir/gobuster/results"
+	"github.com/Matir/gobuster/settings"
+	"net/url"
+	"testing"
+)
+
+func TestUnicodeVariants_ASCII(t *testing.T) {
+	res := UnicodeVariants("admin")
+	if len(res) != 2 {
+		t.Fatalf("Expected 2 fullwidth variants, got %v", res)
+	}
+	if res[0] != "ａｄｍｉｎ" {
+		t.Errorf("Expected fullwidth variant, got %s", res[0])
+	}
+	if res[1] != "ａdmin" {
+		t.Errorf("Expected single fullwidth char variant, got %s", res[1])
+	}
+}
+
+func TestUnicodeVariants_Normalization(t *testing.T) {
+	nfc := "café"
+	nfd := "cafe\u0301"
+	if v := toNFD(nfc); v != nfd {
+		t.Errorf("Expected NFD %q, got %q", nfd, v)
+	}
+	if v := toNFC(nfd); v != nfc {
+		t.Errorf("Expected NFC %q, got %q", nfc, v)
+	}
+	found := false
+	for _, v := range UnicodeVariants(nfc) {
+		if v == nfd {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("Expected NFD variant of %q.", nfc)
+	}
+}
+
+func TestTryUnicodeURL(t *testing.T) {
+	resp := mock.ResponseFromString("")
+	resp.StatusCode = 404
+	client := &mock.MockClient{ForeverResponse: resp}
+	rchan := make(chan results.Result)
+	go func() {
+		for range rchan {
+		}
+	}()
+	w := &Worker{
+		client:   client,
+		settings: &settings.ScanSettings{UnicodeProbe: true},
+		rchan:    rchan,
+		adder:    noopUrl,
+	}
+	w.TryUnicodeURL(&url.URL{Scheme: "http", Host: "localhost", Path: "/foo/admin"})
+	if len(client.Requests) != 2 {
+		t.Errorf("Expected 2 requests, got %d", len(client.Requests))
+	}
+}
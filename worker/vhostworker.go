@@ -0,0 +1,370 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package worker
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/Matir/gobuster/client"
+	"github.com/Matir/gobuster/logging"
+	"github.com/Matir/gobuster/results"
+	ss "github.com/Matir/gobuster/settings"
+	"github.com/Matir/gobuster/workqueue"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Stands in for the requested hostname when comparing responses
+const vhostPlaceholder = "{host}"
+
+// Share of their words two bodies must have in common to be the same page
+const vhostSimilarity = 0.9
+
+// How much two bodies of the same page may differ in length and word count
+const vhostSizeSlack = 0.1
+
+// Most distinct words kept from a body for comparisons
+const vhostMaxTerms = 4096
+
+// VHostWorker brute-forces virtual hosts.  Each URL names a candidate host;
+// the worker requests its target under that name, and reports the names that
+// get a different response than both a name that can't exist and the
+// target's bare address.
+type VHostWorker struct {
+	// Channel of URLs whose hosts to try
+	src <-chan *url.URL
+	// Client to make requests with
+	client client.HostClient
+	// Responses to nonexistent names, shared between workers
+	baselines *VHostBaselines
+	// Domain names are made under, instead of each target's hostname
+	domain string
+	// Most of each response body to read
+	maxBody int64
+	// Function to mark work done
+	done workqueue.QueueDoneFunc
+	// Channel for scan results
+	rchan chan<- results.Result
+	// How URLs entered the queue
+	provenance *workqueue.ProvenanceTracker
+	// Completion of targets
+	dirs *workqueue.DirectoryTracker
+	// Progress for resuming
+	state *workqueue.StateTracker
+	// Base URLs, which are what actually get requested
+	targets []*url.URL
+	// Hooks of a program embedding the scanner, if any
+	hooks *results.Hooks
+	// Delay between requests
+	sleep time.Duration
+	// Channel to trigger stopping
+	stop chan bool
+}
+
+// What a response looks like with the requested hostname taken out.
+type vhostSignature struct {
+	code     int
+	length   int
+	words    int
+	location string
+	// Protocol the response came over, not compared
+	proto string
+	// Distinct words of the body
+	terms map[string]bool
+}
+
+// Whether two responses look like the same page: the same status and
+// redirect, and bodies of about the same size sharing nearly all their words.
+// Servers with noisy default pages vary a little from one response to the
+// next.
+func (s *vhostSignature) like(o *vhostSignature) bool {
+	if s.code != o.code || s.location != o.location {
+		return false
+	}
+	if !nearSize(s.length, o.length) || !nearSize(s.words, o.words) {
+		return false
+	}
+	return termSimilarity(s.terms, o.terms) >= vhostSimilarity
+}
+
+func nearSize(a, b int) bool {
+	if a < b {
+		a, b = b, a
+	}
+	return float64(a-b) <= vhostSizeSlack*float64(a)
+}
+
+// Jaccard similarity of two sets of words.
+func termSimilarity(a, b map[string]bool) float64 {
+	if len(a) == 0 && len(b) == 0 {
+		return 1
+	}
+	shared := 0
+	for term := range a {
+		if b[term] {
+			shared++
+		}
+	}
+	return float64(shared) / float64(len(a)+len(b)-shared)
+}
+
+func (w *VHostWorker) Run() {
+	for true {
+		select {
+		case <-w.stop:
+			return
+		case task, ok := <-w.src:
+			if !ok {
+				return
+			}
+			w.HandleURL(task)
+		}
+	}
+}
+
+func (w *VHostWorker) RunInBackground() {
+	go w.Run()
+}
+
+func (w *VHostWorker) Stop() {
+	w.stop <- true
+}
+
+func (w *VHostWorker) HandleURL(task *url.URL) {
+	target := w.targetOf(task.Hostname())
+	if target == nil {
+		logging.Logf(logging.LogWarning, "No target for virtual host %s.", task.Host)
+	} else if task.Host != target.Host {
+		logging.Logf(logging.LogInfo, "Trying virtual host: %s", task.Host)
+		w.try(task, target)
+		if w.sleep != 0 {
+			time.Sleep(w.sleep)
+		}
+	}
+	w.dirs.Done(task)
+	w.state.Done(task)
+	w.provenance.Done(task)
+	w.done(1)
+}
+
+func (w *VHostWorker) try(task, target *url.URL) {
+	if !w.hooks.Request(task) {
+		logging.Logf(logging.LogDebug, "Hook skipped %s.", task.String())
+		return
+	}
+	sig, length, err := w.fetch(target, task.Host, task)
+	if err != nil {
+		logging.Logf(logging.LogWarning, "Error requesting %s as %s: %s", target, task.Host, err.Error())
+		return
+	}
+	baselines := w.baselines.For(target, w.domainOf(target), func(host string) (*vhostSignature, error) {
+		sig, _, err := w.fetch(target, host, nil)
+		return sig, err
+	})
+	for _, baseline := range baselines {
+		if sig.like(baseline) {
+			logging.Logf(logging.LogDebug, "Virtual host %s looks like the default.", task.Host)
+			return
+		}
+	}
+	report(w.hooks, w.rchan, results.Result{
+		URL:      task,
+		Code:     sig.code,
+		Protocol: sig.proto,
+		Length:   length,
+		Source:   string(w.provenance.Lookup(task).Source),
+		Target:   target.String(),
+	})
+}
+
+// Request target under host, returning the response's signature and length.
+// The response is passed to hooks as one for task, unless task is nil as for
+// baselines.
+func (w *VHostWorker) fetch(target *url.URL, host string, task *url.URL) (*vhostSignature, int64, error) {
+	resp, err := w.client.RequestHost(target, host)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer resp.Body.Close()
+	if task != nil {
+		w.hooks.Response(task, resp)
+	}
+	body, err := ioutil.ReadAll(io.LimitReader(resp.Body, bodyLimit(w.maxBody)))
+	if err != nil {
+		return nil, 0, err
+	}
+	name := host
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		name = h
+	}
+	normalized := bytes.Replace(body, []byte(name), []byte(vhostPlaceholder), -1)
+	words := strings.Fields(string(normalized))
+	sig := &vhostSignature{
+		code:     resp.StatusCode,
+		length:   len(normalized),
+		words:    len(words),
+		location: strings.Replace(resp.Header.Get("Location"), name, vhostPlaceholder, -1),
+		proto:    resp.Proto,
+		terms:    make(map[string]bool),
+	}
+	for _, word := range words {
+		if len(sig.terms) >= vhostMaxTerms {
+			break
+		}
+		sig.terms[word] = true
+	}
+	return sig, int64(len(body)), nil
+}
+
+// The domain names for target are made under.
+func (w *VHostWorker) domainOf(target *url.URL) string {
+	if w.domain != "" {
+		return w.domain
+	}
+	return target.Hostname()
+}
+
+// The target whose domain host is in, if any.
+func (w *VHostWorker) targetOf(host string) *url.URL {
+	var target *url.URL
+	for _, t := range w.targets {
+		domain := w.domainOf(t)
+		if host != t.Hostname() && !strings.HasSuffix(host, "."+domain) {
+			continue
+		}
+		if target == nil || len(domain) > len(w.domainOf(target)) {
+			target = t
+		}
+	}
+	return target
+}
+
+// VHostBaselines holds what each target serves for names that don't pick a
+// virtual host: a name that doesn't exist, which is what a missing virtual
+// host looks like, and the target's address, which gets the default site.
+type VHostBaselines struct {
+	mu   sync.Mutex
+	sigs map[string][]*vhostSignature
+	// Resolves target hostnames for the address baseline
+	lookup func(string) ([]string, error)
+}
+
+func NewVHostBaselines() *VHostBaselines {
+	return &VHostBaselines{sigs: make(map[string][]*vhostSignature), lookup: net.LookupHost}
+}
+
+// The baselines for target, fetched once by requesting a random name under
+// domain and the target's address.  Empty if neither could be fetched, in
+// which case every name is reported.
+func (b *VHostBaselines) For(target *url.URL, domain string, fetch func(string) (*vhostSignature, error)) []*vhostSignature {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	key := target.String()
+	if sigs, ok := b.sigs[key]; ok {
+		return sigs
+	}
+	names := []string{fmt.Sprintf("%x.%s", rand.Int63(), domain)}
+	if addr := b.address(target.Hostname()); addr != "" {
+		names = append(names, addr)
+	}
+	var sigs []*vhostSignature
+	for _, name := range names {
+		host := name
+		if port := target.Port(); port != "" {
+			host = net.JoinHostPort(name, port)
+		} else if strings.Contains(name, ":") {
+			host = "[" + name + "]"
+		}
+		sig, err := fetch(host)
+		if err != nil {
+			logging.Logf(logging.LogWarning, "Unable to request baseline %s for %s: %s", host, key, err.Error())
+			continue
+		}
+		sigs = append(sigs, sig)
+	}
+	if len(sigs) == 0 {
+		logging.Logf(logging.LogWarning, "No baselines for %s, reporting every virtual host.", key)
+	}
+	b.sigs[key] = sigs
+	return sigs
+}
+
+// An address of host, or "" if it can't be resolved.
+func (b *VHostBaselines) address(host string) string {
+	if net.ParseIP(host) != nil {
+		return host
+	}
+	addrs, err := b.lookup(host)
+	if err != nil || len(addrs) == 0 {
+		logging.Logf(logging.LogInfo, "Unable to resolve %s, comparing virtual hosts to a missing name only.", host)
+		return ""
+	}
+	return addrs[0]
+}
+
+// Starts a batch of virtual host workers based on the relevant settings.
+func StartVHostWorkers(settings *ss.ScanSettings,
+	factory client.ClientFactory,
+	src <-chan *url.URL,
+	provenance *workqueue.ProvenanceTracker,
+	dirs *workqueue.DirectoryTracker,
+	state *workqueue.StateTracker,
+	hooks *results.Hooks,
+	done workqueue.QueueDoneFunc,
+	rchan chan<- results.Result) []*VHostWorker {
+	baselines := NewVHostBaselines()
+	targets, err := settings.GetScopes()
+	if err != nil {
+		logging.Logf(logging.LogError, "Unable to label results by target: %s", err.Error())
+	}
+	var workers []*VHostWorker
+	for i := 0; i < settings.Workers; i++ {
+		c, ok := factory.Get().(client.HostClient)
+		if !ok {
+			logging.Logf(logging.LogError, "Client can't set the Host header, not starting virtual host workers.")
+			return workers
+		}
+		// Redirects are compared, not followed
+		c.SetCheckRedirect(func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		})
+		w := &VHostWorker{
+			src:        src,
+			client:     c,
+			baselines:  baselines,
+			domain:     settings.VHostDomain,
+			maxBody:    settings.MaxBodyRead,
+			done:       done,
+			rchan:      rchan,
+			provenance: provenance,
+			dirs:       dirs,
+			state:      state,
+			targets:    targets,
+			hooks:      hooks,
+			sleep:      settings.SleepTime,
+			stop:       make(chan bool),
+		}
+		w.RunInBackground()
+		workers = append(workers, w)
+	}
+	return workers
+}
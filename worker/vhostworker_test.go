@@ -0,0 +1,150 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package worker
+
+import (
+	"fmt"
+	"github.com/Matir/gobuster/client"
+	"github.com/Matir/gobuster/results"
+	"math/rand"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestVHostWorker_HandleURL(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host, _, _ := net.SplitHostPort(r.Host)
+		switch host {
+		case "admin.example.com":
+			w.Write([]byte("Admin panel"))
+		case "old.example.com":
+			http.Redirect(w, r, "http://new.example.com/", http.StatusFound)
+		case "127.0.0.1", "www.example.com":
+			w.Write([]byte("It works! This is the default web page for this server."))
+		default:
+			// Noisy, so never quite the same twice
+			fmt.Fprintf(w, "Welcome to %s, which is not configured on this server yet. "+
+				"Check the name of the site you asked for, or ask its administrator to "+
+				"finish setting it up.  Request %x", host, rand.Int63())
+		}
+	}))
+	defer server.Close()
+	target, _ := url.Parse(server.URL + "/")
+	factory, err := client.NewProxyClientFactory(nil, time.Second, "test")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	c := factory.Get().(client.HostClient)
+	c.SetCheckRedirect(func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	})
+	rchan := make(chan results.Result, 10)
+	done := 0
+	w := &VHostWorker{
+		client:    c,
+		baselines: NewVHostBaselines(),
+		domain:    "example.com",
+		done:      func(n int) { done += n },
+		rchan:     rchan,
+		targets:   []*url.URL{target},
+	}
+	w.HandleURL(target)
+	for _, sub := range []string{"www", "admin", "old", "mail"} {
+		w.HandleURL(&url.URL{Scheme: "http", Host: sub + ".example.com:" + target.Port(), Path: "/"})
+	}
+	close(rchan)
+	var found []string
+	for r := range rchan {
+		found = append(found, fmt.Sprintf("%s=%d@%s", r.URL.Hostname(), r.Code, r.Target))
+	}
+	expected := fmt.Sprintf("admin.example.com=200@%s old.example.com=302@%s", target, target)
+	if got := strings.Join(found, " "); got != expected {
+		t.Errorf("Expected %s, got %s", expected, got)
+	}
+	if done != 5 {
+		t.Errorf("Expected 5 done, got %d", done)
+	}
+}
+
+func TestVHostBaselines_For(t *testing.T) {
+	b := NewVHostBaselines()
+	target := &url.URL{Scheme: "http", Host: "10.0.0.1:8080", Path: "/"}
+	var hosts []string
+	fetch := func(host string) (*vhostSignature, error) {
+		hosts = append(hosts, host)
+		return &vhostSignature{code: 200}, nil
+	}
+	b.For(target, "example.com", fetch)
+	if sigs := b.For(target, "example.com", fetch); len(sigs) != 2 || sigs[0].code != 200 {
+		t.Errorf("Expected two baselines with code 200, got %v", sigs)
+	}
+	if len(hosts) != 2 || !strings.HasSuffix(hosts[0], ".example.com:8080") || hosts[1] != "10.0.0.1:8080" {
+		t.Errorf("Expected requests under example.com:8080 and to 10.0.0.1:8080, got %v", hosts)
+	}
+	other := &url.URL{Scheme: "http", Host: "10.0.0.2", Path: "/"}
+	if sigs := b.For(other, "example.com", func(string) (*vhostSignature, error) {
+		return nil, fmt.Errorf("Connection refused.")
+	}); len(sigs) != 0 {
+		t.Errorf("Expected no baselines when they can't be fetched, got %v", sigs)
+	}
+	hosts = nil
+	b.lookup = func(host string) ([]string, error) {
+		if host == "www.example.com" {
+			return []string{"2001:db8::1"}, nil
+		}
+		return nil, fmt.Errorf("No such host.")
+	}
+	b.For(&url.URL{Scheme: "https", Host: "www.example.com", Path: "/"}, "example.com", fetch)
+	if len(hosts) != 2 || hosts[1] != "[2001:db8::1]" {
+		t.Errorf("Expected a request to [2001:db8::1], got %v", hosts)
+	}
+	hosts = nil
+	b.For(&url.URL{Scheme: "https", Host: "missing.example.com", Path: "/"}, "example.com", fetch)
+	if len(hosts) != 1 {
+		t.Errorf("Expected only the missing name without an address, got %v", hosts)
+	}
+}
+
+func TestVHostSignature_Like(t *testing.T) {
+	terms := func(words ...string) map[string]bool {
+		m := make(map[string]bool)
+		for _, w := range words {
+			m[w] = true
+		}
+		return m
+	}
+	common := strings.Fields("a b c d e f g h i j k l m n o p q r s t")
+	base := &vhostSignature{code: 200, length: 1000, words: 20, terms: terms(common...)}
+	for _, c := range []struct {
+		sig  *vhostSignature
+		like bool
+	}{
+		{&vhostSignature{code: 200, length: 1000, words: 20, terms: terms(common...)}, true},
+		{&vhostSignature{code: 200, length: 1040, words: 21, terms: terms(append(common, "x")...)}, true},
+		{&vhostSignature{code: 302, length: 1000, words: 20, terms: terms(common...)}, false},
+		{&vhostSignature{code: 200, length: 1000, words: 20, location: "/login", terms: terms(common...)}, false},
+		{&vhostSignature{code: 200, length: 2000, words: 20, terms: terms(common...)}, false},
+		{&vhostSignature{code: 200, length: 1000, words: 20, terms: terms(common[:10]...)}, false},
+	} {
+		if got := c.sig.like(base); got != c.like {
+			t.Errorf("Expected %v for %+v, got %v", c.like, c.sig, got)
+		}
+	}
+}
@@ -0,0 +1,200 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package worker
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// Fields of security.txt (RFC 9116) to report.
+var securityTxtFields = map[string]bool{
+	"contact":             true,
+	"expires":             true,
+	"encryption":          true,
+	"acknowledgments":     true,
+	"preferred-languages": true,
+	"canonical":           true,
+	"policy":              true,
+	"hiring":              true,
+}
+
+// Check if a URL is one of the well-known resources we parse.
+func IsWellKnown(u *url.URL) bool {
+	return wellKnownName(u) != ""
+}
+
+func wellKnownName(u *url.URL) string {
+	p := strings.TrimPrefix(strings.ToLower(u.Path), "/.well-known")
+	switch p {
+	case "/security.txt", "/openid-configuration", "/oauth-authorization-server",
+		"/apple-app-site-association", "/assetlinks.json":
+		return p[1:]
+	}
+	return ""
+}
+
+// Parse a well-known resource into findings and links to further
+// endpoints.  Unknown or malformed bodies give neither.
+func ParseWellKnown(u *url.URL, body []byte) (map[string]string, []string) {
+	switch wellKnownName(u) {
+	case "security.txt":
+		return parseSecurityTxt(body)
+	case "openid-configuration", "oauth-authorization-server":
+		return parseOAuthMetadata(body)
+	case "apple-app-site-association":
+		return parseAppleAssociation(body)
+	case "assetlinks.json":
+		return parseAssetLinks(body)
+	}
+	return nil, nil
+}
+
+func parseSecurityTxt(body []byte) (map[string]string, []string) {
+	fields := make(map[string][]string)
+	var links []string
+	scanner := bufio.NewScanner(bytes.NewReader(body))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		pieces := strings.SplitN(line, ":", 2)
+		if len(pieces) != 2 {
+			continue
+		}
+		name := strings.ToLower(strings.TrimSpace(pieces[0]))
+		if !securityTxtFields[name] {
+			continue
+		}
+		value := strings.TrimSpace(pieces[1])
+		fields[name] = append(fields[name], value)
+		if strings.HasPrefix(value, "http://") || strings.HasPrefix(value, "https://") {
+			links = append(links, value)
+		}
+	}
+	return joinFields(fields), links
+}
+
+func parseOAuthMetadata(body []byte) (map[string]string, []string) {
+	var doc map[string]interface{}
+	if err := json.Unmarshal(body, &doc); err != nil {
+		return nil, nil
+	}
+	meta := make(map[string]string)
+	var links []string
+	for key, value := range doc {
+		s, ok := value.(string)
+		if !ok {
+			continue
+		}
+		if key == "issuer" || strings.HasSuffix(key, "_endpoint") || strings.HasSuffix(key, "_uri") {
+			meta[key] = s
+			if key != "issuer" {
+				links = append(links, s)
+			}
+		}
+	}
+	sort.Strings(links)
+	return meta, links
+}
+
+func parseAppleAssociation(body []byte) (map[string]string, []string) {
+	var doc struct {
+		Applinks struct {
+			Details []struct {
+				AppID      string
+				AppIDs     []string
+				Paths      []string
+				Components []struct {
+					Path string `json:"/"`
+				}
+			}
+		}
+		Webcredentials struct {
+			Apps []string
+		}
+	}
+	if err := json.Unmarshal(body, &doc); err != nil {
+		return nil, nil
+	}
+	fields := make(map[string][]string)
+	var links []string
+	addPath := func(p string) {
+		// Wildcards and exclusions aren't fetchable
+		p = strings.TrimSpace(p)
+		if strings.HasPrefix(p, "/") && !strings.ContainsAny(p, "*?") {
+			links = append(links, p)
+		}
+	}
+	for _, detail := range doc.Applinks.Details {
+		if detail.AppID != "" {
+			fields["app_id"] = append(fields["app_id"], detail.AppID)
+		}
+		fields["app_id"] = append(fields["app_id"], detail.AppIDs...)
+		for _, p := range detail.Paths {
+			addPath(p)
+		}
+		for _, c := range detail.Components {
+			addPath(c.Path)
+		}
+	}
+	if len(doc.Webcredentials.Apps) > 0 {
+		fields["webcredentials"] = doc.Webcredentials.Apps
+	}
+	return joinFields(fields), links
+}
+
+func parseAssetLinks(body []byte) (map[string]string, []string) {
+	var doc []struct {
+		Target struct {
+			Namespace   string
+			PackageName string `json:"package_name"`
+			Site        string
+		}
+	}
+	if err := json.Unmarshal(body, &doc); err != nil {
+		return nil, nil
+	}
+	fields := make(map[string][]string)
+	var links []string
+	for _, statement := range doc {
+		if statement.Target.PackageName != "" {
+			fields["package"] = append(fields["package"], statement.Target.PackageName)
+		}
+		if statement.Target.Site != "" {
+			fields["site"] = append(fields["site"], statement.Target.Site)
+			links = append(links, statement.Target.Site)
+		}
+	}
+	return joinFields(fields), links
+}
+
+func joinFields(fields map[string][]string) map[string]string {
+	if len(fields) == 0 {
+		return nil
+	}
+	meta := make(map[string]string)
+	for name, values := range fields {
+		if len(values) > 0 {
+			meta[name] = strings.Join(values, ", ")
+		}
+	}
+	return meta
+}
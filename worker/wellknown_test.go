@@ -0,0 +1,111 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package worker
+
+import (
+	"github.com/Matir/gobuster/client/mock"
+	"github.com/Matir/gobuster/results"
+	"github.com/Matir/gobuster/settings"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestIsWellKnown(t *testing.T) {
+	for p, expected := range map[string]bool{
+		"/.well-known/security.txt":         true,
+		"/security.txt":                     true,
+		"/.well-known/openid-configuration": true,
+		"/.well-known/assetlinks.json":      true,
+		"/.well-known/change-password":      false,
+		"/index.html":                       false,
+	} {
+		if res := IsWellKnown(&url.URL{Path: p}); res != expected {
+			t.Errorf("Expected %v for %s, got %v", expected, p, res)
+		}
+	}
+}
+
+func TestParseWellKnown(t *testing.T) {
+	cases := []struct {
+		path  string
+		body  string
+		meta  map[string]string
+		links string
+	}{
+		{
+			"/.well-known/security.txt",
+			"# Comment\nContact: mailto:security@example.com\nContact: https://example.com/report\nPolicy: https://example.com/policy\nBogus: x\n",
+			map[string]string{"contact": "mailto:security@example.com, https://example.com/report", "policy": "https://example.com/policy"},
+			"https://example.com/report https://example.com/policy",
+		},
+		{
+			"/.well-known/openid-configuration",
+			`{"issuer":"https://id.example.com","authorization_endpoint":"https://id.example.com/auth","jwks_uri":"https://id.example.com/keys","scopes_supported":["openid"]}`,
+			map[string]string{"issuer": "https://id.example.com", "authorization_endpoint": "https://id.example.com/auth", "jwks_uri": "https://id.example.com/keys"},
+			"https://id.example.com/auth https://id.example.com/keys",
+		},
+		{
+			"/.well-known/apple-app-site-association",
+			`{"applinks":{"details":[{"appID":"ABC.com.example","paths":["/account/*","/help","NOT /x"],"components":[{"/":"/deep/link"}]}]}}`,
+			map[string]string{"app_id": "ABC.com.example"},
+			"/help /deep/link",
+		},
+		{
+			"/.well-known/assetlinks.json",
+			`[{"relation":["delegate_permission/common.handle_all_urls"],"target":{"namespace":"android_app","package_name":"com.example.app"}}]`,
+			map[string]string{"package": "com.example.app"},
+			"",
+		},
+		{"/.well-known/openid-configuration", "not json", nil, ""},
+	}
+	for _, c := range cases {
+		meta, links := ParseWellKnown(&url.URL{Path: c.path}, []byte(c.body))
+		if len(meta) != len(c.meta) {
+			t.Errorf("Expected %v for %s, got %v", c.meta, c.path, meta)
+		}
+		for k, v := range c.meta {
+			if meta[k] != v {
+				t.Errorf("Expected %s=%s for %s, got %s", k, v, c.path, meta[k])
+			}
+		}
+		if res := strings.Join(links, " "); res != c.links {
+			t.Errorf("Expected links %q for %s, got %q", c.links, c.path, res)
+		}
+	}
+}
+
+func TestTryURL_WellKnown(t *testing.T) {
+	resp := mock.ResponseFromString("Contact: mailto:a@localhost\nPolicy: /policy\n")
+	resp.StatusCode = 200
+	var added []*url.URL
+	rchan := make(chan results.Result, 1)
+	w := &Worker{
+		client:   &mock.MockClient{NextResponse: resp},
+		settings: &settings.ScanSettings{WellKnown: true},
+		rchan:    rchan,
+		adder: func(urls ...*url.URL) {
+			added = append(added, urls...)
+		},
+	}
+	w.TryURL(&url.URL{Scheme: "http", Host: "localhost", Path: "/.well-known/security.txt"})
+	res := <-rchan
+	if res.Metadata["contact"] != "mailto:a@localhost" {
+		t.Errorf("Expected contact in metadata, got %v", res.Metadata)
+	}
+	if len(added) != 0 {
+		t.Errorf("Expected only absolute URLs to be queued, got %v", added)
+	}
+}
@@ -17,14 +17,20 @@
 package worker
 
 import (
+	"bufio"
+	"bytes"
 	"fmt"
 	"github.com/Matir/gobuster/client"
 	"github.com/Matir/gobuster/logging"
+	"github.com/Matir/gobuster/redact"
 	"github.com/Matir/gobuster/results"
 	ss "github.com/Matir/gobuster/settings"
+	"github.com/Matir/gobuster/storage"
 	"github.com/Matir/gobuster/util"
 	"github.com/Matir/gobuster/workqueue"
 	"io"
+	"io/ioutil"
+	"mime"
 	"net/http"
 	"net/url"
 	"strings"
@@ -36,8 +42,8 @@ type Stoppable interface {
 }
 
 type PageWorker interface {
-	Eligible(*http.Response) bool
-	Handle(*url.URL, io.Reader)
+	Eligible(contentType string, length int64) bool
+	Handle(*url.URL, io.Reader, http.Header)
 }
 
 // Workers do the work of connecting to the server, issuing the request, and
@@ -62,8 +68,62 @@ type Worker struct {
 	stop chan bool
 	// Request for redirection
 	redir *http.Request
+	// Detector for soft 404 pages
+	notFound *NotFoundDetector
+	// Detector for responses unlike their neighbours
+	anomalies *AnomalyDetector
+	// Client without credentials, in differential scans
+	anon client.Client
+	// How long the last request took
+	elapsed time.Duration
+	// Store for response bodies
+	store *storage.BodyStore
+	// Limits concurrency by latency
+	pacer *LatencyPacer
+	// Patterns for secrets in bodies
+	secretRules []SecretRule
+	// How URLs entered the queue
+	provenance *workqueue.ProvenanceTracker
+	// Hosts that trickle responses
+	tarpits *TarpitTracker
+	// Which extensions find things on each host
+	extensions *ExtensionStats
+	// Completion of directories
+	dirs *workqueue.DirectoryTracker
+	// Progress for resuming
+	state *workqueue.StateTracker
+	// Which directories exist, to release held expansions
+	existence *workqueue.DirExistence
+	// Identifies the worker in events
+	id int
+	// Stream of requests and results, if any
+	events *results.EventStream
+	// Hooks of a program embedding the scanner, if any
+	hooks *results.Hooks
+	// Base URLs, to say which target results belong to
+	targets []*url.URL
 }
 
+// Largest amount of a body that will be read, unless set otherwise
+const defaultMaxBodyRead = 2 * 1024 * 1024
+
+// Largest amount of a body to read, given the configured limit.
+func bodyLimit(limit int64) int64 {
+	if limit > 0 {
+		return limit
+	}
+	return defaultMaxBodyRead
+}
+
+// Whether a body has more to read.
+func bodyContinues(body io.Reader) bool {
+	n, _ := io.ReadFull(body, make([]byte, 1))
+	return n > 0
+}
+
+// Smallest compressed body to check the compression ratio of
+const minRatioBytes = 128
+
 // Construct a worker with given settings.
 func NewWorker(settings *ss.ScanSettings,
 	factory client.ClientFactory,
@@ -119,22 +179,31 @@ func (w *Worker) Stop() {
 
 func (w *Worker) HandleURL(task *url.URL) {
 	logging.Logf(logging.LogDebug, "Trying Raw URL (unmangled): %s", task.String())
-	withMangle := w.TryURL(task)
+	withMangle := w.tryURL(task, w.provenance.Lookup(task))
+	if withMangle {
+		w.TryUnicodeURL(task)
+	}
 	if !util.URLIsDir(task) {
 		if withMangle {
 			w.TryMangleURL(task)
 		}
 		if !util.URLHasExtension(task) {
-			for _, ext := range w.settings.Extensions {
+			base := task
+			for _, ext := range w.extensions.Order(task.Host, w.settings.Extensions) {
 				task := *task
 				task.Path += "." + ext
-				if w.TryURL(&task) {
+				hit := w.tryURL(&task, workqueue.Provenance{Source: workqueue.SourceExtension, Parent: base})
+				w.extensions.Record(task.Host, ext, hit)
+				if hit {
 					w.TryMangleURL(&task)
 				}
 			}
 		}
 	}
 	// Mark as done
+	w.dirs.Done(task)
+	w.state.Done(task)
+	w.provenance.Done(task)
 	w.done(1)
 }
 
@@ -152,44 +221,237 @@ func (w *Worker) TryMangleURL(task *url.URL) {
 	for _, newname := range Mangle(basename) {
 		clone := clone
 		clone.Path = dirname + "/" + newname
-		w.TryURL(&clone)
+		w.tryURL(&clone, workqueue.Provenance{Source: workqueue.SourceMangle, Parent: task})
 	}
 }
 
 func (w *Worker) TryURL(task *url.URL) bool {
+	return w.tryURL(task, w.provenance.Lookup(task))
+}
+
+func (w *Worker) tryURL(task *url.URL, prov workqueue.Provenance) bool {
+	if w.tarpits.Marked(task.Host) {
+		w.emit(results.Result{URL: task, Error: ErrTarpitHost, Source: string(prov.Source), Parent: prov.Parent})
+		return false
+	}
+	if !w.hooks.Request(task) {
+		logging.Logf(logging.LogDebug, "Hook skipped %s.", task.String())
+		return false
+	}
 	logging.Logf(logging.LogInfo, "Trying: %s", task.String())
 	tryMangle := false
 	w.redir = nil
-	if resp, err := w.client.RequestURL(task); err != nil && w.redir == nil {
-		result := results.Result{URL: task, Error: err}
+	if resp, err := w.request(task); err != nil && w.redir == nil {
+		result := results.Result{URL: task, Error: err, Source: string(prov.Source), Parent: prov.Parent}
 		if resp != nil {
 			result.Code = resp.StatusCode
+			result.Protocol = resp.Proto
+		}
+		w.emit(result)
+	} else if w.redir == nil && w.notFound != nil && w.notFound.IsNotFound(task, resp) {
+		resp.Body.Close()
+		w.anomalies.Observe(task, resp.StatusCode, resp.ContentLength, w.elapsed)
+		logging.Logf(logging.LogDebug, "Dropping soft 404 for %s.", task.String())
+		if util.URLIsDir(task) {
+			w.existence.Record(task, false)
 		}
-		w.rchan <- result
 	} else {
 		defer resp.Body.Close()
+		// Only look at the ends of very large bodies
+		limit := bodyLimit(w.settings.MaxBodyRead)
+		sampled := w.settings.SampleSize > 0 && w.settings.SampleThreshold > 0 &&
+			resp.ContentLength > w.settings.SampleThreshold && w.redir == nil
+		if sampled {
+			limit = w.settings.SampleSize
+		}
+		// Responses to HEAD have headers only
+		headOnly := w.settings.Method == http.MethodHead
+		reader := bufio.NewReaderSize(io.LimitReader(resp.Body, limit), sniffLen)
+		declared := resp.Header.Get("Content-Type")
+		var sniffed string
+		var mismatch bool
+		if w.settings.SniffTypes && w.redir == nil {
+			if head, _ := reader.Peek(sniffLen); len(head) > 0 {
+				sniffed = SniffContentType(head)
+				mismatch = TypeMismatch(declared, sniffed)
+			}
+			if mismatch {
+				logging.Logf(logging.LogInfo, "Content type mismatch for %s: declared %s, sniffed %s", task.String(), declared, sniffed)
+			}
+		}
+		// What the body really is decides what is spidered and parsed
+		contentType := declared
+		if mismatch {
+			contentType = sniffed
+		}
 		// Do we keep going?
-		if util.URLIsDir(task) && w.KeepSpidering(resp.StatusCode) {
-			logging.Logf(logging.LogDebug, "Referring %s back for spidering.", task.String())
-			w.adder(task)
+		if util.URLIsDir(task) {
+			missing := resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusGone
+			released := w.existence.Record(task, !missing)
+			if released || w.KeepSpidering(resp.StatusCode) && w.SpiderContentType(contentType) {
+				logging.Logf(logging.LogDebug, "Referring %s back for spidering.", task.String())
+				w.adder(task)
+			}
 		}
 		if w.redir != nil {
 			logging.Logf(logging.LogDebug, "Referring redirect %s back.", w.redir.URL.String())
+			w.provenance.Record(workqueue.Provenance{Source: workqueue.SourceRedirect, Parent: task}, w.redir.URL)
 			w.adder(w.redir.URL)
 		}
-		if w.pageWorker != nil && w.pageWorker.Eligible(resp) {
-			w.pageWorker.Handle(task, resp.Body)
+		if w.settings.HeaderLinks && w.redir == nil {
+			if links := HeaderLinks(task, resp.Header); len(links) > 0 {
+				logging.Logf(logging.LogDebug, "Found %d links in headers of %s.", len(links), task.String())
+				w.provenance.Record(workqueue.Provenance{Source: workqueue.SourceHeader, Parent: task}, links...)
+				w.adder(links...)
+			}
+		}
+		stats := NewBodyStats()
+		sinks := []io.Writer{stats}
+		var pending *storage.PendingBody
+		if w.store != nil && w.redir == nil && !headOnly {
+			var err error
+			if pending, err = w.store.NewPending(); err == nil {
+				sinks = append(sinks, pending)
+			} else if err != storage.ErrStoreFull {
+				logging.Logf(logging.LogWarning, "Unable to save body for %s: %s", task.String(), err.Error())
+			}
+		}
+		var secrets *SecretScanner
+		if len(w.secretRules) > 0 && w.redir == nil {
+			secrets = NewSecretScanner(w.secretRules)
+			sinks = append(sinks, secrets)
+		}
+		var document *bytes.Buffer
+		docKind := docNone
+		if w.settings.ExtractMetadata && w.redir == nil {
+			if docKind = DocumentKind(task, contentType); docKind != docNone {
+				document = &bytes.Buffer{}
+				sinks = append(sinks, document)
+			}
+		}
+		var script *bytes.Buffer
+		mapKind := mapNone
+		if w.settings.SourceMaps && w.redir == nil {
+			if mapKind = SourceMapKind(task, contentType); mapKind != mapNone {
+				script = &bytes.Buffer{}
+				sinks = append(sinks, script)
+			}
+		}
+		var wellKnown *bytes.Buffer
+		if w.settings.WellKnown && w.redir == nil && IsWellKnown(task) {
+			wellKnown = &bytes.Buffer{}
+			sinks = append(sinks, wellKnown)
+		}
+		body := io.TeeReader(reader, io.MultiWriter(sinks...))
+		if w.pageWorker != nil && w.pageWorker.Eligible(contentType, resp.ContentLength) {
+			w.pageWorker.Handle(task, body, resp.Header)
+		}
+		// Finish reading to complete the stats
+		_, readErr := io.Copy(ioutil.Discard, body)
+		w.noteTarpit(task, readErr)
+		// Whatever is past the limit was never read
+		truncated := !sampled && readErr == nil && stats.Length >= limit && bodyContinues(resp.Body)
+		// The tail is kept apart from the head, as the two aren't one body
+		var tail []byte
+		if sampled && (secrets != nil || document != nil || script != nil) {
+			tail = w.sampleTail(task)
+			if secrets != nil && tail != nil {
+				secrets.Skip()
+				secrets.Write(tail)
+			}
+		}
+		var compressed int64
+		var anomaly bool
+		if cs, ok := resp.Body.(client.CompressionStats); ok {
+			compressed = cs.CompressedBytes()
+			anomaly = readErr == client.ErrDecompressionBudget || CompressionAnomaly(cs, w.settings.MaxCompressionRatio)
+			if anomaly {
+				logging.Logf(logging.LogWarning, "Compression anomaly for %s: %d bytes decompressed to %d.", task.String(), compressed, cs.DecompressedBytes())
+			}
+		}
+		bodyHash := stats.Sum()
+		if headOnly {
+			// Every empty body would look the same
+			bodyHash = ""
+		}
+		if sampled || truncated {
+			// Neither the hash nor a saved copy would be of the whole body
+			bodyHash = ""
+			if pending != nil {
+				w.store.Abort(pending)
+				pending = nil
+			}
+		}
+		if pending != nil {
+			if err := w.store.Commit(pending, task, bodyHash); err != nil {
+				logging.Logf(logging.LogWarning, "Unable to save body for %s: %s", task.String(), err.Error())
+			}
+		}
+		length := resp.ContentLength
+		if length < 0 && !headOnly {
+			length = stats.Length
 		}
 		var redir *url.URL
 		if w.redir != nil {
 			redir = w.redir.URL
 		}
-		w.rchan <- results.Result{
-			URL:    task,
-			Code:   resp.StatusCode,
-			Redir:  redir,
-			Length: resp.ContentLength,
+		var foundSecrets []string
+		if secrets != nil {
+			foundSecrets = secrets.Matches()
+		}
+		var metadata map[string]string
+		if document != nil && sampled {
+			metadata = ExtractSampledMetadata(docKind, document.Bytes(), tail, resp.ContentLength)
+		} else if document != nil {
+			metadata = ExtractMetadata(docKind, document.Bytes())
+		}
+		if wellKnown != nil && resp.StatusCode == http.StatusOK {
+			metadata = w.handleWellKnown(task, wellKnown.Bytes(), metadata)
+		}
+		var sourceMap *SourceMap
+		if script != nil && sampled {
+			// A script names its map at the end, part of a map is no use
+			if mapKind != mapJSON && tail != nil {
+				sourceMap = w.handleSourceMap(task, mapKind, tail)
+			}
+		} else if script != nil {
+			sourceMap = w.handleSourceMap(task, mapKind, script.Bytes())
+		}
+		result := results.Result{
+			URL:                task,
+			Code:               resp.StatusCode,
+			Protocol:           resp.Proto,
+			Redir:              redir,
+			Length:             length,
+			ETag:               resp.Header.Get("ETag"),
+			LastModified:       resp.Header.Get("Last-Modified"),
+			BodyHash:           bodyHash,
+			Words:              stats.Words,
+			Lines:              stats.LineCount(),
+			ContentType:        declared,
+			SniffedType:        sniffed,
+			TypeMismatch:       mismatch,
+			Secrets:            foundSecrets,
+			Metadata:           metadata,
+			Source:             string(prov.Source),
+			Parent:             prov.Parent,
+			CompressedLength:   compressed,
+			CompressionAnomaly: anomaly,
+			Sampled:            sampled,
+			Truncated:          truncated,
 		}
+		if sourceMap != nil {
+			result.SourceFiles = sourceMap.Sources
+			result.SourcePaths = sourceMap.Paths
+		}
+		if anomaly := w.anomalies.Observe(task, resp.StatusCode, length, w.elapsed); anomaly != "" {
+			logging.Logf(logging.LogInfo, "%s stands out from its directory: %s", task.String(), anomaly)
+			result.Anomaly = anomaly
+		}
+		if w.anon != nil {
+			result.AnonCode, result.AnonLength = w.requestAnonymous(task)
+		}
+		w.emit(result)
 		tryMangle = w.KeepSpidering(resp.StatusCode)
 	}
 	if w.settings.SleepTime != 0 {
@@ -198,6 +460,209 @@ func (w *Worker) TryURL(task *url.URL) bool {
 	return tryMangle
 }
 
+// Check whether a body decompressed at more than maxRatio.  Tiny bodies are
+// ignored since their ratios are meaningless.
+func CompressionAnomaly(cs client.CompressionStats, maxRatio float64) bool {
+	compressed := cs.CompressedBytes()
+	if maxRatio <= 0 || compressed < minRatioBytes {
+		return false
+	}
+	return float64(cs.DecompressedBytes())/float64(compressed) > maxRatio
+}
+
+// Parse a well-known resource, queueing the endpoints it refers to and
+// adding its findings to metadata.
+func (w *Worker) handleWellKnown(task *url.URL, body []byte, metadata map[string]string) map[string]string {
+	found, refs := ParseWellKnown(task, body)
+	var links []*url.URL
+	for _, ref := range refs {
+		if u := resolveLink(task, ref); u != nil {
+			links = append(links, u)
+		}
+	}
+	if len(links) > 0 {
+		logging.Logf(logging.LogDebug, "Found %d endpoints in %s.", len(links), task.String())
+		w.provenance.Record(workqueue.Provenance{Source: workqueue.SourceWellKnown, Parent: task}, links...)
+		w.adder(links...)
+	}
+	if len(found) > 0 && metadata == nil {
+		metadata = make(map[string]string)
+	}
+	for k, v := range found {
+		metadata[k] = v
+	}
+	return metadata
+}
+
+// Queue the sourcemap a script refers to, or parse a sourcemap (or one
+// inlined in a script) for findings.
+func (w *Worker) handleSourceMap(task *url.URL, kind string, body []byte) *SourceMap {
+	if kind == mapJSON {
+		return ParseSourceMap(body)
+	}
+	ref := SourceMappingURL(body)
+	if ref == "" {
+		return nil
+	}
+	if sm := InlineSourceMap(ref); sm != nil {
+		return sm
+	}
+	if mapURL := resolveLink(task, ref); mapURL != nil {
+		logging.Logf(logging.LogDebug, "Found sourcemap %s for %s.", mapURL.String(), task.String())
+		w.provenance.Record(workqueue.Provenance{Source: workqueue.SourceSourceMap, Parent: task}, mapURL)
+		w.adder(mapURL)
+	}
+	return nil
+}
+
+// Fetch the end of a large body.  Servers that don't honor the range are
+// left alone rather than downloading the whole body, and nil returned.
+func (w *Worker) sampleTail(task *url.URL) []byte {
+	rc, ok := w.client.(client.RangeClient)
+	if !ok {
+		return nil
+	}
+	resp, _, err := w.paced(task, func() (*http.Response, error) {
+		return rc.RequestRange(task, fmt.Sprintf("bytes=-%d", w.settings.SampleSize))
+	})
+	if err != nil {
+		logging.Logf(logging.LogDebug, "Unable to sample end of %s: %s", task.String(), err.Error())
+		return nil
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusPartialContent {
+		logging.Logf(logging.LogDebug, "Range not supported for %s.", task.String())
+		return nil
+	}
+	tail, err := ioutil.ReadAll(io.LimitReader(resp.Body, w.settings.SampleSize))
+	w.noteTarpit(task, err)
+	if err != nil {
+		return nil
+	}
+	return tail
+}
+
+// Send a result on, noting it against its directory.
+func (w *Worker) emit(result results.Result) {
+	result.Target = w.targetOf(result)
+	w.dirs.Observe(result.URL, result.Code, results.ReportResult(result))
+	if !w.hooks.Finding(result) {
+		logging.Logf(logging.LogDebug, "Hook dropped result for %s.", result.URL.String())
+		return
+	}
+	w.events.Result(w.id, result)
+	w.rchan <- result
+}
+
+// Send result to rchan unless a hook drops it, for workers of the other
+// modes.
+func report(hooks *results.Hooks, rchan chan<- results.Result, result results.Result) {
+	if !hooks.Finding(result) {
+		logging.Logf(logging.LogDebug, "Hook dropped result for %s.", result.URL.String())
+		return
+	}
+	rchan <- result
+}
+
+// The base URL a result belongs to.  Results outside every scope, such as
+// cross-origin links, belong to the target of the page that led to them.
+func (w *Worker) targetOf(result results.Result) string {
+	target := workqueue.TargetOf(w.targets, result.URL)
+	if target == nil && result.Parent != nil {
+		target = workqueue.TargetOf(w.targets, result.Parent)
+	}
+	if target == nil {
+		return ""
+	}
+	return target.String()
+}
+
+// Count bodies aborted for being too slow against the host.  Timeouts
+// connecting or waiting for headers aren't counted, as a host that is down
+// for a moment isn't a tarpit.
+func (w *Worker) noteTarpit(task *url.URL, err error) {
+	if err == client.ErrTarpit {
+		w.tarpits.Record(task.Host)
+	}
+}
+
+// Make a request, subject to pacing.
+func (w *Worker) request(task *url.URL) (*http.Response, error) {
+	resp, elapsed, err := w.paced(task, func() (*http.Response, error) {
+		return w.send(w.client, task)
+	})
+	w.elapsed = elapsed
+	if resp != nil {
+		w.hooks.Response(task, resp)
+	}
+	return resp, err
+}
+
+// Make a request for task with send, subject to pacing and noted in the
+// event stream.
+func (w *Worker) paced(task *url.URL, send func() (*http.Response, error)) (*http.Response, time.Duration, error) {
+	if w.pacer != nil {
+		w.pacer.Acquire()
+	}
+	w.events.Request(w.id, task)
+	start := time.Now()
+	resp, err := send()
+	elapsed := time.Since(start)
+	if w.pacer != nil {
+		w.pacer.Release(elapsed)
+	}
+	if w.events != nil {
+		var code int
+		if resp != nil {
+			code = resp.StatusCode
+		}
+		// Stopping at a redirect is not an error
+		evErr := err
+		if w.redir != nil {
+			evErr = nil
+		}
+		w.events.Response(w.id, task, code, elapsed, evErr)
+	}
+	return resp, elapsed, err
+}
+
+// Send the request for task with c, with the configured method and body if
+// any.  The fuzz marker in the body is replaced by the last part of the path.
+func (w *Worker) send(c client.Client, task *url.URL) (*http.Response, error) {
+	return sendRequest(w.settings, c, task)
+}
+
+// Request task with the method and body the settings give, if any.
+func sendRequest(settings *ss.ScanSettings, c client.Client, task *url.URL) (*http.Response, error) {
+	mc, ok := c.(client.MethodClient)
+	if !ok || settings == nil || (settings.Method == "" && settings.Data == "") {
+		return c.RequestURL(task)
+	}
+	trimmed := strings.TrimSuffix(task.Path, "/")
+	body := fuzz(settings.Data, trimmed[strings.LastIndex(trimmed, "/")+1:])
+	header := make(http.Header)
+	if body != "" && settings.ContentType != "" {
+		header.Set("Content-Type", settings.ContentType)
+	}
+	return mc.RequestMethod(requestMethod(settings.Method, settings.Data), task, header, body)
+}
+
+// Request task again without credentials, for the status and length.  The
+// status is 0 if the request failed.
+func (w *Worker) requestAnonymous(task *url.URL) (int, int64) {
+	resp, err := w.send(w.anon, task)
+	if err != nil {
+		logging.Logf(logging.LogDebug, "Unable to request %s without credentials: %s", task.String(), err.Error())
+		return 0, 0
+	}
+	defer resp.Body.Close()
+	length, _ := io.Copy(ioutil.Discard, io.LimitReader(resp.Body, bodyLimit(w.settings.MaxBodyRead)))
+	if resp.ContentLength >= 0 {
+		length = resp.ContentLength
+	}
+	return resp.StatusCode, length
+}
+
 // Should we keep spidering from this code?
 func (w *Worker) KeepSpidering(code int) bool {
 	for _, v := range w.settings.SpiderCodes {
@@ -208,20 +673,176 @@ func (w *Worker) KeepSpidering(code int) bool {
 	return false
 }
 
+// Should we keep spidering from this content type?  Entries starting with
+// "!" deny a type, and take priority over allowed types.  Responses without a
+// content type are given the benefit of the doubt.
+func (w *Worker) SpiderContentType(ct string) bool {
+	if ct == "" || len(w.settings.SpiderContentTypes) == 0 {
+		return true
+	}
+	mediaType, _, err := mime.ParseMediaType(ct)
+	if err != nil {
+		return false
+	}
+	allow := false
+	onlyDeny := true
+	for _, entry := range w.settings.SpiderContentTypes {
+		entry = strings.ToLower(strings.TrimSpace(entry))
+		if strings.HasPrefix(entry, "!") {
+			if matchMediaType(entry[1:], mediaType) {
+				return false
+			}
+			continue
+		}
+		onlyDeny = false
+		if matchMediaType(entry, mediaType) {
+			allow = true
+		}
+	}
+	return allow || onlyDeny
+}
+
+// Match a media type against a pattern, which may be type/*.
+func matchMediaType(pattern, mediaType string) bool {
+	if strings.HasSuffix(pattern, "/*") {
+		return strings.HasPrefix(mediaType, pattern[:len(pattern)-1])
+	}
+	return pattern == mediaType
+}
+
+// Open the store to save bodies in, if the settings say to.  The caller
+// closes it once the workers are done.
+func OpenBodyStore(settings *ss.ScanSettings) (*storage.BodyStore, error) {
+	if settings.SaveBodiesPath == "" {
+		return nil, nil
+	}
+	store, err := storage.NewBodyStore(settings.SaveBodiesPath)
+	if err != nil {
+		return nil, err
+	}
+	store.SetLimits(settings.MaxSavedBytes, settings.MinFreeDisk)
+	// Already validated
+	redactor, _ := redact.New(settings.Redact, settings.RedactPatterns)
+	store.SetRedactor(redactor)
+	return store, nil
+}
+
+// Open the event stream, if the settings ask for one.  The caller closes it
+// once the scan is done.
+func OpenEventStream(settings *ss.ScanSettings) (*results.EventStream, error) {
+	if settings.Events == "" {
+		return nil, nil
+	}
+	events, err := results.OpenEventStream(settings.Events)
+	if err != nil {
+		return nil, err
+	}
+	// Already validated
+	redactor, _ := redact.New(settings.Redact, settings.RedactPatterns)
+	events.SetRedactor(redactor)
+	return events, nil
+}
+
 // Starts a batch of workers based on the relevant settings.
 func StartWorkers(settings *ss.ScanSettings,
 	factory client.ClientFactory,
 	src <-chan *url.URL,
 	adder workqueue.QueueAddFunc,
+	provenance *workqueue.ProvenanceTracker,
+	dirs *workqueue.DirectoryTracker,
+	state *workqueue.StateTracker,
+	existence *workqueue.DirExistence,
+	store *storage.BodyStore,
+	events *results.EventStream,
+	hooks *results.Hooks,
 	done workqueue.QueueDoneFunc,
 	rchan chan<- results.Result) []*Worker {
 	count := settings.Workers
 	workers := make([]*Worker, count)
+	var notFound *NotFoundDetector
+	if settings.DetectSoft404 {
+		notFound = NewNotFoundDetector(factory)
+		notFound.SetRequestSettings(settings)
+	}
+	var anonFactory client.ClientFactory
+	if settings.CompareAnonymous {
+		if af, ok := factory.(client.AnonymousClientFactory); ok {
+			anonFactory = af.Anonymous()
+		} else {
+			logging.Logf(logging.LogError, "Clients can't leave out credentials, not comparing anonymous requests.")
+		}
+	}
+	var anomalies *AnomalyDetector
+	if settings.DetectAnomalies {
+		anomalies = NewAnomalyDetector()
+	}
+	var pacer *LatencyPacer
+	if settings.PaceLatency > 0 {
+		pacer = NewLatencyPacer(settings.PaceLatency, count)
+	}
+	var secretRules []SecretRule
+	if settings.ScanSecrets {
+		secretRules = DefaultSecretRules
+		if settings.SecretRulesPath != "" {
+			if rules, err := LoadSecretRules(settings.SecretRulesPath); err != nil {
+				logging.Logf(logging.LogError, "Unable to load secret rules: %s", err.Error())
+			} else {
+				secretRules = append(rules, DefaultSecretRules...)
+			}
+		}
+	}
+	linkSources := settings.SpiderElements
+	if err := CheckLinkSources(linkSources); err != nil {
+		logging.Logf(logging.LogError, "%s Following all links.", err.Error())
+		linkSources = nil
+	}
+	var memory *util.MemoryGuard
+	if settings.MaxMemory > 0 {
+		memory = util.NewMemoryGuard(settings.MaxMemory)
+	}
+	var tarpits *TarpitTracker
+	if settings.TarpitLimit > 0 {
+		tarpits = NewTarpitTracker(settings.TarpitLimit)
+	}
+	var extensions *ExtensionStats
+	if settings.ExtensionMisses > 0 {
+		extensions = NewExtensionStats(settings.ExtensionMisses)
+	}
+	targets, err := settings.GetScopes()
+	if err != nil {
+		logging.Logf(logging.LogError, "Unable to label results by target: %s", err.Error())
+	}
 	for i := 0; i < count; i++ {
 		workers[i] = NewWorker(settings, factory, src, adder, done, rchan)
+		workers[i].targets = targets
+		workers[i].notFound = notFound
+		workers[i].anomalies = anomalies
+		if anonFactory != nil {
+			workers[i].anon = anonFactory.Get()
+			// Redirects are compared, not followed
+			workers[i].anon.SetCheckRedirect(func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			})
+		}
+		workers[i].store = store
+		workers[i].pacer = pacer
+		workers[i].secretRules = secretRules
+		workers[i].provenance = provenance
+		workers[i].tarpits = tarpits
+		workers[i].extensions = extensions
+		workers[i].dirs = dirs
+		workers[i].state = state
+		workers[i].existence = existence
+		workers[i].id = i
+		workers[i].events = events
+		workers[i].hooks = hooks
 		workers[i].RunInBackground()
 		if settings.ParseHTML {
-			workers[i].SetPageWorker(NewHTMLWorker(adder))
+			htmlWorker := NewHTMLWorker(adder, provenance)
+			htmlWorker.memory = memory
+			htmlWorker.sources = linkSources
+			htmlWorker.maxSize = settings.MaxParseSize
+			workers[i].SetPageWorker(htmlWorker)
 		}
 	}
 	return workers
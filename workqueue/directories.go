@@ -0,0 +1,180 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package workqueue
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// DirectorySummary describes a directory once all of its wordlist children
+// have been tried.
+type DirectorySummary struct {
+	URL *url.URL
+	// Children tried
+	Children int
+	// Children that turned up something
+	Found int
+	// Count of results by status code
+	Codes map[int]int
+}
+
+// DirectoryTracker follows the wordlist expansion of each directory through
+// to completion.  A nil DirectoryTracker tracks nothing.
+type DirectoryTracker struct {
+	mu   sync.Mutex
+	dirs map[string]*dirState
+	// Directories of each in-flight child, once for each time it was
+	// tracked, as a word may appear more than once
+	pending map[string][]string
+	// Called as each directory completes
+	onComplete func(DirectorySummary)
+	total      int
+	completed  int
+}
+
+type dirState struct {
+	summary   DirectorySummary
+	remaining int
+}
+
+func NewDirectoryTracker(onComplete func(DirectorySummary)) *DirectoryTracker {
+	return &DirectoryTracker{
+		dirs:       make(map[string]*dirState),
+		pending:    make(map[string][]string),
+		onComplete: onComplete,
+	}
+}
+
+// Expect n children under dir.  Each must then be passed to Track.
+func (t *DirectoryTracker) Expect(dir *url.URL, n int) {
+	if t == nil || n == 0 {
+		return
+	}
+	key := dirKey(dir)
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	state, ok := t.dirs[key]
+	if !ok {
+		parsed, _ := url.Parse(key)
+		state = &dirState{summary: DirectorySummary{URL: parsed, Codes: make(map[int]int)}}
+		t.dirs[key] = state
+		t.total++
+	}
+	state.remaining += n
+}
+
+// Note that child of dir is on its way to be tried.
+func (t *DirectoryTracker) Track(dir, child *url.URL) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	key := child.String()
+	t.pending[key] = append(t.pending[key], dirKey(dir))
+}
+
+// Record a result under its directory.
+func (t *DirectoryTracker) Observe(u *url.URL, code int, found bool) {
+	if t == nil {
+		return
+	}
+	parent := parentDir(u)
+	if parent == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if state, ok := t.dirs[parent.String()]; ok {
+		state.summary.Codes[code]++
+		if found {
+			state.summary.Found++
+		}
+	}
+}
+
+// Note that a URL is finished with, whether tried or dropped.
+func (t *DirectoryTracker) Done(u *url.URL) {
+	if t == nil {
+		return
+	}
+	var finished *DirectorySummary
+	t.mu.Lock()
+	key := u.String()
+	if dirs, ok := t.pending[key]; ok {
+		dir := dirs[0]
+		if len(dirs) == 1 {
+			delete(t.pending, key)
+		} else {
+			t.pending[key] = dirs[1:]
+		}
+		state := t.dirs[dir]
+		state.remaining--
+		state.summary.Children++
+		if state.remaining == 0 {
+			delete(t.dirs, dir)
+			t.completed++
+			finished = &state.summary
+		}
+	}
+	t.mu.Unlock()
+	if finished != nil && t.onComplete != nil {
+		t.onComplete(*finished)
+	}
+}
+
+// Get the number of directories completed and known about.
+func (t *DirectoryTracker) Counts() (int, int) {
+	if t == nil {
+		return 0, 0
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.completed, t.total
+}
+
+// Status codes of a summary in ascending order.
+func (s DirectorySummary) SortedCodes() []int {
+	codes := make([]int, 0, len(s.Codes))
+	for code := range s.Codes {
+		codes = append(codes, code)
+	}
+	sort.Ints(codes)
+	return codes
+}
+
+func (s DirectorySummary) String() string {
+	codes := make([]string, 0, len(s.Codes))
+	for _, code := range s.SortedCodes() {
+		codes = append(codes, fmt.Sprintf("%dx%d", code, s.Codes[code]))
+	}
+	return fmt.Sprintf("%s: %d children, %d found (%s)", s.URL, s.Children, s.Found, strings.Join(codes, " "))
+}
+
+// Normalize a directory URL so children map back to it.
+func dirKey(u *url.URL) string {
+	dir := *u
+	if !strings.HasSuffix(dir.Path, "/") {
+		dir.Path += "/"
+	}
+	dir.RawPath = ""
+	dir.RawQuery = ""
+	dir.Fragment = ""
+	return dir.String()
+}
@@ -0,0 +1,106 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package workqueue
+
+import (
+	"net/url"
+	"testing"
+)
+
+func TestDirectoryTracker(t *testing.T) {
+	var summaries []DirectorySummary
+	tracker := NewDirectoryTracker(func(s DirectorySummary) {
+		summaries = append(summaries, s)
+	})
+	dir := &url.URL{Scheme: "http", Host: "localhost", Path: "/admin"}
+	children := []*url.URL{
+		&url.URL{Scheme: "http", Host: "localhost", Path: "/admin/login"},
+		&url.URL{Scheme: "http", Host: "localhost", Path: "/admin/backup/"},
+	}
+	tracker.Expect(dir, len(children))
+	for _, c := range children {
+		tracker.Track(dir, c)
+	}
+	if done, total := tracker.Counts(); done != 0 || total != 1 {
+		t.Errorf("Expected 0/1 directories, got %d/%d", done, total)
+	}
+	tracker.Observe(children[0], 200, true)
+	tracker.Observe(&url.URL{Scheme: "http", Host: "localhost", Path: "/admin/login.php"}, 404, false)
+	tracker.Done(children[0])
+	if len(summaries) != 0 {
+		t.Fatal("Directory completed early.")
+	}
+	// Untracked URLs are ignored
+	tracker.Done(&url.URL{Scheme: "http", Host: "localhost", Path: "/other"})
+	tracker.Done(children[1])
+	if len(summaries) != 1 {
+		t.Fatalf("Expected 1 summary, got %d", len(summaries))
+	}
+	s := summaries[0]
+	if s.URL.String() != "http://localhost/admin/" {
+		t.Errorf("Unexpected directory: %s", s.URL)
+	}
+	if s.Children != 2 || s.Found != 1 || s.Codes[200] != 1 || s.Codes[404] != 1 {
+		t.Errorf("Unexpected summary: %+v", s)
+	}
+	if codes := s.SortedCodes(); len(codes) != 2 || codes[0] != 200 {
+		t.Errorf("Unexpected sorted codes: %v", codes)
+	}
+	if str := s.String(); str != "http://localhost/admin/: 2 children, 1 found (200x1 404x1)" {
+		t.Errorf("Unexpected summary string: %s", str)
+	}
+	if done, total := tracker.Counts(); done != 1 || total != 1 {
+		t.Errorf("Expected 1/1 directories, got %d/%d", done, total)
+	}
+}
+
+func TestDirectoryTracker_Duplicates(t *testing.T) {
+	completed := 0
+	tracker := NewDirectoryTracker(func(s DirectorySummary) {
+		completed++
+		if s.Children != 3 {
+			t.Errorf("Expected 3 children, got %d", s.Children)
+		}
+	})
+	dir := &url.URL{Scheme: "http", Host: "localhost", Path: "/"}
+	admin := &url.URL{Scheme: "http", Host: "localhost", Path: "/admin"}
+	other := &url.URL{Scheme: "http", Host: "localhost", Path: "/other"}
+	tracker.Expect(dir, 3)
+	tracker.Track(dir, admin)
+	tracker.Track(dir, admin)
+	tracker.Track(dir, other)
+	tracker.Done(admin)
+	tracker.Done(other)
+	if completed != 0 {
+		t.Fatal("Directory completed before the duplicate was done.")
+	}
+	// The duplicate is dropped by the filter
+	tracker.Done(admin)
+	if completed != 1 {
+		t.Errorf("Expected directory to complete, got %d completions", completed)
+	}
+}
+
+func TestDirectoryTracker_Nil(t *testing.T) {
+	var tracker *DirectoryTracker
+	u := &url.URL{Scheme: "http", Host: "localhost", Path: "/"}
+	tracker.Expect(u, 1)
+	tracker.Track(u, u)
+	tracker.Observe(u, 200, true)
+	tracker.Done(u)
+	if done, total := tracker.Counts(); done != 0 || total != 0 {
+		t.Errorf("Expected nil tracker to count nothing, got %d/%d", done, total)
+	}
+}
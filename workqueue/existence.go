@@ -0,0 +1,86 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package workqueue
+
+import (
+	"github.com/Matir/gobuster/logging"
+	"net/url"
+	"sync"
+)
+
+// DirExistence remembers which directories exist, so the wordlist is only
+// brute-forced inside directories that have been found.  Directories that
+// reach the queue some other way, such as links, are held back until a
+// request shows they aren't missing.  Only directories that exist are
+// remembered, since those are few.  A nil DirExistence holds nothing back.
+type DirExistence struct {
+	mu     sync.Mutex
+	exists map[string]bool
+	// Directories waiting on a request to be expanded
+	held map[string]bool
+}
+
+func NewDirExistence() *DirExistence {
+	return &DirExistence{exists: make(map[string]bool), held: make(map[string]bool)}
+}
+
+// Treat directories as existing without checking, such as the targets.
+func (d *DirExistence) Assume(urls ...*url.URL) {
+	if d == nil {
+		return
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for _, u := range urls {
+		d.exists[u.String()] = true
+	}
+}
+
+// Whether the directory u is known to exist.  If not, it is held until its
+// request is recorded.
+func (d *DirExistence) Ready(u *url.URL) bool {
+	if d == nil {
+		return true
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	key := u.String()
+	if d.exists[key] {
+		return true
+	}
+	d.held[key] = true
+	return false
+}
+
+// Record whether the directory u exists, after requesting it.  Returns true
+// if u was held and exists, so should now be expanded.
+func (d *DirExistence) Record(u *url.URL, exists bool) bool {
+	if d == nil {
+		return false
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	key := u.String()
+	held := d.held[key]
+	delete(d.held, key)
+	if !exists {
+		if held {
+			logging.Logf(logging.LogDebug, "Not brute-forcing missing directory %s.", key)
+		}
+		return false
+	}
+	d.exists[key] = true
+	return held
+}
@@ -0,0 +1,51 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package workqueue
+
+import (
+	"net/url"
+	"testing"
+)
+
+func TestDirExistence(t *testing.T) {
+	d := NewDirExistence()
+	root := &url.URL{Scheme: "http", Host: "localhost", Path: "/"}
+	found := &url.URL{Scheme: "http", Host: "localhost", Path: "/found/"}
+	linked := &url.URL{Scheme: "http", Host: "localhost", Path: "/linked/"}
+	missing := &url.URL{Scheme: "http", Host: "localhost", Path: "/missing/"}
+	d.Assume(root)
+	if !d.Ready(root) {
+		t.Errorf("Expected assumed directory to be ready.")
+	}
+	if d.Record(found, true) {
+		t.Errorf("Expected directory that wasn't held not to be released.")
+	}
+	if !d.Ready(found) {
+		t.Errorf("Expected found directory to be ready.")
+	}
+	if d.Ready(linked) {
+		t.Errorf("Expected unchecked directory to be held.")
+	}
+	if !d.Record(linked, true) {
+		t.Errorf("Expected held directory to be released once found.")
+	}
+	if d.Ready(missing) || d.Record(missing, false) {
+		t.Errorf("Expected missing directory to stay held back.")
+	}
+	var none *DirExistence
+	if !none.Ready(missing) || none.Record(missing, true) {
+		t.Errorf("Expected nil DirExistence to hold nothing back.")
+	}
+}
@@ -0,0 +1,74 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package workqueue
+
+import (
+	"golang.org/x/net/publicsuffix"
+	"net"
+	"net/url"
+	"path"
+	"strings"
+)
+
+// Check whether the origin of u matches one of the patterns.  Patterns are
+// hostnames or globs such as *.example.com, and match any port unless they
+// include one.
+func matchOrigin(patterns []string, u *url.URL) bool {
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return false
+	}
+	host := strings.ToLower(u.Hostname())
+	hostPort := strings.ToLower(u.Host)
+	for _, pattern := range patterns {
+		pattern = strings.ToLower(pattern)
+		target := host
+		if strings.Contains(pattern, ":") {
+			target = hostPort
+		}
+		if ok, _ := path.Match(pattern, target); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// Build patterns covering the registered domain of each scope URL and its
+// subdomains, so that www.example.com brings in *.example.com.  The domain
+// comes from the public suffix list, so www.example.co.uk brings in
+// *.example.co.uk and never *.co.uk.  IP addresses are skipped.
+func SubdomainPatterns(scope []*url.URL) []string {
+	patterns := make([]string, 0, 2*len(scope))
+	seen := make(map[string]bool)
+	for _, u := range scope {
+		host := strings.ToLower(u.Hostname())
+		if host == "" || net.ParseIP(host) != nil {
+			continue
+		}
+		domain, err := publicsuffix.EffectiveTLDPlusOne(host)
+		if seen[host] || seen[domain] {
+			continue
+		}
+		if err != nil {
+			// The host is itself a public suffix, whose subdomains belong
+			// to others
+			seen[host] = true
+			patterns = append(patterns, host)
+			continue
+		}
+		seen[domain] = true
+		patterns = append(patterns, domain, "*."+domain)
+	}
+	return patterns
+}
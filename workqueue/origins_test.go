@@ -0,0 +1,94 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package workqueue
+
+import (
+	"net/url"
+	"testing"
+)
+
+func TestMatchOrigin(t *testing.T) {
+	patterns := []string{"cdn.example.com", "*.example.org", "api.example.net:8443"}
+	cases := map[string]bool{
+		"http://cdn.example.com/x":        true,
+		"https://CDN.example.com:444/":    true,
+		"http://www.example.com/":         false,
+		"http://static.example.org/a.js":  true,
+		"http://example.org/":             false,
+		"https://api.example.net:8443/v1": true,
+		"https://api.example.net/v1":      false,
+		"ftp://cdn.example.com/":          false,
+	}
+	for raw, expected := range cases {
+		u, _ := url.Parse(raw)
+		if res := matchOrigin(patterns, u); res != expected {
+			t.Errorf("Expected %v for %s, got %v", expected, raw, res)
+		}
+	}
+}
+
+func TestAllowOrigins(t *testing.T) {
+	scope := []*url.URL{&url.URL{Scheme: "http", Host: "www.example.com", Path: "/"}}
+	q := NewWorkQueue(1, scope, false)
+	other := &url.URL{Scheme: "https", Host: "cdn.example.com", Path: "/lib.js"}
+	if q.filter(other) {
+		t.Error("Expected cross-origin URL to be rejected by default.")
+	}
+	q.AllowOrigins("cdn.example.com")
+	if !q.filter(other) {
+		t.Error("Expected allowed origin to pass the filter.")
+	}
+}
+
+func TestSubdomainPatterns(t *testing.T) {
+	scope := []*url.URL{
+		&url.URL{Scheme: "http", Host: "www.example.com", Path: "/"},
+		&url.URL{Scheme: "https", Host: "example.com:8443", Path: "/"},
+		&url.URL{Scheme: "http", Host: "10.0.0.1", Path: "/"},
+	}
+	patterns := SubdomainPatterns(scope)
+	if len(patterns) != 2 || patterns[0] != "example.com" || patterns[1] != "*.example.com" {
+		t.Errorf("Unexpected patterns: %v", patterns)
+	}
+	sibling := &url.URL{Scheme: "https", Host: "api.example.com", Path: "/"}
+	if !matchOrigin(patterns, sibling) {
+		t.Errorf("Expected %s to be in scope.", sibling)
+	}
+}
+
+func TestSubdomainPatterns_PublicSuffix(t *testing.T) {
+	scope := []*url.URL{
+		&url.URL{Scheme: "http", Host: "www.example.co.uk", Path: "/"},
+		&url.URL{Scheme: "http", Host: "example.github.io", Path: "/"},
+	}
+	patterns := SubdomainPatterns(scope)
+	expected := []string{"example.co.uk", "*.example.co.uk", "example.github.io", "*.example.github.io"}
+	if len(patterns) != len(expected) {
+		t.Fatalf("Expected %v, got %v", expected, patterns)
+	}
+	for i, p := range expected {
+		if patterns[i] != p {
+			t.Errorf("Expected %v, got %v", expected, patterns)
+			break
+		}
+	}
+	other := &url.URL{Scheme: "http", Host: "other.co.uk", Path: "/"}
+	if matchOrigin(patterns, other) {
+		t.Errorf("Expected %s out of scope.", other)
+	}
+	if p := SubdomainPatterns([]*url.URL{&url.URL{Scheme: "http", Host: "co.uk"}}); len(p) != 1 || p[0] != "co.uk" {
+		t.Errorf("Expected only the suffix itself, got %v", p)
+	}
+}
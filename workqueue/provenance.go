@@ -0,0 +1,117 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package workqueue
+
+import (
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// Source describes how a URL came to be scanned.
+type Source string
+
+const (
+	SourceSeed      Source = "seed"
+	SourceWordlist  Source = "wordlist"
+	SourceExtension Source = "extension"
+	SourceMangle    Source = "mangle"
+	SourceLink      Source = "link"
+	SourceRedirect  Source = "redirect"
+	SourceRobots    Source = "robots"
+	SourceHeader    Source = "header"
+	SourceSourceMap Source = "sourcemap"
+	SourceWellKnown Source = "wellknown"
+	SourceKnowledge Source = "knowledge"
+	// Names from a zone transfer, which are reported without being queued
+	SourceZoneTransfer Source = "zonetransfer"
+)
+
+// Provenance records where a URL came from.
+type Provenance struct {
+	Source Source
+	// URL that led to this one, nil for seeds
+	Parent *url.URL
+}
+
+// ProvenanceTracker remembers how URLs entered the queue.  URLs without a
+// record are assumed to come from the wordlist under their parent directory,
+// so the common case costs nothing.  Records are dropped once a URL is done,
+// so only queued work is held.  A nil ProvenanceTracker records nothing.
+type ProvenanceTracker struct {
+	mu      sync.Mutex
+	entries map[string]Provenance
+	// URLs the queue would accept, nil for all
+	inScope func(*url.URL) bool
+}
+
+func NewProvenanceTracker() *ProvenanceTracker {
+	return &ProvenanceTracker{entries: make(map[string]Provenance)}
+}
+
+// Record the provenance of urls.  The first record for a URL wins.  URLs the
+// queue will reject are not recorded.
+func (t *ProvenanceTracker) Record(p Provenance, urls ...*url.URL) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for _, u := range urls {
+		if t.inScope != nil && !t.inScope(u) {
+			continue
+		}
+		key := u.String()
+		if _, ok := t.entries[key]; !ok {
+			t.entries[key] = p
+		}
+	}
+}
+
+// Find the provenance of a URL.
+func (t *ProvenanceTracker) Lookup(u *url.URL) Provenance {
+	if t != nil {
+		t.mu.Lock()
+		defer t.mu.Unlock()
+		if p, ok := t.entries[u.String()]; ok {
+			return p
+		}
+	}
+	return Provenance{Source: SourceWordlist, Parent: parentDir(u)}
+}
+
+// Forget a URL that has been scanned or dropped.
+func (t *ProvenanceTracker) Done(u *url.URL) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.entries, u.String())
+}
+
+// Directory containing u, or nil at the root.
+func parentDir(u *url.URL) *url.URL {
+	p := strings.TrimRight(u.Path, "/")
+	if p == "" {
+		return nil
+	}
+	parent := *u
+	parent.Path = p[:strings.LastIndex(p, "/")+1]
+	parent.RawPath = ""
+	parent.RawQuery = ""
+	parent.Fragment = ""
+	return &parent
+}
@@ -0,0 +1,95 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package workqueue
+
+import (
+	"net/url"
+	"testing"
+)
+
+func TestProvenanceTracker(t *testing.T) {
+	tracker := NewProvenanceTracker()
+	u := &url.URL{Scheme: "http", Host: "localhost", Path: "/admin/"}
+	if p := tracker.Lookup(u); p.Source != SourceWordlist {
+		t.Errorf("Expected wordlist for unknown URL, got %s", p.Source)
+	} else if p.Parent == nil || p.Parent.String() != "http://localhost/" {
+		t.Errorf("Expected parent http://localhost/, got %v", p.Parent)
+	}
+	page := &url.URL{Scheme: "http", Host: "localhost", Path: "/index.html"}
+	tracker.Record(Provenance{Source: SourceLink, Parent: page}, u)
+	tracker.Record(Provenance{Source: SourceRedirect}, u)
+	if p := tracker.Lookup(u); p.Source != SourceLink {
+		t.Errorf("Expected first source to win, got %s", p.Source)
+	} else if p.Parent != page {
+		t.Errorf("Expected parent %s, got %v", page, p.Parent)
+	}
+}
+
+func TestProvenanceTracker_Done(t *testing.T) {
+	tracker := NewProvenanceTracker()
+	u := &url.URL{Scheme: "http", Host: "localhost", Path: "/admin/"}
+	tracker.Record(Provenance{Source: SourceLink}, u)
+	tracker.Done(u)
+	if p := tracker.Lookup(u); p.Source != SourceWordlist {
+		t.Errorf("Expected record dropped once done, got %s", p.Source)
+	}
+	if len(tracker.entries) != 0 {
+		t.Errorf("Expected no entries, got %d", len(tracker.entries))
+	}
+}
+
+func TestProvenanceTracker_OutOfScope(t *testing.T) {
+	scope := []*url.URL{&url.URL{Scheme: "http", Host: "localhost", Path: "/"}}
+	tracker := NewWorkQueue(5, scope, false).Provenance()
+	in := &url.URL{Scheme: "http", Host: "localhost", Path: "/a"}
+	out := &url.URL{Scheme: "http", Host: "example.com", Path: "/a"}
+	tracker.Record(Provenance{Source: SourceLink}, in, out)
+	if len(tracker.entries) != 1 {
+		t.Errorf("Expected only the in-scope URL recorded, got %d entries", len(tracker.entries))
+	}
+	if p := tracker.Lookup(in); p.Source != SourceLink {
+		t.Errorf("Expected link for in-scope URL, got %s", p.Source)
+	}
+}
+
+func TestParentDir(t *testing.T) {
+	cases := map[string]string{
+		"/":          "",
+		"/a":         "http://localhost/",
+		"/a/b/":      "http://localhost/a/",
+		"/a/b.php":   "http://localhost/a/",
+		"/a/b?x=1#f": "http://localhost/a/",
+	}
+	for path, expected := range cases {
+		u, _ := url.Parse("http://localhost" + path)
+		parent := parentDir(u)
+		got := ""
+		if parent != nil {
+			got = parent.String()
+		}
+		if got != expected {
+			t.Errorf("Expected parent %q for %s, got %q", expected, path, got)
+		}
+	}
+}
+
+func TestProvenanceTracker_Nil(t *testing.T) {
+	var tracker *ProvenanceTracker
+	u := &url.URL{Scheme: "http", Host: "localhost", Path: "/"}
+	tracker.Record(Provenance{Source: SourceSeed}, u)
+	if p := tracker.Lookup(u); p.Source != SourceWordlist {
+		t.Errorf("Expected wordlist from nil tracker, got %s", p.Source)
+	}
+}
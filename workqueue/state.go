@@ -0,0 +1,424 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package workqueue
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/Matir/gobuster/logging"
+	"github.com/Matir/gobuster/results"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ScanState is a snapshot of a scan with enough detail to resume it.
+type ScanState struct {
+	SettingsHash string    `json:"settings_hash"`
+	WordlistHash string    `json:"wordlist_hash"`
+	Targets      []string  `json:"targets"`
+	Saved        time.Time `json:"saved"`
+	// URLs given to the queue, in order
+	Queued []QueuedURL `json:"queued"`
+	// Words of each directory's expansion whose children are all done
+	Offsets map[string]int `json:"offsets"`
+	// Last word counted in each offset, so a resumed scan can find the right
+	// word to continue at even if the offsets don't line up with the wordlist
+	Words map[string]string `json:"words,omitempty"`
+	// Other URLs already tried
+	Done []string `json:"done"`
+	// Results so far, as JSON output lines
+	Results []json.RawMessage `json:"results"`
+}
+
+// QueuedURL is a URL given to the queue and how it was found.
+type QueuedURL struct {
+	URL    string `json:"url"`
+	Source Source `json:"source"`
+	Parent string `json:"parent,omitempty"`
+}
+
+// Load a state file written by a StateTracker.
+func LoadState(path string) (*ScanState, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	state := &ScanState{}
+	if err := json.Unmarshal(data, state); err != nil {
+		return nil, fmt.Errorf("Invalid state file %s: %s", path, err.Error())
+	}
+	return state, nil
+}
+
+// Write the state to path, replacing it atomically so an interruption never
+// leaves a partial file.
+func (s *ScanState) Save(path string) error {
+	data, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+	tmp, err := ioutil.TempFile(filepath.Dir(path), ".gobuster-state")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// Parse the queued URLs of a state, with their provenance.
+func (s *ScanState) QueuedURLs() ([]*url.URL, []Provenance, error) {
+	urls := make([]*url.URL, 0, len(s.Queued))
+	provs := make([]Provenance, 0, len(s.Queued))
+	for _, q := range s.Queued {
+		u, err := url.Parse(q.URL)
+		if err != nil {
+			return nil, nil, err
+		}
+		p := Provenance{Source: q.Source}
+		if q.Parent != "" {
+			if p.Parent, err = url.Parse(q.Parent); err != nil {
+				return nil, nil, err
+			}
+		}
+		urls = append(urls, u)
+		provs = append(provs, p)
+	}
+	return urls, provs, nil
+}
+
+// StateTracker follows the progress of a scan and periodically saves it as a
+// ScanState.  A nil StateTracker tracks nothing.
+type StateTracker struct {
+	mu       sync.Mutex
+	path     string
+	interval time.Duration
+	stop     chan bool
+	stopped  chan bool
+	// Unchanging details of the scan
+	settingsHash string
+	wordlistHash string
+	targets      []string
+	queued       []QueuedURL
+	queuedSeen   map[string]bool
+	dirs         map[string]*dirProgress
+	// Directories and words of each in-flight child.  Several words may
+	// expand to the same URL, which is only tried once.
+	children map[string][]childRef
+	// URLs done that aren't tracked children
+	visited map[string]bool
+	results []json.RawMessage
+}
+
+type dirProgress struct {
+	// Children not yet done, for each word expanded so far
+	remaining []int
+	// Each word expanded so far, indexed like remaining
+	words []string
+	// Words whose children are all done
+	offset int
+	// Last word counted in the offset
+	last string
+	// Children done beyond offset, by word
+	done map[string]int
+}
+
+type childRef struct {
+	dir  string
+	word int
+}
+
+func NewStateTracker(path string, interval time.Duration, settingsHash, wordlistHash string, targets []string) *StateTracker {
+	return &StateTracker{
+		path:         path,
+		interval:     interval,
+		settingsHash: settingsHash,
+		wordlistHash: wordlistHash,
+		targets:      targets,
+		queuedSeen:   make(map[string]bool),
+		dirs:         make(map[string]*dirProgress),
+		children:     make(map[string][]childRef),
+		visited:      make(map[string]bool),
+	}
+}
+
+// Carry over the progress of an interrupted scan, so later saves include it.
+// Queued URLs are recorded again as they are re-added to the queue.
+func (t *StateTracker) Restore(state *ScanState) {
+	if t == nil || state == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for dir, offset := range state.Offsets {
+		t.dirs[dir] = &dirProgress{offset: offset, last: state.Words[dir], done: make(map[string]int)}
+	}
+	for _, u := range state.Done {
+		t.visited[u] = true
+	}
+}
+
+// Number of words of dir's expansion known to be done.
+func (t *StateTracker) Offset(dir *url.URL) int {
+	if t == nil {
+		return 0
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if dp, ok := t.dirs[dir.String()]; ok {
+		return dp.offset
+	}
+	return 0
+}
+
+// The last word counted in dir's offset, if known.
+func (t *StateTracker) OffsetWord(dir *url.URL) string {
+	if t == nil {
+		return ""
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if dp, ok := t.dirs[dir.String()]; ok {
+		return dp.last
+	}
+	return ""
+}
+
+// Move dir's offset, when a resumed expansion continues somewhere other than
+// its restored offset.  Must be called before any of dir's words are expanded.
+func (t *StateTracker) Realign(dir *url.URL, offset int) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if dp, ok := t.dirs[dir.String()]; ok {
+		dp.offset = offset
+		dp.remaining, dp.words = nil, nil
+	}
+}
+
+// Note a URL given to the queue.
+func (t *StateTracker) Queued(u *url.URL, p Provenance) {
+	if t == nil {
+		return
+	}
+	key := u.String()
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.queuedSeen[key] {
+		return
+	}
+	t.queuedSeen[key] = true
+	q := QueuedURL{URL: key, Source: p.Source}
+	if p.Parent != nil {
+		q.Parent = p.Parent.String()
+	}
+	t.queued = append(t.queued, q)
+}
+
+// Note the children of dir for the index'th word of the wordlist.  Words must
+// be expanded in order.
+func (t *StateTracker) Expand(dir *url.URL, index int, word string, children ...*url.URL) {
+	if t == nil {
+		return
+	}
+	key := dir.String()
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	dp, ok := t.dirs[key]
+	if !ok {
+		dp = &dirProgress{done: make(map[string]int)}
+		t.dirs[key] = dp
+	}
+	if index < dp.offset {
+		return
+	}
+	for len(dp.remaining) <= index-dp.offset {
+		dp.remaining = append(dp.remaining, 0)
+		dp.words = append(dp.words, "")
+	}
+	dp.words[index-dp.offset] = word
+	for _, child := range children {
+		ck := child.String()
+		t.children[ck] = append(t.children[ck], childRef{dir: key, word: index})
+		dp.remaining[index-dp.offset]++
+	}
+	dp.advance()
+}
+
+// Note that a URL is finished with, whether tried or dropped.
+func (t *StateTracker) Done(u *url.URL) {
+	if t == nil {
+		return
+	}
+	key := u.String()
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	refs, ok := t.children[key]
+	if !ok {
+		t.visited[key] = true
+		return
+	}
+	delete(t.children, key)
+	for _, ref := range refs {
+		dp := t.dirs[ref.dir]
+		dp.remaining[ref.word-dp.offset]--
+		if prev, ok := dp.done[key]; !ok || ref.word > prev {
+			dp.done[key] = ref.word
+		}
+		dp.advance()
+	}
+}
+
+// Move the offset past words that are done.  remaining is indexed from the
+// offset, so it is trimmed as the offset moves.
+func (dp *dirProgress) advance() {
+	n := 0
+	for n < len(dp.remaining) && dp.remaining[n] == 0 {
+		n++
+	}
+	if n == 0 {
+		return
+	}
+	dp.offset += n
+	dp.last = dp.words[n-1]
+	dp.remaining = dp.remaining[n:]
+	dp.words = dp.words[n:]
+	for k, word := range dp.done {
+		if word < dp.offset {
+			delete(dp.done, k)
+		}
+	}
+}
+
+// Record results as they pass through.
+func (t *StateTracker) Watch(src <-chan results.Result) <-chan results.Result {
+	if t == nil {
+		return src
+	}
+	c := make(chan results.Result, cap(src))
+	go func() {
+		for r := range src {
+			// Not found responses are left out, as outputs don't show them
+			if !results.ReportResult(r) && r.Error == nil {
+				c <- r
+				continue
+			}
+			if data, err := results.EncodeResult(r); err == nil {
+				t.mu.Lock()
+				t.results = append(t.results, data)
+				t.mu.Unlock()
+			}
+			c <- r
+		}
+		close(c)
+	}()
+	return c
+}
+
+// Snapshot the progress so far.
+func (t *StateTracker) State() *ScanState {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	state := &ScanState{
+		SettingsHash: t.settingsHash,
+		WordlistHash: t.wordlistHash,
+		Targets:      t.targets,
+		Saved:        time.Now(),
+		Queued:       append([]QueuedURL(nil), t.queued...),
+		Offsets:      make(map[string]int),
+		Words:        make(map[string]string),
+		Results:      append([]json.RawMessage(nil), t.results...),
+	}
+	for u := range t.visited {
+		state.Done = append(state.Done, u)
+	}
+	for dir, dp := range t.dirs {
+		if dp.offset > 0 {
+			state.Offsets[dir] = dp.offset
+		}
+		if dp.last != "" {
+			state.Words[dir] = dp.last
+		}
+		for u := range dp.done {
+			state.Done = append(state.Done, u)
+		}
+	}
+	sort.Strings(state.Done)
+	return state
+}
+
+// Save the progress so far.
+func (t *StateTracker) Save() error {
+	if t == nil {
+		return nil
+	}
+	return t.State().Save(t.path)
+}
+
+// Start saving every interval.
+func (t *StateTracker) Start() {
+	if t == nil {
+		return
+	}
+	t.stop = make(chan bool)
+	t.stopped = make(chan bool)
+	go func() {
+		defer close(t.stopped)
+		ticker := time.NewTicker(t.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-t.stop:
+				return
+			case <-ticker.C:
+				if err := t.Save(); err != nil {
+					logging.Logf(logging.LogWarning, "Unable to save scan state: %s", err.Error())
+				}
+			}
+		}
+	}()
+}
+
+// Stop saving.  The state file is removed if the scan finished, as there is
+// nothing left to resume.
+func (t *StateTracker) Stop(finished bool) {
+	if t == nil || t.stop == nil {
+		return
+	}
+	close(t.stop)
+	<-t.stopped
+	t.stop = nil
+	if finished {
+		if err := os.Remove(t.path); err != nil && !os.IsNotExist(err) {
+			logging.Logf(logging.LogWarning, "Unable to remove state file: %s", err.Error())
+		}
+	} else if err := t.Save(); err != nil {
+		logging.Logf(logging.LogWarning, "Unable to save scan state: %s", err.Error())
+	}
+}
@@ -0,0 +1,195 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package workqueue
+
+import (
+	"github.com/Matir/gobuster/results"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func mustParse(s string) *url.URL {
+	u, _ := url.Parse(s)
+	return u
+}
+
+func TestStateTracker_Offsets(t *testing.T) {
+	tracker := NewStateTracker("", 0, "settings", "words", []string{"http://localhost/"})
+	dir := mustParse("http://localhost/")
+	a, a2 := mustParse("http://localhost/a"), mustParse("http://localhost/v1/a")
+	b := mustParse("http://localhost/b")
+	c := mustParse("http://localhost/c")
+	tracker.Expand(dir, 0, "a", a, a2)
+	tracker.Expand(dir, 1, "b", b)
+	tracker.Expand(dir, 2, "c", c)
+
+	tracker.Done(a)
+	tracker.Done(b)
+	if offset := tracker.Offset(dir); offset != 0 {
+		t.Errorf("Expected offset 0 while a word is unfinished, got %d", offset)
+	}
+	state := tracker.State()
+	if strings.Join(state.Done, " ") != "http://localhost/a http://localhost/b" {
+		t.Errorf("Expected a and b done, got %v", state.Done)
+	}
+
+	tracker.Done(a2)
+	if offset := tracker.Offset(dir); offset != 2 {
+		t.Errorf("Expected offset 2, got %d", offset)
+	}
+	tracker.Done(mustParse("http://localhost/"))
+	state = tracker.State()
+	if state.Offsets["http://localhost/"] != 2 {
+		t.Errorf("Expected saved offset 2, got %v", state.Offsets)
+	}
+	if state.Words["http://localhost/"] != "b" {
+		t.Errorf("Expected saved word b, got %v", state.Words)
+	}
+	if strings.Join(state.Done, " ") != "http://localhost/" {
+		t.Errorf("Expected only the directory itself in done, got %v", state.Done)
+	}
+	tracker.Done(c)
+	if offset := tracker.Offset(dir); offset != 3 {
+		t.Errorf("Expected offset 3, got %d", offset)
+	}
+}
+
+func TestStateTracker_Duplicates(t *testing.T) {
+	tracker := NewStateTracker("", 0, "settings", "words", []string{"http://localhost/"})
+	dir := mustParse("http://localhost/")
+	a := mustParse("http://localhost/a")
+	b := mustParse("http://localhost/b")
+	// Both words expand to a, which the queue only hands out once
+	tracker.Expand(dir, 0, "a", a)
+	tracker.Expand(dir, 1, "A", a, b)
+	tracker.Done(b)
+	tracker.Done(a)
+	if offset := tracker.Offset(dir); offset != 2 {
+		t.Errorf("Expected offset 2 after a duplicate child, got %d", offset)
+	}
+}
+
+func TestStateTracker_SaveLoad(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gobuster-state")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "scan.state")
+	tracker := NewStateTracker(path, time.Hour, "settings", "words", []string{"http://localhost/"})
+	tracker.Queued(mustParse("http://localhost/"), Provenance{Source: SourceSeed})
+	tracker.Queued(mustParse("http://localhost/"), Provenance{Source: SourceSeed})
+	tracker.Queued(mustParse("http://localhost/admin/"), Provenance{Source: SourceLink, Parent: mustParse("http://localhost/")})
+	rchan := make(chan results.Result, 1)
+	rchan <- results.Result{URL: mustParse("http://localhost/admin/"), Code: 200}
+	close(rchan)
+	for range tracker.Watch(rchan) {
+	}
+	tracker.Start()
+	tracker.Stop(false)
+
+	state, err := LoadState(path)
+	if err != nil {
+		t.Fatalf("Expected no error, got %s", err)
+	}
+	if state.SettingsHash != "settings" || state.WordlistHash != "words" || len(state.Targets) != 1 {
+		t.Errorf("Unexpected scan details: %v", state)
+	}
+	urls, provs, err := state.QueuedURLs()
+	if err != nil || len(urls) != 2 {
+		t.Fatalf("Expected 2 queued URLs, got %v (%v)", urls, err)
+	}
+	if provs[1].Source != SourceLink || provs[1].Parent.String() != "http://localhost/" {
+		t.Errorf("Expected link provenance, got %v", provs[1])
+	}
+	if len(state.Results) != 1 {
+		t.Fatalf("Expected 1 result, got %d", len(state.Results))
+	}
+	if r, err := results.DecodeResult(state.Results[0]); err != nil || r.Code != 200 {
+		t.Errorf("Expected saved result, got %v (%v)", r, err)
+	}
+
+	tracker.Start()
+	tracker.Stop(true)
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("Expected state file to be removed when finished.")
+	}
+}
+
+func TestStateTracker_Restore(t *testing.T) {
+	tracker := NewStateTracker("", 0, "", "", nil)
+	tracker.Restore(&ScanState{
+		Offsets: map[string]int{"http://localhost/": 5},
+		Words:   map[string]string{"http://localhost/": "e"},
+		Done:    []string{"http://localhost/x"},
+	})
+	if offset := tracker.Offset(mustParse("http://localhost/")); offset != 5 {
+		t.Errorf("Expected offset 5, got %d", offset)
+	}
+	if word := tracker.OffsetWord(mustParse("http://localhost/")); word != "e" {
+		t.Errorf("Expected word e, got %s", word)
+	}
+	tracker.Expand(mustParse("http://localhost/"), 5, "f", mustParse("http://localhost/f"))
+	tracker.Done(mustParse("http://localhost/f"))
+	state := tracker.State()
+	if state.Offsets["http://localhost/"] != 6 {
+		t.Errorf("Expected offset 6, got %v", state.Offsets)
+	}
+	if strings.Join(state.Done, " ") != "http://localhost/x" {
+		t.Errorf("Expected restored done URLs kept, got %v", state.Done)
+	}
+	if state.Words["http://localhost/"] != "f" {
+		t.Errorf("Expected word f, got %v", state.Words)
+	}
+
+	tracker.Realign(mustParse("http://localhost/"), 2)
+	tracker.Expand(mustParse("http://localhost/"), 2, "c", mustParse("http://localhost/c"))
+	tracker.Done(mustParse("http://localhost/c"))
+	if offset := tracker.Offset(mustParse("http://localhost/")); offset != 3 {
+		t.Errorf("Expected realigned offset 3, got %d", offset)
+	}
+}
+
+func TestStateTracker_Nil(t *testing.T) {
+	var tracker *StateTracker
+	u := mustParse("http://localhost/")
+	tracker.Queued(u, Provenance{})
+	tracker.Expand(u, 0, "", u)
+	tracker.Done(u)
+	tracker.Restore(&ScanState{})
+	tracker.Start()
+	tracker.Stop(true)
+	if tracker.Offset(u) != 0 || tracker.Save() != nil {
+		t.Errorf("Expected nil tracker to do nothing.")
+	}
+}
+
+func TestLoadState_Invalid(t *testing.T) {
+	fp, err := ioutil.TempFile("", "gobuster-state")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fp.WriteString("{")
+	fp.Close()
+	defer os.Remove(fp.Name())
+	if _, err := LoadState(fp.Name()); err == nil {
+		t.Errorf("Expected error for invalid state file.")
+	}
+}
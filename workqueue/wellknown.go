@@ -0,0 +1,47 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package workqueue
+
+import (
+	"net/url"
+)
+
+// RFC 8615 resources worth checking on every target.
+var WellKnownPaths = []string{
+	"/.well-known/security.txt",
+	"/security.txt",
+	"/.well-known/openid-configuration",
+	"/.well-known/oauth-authorization-server",
+	"/.well-known/apple-app-site-association",
+	"/apple-app-site-association",
+	"/.well-known/assetlinks.json",
+	"/.well-known/change-password",
+}
+
+// Queue the well-known resources for the host of each scope.  As with
+// robots.txt, any outside of the scope are dropped by the filter.
+func (q *WorkQueue) SeedWellKnown(scope []*url.URL) {
+	seen := make(map[string]bool)
+	for _, scopeURL := range scope {
+		root := scopeURL.ResolveReference(&url.URL{Path: "/"})
+		if seen[root.String()] {
+			continue
+		}
+		seen[root.String()] = true
+		for _, p := range WellKnownPaths {
+			q.AddURLsFrom(Provenance{Source: SourceWellKnown, Parent: root}, root.ResolveReference(&url.URL{Path: p}))
+		}
+	}
+}
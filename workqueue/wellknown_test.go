@@ -0,0 +1,40 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package workqueue
+
+import (
+	"net/url"
+	"testing"
+)
+
+func TestSeedWellKnown(t *testing.T) {
+	scope := []*url.URL{
+		&url.URL{Scheme: "http", Host: "localhost", Path: "/"},
+		&url.URL{Scheme: "http", Host: "localhost", Path: "/app/"},
+	}
+	q := NewWorkQueue(2*len(WellKnownPaths), scope, false)
+	q.SeedWellKnown(scope)
+	close(q.src)
+	count := 0
+	for u := range q.src {
+		count++
+		if p := q.provenance.Lookup(u); p.Source != SourceWellKnown || p.Parent.String() != "http://localhost/" {
+			t.Errorf("Unexpected provenance for %s: %v", u, p)
+		}
+	}
+	if count != len(WellKnownPaths) {
+		t.Errorf("Expected %d URLs, got %d", len(WellKnownPaths), count)
+	}
+}
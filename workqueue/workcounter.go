@@ -19,7 +19,8 @@ import (
 	"sync"
 )
 
-// Count work to do and work done
+// Count work to do and work done.  The Cond must use the counter's own
+// Mutex, so waiters see the counts that were broadcast.
 type WorkCounter struct {
 	todo int64
 	done int64
@@ -45,12 +46,17 @@ func (ctr *WorkCounter) Done(done int64) {
 	if ctr.done == ctr.todo {
 		// Mark done
 		logging.Logf(logging.LogInfo, "Work counter thinks we're done.")
-		ctr.L.Lock()
-		defer ctr.L.Unlock()
 		ctr.Broadcast()
 	}
 }
 
+// Get the current done and todo counts.
+func (ctr *WorkCounter) Counts() (int64, int64) {
+	ctr.Lock()
+	defer ctr.Unlock()
+	return ctr.done, ctr.todo
+}
+
 func (ctr *WorkCounter) Stats() {
 	logging.Logf(logging.LogDebug, "WorkCounter: %d/%d", ctr.done, ctr.todo)
 }
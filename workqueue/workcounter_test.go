@@ -15,7 +15,6 @@
 package workqueue
 
 import (
-	"sync"
 	"testing"
 )
 
@@ -32,7 +31,7 @@ func TestWorkCounterAdd(t *testing.T) {
 
 func TestWorkCounterDone(t *testing.T) {
 	wc := WorkCounter{todo: 1}
-	wc.L = &sync.Mutex{}
+	wc.L = &wc.Mutex
 	wc.Done(1)
 	if wc.done != 1 {
 		t.Fatalf("Expecting 1 done, got %d", wc.done)
@@ -52,3 +51,10 @@ func TestWorkCounterDone(t *testing.T) {
 		t.Fatalf("Expected a panic, but it did not!")
 	}
 }
+
+func TestWorkCounterCounts(t *testing.T) {
+	wc := WorkCounter{todo: 3, done: 1}
+	if done, todo := wc.Counts(); done != 1 || todo != 3 {
+		t.Errorf("Expected 1/3, got %d/%d", done, todo)
+	}
+}
@@ -19,6 +19,7 @@ package workqueue
 import (
 	"github.com/Matir/gobuster/client"
 	"github.com/Matir/gobuster/logging"
+	"github.com/Matir/gobuster/results"
 	"github.com/Matir/gobuster/robots"
 	"github.com/Matir/gobuster/util"
 	"net/url"
@@ -27,24 +28,48 @@ import (
 
 // WorkQueue is a singleton that maintains the queue of work to be done.
 // It reads from one input channel, verifies that the URL is in scope,
-// queues it, then writes it to the work channel to be done.
+// queues it, then writes it to the work channel to be done.  Work is kept
+// in a sub-queue per host and handed out round-robin, so a large backlog
+// for one host, however its URLs were found, doesn't hold up the others.
 type WorkQueue struct {
-	// Elements to be worked on
-	head *queueNode
-	// End for cheap appends
-	tail *queueNode
+	// Sub-queues with work, by host
+	hosts map[string]*hostQueue
+	// Sub-queues in the order they are served
+	active []*hostQueue
+	// Index in active of the next sub-queue to serve
+	next int
 	// Number of items in queue, for stats
 	queueLen int
 	// Channel for URLs to be considered
 	src chan *url.URL
-	// Channel for URLs to be worked on
+	// Channel for URLs to be worked on, unbuffered so the host to serve is
+	// picked as late as possible
 	dst chan *url.URL
 	// filter to determine if a URL should be processed
 	filter func(*url.URL) bool
-	// channel to track done
+	// closed once the queue is running
 	started chan bool
 	// counter of work being done
 	ctr WorkCounter
+	// how URLs entered the queue
+	provenance *ProvenanceTracker
+	// hosts allowed outside of the scope
+	origins []string
+	// hooks of a program embedding the scanner, if any
+	hooks *results.Hooks
+	// progress for resuming, if saved
+	state *StateTracker
+	// closed when the scan is cancelled
+	cancelled  chan bool
+	cancelOnce sync.Once
+}
+
+type hostQueue struct {
+	host string
+	// Elements to be worked on
+	head *queueNode
+	// End for cheap appends
+	tail *queueNode
 }
 
 type queueNode struct {
@@ -60,22 +85,65 @@ type QueueDoneFunc func(int)
 
 func NewWorkQueue(queueSize int, scope []*url.URL, allowUpgrades bool) *WorkQueue {
 	q := &WorkQueue{
-		src:     make(chan *url.URL, queueSize),
-		dst:     make(chan *url.URL, queueSize),
-		filter:  makeScopeFunc(scope, allowUpgrades),
-		started: make(chan bool, 1),
+		src:        make(chan *url.URL, queueSize),
+		dst:        make(chan *url.URL),
+		hosts:      make(map[string]*hostQueue),
+		started:    make(chan bool, 1),
+		cancelled:  make(chan bool),
+		provenance: NewProvenanceTracker(),
+	}
+	inScope := makeScopeFunc(scope, allowUpgrades)
+	q.provenance.inScope = func(u *url.URL) bool {
+		return inScope(u) || matchOrigin(q.origins, u)
+	}
+	q.filter = func(u *url.URL) bool {
+		return !q.isCancelled() && q.provenance.inScope(u) && q.hooks.QueueAdd(u)
 	}
-	q.ctr.L = &sync.Mutex{}
+	q.ctr.L = &q.ctr.Mutex
 	return q
 }
 
 func (q *WorkQueue) AddURLs(urls ...*url.URL) {
 	q.ctr.Add(int64(len(urls)))
 	for _, u := range urls {
+		q.state.Queued(u, q.provenance.Lookup(u))
 		q.src <- u
 	}
 }
 
+// Allow URLs on hosts matching patterns even though they are outside the
+// scope.  Must be called before the queue is run.
+func (q *WorkQueue) AllowOrigins(patterns ...string) {
+	q.origins = append(q.origins, patterns...)
+}
+
+// Ask hooks before queueing each URL.  Must be called before the queue is
+// run.
+func (q *WorkQueue) SetHooks(hooks *results.Hooks) {
+	q.hooks = hooks
+}
+
+// Add URLs, recording how they were found.
+func (q *WorkQueue) AddURLsFrom(p Provenance, urls ...*url.URL) {
+	q.provenance.Record(p, urls...)
+	q.AddURLs(urls...)
+}
+
+// Stop handing out work.  What is queued, and anything added later, is
+// dropped, so WaitPipe returns once the work already handed out is done.
+func (q *WorkQueue) Cancel() {
+	q.cancelOnce.Do(func() { close(q.cancelled) })
+}
+
+func (q *WorkQueue) isCancelled() bool {
+	select {
+	case <-q.cancelled:
+		return true
+	default:
+		return false
+	}
+}
+
 func (q *WorkQueue) InputFinished() {
 	close(q.src)
 }
@@ -87,7 +155,7 @@ func (q *WorkQueue) GetWorkChan() <-chan *url.URL {
 func (q *WorkQueue) Run() {
 	defer close(q.dst)
 
-	q.started <- true
+	close(q.started)
 	keepGoing := true
 	for keepGoing {
 		keepGoing = q.runStep()
@@ -96,12 +164,15 @@ func (q *WorkQueue) Run() {
 
 // Run a single step of the queue, returning true if we should continue
 func (q *WorkQueue) runStep() bool {
-	if q.head != nil {
+	if q.isCancelled() {
+		q.dropQueued()
+	}
+	if q.queueLen > 0 {
 		// If we have work to send, non-blocking read
 		select {
 		case u, ok := <-q.src:
 			if !ok {
-				for q.head != nil {
+				for q.queueLen > 0 {
 					q.dst <- q.pop()
 				}
 				return false
@@ -113,6 +184,8 @@ func (q *WorkQueue) runStep() bool {
 			}
 		case q.dst <- q.peek():
 			q.pop()
+		case <-q.cancelled:
+			q.dropQueued()
 		}
 	} else {
 		// Blocking read and non-blocking send
@@ -137,14 +210,16 @@ func (q *WorkQueue) RunInBackground() {
 	go q.Run()
 }
 
+// Wait until all the work added so far is done.  More work may be added
+// afterwards and waited for again.
 func (q *WorkQueue) WaitPipe() {
 	<-q.started
-	q.ctr.L.Lock()
-	if q.ctr.todo == q.ctr.done {
-		q.ctr.L.Unlock()
-		return
+	q.ctr.Lock()
+	defer q.ctr.Unlock()
+	// Read directly rather than with Counts, as the lock is already held
+	for q.ctr.todo != q.ctr.done {
+		q.ctr.Wait()
 	}
-	q.ctr.Wait()
 }
 
 func (q *WorkQueue) GetAddFunc() QueueAddFunc {
@@ -165,17 +240,32 @@ func (q *WorkQueue) GetDoneFunc() QueueDoneFunc {
 	}
 }
 
+func (q *WorkQueue) Provenance() *ProvenanceTracker {
+	return q.provenance
+}
+
+// Record queued URLs for resuming.  Must be called before URLs are added.
+func (q *WorkQueue) SetStateTracker(t *StateTracker) {
+	q.state = t
+}
+
+// Get the amount of work done and known about.
+func (q *WorkQueue) Counts() (int64, int64) {
+	return q.ctr.Counts()
+}
+
 func (q *WorkQueue) SeedFromRobots(scope []*url.URL, clientFactory client.ClientFactory) {
 	for _, scopeURL := range scope {
 		robotsData, err := robots.GetRobotsForURL(scopeURL, clientFactory)
 		if err != nil {
 			logging.Logf(logging.LogWarning, "Unable to get robots.txt data: %s", err)
 		} else {
+			robotsURL := scopeURL.ResolveReference(&url.URL{Path: "/robots.txt"})
 			for _, path := range robotsData.GetAllPaths() {
 				pathURL := *scopeURL
 				pathURL.Path = path
 				// Filter will handle if this is out of scope
-				q.AddURLs(scopeURL.ResolveReference(&pathURL))
+				q.AddURLsFrom(Provenance{Source: SourceRobots, Parent: robotsURL}, scopeURL.ResolveReference(&pathURL))
 			}
 		}
 	}
@@ -183,41 +273,89 @@ func (q *WorkQueue) SeedFromRobots(scope []*url.URL, clientFactory client.Client
 
 func (q *WorkQueue) reject(u *url.URL) {
 	logging.Logf(logging.LogDebug, "Workqueue rejecting %s", u.String())
+	q.provenance.Done(u)
 	q.ctr.Done(1)
 }
 
-// Append URL to end of queue
+// Reject everything queued
+func (q *WorkQueue) dropQueued() {
+	for q.queueLen > 0 {
+		q.reject(q.pop())
+	}
+}
+
+// Append URL to end of its host's queue
 func (q *WorkQueue) push(u *url.URL) {
-	node := &queueNode{data: u}
-	if q.tail != nil {
-		q.tail.next = node
-	} else {
-		q.head = node
+	hq, ok := q.hosts[u.Host]
+	if !ok {
+		hq = &hostQueue{host: u.Host}
+		q.hosts[u.Host] = hq
+		q.active = append(q.active, hq)
 	}
-	q.tail = node
+	hq.push(u)
 	q.queueLen++
 }
 
-// Get URL from front of queue
+// Get URL from front of the next host's queue
 func (q *WorkQueue) pop() *url.URL {
-	node := q.head
+	if len(q.active) == 0 {
+		return nil
+	}
+	hq := q.active[q.next]
+	u := hq.pop()
+	q.queueLen--
+	if hq.head == nil {
+		delete(q.hosts, hq.host)
+		q.active = append(q.active[:q.next], q.active[q.next+1:]...)
+	} else {
+		q.next++
+	}
+	if q.next >= len(q.active) {
+		q.next = 0
+	}
+	return u
+}
+
+// Get URL that pop would return without removal
+func (q *WorkQueue) peek() *url.URL {
+	if len(q.active) == 0 {
+		return nil
+	}
+	return q.active[q.next].head.data
+}
+
+func (hq *hostQueue) push(u *url.URL) {
+	node := &queueNode{data: u}
+	if hq.tail != nil {
+		hq.tail.next = node
+	} else {
+		hq.head = node
+	}
+	hq.tail = node
+}
+
+func (hq *hostQueue) pop() *url.URL {
+	node := hq.head
 	if node == nil {
 		return nil
 	}
-	q.head = q.head.next
-	if q.head == nil {
-		q.tail = nil
+	hq.head = hq.head.next
+	if hq.head == nil {
+		hq.tail = nil
 	}
-	q.queueLen--
 	return node.data
 }
 
-// Get URL from front of queue without removal
-func (q *WorkQueue) peek() *url.URL {
-	if q.head != nil {
-		return q.head.data
+// Find the scope URL that u belongs to, preferring the most specific, or nil
+// if it is out of scope.
+func TargetOf(scope []*url.URL, u *url.URL) *url.URL {
+	var target *url.URL
+	for _, scopeURL := range scope {
+		if util.URLIsSubpath(scopeURL, u) && (target == nil || len(scopeURL.Path) > len(target.Path)) {
+			target = scopeURL
+		}
 	}
-	return nil
+	return target
 }
 
 // Build a function to check if the target URL is in scope.
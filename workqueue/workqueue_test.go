@@ -16,8 +16,10 @@ package workqueue
 
 import (
 	"fmt"
+	"github.com/Matir/gobuster/results"
 	"net/url"
 	"strconv"
+	"sync"
 	"testing"
 )
 
@@ -73,6 +75,28 @@ func TestWorkqueue_Reject(t *testing.T) {
 	}
 }
 
+func TestWorkqueue_Hooks(t *testing.T) {
+	scope := []*url.URL{{Scheme: "http", Host: "localhost", Path: "/"}}
+	queue := NewWorkQueue(5, scope, false)
+	queue.SetHooks(&results.Hooks{OnQueueAdd: func(u *url.URL) bool {
+		return u.Path != "/logout"
+	}})
+	queue.RunInBackground()
+	for _, p := range []string{"/a", "/logout", "/b"} {
+		queue.AddURLs(&url.URL{Scheme: "http", Host: "localhost", Path: p})
+	}
+	queue.InputFinished()
+	var got []string
+	for u := range queue.GetWorkChan() {
+		got = append(got, u.Path)
+		queue.GetDoneFunc()(1)
+	}
+	queue.WaitPipe()
+	if len(got) != 2 || got[0] != "/a" || got[1] != "/b" {
+		t.Errorf("Expected /a and /b, got %v", got)
+	}
+}
+
 func TestWorkqueue_PartialReject(t *testing.T) {
 	rounds := 20
 	filter := func(u *url.URL) bool {
@@ -141,3 +165,124 @@ func TestMakeScopeFunc(t *testing.T) {
 		}
 	}
 }
+
+func TestTargetOf(t *testing.T) {
+	urlParse := func(s string) *url.URL {
+		u, _ := url.Parse(s)
+		return u
+	}
+	scope := []*url.URL{urlParse("http://a/"), urlParse("http://a/app/"), urlParse("http://b/")}
+	for u, expected := range map[string]string{
+		"http://a/index":       "http://a/",
+		"http://a/app/login":   "http://a/app/",
+		"http://b/":            "http://b/",
+		"http://c/":            "",
+		"https://a/app/secure": "",
+	} {
+		target := TargetOf(scope, urlParse(u))
+		if expected == "" && target != nil || expected != "" && (target == nil || target.String() != expected) {
+			t.Errorf("Expected target %q for %s, got %v", expected, u, target)
+		}
+	}
+}
+
+func TestWorkqueue_Cancel(t *testing.T) {
+	queue := NewWorkQueue(5, nil, false)
+	queue.RunInBackground()
+	for i := 0; i < 20; i++ {
+		queue.AddURLs(&url.URL{Path: fmt.Sprintf("%d", i)})
+	}
+	queue.Cancel()
+	queue.Cancel()
+	var mu sync.Mutex
+	handed := 0
+	go func() {
+		for range queue.GetWorkChan() {
+			mu.Lock()
+			handed++
+			mu.Unlock()
+			queue.GetDoneFunc()(1)
+		}
+	}()
+	queue.WaitPipe()
+	queue.AddURLs(&url.URL{Path: "later"})
+	queue.WaitPipe()
+	mu.Lock()
+	defer mu.Unlock()
+	if handed > 5 {
+		t.Errorf("Expected at most the buffered URLs handed out after cancelling, got %d", handed)
+	}
+	if done, todo := queue.Counts(); done != todo || todo != 21 {
+		t.Errorf("Expected all work settled, got %d of %d", done, todo)
+	}
+	queue.InputFinished()
+}
+
+func TestWorkqueue_WaitPipeAgain(t *testing.T) {
+	queue := NewWorkQueue(5, nil, false)
+	queue.RunInBackground()
+	out := queue.GetWorkChan()
+	go func() {
+		for range out {
+			queue.GetDoneFunc()(1)
+		}
+	}()
+	for round := 0; round < 3; round++ {
+		for i := 0; i < 10; i++ {
+			queue.AddURLs(&url.URL{Path: fmt.Sprintf("%d/%d", round, i)})
+		}
+		queue.WaitPipe()
+		if done, todo := queue.Counts(); done != todo || todo != int64(10*(round+1)) {
+			t.Errorf("Expected round %d done, got %d of %d", round, done, todo)
+		}
+	}
+	queue.InputFinished()
+}
+
+func TestWorkqueue_RoundRobin(t *testing.T) {
+	queue := NewWorkQueue(5, nil, false)
+	for i := 0; i < 3; i++ {
+		queue.push(&url.URL{Host: "slow", Path: strconv.Itoa(i)})
+	}
+	queue.push(&url.URL{Host: "fast", Path: "0"})
+	queue.push(&url.URL{Host: "fast", Path: "1"})
+	expected := []string{"slow/0", "fast/0", "slow/1", "fast/1", "slow/2"}
+	for _, e := range expected {
+		if p := queue.peek(); p.Host+"/"+p.Path != e {
+			t.Errorf("Expected peek of %s, got %s/%s", e, p.Host, p.Path)
+		}
+		if u := queue.pop(); u.Host+"/"+u.Path != e {
+			t.Errorf("Expected %s, got %s/%s", e, u.Host, u.Path)
+		}
+	}
+	if queue.pop() != nil || queue.queueLen != 0 || len(queue.hosts) != 0 {
+		t.Error("Expected empty queue.")
+	}
+}
+
+func TestWorkqueue_SlowHost(t *testing.T) {
+	slow := &url.URL{Scheme: "http", Host: "slow", Path: "/"}
+	fast := &url.URL{Scheme: "http", Host: "fast", Path: "/"}
+	queue := NewWorkQueue(100, []*url.URL{slow, fast}, false)
+	// A backlog for the slow host, then links found on the fast one
+	for i := 0; i < 50; i++ {
+		queue.AddURLs(slow.ResolveReference(&url.URL{Path: strconv.Itoa(i)}))
+	}
+	queue.AddURLsFrom(Provenance{Source: SourceLink, Parent: fast}, fast.ResolveReference(&url.URL{Path: "a"}), fast.ResolveReference(&url.URL{Path: "b"}))
+	queue.InputFinished()
+	// Queue everything before anything is taken, as a busy pool would
+	for i := 0; i < 52; i++ {
+		queue.runStep()
+	}
+	queue.RunInBackground()
+	var order []string
+	for u := range queue.GetWorkChan() {
+		order = append(order, u.Host)
+	}
+	if len(order) != 52 {
+		t.Fatalf("Expected 52 URLs, got %d", len(order))
+	}
+	if order[1] != "fast" || order[3] != "fast" {
+		t.Errorf("Expected the fast host served alongside the slow one, got %v", order[:4])
+	}
+}